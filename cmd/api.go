@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdnet "net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/api"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// defaultAPIAddr binds the daemon API to a random loopback port
+const defaultAPIAddr = "127.0.0.1:0"
+
+// startBackend serves the state of a running watch mode through the API
+type startBackend struct {
+	cmd           *StartCmd
+	projectConfig *config.ProjectConfig
+}
+
+// State returns the state recorded by the last run
+func (b *startBackend) State() api.State {
+	return b.cmd.currentState()
+}
+
+// Refresh regenerates the env file and returns the new state
+func (b *startBackend) Refresh() (api.State, error) {
+	if err := b.cmd.regenerate(b.projectConfig, runReasonAPI); err != nil {
+		return api.State{}, err
+	}
+	return b.cmd.currentState(), nil
+}
+
+// newAPIState builds the API state from the result of a run
+// The variables resolved from secret references are left out: GET /v1/state needs no token
+// and the URLs are pushed to the paired devices, which only get the http(s) and ws(s) ones
+// without credentials.
+func newAPIState(profile string, projectConfig *config.ProjectConfig, netInfo *net.NetworkInfo, vars []env.EnvVar) api.State {
+	project, _ := filepath.Abs(projectConfig.Dir())
+	state := api.State{
+		Project:   project,
+		Profile:   profile,
+		IP:        netInfo.IP,
+		Interface: netInfo.Interface,
+		Hostname:  projectConfig.Hostname,
+		Output:    projectConfig.OutputPath(),
+		Vars:      make(map[string]string, len(vars)),
+		URLs:      make(map[string]string),
+		UpdatedAt: time.Now(),
+	}
+
+	for _, v := range withoutSecrets(projectConfig, vars) {
+		state.Vars[v.Key] = v.Value
+		if value, ok := env.PublicURL(v.Value); ok {
+			state.URLs[v.Key] = value
+		}
+	}
+
+	return state
+}
+
+// startAPIServer serves the API on addr and records its URL in the project run directory
+// The returned function shuts the server down and removes the address file.
+func startAPIServer(addr string, backend api.Backend) (string, func(), error) {
+	// Mutating endpoints require the token, the API may listen on a LAN address
+	token, err := apiToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	listener, err := stdnet.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	apiURL := "http://" + listener.Addr().String()
+	addrPath, err := process.APIAddrFile(projectDir())
+	if err != nil {
+		listener.Close()
+		return "", nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(addrPath), 0755); err == nil {
+		_ = os.WriteFile(addrPath, []byte(apiURL+"\n"), 0644)
+	}
+
+	server := &http.Server{
+		Handler:           api.RequireToken(api.NewHandler(backend), token),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(utils.Stderr, "%sWarning: API server stopped: %v\n", utils.Emoji("⚠️  ", ""), err)
+		}
+	}()
+
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		_ = os.Remove(addrPath)
+	}
+
+	return apiURL, shutdown, nil
+}
+
+// apiToken returns the token of the daemon API, created on first use
+func apiToken() (string, error) {
+	path, err := api.DefaultTokenPath()
+	if err != nil {
+		return "", err
+	}
+	return api.LoadOrCreateToken(path)
+}
+
+// readAPIURL returns the API URL of the watcher of the current project, if it serves one
+func readAPIURL() string {
+	addrPath, err := process.APIAddrFile(projectDir())
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(addrPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/devserver"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// BindCmd represents the bind command
+type BindCmd struct {
+	Write bool
+}
+
+// NewBindCmd creates a new bind command
+func NewBindCmd() *cobra.Command {
+	bindCmd := &BindCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "bind",
+		Short: "Make the dev servers of the project listen on all interfaces",
+		Long: `Dev servers such as Vite or Next.js only listen on localhost by default, so the
+URLs lanup generates cannot be reached from other devices.
+
+bind reads package.json and shows the changes that make the dev servers of the project
+listen on 0.0.0.0: the --host or -H flags to add to the scripts starting them, and the
+variables read by the frameworks configured through the environment (HOST for Create
+React App and Nuxt). With --write, the scripts are updated in place. Set host_vars in
+.lanup.yaml to have lanup start write the variables to the env file.
+
+Examples:
+  lanup bind
+  lanup bind --write`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bindCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().BoolVar(&bindCmd.Write, "write", false, "update the scripts of package.json")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewBindCmd())
+}
+
+// Run executes the bind command
+func (c *BindCmd) Run() error {
+	binding, err := devserver.PlanBinding(".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			"No package.json in the current directory", err)
+	}
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to read package.json", err)
+	}
+
+	if len(binding.Patches) == 0 && len(binding.Vars) == 0 && len(binding.Advice) == 0 {
+		utils.Success("The dev server scripts already listen on all interfaces")
+		return nil
+	}
+
+	for _, p := range binding.Patches {
+		utils.Info("Script %s (%s):", p.Script, p.Framework)
+		utils.Printf("   - %s\n   + %s\n", p.Before, p.After)
+	}
+	if len(binding.Patches) > 0 {
+		if c.Write {
+			if err := devserver.ApplyPatches(".", binding.Patches); err != nil {
+				return lanuperrors.FromOSError("Failed to update package.json", err)
+			}
+			utils.Success("Updated %d script(s) in package.json", len(binding.Patches))
+		} else {
+			utils.Println("   Run 'lanup bind --write' to update package.json")
+		}
+	}
+
+	if len(binding.Vars) > 0 {
+		keys := make([]string, 0, len(binding.Vars))
+		for key := range binding.Vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if projectConfig, err := config.LoadProjectConfig(""); err == nil && projectConfig.HostVars {
+			utils.Info("lanup start writes %v to the env file (host_vars)", keys)
+		} else {
+			for _, key := range keys {
+				utils.Info("Set %s=%s in the environment of the dev server", key, binding.Vars[key])
+			}
+			utils.Println("   Or set host_vars: true in .lanup.yaml to have lanup start write it")
+		}
+	}
+
+	for _, advice := range binding.Advice {
+		utils.Info("%s", advice)
+	}
+	return nil
+}
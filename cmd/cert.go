@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/cert"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// CertCmd represents the cert command
+type CertCmd struct {
+	Hosts []string
+}
+
+// NewCertCmd creates a new cert command
+func NewCertCmd() *cobra.Command {
+	certCmd := &CertCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "cert [HOST...]",
+		Short: "Issue a development certificate valid on the LAN",
+		Long: `Issue a certificate covering your LAN IP, the .local name of this machine, localhost,
+the hostname of .lanup.yaml and the given hosts, so that HTTPS dev servers are trusted
+from other devices once they trust its CA.
+
+The certificate is issued by mkcert when installed, whose CA is already trusted on this
+machine, otherwise by a local CA lanup creates in ~/.lanup/certs. It is kept until your IP
+or the hosts change or it is about to expire. Set cert_var and key_var in the cert section
+of .lanup.yaml to have lanup start issue it and write its paths to the env file.
+
+Examples:
+  lanup cert
+  lanup cert myapp.lan api.myapp.lan`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			certCmd.Hosts = args
+			return certCmd.Run()
+		},
+	}
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewCertCmd())
+}
+
+// Run executes the cert command
+func (c *CertCmd) Run() error {
+	// The project configuration is optional, it only adds hosts
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		projectConfig = &config.ProjectConfig{}
+	}
+	projectConfig.Cert.Hosts = append(projectConfig.Cert.Hosts, c.Hosts...)
+	if err := projectConfig.Cert.Validate(); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid certificate hosts", err)
+	}
+
+	netInfo, err := net.DetectLocalIP()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+
+	bundle, err := projectCertificate(projectConfig, netInfo.IP, true)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to create certificate", err)
+	}
+
+	utils.Success("Certificate valid for %s", strings.Join(bundle.Hosts, ", "))
+	displayCertificateHint(bundle)
+	utils.Info("Private key: %s", bundle.KeyFile)
+	if !projectConfig.Cert.Enabled() {
+		utils.Println("   Set cert.cert_var and cert.key_var in .lanup.yaml to write these paths to the env file")
+	}
+	return nil
+}
+
+// projectCertificate returns the certificate of the project for the LAN IP ip, issuing it when issue is set
+func projectCertificate(projectConfig *config.ProjectConfig, ip string, issue bool) (*cert.Bundle, error) {
+	dir, err := cert.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := cert.DefaultHosts(ip)
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		seen[host] = true
+	}
+	extra := append([]string{projectConfig.Hostname}, projectConfig.Cert.Hosts...)
+	for _, host := range extra {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	if !issue {
+		return cert.Locate(dir, hosts), nil
+	}
+	return cert.Ensure(dir, hosts)
+}
+
+// withCertificateVars adds the certificate variables of the project to vars, sorted by key
+// The certificate is only issued when issue is set, e.g. not for dry runs.
+func withCertificateVars(vars []env.EnvVar, projectConfig *config.ProjectConfig, ip string, issue bool) ([]env.EnvVar, error) {
+	if !projectConfig.Cert.Enabled() {
+		return vars, nil
+	}
+	bundle, err := projectCertificate(projectConfig, ip, issue)
+	if err != nil {
+		return vars, err
+	}
+
+	for key, value := range projectConfig.Cert.Vars(bundle.CertFile, bundle.KeyFile, bundle.CAFile) {
+		vars = append(vars, env.EnvVar{Key: key, Value: value, Managed: true})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Key < vars[j].Key
+	})
+	return vars, nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// logModules are the modules lanup logs with, --module also matches their submodules (e.g. detector.docker)
+var logModules = []string{
+	"api", "daemon", "detector", "devices", "env", "expo", "files", "history", "hooks", "hosts",
+	"net", "run", "serve", "start", "state", "stop", "telemetry", "templates", "watcher",
+}
+
+// completeProfiles completes --profile with the profiles of the project configuration
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := projectConfig.ProfileNames()
+	if _, ok := projectConfig.Profiles[config.DefaultProfile]; !ok {
+		names = append([]string{config.DefaultProfile}, names...)
+	}
+	return completions(names, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLogModules completes logs --module with the modules of lanup
+func completeLogModules(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completions(logModules, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeServiceNames completes the NAME arguments of qr and verify with the URL variables of the
+// project: the configured ones and those of the env file, which include the detected services
+// Detectors are not run, as they may be too slow for completion.
+func completeServiceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, vars := range []map[string]string{projectConfig.Vars, projectConfig.StaticVars} {
+		for key, value := range vars {
+			if strings.Contains(value, "://") {
+				names = append(names, projectConfig.PrefixKey(key))
+			}
+		}
+	}
+
+	// Remote env files would need an SSH connection
+	if !remote.IsRemote(projectConfig.OutputPath()) {
+		if envWriter, err := newEnvWriter(projectConfig); err == nil {
+			existing, _ := envWriter.Read()
+			for _, v := range existing {
+				if strings.Contains(v.Value, "://") {
+					names = append(names, v.Key)
+				}
+			}
+		}
+	}
+
+	return completions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completions returns the sorted, unique candidates starting with toComplete, except those in used
+func completions(candidates, used []string, toComplete string) []string {
+	skip := make(map[string]bool, len(used))
+	for _, u := range used {
+		skip[strings.ToUpper(u)] = true
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c] || skip[strings.ToUpper(c)] || !strings.HasPrefix(strings.ToUpper(c), strings.ToUpper(toComplete)) {
+			continue
+		}
+		seen[c] = true
+		result = append(result, c)
+	}
+	sort.Strings(result)
+	return result
+}
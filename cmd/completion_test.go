@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletions(t *testing.T) {
+	candidates := []string{"WEB_URL", "API_URL", "api_key", "API_URL"}
+	assert.Equal(t, []string{"API_URL", "WEB_URL", "api_key"}, completions(candidates, nil, ""))
+	assert.Equal(t, []string{"API_URL", "api_key"}, completions(candidates, nil, "ap"))
+	assert.Equal(t, []string{"api_key"}, completions(candidates, []string{"api_url"}, "A"))
+	assert.Empty(t, completions(candidates, nil, "DB"))
+}
+
+func TestCompleteFromProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// Outside of a project there is nothing to complete
+	names, directive := completeProfiles(nil, nil, "")
+	assert.Empty(t, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	testConfig := &config.ProjectConfig{
+		Vars:       map[string]string{"API_URL": "http://localhost:8000", "DEBUG": "true"},
+		StaticVars: map[string]string{"DOCS_URL": "https://docs.example.com"},
+		Output:     ".env.local",
+		Profiles: map[string]config.ProfileConfig{
+			"mobile": {Output: ".env.mobile"},
+			"tablet": {Output: ".env.tablet"},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	names, _ = completeProfiles(nil, nil, "")
+	assert.Equal(t, []string{"default", "mobile", "tablet"}, names)
+	names, _ = completeProfiles(nil, nil, "mo")
+	assert.Equal(t, []string{"mobile"}, names)
+
+	// Detected services are found in the env file
+	require.NoError(t, os.WriteFile(".env.local", []byte("# lanup:managed\nDOCKER_WEB_URL=http://192.168.1.10:3000\nSECRET=x\n"), 0644))
+	names, _ = completeServiceNames(nil, nil, "")
+	assert.Equal(t, []string{"API_URL", "DOCKER_WEB_URL", "DOCS_URL"}, names)
+	names, _ = completeServiceNames(nil, []string{"API_URL"}, "D")
+	assert.Equal(t, []string{"DOCKER_WEB_URL", "DOCS_URL"}, names)
+
+	names, _ = completeLogModules(nil, nil, "d")
+	assert.Equal(t, []string{"daemon", "detector", "devices"}, names)
+}
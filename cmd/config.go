@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd represents the config command
+type ConfigCmd struct{}
+
+// NewConfigCmd creates a new config command
+func NewConfigCmd() *cobra.Command {
+	configCmd := &ConfigCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View or change global settings",
+		Long: `View or change the global settings stored in ~/.lanup/config.yaml.
+
+Values are validated before being saved. 'lanup config edit' opens the file in $VISUAL
+or $EDITOR and only saves it when the result is valid.
+
+Examples:
+  lanup config list
+  lanup config get log_level
+  lanup config set check_interval 10
+  lanup config edit`,
+		// The config command must keep working when config.yaml is invalid, so that it can be fixed
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:       "get KEY",
+		Short:     "Print the value of a setting",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: config.GlobalConfigKeys(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configCmd.Get(args[0])
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Change the value of a setting",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configCmd.Set(args[0], args[1])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all settings",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configCmd.List()
+		},
+	}
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the global configuration in your editor",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configCmd.Edit()
+		},
+	}
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the project configuration",
+		Long: `Print the JSON Schema of .lanup.yaml, .lanup.toml and .lanup.json, for editors and schema stores.
+
+The schema is also published at ` + config.SchemaURL + `, which
+'lanup init --format json' references with the $schema key.
+
+Examples:
+  lanup config schema > lanup.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configCmd.Schema()
+		},
+	}
+
+	cmd.AddCommand(getCmd, setCmd, listCmd, editCmd, schemaCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewConfigCmd())
+}
+
+// Get prints the value of a setting
+func (c *ConfigCmd) Get(key string) error {
+	cfg, err := loadGlobalConfigForEdit()
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(key)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid key", err)
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+// Schema prints the JSON Schema of the project configuration
+func (c *ConfigCmd) Schema() error {
+	schema, err := config.ProjectConfigJSONSchema()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to generate the schema", err)
+	}
+	os.Stdout.Write(schema)
+	return nil
+}
+
+// Set validates and saves a new value for a setting
+func (c *ConfigCmd) Set(key, value string) error {
+	cfg, err := loadGlobalConfigForEdit()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Set(key, value); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid value for %s", key), err)
+	}
+
+	if err := config.SaveGlobalConfig(cfg); err != nil {
+		return lanuperrors.FromOSError("Failed to save global configuration", err)
+	}
+
+	utils.Success("Set %s = %s", key, value)
+	return nil
+}
+
+// List prints every setting with its value
+func (c *ConfigCmd) List() error {
+	cfg, err := loadGlobalConfigForEdit()
+	if err != nil {
+		return err
+	}
+
+	if path, err := config.GlobalConfigPath(); err == nil {
+		utils.Info("Configuration file: %s", path)
+	}
+
+	for _, key := range config.GlobalConfigKeys() {
+		value, _ := cfg.Get(key)
+		fmt.Printf("  %s = %s\n", color.CyanString(key), value)
+	}
+
+	return nil
+}
+
+// Edit opens a copy of the configuration in the user's editor and saves it when valid
+func (c *ConfigCmd) Edit() error {
+	path, err := config.GlobalConfigPath()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to locate global configuration", err)
+	}
+
+	// Create the file with defaults on first use
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := config.SaveGlobalConfig(config.GetDefaultGlobalConfig()); err != nil {
+			return lanuperrors.FromOSError("Failed to create global configuration", err)
+		}
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read global configuration", err)
+	}
+
+	// Edit a temporary copy so that an invalid result never replaces the configuration
+	tmp, err := os.CreateTemp("", "lanup-config-*.yaml")
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to create temporary file", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return lanuperrors.FromOSError("Failed to write temporary file", err)
+	}
+	tmp.Close()
+
+	if err := runEditor(tmp.Name()); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to run editor", err)
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read edited configuration", err)
+	}
+
+	if string(edited) == string(original) {
+		utils.Info("No changes made")
+		return nil
+	}
+
+	if _, err := config.ParseGlobalConfig(edited); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Edited configuration is invalid, changes were not saved", err)
+	}
+
+	if err := os.WriteFile(path, edited, 0600); err != nil {
+		return lanuperrors.FromOSError("Failed to save global configuration", err)
+	}
+
+	utils.Success("Global configuration saved: %s", path)
+	return nil
+}
+
+// loadGlobalConfigForEdit loads the global configuration, pointing to 'lanup config edit' when it is invalid
+func loadGlobalConfigForEdit() (*config.GlobalConfig, error) {
+	cfg, err := config.LoadGlobalConfig()
+	if errors.Is(err, config.ErrGlobalConfigNotFound) {
+		// 'config set' creates the file selected with --config, starting from the defaults
+		return config.GetDefaultGlobalConfig(), nil
+	}
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load global configuration (run 'lanup config edit' to fix it)", err)
+	}
+	return cfg, nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR and waits for the editor to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
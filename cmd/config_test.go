@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCmd_Set(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd := &ConfigCmd{}
+
+	require.NoError(t, cmd.Set("log_level", "debug"))
+	cfg, err := config.LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.LogLevel)
+
+	assert.Error(t, cmd.Set("log_level", "loud"))
+	assert.Error(t, cmd.Set("unknown", "1"))
+	assert.NoError(t, cmd.Get("log_level"))
+	assert.NoError(t, cmd.List())
+}
+
+func TestConfigCmd_Edit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as editor")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VISUAL", "")
+	path := filepath.Join(home, ".lanup", "config.yaml")
+
+	// The "editor" is a script changing the check interval to its first argument
+	editor := filepath.Join(home, "editor.sh")
+	script := "#!/bin/sh\nsed -i \"s/check_interval:.*/check_interval: $1/\" \"$2\"\n"
+	require.NoError(t, os.WriteFile(editor, []byte(script), 0755))
+
+	t.Setenv("EDITOR", editor+" 30")
+	cmd := &ConfigCmd{}
+	require.NoError(t, cmd.Edit())
+
+	cfg, err := config.LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 30, cfg.CheckInterval)
+
+	// An invalid result is not saved
+	t.Setenv("EDITOR", editor+" 0")
+	assert.Error(t, cmd.Edit())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "check_interval: 30")
+}
+
+func TestConfigCmd_Set_CustomPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.EnvGlobalConfig, "")
+	t.Cleanup(func() { config.SetGlobalConfigPath("") })
+
+	path := filepath.Join(t.TempDir(), "lanup.yaml")
+	config.SetGlobalConfigPath(path)
+
+	// The selected file doesn't exist yet: 'config set' creates it from the defaults
+	require.NoError(t, (&ConfigCmd{}).Set("check_interval", "15"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "check_interval: 15")
+}
@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/service"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// daemonStartTimeout is how long 'daemon start' waits for the daemon to register its PID file
+const daemonStartTimeout = 5 * time.Second
+
+// DaemonCmd represents the daemon command
+type DaemonCmd struct {
+	Profile  string
+	APIAddr  string
+	All      bool          // the shared daemon of the projects registered with 'daemon add'
+	Watchdog time.Duration // exit when watch mode hangs for this long, for launchd to restart it
+	Dir      string        // project directory, for Windows services that start in the system directory
+}
+
+// NewDaemonCmd creates a new daemon command
+func NewDaemonCmd() *cobra.Command {
+	daemonCmd := &DaemonCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run watch mode in the background",
+		Long: `Run 'lanup start --watch' for the current project as a background process, so the env
+file keeps tracking network changes without keeping a terminal open.
+
+Each project has its own daemon. Its output is written to ~/.lanup/logs/daemon.log and
+it can also be stopped with 'lanup stop', which additionally reverts the env file.
+Sending SIGHUP to the daemon regenerates the env file immediately.
+
+A single shared daemon can also keep several projects in sync: register them with
+'lanup daemon add' and manage it with --all. On IP changes it regenerates the env file of
+every registered project, except those with their own watch mode or daemon running.
+
+The daemon serves a JSON API on a random loopback port (see 'lanup daemon status'):
+  GET  /v1/state    current IP, variables and URLs
+  POST /v1/refresh  regenerate the env file now
+  POST /v1/pairing  create a code pairing a test device, see 'lanup pair'
+  POST /v1/pairing/disconnect  close the event streams of the devices unpaired by 'lanup pair remove'
+
+The POST endpoints require "Authorization: Bearer <token>", with the token created in
+~/.lanup/api-token (readable by you only), since --api-addr may expose the API on the LAN.
+
+Examples:
+  lanup daemon start
+  lanup daemon start --profile mobile
+  lanup daemon status
+  lanup daemon stop
+  lanup daemon add ~/code/api ~/code/mobile
+  lanup daemon start --all`,
+	}
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon for the current project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.Start()
+		},
+	}
+	startCmd.Flags().StringVar(&daemonCmd.Profile, "profile", "", "configuration profile to use")
+	_ = startCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	startCmd.Flags().StringVar(&daemonCmd.APIAddr, "api-addr", defaultAPIAddr, "address of the JSON API, empty to disable")
+	startCmd.Flags().BoolVar(&daemonCmd.All, "all", false, "start the shared daemon of the registered projects")
+
+	stopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon of the current project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.Stop()
+		},
+	}
+	stopCmd.Flags().BoolVar(&daemonCmd.All, "all", false, "stop the shared daemon of the registered projects")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon of the current project is running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.Status()
+		},
+	}
+	statusCmd.Flags().BoolVar(&daemonCmd.All, "all", false, "show the shared daemon and its registered projects")
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the daemon in the foreground (used by 'daemon start' and system services)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.RunForeground()
+		},
+	}
+	runCmd.Flags().StringVar(&daemonCmd.Profile, "profile", "", "configuration profile to use")
+	_ = runCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	runCmd.Flags().StringVar(&daemonCmd.APIAddr, "api-addr", defaultAPIAddr, "address of the JSON API, empty to disable")
+	runCmd.Flags().BoolVar(&daemonCmd.All, "all", false, "run the shared daemon of the registered projects")
+	runCmd.Flags().StringVar(&daemonCmd.Dir, "dir", "", "project directory, instead of the current directory")
+	runCmd.Flags().DurationVar(&daemonCmd.Watchdog, "watchdog", 0, "exit when the watcher hangs for this long, so that the service manager restarts it")
+
+	addCmd := &cobra.Command{
+		Use:   "add [directory...]",
+		Short: "Register projects with the shared daemon (default: the current directory)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.Add(args)
+		},
+	}
+	addCmd.Flags().StringVar(&daemonCmd.Profile, "profile", "", "configuration profile to use for the projects")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove [directory...]",
+		Short: "Unregister projects from the shared daemon (default: the current directory)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonCmd.Remove(args)
+		},
+	}
+
+	cmd.AddCommand(startCmd, stopCmd, statusCmd, runCmd, addCmd, removeCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewDaemonCmd())
+}
+
+// Start launches 'lanup daemon run' as a detached process
+func (c *DaemonCmd) Start() error {
+	pidPath, err := c.pidFile()
+	if err != nil {
+		return err
+	}
+	if pid, running := process.RunningPID(pidPath); running {
+		if c.All {
+			return lanuperrors.NewError(lanuperrors.ErrProcess,
+				fmt.Sprintf("The shared daemon is already running (PID %d)", pid), nil)
+		}
+		return lanuperrors.NewError(lanuperrors.ErrProcess,
+			fmt.Sprintf("lanup is already watching this project (PID %d)", pid), nil)
+	}
+
+	// Validate the configuration now rather than in a background process
+	if c.All {
+		projects, err := daemonProjects()
+		if err != nil {
+			return err
+		}
+		if len(projects) == 0 {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				"No projects registered with the shared daemon, run 'lanup daemon add' first", nil)
+		}
+	} else if _, err := config.LoadProjectConfigProfile("", c.Profile); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to locate the lanup executable", err)
+	}
+
+	logPath := daemonLogPath()
+	logFile, err := openDaemonLog()
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	child := exec.Command(executable, daemonRunArgs(c.Profile, c.APIAddr, c.All)...)
+	child.Stdout = logFile
+	child.Stderr = logFile
+	process.Detach(child)
+
+	if err := child.Start(); err != nil {
+		return lanuperrors.FromOSError("Failed to start daemon", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+
+	// The daemon is ready once watch mode has written its PID file
+	deadline := time.After(daemonStartTimeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-exited:
+			return lanuperrors.NewError(lanuperrors.ErrProcess,
+				fmt.Sprintf("Daemon exited during startup, see %s", logPath), err)
+		case <-deadline:
+			return lanuperrors.NewError(lanuperrors.ErrProcess,
+				fmt.Sprintf("Daemon did not start within %s, see %s", daemonStartTimeout, logPath), nil)
+		case <-ticker.C:
+			if pid, running := process.RunningPID(pidPath); running && pid == child.Process.Pid {
+				if c.All {
+					utils.Success("Shared daemon started (PID %d)", pid)
+				} else {
+					utils.Success("Daemon started (PID %d)", pid)
+				}
+				utils.Info("Logs: %s", logPath)
+				if apiURL := readAPIURL(); apiURL != "" && !c.All {
+					utils.Info("API: %s/v1/state", apiURL)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// Stop terminates the daemon of the current project, leaving the env file as is
+func (c *DaemonCmd) Stop() error {
+	if c.All {
+		return c.stopShared()
+	}
+
+	pid, stopped, err := terminateWatcher()
+	if err != nil {
+		return err
+	}
+	if !stopped {
+		utils.Info("No daemon running for this project")
+		return nil
+	}
+
+	utils.Success("Daemon stopped (PID %d)", pid)
+	utils.Info("Run 'lanup stop' to also revert the env file to localhost")
+	return nil
+}
+
+// Status reports whether the daemon of the current project is running
+func (c *DaemonCmd) Status() error {
+	if c.All {
+		return c.statusShared()
+	}
+
+	pidPath, err := process.WatchPIDFile(projectDir())
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to determine watcher PID file", err)
+	}
+
+	pid, running := process.RunningPID(pidPath)
+	if !running {
+		utils.Info("Daemon is not running for this project")
+		return nil
+	}
+
+	utils.Success("Daemon is running (PID %d)", pid)
+	table := utils.NewTable()
+	table.Indent = "  "
+	if info, err := os.Stat(pidPath); err == nil {
+		table.AddRow("Started:", fmt.Sprintf("%s (%s ago)", info.ModTime().Format("2006-01-02 15:04:05"),
+			time.Since(info.ModTime()).Round(time.Second)))
+	}
+	table.AddRow("Logs:", daemonLogPath())
+	if apiURL := readAPIURL(); apiURL != "" {
+		table.AddRow("API:", apiURL+"/v1/state")
+	}
+	table.Print()
+
+	return nil
+}
+
+// RunForeground runs watch mode in the foreground with file logging, as a Windows service when
+// started by the service manager
+func (c *DaemonCmd) RunForeground() error {
+	if c.Dir != "" {
+		if err := os.Chdir(c.Dir); err != nil {
+			return lanuperrors.FromOSError("Failed to open the project directory", err)
+		}
+	}
+
+	isService, err := service.RunWindowsService("lanup", func(stop <-chan struct{}) error {
+		// Services have no console, the output goes to the daemon log like with 'daemon start'
+		logFile, err := openDaemonLog()
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		utils.RedirectOutput(logFile)
+		return c.run(stop)
+	})
+	if isService {
+		return err
+	}
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied, "Failed to start the daemon", err)
+	}
+	return c.run(nil)
+}
+
+// run runs the daemon until interrupted or stop is closed
+func (c *DaemonCmd) run(stop <-chan struct{}) error {
+	if c.All {
+		return c.runShared(stop)
+	}
+
+	start := &StartCmd{
+		Watch:    true,
+		Log:      true,
+		Profile:  c.Profile,
+		daemon:   true,
+		apiAddr:  c.APIAddr,
+		watchdog: c.Watchdog,
+		stop:     stop,
+	}
+	return start.Run()
+}
+
+// daemonRunArgs returns the arguments starting the daemon process
+func daemonRunArgs(profile, apiAddr string, all bool) []string {
+	args := []string{"daemon", "run"}
+	if all {
+		// The shared daemon regenerates each project with its own profile and serves no API
+		return append(args, "--all")
+	}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	if apiAddr != defaultAPIAddr {
+		args = append(args, "--api-addr="+apiAddr)
+	}
+	return args
+}
+
+// pidFile returns the PID file of the daemon of the current project, or of the shared daemon
+func (c *DaemonCmd) pidFile() (string, error) {
+	pidPath, err := process.WatchPIDFile(projectDir())
+	if c.All {
+		pidPath, err = process.SharedDaemonPIDFile()
+	}
+	if err != nil {
+		return "", lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to determine daemon PID file", err)
+	}
+	return pidPath, nil
+}
+
+// openDaemonLog opens the daemon log file for appending
+func openDaemonLog() (*os.File, error) {
+	logPath := daemonLogPath()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, lanuperrors.FromOSError("Failed to create log directory", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, lanuperrors.FromOSError("Failed to open daemon log file", err)
+	}
+	return logFile, nil
+}
+
+// daemonLogPath returns the file receiving the daemon output, next to the lanup log file
+func daemonLogPath() string {
+	if globalCfg := GetGlobalConfig(); globalCfg != nil && globalCfg.LogPath != "" {
+		return filepath.Join(filepath.Dir(globalCfg.LogPath), "daemon.log")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".lanup", "logs", "daemon.log")
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// Add registers project directories with the shared daemon
+func (c *DaemonCmd) Add(dirs []string) error {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	projects := make(map[string]string, len(dirs)) // directory to configuration file
+	for _, dir := range dirs {
+		found, err := config.FindProjectConfig(dir)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Failed to load project configuration of %s", dir), err)
+		}
+		configPath, err := filepath.Abs(found)
+		if err != nil {
+			return lanuperrors.FromOSError("Failed to resolve project directory", err)
+		}
+		if _, err := config.LoadProjectConfigProfile(configPath, c.Profile); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Failed to load project configuration of %s", filepath.Dir(configPath)), err)
+		}
+		projects[filepath.Dir(configPath)] = configPath
+	}
+
+	if err := updateDaemonProjects(func(s *state.State) {
+		for dir, configPath := range projects {
+			p := s.Project(dir)
+			p.Config = configPath
+			p.Profile = c.Profile
+			p.Daemon = true
+		}
+	}); err != nil {
+		return err
+	}
+
+	for dir := range projects {
+		utils.Success("Registered %s with the shared daemon", dir)
+	}
+	if pid, running := sharedDaemonPID(); running {
+		utils.Info("The shared daemon (PID %d) picks them up on the next network change", pid)
+	} else {
+		utils.Info("Run 'lanup daemon start --all' to keep them in sync")
+	}
+	return nil
+}
+
+// Remove unregisters project directories from the shared daemon
+func (c *DaemonCmd) Remove(dirs []string) error {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var removed, unknown []string
+	if err := updateDaemonProjects(func(s *state.State) {
+		for _, dir := range dirs {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				abs = dir
+			}
+			if p, ok := s.Projects[abs]; ok && p.Daemon {
+				p.Daemon = false
+				removed = append(removed, abs)
+			} else {
+				unknown = append(unknown, abs)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+
+	for _, dir := range removed {
+		utils.Success("Unregistered %s from the shared daemon", dir)
+	}
+	for _, dir := range unknown {
+		utils.Warning("%s is not registered with the shared daemon", dir)
+	}
+	return nil
+}
+
+// stopShared terminates the shared daemon
+func (c *DaemonCmd) stopShared() error {
+	pidPath, err := c.pidFile()
+	if err != nil {
+		return err
+	}
+
+	pid, running := process.RunningPID(pidPath)
+	if !running {
+		utils.Info("The shared daemon is not running")
+		return nil
+	}
+	if err := process.StopAndWait(pid, 5*time.Second); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to stop the shared daemon", err)
+	}
+	_ = process.RemovePIDFile(pidPath)
+
+	utils.Success("Shared daemon stopped (PID %d)", pid)
+	return nil
+}
+
+// statusShared reports whether the shared daemon runs and lists its projects
+func (c *DaemonCmd) statusShared() error {
+	if pid, running := sharedDaemonPID(); running {
+		utils.Success("Shared daemon is running (PID %d)", pid)
+		utils.Info("Logs: %s", daemonLogPath())
+	} else {
+		utils.Info("Shared daemon is not running")
+	}
+
+	projects, err := daemonProjects()
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		utils.Info("No projects registered, run 'lanup daemon add' in them")
+		return nil
+	}
+
+	utils.Println()
+	currentIP := detectCurrentIP()
+	table := utils.NewTable("PROJECT", "PROFILE", "LAST IP", "STATUS")
+	for _, p := range projects {
+		status := colorProjectStatus(projectStatus(p, currentIP))
+		if w := p.ActiveWatcher(); w != nil {
+			status = fmt.Sprintf("own %s (PID %d)", w.Mode, w.PID)
+		}
+		table.AddRow(p.Dir, valueOrDash(p.Profile), valueOrDash(p.LastIP), status)
+	}
+	table.Print()
+	return nil
+}
+
+// runShared watches the network and regenerates the env files of the registered projects
+// The registrations are read again on each change, 'daemon add' needs no restart
+func (c *DaemonCmd) runShared(stop <-chan struct{}) error {
+	pidPath, err := c.pidFile()
+	if err != nil {
+		return err
+	}
+	if pid, running := process.RunningPID(pidPath); running {
+		return lanuperrors.NewError(lanuperrors.ErrProcess,
+			fmt.Sprintf("The shared daemon is already running (PID %d)", pid), nil)
+	}
+	if err := process.WritePIDFile(pidPath); err != nil {
+		return lanuperrors.FromOSError("Failed to write daemon PID file", err)
+	}
+	defer process.RemovePIDFile(pidPath)
+
+	log, err := newFileLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
+	} else if log != nil {
+		defer log.Close()
+		log = log.With("module", "daemon")
+	}
+
+	utils.Info("Shared daemon started, keeping the registered projects in sync")
+	regenerateProjects(log, hooks.Start, "")
+
+	watcher := net.NewIPWatcher(checkInterval())
+	watcher.Logger = log.With("module", "watcher")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := watcher.Subscribe(ctx)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := watcher.Start(ctx); err != nil && err != context.Canceled {
+			errCh <- err
+		}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			utils.Println("Shutting down gracefully...")
+			cancel()
+			watcher.Stop()
+			return nil
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				regenerateProjects(log, hooks.Start, "")
+				continue
+			}
+			utils.Println("Shutting down gracefully...")
+			cancel()
+			watcher.Stop()
+			return nil
+
+		case err := <-errCh:
+			return lanuperrors.NewError(lanuperrors.ErrNoNetwork, "Watcher error", err)
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event.Reason != net.ReasonIPChanged {
+				continue
+			}
+			utils.Info("IP changed: %s -> %s", event.Old.IP, event.New.IP)
+			if log != nil {
+				log.Info("IP changed",
+					logger.Field{Key: "old_ip", Value: event.Old.IP},
+					logger.Field{Key: "new_ip", Value: event.New.IP})
+			}
+			regenerateProjects(log, hooks.Change, event.Old.IP)
+		}
+	}
+}
+
+// regenerateProjects regenerates the env file of each registered project, in turn
+// Projects with their own watch mode or daemon keep themselves in sync and are skipped
+func regenerateProjects(log *logger.Logger, event hooks.Event, oldIP string) {
+	projects, err := daemonProjects()
+	if err != nil {
+		utils.Error("%v", err)
+		return
+	}
+
+	for _, p := range projects {
+		plog := log.With("module", "daemon."+filepath.Base(p.Dir))
+		if w := p.ActiveWatcher(); w != nil {
+			if plog != nil {
+				plog.Debug("Skipped, the project has its own watcher", logger.Field{Key: "pid", Value: w.PID})
+			}
+			continue
+		}
+		if p.Missing() {
+			utils.Warning("Skipped %s: the project or its configuration is missing", p.Dir)
+			if plog != nil {
+				plog.Warn("Project missing", logger.Field{Key: "dir", Value: p.Dir})
+			}
+			continue
+		}
+
+		if err := regenerateProject(p, event, oldIP, plog); err != nil {
+			utils.Error("%s: %v", p.Dir, err)
+			if plog != nil {
+				plog.Error("Regeneration failed", logger.Field{Key: "error", Value: err.Error()})
+			}
+		}
+	}
+}
+
+// regenerateProject writes the env file of a project and runs its hooks for event
+func regenerateProject(p *state.Project, event hooks.Event, oldIP string, log *logger.Logger) error {
+	return inProject(p.Dir, func() error {
+		projectConfig, err := config.LoadProjectConfigProfile("", p.Profile)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				"Failed to load project configuration", err)
+		}
+		if event == hooks.Change && projectConfig.IP != "" {
+			// The IP set in the configuration doesn't follow the network
+			if log != nil {
+				log.Debug("Skipped, the project sets its IP", logger.Field{Key: "ip", Value: projectConfig.IP})
+			}
+			return nil
+		}
+
+		start := &StartCmd{Profile: p.Profile, logger: log, daemon: true, brief: true}
+		if event == hooks.Change {
+			start.reason = runReasonIPChanged
+		}
+		if err := start.executeStart(projectConfig); err != nil {
+			return err
+		}
+		start.runHooks(event, projectConfig, oldIP)
+		return nil
+	})
+}
+
+// inProject runs fn in the project directory, lanup finds the configuration and env files from there
+func inProject(dir string, fn func() error) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to get current directory", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return lanuperrors.FromOSError(fmt.Sprintf("Failed to enter %s", dir), err)
+	}
+	defer os.Chdir(wd)
+
+	return fn()
+}
+
+// daemonProjects returns the projects registered with the shared daemon
+func daemonProjects() ([]*state.Project, error) {
+	st, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+	return st.DaemonProjects(), nil
+}
+
+// updateDaemonProjects changes the registrations in the state file
+func updateDaemonProjects(fn func(*state.State)) error {
+	path, err := state.DefaultPath()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			"Failed to determine state file", err)
+	}
+	if err := state.Update(path, fn); err != nil {
+		return lanuperrors.FromOSError("Failed to update state file", err)
+	}
+	return nil
+}
+
+// sharedDaemonPID returns the PID of the shared daemon and whether it runs
+func sharedDaemonPID() (int, bool) {
+	pidPath, err := process.SharedDaemonPIDFile()
+	if err != nil {
+		return 0, false
+	}
+	return process.RunningPID(pidPath)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	stdnet "net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonRunArgs(t *testing.T) {
+	assert.Equal(t, []string{"daemon", "run"}, daemonRunArgs("", defaultAPIAddr, false))
+	assert.Equal(t, []string{"daemon", "run", "--profile", "mobile"}, daemonRunArgs("mobile", defaultAPIAddr, false))
+	assert.Equal(t, []string{"daemon", "run", "--api-addr="}, daemonRunArgs("", "", false))
+	assert.Equal(t, []string{"daemon", "run", "--api-addr=127.0.0.1:7070"}, daemonRunArgs("", "127.0.0.1:7070", false))
+	assert.Equal(t, []string{"daemon", "run", "--all"}, daemonRunArgs("mobile", "", true))
+}
+
+func TestDaemonLogPath(t *testing.T) {
+	original := globalConfig
+	defer func() { globalConfig = original }()
+
+	globalConfig = &config.GlobalConfig{LogPath: "/var/log/lanup/lanup.log"}
+	assert.Equal(t, filepath.Join("/var/log/lanup", "daemon.log"), daemonLogPath())
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	globalConfig = nil
+	assert.Equal(t, filepath.Join(home, ".lanup", "logs", "daemon.log"), daemonLogPath())
+}
+
+func TestDaemonCmd_StartAlreadyRunning(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pidPath, err := process.SharedDaemonPIDFile()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(pidPath), 0755))
+	require.NoError(t, process.WritePIDFile(pidPath))
+
+	err = (&DaemonCmd{All: true}).Start()
+	assert.ErrorIs(t, err, lanuperrors.ErrProcess)
+	assert.Equal(t, 6, lanuperrors.ExitCode(err))
+}
+
+func TestDaemonCmd_AddRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	project := filepath.Join(home, "app")
+	require.NoError(t, os.MkdirAll(project, 0755))
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(project, ".lanup.yaml"), &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env",
+	}))
+
+	// Directories without a valid configuration are refused
+	err := (&DaemonCmd{}).Add([]string{filepath.Join(home, "empty")})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+
+	require.NoError(t, (&DaemonCmd{}).Add([]string{project}))
+	projects, err := daemonProjects()
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, project, projects[0].Dir)
+	assert.Equal(t, filepath.Join(project, ".lanup.yaml"), projects[0].Config)
+
+	require.NoError(t, (&DaemonCmd{}).Remove([]string{project}))
+	projects, err = daemonProjects()
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestRegenerateProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+
+	var dirs []string
+	for _, name := range []string{"a", "b"} {
+		dir := filepath.Join(home, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, config.SaveProjectConfig(filepath.Join(dir, ".lanup.yaml"), &config.ProjectConfig{
+			Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+			Output: ".env",
+		}))
+		dirs = append(dirs, dir)
+	}
+	require.NoError(t, (&DaemonCmd{}).Add(dirs))
+
+	// Project b has its own watcher, the shared daemon leaves it alone
+	statePath, err := state.DefaultPath()
+	require.NoError(t, err)
+	require.NoError(t, state.Update(statePath, func(s *state.State) {
+		s.Projects[dirs[1]].Watcher = &state.Watcher{PID: os.Getpid(), Mode: "watch"}
+	}))
+
+	regenerateProjects(nil, hooks.Change, "10.0.0.5")
+
+	content, err := os.ReadFile(filepath.Join(dirs[0], ".env"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=")
+	assert.NotContains(t, string(content), "localhost")
+	assert.NoFileExists(t, filepath.Join(dirs[1], ".env"))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, originalWd, wd, "working directory restored")
+}
+
+func TestInProject_MissingDirectory(t *testing.T) {
+	err := inProject(filepath.Join(t.TempDir(), "removed"), func() error { return nil })
+	assert.ErrorIs(t, err, lanuperrors.ErrFileNotFound)
+}
+
+func TestWatchdog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on Windows")
+	}
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	watcher := net.NewIPWatcher(5 * time.Second)
+
+	// Neither systemd nor --watchdog asks for a watchdog
+	assert.Nil(t, newWatchdog(watcher, 5*time.Second, 0, nil))
+
+	dog := newWatchdog(watcher, 5*time.Second, 2*time.Minute, nil)
+	require.NotNil(t, dog)
+	assert.Equal(t, 30*time.Second, dog.interval())
+	assert.Equal(t, watchdogStall, dog.stall)
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := stdnet.ListenUnixgram("unixgram", &stdnet.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "60000000")
+
+	dog = newWatchdog(watcher, 30*time.Second, 0, nil)
+	require.NotNil(t, dog)
+	assert.Equal(t, 30*time.Second, dog.interval())
+	assert.Equal(t, 90*time.Second, dog.stall)
+
+	// The watcher has not checked yet, it is alive since it started
+	dog.ping()
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "WATCHDOG=1", string(buf[:n]))
+
+	// A watcher that stopped checking is not reported alive
+	dog.started = time.Now().Add(-2 * time.Minute)
+	alive := dog.alive.Load()
+	dog.ping()
+	assert.True(t, dog.hung)
+	assert.Equal(t, alive, dog.alive.Load())
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err = conn.Read(buf)
+	assert.Error(t, err, "no WATCHDOG=1 should be sent")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// DiffCmd represents the diff command
+type DiffCmd struct {
+	Profile  string
+	ExitCode bool
+}
+
+// NewDiffCmd creates a new diff command
+func NewDiffCmd() *cobra.Command {
+	diffCmd := &DiffCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the env file with what lanup would write now",
+		Long: `Recompute the variables lanup start would write right now and compare them with the
+managed variables of the output file, without modifying anything.
+
+Stale values, such as an old IP after an overnight DHCP lease change, are flagged.
+User variables are never modified by lanup and are not compared.
+
+Use --exit-code in scripts: the command then exits with status 7 when the file is out of date,
+so that errors, such as an invalid configuration, can be told apart.
+
+Examples:
+  lanup diff
+  lanup diff --profile mobile
+  lanup diff --exit-code || lanup start`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// An out of date file is not a usage error
+			cmd.SilenceUsage = true
+			return diffCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVar(&diffCmd.Profile, "profile", "", "configuration profile to use (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().BoolVar(&diffCmd.ExitCode, "exit-code", false, "exit with status 7 when there are differences")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewDiffCmd())
+}
+
+// Run executes the diff command
+func (c *DiffCmd) Run() error {
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	// Detect local IP
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+
+	// Compute the variables exactly as lanup start does
+	found, err := collectVariables(context.Background(), projectConfig, nil)
+	if err != nil {
+		return err
+	}
+	vars := found.Vars
+	host := projectConfig.URLHost(netInfo.IP)
+	desired, _ := withCertificateVars(lanup.TransformConfig(projectConfig, vars, host), projectConfig, netInfo.IP, false)
+
+	envWriter, err := newEnvWriter(projectConfig)
+	if err != nil {
+		return err
+	}
+	current, err := envWriter.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
+	}
+
+	if exists, err := envWriter.Exists(); err == nil && !exists {
+		utils.Warning("%s does not exist yet", projectConfig.OutputPath())
+	}
+	utils.Info("Comparing %s with the live state (IP %s)", projectConfig.OutputPath(), netInfo.IP)
+	utils.Println()
+
+	changes := env.Diff(current, desired)
+	if !displayChanges(changes) {
+		utils.Success("%s is up to date", projectConfig.OutputPath())
+		return nil
+	}
+
+	utils.Println()
+	utils.Info("Run 'lanup start' to update %s", projectConfig.OutputPath())
+	if c.ExitCode {
+		return lanuperrors.NewError(lanuperrors.ErrChangesPending,
+			fmt.Sprintf("%s is out of date", projectConfig.OutputPath()), nil)
+	}
+
+	return nil
+}
+
+// displayChanges prints the changed variables and reports whether there were any
+func displayChanges(changes []env.Change) bool {
+	stale, added, removed := 0, 0, 0
+	for _, change := range changes {
+		switch change.Kind {
+		case env.Modified:
+			stale++
+			fmt.Fprintf(utils.Stdout, "%s %s %s\n", color.YellowString("~"), change.Key, color.YellowString("(stale)"))
+			fmt.Fprintf(utils.Stdout, "    %s\n", color.RedString("- %s", change.OldValue))
+			fmt.Fprintf(utils.Stdout, "    %s\n", color.GreenString("+ %s", change.NewValue))
+		case env.Added:
+			added++
+			fmt.Fprintln(utils.Stdout, color.GreenString("+ %s=%s", change.Key, change.NewValue))
+		case env.Removed:
+			removed++
+			fmt.Fprintln(utils.Stdout, color.RedString("- %s=%s", change.Key, change.OldValue))
+		}
+	}
+
+	if stale+added+removed == 0 {
+		return false
+	}
+
+	fmt.Fprintln(utils.Stdout)
+	fmt.Fprintf(utils.Stdout, "%d stale, %d to add, %d to remove\n", stale, added, removed)
+	return true
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayChanges(t *testing.T) {
+	assert.False(t, displayChanges(nil))
+	assert.False(t, displayChanges([]env.Change{{Key: "API_URL", Kind: env.Unchanged}}))
+	assert.True(t, displayChanges([]env.Change{
+		{Key: "API_URL", Kind: env.Modified, OldValue: "http://192.168.1.100:8000", NewValue: "http://192.168.1.104:8000"},
+	}))
+}
+
+func TestDiffCmd_Run(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	testConfig := &config.ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+		},
+		Output: ".env.local",
+	}
+	err = config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig)
+	require.NoError(t, err)
+
+	// A stale IP from a previous lease
+	stale := "# lanup:managed\nAPI_URL=http://10.255.255.1:8000\nSECRET=keep\n"
+	envPath := filepath.Join(tmpDir, ".env.local")
+	require.NoError(t, os.WriteFile(envPath, []byte(stale), 0644))
+
+	// Differences are only an error with --exit-code
+	require.NoError(t, (&DiffCmd{}).Run())
+
+	err = (&DiffCmd{ExitCode: true}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of date")
+	assert.ErrorIs(t, err, lanuperrors.ErrChangesPending)
+	assert.Equal(t, 7, lanuperrors.ExitCode(err))
+
+	// Nothing is modified
+	content, err := os.ReadFile(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, stale, string(content))
+
+	// Once the file is regenerated there is no difference left
+	require.NoError(t, (&StartCmd{}).Run())
+	require.NoError(t, (&DiffCmd{ExitCode: true}).Run())
+}
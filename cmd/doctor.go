@@ -1,9 +1,23 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	stdnet "net"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/raucheacho/lanup/internal/config"
 	"github.com/raucheacho/lanup/internal/docker"
+	"github.com/raucheacho/lanup/internal/firewall"
 	"github.com/raucheacho/lanup/internal/net"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
 	"github.com/raucheacho/lanup/pkg/utils"
@@ -11,13 +25,38 @@ import (
 )
 
 // DoctorCmd represents the doctor command
-type DoctorCmd struct{}
+type DoctorCmd struct {
+	JSON    bool
+	Fix     bool
+	Profile string
+
+	fixes [][]string // commands opening the ports blocked by a firewall
+}
 
 // HealthCheck represents the result of a health check
 type HealthCheck struct {
-	Name    string
-	Status  bool
-	Message string
+	Name     string
+	Status   bool
+	Message  string
+	Hint     string       // how to fix a failed check
+	Warning  bool         // a failure that doesn't make doctor fail, such as DNS on an offline LAN
+	Details  *utils.Table // shown below the message, optional
+	Duration time.Duration
+}
+
+// DoctorReport is the machine-readable output of 'lanup doctor --json'
+type DoctorReport struct {
+	OK     bool              `json:"ok"`
+	Checks []DoctorCheckJSON `json:"checks"`
+}
+
+// DoctorCheckJSON is a single check result in a DoctorReport
+type DoctorCheckJSON struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // pass, warn or fail
+	Message    string `json:"message"`
+	Hint       string `json:"hint,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 // NewDoctorCmd creates a new doctor command
@@ -31,15 +70,28 @@ func NewDoctorCmd() *cobra.Command {
 
 This command checks:
   - Network interfaces and local IP detection
+  - The default gateway (ping, or ARP when it drops pings) and DNS resolution, with their
+    latency, to tell a broken network apart from a broken lanup
   - Docker availability and running containers
   - Supabase local development setup
+  - Host firewalls (ufw, firewalld, Windows Defender Firewall, macOS application
+    firewall) and whether they let LAN devices reach the ports of the project
 
-Use this command to troubleshoot issues with lanup.`,
+Use this command to troubleshoot issues with lanup.
+With --json, results are printed as JSON and the exit code is non-zero when a check fails,
+so CI scripts and onboarding tools can gate on lanup health.
+With --fix, the commands opening the blocked ports are run after confirmation.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return doctorCmd.Run()
 		},
 	}
 
+	// Add flags
+	cmd.Flags().BoolVar(&doctorCmd.JSON, "json", false, "print results as JSON")
+	cmd.Flags().BoolVar(&doctorCmd.Fix, "fix", false, "open the ports blocked by a firewall, after confirmation")
+	cmd.Flags().StringVar(&doctorCmd.Profile, "profile", "", "configuration profile whose ports the firewall check looks at (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
 	return cmd
 }
 
@@ -49,41 +101,136 @@ func init() {
 
 // Run executes the doctor command
 func (c *DoctorCmd) Run() error {
-	utils.PrintSection("Running lanup diagnostics")
+	if c.Fix && c.JSON {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "--fix cannot be used with --json", nil)
+	}
+	if !c.JSON {
+		utils.PrintSection("Running lanup diagnostics")
+	}
 
 	// Run all health checks
-	checks := []HealthCheck{
-		checkNetworkInterfaces(),
-		checkDocker(),
-		checkSupabase(),
-	}
+	checks := runChecks([]func() HealthCheck{
+		checkNetworkInterfaces,
+		checkGateway,
+		checkDNS,
+		checkDocker,
+		checkSupabase,
+		c.checkFirewall,
+	})
 
-	// Display results
 	allPassed := true
 	for _, check := range checks {
-		if check.Status {
+		allPassed = allPassed && (check.Status || check.Warning)
+	}
+
+	if c.JSON {
+		if err := printJSON(buildDoctorReport(checks)); err != nil {
+			return err
+		}
+	} else {
+		displayChecks(checks, allPassed)
+	}
+
+	if c.Fix && len(c.fixes) > 0 {
+		fixed, err := c.openPorts()
+		if err != nil {
+			return err
+		}
+		if fixed {
+			allPassed = true
+			for _, check := range checks {
+				allPassed = allPassed && (check.Status || check.Warning || check.Name == firewallCheckName)
+			}
+		}
+	}
+
+	if !allPassed {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Health checks failed", nil)
+	}
+	return nil
+}
+
+// runChecks runs the checks in order and records how long each one took
+func runChecks(checks []func() HealthCheck) []HealthCheck {
+	results := make([]HealthCheck, 0, len(checks))
+	for _, check := range checks {
+		start := time.Now()
+		result := check()
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+	return results
+}
+
+// displayChecks prints the check results for humans
+func displayChecks(checks []HealthCheck, allPassed bool) {
+	for _, check := range checks {
+		switch {
+		case check.Status:
 			utils.Success("%s", check.Name)
-		} else {
+		case check.Warning:
+			utils.Warning("%s", check.Name)
+		default:
 			utils.Error("%s", check.Name)
-			allPassed = false
 		}
 		if check.Message != "" {
 			fmt.Printf("   %s\n", check.Message)
 		}
+		if check.Details != nil && len(check.Details.Rows) > 0 {
+			check.Details.Indent = "   "
+			check.Details.Print()
+		}
+		if !check.Status && check.Hint != "" {
+			fmt.Printf("   %s %s\n", utils.Symbol("→", "->"), check.Hint)
+		}
 	}
 
 	// Summary
 	fmt.Println()
 	if allPassed {
 		utils.Success("All checks passed! lanup is ready to use.")
-		return nil
 	} else {
 		utils.Warning("Some checks failed. Please review the issues above.")
-		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
-			"Health checks failed", nil)
 	}
 }
 
+// buildDoctorReport converts check results to their JSON representation
+func buildDoctorReport(checks []HealthCheck) DoctorReport {
+	report := DoctorReport{OK: true, Checks: make([]DoctorCheckJSON, 0, len(checks))}
+
+	for _, check := range checks {
+		status := "pass"
+		switch {
+		case check.Status:
+		case check.Warning:
+			status = "warn"
+		default:
+			status = "fail"
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, DoctorCheckJSON{
+			Name:       check.Name,
+			Status:     status,
+			Message:    check.Message,
+			Hint:       check.Hint,
+			DurationMs: check.Duration.Milliseconds(),
+		})
+	}
+
+	return report
+}
+
+// printJSON writes value to stdout as indented JSON
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to encode JSON output", err)
+	}
+	return nil
+}
+
 // checkNetworkInterfaces verifies that active network interfaces are available
 func checkNetworkInterfaces() HealthCheck {
 	netInfo, err := net.DetectLocalIP()
@@ -92,14 +239,126 @@ func checkNetworkInterfaces() HealthCheck {
 			Name:    "Network Interfaces",
 			Status:  false,
 			Message: fmt.Sprintf("Failed to detect local IP: %v", err),
+			Hint:    "Connect to a Wi-Fi or Ethernet network, then run 'lanup doctor' again",
 		}
 	}
 
-	return HealthCheck{
+	check := HealthCheck{
 		Name:    "Network Interfaces",
 		Status:  true,
 		Message: fmt.Sprintf("Detected IP: %s on interface %s (%s)", netInfo.IP, netInfo.Interface, netInfo.Type),
 	}
+	if interfaces, err := net.GetAllInterfaces(); err == nil {
+		check.Details = interfacesTable(interfaces, netInfo)
+	}
+	return check
+}
+
+// interfacesTable lists the interfaces with a private IP, marking the one lanup uses
+func interfacesTable(interfaces []net.NetworkInfo, selected *net.NetworkInfo) *utils.Table {
+	table := utils.NewTable("INTERFACE", "IP", "TYPE", "USED")
+	table.Border = true
+	for _, iface := range interfaces {
+		used := ""
+		if iface == *selected {
+			used = utils.Symbol("✓", "*")
+		}
+		table.AddRow(iface.Interface, iface.IP, iface.Type, used)
+	}
+	return table
+}
+
+// Connectivity checks of doctor
+const (
+	connectivityTimeout = 2 * time.Second
+	doctorDNSHost       = "example.com"          // reserved by IANA, it always resolves
+	slowLatency         = 100 * time.Millisecond // above it, the network is reported as slow
+)
+
+// checkGateway verifies that the default gateway, usually the router, answers
+func checkGateway() HealthCheck {
+	gateway, err := net.DefaultGateway()
+	if err != nil {
+		return gatewayCheck("", net.GatewayResult{}, err)
+	}
+	result, err := net.CheckGateway(context.Background(), gateway, connectivityTimeout)
+	return gatewayCheck(gateway, result, err)
+}
+
+// gatewayCheck builds the result of the gateway check
+func gatewayCheck(gateway string, result net.GatewayResult, err error) HealthCheck {
+	check := HealthCheck{Name: "Gateway", Status: true}
+	switch {
+	case gateway == "":
+		check.Status = false
+		check.Message = fmt.Sprintf("No default gateway: %v", err)
+		check.Hint = "This machine is not connected to a router, connect it to the network of your devices"
+	case err != nil:
+		check.Status = false
+		check.Message = fmt.Sprintf("Gateway %s does not answer ping nor ARP", gateway)
+		check.Hint = "The network is down, not lanup: reconnect to the Wi-Fi or restart the router"
+	case result.Method == "arp" && errors.Is(result.PingErr, net.ErrNoPing):
+		check.Message = fmt.Sprintf("Gateway %s (%s) answers ARP, ping is not installed to measure the latency", gateway, result.MAC)
+	case result.Method == "arp":
+		check.Message = fmt.Sprintf("Gateway %s (%s) answers ARP, it drops pings", gateway, result.MAC)
+	default:
+		check.Message = fmt.Sprintf("Gateway %s answers in %s", gateway, result.Latency.Round(100*time.Microsecond))
+		if result.Latency > slowLatency {
+			check.Message += " (slow, the Wi-Fi may be congested or weak)"
+		}
+	}
+	return check
+}
+
+// checkDNS verifies that the DNS servers of the system resolve a public hostname
+func checkDNS() HealthCheck {
+	result, err := net.CheckDNS(context.Background(), doctorDNSHost, connectivityTimeout)
+	return dnsCheck(result, err)
+}
+
+// dnsCheck builds the result of the DNS check
+// A failure is only a warning: offline LANs, such as a hotspot without data, are fine for lanup.
+func dnsCheck(result net.DNSResult, err error) HealthCheck {
+	check := HealthCheck{Name: "DNS", Status: true}
+	via := ""
+	if len(result.Servers) > 0 {
+		via = " via " + strings.Join(result.Servers, ", ")
+	}
+	if localResolver(result.Servers) {
+		// e.g. 127.0.0.53 of systemd-resolved, which forwards to the servers of the network
+		via = " via the local resolver"
+	}
+
+	if err != nil {
+		// The DNS error repeats the host and the server
+		reason := err.Error()
+		var dnsErr *stdnet.DNSError
+		if errors.As(err, &dnsErr) {
+			reason = dnsErr.Err
+		}
+		check.Status = false
+		check.Warning = true
+		check.Message = fmt.Sprintf("Failed to resolve %s%s: %s", result.Host, via, reason)
+		check.Hint = "DNS is broken on this network, not lanup: the LAN URLs still work, but check the DNS " +
+			"settings of the network or use another DNS server"
+		return check
+	}
+
+	check.Message = fmt.Sprintf("Resolved %s%s in %s", result.Host, via, result.Latency.Round(100*time.Microsecond))
+	if result.Latency > slowLatency {
+		check.Message += " (slow)"
+	}
+	return check
+}
+
+// localResolver reports whether the DNS servers are all on this machine, such as systemd-resolved or dnsmasq
+func localResolver(servers []string) bool {
+	for _, server := range servers {
+		if ip := stdnet.ParseIP(server); ip == nil || !ip.IsLoopback() {
+			return false
+		}
+	}
+	return len(servers) > 0
 }
 
 // checkDocker verifies Docker availability and running containers
@@ -109,6 +368,7 @@ func checkDocker() HealthCheck {
 			Name:    "Docker",
 			Status:  false,
 			Message: "Docker is not installed or not running",
+			Hint:    "Install Docker Desktop or start the Docker daemon, or set auto_detect.docker to false",
 		}
 	}
 
@@ -118,6 +378,7 @@ func checkDocker() HealthCheck {
 			Name:    "Docker",
 			Status:  false,
 			Message: fmt.Sprintf("Docker is available but failed to list containers: %v", err),
+			Hint:    "Check that your user can access the Docker socket (e.g. is in the docker group)",
 		}
 	}
 
@@ -133,7 +394,22 @@ func checkDocker() HealthCheck {
 		Name:    "Docker",
 		Status:  true,
 		Message: fmt.Sprintf("Docker is running with %d active container(s)", len(containers)),
+		Details: containersTable(containers),
+	}
+}
+
+// containersTable lists the running containers and their published ports
+func containersTable(containers []docker.DockerService) *utils.Table {
+	table := utils.NewTable("CONTAINER", "NAME", "PORTS")
+	table.Border = true
+	for _, c := range containers {
+		ports := make([]string, 0, len(c.Ports))
+		for _, p := range c.Ports {
+			ports = append(ports, fmt.Sprintf("%d%s%d/%s", p.HostPort, utils.Symbol("→", "->"), p.ContainerPort, p.Protocol))
+		}
+		table.AddRow(c.ContainerID, c.Name, valueOrDash(strings.Join(ports, ", ")))
 	}
+	return table
 }
 
 // checkSupabase verifies Supabase local development status
@@ -144,6 +420,7 @@ func checkSupabase() HealthCheck {
 			Name:    "Supabase",
 			Status:  false,
 			Message: fmt.Sprintf("Supabase local is not running: %v", err),
+			Hint:    "Run 'supabase start' in your project, or set auto_detect.supabase to false",
 		}
 	}
 
@@ -152,6 +429,7 @@ func checkSupabase() HealthCheck {
 			Name:    "Supabase",
 			Status:  false,
 			Message: "Supabase CLI is available but no services detected",
+			Hint:    "Run 'supabase start' in your project",
 		}
 	}
 
@@ -159,5 +437,213 @@ func checkSupabase() HealthCheck {
 		Name:    "Supabase",
 		Status:  true,
 		Message: fmt.Sprintf("Supabase local is running with %d service(s)", len(services)),
+		Details: servicesTable(services),
+	}
+}
+
+// servicesTable lists the Supabase services and their ports, sorted by name
+func servicesTable(services map[string]int) *utils.Table {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := utils.NewTable("SERVICE", "PORT")
+	table.Border = true
+	for _, name := range names {
+		table.AddRow(name, fmt.Sprint(services[name]))
+	}
+	return table
+}
+
+// firewallCheckName is the name of the firewall check, which --fix can make pass
+const firewallCheckName = "Firewall"
+
+// checkFirewall verifies that the active host firewalls let the ports of the project in
+func (c *DoctorCmd) checkFirewall() HealthCheck {
+	projectConfig, netInfo := doctorProject(c.Profile)
+	iface := ""
+	if netInfo != nil {
+		iface = netInfo.Interface
+	}
+	check, fixes := firewallCheck(firewall.Detect(iface), projectPorts(projectConfig))
+	c.fixes = fixes
+	return check
+}
+
+// firewallCheck checks ports against the active firewalls and returns the commands opening the blocked ones
+func firewallCheck(firewalls []firewall.Firewall, ports map[int][]string) (HealthCheck, [][]string) {
+	check := HealthCheck{Name: firewallCheckName, Status: true}
+
+	var active, notes []string
+	var checked []firewall.Firewall
+	for _, fw := range firewalls {
+		on, err := fw.Active()
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", fw.Name(), err))
+			continue
+		}
+		if on {
+			active = append(active, fw.Name())
+			checked = append(checked, fw)
+		}
+	}
+	switch {
+	case len(active) == 0 && len(notes) == 0:
+		check.Message = "No active host firewall"
+		return check, nil
+	case len(active) == 0:
+		check.Message = strings.Join(notes, "; ")
+		return check, nil
+	case len(ports) == 0:
+		check.Message = fmt.Sprintf("%s active, no project ports to check", strings.Join(active, ", "))
+		return check, nil
+	}
+
+	numbers := make([]int, 0, len(ports))
+	for port := range ports {
+		numbers = append(numbers, port)
+	}
+	sort.Ints(numbers)
+
+	table := utils.NewTable("FIREWALL", "PORT", "VARIABLES", "STATUS")
+	table.Border = true
+	var fixes [][]string
+	var blocked []string
+	unknown := false
+	for _, fw := range checked {
+		for _, port := range numbers {
+			verdict, err := fw.Check(port)
+			status := verdict.String()
+			switch {
+			case err != nil:
+				status = "unknown"
+				notes = append(notes, fmt.Sprintf("%s: %v", fw.Name(), err))
+			case verdict == firewall.Blocked:
+				blocked = append(blocked, fmt.Sprint(port))
+				fixes = append(fixes, fw.AllowCommands(port)...)
+			case verdict == firewall.Unknown:
+				unknown = true
+			}
+			table.AddRow(fw.Name(), fmt.Sprint(port), strings.Join(ports[port], ", "), status)
+		}
+	}
+	check.Details = table
+
+	check.Message = fmt.Sprintf("%s active, %d port(s) checked", strings.Join(active, ", "), len(numbers))
+	if unknown {
+		notes = append(notes, "some ports depend on the application listening on them, allow it when the system asks")
+	}
+	if len(notes) > 0 {
+		check.Message += " (" + strings.Join(unique(notes), "; ") + ")"
+	}
+	if len(blocked) > 0 {
+		commands := make([]string, 0, len(fixes))
+		for _, command := range fixes {
+			commands = append(commands, firewall.CommandString(command))
+		}
+		check.Status = false
+		check.Message = fmt.Sprintf("Port(s) %s blocked for LAN devices by %s", strings.Join(unique(blocked), ", "), strings.Join(active, ", "))
+		check.Hint = fmt.Sprintf("Run 'lanup doctor --fix', or: %s", strings.Join(unique(commands), "; "))
+	}
+	return check, fixes
+}
+
+// unique returns values without duplicates, in order
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// doctorProject loads the configuration of the project in the current directory in profile, resolved
+// for the facts of this machine like lanup start does, and the network it is exposed on
+// Either is nil when missing: the other checks report it.
+func doctorProject(profile string) (*config.ProjectConfig, *net.NetworkInfo) {
+	projectConfig, err := config.LoadProjectConfigProfile("", profile)
+	if err != nil {
+		netInfo, _ := net.DetectLocalIP()
+		return nil, netInfo
+	}
+
+	facts := config.CurrentFacts("", "")
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		netInfo = nil
+	} else {
+		facts = machineFacts(netInfo)
+	}
+	return projectConfig.WithFacts(facts), netInfo
+}
+
+// projectPorts returns the local ports of the services of the project, with the variables pointing to them
+func projectPorts(projectConfig *config.ProjectConfig) map[int][]string {
+	if projectConfig == nil {
+		return nil
+	}
+
+	ports := make(map[int][]string)
+	// Secrets that failed to resolve are no ports anyway
+	found, _ := collectVariables(context.Background(), projectConfig, nil)
+	for key, value := range found.Vars {
+		if _, static := projectConfig.StaticVars[key]; static {
+			continue
+		}
+		u, err := neturl.Parse(value)
+		if err != nil || (u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1") {
+			continue
+		}
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			ports[port] = append(ports[port], key)
+		}
+	}
+	if len(projectConfig.Serve.Routes) > 0 {
+		port := projectConfig.Serve.Port
+		if port == 0 {
+			port = defaultServePort
+		}
+		ports[port] = append(ports[port], "lanup serve")
+	}
+	for _, keys := range ports {
+		sort.Strings(keys)
+	}
+	return ports
+}
+
+// openPorts runs the commands opening the blocked ports after confirmation
+// It reports whether they all succeeded.
+func (c *DoctorCmd) openPorts() (bool, error) {
+	fmt.Println()
+	utils.Info("The following commands open the blocked ports:")
+	for _, command := range c.fixes {
+		fmt.Printf("   %s\n", firewall.CommandString(command))
+	}
+	fmt.Print("Run them now? (y/N): ")
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, lanuperrors.FromOSError("Failed to read confirmation", err)
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "y" && response != "yes" {
+		fmt.Println("Operation cancelled.")
+		return false, nil
+	}
+
+	for _, command := range c.fixes {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return false, lanuperrors.FromOSError(fmt.Sprintf("Failed to run %s", firewall.CommandString(command)), err)
+		}
 	}
+	utils.Success("Opened the blocked ports")
+	return true, nil
 }
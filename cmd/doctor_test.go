@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	stdnet "net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/docker"
+	"github.com/raucheacho/lanup/internal/firewall"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChecks(t *testing.T) {
+	checks := runChecks([]func() HealthCheck{
+		func() HealthCheck {
+			time.Sleep(10 * time.Millisecond)
+			return HealthCheck{Name: "Slow", Status: true}
+		},
+		func() HealthCheck {
+			return HealthCheck{Name: "Failing", Status: false, Hint: "fix it"}
+		},
+	})
+
+	require.Len(t, checks, 2)
+	assert.Equal(t, "Slow", checks[0].Name)
+	assert.GreaterOrEqual(t, checks[0].Duration, 10*time.Millisecond)
+	assert.Equal(t, "Failing", checks[1].Name)
+	assert.Equal(t, "fix it", checks[1].Hint)
+}
+
+func TestBuildDoctorReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []HealthCheck
+		wantOK bool
+	}{
+		{
+			name: "all passed",
+			checks: []HealthCheck{
+				{Name: "Network Interfaces", Status: true, Message: "Detected IP"},
+				{Name: "Docker", Status: true},
+			},
+			wantOK: true,
+		},
+		{
+			name: "one failed",
+			checks: []HealthCheck{
+				{Name: "Network Interfaces", Status: true},
+				{Name: "Docker", Status: false, Message: "Docker is not running", Hint: "Start Docker"},
+			},
+			wantOK: false,
+		},
+		{
+			name:   "no checks",
+			checks: nil,
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := buildDoctorReport(tt.checks)
+
+			assert.Equal(t, tt.wantOK, report.OK)
+			require.Len(t, report.Checks, len(tt.checks))
+			for i, check := range tt.checks {
+				assert.Equal(t, check.Name, report.Checks[i].Name)
+				assert.Equal(t, check.Message, report.Checks[i].Message)
+				assert.Equal(t, check.Hint, report.Checks[i].Hint)
+				if check.Status {
+					assert.Equal(t, "pass", report.Checks[i].Status)
+				} else {
+					assert.Equal(t, "fail", report.Checks[i].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestDoctorReport_JSON(t *testing.T) {
+	report := buildDoctorReport([]HealthCheck{
+		{Name: "Docker", Status: false, Message: "not running", Hint: "Start Docker", Duration: 1500 * time.Millisecond},
+		{Name: "Supabase", Status: true, Message: "running"},
+	})
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, false, decoded["ok"])
+
+	checks := decoded["checks"].([]interface{})
+	require.Len(t, checks, 2)
+
+	docker := checks[0].(map[string]interface{})
+	assert.Equal(t, "Docker", docker["name"])
+	assert.Equal(t, "fail", docker["status"])
+	assert.Equal(t, "not running", docker["message"])
+	assert.Equal(t, "Start Docker", docker["hint"])
+	assert.Equal(t, float64(1500), docker["duration_ms"])
+
+	supabase := checks[1].(map[string]interface{})
+	_, hasHint := supabase["hint"]
+	assert.False(t, hasHint, "hint should be omitted when empty")
+}
+
+func TestDoctorDetails(t *testing.T) {
+	selected := net.NetworkInfo{IP: "192.168.1.10", Interface: "en0", Type: "wifi"}
+	table := interfacesTable([]net.NetworkInfo{
+		{IP: "172.17.0.1", Interface: "docker0", Type: "virtual"},
+		selected,
+	}, &selected)
+	assert.Equal(t, [][]string{
+		{"docker0", "172.17.0.1", "virtual", ""},
+		{"en0", "192.168.1.10", "wifi", "✓"},
+	}, table.Rows)
+
+	table = containersTable([]docker.DockerService{
+		{ContainerID: "abc123", Name: "api", Ports: []docker.PortMapping{
+			{HostPort: 3000, ContainerPort: 80, Protocol: "tcp"},
+			{HostPort: 3443, ContainerPort: 443, Protocol: "tcp"},
+		}},
+		{ContainerID: "def456", Name: "worker"},
+	})
+	assert.Equal(t, [][]string{
+		{"abc123", "api", "3000→80/tcp, 3443→443/tcp"},
+		{"def456", "worker", "-"},
+	}, table.Rows)
+
+	table = servicesTable(map[string]int{"studio": 54323, "api": 54321})
+	assert.Equal(t, [][]string{{"api", "54321"}, {"studio", "54323"}}, table.Rows)
+}
+
+// fakeFirewall is a firewall with fixed answers
+type fakeFirewall struct {
+	active  bool
+	blocked map[int]bool
+}
+
+func (f *fakeFirewall) Name() string          { return "fakewall" }
+func (f *fakeFirewall) Active() (bool, error) { return f.active, nil }
+func (f *fakeFirewall) Check(port int) (firewall.Verdict, error) {
+	if f.blocked[port] {
+		return firewall.Blocked, nil
+	}
+	return firewall.Allowed, nil
+}
+func (f *fakeFirewall) AllowCommands(port int) [][]string {
+	return [][]string{{"fakewall", "allow", fmt.Sprint(port)}}
+}
+
+func TestFirewallCheck(t *testing.T) {
+	ports := map[int][]string{3000: {"WEB_URL"}, 8000: {"API_URL", "WS_URL"}}
+
+	check, fixes := firewallCheck(nil, ports)
+	assert.True(t, check.Status)
+	assert.Equal(t, "No active host firewall", check.Message)
+	assert.Empty(t, fixes)
+
+	check, _ = firewallCheck([]firewall.Firewall{&fakeFirewall{active: false}}, ports)
+	assert.True(t, check.Status)
+
+	check, fixes = firewallCheck([]firewall.Firewall{&fakeFirewall{active: true}}, ports)
+	assert.True(t, check.Status)
+	assert.Contains(t, check.Message, "2 port(s) checked")
+	assert.Empty(t, fixes)
+
+	check, fixes = firewallCheck([]firewall.Firewall{&fakeFirewall{active: true, blocked: map[int]bool{8000: true}}}, ports)
+	assert.False(t, check.Status)
+	assert.Contains(t, check.Message, "Port(s) 8000 blocked")
+	assert.Contains(t, check.Hint, "fakewall allow 8000")
+	assert.Equal(t, [][]string{{"fakewall", "allow", "8000"}}, fixes)
+	require.NotNil(t, check.Details)
+	assert.Len(t, check.Details.Rows, 2)
+}
+
+func TestProjectPorts(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	data := fmt.Sprintf(`output: .env.local
+auto_detect:
+  docker: false
+  supabase: false
+  dev_servers: false
+  expo: false
+vars:
+  WEB_URL: http://localhost:3000
+  API_URL:
+    - {url: "http://localhost:4000", when: os == %s}
+    - {url: "http://localhost:5000"}
+profiles:
+  mobile:
+    vars:
+      WEB_URL: http://localhost:3001
+`, runtime.GOOS)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".lanup.yaml"), []byte(data), 0644))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// The ports are those lanup start uses, with the profile and the conditions resolved
+	projectConfig, _ := doctorProject("")
+	assert.Equal(t, map[int][]string{3000: {"WEB_URL"}, 4000: {"API_URL"}}, projectPorts(projectConfig))
+	projectConfig, _ = doctorProject("mobile")
+	assert.Equal(t, map[int][]string{3001: {"WEB_URL"}, 4000: {"API_URL"}}, projectPorts(projectConfig))
+	assert.Nil(t, projectPorts(nil))
+}
+
+func TestGatewayCheck(t *testing.T) {
+	check := gatewayCheck("", net.GatewayResult{}, net.ErrNoGateway)
+	assert.False(t, check.Status)
+	assert.Contains(t, check.Message, "No default gateway")
+	assert.NotEmpty(t, check.Hint)
+
+	check = gatewayCheck("192.168.1.1", net.GatewayResult{Gateway: "192.168.1.1"}, fmt.Errorf("no reply"))
+	assert.False(t, check.Status)
+	assert.Contains(t, check.Hint, "not lanup")
+
+	check = gatewayCheck("192.168.1.1", net.GatewayResult{Method: "ping", Latency: 2450 * time.Microsecond}, nil)
+	assert.True(t, check.Status)
+	assert.Equal(t, "Gateway 192.168.1.1 answers in 2.5ms", check.Message)
+
+	check = gatewayCheck("192.168.1.1", net.GatewayResult{Method: "ping", Latency: 300 * time.Millisecond}, nil)
+	assert.True(t, check.Status)
+	assert.Contains(t, check.Message, "slow")
+
+	check = gatewayCheck("192.168.1.1", net.GatewayResult{Method: "arp", MAC: "a4:2b:b0:00:01:02", PingErr: fmt.Errorf("no reply")}, nil)
+	assert.True(t, check.Status)
+	assert.Equal(t, "Gateway 192.168.1.1 (a4:2b:b0:00:01:02) answers ARP, it drops pings", check.Message)
+
+	check = gatewayCheck("192.168.1.1", net.GatewayResult{Method: "arp", MAC: "a4:2b:b0:00:01:02", PingErr: net.ErrNoPing}, nil)
+	assert.True(t, check.Status)
+	assert.Contains(t, check.Message, "ping is not installed")
+}
+
+func TestDNSCheck(t *testing.T) {
+	result := net.DNSResult{Host: "example.com", Servers: []string{"192.168.1.1"}, Latency: 12 * time.Millisecond}
+	check := dnsCheck(result, nil)
+	assert.True(t, check.Status)
+	assert.Equal(t, "Resolved example.com via 192.168.1.1 in 12ms", check.Message)
+
+	dnsErr := &stdnet.DNSError{Err: "no such host", Name: "example.com", Server: "192.168.1.1:53"}
+	check = dnsCheck(net.DNSResult{Host: "example.com", Servers: []string{"192.168.1.1"}}, dnsErr)
+	assert.False(t, check.Status)
+	assert.True(t, check.Warning, "offline LANs are fine for lanup")
+	assert.Equal(t, "Failed to resolve example.com via 192.168.1.1: no such host", check.Message)
+	assert.Contains(t, check.Hint, "not lanup")
+
+	check = dnsCheck(net.DNSResult{Host: "example.com", Servers: []string{"127.0.0.53"}, Latency: 3 * time.Millisecond}, nil)
+	assert.Equal(t, "Resolved example.com via the local resolver in 3ms", check.Message)
+
+	report := buildDoctorReport([]HealthCheck{{Name: "Gateway", Status: true}, check, {Name: "DNS", Warning: true}})
+	assert.True(t, report.OK)
+	assert.Equal(t, "warn", report.Checks[2].Status)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewExitCodesHelp creates the exit-codes help topic, shown by 'lanup help exit-codes'
+// It has no Run function, so cobra lists it under "Additional help topics"
+func NewExitCodesHelp() *cobra.Command {
+	return &cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes returned by lanup commands",
+		Long:  exitCodesHelp(),
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(NewExitCodesHelp())
+}
+
+// exitCodesHelp describes every exit code, for scripts checking why lanup failed
+func exitCodesHelp() string {
+	var b strings.Builder
+	b.WriteString("lanup commands exit with one of these codes:\n\n")
+	for _, info := range lanuperrors.ExitCodes {
+		fmt.Fprintf(&b, "  %d  %s\n", info.Code, info.Meaning)
+	}
+	b.WriteString(`
+'lanup run' exits with the exit code of the command it runs.
+
+Example:
+  lanup start --quiet
+  if [ $? -eq 3 ]; then echo "No network, the app will use localhost"; fi`)
+	return b.String()
+}
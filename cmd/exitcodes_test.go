@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCodesHelp(t *testing.T) {
+	help := NewExitCodesHelp()
+	assert.False(t, help.Runnable(), "exit-codes is a help topic")
+	assert.Contains(t, help.Long, "  2  Invalid configuration or flag")
+	assert.Contains(t, help.Long, "  3  Network error")
+	assert.Contains(t, help.Long, "  6  The daemon or watch mode failed to start")
+	assert.Contains(t, help.Long, "  7  Changes pending")
+}
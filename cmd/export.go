@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/remote"
+	"github.com/raucheacho/lanup/internal/secrets"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportCmd represents the export command
+type ExportCmd struct {
+	Format  string
+	Profile string
+}
+
+// ExportReport is the machine-readable state printed by 'lanup export'
+type ExportReport struct {
+	Version    string           `json:"version" yaml:"version"`
+	ExportedAt time.Time        `json:"exported_at" yaml:"exported_at"`
+	System     ExportSystem     `json:"system" yaml:"system"`
+	Project    ExportProject    `json:"project" yaml:"project"`
+	Network    ExportNetwork    `json:"network" yaml:"network"`
+	Detectors  []ExportDetector `json:"detectors" yaml:"detectors"`
+	Vars       []ExportVar      `json:"vars" yaml:"vars"`
+	Outputs    []ExportOutput   `json:"outputs" yaml:"outputs"`
+	Watcher    *state.Watcher   `json:"watcher,omitempty" yaml:"watcher,omitempty"` // running watch mode or daemon
+}
+
+// ExportSystem describes the machine
+type ExportSystem struct {
+	OS       string `json:"os" yaml:"os"`
+	Arch     string `json:"arch" yaml:"arch"`
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+}
+
+// ExportProject describes the project configuration
+type ExportProject struct {
+	Dir     string `json:"dir" yaml:"dir"`
+	Config  string `json:"config" yaml:"config"`
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+}
+
+// ExportNetwork is the IP chosen for the project among the interfaces of the machine
+type ExportNetwork struct {
+	IP         string            `json:"ip" yaml:"ip"`
+	Interface  string            `json:"interface,omitempty" yaml:"interface,omitempty"`
+	Type       string            `json:"type,omitempty" yaml:"type,omitempty"`
+	Manual     bool              `json:"manual" yaml:"manual"`     // set with ip in the configuration
+	URLHost    string            `json:"url_host" yaml:"url_host"` // host written in the URLs
+	Gateway    string            `json:"gateway,omitempty" yaml:"gateway,omitempty"`
+	Interfaces []ExportInterface `json:"interfaces" yaml:"interfaces"` // private IPv4 addresses of the interfaces that are up
+}
+
+// ExportInterface is a private address of a network interface
+type ExportInterface struct {
+	Name string `json:"name" yaml:"name"`
+	IP   string `json:"ip" yaml:"ip"`
+	Type string `json:"type" yaml:"type"` // wifi, ethernet or virtual
+}
+
+// ExportDetector is the result of a detector
+type ExportDetector struct {
+	Name     string   `json:"name" yaml:"name"`
+	Status   string   `json:"status" yaml:"status"` // found, not_running, skipped or failed
+	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Vars     []string `json:"vars,omitempty" yaml:"vars,omitempty"` // names of the variables found
+}
+
+// ExportVar is a generated variable
+type ExportVar struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`                       // the reference of secrets, never their value
+	Source string `json:"source,omitempty" yaml:"source,omitempty"` // detector that found it
+	Secret bool   `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// ExportOutput is a file lanup writes
+type ExportOutput struct {
+	Path     string     `json:"path" yaml:"path"`
+	Kind     string     `json:"kind" yaml:"kind"` // env, template, file or expo
+	Format   string     `json:"format,omitempty" yaml:"format,omitempty"`
+	Exists   bool       `json:"exists" yaml:"exists"`
+	Modified *time.Time `json:"modified,omitempty" yaml:"modified,omitempty"`
+}
+
+// NewExportCmd creates a new export command
+func NewExportCmd() *cobra.Command {
+	exportCmd := &ExportCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the current state as JSON or YAML",
+		Long: `Print everything lanup knows about the current project, for other tools or to attach
+to a bug report: the network interfaces and the IP chosen among them, the result of each
+detector, the generated variables and the files lanup writes.
+
+Nothing is written. Secrets are exported as their reference (op://...), never their value.
+
+Examples:
+  lanup export
+  lanup export --format yaml > lanup-state.yaml
+  lanup export | jq -r '.vars[] | select(.source == "docker") | .key'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportCmd.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&exportCmd.Format, "format", "json", "output format (json or yaml)")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.Flags().StringVar(&exportCmd.Profile, "profile", "", "configuration profile to use")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewExportCmd())
+}
+
+// Run executes the export command
+func (c *ExportCmd) Run() error {
+	if c.Format != "json" && c.Format != "yaml" {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid format: %s (must be json or yaml)", c.Format), nil)
+	}
+
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	report, err := buildExportReport(projectConfig, c.Profile)
+	if err != nil {
+		return err
+	}
+
+	if c.Format == "yaml" {
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(report); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to encode YAML output", err)
+		}
+		return encoder.Close()
+	}
+	return printJSON(report)
+}
+
+// buildExportReport detects the network and the services of the project, like 'lanup start --no-env'
+func buildExportReport(projectConfig *config.ProjectConfig, profile string) (*ExportReport, error) {
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+
+	found, err := collectVariables(context.Background(), projectConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	host := projectConfig.URLHost(netInfo.IP)
+	vars, _ := withCertificateVars(found.transform(projectConfig, host), projectConfig, netInfo.IP, false)
+
+	dir, _ := filepath.Abs(projectConfig.Dir())
+	configPath, _ := filepath.Abs(projectConfig.Path())
+	hostname, _ := os.Hostname()
+	report := &ExportReport{
+		Version:    Version,
+		ExportedAt: time.Now(),
+		System:     ExportSystem{OS: runtime.GOOS, Arch: runtime.GOARCH, Hostname: hostname},
+		Project:    ExportProject{Dir: dir, Config: configPath, Profile: profile},
+		Network: ExportNetwork{
+			IP:        netInfo.IP,
+			Interface: netInfo.Interface,
+			Type:      netInfo.Type,
+			Manual:    projectConfig.IP != "",
+			URLHost:   host,
+		},
+		Detectors: exportDetectors(found.Results),
+		Vars:      exportVars(projectConfig, vars),
+		Outputs:   exportOutputs(projectConfig),
+	}
+
+	interfaces, _ := net.GetAllInterfaces()
+	report.Network.Interfaces = make([]ExportInterface, 0, len(interfaces))
+	for _, iface := range interfaces {
+		report.Network.Interfaces = append(report.Network.Interfaces, ExportInterface{Name: iface.Interface, IP: iface.IP, Type: iface.Type})
+	}
+	report.Network.Gateway, _ = net.DefaultGateway()
+
+	if path, err := state.DefaultPath(); err == nil {
+		if s, err := state.Load(path); err == nil {
+			report.Watcher = s.Project(dir).ActiveWatcher()
+		}
+	}
+
+	return report, nil
+}
+
+// exportDetectors converts the results of the detectors
+func exportDetectors(results []detector.Result) []ExportDetector {
+	detectors := make([]ExportDetector, 0, len(results))
+	for _, result := range results {
+		d := ExportDetector{Name: result.Detector, Status: "found", Warnings: result.Warnings}
+		switch {
+		case result.Skipped:
+			d.Status = "skipped"
+		case errors.Is(result.Err, detector.ErrNotRunning):
+			d.Status = "not_running"
+		case result.Err != nil:
+			d.Status = "failed"
+			d.Error = result.Err.Error()
+		}
+		for _, v := range result.Vars {
+			d.Vars = append(d.Vars, v.Key)
+		}
+		detectors = append(detectors, d)
+	}
+	return detectors
+}
+
+// exportVars converts the generated variables, with the reference of the secrets instead of their value
+func exportVars(projectConfig *config.ProjectConfig, vars []env.EnvVar) []ExportVar {
+	exported := make([]ExportVar, 0, len(vars))
+	for _, v := range vars {
+		e := ExportVar{Key: v.Key, Value: v.Value, Source: v.Source}
+		for _, ref := range []string{projectConfig.Vars[v.Key], projectConfig.StaticVars[v.Key]} {
+			if secrets.IsRef(ref) {
+				e.Value, e.Secret = ref, true
+			}
+		}
+		exported = append(exported, e)
+	}
+	sort.Slice(exported, func(i, j int) bool { return exported[i].Key < exported[j].Key })
+	return exported
+}
+
+// exportOutputs lists the env file and the other files lanup writes
+func exportOutputs(projectConfig *config.ProjectConfig) []ExportOutput {
+	format := projectConfig.Format
+	if format == "" {
+		format = "dotenv"
+	}
+	outputs := []ExportOutput{newExportOutput(projectConfig.OutputPath(), "env", format)}
+	for _, t := range projectConfig.Templates {
+		outputs = append(outputs, newExportOutput(projectConfig.ProjectPath(t.Output), "template", ""))
+	}
+	for _, f := range projectConfig.Files {
+		outputs = append(outputs, newExportOutput(projectConfig.ProjectPath(f), "file", ""))
+	}
+	if projectConfig.Expo.AppJSON != "" {
+		outputs = append(outputs, newExportOutput(projectConfig.ProjectPath(projectConfig.Expo.AppJSON), "expo", ""))
+	}
+	return outputs
+}
+
+// newExportOutput describes a file with its absolute path, remote files are not checked
+func newExportOutput(path, kind, format string) ExportOutput {
+	output := ExportOutput{Path: path, Kind: kind, Format: format}
+	if remote.IsRemote(path) {
+		return output
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		output.Path = abs
+	}
+	if info, err := os.Stat(path); err == nil {
+		modified := info.ModTime()
+		output.Exists, output.Modified = true, &modified
+	}
+	return output
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildExportReport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("LANUP_TEST_PASSWORD", "hunter2")
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars: map[string]string{
+			"API_URL":     "http://localhost:8000",
+			"DB_PASSWORD": "env://LANUP_TEST_PASSWORD",
+		},
+		Output:    ".env.local",
+		IP:        "192.168.1.50",
+		Templates: []config.TemplateConfig{{Source: "app.json.tmpl", Output: "app.json"}},
+		Detectors: []config.DetectorConfig{
+			{Name: "cache", Command: "echo CACHE_URL=redis://localhost:6379"},
+			{Name: "broken", Command: "echo oops >&2; exit 3"},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+	require.NoError(t, os.WriteFile(".env.local", []byte("OTHER=1\n"), 0644))
+	projectConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+
+	report, err := buildExportReport(projectConfig, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, runtime.GOOS, report.System.OS)
+	assert.Equal(t, filepath.Join(tmpDir, ".lanup.yaml"), report.Project.Config)
+	assert.Equal(t, "192.168.1.50", report.Network.IP)
+	assert.True(t, report.Network.Manual)
+	assert.NotNil(t, report.Network.Interfaces)
+
+	detectors := make(map[string]ExportDetector)
+	for _, d := range report.Detectors {
+		detectors[d.Name] = d
+	}
+	assert.Equal(t, ExportDetector{Name: "cache", Status: "found", Vars: []string{"CACHE_URL"}}, detectors["cache"])
+	assert.Equal(t, "failed", detectors["broken"].Status)
+	assert.NotEmpty(t, detectors["broken"].Error)
+
+	// Secrets are exported as their reference
+	assert.Equal(t, []ExportVar{
+		{Key: "API_URL", Value: "http://192.168.1.50:8000"},
+		{Key: "CACHE_URL", Value: "redis://192.168.1.50:6379", Source: "cache"},
+		{Key: "DB_PASSWORD", Value: "env://LANUP_TEST_PASSWORD", Secret: true},
+	}, report.Vars)
+
+	require.Len(t, report.Outputs, 2)
+	assert.Equal(t, filepath.Join(tmpDir, ".env.local"), report.Outputs[0].Path)
+	assert.Equal(t, "dotenv", report.Outputs[0].Format)
+	assert.True(t, report.Outputs[0].Exists)
+	assert.NotNil(t, report.Outputs[0].Modified)
+	assert.Equal(t, "template", report.Outputs[1].Kind)
+	assert.False(t, report.Outputs[1].Exists)
+	assert.Nil(t, report.Watcher)
+}
+
+func TestExportCmd_Run_InvalidFormat(t *testing.T) {
+	err := (&ExportCmd{Format: "xml"}).Run()
+	assert.ErrorContains(t, err, "Invalid format: xml")
+}
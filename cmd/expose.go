@@ -1,52 +1,127 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
 	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/portmap"
+	"github.com/raucheacho/lanup/internal/proxy"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 // ExposeCmd represents the expose command
 type ExposeCmd struct {
-	URL   string
-	Name  string
-	Port  int
-	HTTPS bool
+	URLs   []string
+	Names  []string // aliases of the URLs, in the same order
+	Keys   []string // variable names of the URLs in Output, in the same order
+	Output string
+	Port   int
+	HTTPS  bool
+	TLS    bool
+	QR     bool
+	WAN    bool
+	Watch  bool
+
+	gitChecked bool // the --output file was checked with git, once per process
 }
 
+// exposedService is a localhost URL and its address on the LAN
+type exposedService struct {
+	Name       string
+	Key        string // variable written to --output
+	URL        string
+	NetworkURL string
+}
+
+// label returns the name of the service, or a generic label for unnamed ones
+func (s exposedService) label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return "URL"
+}
+
+// envKeyRe matches valid variable names
+var envKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// nonKeyCharsRe matches the characters of a service name that are not valid in variable names
+var nonKeyCharsRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// defaultExposeTLSPort is the port of the HTTPS proxy started by expose --tls when --port is not set
+const defaultExposeTLSPort = 8443
+
 // NewExposeCmd creates a new expose command
 func NewExposeCmd() *cobra.Command {
 	exposeCmd := &ExposeCmd{}
 
 	cmd := &cobra.Command{
-		Use:   "expose [URL]",
-		Short: "Quickly expose a single service without configuration",
-		Long: `Expose a single localhost URL on your local network without creating a configuration file.
+		Use:   "expose URL [URL...]",
+		Short: "Quickly expose services without configuration",
+		Long: `Expose localhost URLs on your local network without creating a configuration file.
+
+This command detects your local IP address and transforms localhost URLs to be accessible
+from any device on your network. Several URLs are shown as a single table, each --name
+naming the URL in the same position.
+
+With --tls, lanup runs an HTTPS proxy in front of the service using a locally-trusted
+certificate for the LAN IP and the machine's .local hostname, so features requiring a
+secure context (camera, service workers) work from phones. mkcert is used when installed.
 
-This command detects your local IP address and transforms a localhost URL to be accessible
-from any device on your network.
+With --wan, lanup also asks the router to forward a public port to the service with
+UPnP or NAT-PMP, prints the internet URL and removes the mapping when interrupted.
+Anyone on the internet can reach the service meanwhile, and it must listen on the LAN
+interface, not only on localhost.
+
+With --watch, lanup keeps running and prints the URLs again, and rewrites the --output
+file, whenever the network changes.
+
+With --output, the network URLs are also written to an env file as variables managed by
+lanup, named by --key or after --name (api gives API_URL). The other variables of the file
+are kept.
 
 Examples:
   lanup expose http://localhost:3000
   lanup expose http://localhost:8080 --name api
+  lanup expose http://localhost:8080 http://localhost:5173 ws://localhost:8081 --name api --name web --name ws
   lanup expose http://localhost:5000 --port 8000
-  lanup expose http://localhost:3000 --https`,
-		Args: cobra.ExactArgs(1),
+  lanup expose http://localhost:8000 --output .env.local --key VITE_API_URL
+  lanup expose http://localhost:8000 --output .env.local --key VITE_API_URL --watch
+  lanup expose http://localhost:3000 --https
+  lanup expose http://localhost:3000 --qr
+  lanup expose http://localhost:5173 --tls
+  lanup expose http://localhost:3000 --wan`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			exposeCmd.URL = args[0]
+			exposeCmd.URLs = args
 			return exposeCmd.Run()
 		},
 	}
 
 	// Add flags
-	cmd.Flags().StringVar(&exposeCmd.Name, "name", "", "assign an alias to the exposed service")
-	cmd.Flags().IntVar(&exposeCmd.Port, "port", 0, "use a custom port instead of the original")
+	cmd.Flags().StringArrayVar(&exposeCmd.Names, "name", nil, "assign an alias to the exposed service (repeat for each URL, in order)")
+	cmd.Flags().StringVarP(&exposeCmd.Output, "output", "o", "", "also write the network URLs to this env file")
+	cmd.Flags().StringArrayVar(&exposeCmd.Keys, "key", nil, "variable name of the URL in the --output file (repeat for each URL, in order)")
+	cmd.Flags().IntVar(&exposeCmd.Port, "port", 0, "use a custom port instead of the original (single URL only)")
 	cmd.Flags().BoolVar(&exposeCmd.HTTPS, "https", false, "use HTTPS protocol instead of HTTP")
+	cmd.Flags().BoolVar(&exposeCmd.TLS, "tls", false, fmt.Sprintf("serve the service over HTTPS through a local proxy (port --port or %d, single URL only)", defaultExposeTLSPort))
+	cmd.Flags().BoolVar(&exposeCmd.QR, "qr", false, "show a QR code for each exposed URL")
+	cmd.Flags().BoolVarP(&exposeCmd.Watch, "watch", "w", false, "keep running and update the URLs when the network changes")
+	cmd.Flags().BoolVar(&exposeCmd.WAN, "wan", false, "forward a port from the internet with UPnP/NAT-PMP until interrupted")
 
 	return cmd
 }
@@ -57,8 +132,8 @@ func init() {
 
 // Run executes the expose command
 func (c *ExposeCmd) Run() error {
-	// Validate the URL
-	if err := c.validateURL(); err != nil {
+	// Validate the URLs and flags
+	if err := c.validate(); err != nil {
 		return err
 	}
 
@@ -69,23 +144,438 @@ func (c *ExposeCmd) Run() error {
 			"Failed to detect local IP address", err)
 	}
 
-	// Transform the URL
-	transformedURL, err := c.transformURL(netInfo.IP)
+	if c.TLS {
+		return c.serveTLS(netInfo.IP)
+	}
+
+	// Transform the URLs
+	services, err := c.services(netInfo.IP)
 	if err != nil {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
 			"Failed to transform URL", err)
 	}
 
 	// Display the result
-	c.displayResult(netInfo.IP, transformedURL)
+	c.displayResult(netInfo.IP, services)
+	if err := c.writeOutput(services); err != nil {
+		return err
+	}
+
+	if c.WAN {
+		return c.serveWAN(netInfo.IP, services)
+	}
+
+	if err := c.printQRCodes(services); err != nil {
+		return err
+	}
+
+	if c.Watch {
+		return c.watch()
+	}
+	return nil
+}
+
+// watch prints the URLs again, and rewrites the --output file, whenever the IP changes until interrupted
+func (c *ExposeCmd) watch() error {
+	utils.Println()
+	utils.Info("Watch mode enabled - monitoring network changes...")
+	utils.Println("Press Ctrl+C to stop")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := net.NewIPWatcher(checkInterval())
+	events := watcher.Subscribe(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		if err := watcher.Start(ctx); err != nil && err != context.Canceled {
+			errCh <- err
+		}
+	}()
+	defer watcher.Stop()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-sigCh:
+			utils.Println()
+			return nil
+		case err := <-errCh:
+			return lanuperrors.NewError(lanuperrors.ErrNoNetwork, "Watcher failed", err)
+		case event := <-events:
+			// Skip the other changes, and the IP changes superseded while the previous one was handled
+			if event.Reason != net.ReasonIPChanged || event.New.IP != watcher.GetCurrentIP() {
+				continue
+			}
+			c.networkChanged(event)
+		}
+	}
+}
+
+// networkChanged shows the URLs for the new IP and rewrites the --output file
+func (c *ExposeCmd) networkChanged(event net.ChangeEvent) {
+	utils.Println()
+	utils.Warning("Network change detected!")
+	utils.Printf("  Old IP: %s\n", color.CyanString(event.Old.IP))
+	utils.Printf("  New IP: %s\n", color.CyanString(event.New.IP))
+	utils.Println()
+
+	services, err := c.services(event.New.IP)
+	if err != nil {
+		utils.Error("Failed to transform URL: %v", err)
+		return
+	}
+	c.displayResult(event.New.IP, services)
+	if err := c.writeOutput(services); err != nil {
+		utils.Error("Failed to update env file: %v", err)
+	}
+	if err := c.printQRCodes(services); err != nil {
+		utils.Error("%v", err)
+	}
+}
+
+// serveWAN forwards a public port to each service until interrupted
+func (c *ExposeCmd) serveWAN(localIP string, services []exposedService) error {
+	wanServices := make([]exposedService, 0, len(services))
+	for _, service := range services {
+		parsedURL, err := url.Parse(service.NetworkURL)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidURL, "Invalid URL", err)
+		}
+
+		mapping, release, err := openWAN(localIP, urlPort(parsedURL))
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		parsedURL.Host = mapping.Address()
+		service.NetworkURL = parsedURL.String()
+		displayWAN(mapping, service.NetworkURL)
+		wanServices = append(wanServices, service)
+	}
+
+	if err := c.printQRCodes(wanServices); err != nil {
+		return err
+	}
+	utils.Println("Press Ctrl+C to stop and remove the port mappings")
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	<-sigCh
+	utils.Println()
+
+	return nil
+}
+
+// openWAN asks the router to forward a public port to localIP:port and keeps the mapping alive
+// It returns a copy of the mapping and a function removing it.
+func openWAN(localIP string, port int) (portmap.Mapping, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Without a gateway only UPnP is tried
+	gateway, _ := net.DefaultGateway()
+	client, err := portmap.Discover(ctx, gateway, localIP)
+	if err != nil {
+		return portmap.Mapping{}, nil, lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"No router accepted port mapping requests, enable UPnP or NAT-PMP in its settings", err)
+	}
+
+	mapping, err := client.Map(ctx, localIP, port, port, portmap.DefaultLifetime)
+	if err != nil {
+		return portmap.Mapping{}, nil, lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			fmt.Sprintf("The router refused to forward port %d", port), err)
+	}
+	snapshot := *mapping
+
+	keepCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		portmap.Keep(keepCtx, client, mapping, func(err error) {
+			utils.Warning("Failed to renew the port mapping: %v", err)
+		})
+	}()
+
+	release := func() {
+		stop()
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Unmap(ctx, mapping); err != nil {
+			utils.Warning("Failed to remove the port mapping %s: %v", mapping.Address(), err)
+			return
+		}
+		utils.Info("Removed the port mapping %s", mapping.Address())
+	}
+
+	return snapshot, release, nil
+}
+
+// displayWAN shows the internet URL of a port mapping
+func displayWAN(mapping portmap.Mapping, wanURL string) {
+	if utils.IsQuiet() {
+		fmt.Println(wanURL)
+		return
+	}
+
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fmt.Printf("%s%s\n", yellow(utils.Emoji("🌍 ", "")), fmt.Sprintf("Internet URL (%s, port %d → %s:%d):",
+		mapping.Method, mapping.ExternalPort, mapping.InternalIP, mapping.InternalPort))
+	fmt.Printf("  %s\n\n", cyan(wanURL))
+
+	utils.Warning("Anyone on the internet can reach this service until lanup stops")
+	if !mapping.Public() {
+		utils.Warning("The router's external address %s is private: it is behind another NAT (often the ISP's carrier-grade NAT) and the URL is likely unreachable from the internet", mapping.ExternalIP)
+	}
+}
+
+// urlPort returns the port of u, or the default port of its scheme
+func urlPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	if u.Scheme == "https" || u.Scheme == "wss" {
+		return 443
+	}
+	return 80
+}
+
+// serveTLS terminates HTTPS on the LAN and forwards requests to the local service
+func (c *ExposeCmd) serveTLS(localIP string) error {
+	port := c.Port
+	if port == 0 {
+		port = defaultExposeTLSPort
+	}
+
+	service := exposedService{URL: c.URLs[0], Key: c.key(0)}
+	if len(c.Names) > 0 {
+		service.Name = c.Names[0]
+	}
+
+	handler, err := proxy.New([]config.RouteConfig{{Path: "/", Target: service.URL}})
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL, "Invalid URL", err)
+	}
 
+	bundle, err := lanCertificate(localIP)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to create TLS certificate", err)
+	}
+
+	parsedURL, _ := url.Parse(service.URL)
+	parsedURL.Scheme = "https"
+	parsedURL.Host = fmt.Sprintf("%s:%d", localIP, port)
+	service.NetworkURL = parsedURL.String()
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := c.writeOutput([]exposedService{service}); err != nil {
+		return err
+	}
+
+	qrService := service
+	var mapping portmap.Mapping
+	if c.WAN {
+		var release func()
+		mapping, release, err = openWAN(localIP, port)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		parsedURL.Host = mapping.Address()
+		qrService.NetworkURL = parsedURL.String()
+	}
+
+	var qrErr error
+	err = serveUntilInterrupted(server, bundle, func() {
+		c.displayResult(localIP, []exposedService{service})
+		displayCertificateHint(bundle)
+		if c.WAN {
+			displayWAN(mapping, qrService.NetworkURL)
+		}
+		qrErr = c.printQRCodes([]exposedService{qrService})
+		utils.Println("Press Ctrl+C to stop")
+	})
+	if err != nil {
+		return lanuperrors.FromOSError(fmt.Sprintf("Failed to listen on port %d", port), err)
+	}
+
+	return qrErr
+}
+
+// printQRCodes prints a QR code for each exposed URL when --qr is set
+func (c *ExposeCmd) printQRCodes(services []exposedService) error {
+	if !c.QR {
+		return nil
+	}
+
+	for _, service := range services {
+		if err := utils.PrintQRCode(service.label(), service.NetworkURL); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+				"Failed to render QR code", err)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the URLs and the flags that only apply to a single URL
+func (c *ExposeCmd) validate() error {
+	if len(c.Names) > len(c.URLs) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+			fmt.Sprintf("%d names given for %d URLs: each --name names the URL in the same position", len(c.Names), len(c.URLs)), nil)
+	}
+	if len(c.URLs) > 1 && c.Port > 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+			"--port only applies to a single URL", nil)
+	}
+	if c.Watch && (c.TLS || c.WAN) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+			"--watch doesn't apply to --tls and --wan", nil)
+	}
+	if len(c.URLs) > 1 && c.TLS {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+			"--tls only applies to a single URL, use 'lanup serve --tls' to proxy several services", nil)
+	}
+
+	for _, rawURL := range c.URLs {
+		if err := validateExposeURL(rawURL, c.TLS); err != nil {
+			return err
+		}
+	}
+	return c.validateKeys()
+}
+
+// validateKeys checks the variable names of the URLs written to --output
+func (c *ExposeCmd) validateKeys() error {
+	if c.Output == "" {
+		if len(c.Keys) > 0 {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				"--key only applies with --output", nil)
+		}
+		return nil
+	}
+	if len(c.Keys) > len(c.URLs) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("%d keys given for %d URLs: each --key names the URL in the same position", len(c.Keys), len(c.URLs)), nil)
+	}
+
+	seen := make(map[string]bool)
+	for i, rawURL := range c.URLs {
+		key := c.key(i)
+		if key == "" {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("No variable name for %s: set --key or --name for each URL written to --output", rawURL), nil)
+		}
+		if !envKeyRe.MatchString(key) {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Invalid variable name %s: use letters, digits and underscores", key), nil)
+		}
+		if seen[key] {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Variable %s is given to several URLs", key), nil)
+		}
+		seen[key] = true
+	}
 	return nil
 }
 
-// validateURL checks if the URL is valid and uses localhost or 127.0.0.1
-func (c *ExposeCmd) validateURL() error {
+// key returns the variable name of the i-th URL in --output: its --key, or one derived from its
+// --name (api gives API_URL), empty when it has neither
+func (c *ExposeCmd) key(i int) string {
+	if i < len(c.Keys) {
+		return c.Keys[i]
+	}
+	if i < len(c.Names) && c.Names[i] != "" {
+		return strings.ToUpper(strings.Trim(nonKeyCharsRe.ReplaceAllString(c.Names[i], "_"), "_")) + "_URL"
+	}
+	return ""
+}
+
+// writeOutput writes the network URLs to the --output env file as managed variables, replacing
+// the variables of the same names and keeping the others
+func (c *ExposeCmd) writeOutput(services []exposedService) error {
+	if c.Output == "" {
+		return nil
+	}
+
+	values := make(map[string]string, len(services))
+	keys := make([]string, 0, len(services))
+	for _, service := range services {
+		values[service.Key] = service.NetworkURL
+		keys = append(keys, service.Key)
+	}
+
+	// The writer of lanup start, which backs the file up to .bak and to the timestamped backups first
+	projectConfig := &config.ProjectConfig{Output: c.Output}
+	envWriter, err := newEnvWriter(projectConfig)
+	if err != nil {
+		return err
+	}
+	lock, err := lockProject(nil)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	existingVars, err := envWriter.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
+	}
+	if err := envWriter.Write(mergeExposedVars(existingVars, keys, values)); err != nil {
+		return lanuperrors.FromOSError("Failed to write env file", err)
+	}
+
+	if !c.gitChecked {
+		c.gitChecked = true
+		checkGitIgnore(projectConfig, nil)
+	}
+	utils.Success("Wrote %s to %s", strings.Join(keys, ", "), envWriter.FilePath)
+	return nil
+}
+
+// mergeExposedVars sets the managed variables keys to their values in existing: they keep their
+// place when the file already has them, managed or not, and the new ones follow
+func mergeExposedVars(existing []env.EnvVar, keys []string, values map[string]string) []env.EnvVar {
+	merged := make([]env.EnvVar, 0, len(existing)+len(keys))
+	written := make(map[string]bool, len(keys))
+	for _, v := range existing {
+		if value, ok := values[v.Key]; ok {
+			if written[v.Key] {
+				continue
+			}
+			v = env.EnvVar{Key: v.Key, Value: value, Managed: true}
+			written[v.Key] = true
+		}
+		merged = append(merged, v)
+	}
+	for _, key := range keys {
+		if !written[key] {
+			merged = append(merged, env.EnvVar{Key: key, Value: values[key], Managed: true})
+		}
+	}
+	return merged
+}
+
+// validateExposeURL checks if the URL is valid and uses localhost or 127.0.0.1
+// WebSocket URLs are accepted, except behind the HTTPS proxy of --tls.
+func validateExposeURL(rawURL string, tls bool) error {
 	// Parse the URL
-	parsedURL, err := url.Parse(c.URL)
+	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidURL, "Invalid URL format", err)
 	}
@@ -93,13 +583,20 @@ func (c *ExposeCmd) validateURL() error {
 	// Check if scheme is present
 	if parsedURL.Scheme == "" {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
-			"Invalid URL: missing protocol (http:// or https://)", nil)
+			fmt.Sprintf("Invalid URL %s: missing protocol (http:// or https://)", rawURL), nil)
 	}
 
-	// Check if scheme is http or https
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+	// Check if scheme is http, https or, without --tls, ws and wss
+	switch parsedURL.Scheme {
+	case "http", "https":
+	case "ws", "wss":
+		if tls {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+				fmt.Sprintf("Invalid URL: --tls proxies http URLs, got %s (WebSocket upgrades are proxied too)", parsedURL.Scheme), nil)
+		}
+	default:
 		return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
-			fmt.Sprintf("Invalid URL: protocol must be http or https, got %s", parsedURL.Scheme), nil)
+			fmt.Sprintf("Invalid URL: protocol must be http, https, ws or wss, got %s", parsedURL.Scheme), nil)
 	}
 
 	// Extract hostname
@@ -117,10 +614,29 @@ func (c *ExposeCmd) validateURL() error {
 	return nil
 }
 
+// services transforms the URLs and pairs them with their names
+func (c *ExposeCmd) services(localIP string) ([]exposedService, error) {
+	services := make([]exposedService, 0, len(c.URLs))
+	for i, rawURL := range c.URLs {
+		service := exposedService{URL: rawURL, Key: c.key(i)}
+		if i < len(c.Names) {
+			service.Name = c.Names[i]
+		}
+
+		networkURL, err := c.transformURL(rawURL, localIP)
+		if err != nil {
+			return nil, err
+		}
+		service.NetworkURL = networkURL
+		services = append(services, service)
+	}
+	return services, nil
+}
+
 // transformURL replaces localhost/127.0.0.1 with the detected IP and applies custom settings
-func (c *ExposeCmd) transformURL(localIP string) (string, error) {
+func (c *ExposeCmd) transformURL(rawURL, localIP string) (string, error) {
 	// Parse the original URL
-	parsedURL, err := url.Parse(c.URL)
+	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return "", err
 	}
@@ -134,33 +650,64 @@ func (c *ExposeCmd) transformURL(localIP string) (string, error) {
 		parsedURL.Host = fmt.Sprintf("%s:%d", localIP, c.Port)
 	}
 
-	// Apply HTTPS if specified
+	// Apply HTTPS if specified, WebSocket URLs become secure WebSocket ones
 	if c.HTTPS {
-		parsedURL.Scheme = "https"
+		switch parsedURL.Scheme {
+		case "http":
+			parsedURL.Scheme = "https"
+		case "ws":
+			parsedURL.Scheme = "wss"
+		}
 	}
 
 	return parsedURL.String(), nil
 }
 
-// displayResult shows the transformed URL in a user-friendly format
-func (c *ExposeCmd) displayResult(localIP, transformedURL string) {
+// displayResult shows the transformed URLs in a user-friendly format, as a table for several URLs
+func (c *ExposeCmd) displayResult(localIP string, services []exposedService) {
+	// Scripts only need the URLs
+	if utils.IsQuiet() {
+		for _, service := range services {
+			fmt.Println(service.NetworkURL)
+		}
+		return
+	}
+
 	green := color.New(color.FgGreen).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 	bold := color.New(color.Bold).SprintFunc()
 
-	fmt.Printf("%s %s\n", green("✓"), "Successfully exposed service on your LAN!")
-	fmt.Printf("%s %s\n\n", green("✓"), "Local IP: "+cyan(localIP))
-
-	if c.Name != "" {
-		fmt.Printf("%s %s\n", yellow("📌"), "Service name: "+bold(c.Name))
+	check := green(utils.Symbol("✓", "*"))
+	if len(services) > 1 {
+		fmt.Printf("%s Successfully exposed %d services on your LAN!\n", check, len(services))
+		fmt.Printf("%s %s\n\n", check, "Local IP: "+cyan(localIP))
+
+		table := utils.NewTable("NAME", "ORIGINAL URL", "NETWORK URL")
+		for _, service := range services {
+			name := service.Name
+			if name == "" {
+				name = "-"
+			}
+			table.AddRow(name, service.URL, cyan(service.NetworkURL))
+		}
+		table.Print()
+		fmt.Println()
+	} else {
+		service := services[0]
+		fmt.Printf("%s %s\n", check, "Successfully exposed service on your LAN!")
+		fmt.Printf("%s %s\n\n", check, "Local IP: "+cyan(localIP))
+
+		if service.Name != "" {
+			fmt.Printf("%s%s\n", yellow(utils.Emoji("📌 ", "")), "Service name: "+bold(service.Name))
+		}
+
+		fmt.Printf("%s%s\n", yellow(utils.Emoji("🌐 ", "")), "Original URL:")
+		fmt.Printf("  %s\n\n", service.URL)
+
+		fmt.Printf("%s%s\n", yellow(utils.Emoji("🌐 ", "")), "Network URL:")
+		fmt.Printf("  %s\n\n", cyan(service.NetworkURL))
 	}
 
-	fmt.Printf("%s %s\n", yellow("🌐"), "Original URL:")
-	fmt.Printf("  %s\n\n", c.URL)
-
-	fmt.Printf("%s %s\n", yellow("🌐"), "Network URL:")
-	fmt.Printf("  %s\n\n", cyan(transformedURL))
-
-	fmt.Println("💡 Tip: Use 'lanup init' to configure multiple services in your project")
+	fmt.Printf("%sTip: Use 'lanup init' to configure multiple services in your project\n", utils.Emoji("💡 ", ""))
 }
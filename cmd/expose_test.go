@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExposeCmd_Services(t *testing.T) {
+	c := &ExposeCmd{
+		URLs:  []string{"http://localhost:8080", "http://127.0.0.1:5173/app", "ws://localhost:8081"},
+		Names: []string{"api", "web"},
+	}
+	require.NoError(t, c.validate())
+
+	services, err := c.services("192.168.1.100")
+	require.NoError(t, err)
+
+	assert.Equal(t, []exposedService{
+		{Name: "api", Key: "API_URL", URL: "http://localhost:8080", NetworkURL: "http://192.168.1.100:8080"},
+		{Name: "web", Key: "WEB_URL", URL: "http://127.0.0.1:5173/app", NetworkURL: "http://192.168.1.100:5173/app"},
+		{URL: "ws://localhost:8081", NetworkURL: "ws://192.168.1.100:8081"},
+	}, services)
+	assert.Equal(t, "URL", services[2].label())
+}
+
+func TestExposeCmd_Services_HTTPS(t *testing.T) {
+	c := &ExposeCmd{URLs: []string{"http://localhost:8080", "ws://localhost:8081"}, HTTPS: true}
+
+	services, err := c.services("192.168.1.100")
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://192.168.1.100:8080", services[0].NetworkURL)
+	assert.Equal(t, "wss://192.168.1.100:8081", services[1].NetworkURL)
+}
+
+func TestExposeCmd_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  ExposeCmd
+	}{
+		{"more names than URLs", ExposeCmd{URLs: []string{"http://localhost:3000"}, Names: []string{"a", "b"}}},
+		{"port with several URLs", ExposeCmd{URLs: []string{"http://localhost:3000", "http://localhost:4000"}, Port: 8000}},
+		{"tls with several URLs", ExposeCmd{URLs: []string{"http://localhost:3000", "http://localhost:4000"}, TLS: true}},
+		{"tls with a WebSocket URL", ExposeCmd{URLs: []string{"ws://localhost:3000"}, TLS: true}},
+		{"remote host", ExposeCmd{URLs: []string{"http://localhost:3000", "http://example.com"}}},
+		{"unsupported protocol", ExposeCmd{URLs: []string{"ftp://localhost:21"}}},
+		{"watch with tls", ExposeCmd{URLs: []string{"http://localhost:3000"}, Watch: true, TLS: true}},
+		{"watch with wan", ExposeCmd{URLs: []string{"http://localhost:3000"}, Watch: true, WAN: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.validate()
+			assert.ErrorIs(t, err, lanuperrors.ErrInvalidURL)
+		})
+	}
+}
+
+func TestExposeCmd_Key(t *testing.T) {
+	c := &ExposeCmd{
+		URLs:  []string{"http://localhost:8000", "http://localhost:5173", "ws://localhost:8081", "http://localhost:9000"},
+		Names: []string{"api", "my-web", "ws"},
+		Keys:  []string{"VITE_API_URL"},
+	}
+
+	assert.Equal(t, "VITE_API_URL", c.key(0))
+	assert.Equal(t, "MY_WEB_URL", c.key(1))
+	assert.Equal(t, "WS_URL", c.key(2))
+	assert.Equal(t, "", c.key(3))
+}
+
+func TestExposeCmd_ValidateKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  ExposeCmd
+	}{
+		{"key without output", ExposeCmd{URLs: []string{"http://localhost:3000"}, Keys: []string{"API_URL"}}},
+		{"more keys than URLs", ExposeCmd{URLs: []string{"http://localhost:3000"}, Keys: []string{"A", "B"}, Output: ".env"}},
+		{"no key nor name", ExposeCmd{URLs: []string{"http://localhost:3000"}, Output: ".env"}},
+		{"invalid key", ExposeCmd{URLs: []string{"http://localhost:3000"}, Keys: []string{"API-URL"}, Output: ".env"}},
+		{"duplicate key", ExposeCmd{URLs: []string{"http://localhost:3000", "http://localhost:4000"}, Names: []string{"api", "api"}, Output: ".env"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.validate()
+			assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+		})
+	}
+
+	valid := ExposeCmd{URLs: []string{"http://localhost:3000", "http://localhost:4000"}, Names: []string{"api", "web"}, Output: ".env"}
+	assert.NoError(t, valid.validate())
+}
+
+func TestMergeExposedVars(t *testing.T) {
+	existing := []env.EnvVar{
+		{Key: "API_URL", Value: "http://localhost:8000"},
+		{Key: "DB_URL", Value: "postgresql://192.168.1.50:5432", Managed: true},
+		{Key: "TOKEN", Value: "secret"},
+	}
+
+	merged := mergeExposedVars(existing, []string{"API_URL", "WEB_URL"}, map[string]string{
+		"API_URL": "http://192.168.1.100:8000",
+		"WEB_URL": "http://192.168.1.100:5173",
+	})
+
+	assert.Equal(t, []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000", Managed: true},
+		{Key: "DB_URL", Value: "postgresql://192.168.1.50:5432", Managed: true},
+		{Key: "TOKEN", Value: "secret"},
+		{Key: "WEB_URL", Value: "http://192.168.1.100:5173", Managed: true},
+	}, merged)
+}
+
+func TestExposeCmd_WriteOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile(".env.local", []byte("TOKEN=secret\n"), 0644))
+
+	c := &ExposeCmd{URLs: []string{"http://localhost:8000"}, Keys: []string{"VITE_API_URL"}, Output: ".env.local"}
+	require.NoError(t, c.validate())
+	services, err := c.services("192.168.1.100")
+	require.NoError(t, err)
+	require.NoError(t, c.writeOutput(services))
+
+	vars, err := env.NewEnvWriter(filepath.Join(tmpDir, ".env.local")).Read()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []env.EnvVar{
+		{Key: "VITE_API_URL", Value: "http://192.168.1.100:8000", Managed: true},
+		{Key: "TOKEN", Value: "secret"},
+	}, vars)
+
+	// The previous file is backed up like lanup start does
+	backup, err := os.ReadFile(".env.local.bak")
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN=secret\n", string(backup))
+}
+
+func TestExposeCmd_NetworkChanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	c := &ExposeCmd{URLs: []string{"http://localhost:8000"}, Names: []string{"api"}, Output: ".env", Watch: true}
+	require.NoError(t, c.validate())
+	services, err := c.services("192.168.1.100")
+	require.NoError(t, err)
+	require.NoError(t, c.writeOutput(services))
+
+	c.networkChanged(net.ChangeEvent{
+		Old:    net.NetworkInfo{IP: "192.168.1.100"},
+		New:    net.NetworkInfo{IP: "10.0.0.5"},
+		Reason: net.ReasonIPChanged,
+	})
+
+	vars, err := env.NewEnvWriter(filepath.Join(tmpDir, ".env")).Read()
+	require.NoError(t, err)
+	assert.Equal(t, []env.EnvVar{{Key: "API_URL", Value: "http://10.0.0.5:8000", Managed: true}}, vars)
+}
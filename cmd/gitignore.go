@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/git"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/remote"
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// checkGitIgnore warns when git may commit the env file or its backup, whose LAN-specific URLs only
+// work on this machine, and adds them to .gitignore when the project sets gitignore
+// Files written over SSH are not checked.
+func checkGitIgnore(projectConfig *config.ProjectConfig, log *logger.Logger) {
+	output := projectConfig.OutputPath()
+	if remote.IsRemote(output) {
+		return
+	}
+
+	paths := []string{output}
+	if _, err := os.Stat(output + ".bak"); err == nil || projectConfig.GitIgnore {
+		paths = append(paths, output+".bak")
+	}
+
+	var root string
+	var tracked, unignored []*git.FileStatus
+	for _, path := range paths {
+		status, err := git.Check(path)
+		if err != nil {
+			if !errors.Is(err, git.ErrNotRepo) && log != nil {
+				log.Debug("Failed to check the env file with git", logger.Field{Key: "error", Value: err.Error()})
+			}
+			return
+		}
+		root = status.Root
+		switch {
+		case status.Tracked:
+			tracked = append(tracked, status)
+		case !status.Ignored:
+			unignored = append(unignored, status)
+		}
+	}
+
+	if len(unignored) > 0 {
+		entries := make([]string, len(unignored))
+		for i, status := range unignored {
+			entries[i] = status.Entry
+		}
+		names := strings.Join(entries, ", ")
+
+		if projectConfig.GitIgnore {
+			if err := git.Ignore(root, entries); err != nil {
+				utils.Warning("Failed to update .gitignore: %v", err)
+			} else {
+				utils.Info("Added %s to .gitignore", names)
+			}
+		} else {
+			utils.Warning("Not ignored by git: %s. Env files hold LAN-specific URLs, add them to .gitignore or set gitignore: true in .lanup.yaml", names)
+		}
+	}
+
+	for _, status := range tracked {
+		utils.Warning("%s is tracked by git, your LAN IP would be committed: run 'git rm --cached %s' and ignore it", status.Entry, status.Path)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/history"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// HistoryCmd represents the history command
+type HistoryCmd struct {
+	Limit int
+	Clear bool
+}
+
+// NewHistoryCmd creates a new history command
+func NewHistoryCmd() *cobra.Command {
+	historyCmd := &HistoryCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the history of IP address changes",
+		Long: `Show every change of the detected IP address recorded by lanup start, watch mode,
+the daemon and lanup run, with the interface and the command that noticed it.
+
+Frequent changes point to a flaky router or DHCP lease; the shortest time between
+changes helps choosing a sensible check_interval.
+
+Examples:
+  lanup history
+  lanup history --limit 50
+  lanup history --clear`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return historyCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().IntVarP(&historyCmd.Limit, "limit", "n", 20, "number of changes to show, 0 for all")
+	cmd.Flags().BoolVar(&historyCmd.Clear, "clear", false, "delete the recorded history")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewHistoryCmd())
+}
+
+// Run executes the history command
+func (c *HistoryCmd) Run() error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			"Failed to determine history file", err)
+	}
+
+	if c.Clear {
+		if err := history.Clear(path); err != nil {
+			return lanuperrors.FromOSError("Failed to clear history", err)
+		}
+		utils.Success("History cleared")
+		return nil
+	}
+
+	entries, err := history.Read(path)
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read history", err)
+	}
+	if len(entries) == 0 {
+		utils.Info("No IP changes recorded yet")
+		return nil
+	}
+
+	shown := entries
+	if c.Limit > 0 && len(shown) > c.Limit {
+		shown = shown[len(shown)-c.Limit:]
+	}
+	displayHistory(shown)
+
+	utils.Println()
+	utils.Info("%d change(s) recorded since %s", len(entries), entries[0].Time.Local().Format("2006-01-02 15:04"))
+	if shortest, ok := shortestChangeInterval(entries); ok {
+		utils.Info("Shortest time between changes: %s", shortest.Round(time.Second))
+	}
+
+	return nil
+}
+
+// displayHistory prints the entries as a table, oldest first
+func displayHistory(entries []history.Entry) {
+	table := utils.NewTable("TIME", "OLD IP", "NEW IP", "INTERFACE", "TRIGGER", "PROJECT")
+
+	for _, e := range entries {
+		table.AddRow(e.Time.Local().Format("2006-01-02 15:04:05"),
+			valueOrDash(e.OldIP), e.NewIP, valueOrDash(e.Interface), e.Trigger, valueOrDash(e.Project))
+	}
+	table.Print()
+}
+
+// shortestChangeInterval returns the shortest time between two consecutive changes
+func shortestChangeInterval(entries []history.Entry) (time.Duration, bool) {
+	var shortest time.Duration
+	found := false
+	for i := 1; i < len(entries); i++ {
+		if entries[i].OldIP == "" {
+			// First detection, not a change
+			continue
+		}
+		d := entries[i].Time.Sub(entries[i-1].Time)
+		if !found || d < shortest {
+			shortest = d
+			found = true
+		}
+	}
+	return shortest, found
+}
+
+// valueOrDash returns "-" for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// recordIPChange adds the detected IP to the history when it differs from the last recorded one
+// Failures are only logged: the history must never prevent lanup from working
+func recordIPChange(trigger string, projectConfig *config.ProjectConfig, netInfo *net.NetworkInfo, log *logger.Logger) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return
+	}
+
+	last, err := history.Last(path)
+	if err == nil && last != nil && last.NewIP == netInfo.IP {
+		return
+	}
+
+	entry := history.Entry{
+		Time:      time.Now(),
+		NewIP:     netInfo.IP,
+		Interface: netInfo.Interface,
+		Trigger:   trigger,
+	}
+	if last != nil {
+		entry.OldIP = last.NewIP
+	}
+	// The directory of .lanup.yaml, lanup may run from a subdirectory of the project
+	entry.Project, _ = filepath.Abs(projectConfig.Dir())
+
+	if err := history.Append(path, entry); err != nil && log != nil {
+		log.With("module", "history").Warn("Failed to record IP change", logger.Field{Key: "error", Value: err.Error()})
+	}
+}
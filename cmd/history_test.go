@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/history"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordIPChange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := history.DefaultPath()
+	require.NoError(t, err)
+
+	projectDir := t.TempDir()
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(projectDir, ".lanup.yaml"), config.GetDefaultProjectConfig()))
+	subDir := filepath.Join(projectDir, "src")
+	require.NoError(t, os.Mkdir(subDir, 0755))
+
+	// The project is the directory of .lanup.yaml, not the directory lanup runs from
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(subDir))
+	projectConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+
+	recordIPChange("start", projectConfig, &net.NetworkInfo{IP: "192.168.1.10", Interface: "en0"}, nil)
+	recordIPChange("watch", projectConfig, &net.NetworkInfo{IP: "192.168.1.10", Interface: "en0"}, nil)
+	recordIPChange("watch", projectConfig, &net.NetworkInfo{IP: "10.0.0.5", Interface: "en1"}, nil)
+
+	// Detecting the same IP again is not a change
+	entries, err := history.Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "", entries[0].OldIP)
+	assert.Equal(t, "192.168.1.10", entries[0].NewIP)
+	assert.Equal(t, "start", entries[0].Trigger)
+	assert.Equal(t, projectDir, entries[0].Project)
+
+	assert.Equal(t, "192.168.1.10", entries[1].OldIP)
+	assert.Equal(t, "10.0.0.5", entries[1].NewIP)
+	assert.Equal(t, "en1", entries[1].Interface)
+	assert.Equal(t, "watch", entries[1].Trigger)
+}
+
+func TestShortestChangeInterval(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	_, ok := shortestChangeInterval([]history.Entry{{Time: start, NewIP: "192.168.1.10"}})
+	assert.False(t, ok)
+
+	shortest, ok := shortestChangeInterval([]history.Entry{
+		{Time: start, NewIP: "192.168.1.10"},
+		{Time: start.Add(2 * time.Hour), OldIP: "192.168.1.10", NewIP: "192.168.1.11"},
+		{Time: start.Add(2*time.Hour + 10*time.Minute), OldIP: "192.168.1.11", NewIP: "192.168.1.12"},
+	})
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Minute, shortest)
+}
+
+func TestHistoryCmd_Run(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := history.DefaultPath()
+	require.NoError(t, err)
+
+	// An empty history is not an error
+	require.NoError(t, (&HistoryCmd{Limit: 20}).Run())
+
+	recordIPChange("start", config.GetDefaultProjectConfig(), &net.NetworkInfo{IP: "192.168.1.10"}, nil)
+	require.NoError(t, (&HistoryCmd{Limit: 20}).Run())
+
+	require.NoError(t, (&HistoryCmd{Clear: true}).Run())
+	entries, err := history.Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/notify"
+	"github.com/raucheacho/lanup/internal/plugin"
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// pendingNotifications counts the notifications being posted in the background, see waitNotifications
+var pendingNotifications sync.WaitGroup
+
+// runHooks runs the commands of a lifecycle event, notifies the notifier plugins and posts
+// the notifications, reporting the failures as warnings
+// Hooks never make the command fail: the env file is already written when they run.
+// Notifications are posted in the background, so that a slow webhook doesn't delay watch mode.
+func runHooks(event hooks.Event, projectConfig *config.ProjectConfig, vars []env.EnvVar, info hooks.Info, log *logger.Logger) {
+	commands := hookCommands(event, projectConfig.Hooks)
+	notifiers := projectConfig.Plugins.Notifiers
+	var notifications []config.NotificationConfig
+	for _, n := range projectConfig.Notifications {
+		if n.Posts(string(event)) {
+			notifications = append(notifications, n)
+		}
+	}
+	if len(commands) == 0 && len(notifiers) == 0 && len(notifications) == 0 {
+		return
+	}
+	project, _ := filepath.Abs(projectConfig.Dir())
+
+	log = log.With("module", "hooks")
+	if len(commands) > 0 {
+		if log != nil {
+			log.Info("Running hooks",
+				logger.Field{Key: "event", Value: string(event)},
+				logger.Field{Key: "count", Value: len(commands)})
+		}
+		utils.Info("Running %d %s hook(s)", len(commands), event)
+		runner := hooks.NewRunner()
+		runner.Stdout, runner.Stderr = utils.Stdout, utils.Stderr
+		if err := runner.Run(context.Background(), event, commands, vars, info); err != nil {
+			hookWarning(log, "Hook failed", err)
+		}
+	}
+
+	if len(notifiers) > 0 {
+		if log != nil {
+			log.Info("Notifying plugins",
+				logger.Field{Key: "event", Value: string(event)},
+				logger.Field{Key: "count", Value: len(notifiers)})
+		}
+		err := plugin.Notify(context.Background(), notifiers, plugin.Request{
+			Project: project,
+			Event:   string(event),
+			Vars:    plugin.ToVars(vars),
+			IP:      info.IP,
+			OldIP:   info.OldIP,
+			Output:  info.Output,
+		})
+		if err != nil {
+			hookWarning(log, "Notifier plugin failed", err)
+		}
+	}
+
+	if len(notifications) > 0 {
+		if log != nil {
+			log.Info("Posting notifications",
+				logger.Field{Key: "event", Value: string(event)},
+				logger.Field{Key: "count", Value: len(notifications)})
+		}
+		e := notify.Event{
+			Name:    string(event),
+			Project: filepath.Base(project),
+			IP:      info.IP,
+			OldIP:   info.OldIP,
+			Vars:    withoutSecrets(projectConfig, vars),
+		}
+		pendingNotifications.Add(1)
+		go func() {
+			defer pendingNotifications.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), notify.DefaultTimeout)
+			defer cancel()
+			if err := notify.NewSender().Send(ctx, notifications, e); err != nil {
+				hookWarning(log, "Notification failed", err)
+			}
+		}()
+	}
+}
+
+// waitNotifications waits for the notifications posted in the background before lanup exits
+// Each post gives up after notify.DefaultTimeout, so the wait is bounded.
+func waitNotifications() {
+	pendingNotifications.Wait()
+}
+
+// hookCommands returns the hook commands of an event
+func hookCommands(event hooks.Event, cfg config.HooksConfig) []string {
+	switch event {
+	case hooks.Start:
+		return cfg.OnStart
+	case hooks.Change:
+		return cfg.OnChange
+	case hooks.Stop:
+		return cfg.OnStop
+	default:
+		return nil
+	}
+}
+
+// hookWarning logs and prints the failure of a hook or notifier
+func hookWarning(log *logger.Logger, msg string, err error) {
+	if log != nil {
+		log.Warn(msg, logger.Field{Key: "error", Value: err.Error()})
+	}
+	fmt.Fprintf(utils.Stderr, "%sWarning: %v\n", utils.Emoji("⚠️  ", ""), err)
+}
+
+// runHooks runs the hooks of an event with the variables of the last run, unless no env file was written
+func (c *StartCmd) runHooks(event hooks.Event, projectConfig *config.ProjectConfig, oldIP string) {
+	if c.NoEnv || c.DryRun {
+		return
+	}
+	state := c.currentState()
+	info := hooks.Info{IP: state.IP, OldIP: oldIP, Output: state.Output}
+	runHooks(event, projectConfig, c.currentVars(), info, c.logger)
+}
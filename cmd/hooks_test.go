@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHooks_ProjectName(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	projectDir := filepath.Join(tmpDir, "shop")
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "web"), 0755))
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	posted := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Project string `json:"project"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		posted <- payload.Project
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(projectDir, ".lanup.yaml"), &config.ProjectConfig{
+		Output:        ".env.local",
+		Notifications: []config.NotificationConfig{{URL: server.URL, Type: config.NotificationWebhook}},
+	}))
+
+	// Run from a subdirectory, the project is still named after the directory of .lanup.yaml
+	require.NoError(t, os.Chdir(filepath.Join(projectDir, "web")))
+	projectConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+
+	runHooks(hooks.Change, projectConfig, []env.EnvVar{{Key: "API_URL", Value: "http://192.168.1.42:8000", Managed: true}},
+		hooks.Info{IP: "192.168.1.42"}, nil)
+	assert.Equal(t, "shop", <-posted)
+	waitNotifications()
+}
+
+func TestRunHooks_NotificationsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	projectConfig := &config.ProjectConfig{
+		Output:        ".env.local",
+		Notifications: []config.NotificationConfig{{URL: server.URL, Type: config.NotificationWebhook}},
+	}
+
+	// A slow webhook doesn't hold the caller, lanup waits for it before exiting
+	returned := make(chan struct{})
+	go func() {
+		runHooks(hooks.Change, projectConfig, nil, hooks.Info{IP: "192.168.1.42"}, nil)
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runHooks waited for the webhook")
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		waitNotifications()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("waitNotifications returned before the webhook answered")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-waited
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/hosts"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// HostsCmd represents the hosts command
+type HostsCmd struct {
+	File string
+	IP   string
+	Save bool
+}
+
+// NewHostsCmd creates a new hosts command
+func NewHostsCmd() *cobra.Command {
+	hostsCmd := &HostsCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "hosts",
+		Short: "Manage hosts file entries pointing to your LAN IP",
+		Long: `Map a friendly hostname such as myapp.lan to your detected LAN IP in the hosts file.
+
+When 'hostname' is set in .lanup.yaml, 'lanup start' generates URLs with that hostname
+instead of the IP, and keeps the hosts entry in sync when your IP changes.
+Editing the hosts file requires administrator privileges (sudo).
+
+Examples:
+  sudo lanup hosts add myapp.lan --save
+  sudo lanup hosts remove myapp.lan
+  lanup hosts list`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add [HOSTNAME]",
+		Short: "Map a hostname to your LAN IP",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return hostsCmd.Add(args)
+		},
+	}
+	addCmd.Flags().StringVar(&hostsCmd.IP, "ip", "", "IP address to map (default: detected LAN IP)")
+	addCmd.Flags().BoolVar(&hostsCmd.Save, "save", false, "store the hostname in .lanup.yaml so URLs use it")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove [HOSTNAME]",
+		Short: "Remove a hostname added by lanup",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return hostsCmd.Remove(args)
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List hostnames added by lanup",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return hostsCmd.List()
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&hostsCmd.File, "file", hosts.DefaultPath(), "hosts file to edit")
+	cmd.AddCommand(addCmd, removeCmd, listCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewHostsCmd())
+}
+
+// Add maps the hostname to the LAN IP
+func (c *HostsCmd) Add(args []string) error {
+	hostname, err := resolveHostname(args)
+	if err != nil {
+		return err
+	}
+
+	ip := c.IP
+	if ip == "" {
+		netInfo, err := net.DetectLocalIP()
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+				"Failed to detect local IP address", err)
+		}
+		ip = netInfo.IP
+	}
+
+	if entry, ok := c.lookup(hostname); ok && !entry.Managed {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("%s is already mapped to %s by an entry lanup does not manage", hostname, entry.IP), nil)
+	}
+
+	err = hosts.Update(c.File, func(content string) string {
+		return hosts.Set(content, hostname, ip)
+	})
+	if err != nil {
+		return hostsFileError(c.File, err)
+	}
+
+	utils.Success("Mapped %s to %s in %s", hostname, ip, c.File)
+
+	if c.Save {
+		if err := saveProjectHostname(hostname); err != nil {
+			return err
+		}
+		utils.Success("Saved hostname in .lanup.yaml, 'lanup start' will now use it in URLs")
+	}
+
+	displayDeviceInstructions(hostname, ip)
+
+	return nil
+}
+
+// Remove deletes the lanup entry for the hostname
+func (c *HostsCmd) Remove(args []string) error {
+	hostname, err := resolveHostname(args)
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	err = hosts.Update(c.File, func(content string) string {
+		content, removed = hosts.Remove(content, hostname)
+		return content
+	})
+	if err != nil {
+		return hostsFileError(c.File, err)
+	}
+
+	if !removed {
+		utils.Info("No lanup entry for %s in %s", hostname, c.File)
+		return nil
+	}
+
+	utils.Success("Removed %s from %s", hostname, c.File)
+	return nil
+}
+
+// List shows the entries managed by lanup
+func (c *HostsCmd) List() error {
+	data, err := os.ReadFile(c.File)
+	if err != nil {
+		return hostsFileError(c.File, err)
+	}
+
+	entries := hosts.Managed(string(data))
+	if len(entries) == 0 {
+		utils.Info("No hostnames managed by lanup in %s", c.File)
+		return nil
+	}
+
+	utils.PrintSection("Hostnames managed by lanup")
+	for _, entry := range entries {
+		fmt.Printf("  %s %s %s\n", color.CyanString(entry.Hostname), utils.Symbol("→", "->"), entry.IP)
+	}
+
+	return nil
+}
+
+// lookup returns the entry of the hosts file for hostname
+func (c *HostsCmd) lookup(hostname string) (hosts.Entry, bool) {
+	data, err := os.ReadFile(c.File)
+	if err != nil {
+		return hosts.Entry{}, false
+	}
+	return hosts.Lookup(string(data), hostname)
+}
+
+// resolveHostname returns the hostname argument, or the one from .lanup.yaml
+func resolveHostname(args []string) (string, error) {
+	hostname := ""
+	if len(args) > 0 {
+		hostname = args[0]
+	} else if projectConfig, err := config.LoadProjectConfig(""); err == nil {
+		hostname = projectConfig.Hostname
+	}
+
+	if hostname == "" {
+		return "", lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"No hostname given and no 'hostname' set in .lanup.yaml", nil)
+	}
+	if !hosts.ValidHostname(hostname) {
+		return "", lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid hostname: %s", hostname), nil)
+	}
+
+	return hostname, nil
+}
+
+// saveProjectHostname stores the hostname in the project configuration
+func saveProjectHostname(hostname string) error {
+	path, err := config.FindProjectConfig(".")
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration (run 'lanup init' first)", err)
+	}
+
+	if err := config.UpdateProjectConfig(path, func(projectConfig *config.ProjectConfig) {
+		projectConfig.Hostname = hostname
+	}); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to save project configuration", err)
+	}
+
+	return nil
+}
+
+// hostsFileError converts a hosts file access error to a lanup error
+func hostsFileError(path string, err error) error {
+	lanupErr := lanuperrors.FromOSError(fmt.Sprintf("Failed to update %s", path), err)
+	switch lanupErr.Code {
+	case lanuperrors.ErrPermissionDenied:
+		lanupErr.Message = fmt.Sprintf("Permission denied writing %s (try again with sudo)", path)
+	case lanuperrors.ErrFileNotFound:
+		lanupErr.Message = fmt.Sprintf("Hosts file not found: %s", path)
+	}
+	return lanupErr
+}
+
+// displayDeviceInstructions explains how to resolve the hostname from other devices
+func displayDeviceInstructions(hostname, ip string) {
+	line := hosts.Entry{IP: ip, Hostname: hostname}.Line()
+
+	utils.PrintSection("Use the same hostname on other devices")
+	fmt.Println("  macOS / Linux:")
+	fmt.Printf("    echo '%s' | sudo tee -a /etc/hosts\n", line)
+	fmt.Println("  Windows (PowerShell as administrator):")
+	fmt.Printf("    Add-Content $env:SystemRoot\\System32\\drivers\\etc\\hosts \"%s\"\n", line)
+	fmt.Println("  Phones and tablets cannot edit their hosts file without root access:")
+	fmt.Printf("    add %s %s %s to your router's local DNS, or use the IP URLs\n", hostname, utils.Symbol("→", "->"), ip)
+	fmt.Println()
+}
+
+// ensureHostsEntry checks that the hosts file at path maps hostname to ip
+// A stale lanup entry is rewritten when update is true. It reports whether the file changed.
+func ensureHostsEntry(path, hostname, ip string, update bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	entry, ok := hosts.Lookup(string(data), hostname)
+	switch {
+	case !ok:
+		return false, fmt.Errorf("%s is not in %s, run 'sudo lanup hosts add %s'", hostname, path, hostname)
+	case entry.IP == ip:
+		return false, nil
+	case !entry.Managed:
+		return false, fmt.Errorf("%s maps %s to %s instead of %s", path, hostname, entry.IP, ip)
+	case !update:
+		return false, fmt.Errorf("%s maps %s to the previous IP %s, run 'sudo lanup hosts add %s'", path, hostname, entry.IP, hostname)
+	}
+
+	err = hosts.Update(path, func(content string) string {
+		return hosts.Set(content, hostname, ip)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update %s for %s (run 'sudo lanup hosts add %s'): %w", path, hostname, hostname, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/hosts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeHostsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func readHostsEntry(t *testing.T, path, hostname string) (hosts.Entry, bool) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return hosts.Lookup(string(data), hostname)
+}
+
+func TestHostsCmd_AddAndRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, config.SaveProjectConfig("", config.GetDefaultProjectConfig()))
+
+	path := writeHostsFile(t, "127.0.0.1 localhost\n")
+	cmd := &HostsCmd{File: path, IP: "192.168.1.10", Save: true}
+
+	require.NoError(t, cmd.Add([]string{"myapp.lan"}))
+	entry, ok := readHostsEntry(t, path, "myapp.lan")
+	require.True(t, ok)
+	assert.Equal(t, "192.168.1.10", entry.IP)
+	assert.True(t, entry.Managed)
+
+	projectConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, "myapp.lan", projectConfig.Hostname)
+
+	// Without argument the hostname comes from .lanup.yaml
+	require.NoError(t, cmd.Remove(nil))
+	_, ok = readHostsEntry(t, path, "myapp.lan")
+	assert.False(t, ok)
+}
+
+func TestHostsCmd_AddRefusesUnmanagedEntry(t *testing.T) {
+	path := writeHostsFile(t, "10.0.0.1 myapp.lan\n")
+	cmd := &HostsCmd{File: path, IP: "192.168.1.10"}
+
+	assert.Error(t, cmd.Add([]string{"myapp.lan"}))
+	assert.Error(t, cmd.Add([]string{"not a hostname"}))
+}
+
+func TestEnsureHostsEntry(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		update      bool
+		wantUpdated bool
+		wantErr     bool
+		wantIP      string
+	}{
+		{name: "up to date", content: "192.168.1.10\tmyapp.lan # lanup\n", update: true, wantIP: "192.168.1.10"},
+		{name: "stale entry updated", content: "192.168.1.5\tmyapp.lan # lanup\n", update: true, wantUpdated: true, wantIP: "192.168.1.10"},
+		{name: "stale entry in dry run", content: "192.168.1.5\tmyapp.lan # lanup\n", update: false, wantErr: true, wantIP: "192.168.1.5"},
+		{name: "unmanaged entry", content: "192.168.1.5 myapp.lan\n", update: true, wantErr: true, wantIP: "192.168.1.5"},
+		{name: "missing entry", content: "127.0.0.1 localhost\n", update: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeHostsFile(t, tt.content)
+
+			updated, err := ensureHostsEntry(path, "myapp.lan", "192.168.1.10", tt.update)
+			assert.Equal(t, tt.wantUpdated, updated)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			entry, _ := readHostsEntry(t, path, "myapp.lan")
+			assert.Equal(t, tt.wantIP, entry.IP)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/raucheacho/lanup/internal/config"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
@@ -15,6 +16,7 @@ import (
 type InitCmd struct {
 	Format string
 	Force  bool
+	Preset string
 }
 
 // NewInitCmd creates a new init command
@@ -24,18 +26,29 @@ func NewInitCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize lanup configuration in the current project",
-		Long: `Initialize lanup configuration by creating a .lanup.yaml file in the current directory.
+		Long: `Initialize lanup configuration by creating a .lanup.yaml file in the current directory,
+or a .lanup.toml or .lanup.json file with --format toml or json.
 
 This file defines which services should be exposed on your local network.
-You can customize the variables, output file path, and auto-detection settings.`,
+You can customize the variables, output file path, and auto-detection settings.
+
+Use --preset to start from variables, output file and detectors suited to your stack
+(` + strings.Join(config.PresetNames(), ", ") + `).
+
+Examples:
+  lanup init
+  lanup init --preset nextjs
+  lanup init --format toml
+  lanup init --format json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return initCmd.Run()
 		},
 	}
 
 	// Add flags
-	cmd.Flags().StringVar(&initCmd.Format, "format", "yaml", "configuration file format (yaml or toml)")
+	cmd.Flags().StringVar(&initCmd.Format, "format", "yaml", "configuration file format (yaml, toml or json)")
 	cmd.Flags().BoolVar(&initCmd.Force, "force", false, "overwrite existing configuration file")
+	cmd.Flags().StringVar(&initCmd.Preset, "preset", "", "framework preset ("+strings.Join(config.PresetNames(), ", ")+")")
 
 	return cmd
 }
@@ -47,36 +60,60 @@ func init() {
 // Run executes the init command
 func (c *InitCmd) Run() error {
 	// Validate format
-	if c.Format != "yaml" && c.Format != "toml" {
+	if c.Format != "yaml" && c.Format != "toml" && c.Format != "json" {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
-			fmt.Sprintf("Unsupported format: %s (supported: yaml, toml)", c.Format), nil)
+			fmt.Sprintf("Unsupported format: %s (supported: yaml, toml, json)", c.Format), nil)
 	}
 
-	// Note: Currently only YAML is implemented
-	if c.Format == "toml" {
-		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
-			"TOML format is not yet supported, please use yaml", nil)
+	// Resolve the preset before touching any file
+	var preset *config.Preset
+	if c.Preset != "" {
+		p, err := config.GetPreset(c.Preset)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --preset flag", err)
+		}
+		preset = &p
 	}
 
 	// Determine config file path
-	configPath := ".lanup.yaml"
+	configPath := ".lanup." + c.Format
 
-	// Check if file already exists
-	if _, err := os.Stat(configPath); err == nil {
+	// Check if a file already exists, in either format
+	var replaced []string
+	for _, name := range config.ProjectConfigFiles {
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
 		if !c.Force {
 			return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-				fmt.Sprintf("Configuration file already exists at %s\nUse --force to overwrite", configPath), nil)
+				fmt.Sprintf("Configuration file already exists at %s\nUse --force to overwrite", name), nil)
+		}
+		utils.Warning("Overwriting existing configuration file at %s", name)
+		if name != configPath {
+			replaced = append(replaced, name)
 		}
-		utils.Warning("Overwriting existing configuration file at %s", configPath)
 	}
 
 	// Generate default configuration
 	defaultConfig := config.GetDefaultProjectConfig()
+	if preset != nil {
+		defaultConfig = preset.Config()
+	}
+	if c.Format == "json" {
+		// Editors find the schema from the file itself, for completion and validation
+		defaultConfig.Schema = config.SchemaURL
+	}
 
 	// Save configuration to file
 	if err := config.SaveProjectConfig(configPath, defaultConfig); err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
-			"Failed to create configuration file", err)
+		return lanuperrors.FromOSError("Failed to create configuration file", err)
+	}
+
+	// A configuration in the other format would take precedence over the new one
+	for _, name := range replaced {
+		if err := os.Remove(name); err != nil {
+			return lanuperrors.FromOSError(fmt.Sprintf("Failed to remove %s", name), err)
+		}
 	}
 
 	// Get absolute path for display
@@ -88,10 +125,17 @@ func (c *InitCmd) Run() error {
 	// Display success message
 	utils.Success("Configuration file created successfully!")
 	utils.Info("Location: %s", absPath)
-	fmt.Println()
+	utils.Println()
 	utils.PrintSection("Next steps")
-	fmt.Printf("  1. Edit %s to configure your services\n", configPath)
-	fmt.Printf("  2. Run 'lanup start' to expose your services on the LAN\n")
+	utils.Printf("  1. Edit %s to configure your services\n", configPath)
+	utils.Printf("  2. Run 'lanup start' to expose your services on the LAN\n")
+
+	if preset != nil {
+		utils.PrintSection(fmt.Sprintf("Tips for %s", preset.Title))
+		for _, hint := range preset.Hints {
+			utils.Printf("  %s %s\n", utils.Symbol("•", "-"), hint)
+		}
+	}
 
 	return nil
 }
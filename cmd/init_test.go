@@ -136,7 +136,7 @@ func TestInitCmd_Run_InvalidFormat(t *testing.T) {
 
 	// Create init command with invalid format
 	initCmd := &InitCmd{
-		Format: "json",
+		Format: "xml",
 		Force:  false,
 	}
 
@@ -145,7 +145,7 @@ func TestInitCmd_Run_InvalidFormat(t *testing.T) {
 	assert.Contains(t, err.Error(), "Unsupported format")
 }
 
-func TestInitCmd_Run_TOMLNotSupported(t *testing.T) {
+func TestInitCmd_Run_TOML(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
 
@@ -157,13 +157,107 @@ func TestInitCmd_Run_TOMLNotSupported(t *testing.T) {
 	err = os.Chdir(tmpDir)
 	require.NoError(t, err)
 
-	// Create init command with TOML format
 	initCmd := &InitCmd{
 		Format: "toml",
 		Force:  false,
 	}
 
 	err = initCmd.Run()
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "TOML format is not yet supported")
+	require.NoError(t, err)
+
+	loadedConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, ".lanup.toml", loadedConfig.Path())
+	assert.Equal(t, ".env.local", loadedConfig.Output)
+
+	// A YAML configuration can't be created next to it without --force
+	initCmd = &InitCmd{Format: "yaml"}
+	err = initCmd.Run()
+	assert.ErrorContains(t, err, "already exists at .lanup.toml")
+
+	// --force replaces it
+	initCmd = &InitCmd{Format: "yaml", Force: true}
+	require.NoError(t, initCmd.Run())
+	_, err = os.Stat(".lanup.toml")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(".lanup.yaml")
+	assert.NoError(t, err)
+}
+
+func TestInitCmd_Run_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	initCmd := &InitCmd{Format: "json", Preset: "vite"}
+	require.NoError(t, initCmd.Run())
+
+	data, err := os.ReadFile(".lanup.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"$schema": "`+config.SchemaURL+`"`)
+
+	loadedConfig, err := config.LoadProjectConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, ".lanup.json", loadedConfig.Path())
+	assert.Contains(t, loadedConfig.Vars, "VITE_API_URL")
+}
+
+func TestInitCmd_Run_Preset(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	initCmd := &InitCmd{
+		Format: "yaml",
+		Preset: "expo",
+	}
+
+	err = initCmd.Run()
+	require.NoError(t, err)
+
+	loadedConfig, err := config.LoadProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost:8000", loadedConfig.Vars["EXPO_PUBLIC_API_URL"])
+	assert.Equal(t, ".env", loadedConfig.Output)
+	assert.True(t, loadedConfig.AutoDetect.Expo)
+	assert.False(t, loadedConfig.AutoDetect.Supabase)
+}
+
+func TestInitCmd_Run_UnknownPreset(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	initCmd := &InitCmd{
+		Format: "yaml",
+		Preset: "rails",
+	}
+
+	err = initCmd.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown preset")
+
+	// No file is created
+	_, err = os.Stat(filepath.Join(tmpDir, ".lanup.yaml"))
+	assert.True(t, os.IsNotExist(err))
 }
@@ -2,13 +2,23 @@ package cmd
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/raucheacho/lanup/internal/logger"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +27,21 @@ type LogsCmd struct {
 	Tail   int
 	Follow bool
 	Clear  bool
+	Level  string
+	Module string
+	Since  string
+	Until  string
+	Grep   string
+	Raw    bool
+}
+
+// logFilter selects the log entries to display
+type logFilter struct {
+	minLevel logger.LogLevel
+	module   string
+	since    time.Time // zero for no lower bound
+	until    time.Time // zero for no upper bound
+	pattern  *regexp.Regexp
 }
 
 var logsCmd = &cobra.Command{
@@ -24,28 +49,82 @@ var logsCmd = &cobra.Command{
 	Short: "View or manage lanup logs",
 	Long: `View or manage lanup logs.
 
-By default, displays all log entries. Use --tail to limit the number of lines,
---follow to stream logs in real-time, or --clear to remove the log file.`,
+By default, displays all log entries, including the rotated backups of the log file
+(lanup.log.1 ... lanup.log.N) in chronological order. Use --tail to limit the number
+of lines, --follow to stream logs in real-time, or --clear to remove the log files.
+
+Entries can be filtered by severity with --level (the given level and above) and by
+subsystem with --module. --since and --until take a duration (2h, 3d) or a time
+("2024-05-01 10:00", "2024-05-01", "10:00" for today). Use --grep to search entries with a regular expression
+matched against the whole entry, fields included. Filters apply to the parsed entries,
+so --tail counts matching entries only and --follow streams matching entries.
+
+Logs written with log_format: json are displayed as text lines; use --raw to print
+the JSON lines unchanged, e.g. to pipe them into jq.
+
+Examples:
+  lanup logs --level warn
+  lanup logs --level error --module watcher --follow
+  lanup logs --since 2h
+  lanup logs --since "2024-05-01 10:00" --until "2024-05-01 12:00"
+  lanup logs --grep 'new_ip=192\.168\.1\.\d+' --follow
+  lanup logs --raw | jq .fields`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tail, err := cmd.Flags().GetInt("tail")
 		if err != nil {
-			return fmt.Errorf("invalid tail value: %w", err)
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --tail flag", err)
 		}
 
 		follow, err := cmd.Flags().GetBool("follow")
 		if err != nil {
-			return fmt.Errorf("invalid follow value: %w", err)
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --follow flag", err)
 		}
 
 		clear, err := cmd.Flags().GetBool("clear")
 		if err != nil {
-			return fmt.Errorf("invalid clear value: %w", err)
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --clear flag", err)
+		}
+
+		level, err := cmd.Flags().GetString("level")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --level flag", err)
+		}
+
+		module, err := cmd.Flags().GetString("module")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --module flag", err)
+		}
+
+		since, err := cmd.Flags().GetString("since")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --since flag", err)
+		}
+
+		until, err := cmd.Flags().GetString("until")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --until flag", err)
+		}
+
+		grep, err := cmd.Flags().GetString("grep")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --grep flag", err)
+		}
+
+		raw, err := cmd.Flags().GetBool("raw")
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --raw flag", err)
 		}
 
 		logsCmd := &LogsCmd{
 			Tail:   tail,
 			Follow: follow,
 			Clear:  clear,
+			Level:  level,
+			Module: module,
+			Since:  since,
+			Until:  until,
+			Grep:   grep,
+			Raw:    raw,
 		}
 
 		return logsCmd.Run()
@@ -58,7 +137,14 @@ func init() {
 	// Add flags
 	logsCmd.Flags().IntP("tail", "n", 0, "show last N lines (0 = show all)")
 	logsCmd.Flags().BoolP("follow", "f", false, "follow log output in real-time")
-	logsCmd.Flags().Bool("clear", false, "clear the log file (requires confirmation)")
+	logsCmd.Flags().Bool("clear", false, "clear the log file and its backups (requires confirmation)")
+	logsCmd.Flags().String("level", "", "only show entries of this level and above (debug, info, warn, error)")
+	logsCmd.Flags().String("module", "", "only show entries of this module (e.g. watcher)")
+	_ = logsCmd.RegisterFlagCompletionFunc("module", completeLogModules)
+	logsCmd.Flags().String("since", "", "only show entries after a time or a duration ago (e.g. 2h, \"2024-05-01 10:00\")")
+	logsCmd.Flags().String("until", "", "only show entries before a time or a duration ago")
+	logsCmd.Flags().String("grep", "", "only show entries matching a regular expression")
+	logsCmd.Flags().Bool("raw", false, "print JSON log lines as written instead of as text")
 }
 
 // Run executes the logs command
@@ -82,6 +168,11 @@ func (c *LogsCmd) Run() error {
 		logPath = filepath.Join(home, logPath[1:])
 	}
 
+	filter, err := c.filter()
+	if err != nil {
+		return err
+	}
+
 	// Handle clear flag
 	if c.Clear {
 		return c.clearLogs(logPath)
@@ -89,112 +180,395 @@ func (c *LogsCmd) Run() error {
 
 	// Handle follow flag
 	if c.Follow {
-		return c.streamLogs(logPath)
+		return c.streamLogs(logPath, filter)
 	}
 
 	// Default: display logs with optional tail
-	return c.displayLogs(logPath)
+	return c.displayLogs(logPath, filter)
 }
 
-// displayLogs reads and displays the log file
-func (c *LogsCmd) displayLogs(logPath string) error {
-	// Check if log file exists
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+// filter builds the entry filter from the flags
+func (c *LogsCmd) filter() (*logFilter, error) {
+	if c.Level == "" && c.Module == "" && c.Since == "" && c.Until == "" && c.Grep == "" {
+		return nil, nil
+	}
+
+	f := &logFilter{minLevel: logger.DEBUG, module: strings.ToLower(c.Module)}
+	if c.Level != "" {
+		level, err := logger.ParseLevel(c.Level)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --level flag", err)
+		}
+		f.minLevel = level
+	}
+
+	if c.Grep != "" {
+		pattern, err := regexp.Compile(c.Grep)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --grep pattern", err)
+		}
+		f.pattern = pattern
+	}
+
+	now := time.Now()
+	if c.Since != "" {
+		since, err := parseTimeFlag(c.Since, now)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --since flag", err)
+		}
+		f.since = since
+	}
+	if c.Until != "" {
+		until, err := parseTimeFlag(c.Until, now)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --until flag", err)
+		}
+		f.until = until
+	}
+	if !f.since.IsZero() && !f.until.IsZero() && f.until.Before(f.since) {
+		return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Invalid time range: --until is before --since", nil)
+	}
+
+	return f, nil
+}
+
+// match reports whether an entry passes the filter
+func (f *logFilter) match(entry logger.Entry) bool {
+	if entry.Level < f.minLevel {
+		return false
+	}
+	if f.module != "" {
+		module := strings.ToLower(entry.Module)
+		if module != f.module && !strings.HasPrefix(module, f.module+".") {
+			return false
+		}
+	}
+	if !f.since.IsZero() && entry.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && entry.Time.After(f.until) {
+		return false
+	}
+	if f.pattern != nil && !f.pattern.MatchString(entry.Raw) {
+		return false
+	}
+	return true
+}
+
+// timeFlagLayouts are the time formats accepted by --since and --until
+var timeFlagLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeFlag parses a duration ago (2h, 30m, 3d) or a local time
+// A time of day alone (15:04) refers to today
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range timeFlagLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("expected a duration (2h, 3d) or a time (\"2006-01-02 15:04\"), got %q", value)
+}
+
+// logFiles returns the existing rotated backups of the log file (log.N ... log.1, or
+// log.N.gz when compressed), oldest first, followed by the log file itself when it exists
+func logFiles(logPath string) []string {
+	matches, _ := filepath.Glob(logPath + ".*")
+
+	type backup struct {
+		path string
+		n    int
+	}
+	var backups []backup
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(match, logPath+"."), ".gz")
+		n, err := strconv.Atoi(suffix)
+		if err != nil || n < 1 {
+			continue
+		}
+		backups = append(backups, backup{path: match, n: n})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].n > backups[j].n
+	})
+
+	files := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		files = append(files, b.path)
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		files = append(files, logPath)
+	}
+	return files
+}
+
+// filterFiles returns the lines of the files passing the filter, in order
+func filterFiles(paths []string, filter *logFilter) ([]string, error) {
+	var lines []string
+	for _, path := range paths {
+		file, err := openLogFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		fileLines, err := filterLines(file, filter)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fileLines...)
+	}
+	return lines, nil
+}
+
+// lineMatcher applies a filter line by line
+// Lines that are not entries (e.g. a multi-line value) follow the decision of the entry above them
+type lineMatcher struct {
+	filter *logFilter
+	keep   bool
+}
+
+// match reports whether a line passes the filter
+func (m *lineMatcher) match(line string) bool {
+	if m.filter == nil {
+		return true
+	}
+	if entry, ok := logger.ParseEntry(line); ok {
+		m.keep = m.filter.match(entry)
+	}
+	return m.keep
+}
+
+// filterLines returns the lines of r passing the filter
+func filterLines(r io.Reader, filter *logFilter) ([]string, error) {
+	matcher := &lineMatcher{filter: filter}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if matcher.match(scanner.Text()) {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// displayLogs reads and displays the log file and its rotated backups
+func (c *LogsCmd) displayLogs(logPath string, filter *logFilter) error {
+	// Backups come first, so the history survives rotation
+	paths := logFiles(logPath)
+	if len(paths) == 0 {
 		fmt.Println("No log file found. Logs will be created when lanup runs.")
 		return nil
 	}
 
-	// Open log file
-	file, err := os.Open(logPath)
-	if err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
-			"Failed to open log file", err)
+	// Filters need every entry to be parsed, the tail is taken from the matching ones
+	if filter != nil {
+		lines, err := filterFiles(paths, filter)
+		if err != nil {
+			return lanuperrors.FromOSError("Error reading log file", err)
+		}
+		if c.Tail > 0 && len(lines) > c.Tail {
+			lines = lines[len(lines)-c.Tail:]
+		}
+		for _, line := range lines {
+			fmt.Println(c.render(line))
+		}
+		return nil
 	}
-	defer file.Close()
 
 	// If tail is specified, read last N lines
 	if c.Tail > 0 {
-		lines, err := readLastNLines(file, c.Tail)
+		lines, err := tailFiles(paths, c.Tail)
 		if err != nil {
-			return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-				"Failed to read log file", err)
+			return lanuperrors.FromOSError("Failed to read log file", err)
 		}
 		for _, line := range lines {
-			fmt.Print(line)
+			fmt.Println(c.render(strings.TrimSuffix(line, "\n")))
 		}
 		return nil
 	}
 
-	// Otherwise, read entire file
+	// Otherwise, read every file
+	for _, path := range paths {
+		if err := c.printFile(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printFile displays a whole log file
+func (c *LogsCmd) printFile(path string) error {
+	file, err := openLogFile(path)
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to open log file", err)
+	}
+	defer file.Close()
+
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		fmt.Println(scanner.Text())
+		fmt.Println(c.render(scanner.Text()))
 	}
 
 	if err := scanner.Err(); err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-			"Error reading log file", err)
+		return lanuperrors.FromOSError("Error reading log file", err)
 	}
 
 	return nil
 }
 
-// streamLogs follows the log file and displays new entries in real-time
-func (c *LogsCmd) streamLogs(logPath string) error {
-	// Check if log file exists, if not wait for it
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		fmt.Println("Waiting for log file to be created...")
-		// Wait for file to be created
-		for {
-			if _, err := os.Stat(logPath); err == nil {
-				break
+// tailFiles reads the last N lines of the files, going back into older files
+// when the newer ones are shorter than N lines
+func tailFiles(paths []string, n int) ([]string, error) {
+	var lines []string
+	for i := len(paths) - 1; i >= 0 && len(lines) < n; i-- {
+		var fileLines []string
+		var err error
+		if strings.HasSuffix(paths[i], ".gz") {
+			fileLines, err = readLastNCompressedLines(paths[i], n-len(lines))
+		} else {
+			var file *os.File
+			if file, err = os.Open(paths[i]); err != nil {
+				return nil, err
 			}
-			time.Sleep(500 * time.Millisecond)
+			fileLines, err = readLastNLines(file, n-len(lines))
+			file.Close()
 		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(fileLines, lines...)
+	}
+	return lines, nil
+}
+
+// openLogFile opens a log file, decompressing gzip backups
+func openLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return file, err
 	}
 
-	// Open log file
-	file, err := os.Open(logPath)
+	zr, err := gzip.NewReader(file)
 	if err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
-			"Failed to open log file", err)
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
 	}
-	defer file.Close()
+	return &gzipFile{Reader: zr, file: file}, nil
+}
 
-	// Seek to end of file
-	if _, err := file.Seek(0, 2); err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-			"Failed to seek to end of file", err)
-	}
+// gzipFile closes the decompressor and the underlying file together
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close closes the decompressor and the file
+func (f *gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
 
-	fmt.Println("Following log file (Ctrl+C to stop)...")
+// readLastNCompressedLines reads the last N lines of a gzip backup
+// Compressed files can't be read backwards, the whole file is decompressed
+func readLastNCompressedLines(path string, n int) ([]string, error) {
+	file, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	// Create a scanner and continuously read new lines
+	var lines []string
 	scanner := bufio.NewScanner(file)
-	for {
-		if scanner.Scan() {
-			fmt.Println(scanner.Text())
-		} else {
-			// No new data, wait a bit
-			time.Sleep(500 * time.Millisecond)
-			// Check if file still exists (might have been rotated)
-			if _, err := os.Stat(logPath); os.IsNotExist(err) {
-				fmt.Println("Log file was removed or rotated. Exiting.")
-				return nil
-			}
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		lines = append(lines, scanner.Text()+"\n")
+		if len(lines) > n {
+			lines = lines[1:]
 		}
+	}
+	return lines, scanner.Err()
+}
 
-		if err := scanner.Err(); err != nil {
-			return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-				"Error reading log file", err)
+// streamLogs follows the log file and displays new entries in real-time
+// Like tail -F, it keeps following the log file across rotations and truncations
+func (c *LogsCmd) streamLogs(logPath string, filter *logFilter) error {
+	matcher := &lineMatcher{filter: filter}
+
+	follower := logger.NewFollower(logPath)
+	follower.OnLine = func(line string) {
+		if matcher.match(line) {
+			fmt.Println(c.render(line))
 		}
 	}
+	follower.OnNotice = func(msg string) {
+		fmt.Println(msg)
+	}
+
+	if err := follower.Open(); err != nil {
+		return lanuperrors.FromOSError("Failed to open log file", err)
+	}
+	defer follower.Close()
+
+	if !follower.Exists() {
+		utils.Println("Waiting for log file to be created...")
+	}
+	utils.Println("Following log file (Ctrl+C to stop)...")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := follower.Run(ctx); err != nil {
+		return lanuperrors.FromOSError("Error reading log file", err)
+	}
+	return nil
+}
+
+// render formats a log line for display
+// JSON entries are shown as text lines unless --raw is set
+func (c *LogsCmd) render(line string) string {
+	if c.Raw {
+		return line
+	}
+	if entry, ok := logger.ParseEntry(line); ok && entry.JSON {
+		return entry.Text()
+	}
+	return line
 }
 
-// clearLogs removes the log file after confirmation
+// clearLogs removes the log file and its backups after confirmation
 func (c *LogsCmd) clearLogs(logPath string) error {
 	// Check if log file exists
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+	paths := logFiles(logPath)
+	if len(paths) == 0 {
 		fmt.Println("No log file found.")
 		return nil
 	}
@@ -204,8 +578,7 @@ func (c *LogsCmd) clearLogs(logPath string) error {
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-			"Failed to read confirmation", err)
+		return lanuperrors.FromOSError("Failed to read confirmation", err)
 	}
 
 	response = strings.TrimSpace(strings.ToLower(response))
@@ -214,10 +587,11 @@ func (c *LogsCmd) clearLogs(logPath string) error {
 		return nil
 	}
 
-	// Remove the log file
-	if err := os.Remove(logPath); err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
-			"Failed to remove log file", err)
+	// Remove the log file and its backups
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return lanuperrors.FromOSError("Failed to remove log file", err)
+		}
 	}
 
 	fmt.Println("Log file cleared successfully.")
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleLog = `[2024-05-01 10:00:00] INFO  Starting lanup watch=true profile=
+[2024-05-01 10:00:01] DEBUG Detector not available detector=docker
+[2024-05-01 10:00:05] WARN  watcher: Network interface changed old_ip=192.168.1.10 new_ip=192.168.1.20
+[2024-05-01 10:00:06] ERROR watcher: Failed to regenerate env file error=Failed to write env file:
+permission denied
+[2024-05-01 10:00:07] ERROR env: Failed to write env file error=disk full
+[2024-05-01 10:00:08] INFO  Updated env file path=.env.local vars=3
+`
+
+func TestLogsCmd_Filter(t *testing.T) {
+	filter, err := (&LogsCmd{}).filter()
+	require.NoError(t, err)
+	assert.Nil(t, filter, "no filter without --level and --module")
+
+	_, err = (&LogsCmd{Level: "loud"}).filter()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid --level flag")
+
+	_, err = (&LogsCmd{Grep: "(unclosed"}).filter()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid --grep pattern")
+
+	_, err = (&LogsCmd{Since: "yesterday"}).filter()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid --since flag")
+
+	_, err = (&LogsCmd{Since: "2024-05-02", Until: "2024-05-01"}).filter()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--until is before --since")
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 5, 3, 12, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2h", want: now.Add(-2 * time.Hour)},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "3d", want: time.Date(2024, 4, 30, 12, 30, 0, 0, time.Local)},
+		{value: "2024-05-01 10:00", want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)},
+		{value: "2024-05-01 10:00:30", want: time.Date(2024, 5, 1, 10, 0, 30, 0, time.Local)},
+		{value: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{value: "09:15", want: time.Date(2024, 5, 3, 9, 15, 0, 0, time.Local)},
+		{value: "2024-05-01T10:00:00Z", want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeFlag(tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+}
+
+func TestFilterFiles_RotatedBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "lanup.log")
+
+	files := map[string]string{
+		logPath + ".2":  "[2024-04-29 08:00:00] INFO  Oldest\n",
+		logPath + ".1":  "[2024-04-30 08:00:00] INFO  Older\n",
+		logPath:         sampleLog,
+		logPath + ".gz": "not a backup\n",
+	}
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	assert.Equal(t, []string{logPath + ".2", logPath + ".1", logPath}, logFiles(logPath))
+
+	filter, err := (&LogsCmd{Since: "2024-04-30", Until: "2024-05-01 10:00:01"}).filter()
+	require.NoError(t, err)
+
+	lines, err := filterFiles(logFiles(logPath), filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"[2024-04-30 08:00:00] INFO  Older",
+		"[2024-05-01 10:00:00] INFO  Starting lanup watch=true profile=",
+		"[2024-05-01 10:00:01] DEBUG Detector not available detector=docker",
+	}, lines)
+}
+
+func TestFilterLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		cmd    *LogsCmd
+		prefix []string // expected lines, by their first characters
+	}{
+		{
+			name: "level",
+			cmd:  &LogsCmd{Level: "warn"},
+			prefix: []string{
+				"[2024-05-01 10:00:05] WARN",
+				"[2024-05-01 10:00:06] ERROR",
+				"permission denied",
+				"[2024-05-01 10:00:07] ERROR",
+			},
+		},
+		{
+			name: "module",
+			cmd:  &LogsCmd{Module: "watcher"},
+			prefix: []string{
+				"[2024-05-01 10:00:05] WARN",
+				"[2024-05-01 10:00:06] ERROR",
+				"permission denied",
+			},
+		},
+		{
+			name:   "level and module",
+			cmd:    &LogsCmd{Level: "error", Module: "ENV"},
+			prefix: []string{"[2024-05-01 10:00:07] ERROR"},
+		},
+		{
+			name: "time range",
+			cmd:  &LogsCmd{Since: "2024-05-01 10:00:05", Until: "2024-05-01 10:00:07"},
+			prefix: []string{
+				"[2024-05-01 10:00:05] WARN",
+				"[2024-05-01 10:00:06] ERROR",
+				"permission denied",
+				"[2024-05-01 10:00:07] ERROR",
+			},
+		},
+		{
+			name: "grep matches fields",
+			cmd:  &LogsCmd{Grep: `old_ip=192\.168\.1\.10 new_ip=`},
+			prefix: []string{
+				"[2024-05-01 10:00:05] WARN",
+			},
+		},
+		{
+			name: "grep keeps continuation lines",
+			cmd:  &LogsCmd{Grep: "regenerate"},
+			prefix: []string{
+				"[2024-05-01 10:00:06] ERROR",
+				"permission denied",
+			},
+		},
+		{
+			name:   "grep combined with level",
+			cmd:    &LogsCmd{Grep: "env file", Level: "info"},
+			prefix: []string{"[2024-05-01 10:00:06] ERROR", "permission denied", "[2024-05-01 10:00:07] ERROR", "[2024-05-01 10:00:08] INFO"},
+		},
+		{
+			name:   "debug includes everything",
+			cmd:    &LogsCmd{Level: "debug"},
+			prefix: strings.Split(strings.TrimSpace(sampleLog), "\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.cmd.filter()
+			require.NoError(t, err)
+
+			lines, err := filterLines(strings.NewReader(sampleLog), filter)
+			require.NoError(t, err)
+			require.Len(t, lines, len(tt.prefix))
+			for i, prefix := range tt.prefix {
+				assert.True(t, strings.HasPrefix(lines[i], prefix), "line %d: %s", i, lines[i])
+			}
+		})
+	}
+}
+
+func TestLogFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "lanup.log")
+
+	assert.Empty(t, logFiles(logPath))
+
+	// Backups are listed even when the log file was removed
+	require.NoError(t, os.WriteFile(logPath+".1", []byte("old\n"), 0644))
+	assert.Equal(t, []string{logPath + ".1"}, logFiles(logPath))
+
+	require.NoError(t, os.WriteFile(logPath+".10", []byte("older\n"), 0644))
+	require.NoError(t, os.WriteFile(logPath, []byte("new\n"), 0644))
+	assert.Equal(t, []string{logPath + ".10", logPath + ".1", logPath}, logFiles(logPath))
+}
+
+func TestTailFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "lanup.log")
+	require.NoError(t, os.WriteFile(logPath+".2", []byte("line 1\nline 2\n"), 0644))
+	require.NoError(t, os.WriteFile(logPath+".1", []byte("line 3\nline 4\n"), 0644))
+	require.NoError(t, os.WriteFile(logPath, []byte("line 5\n"), 0644))
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 1, want: []string{"line 5\n"}},
+		{n: 3, want: []string{"line 3\n", "line 4\n", "line 5\n"}},
+		{n: 10, want: []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n", "line 5\n"}},
+	}
+
+	for _, tt := range tests {
+		lines, err := tailFiles(logFiles(logPath), tt.n)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, lines, "n=%d", tt.n)
+	}
+}
+
+func TestLogFiles_Compressed(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "lanup.log")
+
+	// lanup.log.2.gz holds lines 1-2, lanup.log.1 line 3, lanup.log line 4
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte("[2024-04-29 08:00:00] INFO  line 1\n[2024-04-29 09:00:00] INFO  line 2\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(logPath+".2.gz", buf.Bytes(), 0644))
+	require.NoError(t, os.WriteFile(logPath+".1", []byte("[2024-04-30 08:00:00] INFO  line 3\n"), 0644))
+	require.NoError(t, os.WriteFile(logPath, []byte("[2024-05-01 08:00:00] INFO  line 4\n"), 0644))
+
+	paths := logFiles(logPath)
+	assert.Equal(t, []string{logPath + ".2.gz", logPath + ".1", logPath}, paths)
+
+	lines, err := tailFiles(paths, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"[2024-04-29 09:00:00] INFO  line 2\n",
+		"[2024-04-30 08:00:00] INFO  line 3\n",
+		"[2024-05-01 08:00:00] INFO  line 4\n",
+	}, lines)
+
+	filter, err := (&LogsCmd{Until: "2024-04-29 08:30"}).filter()
+	require.NoError(t, err)
+	lines, err = filterFiles(paths, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"[2024-04-29 08:00:00] INFO  line 1"}, lines)
+}
+
+func TestLogsCmd_Render(t *testing.T) {
+	jsonLine := `{"ts":"2024-05-01T10:00:05+02:00","level":"INFO","msg":"Updated env file","fields":{"path":".env.local"}}`
+	textLine := "[2024-05-01 10:00:08] INFO  Updated env file path=.env.local vars=3"
+
+	c := &LogsCmd{}
+	assert.Regexp(t, `^\[2024-05-01 \d{2}:00:05\] INFO  Updated env file path=\.env\.local$`, c.render(jsonLine))
+	assert.Equal(t, textLine, c.render(textLine))
+	assert.Equal(t, "permission denied", c.render("permission denied"))
+
+	raw := &LogsCmd{Raw: true}
+	assert.Equal(t, jsonLine, raw.render(jsonLine))
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	stdnet "net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/api"
+	"github.com/raucheacho/lanup/internal/cert"
+	"github.com/raucheacho/lanup/internal/devices"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/process"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// PairCmd represents the pair command
+type PairCmd struct{}
+
+// NewPairCmd creates a new pair command
+func NewPairCmd() *cobra.Command {
+	pairCmd := &PairCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "pair",
+		Short: "Pair a test device to receive the new URLs when the IP changes",
+		Long: `Show a QR code pairing a test device with the daemon of the current project, so that a
+companion script or app on the device receives the new URLs whenever the IP of this machine
+changes, instead of them being typed again in the debug settings of the app.
+
+The daemon serves the paired devices on a LAN port, opened with the first pairing and kept
+across restarts. The QR code holds a one-time code valid for 5 minutes. The device pairs with:
+  POST /v1/pair    {"code": "...", "name": "Pixel 7"}, returns its token
+then authenticates with "Authorization: Bearer <token>" (or ?token= for EventSource):
+  GET  /v1/state   current IP and URLs
+  GET  /v1/events  current IP and URLs, then the new ones whenever they change (server-sent events)
+
+Only the URLs are sent to the devices, never the other variables.
+
+Examples:
+  lanup daemon start
+  lanup pair
+  lanup pair list
+  lanup pair remove "Pixel 7"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pairCmd.Run()
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the paired devices",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pairCmd.List()
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:   "remove NAME|ID",
+		Short: "Unpair a device",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pairCmd.Remove(args[0])
+		},
+	}
+
+	cmd.AddCommand(listCmd, removeCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewPairCmd())
+}
+
+// Run asks the daemon of the current project for a pairing code and shows it as a QR code
+func (c *PairCmd) Run() error {
+	apiURL := readAPIURL()
+	if apiURL == "" {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"No daemon is running for this project, start it with 'lanup daemon start'", nil)
+	}
+
+	pairing, err := requestPairing(apiURL)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork, "Failed to get a pairing code from the daemon", err)
+	}
+
+	utils.PrintSection("Scan with the device to pair")
+	if err := utils.PrintQRCode("Pairing", pairing.URL); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidURL, "Failed to render the QR code", err)
+	}
+	utils.Info("Code %s, valid until %s", pairing.Code, pairing.ExpiresAt.Local().Format("15:04:05"))
+	utils.Println(`   The device POSTs {"name": "..."} to this URL to get its token, then listens to /v1/events`)
+	return nil
+}
+
+// requestPairing asks the API of the daemon for a pairing code
+func requestPairing(apiURL string) (api.Pairing, error) {
+	token, err := apiToken()
+	if err != nil {
+		return api.Pairing{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/v1/pairing", nil)
+	if err != nil {
+		return api.Pairing{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return api.Pairing{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return api.Pairing{}, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return api.Pairing{}, errors.New(apiErr.Error)
+		}
+		return api.Pairing{}, fmt.Errorf("the daemon returned %s (restart it if it predates 'lanup pair')", resp.Status)
+	}
+
+	var pairing api.Pairing
+	if err := json.Unmarshal(body, &pairing); err != nil {
+		return api.Pairing{}, fmt.Errorf("invalid pairing response: %w", err)
+	}
+	return pairing, nil
+}
+
+// List prints the devices paired with the daemon of the current project
+func (c *PairCmd) List() error {
+	store, _, err := loadDevices()
+	if err != nil {
+		return err
+	}
+	if len(store.Devices) == 0 {
+		utils.Info("No paired devices, run 'lanup pair' to pair one")
+		return nil
+	}
+
+	table := utils.NewTable("NAME", "ID", "PAIRED", "LAST SEEN")
+	for _, d := range store.Devices {
+		lastSeen := "-"
+		if !d.LastSeen.IsZero() {
+			lastSeen = d.LastSeen.Local().Format("2006-01-02 15:04")
+		}
+		table.AddRow(d.Name, d.ID, d.PairedAt.Local().Format("2006-01-02 15:04"), lastSeen)
+	}
+	table.Print()
+	return nil
+}
+
+// Remove unpairs a device, the daemon rejects its token at once
+func (c *PairCmd) Remove(nameOrID string) error {
+	store, path, err := loadDevices()
+	if err != nil {
+		return err
+	}
+	if !store.Remove(nameOrID) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("No paired device named %s (see 'lanup pair list')", nameOrID), nil)
+	}
+	if err := store.Save(path); err != nil {
+		return lanuperrors.FromOSError("Failed to save the paired devices", err)
+	}
+
+	// The daemon rejects the token from now on, but the event stream it opened stays connected
+	if apiURL := readAPIURL(); apiURL != "" {
+		if err := requestDisconnect(apiURL); err != nil {
+			utils.Warning("The daemon could not disconnect %s, it stops getting URLs at the next heartbeat: %v", nameOrID, err)
+		}
+	}
+
+	utils.Success("Unpaired %s", nameOrID)
+	return nil
+}
+
+// requestDisconnect asks the API of the daemon to close the event streams of the unpaired devices
+func requestDisconnect(apiURL string) error {
+	token, err := apiToken()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/v1/pairing/disconnect", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("the daemon returned %s", resp.Status)
+	}
+	return nil
+}
+
+// loadDevices reads the devices paired with the daemon of the current project
+func loadDevices() (*devices.Store, string, error) {
+	path, err := process.DevicesFile(projectDir())
+	if err != nil {
+		return nil, "", lanuperrors.FromOSError("Failed to locate the paired devices", err)
+	}
+	store, err := devices.Load(path)
+	if err != nil {
+		return nil, "", lanuperrors.FromOSError("Failed to read the paired devices", err)
+	}
+	return store, path, nil
+}
+
+// deviceServer pushes the URLs of the daemon to the paired devices, on a LAN port opened at
+// the first pairing, or at start when devices are already paired
+type deviceServer struct {
+	path string // devices file
+	log  *logger.Logger
+
+	mu       sync.Mutex
+	server   *devices.Server
+	port     int
+	shutdown func()
+}
+
+// newDeviceServer creates the device server of a project, without opening its port
+func newDeviceServer(projectDir string, log *logger.Logger) (*deviceServer, error) {
+	path, err := process.DevicesFile(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return &deviceServer{path: path, log: log}, nil
+}
+
+// resume opens the port when devices were paired by a previous run
+func (d *deviceServer) resume(state devices.State) error {
+	store, err := devices.Load(d.path)
+	if err != nil || len(store.Devices) == 0 {
+		return err
+	}
+	return d.start(state)
+}
+
+// start opens the port of the device server if it is not open yet
+// The port of the previous run is reused, so that the devices find the server again.
+func (d *deviceServer) start(state devices.State) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.server != nil {
+		return nil
+	}
+
+	store, err := devices.Load(d.path)
+	if err != nil {
+		return err
+	}
+	listener, err := stdnet.Listen("tcp", ":"+strconv.Itoa(store.Port))
+	if err != nil && store.Port != 0 {
+		if d.log != nil {
+			d.log.Warn("Port of the paired devices in use, they must be paired again",
+				logger.Field{Key: "port", Value: store.Port}, logger.Field{Key: "error", Value: err.Error()})
+		}
+		listener, err = stdnet.Listen("tcp", ":0")
+	}
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to open the port of the paired devices", err)
+	}
+
+	d.port = listener.Addr().(*stdnet.TCPAddr).Port
+	if d.port != store.Port {
+		store.Port = d.port
+		if err := store.Save(d.path); err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	d.server = devices.NewServer(d.path, cert.LocalHostname(), state)
+	server := &http.Server{
+		Handler:           d.server.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) && d.log != nil {
+			d.log.Error("Device server stopped", logger.Field{Key: "error", Value: err.Error()})
+		}
+	}()
+	d.shutdown = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		// The event streams only end with their connection
+		if server.Shutdown(ctx) != nil {
+			server.Close()
+		}
+	}
+
+	if d.log != nil {
+		d.log.Info("Device server started", logger.Field{Key: "port", Value: d.port})
+	}
+	return nil
+}
+
+// pair opens the port if needed and returns a new pairing code
+func (d *deviceServer) pair(state devices.State) (api.Pairing, error) {
+	if err := d.start(state); err != nil {
+		return api.Pairing{}, err
+	}
+
+	d.mu.Lock()
+	server, port := d.server, d.port
+	d.mu.Unlock()
+	code, expires, err := server.NewCode()
+	if err != nil {
+		return api.Pairing{}, err
+	}
+
+	host := stdnet.JoinHostPort(state.IP, strconv.Itoa(port))
+	return api.Pairing{
+		URL:       fmt.Sprintf("http://%s/v1/pair?code=%s", host, code),
+		Code:      code,
+		ExpiresAt: expires,
+	}, nil
+}
+
+// publish pushes the state to the connected devices
+func (d *deviceServer) publish(state devices.State) {
+	d.mu.Lock()
+	server := d.server
+	d.mu.Unlock()
+	if server != nil {
+		server.Publish(state)
+	}
+}
+
+// disconnect closes the event streams of the devices that are no longer paired
+func (d *deviceServer) disconnect() error {
+	d.mu.Lock()
+	server := d.server
+	d.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Disconnect()
+}
+
+// close closes the port of the device server
+func (d *deviceServer) close() {
+	d.mu.Lock()
+	shutdown := d.shutdown
+	d.mu.Unlock()
+	if shutdown != nil {
+		shutdown()
+	}
+}
+
+// Pair returns a code pairing a device with the watch mode
+func (b *startBackend) Pair() (api.Pairing, error) {
+	if b.cmd.devices == nil {
+		return api.Pairing{}, errors.New("pairing is only available in the daemon")
+	}
+	return b.cmd.devices.pair(deviceState(b.cmd.currentState()))
+}
+
+// Disconnect closes the event streams of the devices unpaired by 'lanup pair remove'
+func (b *startBackend) Disconnect() error {
+	if b.cmd.devices == nil {
+		return nil
+	}
+	return b.cmd.devices.disconnect()
+}
+
+// deviceState returns the part of the state sent to the paired devices
+func deviceState(state api.State) devices.State {
+	return devices.State{
+		Project:   filepath.Base(state.Project),
+		IP:        state.IP,
+		Hostname:  state.Hostname,
+		URLs:      state.URLs,
+		UpdatedAt: state.UpdatedAt,
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/api"
+	"github.com/raucheacho/lanup/internal/devices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceServer(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	state := api.State{Project: tmpDir, IP: "127.0.0.1", URLs: map[string]string{"API_URL": "http://127.0.0.1:8000"}}
+
+	// Nothing is served until a device pairs
+	server, err := newDeviceServer(projectDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, server.resume(deviceState(state)))
+	assert.Zero(t, server.port)
+
+	pairing, err := server.pair(deviceState(state))
+	require.NoError(t, err)
+	port := server.port
+	assert.NotZero(t, port)
+	pairURL, err := url.Parse(pairing.URL)
+	require.NoError(t, err)
+	assert.Equal(t, pairing.Code, pairURL.Query().Get("code"))
+
+	resp, err := http.Post(pairing.URL, "application/json", strings.NewReader(`{"name": "Pixel 7"}`))
+	require.NoError(t, err)
+	var pair devices.PairResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pair))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "http://127.0.0.1:8000", pair.State.URLs["API_URL"])
+	server.close()
+
+	// The next run serves the paired devices on the same port
+	server, err = newDeviceServer(projectDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, server.resume(deviceState(state)))
+	defer server.close()
+	assert.Equal(t, port, server.port)
+
+	store, _, err := loadDevices()
+	require.NoError(t, err)
+	require.Len(t, store.Devices, 1)
+	require.NoError(t, (&PairCmd{}).Remove("Pixel 7"))
+	assert.Error(t, (&PairCmd{}).Remove("Pixel 7"))
+}
+
+func TestPairCmd_Run_NoDaemon(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	assert.Error(t, (&PairCmd{}).Run())
+}
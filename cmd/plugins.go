@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/plugin"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// PluginsCmd represents the plugins command
+type PluginsCmd struct{}
+
+// NewPluginsCmd creates a new plugins command
+func NewPluginsCmd() *cobra.Command {
+	pluginsCmd := &PluginsCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "List the lanup-<name> plugins found on PATH",
+		Long: `List the plugins found on PATH with the capabilities they declare.
+
+A plugin is an executable named lanup-<name>. lanup writes a JSON request on its
+standard input and reads a JSON response from its standard output:
+
+  {"version": 1, "action": "detect", "project": "/path/to/project"}
+  {"vars": [{"key": "RAILS_URL", "value": "http://localhost:3000"}]}
+
+Plugins are detectors, formatters or notifiers, enabled in .lanup.yaml:
+
+  plugins:
+    detectors: [rails]
+    notifiers: [slack]
+  format: json
+
+Examples:
+  lanup plugins`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pluginsCmd.Run()
+		},
+	}
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewPluginsCmd())
+}
+
+// Run executes the plugins command
+func (c *PluginsCmd) Run() error {
+	plugins := plugin.Discover()
+	if len(plugins) == 0 {
+		utils.Info("No plugins found on PATH (executables named %s<name>)", plugin.Prefix)
+		return nil
+	}
+
+	table := utils.NewTable("NAME", "CAPABILITIES", "PATH")
+	for _, p := range plugins {
+		capabilities := color.RedString("no answer")
+		if info, err := p.Info(context.Background()); err == nil {
+			capabilities = strings.Join(info.Capabilities, ", ")
+		}
+		table.AddRow(p.Name, capabilities, p.Path)
+	}
+	table.Print()
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// PortsCmd represents the ports command
+type PortsCmd struct {
+	Loopback bool
+}
+
+// Reachability describes from where a listening port can be reached
+type Reachability int
+
+const (
+	// ReachableLAN means the port listens on every interface or on the LAN IP
+	ReachableLAN Reachability = iota
+	// ReachableLoopback means the port only accepts connections from this machine
+	ReachableLoopback
+	// ReachableOther means the port is bound to another interface (e.g. a Docker bridge or VPN)
+	ReachableOther
+)
+
+// NewPortsCmd creates a new ports command
+func NewPortsCmd() *cobra.Command {
+	portsCmd := &PortsCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "ports",
+		Short: "List listening ports and whether they are reachable from the LAN",
+		Long: `List the TCP ports listening on this machine with their owning process and bind address.
+
+Ports bound to every interface (0.0.0.0, ::) or to your LAN IP can be reached from other
+devices. Loopback-only ports (127.0.0.1, ::1) cannot: restart the service bound to 0.0.0.0
+or expose it with 'lanup serve'.
+
+Only processes owned by the current user are listed unless run as root.
+
+Examples:
+  lanup ports
+  lanup ports --loopback`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return portsCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().BoolVar(&portsCmd.Loopback, "loopback", false, "only show loopback-only ports")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewPortsCmd())
+}
+
+// Run executes the ports command
+func (c *PortsCmd) Run() error {
+	ports, err := net.GetListeningPorts()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to list listening ports (is lsof installed?)", err)
+	}
+
+	// The LAN IP is optional, without it only wildcard sockets are reported as reachable
+	lanIP := ""
+	if netInfo, err := net.DetectLocalIP(); err == nil {
+		lanIP = netInfo.IP
+	}
+
+	if c.Loopback {
+		filtered := ports[:0]
+		for _, p := range ports {
+			if portReachability(p, lanIP) == ReachableLoopback {
+				filtered = append(filtered, p)
+			}
+		}
+		ports = filtered
+	}
+
+	if len(ports) == 0 {
+		utils.Info("No listening ports found")
+		return nil
+	}
+
+	sortPorts(ports)
+	c.displayPorts(ports, lanIP)
+
+	return nil
+}
+
+// displayPorts prints the ports as a table
+func (c *PortsCmd) displayPorts(ports []net.ListeningPort, lanIP string) {
+	table := utils.NewTable("PORT", "ADDRESS", "PID", "PROCESS", "REACHABLE")
+
+	loopbackOnly := 0
+	for _, p := range ports {
+		pid := "-"
+		if p.PID > 0 {
+			pid = fmt.Sprint(p.PID)
+		}
+		process := p.Process
+		if process == "" {
+			process = "-"
+		}
+
+		var reachable string
+		switch portReachability(p, lanIP) {
+		case ReachableLAN:
+			reachable = color.GreenString("LAN")
+		case ReachableLoopback:
+			reachable = color.YellowString("loopback only")
+			loopbackOnly++
+		default:
+			reachable = color.HiBlackString("other interface")
+		}
+
+		table.AddRow(fmt.Sprint(p.Port), p.Address, pid, process, reachable)
+	}
+	table.Print()
+
+	if loopbackOnly > 0 {
+		utils.Println()
+		utils.Info("Loopback-only ports are not reachable from other devices.")
+		utils.Println("   Bind them to 0.0.0.0 or run 'lanup serve --route /=http://localhost:PORT'")
+	}
+}
+
+// portReachability reports from where a listening port can be reached
+func portReachability(p net.ListeningPort, lanIP string) Reachability {
+	switch {
+	case p.IsWildcard():
+		return ReachableLAN
+	case p.IsLoopbackOnly():
+		return ReachableLoopback
+	case lanIP != "" && strings.Trim(p.Address, "[]") == lanIP:
+		return ReachableLAN
+	default:
+		return ReachableOther
+	}
+}
+
+// sortPorts orders ports by number, then by address
+func sortPorts(ports []net.ListeningPort) {
+	sort.SliceStable(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Address < ports[j].Address
+	})
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortReachability(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected Reachability
+	}{
+		{"*", ReachableLAN},
+		{"0.0.0.0", ReachableLAN},
+		{"::", ReachableLAN},
+		{"192.168.1.100", ReachableLAN},
+		{"127.0.0.1", ReachableLoopback},
+		{"::1", ReachableLoopback},
+		{"172.17.0.1", ReachableOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			p := net.ListeningPort{Port: 3000, Address: tt.address}
+			assert.Equal(t, tt.expected, portReachability(p, "192.168.1.100"))
+		})
+	}
+}
+
+func TestSortPorts(t *testing.T) {
+	ports := []net.ListeningPort{
+		{Port: 8080, Address: "*"},
+		{Port: 3000, Address: "::1"},
+		{Port: 3000, Address: "127.0.0.1"},
+	}
+
+	sortPorts(ports)
+
+	assert.Equal(t, []net.ListeningPort{
+		{Port: 3000, Address: "127.0.0.1"},
+		{Port: 3000, Address: "::1"},
+		{Port: 8080, Address: "*"},
+	}, ports)
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// Project statuses shown by 'lanup projects'
+const (
+	projectUpToDate      = "up to date"
+	projectStale         = "stale"          // written for another IP
+	projectOutputMissing = "output missing" // an env file was deleted
+	projectMissing       = "missing"        // the directory or its configuration is gone
+	projectUnknown       = "unknown"        // no network to compare with
+)
+
+// ProjectsCmd represents the projects command
+type ProjectsCmd struct {
+	All bool // refresh every project, not only the stale ones
+}
+
+// NewProjectsCmd creates a new projects command
+func NewProjectsCmd() *cobra.Command {
+	projectsCmd := &ProjectsCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "List the projects lanup manages",
+		Long: `List every project lanup generated an env file for, as recorded in ~/.lanup/state.json,
+with its env files, the IP they were written for and whether they are stale.
+
+A project is stale when its env files were written for another IP than the current one,
+e.g. after joining another network. 'lanup projects refresh' regenerates them.
+
+Examples:
+  lanup projects
+  lanup projects refresh
+  lanup projects refresh --all
+  lanup projects refresh ~/code/myapp`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return projectsCmd.List()
+		},
+	}
+
+	refreshCmd := &cobra.Command{
+		Use:   "refresh [directory...]",
+		Short: "Regenerate the env files of the stale projects, or of the given ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return projectsCmd.Refresh(args)
+		},
+	}
+	refreshCmd.Flags().BoolVar(&projectsCmd.All, "all", false, "regenerate every project, not only the stale ones")
+
+	cmd.AddCommand(refreshCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewProjectsCmd())
+}
+
+// List prints the recorded projects
+func (c *ProjectsCmd) List() error {
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+	projects := st.List()
+	if len(projects) == 0 {
+		utils.Info("No projects recorded yet, run 'lanup start' in a project")
+		return nil
+	}
+
+	currentIP := detectCurrentIP()
+	table := utils.NewTable("PROJECT", "PROFILE", "OUTPUT", "LAST IP", "LAST RUN", "WATCHER", "STATUS")
+	for _, p := range projects {
+		lastRun := "-"
+		if !p.LastRun.IsZero() {
+			lastRun = p.LastRun.Local().Format("2006-01-02 15:04")
+		}
+		watcher := "-"
+		if w := p.ActiveWatcher(); w != nil {
+			watcher = fmt.Sprintf("%s (PID %d)", w.Mode, w.PID)
+		} else if pid, running := sharedDaemonPID(); running && p.Daemon {
+			watcher = fmt.Sprintf("shared daemon (PID %d)", pid)
+		}
+		table.AddRow(p.Dir, valueOrDash(p.Profile), valueOrDash(projectOutputs(p)),
+			valueOrDash(p.LastIP), lastRun, watcher, colorProjectStatus(projectStatus(p, currentIP)))
+	}
+	table.Print()
+
+	if currentIP != "" {
+		utils.Println()
+		utils.Info("Current IP: %s", currentIP)
+	}
+	return nil
+}
+
+// Refresh regenerates the env files of the given project directories, of all projects with --all,
+// or of the stale ones
+func (c *ProjectsCmd) Refresh(dirs []string) error {
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	currentIP := detectCurrentIP()
+	var projects []*state.Project
+	switch {
+	case len(dirs) > 0:
+		for _, dir := range dirs {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return lanuperrors.FromOSError("Failed to resolve project directory", err)
+			}
+			p, ok := st.Projects[abs]
+			if !ok {
+				return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+					fmt.Sprintf("%s is not a lanup project, run 'lanup start' in it first", abs), nil)
+			}
+			projects = append(projects, p)
+		}
+	default:
+		for _, p := range st.List() {
+			status := projectStatus(p, currentIP)
+			if status == projectMissing {
+				continue
+			}
+			if c.All || status == projectStale || status == projectOutputMissing {
+				projects = append(projects, p)
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		utils.Success("All projects are up to date")
+		return nil
+	}
+
+	failed := 0
+	for _, p := range projects {
+		utils.PrintSection(p.Dir)
+		if err := refreshProject(p); err != nil {
+			failed++
+			utils.Error("%v", err)
+		}
+		utils.Println()
+	}
+
+	if failed > 0 {
+		return lanuperrors.NewError(lanuperrors.ErrIO,
+			fmt.Sprintf("Failed to refresh %d of %d project(s)", failed, len(projects)), nil)
+	}
+	utils.Success("Refreshed %d project(s)", len(projects))
+	return nil
+}
+
+// refreshProject runs 'lanup start' in the project directory, with the profile of its last run
+func refreshProject(p *state.Project) error {
+	return inProject(p.Dir, func() error {
+		start := &StartCmd{Profile: p.Profile, Log: true, brief: true, reason: runReasonRefresh}
+		return start.Run()
+	})
+}
+
+// loadState reads the state file
+func loadState() (*state.State, error) {
+	path, err := state.DefaultPath()
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			"Failed to determine state file", err)
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return nil, lanuperrors.FromOSError("Failed to read state file", err)
+	}
+	return st, nil
+}
+
+// detectCurrentIP returns the current LAN IP, empty when there is no network
+func detectCurrentIP() string {
+	netInfo, err := net.DetectLocalIP()
+	if err != nil {
+		return ""
+	}
+	return netInfo.IP
+}
+
+// projectStatus tells whether the env files of a project match the current IP
+func projectStatus(p *state.Project, currentIP string) string {
+	switch {
+	case p.Missing():
+		return projectMissing
+	case len(p.MissingOutputs()) > 0:
+		return projectOutputMissing
+	case currentIP == "":
+		return projectUnknown
+	case p.LastIP != currentIP:
+		return projectStale
+	default:
+		return projectUpToDate
+	}
+}
+
+// colorProjectStatus highlights the statuses that need attention
+func colorProjectStatus(status string) string {
+	switch status {
+	case projectUpToDate:
+		return color.GreenString(status)
+	case projectStale, projectOutputMissing:
+		return color.YellowString(status)
+	case projectMissing:
+		return color.RedString(status)
+	default:
+		return status
+	}
+}
+
+// projectOutputs lists the env files of a project, relative to its directory
+func projectOutputs(p *state.Project) string {
+	outputs := make([]string, 0, len(p.Outputs))
+	for _, output := range p.Outputs {
+		if rel, err := filepath.Rel(p.Dir, output); err == nil && !strings.HasPrefix(rel, "..") {
+			output = rel
+		}
+		outputs = append(outputs, output)
+	}
+	return strings.Join(outputs, ", ")
+}
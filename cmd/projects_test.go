@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectStatus(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".lanup.yaml")
+	output := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(configPath, []byte("vars: {}\n"), 0644))
+	require.NoError(t, os.WriteFile(output, nil, 0644))
+
+	project := func(lastIP string, outputs ...string) *state.Project {
+		return &state.Project{Dir: dir, Config: configPath, LastIP: lastIP, Outputs: outputs}
+	}
+
+	assert.Equal(t, projectUpToDate, projectStatus(project("192.168.1.20", output), "192.168.1.20"))
+	assert.Equal(t, projectStale, projectStatus(project("10.0.0.5", output), "192.168.1.20"))
+	assert.Equal(t, projectUnknown, projectStatus(project("10.0.0.5", output), ""))
+	assert.Equal(t, projectOutputMissing, projectStatus(project("192.168.1.20", filepath.Join(dir, ".env.gone")), "192.168.1.20"))
+	assert.Equal(t, projectMissing, projectStatus(&state.Project{Dir: filepath.Join(dir, "gone")}, "192.168.1.20"))
+
+	assert.Equal(t, ".env", projectOutputs(project("", output)))
+}
+
+func TestProjectsCmd_Refresh(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	// Two projects started once, so that they are recorded
+	for _, name := range []string{"a", "b"} {
+		dir := filepath.Join(home, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, config.SaveProjectConfig(filepath.Join(dir, ".lanup.yaml"), &config.ProjectConfig{
+			Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+			Output: ".env",
+		}))
+		require.NoError(t, os.Chdir(dir))
+		require.NoError(t, (&StartCmd{}).Run())
+	}
+	require.NoError(t, os.Chdir(originalWd))
+
+	statePath, err := state.DefaultPath()
+	require.NoError(t, err)
+	before, err := state.Load(statePath)
+	require.NoError(t, err)
+	require.Len(t, before.Projects, 2)
+	a, b := before.List()[1], before.List()[0]
+	currentIP := a.LastIP
+
+	// Project a was written on another network
+	require.NoError(t, state.Update(statePath, func(s *state.State) {
+		s.Projects[a.Dir].LastIP = "10.0.0.5"
+	}))
+
+	require.NoError(t, (&ProjectsCmd{}).Refresh(nil))
+
+	after, err := state.Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, currentIP, after.Projects[a.Dir].LastIP, "stale project refreshed")
+	assert.True(t, after.Projects[b.Dir].LastRun.Equal(b.LastRun), "up to date project left alone")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, originalWd, wd, "working directory restored")
+
+	// Explicit directories must be recorded projects
+	err = (&ProjectsCmd{}).Refresh([]string{filepath.Join(home, "unknown")})
+	assert.ErrorIs(t, err, lanuperrors.ErrFileNotFound)
+
+	require.NoError(t, (&ProjectsCmd{All: true}).Refresh(nil))
+	after, err = state.Load(statePath)
+	require.NoError(t, err)
+	assert.True(t, after.Projects[b.Dir].LastRun.After(b.LastRun), "--all refreshes every project")
+}
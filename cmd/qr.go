@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// QRCmd represents the qr command
+type QRCmd struct {
+	Names []string
+}
+
+// NewQRCmd creates a new qr command
+func NewQRCmd() *cobra.Command {
+	qrCmd := &QRCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "qr [NAME...]",
+		Short: "Show QR codes for your exposed services",
+		Long: `Render a terminal QR code for each URL generated from .lanup.yaml.
+
+Point your phone's camera at the terminal instead of typing 192.168.x.x addresses.
+Pass one or more variable names to only show those services.
+
+Examples:
+  lanup qr
+  lanup qr API_URL DASHBOARD_URL`,
+		ValidArgsFunction: completeServiceNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			qrCmd.Names = args
+			return qrCmd.Run()
+		},
+	}
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewQRCmd())
+}
+
+// Run executes the qr command
+func (c *QRCmd) Run() error {
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	// Detect local IP
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+
+	found, err := collectVariables(context.Background(), projectConfig, nil)
+	if err != nil {
+		return err
+	}
+	transformedVars := lanup.TransformConfig(projectConfig, found.Vars, netInfo.IP)
+	if metro := found.Metro; metro != nil {
+		transformedVars = append(transformedVars, env.EnvVar{Key: "Expo", Value: metro.ExpoURL(netInfo.IP)})
+	}
+
+	urls := filterURLVars(transformedVars, c.Names)
+	if len(urls) == 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"No service URLs to display", nil)
+	}
+
+	utils.Success("Local IP: %s", netInfo.IP)
+	utils.PrintSection("Scan to open on your device")
+	return printQRCodes(urls)
+}
+
+// filterURLVars returns the variables whose value is a URL, restricted to names when provided
+func filterURLVars(vars []env.EnvVar, names []string) []env.EnvVar {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[strings.ToUpper(name)] = true
+	}
+
+	var urls []env.EnvVar
+	for _, v := range vars {
+		if !strings.Contains(v.Value, "://") {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[strings.ToUpper(v.Key)] {
+			continue
+		}
+		urls = append(urls, v)
+	}
+
+	return urls
+}
+
+// printQRCodes renders a QR code for each URL variable
+func printQRCodes(urls []env.EnvVar) error {
+	for _, v := range urls {
+		if err := utils.PrintQRCode(v.Key, v.Value); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidURL,
+				fmt.Sprintf("Failed to render QR code for %s", v.Key), err)
+		}
+	}
+	return nil
+}
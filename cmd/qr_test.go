@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterURLVars(t *testing.T) {
+	vars := []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000"},
+		{Key: "ANON_KEY", Value: "secret"},
+		{Key: "WS_URL", Value: "ws://192.168.1.100:8080"},
+	}
+
+	all := filterURLVars(vars, nil)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "API_URL", all[0].Key)
+	assert.Equal(t, "WS_URL", all[1].Key)
+
+	// Names are matched case-insensitively
+	only := filterURLVars(vars, []string{"api_url"})
+	assert.Len(t, only, 1)
+	assert.Equal(t, "API_URL", only[0].Key)
+
+	assert.Empty(t, filterURLVars(vars, []string{"ANON_KEY"}))
+}
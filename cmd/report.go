@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/crash"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// issuesURL is where bug reports are filed
+const issuesURL = "https://github.com/raucheacho/lanup/issues/new"
+
+// defaultReportLines is how many lines of each log file a report includes
+const defaultReportLines = 500
+
+// ReportCmd represents the report command
+type ReportCmd struct {
+	Output string
+	Crash  string
+	Lines  int
+}
+
+// NewReportCmd creates a new report command
+func NewReportCmd() *cobra.Command {
+	reportCmd := &ReportCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Bundle the last crash report and the logs for a bug report",
+		Long: `Write a zip archive to attach to a GitHub issue, with:
+  - the last crash report of ~/.lanup/crash, or the one given with --crash
+  - the end of the lanup log and of the daemon log
+  - the state of the current project, as printed by 'lanup export'
+
+When lanup crashes, it saves a crash report with the stack trace, the version and a
+summary of the configuration. Neither contains the values of the variables or secrets,
+but check the archive before sharing it: the logs and the state include your IP
+addresses and URLs.
+
+Examples:
+  lanup report
+  lanup report -o ~/Desktop/lanup-report.zip
+  lanup report --crash ~/.lanup/crash/crash-20251027-235012-4242.txt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return reportCmd.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&reportCmd.Output, "output", "o", "", "archive to write (default lanup-report-<time>.zip)")
+	cmd.Flags().StringVar(&reportCmd.Crash, "crash", "", "crash report to include instead of the last one")
+	cmd.Flags().IntVar(&reportCmd.Lines, "lines", defaultReportLines, "lines of each log file to include")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewReportCmd())
+}
+
+// Run writes the report archive
+func (c *ReportCmd) Run() error {
+	if c.Lines < 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid number of lines: %d", c.Lines), nil)
+	}
+
+	crashPath := c.Crash
+	if crashPath == "" {
+		if dir, err := crash.DefaultDir(); err == nil {
+			if reports, err := crash.List(dir); err == nil && len(reports) > 0 {
+				crashPath = reports[0]
+			}
+		}
+	}
+
+	output := c.Output
+	if output == "" {
+		output = fmt.Sprintf("lanup-report-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	files, err := c.reportFiles(crashPath)
+	if err != nil {
+		return err
+	}
+	if err := writeZip(output, files); err != nil {
+		return lanuperrors.FromOSError("Failed to write the report", err)
+	}
+
+	utils.Success("Report written to %s", output)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	utils.Info("Contents: %s", strings.Join(names, ", "))
+	if crashPath == "" {
+		utils.Info("No crash report found, the report only has the logs and the state")
+	}
+	utils.Println("   Check it for anything private, then attach it to an issue: " + issuesURL)
+	return nil
+}
+
+// reportFiles returns the contents of the report archive, by file name
+func (c *ReportCmd) reportFiles(crashPath string) (map[string][]byte, error) {
+	files := map[string][]byte{
+		"system.txt": []byte(fmt.Sprintf("lanup %s\n%s %s/%s\n", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)),
+	}
+
+	if crashPath != "" {
+		data, err := os.ReadFile(crashPath)
+		if err != nil {
+			return nil, lanuperrors.FromOSError("Failed to read the crash report", err)
+		}
+		files["crash.txt"] = data
+	}
+
+	if globalCfg := GetGlobalConfig(); globalCfg != nil && globalCfg.LogPath != "" && c.Lines > 0 {
+		if lines, err := tailFiles(logFiles(globalCfg.LogPath), c.Lines); err == nil && len(lines) > 0 {
+			files["lanup.log"] = []byte(strings.Join(lines, "\n") + "\n")
+		}
+	}
+	if c.Lines > 0 {
+		if lines, err := tailFiles(logFiles(daemonLogPath()), c.Lines); err == nil && len(lines) > 0 {
+			files["daemon.log"] = []byte(strings.Join(lines, "\n") + "\n")
+		}
+	}
+
+	// The state needs a project, the report is still useful without it
+	if projectConfig, err := config.LoadProjectConfig(""); err == nil {
+		report, err := buildExportReport(projectConfig, "")
+		if err != nil {
+			utils.Warning("Failed to export the state of the project: %v", err)
+		} else if data, err := yaml.Marshal(report); err == nil {
+			files["state.yaml"] = data
+		}
+	}
+
+	return files, nil
+}
+
+// writeZip writes the files to a zip archive at path
+func writeZip(path string, files map[string][]byte) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(out)
+	now := time.Now()
+	for name, data := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			out.Close()
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// HandlePanic turns a panic of the command into a crash report, and exits
+// It must be deferred by main: panics of other goroutines still crash the process as usual.
+func HandlePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	report := crash.Report{
+		Time:    time.Now(),
+		Version: Version,
+		Args:    crash.SanitizeArgs(os.Args[1:]),
+		Panic:   fmt.Sprint(r),
+		Stack:   debug.Stack(),
+		Config:  configSummary(),
+	}
+
+	fmt.Fprintf(os.Stderr, "\nlanup crashed: %s\n", report.Panic)
+	dir, err := crash.DefaultDir()
+	var path string
+	if err == nil {
+		path, err = crash.Write(dir, report)
+	}
+	if err != nil {
+		// Without a report, the stack trace is the only trace of the crash
+		fmt.Fprintf(os.Stderr, "Failed to save the crash report: %v\n\n%s", err, report.Stack)
+	} else {
+		fmt.Fprintf(os.Stderr, "A crash report was saved to %s\n", path)
+		fmt.Fprintf(os.Stderr, "Please run 'lanup report' and attach the archive to an issue: %s\n", issuesURL)
+	}
+	os.Exit(1)
+}
+
+// configSummary describes the configuration for a crash report, with the names of the variables
+// but not their values
+func configSummary() (summary string) {
+	// The configuration may be what made lanup crash
+	defer func() {
+		if r := recover(); r != nil {
+			summary = fmt.Sprintf("  unavailable: %v\n", r)
+		}
+	}()
+
+	var b strings.Builder
+	if globalCfg := GetGlobalConfig(); globalCfg != nil {
+		fmt.Fprintf(&b, "  global: log_level=%s check_interval=%d detect_interval=%d style=%s\n",
+			globalCfg.LogLevel, globalCfg.CheckInterval, globalCfg.DetectInterval, globalCfg.Style)
+	}
+
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		fmt.Fprintf(&b, "  project: %v\n", err)
+		return b.String()
+	}
+	format := projectConfig.Format
+	if format == "" {
+		format = "dotenv"
+	}
+	fmt.Fprintf(&b, "  project: %s\n", projectConfig.Path())
+	fmt.Fprintf(&b, "  output: %s (%s)\n", projectConfig.Output, format)
+	fmt.Fprintf(&b, "  vars: %s\n", summaryNames(projectConfig.Vars))
+	fmt.Fprintf(&b, "  static_vars: %s\n", summaryNames(projectConfig.StaticVars))
+
+	detectors := make([]string, 0, len(projectConfig.Detectors))
+	for _, d := range projectConfig.Detectors {
+		detectors = append(detectors, d.Name)
+	}
+	fmt.Fprintf(&b, "  detectors: %s\n", strings.Join(detectors, ", "))
+	fmt.Fprintf(&b, "  ip: %t, hostname: %t, templates: %d, files: %d, expo: %t\n",
+		projectConfig.IP != "", projectConfig.Hostname != "", len(projectConfig.Templates),
+		len(projectConfig.Files), projectConfig.Expo.AppJSON != "")
+	fmt.Fprintf(&b, "  profiles: %s\n", strings.Join(projectConfig.ProfileNames(), ", "))
+	return b.String()
+}
+
+// summaryNames returns the sorted keys of vars
+func summaryNames(vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
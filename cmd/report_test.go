@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/crash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+		IP:     "192.168.1.50",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	crashDir, err := crash.DefaultDir()
+	require.NoError(t, err)
+	_, err = crash.Write(crashDir, crash.Report{Panic: "boom", Config: configSummary()})
+	require.NoError(t, err)
+
+	output := filepath.Join(tmpDir, "report.zip")
+	require.NoError(t, (&ReportCmd{Output: output, Lines: defaultReportLines}).Run())
+
+	archive, err := zip.OpenReader(output)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	contents := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		contents[f.Name] = string(data)
+	}
+
+	assert.Contains(t, contents["system.txt"], "lanup ")
+	assert.Contains(t, contents["crash.txt"], "Panic:   boom")
+	assert.Contains(t, contents["crash.txt"], "vars: API_URL")
+	assert.NotContains(t, contents["crash.txt"], "http://localhost:8000")
+	assert.Contains(t, contents["state.yaml"], "API_URL")
+}
+
+func TestReportCmd_Run_MissingCrash(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	err := (&ReportCmd{Output: filepath.Join(tmpDir, "report.zip"), Crash: filepath.Join(tmpDir, "missing.txt")}).Run()
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/backup"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/process"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// RestoreCmd represents the restore command
+type RestoreCmd struct {
+	At      string
+	List    bool
+	Yes     bool
+	Profile string
+	Output  string // env file restored instead of the configured one
+}
+
+// NewRestoreCmd creates a new restore command
+func NewRestoreCmd() *cobra.Command {
+	restoreCmd := &RestoreCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the env file from a backup",
+		Long: `Restore the env file as it was at a given time, from the backups lanup keeps in
+~/.lanup/backups before each rewrite (the last 20 per file).
+
+--at takes a time ("10:30" for today, "2024-05-01 10:30") or a duration ago (2h, 3d), like
+'lanup logs --since'. The backup holding the content of the file at that time is shown as a
+diff against the current file and restored after confirmation. Without --at, the file is
+restored as it was before the last rewrite. The current content is backed up first, so a
+restore can be undone with another one.
+
+This helps when a detector wrote wrong values several runs ago and the .bak file, which
+only holds the content before the last run, already has them.
+
+Examples:
+  lanup restore --list
+  lanup restore --at 10:30
+  lanup restore --at 2h --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return restoreCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVar(&restoreCmd.At, "at", "", "restore the file as it was at this time or duration ago (e.g. 10:30, 2h)")
+	cmd.Flags().BoolVar(&restoreCmd.List, "list", false, "list the backups of the env file")
+	cmd.Flags().BoolVarP(&restoreCmd.Yes, "yes", "y", false, "restore without asking for confirmation")
+	cmd.Flags().StringVar(&restoreCmd.Profile, "profile", "", "configuration profile whose output to restore (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().StringVarP(&restoreCmd.Output, "output", "o", "", "restore this env file instead of the configured output")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewRestoreCmd())
+}
+
+// Run executes the restore command
+func (c *RestoreCmd) Run() error {
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+	if err := overrideOutput(projectConfig, c.Output); err != nil {
+		return err
+	}
+
+	envWriter, err := newEnvWriter(projectConfig)
+	if err != nil {
+		return err
+	}
+	if envWriter.Store == nil {
+		return lanuperrors.NewError(lanuperrors.ErrFileNotFound, "Failed to locate the backups", nil)
+	}
+	backups, err := envWriter.Store.List(envWriter.FilePath)
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to list the backups", err)
+	}
+	if len(backups) == 0 {
+		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			fmt.Sprintf("No backup of %s yet, lanup keeps one each time it rewrites the file", envWriter.FilePath), nil)
+	}
+
+	if c.List {
+		displayBackups(backups)
+		return nil
+	}
+
+	selected := &backups[len(backups)-1]
+	if c.At != "" {
+		at, err := parseTimeFlag(c.At, time.Now())
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --at flag", err)
+		}
+		if selected = backup.At(backups, at); selected == nil {
+			utils.Info("%s hasn't been rewritten since %s, nothing to restore", envWriter.FilePath, at.Format("2006-01-02 15:04:05"))
+			return nil
+		}
+	}
+
+	return c.restore(envWriter, selected)
+}
+
+// restore shows the changes restoring b makes to the env file, and restores it once confirmed
+func (c *RestoreCmd) restore(envWriter *env.EnvWriter, b *backup.Backup) error {
+	data, err := b.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read the backup", err)
+	}
+
+	// The backup is read with the format of the env file
+	backupReader := *envWriter
+	backupReader.FilePath = b.Path
+	restored, err := backupReader.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read the backup", err)
+	}
+	current, err := envWriter.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read the env file", err)
+	}
+
+	utils.Info("Backup of %s taken %s", envWriter.FilePath, b.Time.Format("2006-01-02 15:04:05"))
+	utils.Println()
+	if !displayRestoreChanges(current, restored) {
+		utils.Success("%s already has the content of this backup", envWriter.FilePath)
+		return nil
+	}
+	utils.Println()
+
+	if !c.Yes {
+		fmt.Printf("Restore %s? (y/N): ", envWriter.FilePath)
+		response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return lanuperrors.FromOSError("Failed to read confirmation", err)
+		}
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	lock, err := lockProject(nil)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	if err := envWriter.Restore(data); err != nil {
+		return lanuperrors.FromOSError("Failed to restore the env file", err)
+	}
+
+	utils.Success("Restored %s as of %s", envWriter.FilePath, b.Time.Format("2006-01-02 15:04:05"))
+	if pidPath, err := process.WatchPIDFile(projectDir()); err == nil {
+		if _, running := process.RunningPID(pidPath); running {
+			utils.Warning("Watch mode is running for this project and rewrites the file on the next change, stop it with 'lanup stop'")
+		}
+	}
+	return nil
+}
+
+// displayBackups prints the backups, most recent first
+func displayBackups(backups []backup.Backup) {
+	table := utils.NewTable("TAKEN", "FILE")
+	for i := len(backups) - 1; i >= 0; i-- {
+		table.AddRow(backups[i].Time.Format("2006-01-02 15:04:05"), backups[i].Path)
+	}
+	table.Print()
+}
+
+// displayRestoreChanges prints the variables restoring changes, managed or not, and reports
+// whether there are any
+func displayRestoreChanges(current, restored []env.EnvVar) bool {
+	changes := env.Diff(allManaged(current), allManaged(restored))
+
+	changed, added, removed := 0, 0, 0
+	for _, change := range changes {
+		switch change.Kind {
+		case env.Modified:
+			changed++
+			fmt.Printf("%s %s\n", color.YellowString("~"), change.Key)
+			fmt.Printf("    %s\n", color.RedString("- %s", change.OldValue))
+			fmt.Printf("    %s\n", color.GreenString("+ %s", change.NewValue))
+		case env.Added:
+			added++
+			fmt.Println(color.GreenString("+ %s=%s", change.Key, change.NewValue))
+		case env.Removed:
+			removed++
+			fmt.Println(color.RedString("- %s=%s", change.Key, change.OldValue))
+		}
+	}
+
+	if changed+added+removed == 0 {
+		return false
+	}
+	fmt.Println()
+	fmt.Printf("%d changed, %d restored, %d removed\n", changed, added, removed)
+	return true
+}
+
+// allManaged returns a copy of vars marked as managed, for diffs covering the user variables too
+func allManaged(vars []env.EnvVar) []env.EnvVar {
+	result := make([]env.EnvVar, len(vars))
+	for i, v := range vars {
+		v.Managed = true
+		result[i] = v
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	err = (&RestoreCmd{Yes: true}).Run()
+	assert.ErrorIs(t, err, lanuperrors.ErrFileNotFound)
+
+	// Three runs wrote the file: good at 9:00, garbage at 10:00 and 11:00
+	store := backupStore()
+	envPath := filepath.Join(tmpDir, ".env.local")
+	day := time.Now().AddDate(0, 0, -1)
+	at := func(hour int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.Local)
+	}
+	good := "API_URL=http://192.168.1.10:8000\nSECRET=x\n"
+	require.NoError(t, store.Save(envPath, []byte(good), at(10)))
+	require.NoError(t, store.Save(envPath, []byte("API_URL=http://172.17.0.1:8000\nSECRET=x\n"), at(11)))
+	require.NoError(t, os.WriteFile(envPath, []byte("API_URL=http://172.17.0.2:8000\nSECRET=x\n"), 0644))
+
+	require.NoError(t, (&RestoreCmd{List: true}).Run())
+
+	// The file as it was at 9:30 is the content replaced at 10:00
+	restoreCmd := &RestoreCmd{At: day.Format("2006-01-02") + " 09:30", Yes: true}
+	require.NoError(t, restoreCmd.Run())
+	content, err := os.ReadFile(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, good, string(content))
+
+	// The replaced content is backed up, so the restore can be undone
+	backups, err := store.List(envPath)
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	data, err := backups[2].Read()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "172.17.0.2")
+
+	// The file hasn't been rewritten since now
+	require.NoError(t, (&RestoreCmd{At: "0s", Yes: true}).Run())
+	content, err = os.ReadFile(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, good, string(content))
+
+	err = (&RestoreCmd{At: "soon", Yes: true}).Run()
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+}
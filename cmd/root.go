@@ -3,9 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/logger"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +18,8 @@ var (
 	// Global flags
 	cfgFile string
 	verbose bool
+	quiet   bool
+	noColor bool
 
 	// Global configuration loaded at startup
 	globalConfig *config.GlobalConfig
@@ -35,24 +42,43 @@ your applications from any device on the same network without manual configurati
 	},
 }
 
-// Execute runs the root command
+// Execute runs the root command, then sends its telemetry event when telemetry is enabled
+// The returned error decides the exit code, see lanuperrors.ExitCode
 func Execute() error {
-	err := RootCmd.Execute()
-	if err != nil {
-		// If it's a LanupError, exit with the appropriate code
-		if lanupErr, ok := err.(*lanuperrors.LanupError); ok {
-			os.Exit(lanupErr.ExitCode())
-		}
-		// Otherwise, exit with generic error code
-		os.Exit(1)
-	}
-	return nil
+	started := time.Now()
+	cmd, err := RootCmd.ExecuteC()
+	waitNotifications()
+	sendTelemetry(cmd, err, time.Since(started))
+	return err
 }
 
 func init() {
 	// Add persistent flags available to all commands
-	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.lanup/config.yaml)")
-	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "global config file (default is $LANUP_CONFIG or $HOME/.lanup/config.yaml)")
+	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output (debug logs on stderr)")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print results, warnings and errors")
+	RootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also set by the NO_COLOR environment variable)")
+
+	// Unknown flags and invalid flag values exit with the configuration error code
+	RootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid flag", err)
+	})
+
+	// Applied before every command, including those replacing the root PersistentPreRunE
+	cobra.OnInitialize(func() {
+		if cfgFile != "" {
+			config.SetGlobalConfigPath(cfgFile)
+			// Processes started by lanup, such as the daemon and hooks, use the same file
+			if abs, err := filepath.Abs(cfgFile); err == nil {
+				os.Setenv(config.EnvGlobalConfig, abs)
+			}
+		}
+		utils.SetQuiet(quiet)
+		if noColor {
+			utils.SetColor(false)
+			logger.SetColors(false)
+		}
+	})
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -65,19 +91,14 @@ func initConfig() error {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to load global configuration", err)
 	}
 
-	// If verbose flag is set, override log level
+	utils.SetStyle(utils.Style(strings.ToLower(globalConfig.Style)))
+	config.SetProjectDefaults(globalConfig.Project)
+
+	// If verbose flag is set, override log level: debug entries are also shown on stderr
 	if verbose {
 		globalConfig.LogLevel = "debug"
 	}
 
-	// If a custom config file is specified, we could load it here
-	// For now, we always use the default ~/.lanup/config.yaml
-	if cfgFile != "" {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Note: Custom config file path is not yet supported, using default\n")
-		}
-	}
-
 	return nil
 }
 
@@ -85,3 +106,56 @@ func initConfig() error {
 func GetGlobalConfig() *config.GlobalConfig {
 	return globalConfig
 }
+
+// newFileLogger creates a logger writing to the global log file, and to the system log when enabled
+// It returns nil without error when the global configuration is not loaded
+func newFileLogger() (*logger.Logger, error) {
+	globalCfg := GetGlobalConfig()
+	if globalCfg == nil {
+		return nil, nil
+	}
+
+	// The format is checked when the configuration is loaded
+	format, _ := logger.ParseFormat(globalCfg.LogFormat)
+
+	// The system log is a secondary destination, the log file is still written without it
+	var sink logger.Sink
+	if globalCfg.SystemLog {
+		var err error
+		sink, err = logger.NewSystemSink(logger.SystemLogTag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to connect to the system log: %v\n", err)
+		}
+	}
+
+	return logger.NewLogger(logger.LoggerConfig{
+		Level:      parseLogLevel(globalCfg.LogLevel),
+		FilePath:   globalCfg.LogPath,
+		MaxSize:    5 * 1024 * 1024, // 5MB
+		MaxBackups: 5,
+		MaxAge:     time.Duration(globalCfg.LogMaxAge) * 24 * time.Hour,
+		Compress:   globalCfg.LogCompress,
+		Format:     format,
+		Sink:       sink,
+		Console:    verbose,
+		Output:     utils.Stderr,
+		Colors:     verbose && utils.ColorEnabled(),
+
+		// An outage makes the watcher log the same error at every check
+		RepeatInterval: 5 * time.Minute,
+	})
+}
+
+// parseLogLevel converts a configured log level name to a logger.LogLevel
+func parseLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(level) {
+	case "debug":
+		return logger.DEBUG
+	case "warn":
+		return logger.WARN
+	case "error":
+		return logger.ERROR
+	default:
+		return logger.INFO
+	}
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/process"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// runStopTimeout is how long the command gets to exit before being killed on restart
+const runStopTimeout = 10 * time.Second
+
+// RunCmd represents the run command
+type RunCmd struct {
+	Profile string
+	Signal  string
+	NoWatch bool
+	logger  *logger.Logger
+}
+
+// exitError reports the exit code of the wrapped command
+type exitError struct {
+	code int
+}
+
+// Error implements the error interface
+func (e *exitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.code)
+}
+
+// ExitCode returns the exit code of the command, which lanup exits with
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// NewRunCmd creates a new run command
+func NewRunCmd() *cobra.Command {
+	runCmd := &RunCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "run [flags] -- COMMAND [ARGS...]",
+		Short: "Run a command with the LAN variables in its environment",
+		Long: `Detect your local IP, compute the variables from .lanup.yaml and run a command
+with them added to its environment. No env file is written.
+
+The network keeps being watched while the command runs: when the IP changes, the
+command is restarted with the new values. With --signal, it is sent a signal instead
+and keeps running with its current environment.
+
+lanup exits with the exit code of the command.
+
+Examples:
+  lanup run -- npm run dev
+  lanup run --profile mobile -- npx expo start
+  lanup run --signal HUP -- ./server`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Errors from here on are not about the usage
+			cmd.SilenceUsage = true
+			return runCmd.Run(args)
+		},
+	}
+
+	// Flags after the command belong to it
+	cmd.Flags().SetInterspersed(false)
+
+	// Add flags
+	cmd.Flags().StringVar(&runCmd.Profile, "profile", "", "configuration profile to use (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().StringVar(&runCmd.Signal, "signal", "", "signal to send on IP change instead of restarting (e.g. HUP)")
+	cmd.Flags().BoolVar(&runCmd.NoWatch, "no-watch", false, "don't watch for network changes")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewRunCmd())
+}
+
+// Run executes the run command
+func (c *RunCmd) Run(args []string) error {
+	var sig os.Signal
+	if c.Signal != "" {
+		var err error
+		if sig, err = process.ParseSignal(c.Signal); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --signal flag", err)
+		}
+	}
+
+	var err error
+	c.logger, err = newFileLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
+	} else if c.logger != nil {
+		defer c.logger.Close()
+		c.logger = c.logger.With("module", "run")
+	}
+
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	vars, ip, err := c.variables(projectConfig)
+	if err != nil {
+		return err
+	}
+
+	child, err := startChild(args, vars)
+	if err != nil {
+		return lanuperrors.FromOSError(fmt.Sprintf("Failed to run %s", args[0]), err)
+	}
+	utils.Info("Running %s with %d variable(s) for %s", strings.Join(args, " "), len(vars), ip)
+	if c.logger != nil {
+		c.logger.Info("Started command",
+			logger.Field{Key: "command", Value: strings.Join(args, " ")},
+			logger.Field{Key: "pid", Value: child.cmd.Process.Pid},
+			logger.Field{Key: "ip", Value: ip})
+	}
+
+	// Ctrl+C reaches the command through the terminal, SIGTERM has to be forwarded
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var events <-chan net.ChangeEvent // never ready with --no-watch
+	var watcher *net.IPWatcher
+	// The IP set in the configuration doesn't follow the network
+	if !c.NoWatch && projectConfig.IP == "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		interval, _ := projectConfig.WatchInterval()
+		if interval == 0 {
+			interval = checkInterval()
+		}
+		watcher = net.NewIPWatcher(interval)
+		watcher.Logger = c.logger.With("module", "watcher")
+		events = watcher.Subscribe(ctx)
+		go watcher.Start(ctx)
+		defer watcher.Stop()
+	}
+
+	for {
+		select {
+		case received := <-sigCh:
+			if received != os.Interrupt {
+				child.signal(received)
+			}
+			return childExitError(<-child.done)
+
+		case err := <-child.done:
+			return childExitError(err)
+
+		case event := <-events:
+			// Skip the other changes, and the IP changes superseded while the previous one was handled
+			newIP := event.New.IP
+			if event.Reason != net.ReasonIPChanged || newIP != watcher.GetCurrentIP() {
+				continue
+			}
+			utils.Warning("Network change detected, new IP: %s", newIP)
+			if c.logger != nil {
+				c.logger.Warn("Network interface changed", logger.Field{Key: "new_ip", Value: newIP})
+			}
+
+			if sig != nil {
+				utils.Info("Sending %s to %s", c.Signal, args[0])
+				child.signal(sig)
+				continue
+			}
+
+			vars, ip, err = c.variables(projectConfig)
+			if err != nil {
+				utils.Error("Failed to compute variables: %v", err)
+				continue
+			}
+			utils.Info("Restarting %s with the variables for %s", args[0], ip)
+			child.stop(runStopTimeout)
+			if child, err = startChild(args, vars); err != nil {
+				return lanuperrors.FromOSError(fmt.Sprintf("Failed to restart %s", args[0]), err)
+			}
+			if c.logger != nil {
+				c.logger.Info("Restarted command",
+					logger.Field{Key: "pid", Value: child.cmd.Process.Pid},
+					logger.Field{Key: "ip", Value: ip})
+			}
+		}
+	}
+}
+
+// variables detects the IP and computes the variables to inject
+func (c *RunCmd) variables(projectConfig *config.ProjectConfig) ([]env.EnvVar, string, error) {
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return nil, "", lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+	recordIPChange("run", projectConfig, netInfo, c.logger)
+
+	found, err := collectVariables(context.Background(), projectConfig, c.logger)
+	if err != nil {
+		return nil, "", err
+	}
+	vars := found.Vars
+
+	// URLs use the project hostname or the address of the emulators instead of the IP when configured
+	host := projectConfig.URLHost(netInfo.IP)
+
+	transformed, err := withCertificateVars(lanup.TransformConfig(projectConfig, vars, host), projectConfig, netInfo.IP, true)
+	if err != nil {
+		utils.Warning("Failed to issue the LAN certificate: %v", err)
+	}
+	return transformed, netInfo.IP, nil
+}
+
+// checkInterval returns the watcher interval from the global configuration
+func checkInterval() time.Duration {
+	globalCfg := GetGlobalConfig()
+	if globalCfg != nil && globalCfg.CheckInterval > 0 {
+		return time.Duration(globalCfg.CheckInterval) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// detectInterval returns how often watch mode runs the detectors, 0 when disabled
+func detectInterval() time.Duration {
+	globalCfg := GetGlobalConfig()
+	switch {
+	case globalCfg == nil || globalCfg.DetectInterval == 0:
+		return 30 * time.Second
+	case globalCfg.DetectInterval < 0:
+		return 0
+	default:
+		return time.Duration(globalCfg.DetectInterval) * time.Second
+	}
+}
+
+// childEnv returns base with vars added, replacing the variables already set
+func childEnv(base []string, vars []env.EnvVar) []string {
+	override := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		override[v.Key] = true
+	}
+
+	environ := make([]string, 0, len(base)+len(vars))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if !override[key] {
+			environ = append(environ, kv)
+		}
+	}
+	for _, v := range vars {
+		environ = append(environ, v.Key+"="+v.Value)
+	}
+
+	return environ
+}
+
+// childProcess is a running command and the result of waiting for it
+type childProcess struct {
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// startChild starts the command with vars in its environment
+func startChild(args []string, vars []env.EnvVar) (*childProcess, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = childEnv(os.Environ(), vars)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	child := &childProcess{cmd: cmd, done: make(chan error, 1)}
+	go func() {
+		child.done <- cmd.Wait()
+	}()
+
+	return child, nil
+}
+
+// signal sends sig to the command
+func (p *childProcess) signal(sig os.Signal) {
+	if err := p.cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		fmt.Fprintf(os.Stderr, "%sWarning: failed to send %v to process %d: %v\n", utils.Emoji("⚠️  ", ""), sig, p.cmd.Process.Pid, err)
+	}
+}
+
+// stop terminates the command and waits for it, killing it after timeout
+func (p *childProcess) stop(timeout time.Duration) {
+	if err := process.Terminate(p.cmd.Process.Pid); err != nil {
+		p.cmd.Process.Kill()
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// childExitError converts the result of waiting for the command to the error lanup exits with
+func childExitError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// Killed by a signal
+			code = 1
+		}
+		return &exitError{code: code}
+	}
+
+	return lanuperrors.FromOSError("Failed to wait for command", err)
+}
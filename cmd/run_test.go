@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "API_URL=http://localhost:8000", "EMPTY="}
+	vars := []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000"},
+		{Key: "WEB_URL", Value: "http://192.168.1.100:3000"},
+	}
+
+	environ := childEnv(base, vars)
+	assert.Equal(t, []string{
+		"PATH=/usr/bin",
+		"EMPTY=",
+		"API_URL=http://192.168.1.100:8000",
+		"WEB_URL=http://192.168.1.100:3000",
+	}, environ)
+}
+
+func TestChildExitError(t *testing.T) {
+	assert.NoError(t, childExitError(nil))
+
+	if runtime.GOOS != "windows" {
+		err := childExitError(exec.Command("sh", "-c", "exit 3").Run())
+		var exitErr *exitError
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, 3, exitErr.ExitCode())
+	}
+
+	var lanupErr *lanuperrors.LanupError
+	assert.True(t, errors.As(childExitError(errors.New("boom")), &lanupErr))
+}
+
+func TestRunCmd_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as command")
+	}
+
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	testConfig := &config.ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+		},
+		Output: ".env.local",
+	}
+	err = config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig)
+	require.NoError(t, err)
+
+	runCmd := &RunCmd{NoWatch: true}
+	err = runCmd.Run([]string{"sh", "-c", `echo "$API_URL" > out.txt; exit 5`})
+
+	// lanup exits with the exit code of the command
+	var exitErr *exitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 5, exitErr.ExitCode())
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "out.txt"))
+	require.NoError(t, err)
+	value := strings.TrimSpace(string(content))
+	assert.True(t, strings.HasPrefix(value, "http://"))
+	assert.NotContains(t, value, "localhost")
+
+	// No env file is written
+	_, err = os.Stat(filepath.Join(tmpDir, ".env.local"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunCmd_Run_InvalidSignal(t *testing.T) {
+	runCmd := &RunCmd{Signal: "NOPE"}
+	err := runCmd.Run([]string{"true"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid --signal flag")
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// Reasons recorded with the runs that watch mode, the daemon and 'lanup projects refresh' start
+const (
+	runReasonIPChanged = "ip_changed"       // the IP of the machine changed
+	runReasonServices  = "services_changed" // detected services started or stopped
+	runReasonReload    = "reload"           // SIGHUP sent to the daemon
+	runReasonAPI       = "api"              // POST /v1/refresh
+	runReasonRefresh   = "projects_refresh" // 'lanup projects refresh'
+)
+
+// RunsCmd represents the runs command
+type RunsCmd struct {
+	Limit int
+	JSON  bool
+}
+
+// NewRunsCmd creates a new runs command
+func NewRunsCmd() *cobra.Command {
+	runsCmd := &RunsCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "runs [ID]",
+		Short: "List the generations of the env file of the project",
+		Long: fmt.Sprintf(`List the runs that generated the env file of the current project, or show one of them:
+what started it (start, watch mode after an IP change, the daemon, the API...), the IP,
+the variables written and changed, how long it took and the warnings printed.
+
+Runs are recorded in ~/.lanup/state.json, the last %d per project. The values of the
+variables are not recorded since they may hold secrets, 'lanup restore' shows the backups.
+
+Examples:
+  lanup runs
+  lanup runs 42
+  lanup runs --limit 50
+  lanup runs 42 --json`, state.MaxRuns),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return runsCmd.Show(args[0])
+			}
+			return runsCmd.List()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().IntVarP(&runsCmd.Limit, "limit", "n", 20, "number of runs to show, 0 for all")
+	cmd.Flags().BoolVar(&runsCmd.JSON, "json", false, "print the runs as JSON")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewRunsCmd())
+}
+
+// List prints the last runs of the current project, oldest first
+func (c *RunsCmd) List() error {
+	runs, err := projectRuns()
+	if err != nil {
+		return err
+	}
+	if c.Limit > 0 && len(runs) > c.Limit {
+		runs = runs[len(runs)-c.Limit:]
+	}
+	if c.JSON {
+		if runs == nil {
+			runs = []state.Run{}
+		}
+		return printJSON(runs)
+	}
+	if len(runs) == 0 {
+		utils.Info("No runs recorded for this project yet, run 'lanup start'")
+		return nil
+	}
+
+	table := utils.NewTable("ID", "TIME", "TRIGGER", "IP", "VARS", "CHANGES", "DURATION", "RESULT")
+	for _, run := range runs {
+		table.AddRow(strconv.Itoa(run.ID), run.Time.Local().Format("2006-01-02 15:04:05"), runTrigger(run),
+			valueOrDash(run.IP), strconv.Itoa(len(run.Vars)), strconv.Itoa(len(run.Changes)),
+			runDuration(run), runResult(run))
+	}
+	table.Print()
+
+	utils.Println()
+	utils.Info("Run 'lanup runs <ID>' for the changes and warnings of a run")
+	return nil
+}
+
+// Show prints a run of the current project
+func (c *RunsCmd) Show(arg string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid run ID %s, see 'lanup runs'", arg), nil)
+	}
+	runs, err := projectRuns()
+	if err != nil {
+		return err
+	}
+	p := &state.Project{Runs: runs}
+	run := p.Run(id)
+	if run == nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("No run %d recorded for this project, see 'lanup runs'", id), nil)
+	}
+	if c.JSON {
+		return printJSON(run)
+	}
+
+	utils.PrintSection(fmt.Sprintf("Run %d", run.ID))
+	fmt.Printf("  Time:     %s\n", run.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Trigger:  %s\n", runTrigger(*run))
+	if run.Profile != "" {
+		fmt.Printf("  Profile:  %s\n", run.Profile)
+	}
+	fmt.Printf("  IP:       %s\n", valueOrDash(run.IP))
+	fmt.Printf("  Output:   %s\n", valueOrDash(run.Output))
+	fmt.Printf("  Duration: %s\n", runDuration(*run))
+	if run.Error != "" {
+		fmt.Printf("  Error:    %s\n", color.RedString(run.Error))
+	}
+
+	if len(run.Changes) > 0 {
+		utils.PrintSection("Changes")
+		for _, change := range run.Changes {
+			switch change.Change {
+			case "added":
+				fmt.Println(color.GreenString("  + %s", change.Key))
+			case "removed":
+				fmt.Println(color.RedString("  - %s", change.Key))
+			default:
+				fmt.Println(color.YellowString("  ~ %s", change.Key))
+			}
+		}
+	} else if run.Error == "" {
+		utils.Println()
+		utils.Info("No variable changed")
+	}
+
+	if len(run.Warnings) > 0 {
+		utils.PrintSection("Warnings")
+		for _, warning := range run.Warnings {
+			fmt.Printf("  %s\n", warning)
+		}
+	}
+
+	if len(run.Vars) > 0 {
+		utils.PrintSection("Variables written")
+		for _, key := range run.Vars {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+	return nil
+}
+
+// projectRuns returns the runs recorded for the current project, oldest first
+func projectRuns() ([]state.Run, error) {
+	st, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := filepath.Abs(projectDir())
+	if err != nil {
+		return nil, lanuperrors.FromOSError("Failed to get current directory", err)
+	}
+	if p, ok := st.Projects[dir]; ok {
+		return p.Runs, nil
+	}
+	return nil, nil
+}
+
+// runTrigger returns the command that ran a run, with the reason when there is one
+func runTrigger(run state.Run) string {
+	if run.Reason == "" {
+		return run.Trigger
+	}
+	return fmt.Sprintf("%s (%s)", run.Trigger, run.Reason)
+}
+
+// runDuration returns the duration of a run, rounded for display
+func runDuration(run state.Run) string {
+	d := time.Duration(run.Duration) * time.Millisecond
+	if d >= time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.String()
+}
+
+// runResult summarizes the outcome of a run
+func runResult(run state.Run) string {
+	switch {
+	case run.Error != "":
+		return color.RedString("failed")
+	case len(run.Warnings) > 0:
+		return color.YellowString("%d warning(s)", len(run.Warnings))
+	default:
+		return color.GreenString("ok")
+	}
+}
+
+// recordRun adds a run to the record of the project
+// Failures are only logged: the state must never prevent lanup from working
+func recordRun(projectConfig *config.ProjectConfig, run state.Run, log *logger.Logger) {
+	updateState(log, func(s *state.State) {
+		s.Project(projectConfig.Dir()).AddRun(run)
+	})
+}
+
+// warn prints a warning and records it with the run in progress
+func (c *StartCmd) warn(format string, args ...interface{}) {
+	utils.Warning(format, args...)
+	c.recordWarning(fmt.Sprintf(format, args...))
+}
+
+// recordWarning records a warning already printed with the run in progress
+func (c *StartCmd) recordWarning(warning string) {
+	if c.run != nil {
+		c.run.Warnings = append(c.run.Warnings, warning)
+	}
+}
+
+// recordDetectorWarnings records the failures and warnings of the detectors, printed by logDetectorResult
+func (c *StartCmd) recordDetectorWarnings(results []detector.Result) {
+	for _, result := range results {
+		if result.Skipped {
+			continue
+		}
+		if result.Err != nil {
+			if !errors.Is(result.Err, detector.ErrNotRunning) {
+				c.recordWarning(result.Err.Error())
+			}
+			continue
+		}
+		for _, warning := range result.Warnings {
+			c.recordWarning(warning)
+		}
+	}
+}
+
+// recordChanges records the managed variables written and how they changed in the env file
+func (c *StartCmd) recordChanges(existing, merged []env.EnvVar) {
+	if c.run == nil {
+		return
+	}
+
+	var managed []env.EnvVar
+	for _, v := range merged {
+		if v.Managed {
+			managed = append(managed, v)
+			c.run.Vars = append(c.run.Vars, v.Key)
+		}
+	}
+	for _, change := range env.Diff(existing, managed) {
+		switch change.Kind {
+		case env.Added:
+			c.run.Changes = append(c.run.Changes, state.VarChange{Key: change.Key, Change: "added"})
+		case env.Modified:
+			c.run.Changes = append(c.run.Changes, state.VarChange{Key: change.Key, Change: "modified"})
+		case env.Removed:
+			c.run.Changes = append(c.run.Changes, state.VarChange{Key: change.Key, Change: "removed"})
+		}
+	}
+}
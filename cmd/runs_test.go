@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartCmd_RecordsRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env",
+		IP:     "10.0.0.7",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	// Runs with no file written are not recorded
+	require.NoError(t, (&StartCmd{NoProbe: true, DryRun: true}).Run())
+	runs, err := projectRuns()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	require.NoError(t, (&StartCmd{NoProbe: true}).Run())
+	require.NoError(t, (&StartCmd{NoProbe: true, IP: "10.0.0.8", reason: runReasonRefresh}).Run())
+
+	runs, err = projectRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	first := runs[0]
+	assert.Equal(t, 1, first.ID)
+	assert.Equal(t, "start", first.Trigger)
+	assert.Equal(t, "10.0.0.7", first.IP)
+	assert.Equal(t, filepath.Join(tmpDir, ".env"), first.Output)
+	assert.Equal(t, []string{"API_URL"}, first.Vars)
+	assert.Equal(t, []state.VarChange{{Key: "API_URL", Change: "added"}}, first.Changes)
+	require.NotEmpty(t, first.Warnings, "10.0.0.7 is not an address of this machine")
+	assert.Contains(t, first.Warnings[0], "10.0.0.7")
+
+	second := runs[1]
+	assert.Equal(t, 2, second.ID)
+	assert.Equal(t, runReasonRefresh, second.Reason)
+	assert.Equal(t, []state.VarChange{{Key: "API_URL", Change: "modified"}}, second.Changes)
+
+	require.NoError(t, (&RunsCmd{Limit: 20}).List())
+	require.NoError(t, (&RunsCmd{}).Show("2"))
+	assert.ErrorIs(t, (&RunsCmd{}).Show("3"), lanuperrors.ErrInvalidConfig)
+	assert.ErrorIs(t, (&RunsCmd{}).Show("latest"), lanuperrors.ErrInvalidConfig)
+}
+
+func TestStartCmd_RecordsFailedRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// The output is a directory, the env file can't be written
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".env"), 0755))
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env",
+		IP:     "10.0.0.7",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.Error(t, (&StartCmd{NoProbe: true}).Run())
+
+	runs, err := projectRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.NotEmpty(t, runs[0].Error)
+	assert.Empty(t, runs[0].Changes)
+	require.NoError(t, (&RunsCmd{}).Show("1"))
+}
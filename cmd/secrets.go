@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/secrets"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+)
+
+// secretResolver reads the secrets of the configuration, once per process
+var secretResolver = secrets.NewResolver()
+
+// resolveSecrets replaces the secret references among the values of vars, such as op://vault/item/field,
+// with the secrets
+func resolveSecrets(ctx context.Context, vars map[string]string) error {
+	if err := secretResolver.ResolveVars(ctx, vars); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to resolve secrets", err)
+	}
+	return nil
+}
+
+// withoutSecrets drops the variables configured as secret references, from what other devices
+// or services get to see, such as the share page and the notifications
+func withoutSecrets(projectConfig *config.ProjectConfig, vars []env.EnvVar) []env.EnvVar {
+	var result []env.EnvVar
+	for _, v := range vars {
+		if secrets.IsRef(projectConfig.Vars[v.Key]) || secrets.IsRef(projectConfig.StaticVars[v.Key]) {
+			continue
+		}
+		result = append(result, v)
+	}
+	return result
+}
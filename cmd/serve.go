@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/cert"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/proxy"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// defaultServePort is used when neither --port nor serve.port is set
+const defaultServePort = 8888
+
+// ServeCmd represents the serve command
+type ServeCmd struct {
+	Port   int
+	Routes []string
+	TLS    bool
+	Auth   string   // basic auth user:password, replaces serve.auth
+	Allow  []string // added to serve.allow
+	Log    bool
+
+	logger *logger.Logger
+}
+
+// NewServeCmd creates a new serve command
+func NewServeCmd() *cobra.Command {
+	serveCmd := &ServeCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a reverse proxy exposing localhost services on the LAN",
+		Long: `Start a reverse proxy listening on all interfaces that forwards requests to your
+localhost services, based on the request path or host.
+
+This exposes services that refuse to bind beyond 127.0.0.1 without touching their
+configuration. Routes come from the 'serve' section of .lanup.yaml and from --route flags.
+A route prefix starting with / matches the request path, anything else matches the host.
+
+With --tls, the proxy terminates HTTPS with a locally-trusted certificate covering the LAN IP
+and the machine's .local hostname, so browser features requiring a secure context (camera,
+service workers) work from other devices. mkcert is used to issue it when installed.
+
+--auth asks for a user and password before forwarding, and --allow only lets the given IP
+addresses or CIDR ranges in (loopback always is), so that an admin dashboard exposed on a
+shared network isn't open to everyone on it. Each request is written to the log file.
+
+Examples:
+  lanup serve
+  lanup serve --route /api=http://localhost:8000 --route /=http://localhost:3000
+  lanup serve --port 9000 --route admin.lan=http://localhost:4000
+  lanup serve --tls
+  lanup serve --allow 192.168.1.0/24 --auth admin:s3cret`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serveCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().IntVarP(&serveCmd.Port, "port", "p", 0, fmt.Sprintf("port to listen on (default serve.port or %d)", defaultServePort))
+	cmd.Flags().StringArrayVar(&serveCmd.Routes, "route", nil, "route in the form PREFIX=TARGET (repeatable)")
+	cmd.Flags().BoolVar(&serveCmd.TLS, "tls", false, "serve HTTPS with a locally-trusted certificate (default serve.tls)")
+	cmd.Flags().StringVar(&serveCmd.Auth, "auth", "", "ask for basic auth credentials USER:PASSWORD (default serve.auth)")
+	cmd.Flags().StringArrayVar(&serveCmd.Allow, "allow", nil, "only let this IP address or CIDR range in, besides serve.allow (repeatable)")
+	cmd.Flags().BoolVar(&serveCmd.Log, "log", true, "enable logging to file, requests included")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewServeCmd())
+}
+
+// Run executes the serve command
+func (c *ServeCmd) Run() error {
+	if c.Log {
+		var err error
+		c.logger, err = newFileLogger()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
+		} else if c.logger != nil {
+			defer c.logger.Close()
+			c.logger = c.logger.With("module", "serve")
+		}
+	}
+
+	// The project configuration is optional when routes are given on the command line,
+	// but an invalid one must not silently drop serve.auth and serve.allow
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		if len(c.Routes) == 0 || !errors.Is(err, config.ErrProjectConfigNotFound) {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				"Failed to load project configuration", err)
+		}
+		projectConfig = &config.ProjectConfig{}
+	}
+
+	routes := append([]config.RouteConfig(nil), projectConfig.Serve.Routes...)
+	for _, flag := range c.Routes {
+		route, err := parseRouteFlag(flag)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --route flag", err)
+		}
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"No routes configured (add a 'serve.routes' section to .lanup.yaml or use --route)", nil)
+	}
+
+	port := c.Port
+	if port == 0 {
+		port = projectConfig.Serve.Port
+	}
+	if port == 0 {
+		port = defaultServePort
+	}
+	if port < 1 || port > 65535 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid port: %d (must be between 1 and 65535)", port), nil)
+	}
+
+	handler, err := proxy.New(routes)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid proxy route", err)
+	}
+	access, err := c.access(projectConfig.Serve)
+	if err != nil {
+		return err
+	}
+
+	// Detect local IP
+	netInfo, err := net.DetectLocalIP()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", port),
+		Handler:           proxy.LogRequests(access.Restrict(handler), c.logRequest),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var bundle *cert.Bundle
+	if c.TLS || projectConfig.Serve.TLS {
+		bundle, err = lanCertificate(netInfo.IP)
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+				"Failed to create TLS certificate", err)
+		}
+	}
+
+	err = serveUntilInterrupted(server, bundle, func() {
+		c.displayRoutes(handler, access, netInfo.IP, port, bundle)
+	})
+	if err != nil {
+		return lanuperrors.FromOSError(fmt.Sprintf("Failed to listen on port %d", port), err)
+	}
+
+	return nil
+}
+
+// access returns who the proxy lets in, from the flags and the serve section
+func (c *ServeCmd) access(serveConfig config.ServeConfig) (proxy.Access, error) {
+	var access proxy.Access
+
+	auth := c.Auth
+	if auth == "" {
+		auth = os.ExpandEnv(serveConfig.Auth)
+	}
+	if auth != "" {
+		var err error
+		if access.User, access.Password, err = proxy.ParseAuth(auth); err != nil {
+			return access, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid basic auth credentials", err)
+		}
+	}
+
+	allow, err := proxy.ParseAllow(append(append([]string(nil), serveConfig.Allow...), c.Allow...))
+	if err != nil {
+		return access, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid allowlist", err)
+	}
+	access.Allow = allow
+	return access, nil
+}
+
+// logRequest writes a request served by the proxy to the log file
+func (c *ServeCmd) logRequest(e proxy.Entry) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.Info("Request",
+		logger.Field{Key: "method", Value: e.Method},
+		logger.Field{Key: "host", Value: e.Host},
+		logger.Field{Key: "path", Value: e.Path},
+		logger.Field{Key: "status", Value: e.Status},
+		logger.Field{Key: "bytes", Value: e.Bytes},
+		logger.Field{Key: "duration_ms", Value: e.Duration.Milliseconds()},
+		logger.Field{Key: "remote", Value: e.Remote},
+		logger.Field{Key: "user", Value: e.User})
+}
+
+// displayRoutes shows the LAN URL of each route and its target, and who may use them
+func (c *ServeCmd) displayRoutes(handler *proxy.Proxy, access proxy.Access, ip string, port int, bundle *cert.Bundle) {
+	scheme := "http"
+	if bundle != nil {
+		scheme = "https"
+	}
+
+	utils.Success("Reverse proxy listening on all interfaces, port %d", port)
+	utils.Success("Local IP: %s", ip)
+	if bundle != nil {
+		displayCertificateHint(bundle)
+	}
+
+	utils.PrintSection("Routes")
+	for _, route := range handler.Routes() {
+		var from string
+		if route.Host != "" {
+			from = fmt.Sprintf("%s://%s:%d%s", scheme, route.Host, port, route.Path)
+		} else {
+			from = fmt.Sprintf("%s://%s:%d%s", scheme, ip, port, route.Path)
+		}
+		if route.Path == "" {
+			from += "/"
+		}
+		fmt.Printf("  %s %s %s\n", color.CyanString(from), utils.Symbol("→", "->"), route.Target.String())
+	}
+	utils.Println()
+
+	if access.User != "" {
+		utils.Info("Basic auth required, user %s", access.User)
+	}
+	if len(access.Allow) > 0 {
+		networks := make([]string, len(access.Allow))
+		for i, network := range access.Allow {
+			networks[i] = network.String()
+		}
+		utils.Info("Only allowing %s and this machine", strings.Join(networks, ", "))
+	}
+	if access.User == "" && len(access.Allow) == 0 {
+		utils.Info("Anyone on the network can use these routes, restrict them with --allow or --auth")
+	}
+	utils.Println("Press Ctrl+C to stop")
+}
+
+// parseRouteFlag parses a PREFIX=TARGET route flag
+func parseRouteFlag(flag string) (config.RouteConfig, error) {
+	parts := strings.SplitN(flag, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return config.RouteConfig{}, fmt.Errorf("expected PREFIX=TARGET, got %q", flag)
+	}
+
+	route := config.RouteConfig{Target: parts[1]}
+	if strings.HasPrefix(parts[0], "/") {
+		route.Path = parts[0]
+	} else {
+		route.Host = parts[0]
+	}
+
+	return route, route.Validate()
+}
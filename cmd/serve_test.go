@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteFlag(t *testing.T) {
+	route, err := parseRouteFlag("/api=http://localhost:8000")
+	require.NoError(t, err)
+	assert.Equal(t, config.RouteConfig{Path: "/api", Target: "http://localhost:8000"}, route)
+
+	route, err = parseRouteFlag("admin.lan=http://localhost:4000")
+	require.NoError(t, err)
+	assert.Equal(t, config.RouteConfig{Host: "admin.lan", Target: "http://localhost:4000"}, route)
+
+	_, err = parseRouteFlag("/api")
+	assert.Error(t, err)
+
+	_, err = parseRouteFlag("/api=localhost:8000")
+	assert.Error(t, err)
+}
+
+func TestServeCmd_Access(t *testing.T) {
+	t.Setenv("LANUP_TEST_SERVE_PASSWORD", "s3cret")
+	serveConfig := config.ServeConfig{Auth: "admin:${LANUP_TEST_SERVE_PASSWORD}", Allow: []string{"192.168.1.0/24"}}
+
+	access, err := (&ServeCmd{Allow: []string{"10.0.0.7"}}).access(serveConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", access.User)
+	assert.Equal(t, "s3cret", access.Password)
+	require.Len(t, access.Allow, 2)
+	assert.Equal(t, "10.0.0.7/32", access.Allow[1].String())
+
+	// --auth replaces the configured credentials
+	access, err = (&ServeCmd{Auth: "me:pass"}).access(serveConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "me", access.User)
+
+	// An unset variable leaves no password
+	_, err = (&ServeCmd{}).access(config.ServeConfig{Auth: "admin:${LANUP_TEST_UNSET}"})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+}
+
+func TestServeCmd_InvalidConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// --route must not fall back to an empty configuration, which has no serve.auth
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".lanup.yaml"),
+		[]byte("serve:\n  auth: [invalid\n"), 0644))
+	err := (&ServeCmd{Routes: []string{"/api=http://localhost:8000"}}).Run()
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/cert"
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// lanCertificate returns a certificate valid for the LAN IP and the machine's .local hostname
+func lanCertificate(ip string) (*cert.Bundle, error) {
+	dir, err := cert.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return cert.Ensure(dir, cert.DefaultHosts(ip))
+}
+
+// displayCertificateHint explains how to make other devices trust the certificate
+func displayCertificateHint(bundle *cert.Bundle) {
+	if bundle.Mkcert {
+		utils.Info("Certificate issued by mkcert: %s", bundle.CertFile)
+	} else {
+		utils.Info("Certificate issued by the lanup local CA: %s", bundle.CertFile)
+	}
+	if bundle.CAFile != "" {
+		utils.Info("Install this CA on your devices to trust it: %s", bundle.CAFile)
+	}
+}
+
+// serveUntilInterrupted runs the server until SIGINT/SIGTERM, then shuts it down gracefully
+// The server uses TLS when a certificate bundle is given. Errors preventing the
+// server from starting are returned as-is for the caller to wrap.
+func serveUntilInterrupted(server *http.Server, bundle *cert.Bundle, ready func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if bundle != nil {
+			err = server.ListenAndServeTLS(bundle.CertFile, bundle.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	if ready != nil {
+		ready()
+	}
+
+	// Set up signal handling for graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case <-sigCh:
+		utils.Println()
+		utils.Println("Shutting down gracefully...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(ctx)
+	case err := <-errCh:
+		return err
+	}
+}
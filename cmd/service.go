@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/service"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ServiceCmd represents the service command
+type ServiceCmd struct {
+	Profile string
+}
+
+// NewServiceCmd creates a new service command
+func NewServiceCmd() *cobra.Command {
+	serviceCmd := &ServiceCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Install lanup as a system service for the current project",
+		Long: `Register the lanup daemon of the current project as a user service, so that it starts
+on login and survives reboots.
+
+A systemd user unit is installed on Linux (~/.config/systemd/user), a launchd agent
+on macOS (~/Library/LaunchAgents) and a Windows service on Windows, which requires an
+elevated prompt. The service runs 'lanup daemon run' in the project directory and writes
+its output to ~/.lanup/logs/daemon.log.
+
+Examples:
+  lanup service install
+  lanup service install --profile mobile
+  lanup service uninstall`,
+	}
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serviceCmd.Install()
+		},
+	}
+	installCmd.Flags().StringVar(&serviceCmd.Profile, "profile", "", "configuration profile to use")
+	_ = installCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serviceCmd.Uninstall()
+		},
+	}
+
+	cmd.AddCommand(installCmd, uninstallCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewServiceCmd())
+}
+
+// Install registers and starts the service
+func (c *ServiceCmd) Install() error {
+	if _, err := config.LoadProjectConfigProfile("", c.Profile); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	// A running watcher would make the service fail to start
+	pidPath, err := process.WatchPIDFile(projectDir())
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to determine watcher PID file", err)
+	}
+	if pid, running := process.RunningPID(pidPath); running {
+		return lanuperrors.NewError(lanuperrors.ErrProcess,
+			fmt.Sprintf("lanup is already watching this project (PID %d), run 'lanup daemon stop' first", pid), nil)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to locate the lanup executable", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	manager, spec, err := c.serviceSpec(executable)
+	if err != nil {
+		return err
+	}
+	if manager.Kind() == "windows" {
+		// The service runs in the account of the user, with their rights rather than those of SYSTEM
+		account, err := service.WindowsAccount()
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrPermissionDenied, "Failed to install service", err)
+		}
+		spec.Password, err = service.ReadPassword(fmt.Sprintf("Password of %s, to run the service in this account: ", account))
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrPermissionDenied, "Failed to read the password", err)
+		}
+	}
+
+	if err := manager.Install(spec); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to install service", err)
+	}
+
+	path, _ := manager.FilePath(spec)
+	utils.Success("Installed %s service %s", manager.Kind(), spec.Name)
+	utils.Info("Definition: %s", path)
+	utils.Info("Logs: %s", spec.LogPath)
+	utils.Info("lanup now watches this project on every login, run 'lanup service uninstall' to stop")
+
+	return nil
+}
+
+// Uninstall stops and removes the service
+func (c *ServiceCmd) Uninstall() error {
+	manager, spec, err := c.serviceSpec("")
+	if err != nil {
+		return err
+	}
+
+	if err := manager.Uninstall(spec); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to uninstall service", err)
+	}
+
+	utils.Success("Uninstalled %s service %s", manager.Kind(), spec.Name)
+	return nil
+}
+
+// serviceSpec returns the service manager of this platform and the service of the current project
+func (c *ServiceCmd) serviceSpec(executable string) (service.Manager, service.Spec, error) {
+	manager, err := service.NewManager()
+	if err != nil {
+		return nil, service.Spec{}, lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"System services are not available", err)
+	}
+
+	spec, err := service.NewSpec(projectDir(), executable, daemonRunArgs(c.Profile, defaultAPIAddr, false), daemonLogPath())
+	if err != nil {
+		return nil, service.Spec{}, lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+			"Failed to determine the service", err)
+	}
+
+	return manager, spec, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/share"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ShareCmd represents the share command
+type ShareCmd struct {
+	Port int
+}
+
+// NewShareCmd creates a new share command
+func NewShareCmd() *cobra.Command {
+	shareCmd := &ShareCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "Serve a landing page listing your exposed services",
+		Long: `Serve a small web page listing every exposed service URL with a QR code.
+
+The page itself is reachable from your LAN at http://<ip>:<port>/, so you can hand
+teammates a single link instead of pasting every URL.
+
+Examples:
+  lanup share
+  lanup share --port 9000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return shareCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().IntVarP(&shareCmd.Port, "port", "p", 8765, "port to serve the landing page on")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewShareCmd())
+}
+
+// Run executes the share command
+func (c *ShareCmd) Run() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid port: %d (must be between 1 and 65535)", c.Port), nil)
+	}
+
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfig("")
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	// Detect local IP
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+
+	// Detection can be slow, so it runs once; the IP is re-detected on every page load
+	found, err := collectVariables(context.Background(), projectConfig, nil)
+	if err != nil {
+		return err
+	}
+	provider := func() ([]share.Service, error) {
+		current, err := projectNetwork(projectConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		urls := filterURLVars(withoutSecrets(projectConfig, lanup.TransformConfig(projectConfig, found.Vars, current.IP)), nil)
+		if metro := found.Metro; metro != nil {
+			urls = append(urls, env.EnvVar{Key: "Expo", Value: metro.ExpoURL(current.IP)})
+		}
+
+		services := make([]share.Service, 0, len(urls))
+		for _, v := range urls {
+			services = append(services, share.Service{Name: v.Key, URL: v.Value})
+		}
+		return services, nil
+	}
+
+	title := "lanup"
+	if dir, err := filepath.Abs(projectConfig.Dir()); err == nil {
+		title = fmt.Sprintf("lanup · %s", filepath.Base(dir))
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", c.Port),
+		Handler:           share.Handler(title, provider),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	shareURL := fmt.Sprintf("http://%s:%d/", netInfo.IP, c.Port)
+	err = serveUntilInterrupted(server, nil, func() {
+		utils.Success("Sharing your services on the LAN!")
+		utils.PrintSection("Share this link")
+		if err := utils.PrintQRCode("Page", shareURL); err != nil {
+			utils.PrintURL("Page", shareURL)
+		}
+		utils.Println("Press Ctrl+C to stop")
+	})
+	if err != nil {
+		return lanuperrors.FromOSError(fmt.Sprintf("Failed to serve on port %d", c.Port), err)
+	}
+
+	return nil
+}
@@ -2,31 +2,83 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	stdnet "net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/api"
+	"github.com/raucheacho/lanup/internal/backup"
 	"github.com/raucheacho/lanup/internal/config"
-	"github.com/raucheacho/lanup/internal/docker"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/devserver"
 	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/expo"
+	"github.com/raucheacho/lanup/internal/files"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/raucheacho/lanup/internal/hosts"
 	"github.com/raucheacho/lanup/internal/logger"
 	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/plugin"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/remote"
+	"github.com/raucheacho/lanup/internal/service"
+	"github.com/raucheacho/lanup/internal/state"
+	"github.com/raucheacho/lanup/internal/templates"
 	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
 	"github.com/raucheacho/lanup/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
+// startProbeTimeout bounds the reachability probes of the exposed URLs
+const startProbeTimeout = time.Second
+
+// statusInterval is how often the status line of watch mode is updated
+const statusInterval = time.Second
+
 // StartCmd represents the start command
 type StartCmd struct {
-	Watch  bool
-	NoEnv  bool
-	DryRun bool
-	Log    bool
-	logger *logger.Logger
+	Watch     bool
+	NoEnv     bool
+	DryRun    bool
+	Log       bool
+	QR        bool
+	Profile   string
+	IP        string        // used instead of the detected IP and the ip of the configuration
+	Vars      []string      // KEY=VALUE variables added to the configured ones
+	Output    string        // env file written instead of the configured one
+	Detect    []string      // detectors turned on for this run
+	NoDetect  []string      // detectors turned off for this run
+	Interval  time.Duration // network polling interval of watch mode, instead of the configured one
+	KeepStale bool          // keep the managed variables whose service disappeared
+	NoProbe   bool          // don't check that the exposed URLs are reachable
+	Silent    bool          // no status line in watch mode
+	logger    *logger.Logger
+	metro     *devserver.MetroServer
+	daemon    bool            // running as 'lanup daemon run': SIGHUP regenerates the env file
+	brief     bool            // print no URLs, for 'lanup projects refresh'
+	apiAddr   string          // address of the JSON API served in watch mode, empty to disable
+	watchdog  time.Duration   // exit when watch mode hangs for this long, for services
+	stop      <-chan struct{} // closed by the Windows service manager to stop watch mode
+	devices   *deviceServer   // pushes the URLs to the paired devices, in the daemon
+	reason    string          // why watch mode or the daemon runs again, recorded with the run
+	run       *state.Run      // run in progress, recorded in the state file
+
+	gitChecked bool // the env file was checked against git, once per process
+
+	runMu   sync.Mutex // serializes regenerations from the watcher, signals and the API
+	stateMu sync.Mutex
+	state   api.State    // result of the last run, without the secrets
+	vars    []env.EnvVar // variables of the last run, secrets included, for the hooks
+	facts   config.Facts // machine of the last run, the conditional vars depend on it
 }
 
 // NewStartCmd creates a new start command
@@ -39,7 +91,26 @@ func NewStartCmd() *cobra.Command {
 		Long: `Detect your local IP address and generate environment variables for your services.
 
 This command reads the .lanup.yaml configuration file, detects your local IP address,
-and generates a .env file with URLs that can be accessed from any device on your network.`,
+and generates a .env file with URLs that can be accessed from any device on your network.
+
+Use --profile to select one of the profiles defined in .lanup.yaml, e.g. a 'mobile'
+profile with its own variables, output file and detectors.
+
+Use --ip, or 'ip' in .lanup.yaml, to skip the detection when it picks the wrong address
+or to use a secondary address of the interface. Watch mode then ignores IP changes.
+
+Use --var and --output for one-off additions and redirections without editing .lanup.yaml:
+the variables are added to the configured ones, replacing those of the same name, and are
+managed like them.
+
+Use --detect and --no-detect to turn detectors on or off for one run, overriding
+auto_detect, e.g. --no-detect supabase when it is not running.
+
+Watch mode checks the network every --interval, or the check_interval of .lanup.yaml,
+or the global check_interval (5s by default).
+
+Once the env file is written, each exposed URL is probed through your LAN address and
+marked reachable (✓) or not (✗), like 'lanup verify' does. Use --no-probe to skip it.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return startCmd.Run()
 		},
@@ -50,6 +121,18 @@ and generates a .env file with URLs that can be accessed from any device on your
 	cmd.Flags().BoolVar(&startCmd.NoEnv, "no-env", false, "display variables without writing to file")
 	cmd.Flags().BoolVar(&startCmd.DryRun, "dry-run", false, "simulate all operations without writing files")
 	cmd.Flags().BoolVar(&startCmd.Log, "log", true, "enable logging to file")
+	cmd.Flags().BoolVar(&startCmd.QR, "qr", false, "show a QR code for each exposed URL")
+	cmd.Flags().StringVar(&startCmd.Profile, "profile", "", "configuration profile to use (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().StringVar(&startCmd.IP, "ip", "", "use this IP address instead of detecting it")
+	cmd.Flags().StringArrayVar(&startCmd.Vars, "var", nil, "add a variable, e.g. API_URL=http://localhost:8000 (repeatable)")
+	cmd.Flags().StringVarP(&startCmd.Output, "output", "o", "", "write this env file instead of the configured output")
+	cmd.Flags().DurationVar(&startCmd.Interval, "interval", 0, "network polling interval of watch mode, e.g. 2s")
+	cmd.Flags().StringSliceVar(&startCmd.Detect, "detect", nil, "turn on detectors for this run, e.g. docker,supabase or all")
+	cmd.Flags().StringSliceVar(&startCmd.NoDetect, "no-detect", nil, "turn off detectors for this run, e.g. supabase or all")
+	cmd.Flags().BoolVar(&startCmd.KeepStale, "keep-stale", false, "keep managed variables whose service is no longer detected")
+	cmd.Flags().BoolVar(&startCmd.NoProbe, "no-probe", false, "don't check that the exposed URLs are reachable")
+	cmd.Flags().BoolVar(&startCmd.Silent, "silent", false, "don't show the status line of watch mode")
 
 	return cmd
 }
@@ -62,44 +145,33 @@ func init() {
 func (c *StartCmd) Run() error {
 	// Initialize logger if enabled
 	if c.Log {
-		globalCfg := GetGlobalConfig()
-		if globalCfg != nil {
-			logLevel := logger.INFO
-			switch strings.ToLower(globalCfg.LogLevel) {
-			case "debug":
-				logLevel = logger.DEBUG
-			case "warn":
-				logLevel = logger.WARN
-			case "error":
-				logLevel = logger.ERROR
-			}
-
-			var err error
-			c.logger, err = logger.NewLogger(logger.LoggerConfig{
-				Level:      logLevel,
-				FilePath:   globalCfg.LogPath,
-				MaxSize:    5 * 1024 * 1024, // 5MB
-				MaxBackups: 5,
-				Console:    false,
-				Colors:     false,
-			})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
-			} else {
-				defer c.logger.Close()
-			}
+		var err error
+		c.logger, err = newFileLogger()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
+		} else if c.logger != nil {
+			defer c.logger.Close()
+			c.logger = c.logger.With("module", "start")
 		}
 	}
 
 	// Load project configuration
-	projectConfig, err := config.LoadProjectConfig("")
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
 	if err != nil {
 		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
 			"Failed to load project configuration", err)
 	}
+	if err := c.applyOverrides(projectConfig); err != nil {
+		return err
+	}
 
 	if c.logger != nil {
-		c.logger.Info("Starting lanup", logger.Field{Key: "watch", Value: c.Watch})
+		c.logger.Info("Starting lanup",
+			logger.Field{Key: "watch", Value: c.Watch},
+			logger.Field{Key: "profile", Value: c.Profile})
+	}
+	if c.Profile != "" {
+		utils.Info("Using profile: %s", c.Profile)
 	}
 
 	// Execute the core start logic
@@ -109,6 +181,7 @@ func (c *StartCmd) Run() error {
 		}
 		return err
 	}
+	c.runHooks(hooks.Start, projectConfig, "")
 
 	// If watch mode is enabled, start watching for network changes
 	if c.Watch {
@@ -118,145 +191,615 @@ func (c *StartCmd) Run() error {
 	return nil
 }
 
-// executeStart performs the core start logic
+// applyOverrides applies --ip, --var, --output, --detect, --no-detect and --interval to the configuration
+func (c *StartCmd) applyOverrides(projectConfig *config.ProjectConfig) error {
+	if c.IP != "" {
+		if stdnet.ParseIP(c.IP) == nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Invalid IP address: %s", c.IP), nil)
+		}
+		projectConfig.IP = c.IP
+	}
+
+	for _, v := range c.Vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || !envKeyRe.MatchString(key) {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Invalid --var %s: expected KEY=VALUE, e.g. API_URL=http://localhost:8000", v), nil)
+		}
+		if projectConfig.Vars == nil {
+			projectConfig.Vars = make(map[string]string)
+		}
+		projectConfig.Vars[key] = value
+		delete(projectConfig.VarOptions, key)
+		delete(projectConfig.StaticVars, key)
+	}
+
+	if err := c.applyDetectors(projectConfig); err != nil {
+		return err
+	}
+
+	if c.Interval < 0 || (c.Interval > 0 && (!c.Watch || c.Interval < config.MinInterval)) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("--interval must be a duration of at least %s used with --watch, e.g. --watch --interval 2s", config.MinInterval), nil)
+	}
+
+	return overrideOutput(projectConfig, c.Output)
+}
+
+// applyDetectors turns on the detectors of --detect and off the ones of --no-detect
+func (c *StartCmd) applyDetectors(projectConfig *config.ProjectConfig) error {
+	// dev-servers is accepted for dev_servers, like the other flags
+	normalize := func(name string) string {
+		return strings.ReplaceAll(strings.TrimSpace(name), "-", "_")
+	}
+
+	enabled := make(map[string]bool, len(c.Detect))
+	for _, name := range c.Detect {
+		enabled[normalize(name)] = true
+	}
+	for _, name := range c.NoDetect {
+		if enabled[normalize(name)] {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+				fmt.Sprintf("Detector %s is in both --detect and --no-detect", name), nil)
+		}
+	}
+
+	for _, names := range []struct {
+		list    []string
+		enabled bool
+	}{{c.Detect, true}, {c.NoDetect, false}} {
+		for _, name := range names.list {
+			if err := projectConfig.EnableDetector(normalize(name), names.enabled); err != nil {
+				return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid detector", err)
+			}
+		}
+	}
+	return nil
+}
+
+// overrideOutput replaces the output of the configuration with output, relative to the current
+// directory rather than to the configuration file, when set
+func overrideOutput(projectConfig *config.ProjectConfig, output string) error {
+	if output == "" {
+		return nil
+	}
+	if remote.IsRemote(output) {
+		if _, err := remote.ParseSSH(output); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid --output", err)
+		}
+		projectConfig.Output = output
+		return nil
+	}
+
+	abs, err := filepath.Abs(output)
+	if err != nil {
+		return lanuperrors.FromOSError("Invalid --output", err)
+	}
+	projectConfig.Output = abs
+	return nil
+}
+
+// executeStart performs the core start logic and records the run, dry runs excepted
 func (c *StartCmd) executeStart(projectConfig *config.ProjectConfig) error {
+	started := time.Now()
+	c.run = &state.Run{Time: started, Trigger: c.trigger(), Reason: c.reason, Profile: c.Profile}
+	defer func() { c.run = nil }()
+
+	err := c.generate(projectConfig)
+	if c.DryRun || c.NoEnv {
+		return err
+	}
+	c.run.Duration = time.Since(started).Milliseconds()
+	if err != nil {
+		c.run.Error = err.Error()
+	}
+	recordRun(projectConfig, *c.run, c.logger)
+	return err
+}
+
+// generate detects the IP and the services and writes the env file
+func (c *StartCmd) generate(projectConfig *config.ProjectConfig) error {
 	// Detect local IP
-	netInfo, err := net.DetectLocalIP()
+	netInfo, err := projectNetwork(projectConfig)
 	if err != nil {
 		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
 			"Failed to detect local IP address", err)
 	}
 
 	if c.logger != nil {
-		c.logger.Info("Detected IP",
+		c.logger.With("module", "net").Info("Detected IP",
 			logger.Field{Key: "ip", Value: netInfo.IP},
 			logger.Field{Key: "interface", Value: netInfo.Interface},
-			logger.Field{Key: "type", Value: netInfo.Type})
-	}
-
-	// Collect variables from configuration
-	vars := make(map[string]string)
-	for key, value := range projectConfig.Vars {
-		vars[key] = value
-	}
-
-	// Handle Docker auto-detection if enabled
-	if projectConfig.AutoDetect.Docker {
-		if docker.IsDockerAvailable() {
-			containers, err := docker.GetRunningContainers()
-			if err != nil {
-				if c.logger != nil {
-					c.logger.Warn("Failed to get Docker containers", logger.Field{Key: "error", Value: err.Error()})
-				}
-				fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to detect Docker containers: %v\n", err)
-			} else {
-				if c.logger != nil {
-					c.logger.Info("Detected Docker containers", logger.Field{Key: "count", Value: len(containers)})
-				}
-				// Add Docker container ports to variables
-				for _, container := range containers {
-					for _, port := range container.Ports {
-						varName := fmt.Sprintf("DOCKER_%s_PORT", strings.ToUpper(strings.ReplaceAll(container.Name, "-", "_")))
-						vars[varName] = fmt.Sprintf("http://localhost:%d", port.HostPort)
-					}
-				}
-			}
-		}
+			logger.Field{Key: "type", Value: netInfo.Type},
+			logger.Field{Key: "manual", Value: projectConfig.IP != ""})
+	}
+	c.run.IP = netInfo.IP
+	if projectConfig.IP != "" && netInfo.Interface == "" {
+		c.warn("%s is not an address of this machine, other devices may not reach your services", netInfo.IP)
 	}
+	if !c.DryRun {
+		recordIPChange(c.trigger(), projectConfig, netInfo, c.logger)
+	}
+	facts := machineFacts(netInfo)
+	projectConfig = projectConfig.WithFacts(facts)
 
-	// Handle Supabase auto-detection if enabled
-	if projectConfig.AutoDetect.Supabase {
-		services, err := docker.GetSupabaseStatus()
-		if err != nil {
-			if c.logger != nil {
-				c.logger.Warn("Failed to get Supabase status", logger.Field{Key: "error", Value: err.Error()})
-			}
-			// Don't show warning for Supabase as it's optional
-		} else {
-			if c.logger != nil {
-				c.logger.Info("Detected Supabase services", logger.Field{Key: "count", Value: len(services)})
-			}
-			// Add Supabase service ports to variables
-			for serviceName, port := range services {
-				varName := fmt.Sprintf("SUPABASE_%s_PORT", strings.ToUpper(strings.ReplaceAll(serviceName, "_", "_")))
-				vars[varName] = fmt.Sprintf("http://localhost:%d", port)
-			}
-		}
+	// Collect configured and detected variables, then transform them for the LAN
+	found, err := collectVariables(context.Background(), projectConfig, c.logger)
+	if err != nil {
+		return err
 	}
+	c.metro = found.Metro
+	c.recordDetectorWarnings(found.Results)
 
-	// Transform URLs from localhost to detected IP
-	transformedVars := make([]env.EnvVar, 0, len(vars))
-	for key, value := range vars {
-		transformedValue := transformURL(value, netInfo.IP)
-		transformedVars = append(transformedVars, env.EnvVar{
-			Key:     key,
-			Value:   transformedValue,
-			Managed: true,
-		})
+	// URLs use the project hostname or the address of the emulators instead of the IP when configured
+	host := projectConfig.URLHost(netInfo.IP)
+	if host == projectConfig.Hostname {
+		c.syncHostsEntry(projectConfig.Hostname, netInfo.IP)
 	}
+	transformedVars, err := withCertificateVars(found.transform(projectConfig, host), projectConfig, netInfo.IP, !c.DryRun)
+	if err != nil {
+		c.warn("Failed to issue the LAN certificate: %v", err)
+	}
+
+	c.recordState(newAPIState(c.Profile, projectConfig, netInfo, transformedVars), transformedVars, facts)
 
-	// If no-env or dry-run, just display the variables
-	if c.NoEnv || c.DryRun {
-		c.displayVariables(transformedVars, netInfo.IP, c.DryRun)
+	// If no-env, just display the variables
+	if c.NoEnv {
+		c.displayVariables(transformedVars, netInfo.IP)
 		return nil
 	}
 
-	// Read existing .env file
-	envWriter := env.NewEnvWriter(projectConfig.Output)
+	c.run.Output = projectConfig.OutputPath()
+	if abs, err := filepath.Abs(c.run.Output); err == nil && !remote.IsRemote(c.run.Output) {
+		c.run.Output = abs
+	}
+
+	// Read existing .env file, no other lanup process writes it until this one is done
+	envWriter, err := newEnvWriter(projectConfig)
+	if err != nil {
+		return err
+	}
+	if !c.DryRun {
+		lock, err := lockProject(c.logger)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+	envWriter.Logger = c.logger.With("module", "env")
 	existingVars, err := envWriter.Read()
 	if err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
-			"Failed to read existing env file", err)
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
 	}
 
-	// Merge new and existing variables
-	mergedVars := envWriter.Merge(transformedVars, existingVars)
+	// Merge new and existing variables, dropping the stale ones
+	kept := c.staleVars(env.Stale(transformedVars, existingVars), found.Failed)
+	mergedVars := envWriter.Merge(append(transformedVars, kept...), existingVars)
+
+	// Dry runs stop here and tell what the run would have done
+	if c.DryRun {
+		return c.displayPlan(projectConfig, envWriter, existingVars, mergedVars, netInfo.IP)
+	}
 
 	// Write the new .env file
 	if err := envWriter.Write(mergedVars); err != nil {
-		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
-			"Failed to write env file", err)
+		return lanuperrors.FromOSError("Failed to write env file", err)
 	}
 
-	if c.logger != nil {
-		c.logger.Info("Updated env file",
-			logger.Field{Key: "path", Value: projectConfig.Output},
+	recordProject(projectConfig, c.Profile, netInfo.IP, c.logger)
+	c.recordChanges(existingVars, mergedVars)
+	c.renderTemplates(projectConfig, netInfo, host, transformedVars)
+	c.rewriteFiles(projectConfig, host)
+	c.rewriteExpoConfig(projectConfig, host)
+	if !c.gitChecked {
+		c.gitChecked = true
+		checkGitIgnore(projectConfig, c.logger)
+	}
+
+	if envWriter.Logger != nil {
+		envWriter.Logger.Info("Updated env file",
+			logger.Field{Key: "path", Value: projectConfig.OutputPath()},
 			logger.Field{Key: "vars", Value: len(transformedVars)})
 	}
 
 	// Display success message and URLs
-	c.displaySuccess(transformedVars, netInfo.IP, projectConfig.Output)
+	if c.brief {
+		utils.Success("Environment file updated: %s (%s)", projectConfig.OutputPath(), netInfo.IP)
+		return nil
+	}
+	c.displaySuccess(transformedVars, netInfo.IP, projectConfig.OutputPath())
 
 	return nil
 }
 
-// transformURL replaces localhost or 127.0.0.1 with the detected IP address
-func transformURL(url string, newIP string) string {
-	// Replace localhost
-	url = strings.ReplaceAll(url, "localhost", newIP)
+// staleVars reports the managed variables that are no longer generated and returns the ones to keep:
+// all of them with --keep-stale, otherwise those of detectors that failed, whose services may still run
+func (c *StartCmd) staleVars(stale []env.EnvVar, failed map[string]bool) []env.EnvVar {
+	keptVerb, removedVerb := "Kept", "Removed"
+	if c.DryRun {
+		keptVerb, removedVerb = "Would keep", "Would remove"
+	}
+
+	var kept []env.EnvVar
+	for _, v := range stale {
+		keep := c.KeepStale || (v.Source != "" && failed[v.Source])
+		if keep {
+			kept = append(kept, v)
+		}
+
+		if c.logger != nil {
+			c.logger.With("module", "env").Info("Stale variable",
+				logger.Field{Key: "key", Value: v.Key},
+				logger.Field{Key: "source", Value: v.Source},
+				logger.Field{Key: "kept", Value: keep})
+		}
+		switch {
+		case keep && !c.KeepStale:
+			utils.Info("%s %s: detector %s failed", keptVerb, v.Key, v.Source)
+		case !keep && v.Source != "":
+			utils.Info("%s %s: no longer detected by %s", removedVerb, v.Key, v.Source)
+		case !keep:
+			utils.Info("%s %s: no longer configured", removedVerb, v.Key)
+		}
+	}
+	return kept
+}
 
-	// Replace 127.0.0.1
-	url = strings.ReplaceAll(url, "127.0.0.1", newIP)
+// projectDir returns the directory of the project configuration found from the current directory,
+// "." when there is none
+// The lock, PID files and state of a project are keyed by this directory, not by where lanup runs.
+func projectDir() string {
+	if path, err := config.FindProjectConfig("."); err == nil {
+		return filepath.Dir(path)
+	}
+	return "."
+}
 
-	return url
+// lockProject keeps other lanup processes, such as watch mode or the daemon, from writing the
+// env file and its backups at the same time, it waits for them to finish
+func lockProject(log *logger.Logger) (*process.Lock, error) {
+	dir := projectDir()
+	lock, err := process.LockProject(dir, 0)
+	if errors.Is(err, process.ErrLocked) {
+		utils.Info("Waiting for another lanup process to finish writing the env file...")
+		if log != nil {
+			log.Info("Waiting for project lock", logger.Field{Key: "error", Value: err.Error()})
+		}
+		lock, err = process.LockProject(dir, process.DefaultLockTimeout)
+	}
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrIO,
+			"Failed to lock the project, another lanup process is still writing the env file", err)
+	}
+	return lock, nil
 }
 
-// displayVariables shows the environment variables in the console
-func (c *StartCmd) displayVariables(vars []env.EnvVar, ip string, isDryRun bool) {
-	if isDryRun {
-		utils.Info("Dry run mode - no files will be modified")
-		fmt.Println()
+// newEnvWriter creates the writer of the project env file, in the configured format
+func newEnvWriter(projectConfig *config.ProjectConfig) (*env.EnvWriter, error) {
+	formatter, err := plugin.NewFormatter(projectConfig.Format)
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid env file format", err)
+	}
+	envWriter := env.NewEnvWriter(projectConfig.OutputPath())
+	envWriter.Formatter = formatter
+	envWriter.Store = backupStore()
+	return envWriter, nil
+}
+
+// backupStore returns the store of the timestamped backups, nil when the home directory is unknown
+func backupStore() *backup.Store {
+	dir, err := backup.DefaultDir()
+	if err != nil {
+		return nil
+	}
+	return backup.NewStore(dir)
+}
+
+// projectNetwork returns the network of the project: the one of its ip when set, otherwise the detected one
+func projectNetwork(projectConfig *config.ProjectConfig) (*net.NetworkInfo, error) {
+	if projectConfig.IP != "" {
+		return net.ManualIP(projectConfig.IP)
+	}
+	return net.DetectLocalIP()
+}
+
+// machineFacts returns the facts the conditional vars are resolved for, on the interface of netInfo
+func machineFacts(netInfo *net.NetworkInfo) config.Facts {
+	return config.CurrentFacts(netInfo.Interface, netInfo.Type)
+}
+
+// collected holds the variables gathered by collectVariables
+type collected struct {
+	*detector.Collected
+	Failed map[string]bool // detectors that failed, services that are not running excepted
+}
+
+// transform replaces localhost with host and records the source of the detected variables
+func (f *collected) transform(projectConfig *config.ProjectConfig, host string) []env.EnvVar {
+	vars := lanup.TransformConfig(projectConfig, f.Vars, host)
+	for i := range vars {
+		vars[i].Source = f.Sources[vars[i].Key]
+	}
+	return vars
+}
+
+// collectVariables gathers the configured variables and the ones discovered by the enabled detectors
+// Detected variables override configured ones with the same key, static vars excepted
+func collectVariables(ctx context.Context, projectConfig *config.ProjectConfig, log *logger.Logger) (*collected, error) {
+	failed := make(map[string]bool)
+	all := detector.Collect(ctx, projectConfig, func(result detector.Result) {
+		logDetectorResult(log.With("module", "detector."+result.Detector), result)
+		if result.Err != nil && !errors.Is(result.Err, detector.ErrNotRunning) {
+			failed[result.Detector] = true
+		}
+	})
+	recordDetectors(all.Results)
+
+	found := &collected{Collected: all, Failed: failed}
+	return found, resolveSecrets(ctx, found.Vars)
+}
+
+// logDetectorResult logs the outcome of a detector and prints its warnings
+func logDetectorResult(log *logger.Logger, result detector.Result) {
+	if result.Skipped {
+		if log != nil {
+			log.Debug("Detector not available")
+		}
+		return
+	}
+
+	if result.Err != nil {
+		if errors.Is(result.Err, detector.ErrNotRunning) {
+			// Optional services that are not running don't deserve a warning
+			if log != nil {
+				log.Debug("Detector found nothing", logger.Field{Key: "error", Value: result.Err.Error()})
+			}
+			return
+		}
+		if log != nil {
+			log.Warn("Detector failed", logger.Field{Key: "error", Value: result.Err.Error()})
+		}
+		fmt.Fprintf(utils.Stderr, "%sWarning: %v\n", utils.Emoji("⚠️  ", ""), result.Err)
+		return
+	}
+
+	if log != nil {
+		log.Info("Detector completed", logger.Field{Key: "count", Value: len(result.Vars)})
+	}
+
+	for _, warning := range result.Warnings {
+		if log != nil {
+			log.Warn(warning)
+		}
+		fmt.Fprintf(utils.Stderr, "%sWarning: %s\n", utils.Emoji("⚠️  ", ""), warning)
+	}
+}
+
+// renderTemplates writes the template files of the project with the generated variables
+// A template that fails is reported and doesn't prevent the others from being written
+func (c *StartCmd) renderTemplates(projectConfig *config.ProjectConfig, netInfo *net.NetworkInfo, host string, vars []env.EnvVar) {
+	if len(projectConfig.Templates) == 0 {
+		return
 	}
 
+	data := templates.Data{IP: netInfo.IP, Host: host, Interface: netInfo.Interface, Vars: make(map[string]string, len(vars))}
+	for _, v := range vars {
+		data.Vars[v.Key] = v.Value
+	}
+
+	log := c.logger.With("module", "templates")
+	for _, t := range projectConfig.Templates {
+		output := projectConfig.ProjectPath(t.Output)
+		written, err := templates.Render(projectConfig.ProjectPath(t.Source), output, data)
+		if err != nil {
+			c.warn("Template %s: %v", t.Source, err)
+			if log != nil {
+				log.Warn("Template failed", logger.Field{Key: "source", Value: t.Source}, logger.Field{Key: "error", Value: err.Error()})
+			}
+			continue
+		}
+		if written {
+			if !c.brief {
+				utils.Info("Rendered %s", output)
+			}
+			if log != nil {
+				log.Info("Rendered template", logger.Field{Key: "source", Value: t.Source}, logger.Field{Key: "output", Value: output})
+			}
+		}
+	}
+}
+
+// rewriteFiles replaces localhost with host between the markers of the files of the project
+// A file that fails is reported and doesn't prevent the others from being rewritten
+func (c *StartCmd) rewriteFiles(projectConfig *config.ProjectConfig, host string) {
+	log := c.logger.With("module", "files")
+	for _, file := range projectConfig.Files {
+		path := projectConfig.ProjectPath(file)
+		changed, err := files.Rewrite(path, host)
+		if err != nil {
+			c.warn("File %s: %v", file, err)
+			if log != nil {
+				log.Warn("File rewrite failed", logger.Field{Key: "path", Value: path}, logger.Field{Key: "error", Value: err.Error()})
+			}
+			continue
+		}
+		if changed {
+			if !c.brief {
+				utils.Info("Rewrote %s", path)
+			}
+			if log != nil {
+				log.Info("Rewrote file", logger.Field{Key: "path", Value: path}, logger.Field{Key: "host", Value: host})
+			}
+		}
+	}
+}
+
+// rewriteExpoConfig replaces localhost with host in the URLs of the Expo app config
+func (c *StartCmd) rewriteExpoConfig(projectConfig *config.ProjectConfig, host string) {
+	if projectConfig.Expo.AppJSON == "" {
+		return
+	}
+
+	log := c.logger.With("module", "expo")
+	path := projectConfig.ProjectPath(projectConfig.Expo.AppJSON)
+	changes, err := updateExpoConfig(projectConfig, path, host)
+	if err != nil {
+		c.warn("Expo app config %s: %v", projectConfig.Expo.AppJSON, err)
+		if log != nil {
+			log.Warn("Expo app config failed", logger.Field{Key: "path", Value: path}, logger.Field{Key: "error", Value: err.Error()})
+		}
+		return
+	}
+	if len(changes) > 0 {
+		if !c.brief {
+			utils.Info("Rewrote %d URL(s) of %s", len(changes), path)
+		}
+		if log != nil {
+			log.Info("Rewrote Expo app config", logger.Field{Key: "path", Value: path}, logger.Field{Key: "urls", Value: len(changes)})
+		}
+	}
+}
+
+// updateExpoConfig rewrites the Expo app config at path for host, or reverts it when host is empty,
+// and returns the values it changed
+// The file is backed up first, in the backups of the env files, and the values rewritten are
+// recorded in the state of the project.
+func updateExpoConfig(projectConfig *config.ProjectConfig, path, host string) (map[string]string, error) {
+	statePath, err := state.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	st, err := state.Load(statePath)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := filepath.Abs(path)
+	previous := st.Project(projectConfig.Dir()).Expo[key]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := expo.Changes(data, host, previous)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+
+	if store := backupStore(); store != nil {
+		if err := store.Save(path, data, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	var rec *expo.Record
+	if host == "" {
+		_, err = expo.Revert(path, previous)
+	} else {
+		rec, _, err = expo.Rewrite(path, host, previous)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = state.Update(statePath, func(s *state.State) {
+		p := s.Project(projectConfig.Dir())
+		if rec == nil {
+			delete(p.Expo, key)
+			return
+		}
+		if p.Expo == nil {
+			p.Expo = make(map[string]*expo.Record)
+		}
+		p.Expo[key] = rec
+	})
+	return changes, err
+}
+
+// syncHostsEntry keeps the hosts file entry of the project hostname pointing to the current IP
+func (c *StartCmd) syncHostsEntry(hostname, ip string) {
+	log := c.logger.With("module", "hosts")
+	updated, err := ensureHostsEntry(hosts.DefaultPath(), hostname, ip, !c.DryRun && !c.NoEnv)
+	if err != nil {
+		if log != nil {
+			log.Warn("Hosts entry out of date",
+				logger.Field{Key: "hostname", Value: hostname},
+				logger.Field{Key: "error", Value: err.Error()})
+		}
+		fmt.Fprintf(utils.Stderr, "%sWarning: %v\n", utils.Emoji("⚠️  ", ""), err)
+		c.recordWarning(err.Error())
+		return
+	}
+
+	if updated {
+		if log != nil {
+			log.Info("Updated hosts entry",
+				logger.Field{Key: "hostname", Value: hostname},
+				logger.Field{Key: "ip", Value: ip})
+		}
+		utils.Success("Updated hosts entry: %s %s %s", hostname, utils.Symbol("→", "->"), ip)
+	}
+}
+
+// displayVariables shows the environment variables in the console
+func (c *StartCmd) displayVariables(vars []env.EnvVar, ip string) {
 	utils.Success("Detected local IP: %s", ip)
-	fmt.Println()
+	utils.Println()
 
 	if len(vars) > 0 {
 		utils.PrintSection("Environment Variables")
 		for _, v := range vars {
-			fmt.Printf("  %s=%s\n", color.CyanString(v.Key), v.Value)
+			fmt.Fprintf(utils.Stdout, "  %s=%s\n", color.CyanString(v.Key), v.Value)
+		}
+	}
+
+	c.displayExpoURL(ip)
+}
+
+// displayPlan shows what a run would write: the env file, its backup, the changed variables,
+// the templates, the files and the Expo app config
+func (c *StartCmd) displayPlan(projectConfig *config.ProjectConfig, envWriter *env.EnvWriter, existing, merged []env.EnvVar, ip string) error {
+	exists, err := envWriter.Exists()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to check the env file", err)
+	}
+
+	utils.Info("Dry run mode - no files will be modified")
+	utils.Println()
+	utils.Success("Detected local IP: %s", ip)
+
+	utils.PrintSection("Plan")
+	if exists {
+		utils.Printf("Would update %s\n", envWriter.FilePath)
+		if envWriter.BackupEnabled {
+			utils.Printf("Would back it up to %s.bak\n", envWriter.FilePath)
+		}
+	} else {
+		utils.Printf("Would create %s\n", envWriter.FilePath)
+	}
+	for _, t := range projectConfig.Templates {
+		utils.Printf("Would render %s from %s\n", projectConfig.ProjectPath(t.Output), projectConfig.ProjectPath(t.Source))
+	}
+	for _, file := range projectConfig.Files {
+		utils.Printf("Would rewrite localhost in %s\n", projectConfig.ProjectPath(file))
+	}
+	if projectConfig.Expo.AppJSON != "" {
+		utils.Printf("Would rewrite the URLs of %s\n", projectConfig.ProjectPath(projectConfig.Expo.AppJSON))
+	}
+	utils.Println()
+
+	// Compare the managed variables only, the others are kept as they are
+	var managed []env.EnvVar
+	for _, v := range merged {
+		if v.Managed {
+			managed = append(managed, v)
 		}
 	}
+	if !displayChanges(env.Diff(existing, managed)) {
+		utils.Success("No variable would change")
+	}
+
+	c.displayExpoURL(ip)
+	return nil
 }
 
 // displaySuccess shows a success message with the exposed URLs
@@ -264,73 +807,147 @@ func (c *StartCmd) displaySuccess(vars []env.EnvVar, ip string, outputPath strin
 	utils.Success("Successfully exposed services on your LAN!")
 	utils.Success("Environment file updated: %s", outputPath)
 	utils.Success("Local IP: %s", ip)
-	fmt.Println()
+	utils.Println()
 
 	if len(vars) > 0 {
-		utils.PrintSection("Your services are now accessible at")
+		// Only display URLs (values that start with http)
+		var urls []env.EnvVar
 		for _, v := range vars {
-			// Only display URLs (values that start with http)
 			if strings.HasPrefix(v.Value, "http") {
+				urls = append(urls, v)
+			}
+		}
+
+		utils.PrintSection("Your services are now accessible at")
+		results := c.probeURLs(urls, ip)
+		unreachable := 0
+		for i, v := range urls {
+			if results == nil {
 				utils.PrintURL(v.Key, v.Value)
+				continue
+			}
+			utils.PrintURLStatus(v.Key, v.Value, reachabilityMark(results[i].ProbeResult))
+			if !results[i].Reachable() {
+				unreachable++
+			}
+		}
+		utils.Println()
+
+		if unreachable > 0 {
+			c.warn("%d service(s) not reachable from the LAN: stopped, or listening on 127.0.0.1 only ('lanup verify' tells why)", unreachable)
+			utils.Println()
+		}
+	}
+
+	c.displayExpoURL(ip)
+
+	if c.QR {
+		urls := filterURLVars(vars, nil)
+		if c.metro != nil {
+			urls = append(urls, env.EnvVar{Key: "Expo", Value: c.metro.ExpoURL(ip)})
+		}
+		if len(urls) > 0 {
+			utils.PrintSection("Scan to open on your device")
+			if err := printQRCodes(urls); err != nil {
+				utils.Warning("%v", err)
 			}
 		}
-		fmt.Println()
 	}
 
 	utils.Info("Tip: Use 'lanup start --watch' to automatically update when your network changes")
 }
 
+// probeURLs probes the exposed URLs from ip, nil with --no-probe
+func (c *StartCmd) probeURLs(urls []env.EnvVar, ip string) []verifyResult {
+	if c.NoProbe || len(urls) == 0 {
+		return nil
+	}
+	return probeVars(context.Background(), urls, ip, startProbeTimeout)
+}
+
+// reachabilityMark returns ✓ for a reachable service and ✗ for an unreachable one
+func reachabilityMark(r net.ProbeResult) string {
+	if r.Reachable() {
+		return color.GreenString(utils.Symbol("✓", "ok"))
+	}
+	return color.RedString(utils.Symbol("✗", "unreachable"))
+}
+
+// displayExpoURL shows the Expo Go URL when a Metro bundler was detected
+func (c *StartCmd) displayExpoURL(ip string) {
+	if c.metro == nil {
+		return
+	}
+
+	utils.PrintSection("Open in Expo Go")
+	utils.PrintURL("Expo", c.metro.ExpoURL(ip))
+	utils.Println()
+}
+
 // watchMode starts watching for network changes and regenerates the .env file
 func (c *StartCmd) watchMode(projectConfig *config.ProjectConfig) error {
-	fmt.Println()
-	utils.Info("Watch mode enabled - monitoring network changes...")
-	fmt.Println("Press Ctrl+C to stop")
-	fmt.Println()
+	utils.Println()
+	// Get check interval from the flag, the project or the global config
+	interval := c.watchInterval(projectConfig)
+
+	utils.Info("Watch mode enabled - monitoring network changes every %s...", interval)
+	utils.Println("Press Ctrl+C to stop")
+	utils.Println()
 
-	// Get check interval from global config
-	globalCfg := GetGlobalConfig()
-	interval := 5 * time.Second
-	if globalCfg != nil && globalCfg.CheckInterval > 0 {
-		interval = time.Duration(globalCfg.CheckInterval) * time.Second
+	// Register this watcher so that 'lanup stop' can find it
+	pidPath, err := process.WatchPIDFile(projectDir())
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to determine watcher PID file", err)
+	}
+	if pid, running := process.RunningPID(pidPath); running {
+		return lanuperrors.NewError(lanuperrors.ErrProcess,
+			fmt.Sprintf("Watch mode is already running for this project (PID %d), run 'lanup stop' first", pid), nil)
 	}
 
-	// Create IP watcher
-	watcher := net.NewIPWatcher(interval)
+	// Serve the state to editors and scripts, before announcing the watcher with its PID file
+	if c.apiAddr != "" {
+		if c.devices, err = newDeviceServer(projectDir(), c.logger.With("module", "devices")); err != nil {
+			return lanuperrors.FromOSError("Failed to locate the paired devices", err)
+		}
+		defer c.devices.close()
+		if err := c.devices.resume(deviceState(c.currentState())); err != nil {
+			utils.Warning("Failed to serve the paired devices: %v", err)
+		}
 
-	// Set up the OnChange callback
-	watcher.OnChange = func(oldIP, newIP string) {
+		apiURL, shutdown, err := startAPIServer(c.apiAddr, &startBackend{cmd: c, projectConfig: projectConfig})
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+				"Failed to start API server", err)
+		}
+		defer shutdown()
+		utils.Info("API listening on %s", apiURL)
 		if c.logger != nil {
-			c.logger.Warn("Network interface changed",
-				logger.Field{Key: "old_ip", Value: oldIP},
-				logger.Field{Key: "new_ip", Value: newIP})
-		}
-
-		fmt.Println()
-		utils.Warning("Network change detected!")
-		fmt.Printf("  Old IP: %s\n", color.CyanString(oldIP))
-		fmt.Printf("  New IP: %s\n", color.CyanString(newIP))
-		fmt.Println()
-		utils.Info("Regenerating environment file...")
-
-		// Regenerate the .env file with the new IP
-		if err := c.executeStart(projectConfig); err != nil {
-			utils.Error("Failed to regenerate env file: %v", err)
-			if c.logger != nil {
-				c.logger.Error("Failed to regenerate env file", logger.Field{Key: "error", Value: err.Error()})
-			}
-		} else {
-			utils.Success("Environment file updated successfully!")
-			fmt.Println()
+			c.logger.With("module", "api").Info("API server started", logger.Field{Key: "url", Value: apiURL})
 		}
 	}
 
+	if err := process.WritePIDFile(pidPath); err != nil {
+		return lanuperrors.FromOSError("Failed to write watcher PID file", err)
+	}
+	defer process.RemovePIDFile(pidPath)
+	defer recordWatcher(c.trigger(), c.logger)()
+
+	// Create IP watcher
+	watcher := net.NewIPWatcher(interval)
+	watcher.Logger = c.logger.With("module", "watcher")
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	events := watcher.Subscribe(ctx)
 
 	// Set up signal handling for graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	if c.daemon {
+		signal.Notify(sigCh, syscall.SIGHUP)
+	}
 
 	// Start the watcher in a goroutine
 	errCh := make(chan error, 1)
@@ -340,20 +957,296 @@ func (c *StartCmd) watchMode(projectConfig *config.ProjectConfig) error {
 		}
 	}()
 
+	// Services started or stopped later change the variables without any network change
+	servicesCh := make(chan []env.Change)
+	if interval := detectInterval(); interval > 0 {
+		go c.watchServices(ctx, interval, projectConfig, servicesCh)
+	}
+
+	// The status line shows that watch mode is alive between changes
+	var statusTick <-chan time.Time
+	if !c.Silent && !c.daemon {
+		ticker := time.NewTicker(statusInterval)
+		defer ticker.Stop()
+		defer utils.ClearStatus()
+		statusTick = ticker.C
+	}
+
+	// Service managers restart the daemon when it hangs
+	var dog *watchdog
+	var watchdogTick <-chan time.Time
+	if c.daemon {
+		if dog = newWatchdog(watcher, interval, c.watchdog, c.logger.With("module", "daemon")); dog != nil {
+			ticker := time.NewTicker(dog.interval())
+			defer ticker.Stop()
+			go dog.guard(ctx)
+			watchdogTick = ticker.C
+		}
+		c.notify(service.NotifyReady)
+		defer c.notify(service.NotifyStopping)
+	}
+
 	// Wait for signal or error
-	select {
-	case <-sigCh:
-		fmt.Println()
-		fmt.Println("Shutting down gracefully...")
-		cancel()
-		watcher.Stop()
+	for {
+		select {
+		case <-watchdogTick:
+			dog.ping()
+			continue
+		case <-statusTick:
+			c.printStatus(watcher)
+			continue
+		case <-c.stop:
+			utils.Println("Shutting down gracefully...")
+			cancel()
+			watcher.Stop()
+			if watcher.Logger != nil {
+				watcher.Logger.Info("Watch mode stopped by the service manager")
+			}
+			return nil
+		case sig := <-sigCh:
+			utils.ClearStatus()
+			if sig == syscall.SIGHUP {
+				c.reload(projectConfig)
+				continue
+			}
+			utils.Println()
+			utils.Println("Shutting down gracefully...")
+			cancel()
+			watcher.Stop()
+			if watcher.Logger != nil {
+				watcher.Logger.Info("Watch mode stopped by user")
+			}
+			return nil
+		case event := <-events:
+			utils.ClearStatus()
+			c.networkChanged(watcher.Logger, event, projectConfig)
+		case changes := <-servicesCh:
+			utils.ClearStatus()
+			c.servicesChanged(changes, projectConfig)
+		case err := <-errCh:
+			utils.ClearStatus()
+			cancel()
+			watcher.Stop()
+			return lanuperrors.NewError(lanuperrors.ErrNoNetwork, "Watcher failed", err)
+		}
+	}
+}
+
+// notify sends a state to systemd when the daemon runs as a Type=notify unit
+func (c *StartCmd) notify(state string) {
+	if err := service.Notify(state); err != nil && c.logger != nil {
+		c.logger.With("module", "daemon").Warn("Failed to notify systemd", logger.Field{Key: "error", Value: err.Error()})
+	}
+}
+
+// printStatus updates the status line of watch mode with the IP, the exposed services and the
+// time of the last network check
+func (c *StartCmd) printStatus(watcher *net.IPWatcher) {
+	sep := " " + utils.Symbol("·", "-") + " "
+	status := utils.Symbol("●", "*") + " Watching" + sep
+
+	// The IP written, which is not the detected one when set in the configuration
+	state := c.currentState()
+	if network, ok := watcher.Network(); !ok {
+		status += "network lost, last IP " + state.IP
+	} else if network.IP == state.IP && network.Interface != "" {
+		status += state.IP + " (" + network.Interface + ")"
+	} else {
+		status += state.IP
+	}
+	status += sep + fmt.Sprintf("%d service(s)", len(state.URLs))
+
+	if checked := watcher.LastCheck(); checked.IsZero() {
+		status += sep + "checking the network"
+	} else {
+		status += sep + fmt.Sprintf("checked %s ago", time.Since(checked).Truncate(time.Second))
+	}
+	utils.Status("%s", status)
+}
+
+// watchInterval returns how often watch mode checks the network: --interval, then the check_interval
+// of the project, then the global one
+func (c *StartCmd) watchInterval(projectConfig *config.ProjectConfig) time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	// The configuration was validated when loaded
+	if interval, _ := projectConfig.WatchInterval(); interval > 0 {
+		return interval
+	}
+	return checkInterval()
+}
+
+// networkChanged regenerates the env file when the IP changed, other changes are only logged
+func (c *StartCmd) networkChanged(log *logger.Logger, event net.ChangeEvent, projectConfig *config.ProjectConfig) {
+	// The IP set with --ip or the configuration doesn't follow the network
+	if event.Reason != net.ReasonIPChanged || projectConfig.IP != "" {
+		if log != nil {
+			log.Info("Network changed",
+				logger.Field{Key: "reason", Value: string(event.Reason)},
+				logger.Field{Key: "interface", Value: event.New.Interface})
+		}
+		return
+	}
+
+	if log != nil {
+		log.Warn("Network interface changed",
+			logger.Field{Key: "old_ip", Value: event.Old.IP},
+			logger.Field{Key: "new_ip", Value: event.New.IP})
+	}
+
+	utils.Println()
+	utils.Warning("Network change detected!")
+	utils.Printf("  Old IP: %s\n", color.CyanString(event.Old.IP))
+	utils.Printf("  New IP: %s\n", color.CyanString(event.New.IP))
+	utils.Println()
+	utils.Info("Regenerating environment file...")
+
+	// Regenerate the .env file with the new IP
+	if err := c.regenerate(projectConfig, runReasonIPChanged); err != nil {
+		utils.Error("Failed to regenerate env file: %v", err)
+		if log != nil {
+			log.Error("Failed to regenerate env file", logger.Field{Key: "error", Value: err.Error()})
+		}
+	} else {
+		utils.Success("Environment file updated successfully!")
+		utils.Println()
+		c.runHooks(hooks.Change, projectConfig, event.Old.IP)
+	}
+}
+
+// watchServices runs the detectors every interval and sends the changes of the variables
+// compared to the last run, when there are any
+func (c *StartCmd) watchServices(ctx context.Context, interval time.Duration, projectConfig *config.ProjectConfig, changed chan<- []env.Change) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state := c.currentState()
+		host := projectConfig.URLHost(state.IP)
+		resolved := projectConfig.WithFacts(c.currentFacts())
+		vars := lanup.Collect(ctx, resolved, nil)
+		if err := resolveSecrets(ctx, vars); err != nil {
+			continue
+		}
+		desired := lanup.TransformConfig(resolved, vars, host)
+		desired, _ = withCertificateVars(desired, resolved, state.IP, false)
+
+		var changes []env.Change
+		for _, change := range env.Diff(c.currentVars(), desired) {
+			if change.Kind != env.Unchanged {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		select {
+		case changed <- changes:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// servicesChanged regenerates the env file when the detected services changed
+func (c *StartCmd) servicesChanged(changes []env.Change, projectConfig *config.ProjectConfig) {
+	keys := make([]string, 0, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	if c.logger != nil {
+		c.logger.Info("Detected services changed", logger.Field{Key: "vars", Value: strings.Join(keys, ",")})
+	}
+
+	utils.Println()
+	utils.Warning("Detected services changed: %s", strings.Join(keys, ", "))
+	utils.Info("Regenerating environment file...")
+
+	if err := c.regenerate(projectConfig, runReasonServices); err != nil {
+		utils.Error("Failed to regenerate env file: %v", err)
 		if c.logger != nil {
-			c.logger.Info("Watch mode stopped by user")
+			c.logger.Error("Failed to regenerate env file", logger.Field{Key: "error", Value: err.Error()})
+		}
+		return
+	}
+	utils.Success("Environment file updated successfully!")
+	utils.Println()
+	c.runHooks(hooks.Change, projectConfig, "")
+}
+
+// trigger names how this run was started, for the IP history
+func (c *StartCmd) trigger() string {
+	switch {
+	case c.daemon:
+		return "daemon"
+	case c.Watch:
+		return "watch"
+	default:
+		return "start"
+	}
+}
+
+// regenerate runs the start logic again for reason, one run at a time
+func (c *StartCmd) regenerate(projectConfig *config.ProjectConfig, reason string) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	c.reason = reason
+	return c.executeStart(projectConfig)
+}
+
+// recordState stores the result of the last run for the API, the hooks and watchServices
+func (c *StartCmd) recordState(state api.State, vars []env.EnvVar, facts config.Facts) {
+	c.stateMu.Lock()
+	c.state = state
+	c.vars = vars
+	c.facts = facts
+	c.stateMu.Unlock()
+
+	if c.devices != nil {
+		c.devices.publish(deviceState(state))
+	}
+}
+
+// currentState returns the result of the last run
+func (c *StartCmd) currentState() api.State {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// currentVars returns the variables of the last run, secrets included
+func (c *StartCmd) currentVars() []env.EnvVar {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.vars
+}
+
+// currentFacts returns the machine facts of the last run
+func (c *StartCmd) currentFacts() config.Facts {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.facts
+}
+
+// reload regenerates the env file on request (SIGHUP) without waiting for a network change
+func (c *StartCmd) reload(projectConfig *config.ProjectConfig) {
+	if c.logger != nil {
+		c.logger.Info("Reload requested")
+	}
+	utils.Info("Reload requested, regenerating environment file...")
+
+	if err := c.regenerate(projectConfig, runReasonReload); err != nil {
+		utils.Error("Failed to regenerate env file: %v", err)
+		if c.logger != nil {
+			c.logger.Error("Failed to regenerate env file", logger.Field{Key: "error", Value: err.Error()})
 		}
-		return nil
-	case err := <-errCh:
-		cancel()
-		watcher.Stop()
-		return fmt.Errorf("watcher error: %w", err)
 	}
 }
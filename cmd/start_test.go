@@ -1,13 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/raucheacho/lanup/internal/config"
 	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/state"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,6 +28,7 @@ import (
 func TestStartCmd_Run_Success(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir) // keep the IP history out of the real home
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -75,11 +89,63 @@ func TestStartCmd_Run_Success(t *testing.T) {
 
 	// Verify localhost was replaced (should not contain localhost)
 	assert.NotContains(t, contentStr, "localhost")
+
+	// Verify the project was recorded in the state file
+	statePath, err := state.DefaultPath()
+	require.NoError(t, err)
+	st, err := state.Load(statePath)
+	require.NoError(t, err)
+	require.Len(t, st.Projects, 1)
+	project := st.List()[0]
+	assert.Equal(t, ".lanup.yaml", filepath.Base(project.Config))
+	require.Len(t, project.Outputs, 1)
+	assert.Equal(t, ".env.local", filepath.Base(project.Outputs[0]))
+	assert.NotEmpty(t, project.LastIP)
+	assert.False(t, project.LastRun.IsZero())
+	assert.Nil(t, project.Watcher)
+}
+
+func TestStartCmd_Run_FromSubdirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// A monorepo with the configuration at its root
+	root := filepath.Join(tmpDir, "monorepo")
+	frontend := filepath.Join(root, "frontend")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(frontend, 0755))
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(root, ".lanup.yaml"), &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+	}))
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(frontend))
+
+	require.NoError(t, (&StartCmd{}).Run())
+
+	// The env file is written next to the configuration, not in the current directory
+	content, err := os.ReadFile(filepath.Join(root, ".env.local"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=")
+	_, err = os.Stat(filepath.Join(frontend, ".env.local"))
+	assert.True(t, os.IsNotExist(err))
+
+	// The project is recorded under its root
+	statePath, err := state.DefaultPath()
+	require.NoError(t, err)
+	st, err := state.Load(statePath)
+	require.NoError(t, err)
+	require.Len(t, st.Projects, 1)
+	assert.Equal(t, "monorepo", filepath.Base(st.List()[0].Dir))
 }
 
 func TestStartCmd_Run_WithExistingEnv(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -153,6 +219,7 @@ SECRET_KEY=my-secret
 func TestStartCmd_Run_DryRun(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -168,6 +235,7 @@ func TestStartCmd_Run_DryRun(t *testing.T) {
 			"API_URL": "http://localhost:8000",
 		},
 		Output: ".env.local",
+		IP:     "192.168.1.50",
 		AutoDetect: config.AutoDetectConfig{
 			Docker:   false,
 			Supabase: false,
@@ -187,18 +255,50 @@ func TestStartCmd_Run_DryRun(t *testing.T) {
 	}
 
 	// Run start command
-	err = startCmd.Run()
-	require.NoError(t, err)
+	out := captureOutput(t, func() { require.NoError(t, startCmd.Run()) })
 
 	// Verify .env.local was NOT created
 	envPath := filepath.Join(tmpDir, ".env.local")
 	_, err = os.Stat(envPath)
 	assert.True(t, os.IsNotExist(err), ".env.local should not exist in dry-run mode")
+	assert.Contains(t, out, "Would create .env.local\n")
+	assert.Contains(t, out, "+ API_URL=http://192.168.1.50:8000\n\n0 stale, 1 to add, 0 to remove\n")
+	assert.NotContains(t, out, "Would back it up")
+
+	// Existing files are read for the plan but neither written nor backed up
+	existing := "# lanup:managed\nAPI_URL=http://10.0.0.1:8000\n# lanup:managed\nOLD_URL=http://10.0.0.1:9000\nSECRET=keep\n"
+	require.NoError(t, os.WriteFile(envPath, []byte(existing), 0644))
+	out = captureOutput(t, func() { require.NoError(t, startCmd.Run()) })
+	content, err := os.ReadFile(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(content))
+	assert.NoFileExists(t, envPath+".bak")
+	assert.Contains(t, out, "Would update .env.local\nWould back it up to .env.local.bak\n")
+	assert.Contains(t, out, "~ API_URL (stale)\n    - http://10.0.0.1:8000\n    + http://192.168.1.50:8000\n")
+	assert.Contains(t, out, "- OLD_URL=http://10.0.0.1:9000\n\n1 stale, 0 to add, 1 to remove\n")
+	assert.NotContains(t, out, "SECRET")
+}
+
+// captureOutput returns what fn printed to the console
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	restore := utils.RedirectOutput(w)
+	defer restore()
+
+	fn()
+
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
 }
 
 func TestStartCmd_Run_NoEnv(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -245,6 +345,7 @@ func TestStartCmd_Run_NoEnv(t *testing.T) {
 func TestStartCmd_Run_MissingConfig(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -268,9 +369,192 @@ func TestStartCmd_Run_MissingConfig(t *testing.T) {
 	assert.Contains(t, err.Error(), "Failed to load project configuration")
 }
 
+func TestStartCmd_Run_Profile(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	// Create test project config with a mobile profile
+	testConfig := &config.ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+		},
+		Output: ".env.local",
+		Profiles: map[string]config.ProfileConfig{
+			"mobile": {
+				Vars:   map[string]string{"METRO_URL": "http://localhost:8081"},
+				Output: ".env.mobile",
+			},
+		},
+	}
+	err = config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig)
+	require.NoError(t, err)
+
+	// Run start command with the profile
+	startCmd := &StartCmd{Profile: "mobile"}
+	err = startCmd.Run()
+	require.NoError(t, err)
+
+	// The profile output file contains the base and profile variables
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".env.mobile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=")
+	assert.Contains(t, string(content), "METRO_URL=")
+
+	_, err = os.Stat(filepath.Join(tmpDir, ".env.local"))
+	assert.True(t, os.IsNotExist(err), "base output should not be written")
+
+	// Unknown profiles are rejected
+	startCmd = &StartCmd{Profile: "staging"}
+	err = startCmd.Run()
+	assert.Error(t, err)
+}
+
+func TestStartCmd_Run_ManualIP(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+		IP:     "192.168.1.50",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	// The ip of the configuration replaces the detected one
+	require.NoError(t, (&StartCmd{}).Run())
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".env.local"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=http://192.168.1.50:8000")
+
+	// --ip replaces the ip of the configuration
+	require.NoError(t, (&StartCmd{IP: "10.0.0.7"}).Run())
+	content, err = os.ReadFile(filepath.Join(tmpDir, ".env.local"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=http://10.0.0.7:8000")
+
+	err = (&StartCmd{IP: "10.0.0"}).Run()
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+}
+
+func TestStartCmd_WatchInterval(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	projectConfig := &config.ProjectConfig{}
+	assert.Equal(t, checkInterval(), (&StartCmd{}).watchInterval(projectConfig))
+
+	projectConfig.CheckInterval = "2s"
+	assert.Equal(t, 2*time.Second, (&StartCmd{}).watchInterval(projectConfig))
+	assert.Equal(t, 3*time.Second, (&StartCmd{Interval: 3 * time.Second}).watchInterval(projectConfig))
+
+	// --interval only applies to watch mode
+	err := (&StartCmd{Interval: time.Second}).applyOverrides(&config.ProjectConfig{})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+	assert.NoError(t, (&StartCmd{Watch: true, Interval: time.Second}).applyOverrides(&config.ProjectConfig{}))
+	err = (&StartCmd{Watch: true, Interval: 500 * time.Millisecond}).applyOverrides(&config.ProjectConfig{})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+}
+
+func TestStartCmd_ProbeURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	urls := []env.EnvVar{
+		{Key: "API_URL", Value: server.URL},
+		{Key: "WEB_URL", Value: "http://127.0.0.1:1"},
+	}
+
+	results := (&StartCmd{}).probeURLs(urls, "127.0.0.1")
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Reachable(), "any HTTP response counts as reachable")
+	assert.Contains(t, reachabilityMark(results[0].ProbeResult), "✓")
+	assert.False(t, results[1].Reachable())
+	assert.Contains(t, reachabilityMark(results[1].ProbeResult), "✗")
+
+	assert.Nil(t, (&StartCmd{NoProbe: true}).probeURLs(urls, "127.0.0.1"))
+}
+
+func TestStartCmd_Run_VarAndOutputFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000", "WEB_URL": "http://localhost:3000"},
+		Output: ".env.local",
+		IP:     "192.168.1.50",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	// The output is relative to the current directory, not to the configuration
+	require.NoError(t, os.Mkdir("web", 0755))
+	require.NoError(t, os.Chdir("web"))
+
+	startCmd := &StartCmd{
+		Vars:   []string{"WS_URL=ws://localhost:8081", "API_URL=http://localhost:9000"},
+		Output: ".env.adhoc",
+	}
+	require.NoError(t, startCmd.Run())
+
+	vars, err := env.NewEnvWriter(filepath.Join(tmpDir, "web", ".env.adhoc")).Read()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.50:9000", Managed: true},
+		{Key: "WEB_URL", Value: "http://192.168.1.50:3000", Managed: true},
+		{Key: "WS_URL", Value: "ws://192.168.1.50:8081", Managed: true},
+	}, vars)
+
+	_, err = os.Stat(filepath.Join(tmpDir, ".env.local"))
+	assert.True(t, os.IsNotExist(err), "configured output should not be written")
+
+	for _, v := range []string{"API_URL", "=http://localhost:8000", "API-URL=http://localhost:8000"} {
+		err = (&StartCmd{Vars: []string{v}}).Run()
+		assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig, v)
+	}
+}
+
+func TestStartCmd_ApplyDetectors(t *testing.T) {
+	projectConfig := &config.ProjectConfig{AutoDetect: config.AutoDetectConfig{Supabase: true}}
+	c := &StartCmd{Detect: []string{"docker", "dev-servers"}, NoDetect: []string{"supabase"}}
+	require.NoError(t, c.applyDetectors(projectConfig))
+	assert.Equal(t, config.AutoDetectConfig{Docker: true, DevServers: true}, projectConfig.AutoDetect)
+
+	// Everything but one detector
+	projectConfig = &config.ProjectConfig{}
+	c = &StartCmd{Detect: []string{"all"}, NoDetect: []string{"expo"}}
+	require.NoError(t, c.applyDetectors(projectConfig))
+	assert.Equal(t, config.AutoDetectConfig{Docker: true, Supabase: true, DevServers: true}, projectConfig.AutoDetect)
+
+	c = &StartCmd{Detect: []string{"docker"}, NoDetect: []string{"docker"}}
+	assert.ErrorIs(t, c.applyDetectors(&config.ProjectConfig{}), lanuperrors.ErrInvalidConfig)
+
+	c = &StartCmd{Detect: []string{"kubernetes"}}
+	assert.ErrorIs(t, c.applyDetectors(&config.ProjectConfig{}), lanuperrors.ErrInvalidConfig)
+}
+
 func TestStartCmd_ExecuteStart_PreservesUserVariables(t *testing.T) {
 	// Create temporary directory for test
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
 	// Change to temp directory
 	originalWd, err := os.Getwd()
@@ -358,46 +642,323 @@ CUSTOM_VAR=custom-value
 	assert.Equal(t, "custom-value", customVar.Value)
 }
 
-func TestTransformURL(t *testing.T) {
+func TestStartCmd_Run_VarPrefix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// The prefix comes from the global defaults, the project doesn't set it
+	config.SetProjectDefaults(config.ProjectDefaults{VarPrefix: "VITE_"})
+	defer config.SetProjectDefaults(config.ProjectDefaults{})
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"APP_URL": "http://localhost:5173"},
+		Output: ".env",
+		Detectors: []config.DetectorConfig{
+			{Name: "api", Command: "echo API_URL=http://localhost:3000; echo VITE_WS_URL=ws://localhost:3001"},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.NoError(t, (&StartCmd{}).Run())
+
+	vars, err := env.NewEnvWriter(".env").Read()
+	require.NoError(t, err)
+	keys := make([]string, 0, len(vars))
+	for _, v := range vars {
+		keys = append(keys, v.Key)
+	}
+	// Detected variables are prefixed once, configured ones keep their name
+	assert.ElementsMatch(t, []string{"APP_URL", "VITE_API_URL", "VITE_WS_URL"}, keys)
+}
+
+func TestStartCmd_Run_StaticVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:       map[string]string{"API_URL": "http://localhost:8000"},
+		StaticVars: map[string]string{"FEATURE_FLAGS": "localhost-only,beta", "WS_URL": "ws://localhost:3001"},
+		Output:     ".env",
+		Detectors: []config.DetectorConfig{
+			{Name: "api", Command: "echo WS_URL=ws://localhost:4000"},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.NoError(t, (&StartCmd{}).Run())
+
+	vars, err := env.NewEnvWriter(".env").Read()
+	require.NoError(t, err)
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		values[v.Key] = v.Value
+	}
+	assert.NotContains(t, values["API_URL"], "localhost")
+	// Static vars are written as configured, even when a detector finds the same name
+	assert.Equal(t, "localhost-only,beta", values["FEATURE_FLAGS"])
+	assert.Equal(t, "ws://localhost:3001", values["WS_URL"])
+}
+
+func TestStartCmd_Run_Templates(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("Caddyfile.tmpl", []byte("{{ .IP }}:8443 {\n\treverse_proxy {{ .Vars.API_URL }}\n}\n"), 0644))
+	testConfig := &config.ProjectConfig{
+		Vars:      map[string]string{"API_URL": "http://localhost:8000"},
+		Output:    ".env",
+		Templates: []config.TemplateConfig{{Source: "Caddyfile.tmpl", Output: "Caddyfile"}},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.NoError(t, (&StartCmd{}).Run())
+
+	vars, err := env.NewEnvWriter(".env").Read()
+	require.NoError(t, err)
+	require.Len(t, vars, 1)
+	content, err := os.ReadFile("Caddyfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "reverse_proxy "+vars[0].Value)
+	assert.NotContains(t, string(content), "localhost")
+}
+
+func TestStartCmd_Run_Cert(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env",
+		Cert:   config.CertConfig{Hosts: []string{"myapp.lan"}, CertVar: "SSL_CRT_FILE", KeyVar: "SSL_KEY_FILE"},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.NoError(t, (&StartCmd{}).Run())
+
+	vars, err := env.NewEnvWriter(".env").Read()
+	require.NoError(t, err)
+	values := make(map[string]string)
+	for _, v := range vars {
+		values[v.Key] = v.Value
+	}
+	require.Contains(t, values, "SSL_CRT_FILE")
+	require.Contains(t, values, "SSL_KEY_FILE")
+	assert.FileExists(t, values["SSL_KEY_FILE"])
+
+	data, err := os.ReadFile(values["SSL_CRT_FILE"])
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.NoError(t, certificate.VerifyHostname("myapp.lan"))
+	assert.NoError(t, certificate.VerifyHostname("localhost"))
+}
+
+func TestStartCmd_Run_GitIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, exec.Command("git", "init", "-q").Run())
+
+	testConfig := &config.ProjectConfig{
+		Vars:      map[string]string{"API_URL": "http://localhost:8000"},
+		Output:    ".env.local",
+		GitIgnore: true,
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	require.NoError(t, (&StartCmd{}).Run())
+	require.NoError(t, (&StartCmd{}).Run())
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "/.env.local\n"), "added once")
+	assert.Contains(t, string(data), "/.env.local.bak\n")
+}
+
+func TestStartCmd_Run_StaleVariables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Output: ".env",
+		Detectors: []config.DetectorConfig{
+			{Name: "api", Command: "echo API_URL=http://localhost:3000"},
+			{Name: "broken", Command: "exit 1"},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	existing := `# lanup:managed source=api
+API_URL=http://192.168.1.50:3000
+# lanup:managed source=broken
+BROKEN_URL=http://192.168.1.50:4000
+# lanup:managed source=gone
+GONE_URL=http://192.168.1.50:5000
+# lanup:managed
+OLD_VAR=http://192.168.1.50:6000
+SECRET_KEY=my-secret
+`
+
 	tests := []struct {
-		name  string
-		url   string
-		newIP string
+		name      string
+		keepStale bool
+		expected  []string
 	}{
 		{
-			name:  "replace localhost",
-			url:   "http://localhost:8000",
-			newIP: "192.168.1.100",
-		},
-		{
-			name:  "replace 127.0.0.1",
-			url:   "http://127.0.0.1:8000",
-			newIP: "192.168.1.100",
+			name:     "stale variables removed",
+			expected: []string{"API_URL", "BROKEN_URL", "SECRET_KEY"},
 		},
 		{
-			name:  "replace localhost with https",
-			url:   "https://localhost:8443",
-			newIP: "192.168.1.100",
+			name:      "keep stale",
+			keepStale: true,
+			expected:  []string{"API_URL", "BROKEN_URL", "GONE_URL", "OLD_VAR", "SECRET_KEY"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := transformURL(tt.url, tt.newIP)
+			envPath := filepath.Join(tmpDir, ".env")
+			require.NoError(t, os.WriteFile(envPath, []byte(existing), 0644))
+
+			startCmd := &StartCmd{KeepStale: tt.keepStale}
+			require.NoError(t, startCmd.Run())
+
+			vars, err := env.NewEnvWriter(envPath).Read()
+			require.NoError(t, err)
+
+			var keys []string
+			for _, v := range vars {
+				keys = append(keys, v.Key)
+				if v.Key == "API_URL" {
+					assert.Equal(t, "api", v.Source)
+					assert.NotContains(t, v.Value, "192.168.1.50", "detected variable regenerated")
+				}
+				if v.Key == "BROKEN_URL" {
+					assert.Equal(t, "broken", v.Source)
+					assert.True(t, v.Managed)
+				}
+			}
+			sort.Strings(keys)
+			assert.Equal(t, tt.expected, keys)
+		})
+	}
+}
 
-			// Verify localhost was replaced
-			assert.NotContains(t, result, "localhost")
-			assert.NotContains(t, result, "127.0.0.1")
+func TestStartCmd_Run_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
 
-			// Verify new IP is in the result
-			assert.Contains(t, result, tt.newIP)
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 
-			// Verify protocol is preserved
-			if strings.HasPrefix(tt.url, "https") {
-				assert.True(t, strings.HasPrefix(result, "https"))
-			} else {
-				assert.True(t, strings.HasPrefix(result, "http"))
-			}
-		})
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+		Hooks: config.HooksConfig{
+			OnStart: []string{`echo "$LANUP_EVENT $API_URL" > hook.txt`},
+			OnStop:  []string{`echo "$LANUP_EVENT $API_URL" >> hook.txt`},
+		},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	// Dry runs don't write the env file and don't run the hooks
+	require.NoError(t, (&StartCmd{DryRun: true}).Run())
+	assert.NoFileExists(t, "hook.txt")
+
+	require.NoError(t, (&StartCmd{}).Run())
+	require.NoError(t, (&StopCmd{}).Run())
+
+	content, err := os.ReadFile("hook.txt")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^on_start http://[0-9.]+:8000$`, lines[0])
+	assert.Equal(t, "on_stop http://localhost:8000", lines[1])
+}
+
+func TestStartCmd_WatchServices(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	servicesPath := filepath.Join(tmpDir, "services.env")
+	require.NoError(t, os.WriteFile(servicesPath, []byte("WEB_URL=http://localhost:3000\n"), 0644))
+
+	projectConfig := &config.ProjectConfig{
+		Vars:      map[string]string{"API_URL": "http://localhost:8000"},
+		Output:    filepath.Join(tmpDir, ".env.local"),
+		Detectors: []config.DetectorConfig{{Name: "services", Command: "cat " + servicesPath}},
+	}
+	startCmd := &StartCmd{DryRun: true}
+	require.NoError(t, startCmd.executeStart(projectConfig))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan []env.Change)
+	go startCmd.watchServices(ctx, 10*time.Millisecond, projectConfig, changed)
+
+	// A new service is reported, the unchanged ones are not
+	require.NoError(t, os.WriteFile(servicesPath, []byte("WEB_URL=http://localhost:3000\nADMIN_URL=http://localhost:4000\n"), 0644))
+	select {
+	case changes := <-changed:
+		require.Len(t, changes, 1)
+		assert.Equal(t, "ADMIN_URL", changes[0].Key)
+		assert.Equal(t, env.Added, changes[0].Kind)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new service was not reported")
 	}
 }
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/state"
+)
+
+// updateState applies fn to the state file
+// Failures are only logged: the state must never prevent lanup from working
+func updateState(log *logger.Logger, fn func(*state.State)) {
+	path, err := state.DefaultPath()
+	if err != nil {
+		return
+	}
+	if err := state.Update(path, fn); err != nil && log != nil {
+		log.With("module", "state").Warn("Failed to update state", logger.Field{Key: "error", Value: err.Error()})
+	}
+}
+
+// recordProject records the run of the project once its env file was written
+func recordProject(projectConfig *config.ProjectConfig, profile, ip string, log *logger.Logger) {
+	configPath, _ := filepath.Abs(projectConfig.Path())
+	updateState(log, func(s *state.State) {
+		p := s.Project(projectConfig.Dir())
+		p.Config = configPath
+		p.Profile = profile
+		p.AddOutput(projectConfig.OutputPath())
+		p.LastIP = ip
+		p.LastRun = time.Now()
+	})
+}
+
+// recordWatcher registers the watch mode or daemon of the current project
+// The returned function clears the record when the watcher exits
+func recordWatcher(mode string, log *logger.Logger) func() {
+	pid := os.Getpid()
+	dir := projectDir()
+	updateState(log, func(s *state.State) {
+		s.Project(dir).Watcher = &state.Watcher{PID: pid, Mode: mode, Started: time.Now()}
+	})
+
+	return func() {
+		updateState(log, func(s *state.State) {
+			if p := s.Project(dir); p.Watcher != nil && p.Watcher.PID == pid {
+				p.Watcher = nil
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/files"
+	"github.com/raucheacho/lanup/internal/hooks"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/process"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// StopCmd represents the stop command
+type StopCmd struct {
+	Log     bool
+	Profile string
+	Output  string // env file rolled back instead of the configured one
+	logger  *logger.Logger
+}
+
+// NewStopCmd creates a new stop command
+func NewStopCmd() *cobra.Command {
+	stopCmd := &StopCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop exposing services and revert to localhost",
+		Long: `Stop exposing local services on your LAN.
+
+This command stops a running 'lanup start --watch' for the current project and rewrites
+the managed variables of the env file back to their original localhost values from
+.lanup.yaml. Variables added by auto-detection are removed, user variables are preserved.
+Use the same --profile as 'lanup start' to roll back that profile's output file, and
+the same --output when it was started with one.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stopCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().BoolVar(&stopCmd.Log, "log", true, "enable logging to file")
+	cmd.Flags().StringVar(&stopCmd.Profile, "profile", "", "configuration profile to roll back (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().StringVarP(&stopCmd.Output, "output", "o", "", "roll back this env file instead of the configured output")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewStopCmd())
+}
+
+// Run executes the stop command
+func (c *StopCmd) Run() error {
+	// Initialize logger if enabled
+	if c.Log {
+		var err error
+		c.logger, err = newFileLogger()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logger: %v\n", err)
+		} else if c.logger != nil {
+			defer c.logger.Close()
+			c.logger = c.logger.With("module", "stop")
+		}
+	}
+
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+	if err := overrideOutput(projectConfig, c.Output); err != nil {
+		return err
+	}
+
+	// Stop the watcher first so it cannot rewrite the file after the rollback
+	if err := c.stopWatcher(); err != nil {
+		return err
+	}
+
+	return c.rollback(projectConfig)
+}
+
+// stopWatcher terminates a running watch mode for the current project
+func (c *StopCmd) stopWatcher() error {
+	pid, stopped, err := terminateWatcher()
+	if err != nil || !stopped {
+		return err
+	}
+
+	if c.logger != nil {
+		c.logger.Info("Stopped watch mode", logger.Field{Key: "pid", Value: pid})
+	}
+	utils.Success("Stopped watch mode (PID %d)", pid)
+
+	return nil
+}
+
+// terminateWatcher stops the watcher (watch mode or daemon) of the current project
+// It returns the PID of the stopped process and whether one was running.
+func terminateWatcher() (int, bool, error) {
+	pidPath, err := process.WatchPIDFile(projectDir())
+	if err != nil {
+		return 0, false, lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to determine watcher PID file", err)
+	}
+
+	pid, running := process.RunningPID(pidPath)
+	if !running {
+		return 0, false, nil
+	}
+
+	if err := process.StopAndWait(pid, 5*time.Second); err != nil {
+		return pid, false, lanuperrors.NewError(lanuperrors.ErrPermissionDenied,
+			"Failed to stop watch mode", err)
+	}
+	_ = process.RemovePIDFile(pidPath)
+
+	return pid, true, nil
+}
+
+// rollback rewrites the managed variables of the env file to their localhost values
+func (c *StopCmd) rollback(projectConfig *config.ProjectConfig) error {
+	envWriter, err := newEnvWriter(projectConfig)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockProject(c.logger)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	c.revertFiles(projectConfig)
+	c.revertExpoConfig(projectConfig)
+
+	exists, err := envWriter.Exists()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
+	}
+	if !exists {
+		utils.Info("No env file found at %s, nothing to roll back", projectConfig.OutputPath())
+		return nil
+	}
+
+	existingVars, err := envWriter.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
+	}
+
+	// Static vars were written as configured and stay as they are
+	configVars := make(map[string]string, len(projectConfig.Vars)+len(projectConfig.StaticVars))
+	for key, value := range projectConfig.Vars {
+		configVars[key] = value
+	}
+	for key, value := range projectConfig.StaticVars {
+		configVars[key] = value
+	}
+	if err := resolveSecrets(context.Background(), configVars); err != nil {
+		return err
+	}
+	restoredVars, restored, removed := restoreManagedVars(existingVars, configVars)
+
+	if err := envWriter.Write(restoredVars); err != nil {
+		return lanuperrors.FromOSError("Failed to write env file", err)
+	}
+	// The hooks may run lanup again
+	lock.Release()
+
+	if c.logger != nil {
+		c.logger.Info("Rolled back env file",
+			logger.Field{Key: "path", Value: projectConfig.OutputPath()},
+			logger.Field{Key: "restored", Value: restored},
+			logger.Field{Key: "removed", Value: len(removed)})
+	}
+
+	utils.Success("Environment file reverted to localhost: %s", projectConfig.OutputPath())
+	utils.Info("Restored %d variable(s) from configuration", restored)
+	if len(removed) > 0 {
+		utils.Info("Removed %d detected variable(s):", len(removed))
+		for _, key := range removed {
+			utils.Printf("  - %s\n", key)
+		}
+	}
+
+	runHooks(hooks.Stop, projectConfig, managedVars(restoredVars), hooks.Info{Output: projectConfig.OutputPath()}, c.logger)
+
+	return nil
+}
+
+// revertFiles writes the original hostnames back in the files of the project lanup rewrote
+func (c *StopCmd) revertFiles(projectConfig *config.ProjectConfig) {
+	for _, file := range projectConfig.Files {
+		path := projectConfig.ProjectPath(file)
+		changed, err := files.Revert(path)
+		if err != nil {
+			utils.Warning("File %s: %v", file, err)
+			continue
+		}
+		if changed {
+			if c.logger != nil {
+				c.logger.Info("Reverted file", logger.Field{Key: "path", Value: path})
+			}
+			utils.Success("File reverted: %s", path)
+		}
+	}
+}
+
+// revertExpoConfig writes back the URLs of the Expo app config lanup rewrote
+func (c *StopCmd) revertExpoConfig(projectConfig *config.ProjectConfig) {
+	if projectConfig.Expo.AppJSON == "" {
+		return
+	}
+
+	path := projectConfig.ProjectPath(projectConfig.Expo.AppJSON)
+	changes, err := updateExpoConfig(projectConfig, path, "")
+	if err != nil {
+		utils.Warning("Expo app config %s: %v", projectConfig.Expo.AppJSON, err)
+		return
+	}
+	if len(changes) > 0 {
+		if c.logger != nil {
+			c.logger.Info("Reverted Expo app config", logger.Field{Key: "path", Value: path})
+		}
+		utils.Success("Expo app config reverted to localhost: %s", path)
+	}
+}
+
+// restoreManagedVars resets managed variables to their configured values
+// Managed variables that are not part of the configuration were added by detectors and are removed.
+// It returns the resulting variables, the number of restored variables and the removed keys.
+func restoreManagedVars(existing []env.EnvVar, configVars map[string]string) ([]env.EnvVar, int, []string) {
+	result := make([]env.EnvVar, 0, len(existing))
+	restored := 0
+	var removed []string
+
+	for _, v := range existing {
+		if !v.Managed {
+			result = append(result, v)
+			continue
+		}
+
+		original, ok := configVars[v.Key]
+		if !ok {
+			removed = append(removed, v.Key)
+			continue
+		}
+
+		result = append(result, env.EnvVar{Key: v.Key, Value: original, Managed: true})
+		restored++
+	}
+
+	return result, restored, removed
+}
+
+// managedVars returns the variables managed by lanup
+func managedVars(vars []env.EnvVar) []env.EnvVar {
+	var managed []env.EnvVar
+	for _, v := range vars {
+		if v.Managed {
+			managed = append(managed, v)
+		}
+	}
+	return managed
+}
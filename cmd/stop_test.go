@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/expo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopCmd_Run_RevertsToLocalhost(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// Change to temp directory
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	// Create test project config
+	testConfig := &config.ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+		},
+		Output: ".env.local",
+	}
+	err = config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig)
+	require.NoError(t, err)
+
+	// Create an env file written by a previous start
+	envPath := filepath.Join(tmpDir, ".env.local")
+	existingContent := `# lanup:managed
+API_URL=http://192.168.1.50:8000
+# lanup:managed
+DOCKER_WEB_PORT=http://192.168.1.50:8080
+
+# User variables (preserved)
+SECRET_KEY=my-secret
+`
+	err = os.WriteFile(envPath, []byte(existingContent), 0644)
+	require.NoError(t, err)
+
+	stopCmd := &StopCmd{Log: false}
+	err = stopCmd.Run()
+	require.NoError(t, err)
+
+	vars, err := env.NewEnvWriter(envPath).Read()
+	require.NoError(t, err)
+
+	varMap := make(map[string]env.EnvVar)
+	for _, v := range vars {
+		varMap[v.Key] = v
+	}
+
+	assert.Equal(t, "http://localhost:8000", varMap["API_URL"].Value)
+	assert.True(t, varMap["API_URL"].Managed)
+	assert.NotContains(t, varMap, "DOCKER_WEB_PORT")
+	assert.Equal(t, "my-secret", varMap["SECRET_KEY"].Value)
+
+	// The previous content is kept as a backup
+	backup, err := os.ReadFile(envPath + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, existingContent, string(backup))
+}
+
+func TestStopCmd_Run_Output(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+
+	// An env file written by 'lanup start --output .env.adhoc'
+	envPath := filepath.Join(tmpDir, ".env.adhoc")
+	require.NoError(t, os.WriteFile(envPath, []byte("# lanup:managed\nAPI_URL=http://192.168.1.50:8000\n"), 0644))
+
+	require.NoError(t, (&StopCmd{Output: ".env.adhoc"}).Run())
+
+	vars, err := env.NewEnvWriter(envPath).Read()
+	require.NoError(t, err)
+	assert.Equal(t, []env.EnvVar{{Key: "API_URL", Value: "http://localhost:8000", Managed: true}}, vars)
+}
+
+func TestStopCmd_Run_NoEnvFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	err = os.Chdir(tmpDir)
+	require.NoError(t, err)
+
+	err = config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), config.GetDefaultProjectConfig())
+	require.NoError(t, err)
+
+	stopCmd := &StopCmd{Log: false}
+	assert.NoError(t, stopCmd.Run())
+
+	_, err = os.Stat(filepath.Join(tmpDir, ".env.local"))
+	assert.True(t, os.IsNotExist(err), "stop should not create an env file")
+}
+
+func TestStartAndStop_Files(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+		IP:     "192.168.1.50",
+		Files:  []string{"capacitor.config.ts", "missing.json"},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+	original := "server: {\n  // lanup:begin\n  url: 'http://localhost:5173',\n  // lanup:end\n},\n"
+	require.NoError(t, os.WriteFile("capacitor.config.ts", []byte(original), 0644))
+
+	// A missing file only prints a warning
+	require.NoError(t, (&StartCmd{NoProbe: true}).Run())
+	content, err := os.ReadFile("capacitor.config.ts")
+	require.NoError(t, err)
+	assert.Equal(t, "server: {\n  // lanup:begin host=192.168.1.50\n  url: 'http://192.168.1.50:5173',\n  // lanup:end\n},\n", string(content))
+
+	require.NoError(t, (&StopCmd{}).Run())
+	content, err = os.ReadFile("capacitor.config.ts")
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+}
+
+func TestStartAndStop_ExpoConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	testConfig := &config.ProjectConfig{
+		Vars:   map[string]string{"API_URL": "http://localhost:8000"},
+		Output: ".env.local",
+		IP:     "192.168.1.50",
+		Expo:   config.ExpoConfig{AppJSON: "app.json"},
+	}
+	require.NoError(t, config.SaveProjectConfig(filepath.Join(tmpDir, ".lanup.yaml"), testConfig))
+	original := "{\n  \"expo\": {\n    \"extra\": {\n      \"apiUrl\": \"http://localhost:8000\"\n    }\n  }\n}\n"
+	require.NoError(t, os.WriteFile("app.json", []byte(original), 0644))
+
+	require.NoError(t, (&StartCmd{NoProbe: true}).Run())
+	content, err := os.ReadFile("app.json")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(original, "localhost", "192.168.1.50", 1), string(content))
+
+	// The original values are recorded in the state rather than in the file
+	st, err := loadState()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*expo.Record{
+		filepath.Join(tmpDir, "app.json"): {Host: "192.168.1.50", Originals: map[string]string{"extra.apiUrl": "http://localhost:8000"}},
+	}, st.Project(tmpDir).Expo)
+
+	// The original file was backed up
+	backups, err := backupStore().List(filepath.Join(tmpDir, "app.json"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	data, err := backups[0].Read()
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+
+	require.NoError(t, (&StopCmd{}).Run())
+	content, err = os.ReadFile("app.json")
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+	st, err = loadState()
+	require.NoError(t, err)
+	assert.Empty(t, st.Project(tmpDir).Expo)
+}
+
+func TestRestoreManagedVars(t *testing.T) {
+	existing := []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.50:8000", Managed: true},
+		{Key: "SUPABASE_API_URL_PORT", Value: "http://192.168.1.50:54321", Managed: true},
+		{Key: "USER_VAR", Value: "http://localhost:1234", Managed: false},
+	}
+
+	result, restored, removed := restoreManagedVars(existing, map[string]string{
+		"API_URL": "http://localhost:8000",
+	})
+
+	assert.Equal(t, []env.EnvVar{
+		{Key: "API_URL", Value: "http://localhost:8000", Managed: true},
+		{Key: "USER_VAR", Value: "http://localhost:1234", Managed: false},
+	}, result)
+	assert.Equal(t, 1, restored)
+	assert.Equal(t, []string{"SUPABASE_API_URL_PORT"}, removed)
+}
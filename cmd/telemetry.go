@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/telemetry"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// TelemetryCmd represents the telemetry command
+type TelemetryCmd struct{}
+
+// telemetryDetectors holds the statuses of the built-in detectors of the last detection, for the telemetry event
+var telemetryDetectors struct {
+	sync.Mutex
+	statuses map[string]string
+}
+
+// NewTelemetryCmd creates a new telemetry command
+func NewTelemetryCmd() *cobra.Command {
+	telemetryCmd := &TelemetryCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Enable or disable anonymous usage statistics",
+		Long: `Enable or disable anonymous usage statistics, which tell the maintainers which commands
+and detectors matter. Telemetry is off until you run 'lanup telemetry on'.
+
+After each command, lanup then sends:
+  - the command, without its arguments or flags (e.g. "service install")
+  - the version of lanup, the OS and the architecture
+  - whether the command succeeded and how long it took
+  - whether the built-in detectors (docker, supabase, dev_servers, expo) found something
+  - a random ID created by 'lanup telemetry on' and deleted by 'lanup telemetry off'
+
+IP addresses, hostnames, paths, project names, variable names and values are never sent.
+DO_NOT_TRACK=1 or LANUP_TELEMETRY=0 disable telemetry whatever the configuration.
+
+Examples:
+  lanup telemetry status
+  lanup telemetry on
+  lanup telemetry off`,
+	}
+
+	onCmd := &cobra.Command{
+		Use:   "on",
+		Short: "Send anonymous usage statistics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return telemetryCmd.On()
+		},
+	}
+
+	offCmd := &cobra.Command{
+		Use:   "off",
+		Short: "Stop sending usage statistics and delete the telemetry ID",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return telemetryCmd.Off()
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether telemetry is enabled and what is sent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return telemetryCmd.Status()
+		},
+	}
+
+	cmd.AddCommand(onCmd, offCmd, statusCmd)
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewTelemetryCmd())
+}
+
+// On enables telemetry and creates the telemetry ID
+func (c *TelemetryCmd) On() error {
+	if err := setTelemetry(true); err != nil {
+		return err
+	}
+
+	utils.Success("Telemetry enabled, thank you!")
+	utils.Info("Run 'lanup telemetry status' to see what is sent, 'lanup telemetry off' to stop")
+	if env := telemetry.DisabledByEnv(); env != "" {
+		utils.Warning("%s is set: nothing is sent until it is unset", env)
+	}
+	return nil
+}
+
+// Off disables telemetry and deletes the telemetry ID
+func (c *TelemetryCmd) Off() error {
+	if err := setTelemetry(false); err != nil {
+		return err
+	}
+
+	utils.Success("Telemetry disabled, the telemetry ID was deleted")
+	return nil
+}
+
+// setTelemetry saves the telemetry setting, creating the telemetry ID when enabled and deleting it otherwise
+func setTelemetry(enabled bool) error {
+	cfg, err := loadGlobalConfigForEdit()
+	if err != nil {
+		return err
+	}
+	cfg.Telemetry = enabled
+	if err := config.SaveGlobalConfig(cfg); err != nil {
+		return lanuperrors.FromOSError("Failed to save global configuration", err)
+	}
+
+	path, err := telemetry.DefaultPath()
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrIO, "Failed to locate the telemetry state", err)
+	}
+	s, err := telemetry.Load(path)
+	if err != nil {
+		// A corrupt file is replaced
+		s = &telemetry.State{}
+	}
+	s.NoticeShown = true
+	s.ID = ""
+	if enabled {
+		if s.ID, err = telemetry.NewID(); err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrIO, "Failed to create the telemetry ID", err)
+		}
+	}
+	if err := s.Save(path); err != nil {
+		return lanuperrors.FromOSError("Failed to save the telemetry state", err)
+	}
+	return nil
+}
+
+// Status prints whether telemetry is enabled and an example of the events sent
+func (c *TelemetryCmd) Status() error {
+	globalCfg := GetGlobalConfig()
+	env := telemetry.DisabledByEnv()
+
+	switch {
+	case globalCfg == nil || !globalCfg.Telemetry:
+		utils.Info("Telemetry is disabled, run 'lanup telemetry on' to enable it")
+		return nil
+	case env != "":
+		utils.Info("Telemetry is enabled in the configuration, but disabled by %s", env)
+	default:
+		utils.Success("Telemetry is enabled")
+	}
+
+	id := ""
+	if path, err := telemetry.DefaultPath(); err == nil {
+		if s, err := telemetry.Load(path); err == nil {
+			id = s.ID
+		}
+	}
+	fmt.Printf("  Endpoint: %s\n", telemetry.Endpoint())
+	if id != "" {
+		fmt.Printf("  ID:       %s\n", id)
+	}
+
+	utils.PrintSection("Example event")
+	return printJSON(telemetry.Event{
+		ID:        id,
+		Version:   Version,
+		Command:   "start",
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Success:   true,
+		Duration:  850,
+		Detectors: map[string]string{"docker": "found", "supabase": "not_running", "dev_servers": "found", "expo": "skipped"},
+	})
+}
+
+// recordDetectors keeps the statuses of the built-in detectors for the telemetry event
+func recordDetectors(results []detector.Result) {
+	builtin := make(map[string]bool, len(telemetry.BuiltinDetectors))
+	for _, name := range telemetry.BuiltinDetectors {
+		builtin[name] = true
+	}
+
+	statuses := make(map[string]string)
+	for _, d := range exportDetectors(results) {
+		if builtin[d.Name] {
+			statuses[d.Name] = d.Status
+		}
+	}
+
+	telemetryDetectors.Lock()
+	telemetryDetectors.statuses = statuses
+	telemetryDetectors.Unlock()
+}
+
+// telemetryCommand returns the name of the command sent in the telemetry event, empty for
+// the commands that are not reported
+func telemetryCommand(cmd *cobra.Command) string {
+	if cmd == nil || cmd == RootCmd || cmd.Hidden {
+		return ""
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), RootCmd.Name()+" ")
+	// Shell completion runs at every key press, and the telemetry command would report opting out
+	if strings.HasPrefix(name, "__") || strings.HasPrefix(name, "completion") || strings.HasPrefix(name, "telemetry") {
+		return ""
+	}
+	return name
+}
+
+// sendTelemetry sends the event of a command when telemetry is enabled, and otherwise prints
+// the first-run notice explaining how to enable it
+func sendTelemetry(cmd *cobra.Command, err error, duration time.Duration) {
+	globalCfg := GetGlobalConfig()
+	name := telemetryCommand(cmd)
+	if globalCfg == nil || name == "" || telemetry.DisabledByEnv() != "" {
+		return
+	}
+
+	path, pathErr := telemetry.DefaultPath()
+	if pathErr != nil {
+		return
+	}
+	s, loadErr := telemetry.Load(path)
+	if loadErr != nil {
+		s = &telemetry.State{}
+	}
+
+	if !globalCfg.Telemetry {
+		showTelemetryNotice(s, path)
+		return
+	}
+
+	// Telemetry enabled with 'lanup config set' has no ID yet
+	if s.ID == "" {
+		id, idErr := telemetry.NewID()
+		if idErr != nil {
+			return
+		}
+		s.ID, s.NoticeShown = id, true
+		_ = s.Save(path)
+	}
+
+	event := telemetry.Event{
+		ID:       s.ID,
+		Version:  Version,
+		Command:  name,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Success:  err == nil,
+		Duration: duration.Milliseconds(),
+	}
+	telemetryDetectors.Lock()
+	event.Detectors = telemetryDetectors.statuses
+	telemetryDetectors.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetry.Timeout)
+	defer cancel()
+	if sendErr := telemetry.NewClient().Send(ctx, event); sendErr != nil {
+		if log, _ := newFileLogger(); log != nil {
+			log.With("module", "telemetry").Debug("Failed to send telemetry event", logger.Field{Key: "error", Value: sendErr.Error()})
+			log.Close()
+		}
+	}
+}
+
+// showTelemetryNotice tells an interactive user once that telemetry exists and is off
+func showTelemetryNotice(s *telemetry.State, path string) {
+	if s.NoticeShown || utils.IsQuiet() || !logger.IsTerminal() {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%slanup can send anonymous usage statistics (the command, the OS and the detectors\n", utils.Emoji("ℹ️  ", ""))
+	fmt.Fprintln(os.Stderr, "   available, never IPs, paths or variables) to help the maintainers. It is off:")
+	fmt.Fprintln(os.Stderr, "   run 'lanup telemetry on' to enable it. This notice is only shown once.")
+
+	s.NoticeShown = true
+	_ = s.Save(path)
+}
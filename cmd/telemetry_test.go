@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTelemetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(config.EnvGlobalConfig, "")
+	path := filepath.Join(tmpDir, ".lanup", "telemetry.json")
+
+	require.NoError(t, setTelemetry(true))
+	cfg, err := config.LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.Telemetry)
+	s, err := telemetry.Load(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.ID)
+	assert.True(t, s.NoticeShown)
+
+	require.NoError(t, setTelemetry(false))
+	cfg, err = config.LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.False(t, cfg.Telemetry)
+	s, err = telemetry.Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, s.ID)
+}
+
+func TestTelemetryCommand(t *testing.T) {
+	daemonCmd, _, err := RootCmd.Find([]string{"daemon", "status"})
+	require.NoError(t, err)
+	assert.Equal(t, "daemon status", telemetryCommand(daemonCmd))
+
+	telemetryOn, _, err := RootCmd.Find([]string{"telemetry", "on"})
+	require.NoError(t, err)
+	assert.Empty(t, telemetryCommand(telemetryOn))
+	assert.Empty(t, telemetryCommand(RootCmd))
+	assert.Empty(t, telemetryCommand(nil))
+}
+
+func TestSendTelemetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(telemetry.EnvDoNotTrack, "")
+	t.Setenv(telemetry.EnvTelemetry, "")
+
+	events := make(chan telemetry.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e telemetry.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events <- e
+	}))
+	defer server.Close()
+	t.Setenv(telemetry.EnvEndpointURL, server.URL)
+
+	original := globalConfig
+	defer func() { globalConfig = original }()
+	recordDetectors([]detector.Result{
+		{Detector: "docker", Vars: []env.EnvVar{{Key: "DOCKER_WEB_PORT", Value: "3000"}}},
+		{Detector: "supabase", Err: detector.ErrNotRunning},
+		{Detector: "my-api", Vars: []env.EnvVar{{Key: "API_URL", Value: "http://localhost:8000"}}},
+	})
+	startCmd, _, err := RootCmd.Find([]string{"start"})
+	require.NoError(t, err)
+
+	// Disabled: nothing is sent
+	globalConfig = &config.GlobalConfig{}
+	sendTelemetry(startCmd, nil, time.Second)
+	assert.Empty(t, events)
+
+	globalConfig = &config.GlobalConfig{Telemetry: true}
+	sendTelemetry(startCmd, errors.New("failed"), 1500*time.Millisecond)
+	require.Len(t, events, 1)
+	e := <-events
+	assert.NotEmpty(t, e.ID)
+	assert.Equal(t, "start", e.Command)
+	assert.False(t, e.Success)
+	assert.Equal(t, int64(1500), e.Duration)
+	assert.Equal(t, map[string]string{"docker": "found", "supabase": "not_running"}, e.Detectors)
+
+	// DO_NOT_TRACK wins over the configuration
+	t.Setenv(telemetry.EnvDoNotTrack, "1")
+	sendTelemetry(startCmd, nil, time.Second)
+	assert.Empty(t, events)
+}
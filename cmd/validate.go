@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ValidateCmd represents the validate command
+type ValidateCmd struct {
+	Files  []string
+	Strict bool
+}
+
+// NewValidateCmd creates a new validate command
+func NewValidateCmd() *cobra.Command {
+	validateCmd := &ValidateCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "validate [FILE...]",
+		Short: "Check configuration files for mistakes",
+		Long: `Check .lanup.yaml (or .lanup.toml, .lanup.json) and the global configuration (~/.lanup/config.yaml) against their schema.
+
+Unknown keys (such as 'auto-detect' instead of 'auto_detect'), values of the wrong type
+and invalid settings are reported as errors with their line number. Suspicious values,
+such as URL variables that don't point to localhost and will never be rewritten for the
+LAN, are reported as warnings.
+
+Pass project configuration files to check them instead of .lanup.yaml. The command exits
+with a non-zero code when an error is found, or a warning with --strict.
+
+Examples:
+  lanup validate
+  lanup validate configs/.lanup.yaml
+  lanup validate --strict`,
+		// Validation must work when config.yaml is invalid, to report why
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			validateCmd.Files = args
+			return validateCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().BoolVar(&validateCmd.Strict, "strict", false, "treat warnings as errors")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewValidateCmd())
+}
+
+// Run executes the validate command
+func (c *ValidateCmd) Run() error {
+	errorCount, warningCount := 0, 0
+	report := func(path string, issues []config.Issue) {
+		e, w := displayIssues(path, issues)
+		errorCount += e
+		warningCount += w
+	}
+
+	if len(c.Files) == 0 {
+		// The project configuration is only checked when there is one
+		if projectPath, err := config.FindProjectConfig("."); err == nil {
+			issues, err := checkProjectFile(projectPath)
+			if err != nil {
+				return err
+			}
+			report(projectPath, issues)
+		} else {
+			utils.Info("No project configuration in the current directory or its parents")
+		}
+
+		globalPath, err := config.GlobalConfigPath()
+		if err != nil {
+			return lanuperrors.NewError(lanuperrors.ErrFileNotFound,
+				"Failed to determine global config path", err)
+		}
+		if _, err := os.Stat(globalPath); err == nil {
+			issues, err := checkFile(globalPath, config.CheckGlobalConfig)
+			if err != nil {
+				return err
+			}
+			report(globalPath, issues)
+		}
+	}
+
+	for _, path := range c.Files {
+		issues, err := checkProjectFile(path)
+		if err != nil {
+			return err
+		}
+		report(path, issues)
+	}
+
+	utils.Println()
+	if errorCount > 0 || (c.Strict && warningCount > 0) {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Found %d error(s) and %d warning(s)", errorCount, warningCount), nil)
+	}
+	if warningCount > 0 {
+		utils.Warning("Configuration is valid with %d warning(s)", warningCount)
+		return nil
+	}
+	utils.Success("Configuration is valid")
+	return nil
+}
+
+// checkProjectFile checks a project configuration file, in any supported format
+func checkProjectFile(path string) ([]config.Issue, error) {
+	return checkFile(path, func(data []byte) []config.Issue {
+		return config.CheckProjectConfigFile(path, data)
+	})
+}
+
+// checkFile reads a configuration file and checks it
+func checkFile(path string, check func([]byte) []config.Issue) ([]config.Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, lanuperrors.FromOSError(fmt.Sprintf("Failed to read %s", path), err)
+	}
+	return check(data), nil
+}
+
+// displayIssues prints the issues of a file as FILE:LINE:COLUMN and returns the number of errors and warnings
+func displayIssues(path string, issues []config.Issue) (int, int) {
+	if len(issues) == 0 {
+		utils.Success("%s", path)
+		return 0, 0
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		label := color.RedString("error")
+		if issue.Severity == config.SeverityWarning {
+			label = color.YellowString("warning")
+			warningCount++
+		} else {
+			errorCount++
+		}
+
+		location := path
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", path, issue.Line, issue.Column)
+		}
+		fmt.Printf("%s: %s: %s\n", location, label, issue.Message)
+	}
+
+	return errorCount, warningCount
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	valid := filepath.Join(tmpDir, "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("vars:\n  API_URL: http://localhost:8000\noutput: .env.local\n"), 0644))
+
+	typo := filepath.Join(tmpDir, "typo.yaml")
+	require.NoError(t, os.WriteFile(typo, []byte("output: .env.local\nauto-detect:\n  docker: true\n"), 0644))
+
+	remote := filepath.Join(tmpDir, "remote.yaml")
+	require.NoError(t, os.WriteFile(remote, []byte("vars:\n  API_URL: https://api.example.com\noutput: .env.local\n"), 0644))
+
+	tests := []struct {
+		name    string
+		cmd     *ValidateCmd
+		wantErr bool
+	}{
+		{"valid file", &ValidateCmd{Files: []string{valid}}, false},
+		{"unknown key", &ValidateCmd{Files: []string{valid, typo}}, true},
+		{"warnings are not errors", &ValidateCmd{Files: []string{remote}}, false},
+		{"warnings fail in strict mode", &ValidateCmd{Files: []string{remote}, Strict: true}, true},
+		{"missing file", &ValidateCmd{Files: []string{filepath.Join(tmpDir, "missing.yaml")}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.Run()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCmd_Run_InvalidGlobalConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	globalPath, err := config.GlobalConfigPath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(globalPath), 0755))
+	require.NoError(t, os.WriteFile(globalPath, []byte("log_path: /tmp/lanup.log\nlog_level: loud\ndefault_port: 8080\ncheck_interval: 5\n"), 0644))
+
+	err = (&ValidateCmd{}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 error(s)")
+}
+
+func TestDisplayIssues(t *testing.T) {
+	errors, warnings := displayIssues(".lanup.yaml", []config.Issue{
+		{Line: 3, Column: 1, Severity: config.SeverityError, Message: "unknown key"},
+		{Line: 5, Column: 12, Severity: config.SeverityWarning, Message: "remote URL"},
+		{Severity: config.SeverityError, Message: "output file path cannot be empty"},
+	})
+	assert.Equal(t, 2, errors)
+	assert.Equal(t, 1, warnings)
+}
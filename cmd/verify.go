@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/raucheacho/lanup/pkg/lanup"
+	"github.com/raucheacho/lanup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// defaultVerifyTimeout bounds each probe
+const defaultVerifyTimeout = 3 * time.Second
+
+// VerifyCmd represents the verify command
+type VerifyCmd struct {
+	Names   []string
+	Profile string
+	Timeout time.Duration
+}
+
+// verifyResult is the probe result of a generated variable
+type verifyResult struct {
+	Key string
+	net.ProbeResult
+}
+
+// NewVerifyCmd creates a new verify command
+func NewVerifyCmd() *cobra.Command {
+	verifyCmd := &VerifyCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "verify [NAME...]",
+		Short: "Check that your exposed services are reachable from the LAN",
+		Long: `Probe every URL generated from .lanup.yaml through your LAN address, the way
+another device on the network would reach it.
+
+HTTP(S) URLs get a GET request and any response counts as reachable; other URLs
+(postgresql://, redis://, ws://...) only need to accept a TCP connection. The command
+exits with a non-zero code when a service is unreachable, which usually means it only
+listens on 127.0.0.1.
+
+Pass one or more variable names to only verify those services.
+
+Examples:
+  lanup verify
+  lanup verify API_URL --timeout 10s
+  lanup verify --profile mobile`,
+		ValidArgsFunction: completeServiceNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verifyCmd.Names = args
+			return verifyCmd.Run()
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringVar(&verifyCmd.Profile, "profile", "", "configuration profile to use (default \"default\")")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.Flags().DurationVar(&verifyCmd.Timeout, "timeout", defaultVerifyTimeout, "timeout of each probe")
+
+	return cmd
+}
+
+func init() {
+	RootCmd.AddCommand(NewVerifyCmd())
+}
+
+// Run executes the verify command
+func (c *VerifyCmd) Run() error {
+	if c.Timeout <= 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			fmt.Sprintf("Invalid timeout: %s (must be positive)", c.Timeout), nil)
+	}
+
+	// Load project configuration
+	projectConfig, err := config.LoadProjectConfigProfile("", c.Profile)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+
+	// Detect local IP
+	netInfo, err := projectNetwork(projectConfig)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	projectConfig = projectConfig.WithFacts(machineFacts(netInfo))
+
+	// Generate the URLs with the IP rather than the project hostname, so that
+	// the probes don't depend on the hosts file
+	found, err := collectVariables(context.Background(), projectConfig, nil)
+	if err != nil {
+		return err
+	}
+	vars := found.Vars
+	urls := lanURLVars(filterURLVars(lanup.TransformConfig(projectConfig, vars, netInfo.IP), c.Names), netInfo.IP)
+	if len(urls) == 0 {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"No service URLs to verify", nil)
+	}
+
+	utils.Info("Probing %d service(s) from %s...", len(urls), netInfo.IP)
+	utils.Println()
+
+	results := probeVars(context.Background(), urls, netInfo.IP, c.Timeout)
+	unreachable := displayVerifyResults(results)
+
+	utils.Println()
+	if unreachable > 0 {
+		utils.Info("Unreachable services usually listen on 127.0.0.1 only.")
+		utils.Println("   Bind them to 0.0.0.0, check 'lanup ports' or expose them with 'lanup serve'")
+		return lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			fmt.Sprintf("%d of %d service(s) unreachable from the LAN", unreachable, len(results)), nil)
+	}
+
+	utils.Success("All services are reachable from the LAN")
+	return nil
+}
+
+// lanURLVars keeps the URL variables pointing to this machine's LAN address
+func lanURLVars(vars []env.EnvVar, ip string) []env.EnvVar {
+	var urls []env.EnvVar
+	for _, v := range vars {
+		u, err := neturl.Parse(v.Value)
+		if err != nil || u.Hostname() != ip {
+			continue
+		}
+		urls = append(urls, v)
+	}
+	return urls
+}
+
+// probeVars probes every URL concurrently and returns the results in the same order
+func probeVars(ctx context.Context, vars []env.EnvVar, sourceIP string, timeout time.Duration) []verifyResult {
+	results := make([]verifyResult, len(vars))
+
+	var wg sync.WaitGroup
+	for i, v := range vars {
+		wg.Add(1)
+		go func(i int, v env.EnvVar) {
+			defer wg.Done()
+			results[i] = verifyResult{Key: v.Key, ProbeResult: net.Probe(ctx, v.Value, sourceIP, timeout)}
+		}(i, v)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// displayVerifyResults prints the results as a table and returns the number of unreachable services
+func displayVerifyResults(results []verifyResult) int {
+	table := utils.NewTable("NAME", "URL", "STATUS", "LATENCY", "RESULT")
+
+	unreachable := 0
+	var failures []verifyResult
+	for _, r := range results {
+		status := "-"
+		latency := "-"
+
+		var outcome string
+		switch {
+		case errors.Is(r.Err, net.ErrUnsupportedScheme):
+			outcome = color.HiBlackString("skipped")
+		case r.Err != nil:
+			outcome = color.RedString("unreachable")
+			unreachable++
+			failures = append(failures, r)
+		default:
+			if r.StatusCode != 0 {
+				status = fmt.Sprint(r.StatusCode)
+			} else {
+				status = "open"
+			}
+			latency = r.Latency.Round(time.Millisecond).String()
+			outcome = color.GreenString("reachable")
+		}
+
+		table.AddRow(r.Key, r.URL, status, latency, outcome)
+	}
+	table.Print()
+
+	if len(failures) > 0 {
+		fmt.Println()
+		for _, r := range failures {
+			utils.Error("%s: %v", r.Key, r.Err)
+		}
+	}
+
+	return unreachable
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanURLVars(t *testing.T) {
+	vars := []env.EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000"},
+		{Key: "STRIPE_URL", Value: "https://api.stripe.com"},
+		{Key: "DB_URL", Value: "postgresql://postgres@192.168.1.100:54322/postgres"},
+		{Key: "OTHER_HOST", Value: "http://192.168.1.101:8000"},
+	}
+
+	urls := lanURLVars(vars, "192.168.1.100")
+	require.Len(t, urls, 2)
+	assert.Equal(t, "API_URL", urls[0].Key)
+	assert.Equal(t, "DB_URL", urls[1].Key)
+}
+
+func TestProbeVars(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Grab a free port, then close it so that nothing listens there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	vars := []env.EnvVar{
+		{Key: "API_URL", Value: server.URL},
+		{Key: "DOWN_URL", Value: "http://" + closedAddr},
+		{Key: "CUSTOM_URL", Value: "custom://127.0.0.1"},
+	}
+
+	results := probeVars(context.Background(), vars, "127.0.0.1", time.Second)
+	require.Len(t, results, 3)
+	assert.Equal(t, "API_URL", results[0].Key)
+	assert.True(t, results[0].Reachable())
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	assert.Equal(t, "DOWN_URL", results[1].Key)
+	assert.False(t, results[1].Reachable())
+
+	// Unsupported schemes are skipped rather than reported as unreachable
+	assert.Equal(t, 1, displayVerifyResults(results))
+}
+
+func TestVerifyCmd_Run_InvalidTimeout(t *testing.T) {
+	cmd := &VerifyCmd{Timeout: 0}
+	err := cmd.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid timeout")
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/service"
+)
+
+// watchdogStall is the minimum age of the last network check after which the watcher is hung
+const watchdogStall = 30 * time.Second
+
+// watchdog tells the service manager that the daemon is alive while the watcher checks the network,
+// so that a hung daemon is restarted: systemd stops receiving WATCHDOG=1, and without systemd the
+// daemon exits after its timeout for launchd to start it again
+type watchdog struct {
+	watcher *net.IPWatcher
+	stall   time.Duration // age of the last network check after which the watcher is hung
+	timeout time.Duration // exit when no ping succeeded for this long, 0 to never exit
+	notify  time.Duration // WatchdogSec of the systemd unit, 0 when not enabled
+	started time.Time
+	alive   atomic.Int64 // time of the last ping, in Unix nanoseconds
+	hung    bool         // the hang was logged
+	log     *logger.Logger
+}
+
+// newWatchdog returns the watchdog of a daemon, nil when neither systemd nor --watchdog asks for one
+func newWatchdog(watcher *net.IPWatcher, interval, timeout time.Duration, log *logger.Logger) *watchdog {
+	notify := service.WatchdogInterval()
+	if notify == 0 && timeout <= 0 {
+		return nil
+	}
+
+	w := &watchdog{
+		watcher: watcher,
+		stall:   max(3*interval, watchdogStall),
+		timeout: timeout,
+		notify:  notify,
+		started: time.Now(),
+		log:     log,
+	}
+	w.alive.Store(w.started.UnixNano())
+	return w
+}
+
+// interval returns how often to ping, within half of the systemd timeout and a quarter of ours
+func (w *watchdog) interval() time.Duration {
+	var interval time.Duration
+	for _, d := range []time.Duration{w.notify / 2, w.timeout / 4} {
+		if d > 0 && (interval == 0 || d < interval) {
+			interval = d
+		}
+	}
+	return max(interval, time.Second)
+}
+
+// ping tells the service manager that the daemon is alive, unless the watcher stopped checking
+// It is called from the loop of watch mode, which therefore stops pinging when it hangs as well.
+func (w *watchdog) ping() {
+	last := w.watcher.LastCheck()
+	if last.IsZero() {
+		last = w.started
+	}
+	if age := time.Since(last); age > w.stall {
+		if !w.hung && w.log != nil {
+			w.log.Error("Watcher is hung",
+				logger.Field{Key: "last_check", Value: last.Format(time.RFC3339)},
+				logger.Field{Key: "age", Value: age.Round(time.Second).String()})
+		}
+		w.hung = true
+		return
+	}
+
+	w.hung = false
+	w.alive.Store(time.Now().UnixNano())
+	if w.notify > 0 {
+		if err := service.Notify(service.NotifyWatchdog); err != nil && w.log != nil {
+			w.log.Warn("Failed to notify systemd", logger.Field{Key: "error", Value: err.Error()})
+		}
+	}
+}
+
+// guard exits the process once no ping succeeded for the timeout, until ctx is done
+// It runs apart from the loop of watch mode, so that it also notices when the loop hangs.
+func (w *watchdog) guard(ctx context.Context) {
+	if w.timeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			alive := time.Unix(0, w.alive.Load())
+			if time.Since(alive) < w.timeout {
+				continue
+			}
+			if w.log != nil {
+				w.log.Error("Daemon hung, exiting so that it is restarted",
+					logger.Field{Key: "last_ping", Value: alive.Format(time.RFC3339)})
+			}
+			fmt.Fprintf(os.Stderr, "lanup: no network check since %s, exiting so that the service restarts\n",
+				alive.Format(time.RFC3339))
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// State is the current exposure state of a project, as returned by GET /v1/state
+type State struct {
+	Project   string            `json:"project"`
+	Profile   string            `json:"profile,omitempty"`
+	IP        string            `json:"ip"`
+	Interface string            `json:"interface,omitempty"`
+	Hostname  string            `json:"hostname,omitempty"`
+	Output    string            `json:"output"`
+	Vars      map[string]string `json:"vars"`
+	URLs      map[string]string `json:"urls"` // the vars whose value is an http(s) or ws(s) URL, without credentials
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Backend provides the state served by the API
+type Backend interface {
+	// State returns the current state
+	State() State
+	// Refresh re-detects the IP and services, regenerates the env file and returns the new state
+	Refresh() (State, error)
+}
+
+// Pairing is a one-time code pairing a device, as returned by POST /v1/pairing
+type Pairing struct {
+	URL       string    `json:"url"` // for the device to POST to, with the code
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Pairer is implemented by backends that push the URLs to paired devices on the LAN
+type Pairer interface {
+	// Pair starts the device server when needed and returns a new pairing code
+	Pair() (Pairing, error)
+	// Disconnect closes the event streams of the devices that are no longer paired
+	Disconnect() error
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns the HTTP handler of the API
+//
+//	GET  /v1/state    current state
+//	POST /v1/refresh  regenerate the env file now, returns the new state
+//	POST /v1/pairing  create a code pairing a device, when the backend is a Pairer
+//	POST /v1/pairing/disconnect  close the event streams of the unpaired devices, when the backend is a Pairer
+func NewHandler(backend Backend) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		writeJSON(w, http.StatusOK, backend.State())
+	})
+
+	mux.HandleFunc("/v1/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		state, err := backend.Refresh()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	})
+
+	if pairer, ok := backend.(Pairer); ok {
+		mux.HandleFunc("/v1/pairing", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				methodNotAllowed(w, http.MethodPost)
+				return
+			}
+			pairing, err := pairer.Pair()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusCreated, pairing)
+		})
+		mux.HandleFunc("/v1/pairing/disconnect", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				methodNotAllowed(w, http.MethodPost)
+				return
+			}
+			if err := pairer.Disconnect(); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "not found"})
+	})
+
+	return mux
+}
+
+// methodNotAllowed writes a 405 response advertising the allowed method
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+}
+
+// writeJSON writes value as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
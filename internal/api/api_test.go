@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend returns a fixed state and counts refreshes
+type fakeBackend struct {
+	state     State
+	refreshes int
+	err       error
+}
+
+func (b *fakeBackend) State() State {
+	return b.state
+}
+
+func (b *fakeBackend) Refresh() (State, error) {
+	b.refreshes++
+	if b.err != nil {
+		return State{}, b.err
+	}
+	b.state.IP = "192.168.1.20"
+	return b.state, nil
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{state: State{
+		Project: "/projects/app",
+		IP:      "192.168.1.10",
+		Output:  ".env.local",
+		Vars:    map[string]string{"API_URL": "http://192.168.1.10:8000", "APP_NAME": "demo"},
+		URLs:    map[string]string{"API_URL": "http://192.168.1.10:8000"},
+	}}
+}
+
+func TestHandler_State(t *testing.T) {
+	handler := NewHandler(newFakeBackend())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/state", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var state State
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "192.168.1.10", state.IP)
+	assert.Equal(t, "http://192.168.1.10:8000", state.URLs["API_URL"])
+}
+
+func TestHandler_Refresh(t *testing.T) {
+	backend := newFakeBackend()
+	handler := NewHandler(backend)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/refresh", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, backend.refreshes)
+
+	var state State
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "192.168.1.20", state.IP)
+
+	// Failures are reported as JSON errors
+	backend.err = errors.New("no network")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/refresh", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error":"no network"}`, rec.Body.String())
+}
+
+func TestHandler_MethodsAndNotFound(t *testing.T) {
+	handler := NewHandler(newFakeBackend())
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodPost, "/v1/state", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/v1/refresh", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/v2/state", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+// fakePairer is a backend pairing devices
+type fakePairer struct {
+	*fakeBackend
+}
+
+func (b *fakePairer) Pair() (Pairing, error) {
+	return Pairing{URL: "http://192.168.1.10:47800/v1/pair?code=abcd", Code: "abcd"}, nil
+}
+
+func (b *fakePairer) Disconnect() error {
+	return nil
+}
+
+func TestHandler_Pairing(t *testing.T) {
+	handler := NewHandler(&fakePairer{newFakeBackend()})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/pairing", nil))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var pairing Pairing
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pairing))
+	assert.Equal(t, "abcd", pairing.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/pairing/disconnect", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// Backends that don't pair devices don't serve it
+	rec = httptest.NewRecorder()
+	NewHandler(newFakeBackend()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/pairing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultTokenPath returns the file holding the API token (~/.lanup/api-token)
+func DefaultTokenPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "api-token"), nil
+}
+
+// LoadOrCreateToken returns the API token stored in path, generating it on first use
+// The file is only readable by the user: the token is what prevents other machines of the LAN,
+// and other users of the machine, from driving the daemon.
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			if runtime.GOOS != "windows" {
+				if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+					if err := os.Chmod(path, 0600); err != nil {
+						return "", fmt.Errorf("failed to restrict the permissions of %s: %w", path, err)
+					}
+				}
+			}
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read API token: %w", err)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create API token directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write API token: %w", err)
+	}
+	return token, nil
+}
+
+// RequireToken rejects the requests of h that change something (any method but GET and HEAD)
+// without the header "Authorization: Bearer <token>"
+func RequireToken(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !validToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lanup"`)
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token, see ~/.lanup/api-token"})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// validToken reports whether the request carries token
+func validToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanup", "api-token")
+
+	token, err := LoadOrCreateToken(path)
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+
+	again, err := LoadOrCreateToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		// Permissions widened by hand are restricted again
+		require.NoError(t, os.Chmod(path, 0644))
+		_, err = LoadOrCreateToken(path)
+		require.NoError(t, err)
+		info, err = os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	handler := RequireToken(NewHandler(newFakeBackend()), "secret")
+
+	tests := []struct {
+		method string
+		path   string
+		auth   string
+		status int
+	}{
+		{http.MethodGet, "/v1/state", "", http.StatusOK},
+		{http.MethodPost, "/v1/refresh", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/refresh", "Bearer wrong", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/refresh", "secret", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/refresh", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.auth, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Package backup keeps timestamped copies of the env files lanup rewrites, in ~/.lanup/backups,
+// so that the file can be restored as it was before a bad run, not only before the last one
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/remote"
+)
+
+// DefaultMax is the number of backups kept per env file
+const DefaultMax = 20
+
+// nameLayout is the layout of the backup file names, sortable and in UTC
+const nameLayout = "20060102T150405.000000000Z"
+
+// unsafeChars are replaced in the env file names used for the backup directories
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Backup is a copy of an env file taken before lanup rewrote it
+type Backup struct {
+	Path string    // backup file
+	Time time.Time // when the env file was rewritten, the copy holds its content up to then
+}
+
+// Read returns the content of the backup
+func (b Backup) Read() ([]byte, error) {
+	return os.ReadFile(b.Path)
+}
+
+// Store keeps the backups of env files, one directory per file
+type Store struct {
+	Dir string
+	Max int // backups kept per file, DefaultMax when 0
+}
+
+// DefaultDir returns the directory of the backups (~/.lanup/backups)
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "backups"), nil
+}
+
+// NewStore creates a store in dir keeping DefaultMax backups per file
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir, Max: DefaultMax}
+}
+
+// Save stores data, the content of file before it is rewritten at t, and removes the oldest backups
+// Nothing is stored when data is the content of the latest backup.
+func (s *Store) Save(file string, data []byte, t time.Time) error {
+	dir, err := s.fileDir(file)
+	if err != nil {
+		return err
+	}
+
+	backups, err := s.List(file)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if latest, err := backups[len(backups)-1].Read(); err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	// Clocks with a coarse resolution may give the time of the latest backup
+	if len(backups) > 0 && !t.After(backups[len(backups)-1].Time) {
+		t = backups[len(backups)-1].Time.Add(time.Nanosecond)
+	}
+	path := filepath.Join(dir, t.UTC().Format(nameLayout))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	backups = append(backups, Backup{Path: path, Time: t})
+	max := s.Max
+	if max <= 0 {
+		max = DefaultMax
+	}
+	for len(backups) > max {
+		if err := os.Remove(backups[0].Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// List returns the backups of file, oldest first
+func (s *Store) List(file string) ([]Backup, error) {
+	dir, err := s.fileDir(file)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		t, err := time.Parse(nameLayout, entry.Name())
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, entry.Name()), Time: t.Local()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// fileDir returns the directory of the backups of file, named after the file and a hash of its path
+func (s *Store) fileDir(file string) (string, error) {
+	if !remote.IsRemote(file) {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return "", err
+		}
+		file = abs
+	}
+	sum := sha256.Sum256([]byte(file))
+	name := strings.Trim(unsafeChars.ReplaceAllString(filepath.Base(file), "_"), "_")
+	return filepath.Join(s.Dir, name+"-"+hex.EncodeToString(sum[:])[:12]), nil
+}
+
+// At returns the backup holding the content the file had at t: the first one taken after t,
+// nil when the file hasn't been rewritten since
+func At(backups []Backup, t time.Time) *Backup {
+	for i := range backups {
+		if backups[i].Time.After(t) {
+			return &backups[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SaveAndList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "backups"))
+	file := filepath.Join(t.TempDir(), ".env.local")
+
+	backups, err := store.List(file)
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	start := time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local)
+	require.NoError(t, store.Save(file, []byte("API_URL=1\n"), start))
+	require.NoError(t, store.Save(file, []byte("API_URL=2\n"), start.Add(time.Hour)))
+	// The same content as the latest backup is not stored again
+	require.NoError(t, store.Save(file, []byte("API_URL=2\n"), start.Add(2*time.Hour)))
+
+	backups, err = store.List(file)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.True(t, start.Equal(backups[0].Time))
+	assert.True(t, start.Add(time.Hour).Equal(backups[1].Time))
+	data, err := backups[1].Read()
+	require.NoError(t, err)
+	assert.Equal(t, "API_URL=2\n", string(data))
+
+	// Other files have their own backups, remote ones included
+	other, err := store.List(filepath.Join(filepath.Dir(file), "web", ".env.local"))
+	require.NoError(t, err)
+	assert.Empty(t, other)
+	require.NoError(t, store.Save("ssh://pi@raspberrypi.local/srv/api/.env", []byte("A=1\n"), start))
+	remote, err := store.List("ssh://pi@raspberrypi.local/srv/api/.env")
+	require.NoError(t, err)
+	assert.Len(t, remote, 1)
+}
+
+func TestStore_SaveRemovesOldBackups(t *testing.T) {
+	store := &Store{Dir: t.TempDir(), Max: 3}
+	file := filepath.Join(t.TempDir(), ".env")
+
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Save(file, []byte{byte('a' + i)}, start.Add(time.Duration(i)*time.Minute)))
+	}
+
+	backups, err := store.List(file)
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	data, err := backups[0].Read()
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(backups[0].Path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestAt(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	backups := []Backup{
+		{Path: "a", Time: start},
+		{Path: "b", Time: start.Add(time.Hour)},
+		{Path: "c", Time: start.Add(2 * time.Hour)},
+	}
+
+	// The file had at 9:30 the content it was rewritten over at 10:00
+	assert.Equal(t, "b", At(backups, start.Add(30*time.Minute)).Path)
+	assert.Equal(t, "a", At(backups, start.Add(-time.Minute)).Path)
+	assert.Equal(t, "c", At(backups, start.Add(time.Hour)).Path)
+	assert.Nil(t, At(backups, start.Add(3*time.Hour)))
+}
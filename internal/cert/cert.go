@@ -0,0 +1,355 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	caCertFile = "rootCA.pem"
+	caKeyFile  = "rootCA-key.pem"
+
+	// leafValidity stays below the 825 days accepted by Apple platforms
+	leafValidity = 825 * 24 * time.Hour
+	caValidity   = 10 * 365 * 24 * time.Hour
+	// renewBefore triggers re-issuing certificates that are about to expire
+	renewBefore = 7 * 24 * time.Hour
+)
+
+// Bundle describes a certificate and key usable by a TLS server
+type Bundle struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string // root CA to install on other devices
+	Hosts    []string
+	Mkcert   bool // true when the certificate was issued by mkcert
+}
+
+// DefaultDir returns the directory holding lanup certificates (~/.lanup/certs)
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "certs"), nil
+}
+
+// DefaultHosts returns the names a LAN certificate should cover: the LAN IP,
+// the machine's .local mDNS hostname, and localhost
+func DefaultHosts(ip string) []string {
+	hosts := []string{}
+	if ip != "" {
+		hosts = append(hosts, ip)
+	}
+	if name := LocalHostname(); name != "" {
+		hosts = append(hosts, name)
+	}
+	return append(hosts, "localhost", "127.0.0.1")
+}
+
+// LocalHostname returns the machine's mDNS hostname (e.g. macbook.local)
+func LocalHostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return ""
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name + ".local"
+}
+
+// Ensure returns a certificate covering hosts, stored in dir
+// An existing certificate is reused while it covers every host and is not about to expire.
+// Every project shares the certificate, so a new one also covers the hosts of the previous one
+// and the certificate paths written by other projects stay valid for their hosts.
+// mkcert is used when installed, since its CA is already trusted on this machine; otherwise
+// lanup issues the certificate from its own local CA.
+func Ensure(dir string, hosts []string) (*Bundle, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts to include in the certificate")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	bundle := Locate(dir, hosts)
+	if covers(bundle.CertFile, bundle.CAFile, hosts) {
+		return bundle, nil
+	}
+	bundle.Hosts = mergeHosts(hosts, certHosts(bundle.CertFile))
+
+	if bundle.Mkcert {
+		if err := issueWithMkcert(bundle); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+
+	ca, caKey, err := EnsureCA(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := Issue(ca, caKey, bundle.Hosts, bundle.CertFile, bundle.KeyFile); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// Locate returns the files of the certificate Ensure issues in dir, without issuing it
+func Locate(dir string, hosts []string) *Bundle {
+	bundle := &Bundle{
+		CertFile: filepath.Join(dir, "lanup.pem"),
+		KeyFile:  filepath.Join(dir, "lanup-key.pem"),
+		Hosts:    hosts,
+	}
+
+	if _, err := exec.LookPath("mkcert"); err == nil {
+		bundle.Mkcert = true
+		bundle.CAFile = mkcertCAFile()
+	} else {
+		bundle.CAFile = filepath.Join(dir, caCertFile)
+	}
+	return bundle
+}
+
+// EnsureCA loads the lanup root CA from dir, creating it on first use
+func EnsureCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath := filepath.Join(dir, caCertFile)
+	keyPath := filepath.Join(dir, caKeyFile)
+
+	if ca, key, err := loadPair(certPath, keyPath); err == nil {
+		return ca, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject: pkix.Name{
+			Organization: []string{"lanup development CA"},
+			CommonName:   fmt.Sprintf("lanup CA (%s)", hostname),
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	if err := writePair(certPath, keyPath, der, key); err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	return ca, key, nil
+}
+
+// Issue creates a server certificate for hosts signed by the CA
+func Issue(ca *x509.Certificate, caKey *ecdsa.PrivateKey, hosts []string, certPath, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject: pkix.Name{
+			Organization: []string{"lanup development certificate"},
+			CommonName:   hosts[0],
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(leafValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	return writePair(certPath, keyPath, der, key)
+}
+
+// readCert reads a PEM certificate
+func readCert(certPath string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// certHosts returns the names and addresses the certificate at path is valid for, nil when it can't be read
+func certHosts(certPath string) []string {
+	cert, err := readCert(certPath)
+	if err != nil {
+		return nil
+	}
+	hosts := append([]string(nil), cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		hosts = append(hosts, ip.String())
+	}
+	return hosts
+}
+
+// mergeHosts returns hosts followed by the other hosts not already in it
+func mergeHosts(hosts, other []string) []string {
+	seen := make(map[string]bool)
+	merged := make([]string, 0, len(hosts)+len(other))
+	for _, host := range append(append([]string(nil), hosts...), other...) {
+		if !seen[host] {
+			seen[host] = true
+			merged = append(merged, host)
+		}
+	}
+	return merged
+}
+
+// covers reports whether the certificate at path is valid for every host, not about to expire and
+// signed by the CA at caPath, which changes when mkcert is installed or its CA is regenerated
+func covers(certPath, caPath string, hosts []string) bool {
+	cert, err := readCert(certPath)
+	if err != nil {
+		return false
+	}
+	ca, err := readCert(caPath)
+	if err != nil || cert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return false
+	}
+
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// issueWithMkcert issues the certificate with mkcert
+func issueWithMkcert(bundle *Bundle) error {
+	args := append([]string{"-cert-file", bundle.CertFile, "-key-file", bundle.KeyFile}, bundle.Hosts...)
+	cmd := exec.Command("mkcert", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mkcert failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// mkcertCAFile returns the path of the mkcert root CA
+func mkcertCAFile() string {
+	out, err := exec.Command("mkcert", "-CAROOT").Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), caCertFile)
+}
+
+// loadPair reads a PEM certificate and ECDSA key
+func loadPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("invalid PEM data")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// writePair writes a certificate (0644) and its private key (0600) in PEM format
+func writePair(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	return nil
+}
+
+// randomSerial returns a random 128-bit certificate serial number
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
@@ -0,0 +1,145 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureCA_CreatesAndReuses(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, key, err := EnsureCA(dir)
+	require.NoError(t, err)
+	assert.True(t, ca.IsCA)
+	assert.NotNil(t, key)
+
+	info, err := os.Stat(filepath.Join(dir, caKeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	again, _, err := EnsureCA(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ca.SerialNumber, again.SerialNumber, "existing CA should be reused")
+}
+
+func TestIssue_CoversHostsAndChainsToCA(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, err := EnsureCA(dir)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "leaf.pem")
+	keyPath := filepath.Join(dir, "leaf-key.pem")
+	hosts := []string{"192.168.1.100", "macbook.local", "localhost"}
+	require.NoError(t, Issue(ca, caKey, hosts, certPath, keyPath))
+
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	for _, host := range hosts {
+		_, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		assert.NoError(t, err, host)
+	}
+
+	caPath := filepath.Join(dir, caCertFile)
+	assert.True(t, covers(certPath, caPath, hosts))
+	assert.False(t, covers(certPath, caPath, []string{"192.168.1.101"}))
+	assert.False(t, covers(filepath.Join(dir, "missing.pem"), caPath, hosts))
+
+	// A certificate of another CA is not trusted by the devices that installed this one
+	other := t.TempDir()
+	_, _, err = EnsureCA(other)
+	require.NoError(t, err)
+	assert.False(t, covers(certPath, filepath.Join(other, caCertFile), hosts))
+}
+
+func TestEnsure_ReissuesWhenHostsChange(t *testing.T) {
+	t.Setenv("PATH", "") // make sure mkcert is not used
+	dir := t.TempDir()
+
+	first, err := Ensure(dir, []string{"192.168.1.100", "localhost"})
+	require.NoError(t, err)
+	assert.False(t, first.Mkcert)
+	assert.Equal(t, filepath.Join(dir, caCertFile), first.CAFile)
+	firstPEM, err := os.ReadFile(first.CertFile)
+	require.NoError(t, err)
+
+	// Same hosts: the certificate is reused
+	same, err := Ensure(dir, []string{"192.168.1.100"})
+	require.NoError(t, err)
+	samePEM, _ := os.ReadFile(same.CertFile)
+	assert.Equal(t, firstPEM, samePEM)
+
+	// New IP: a new certificate is issued
+	_, err = Ensure(dir, []string{"192.168.1.200"})
+	require.NoError(t, err)
+	assert.True(t, covers(first.CertFile, first.CAFile, []string{"192.168.1.200"}))
+
+	_, err = Ensure(dir, nil)
+	assert.Error(t, err)
+}
+
+func TestEnsure_ReissuesForNewCA(t *testing.T) {
+	t.Setenv("PATH", "") // make sure mkcert is not used
+	dir := t.TempDir()
+	hosts := []string{"192.168.1.100", "localhost"}
+
+	bundle, err := Ensure(dir, hosts)
+	require.NoError(t, err)
+
+	// The CA was regenerated, e.g. after deleting ~/.lanup/certs/rootCA.pem
+	require.NoError(t, os.Remove(filepath.Join(dir, caCertFile)))
+	require.NoError(t, os.Remove(filepath.Join(dir, caKeyFile)))
+	ca, _, err := EnsureCA(dir)
+	require.NoError(t, err)
+	assert.False(t, covers(bundle.CertFile, bundle.CAFile, hosts))
+
+	_, err = Ensure(dir, hosts)
+	require.NoError(t, err)
+	leaf, err := readCert(bundle.CertFile)
+	require.NoError(t, err)
+	assert.NoError(t, leaf.CheckSignatureFrom(ca))
+}
+
+func TestEnsure_SharedByProjects(t *testing.T) {
+	t.Setenv("PATH", "") // make sure mkcert is not used
+	dir := t.TempDir()
+
+	shop := []string{"192.168.1.100", "localhost", "shop.lan"}
+	blog := []string{"192.168.1.100", "localhost", "blog.lan"}
+	first, err := Ensure(dir, shop)
+	require.NoError(t, err)
+	second, err := Ensure(dir, blog)
+	require.NoError(t, err)
+
+	// The second project gets its hosts without breaking the certificate of the first one
+	assert.Equal(t, first.CertFile, second.CertFile)
+	assert.True(t, covers(first.CertFile, first.CAFile, shop))
+	assert.True(t, covers(second.CertFile, second.CAFile, blog))
+	assert.Equal(t, []string{"192.168.1.100", "localhost", "blog.lan", "shop.lan"}, second.Hosts)
+}
+
+func TestLocalHostname(t *testing.T) {
+	name := LocalHostname()
+	if name == "" {
+		t.Skip("hostname not available")
+	}
+	assert.True(t, strings.HasSuffix(name, ".local"))
+	assert.Equal(t, 1, strings.Count(name, "."))
+}
+
+func TestDefaultHosts(t *testing.T) {
+	hosts := DefaultHosts("192.168.1.100")
+	assert.Equal(t, "192.168.1.100", hosts[0])
+	assert.Contains(t, hosts, "localhost")
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Facts describes the machine the conditions of the vars are evaluated on
+type Facts struct {
+	OS            string // runtime.GOOS: linux, darwin, windows...
+	Arch          string // runtime.GOARCH: amd64, arm64...
+	Hostname      string
+	InterfaceName string // network interface whose IP is used, e.g. en0
+	InterfaceType string // wifi, ethernet or virtual
+}
+
+// CurrentFacts returns the facts of this machine, with the given network interface
+func CurrentFacts(interfaceName, interfaceType string) Facts {
+	hostname, _ := os.Hostname()
+	return Facts{
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Hostname:      hostname,
+		InterfaceName: interfaceName,
+		InterfaceType: interfaceType,
+	}
+}
+
+// conditionFacts maps the names usable in conditions to their fact
+var conditionFacts = map[string]func(Facts) string{
+	"os":             func(f Facts) string { return f.OS },
+	"arch":           func(f Facts) string { return f.Arch },
+	"hostname":       func(f Facts) string { return f.Hostname },
+	"interface.name": func(f Facts) string { return f.InterfaceName },
+	"interface.type": func(f Facts) string { return f.InterfaceType },
+}
+
+// CheckCondition reports whether a condition holds for facts
+// A condition compares facts with == or !=, e.g. "os == windows", and combines the comparisons
+// with && and ||, && binding tighter. Values are compared case-insensitively and may be quoted.
+func CheckCondition(condition string, facts Facts) (bool, error) {
+	// Every comparison is evaluated, so that any invalid one is reported
+	result := false
+	for _, clause := range strings.Split(condition, "||") {
+		holds := true
+		for _, comparison := range strings.Split(clause, "&&") {
+			ok, err := checkComparison(comparison, facts)
+			if err != nil {
+				return false, fmt.Errorf("invalid condition %q: %w", condition, err)
+			}
+			holds = holds && ok
+		}
+		result = result || holds
+	}
+	return result, nil
+}
+
+// checkComparison evaluates a single "name == value" or "name != value"
+func checkComparison(comparison string, facts Facts) (bool, error) {
+	op := "=="
+	name, value, ok := strings.Cut(comparison, "==")
+	if !ok {
+		op = "!="
+		if name, value, ok = strings.Cut(comparison, "!="); !ok {
+			return false, fmt.Errorf("%q is not a comparison with == or !=", strings.TrimSpace(comparison))
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	fact, ok := conditionFacts[name]
+	if !ok {
+		return false, fmt.Errorf("unknown fact %q (use os, arch, hostname, interface.name or interface.type)", name)
+	}
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	if value == "" {
+		return false, fmt.Errorf("missing value after %s %s", name, op)
+	}
+
+	equal := strings.EqualFold(fact(facts), value)
+	return equal == (op == "=="), nil
+}
+
+// WithFacts returns a copy of the configuration with its conditional vars resolved for facts:
+// each one gets its first value whose condition holds, or is left out
+// Invalid conditions don't hold, Validate reports them.
+func (c *ProjectConfig) WithFacts(facts Facts) *ProjectConfig {
+	result := c.clone()
+	for key, opts := range c.VarOptions {
+		if opts.When == "" && len(opts.Else) == 0 {
+			continue
+		}
+
+		delete(result.Vars, key)
+		delete(result.VarOptions, key)
+		cases := append([]VarCase{{URL: c.Vars[key], Options: opts}}, opts.Else...)
+		for _, vc := range cases {
+			if vc.Options.When != "" {
+				if ok, err := CheckCondition(vc.Options.When, facts); err != nil || !ok {
+					continue
+				}
+			}
+			result.Vars[key] = vc.URL
+			vc.Options.When, vc.Options.Else = "", nil
+			result.VarOptions[key] = vc.Options
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCondition(t *testing.T) {
+	facts := Facts{OS: "windows", Arch: "amd64", Hostname: "Bob-Laptop", InterfaceName: "Wi-Fi", InterfaceType: "wifi"}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"os == windows", true},
+		{"os != windows", false},
+		{"os==linux", false},
+		{`hostname == "bob-laptop"`, true},
+		{"interface.type == wifi && arch == arm64", false},
+		{"os == linux || interface.type == wifi", true},
+		{"os == linux || os == darwin && interface.type == wifi", false},
+		{"os == windows && interface.name == 'Wi-Fi'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			got, err := CheckCondition(tt.condition, facts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckCondition_Invalid(t *testing.T) {
+	for _, condition := range []string{"windows", "platform == windows", "os ==", "os == windows || shell == bash"} {
+		_, err := CheckCondition(condition, Facts{OS: "windows"})
+		assert.Error(t, err, condition)
+	}
+}
+
+func TestWithFacts(t *testing.T) {
+	cfg := &ProjectConfig{
+		Vars: map[string]string{
+			"API_URL":     "http://localhost:8000",
+			"DOCKER_HOST": "npipe:////./pipe/docker_engine",
+			"WIFI_URL":    "http://localhost:3000",
+		},
+		VarOptions: map[string]VarOptions{
+			"DOCKER_HOST": {
+				When: "os == windows",
+				Else: []VarCase{
+					{URL: "unix:///Users/me/.colima/docker.sock", Options: VarOptions{When: "os == darwin"}},
+					{URL: "unix:///var/run/docker.sock"},
+				},
+			},
+			"WIFI_URL": {When: "interface.type == wifi", Port: 8443},
+		},
+		Output: ".env",
+	}
+	require.NoError(t, cfg.Validate())
+
+	linux := cfg.WithFacts(Facts{OS: "linux", InterfaceType: "ethernet"})
+	assert.Equal(t, map[string]string{
+		"API_URL":     "http://localhost:8000",
+		"DOCKER_HOST": "unix:///var/run/docker.sock",
+	}, linux.Vars)
+
+	mac := cfg.WithFacts(Facts{OS: "darwin", InterfaceType: "wifi"})
+	assert.Equal(t, "unix:///Users/me/.colima/docker.sock", mac.Vars["DOCKER_HOST"])
+	assert.Equal(t, "http://localhost:3000", mac.Vars["WIFI_URL"])
+	assert.Equal(t, VarOptions{Port: 8443}, mac.VarOptions["WIFI_URL"])
+
+	// The configuration itself keeps its conditions
+	assert.Equal(t, "npipe:////./pipe/docker_engine", cfg.Vars["DOCKER_HOST"])
+	assert.Len(t, cfg.VarOptions["DOCKER_HOST"].Else, 2)
+
+	// Invalid conditions are reported by Validate
+	cfg.VarOptions["WIFI_URL"] = VarOptions{When: "network == wifi"}
+	assert.ErrorContains(t, cfg.Validate(), "WIFI_URL")
+}
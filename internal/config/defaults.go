@@ -0,0 +1,60 @@
+package config
+
+// projectDefaults are the project settings of the global configuration, used when a project leaves them out
+var projectDefaults ProjectDefaults
+
+// SetProjectDefaults sets the defaults of the project settings, the 'project' section of the global configuration
+func SetProjectDefaults(defaults ProjectDefaults) {
+	projectDefaults = defaults
+}
+
+// projectConfig returns a project configuration holding the defaults, for a file to be decoded over it
+func (d ProjectDefaults) projectConfig() *ProjectConfig {
+	config := &ProjectConfig{Output: d.Output, VarPrefix: d.VarPrefix}
+	d.applyAutoDetect(&config.AutoDetect)
+	return config
+}
+
+// apply replaces the settings of config with the defaults that are set
+func (d ProjectDefaults) apply(config *ProjectConfig) {
+	if d.Output != "" {
+		config.Output = d.Output
+	}
+	if d.VarPrefix != "" {
+		config.VarPrefix = d.VarPrefix
+	}
+	d.applyAutoDetect(&config.AutoDetect)
+	config.addDetectors(d.Detectors)
+}
+
+// applyAutoDetect enables or disables the built-in detectors set in the defaults
+func (d ProjectDefaults) applyAutoDetect(autoDetect *AutoDetectConfig) {
+	applyOverride(&autoDetect.Docker, d.AutoDetect.Docker)
+	applyOverride(&autoDetect.Supabase, d.AutoDetect.Supabase)
+	applyOverride(&autoDetect.DevServers, d.AutoDetect.DevServers)
+	applyOverride(&autoDetect.Expo, d.AutoDetect.Expo)
+}
+
+// addDetectors adds the external detectors whose name the project doesn't use yet,
+// in its detectors, detector plugins or profiles
+func (c *ProjectConfig) addDetectors(detectors []DetectorConfig) {
+	used := make(map[string]bool)
+	for _, d := range c.Detectors {
+		used[d.Name] = true
+	}
+	for _, name := range c.Plugins.Detectors {
+		used[name] = true
+	}
+	for _, profile := range c.Profiles {
+		for _, d := range profile.Detectors {
+			used[d.Name] = true
+		}
+	}
+
+	for _, d := range detectors {
+		if !used[d.Name] {
+			c.Detectors = append(c.Detectors, d)
+			used[d.Name] = true
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringList is a list of strings that may be written as a single string
+type StringList []string
+
+// UnmarshalYAML reads a string or a list of strings
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var s string
+		if err := node.Decode(&s); err != nil {
+			return err
+		}
+		*l = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalYAML writes a single string as a string
+func (l StringList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+// extendsOthers reports whether a YAML project configuration sets extends
+func extendsOthers(data []byte) bool {
+	return lookupNode(documentRoot(data), "extends") != nil
+}
+
+// extendedDocument returns the project configuration at path, data in YAML, merged over the files
+// it extends, which are relative to it and merged in order
+// visiting holds the absolute paths of the files being merged, to detect cycles.
+func extendedDocument(path string, data []byte, visiting []string) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var extends StringList
+	if node := lookupNode(documentRoot(data), "extends"); node != nil {
+		if err := node.Decode(&extends); err != nil {
+			return nil, fmt.Errorf("%s: extends must be a file or a list of files", path)
+		}
+	}
+	if len(extends) == 0 {
+		return doc, nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	visiting = append(visiting, abs)
+
+	merged := make(map[string]interface{})
+	for _, base := range extends {
+		basePath := base
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(abs), base)
+		}
+		for _, visited := range visiting {
+			if visited == basePath {
+				return nil, fmt.Errorf("extends cycle: %s -> %s", strings.Join(visiting, " -> "), basePath)
+			}
+		}
+
+		baseData, err := os.ReadFile(basePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s extended by %s: %w", base, path, err)
+		}
+		if isTOML(basePath) {
+			if baseData, err = tomlToYAML(baseData); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", basePath, err)
+			}
+		}
+		baseDoc, err := extendedDocument(basePath, baseData, visiting)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeDocuments(merged, baseDoc, "")
+	}
+	return mergeDocuments(merged, doc, ""), nil
+}
+
+// mergeDocuments returns base with the keys of over merged in
+// Mappings are merged key by key, other values, lists and variables included, are replaced.
+func mergeDocuments(base, over map[string]interface{}, path string) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(over))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range over {
+		overMap, overIsMap := value.(map[string]interface{})
+		baseMap, baseIsMap := result[key].(map[string]interface{})
+		if overIsMap && baseIsMap && !isVarsPath(path) {
+			result[key] = mergeDocuments(baseMap, overMap, joinPath(path, key))
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
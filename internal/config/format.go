@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// isTOML reports whether a configuration file is in TOML format, from its extension
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// isJSON reports whether a configuration file is in JSON format, from its extension
+// JSON files are read by the YAML decoder, only writing them needs to know the format.
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// tomlToYAML converts a TOML document to YAML
+// TOML configurations are read through the YAML decoder, so both formats share the
+// same keys, validation and schema checks
+func tomlToYAML(data []byte) ([]byte, error) {
+	doc := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// marshalTOML encodes v as TOML, using its yaml keys
+func marshalTOML(v interface{}) ([]byte, error) {
+	doc, err := yamlDocument(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode TOML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalJSON encodes v as indented JSON, using its yaml keys
+func marshalJSON(v interface{}) ([]byte, error) {
+	doc, err := yamlDocument(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// yamlDocument returns v as the generic map its YAML encoding decodes to, keeping the yaml
+// keys and omitempty rules of its fields
+func yamlDocument(v interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
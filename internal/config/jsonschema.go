@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema of the project configuration is published
+// lanup init --format json references it, so that editors offer completion.
+const SchemaURL = "https://lanup.raucheacho.com/schema/lanup.schema.json"
+
+// schemaDescriptions documents the keys of the project configuration in the JSON Schema
+// Keys of map values use * (e.g. profiles.*.output), profile keys default to the base ones.
+var schemaDescriptions = map[string]string{
+	"$schema":                   "JSON Schema of this file, for editors",
+	"extends":                   "Configuration files this one is merged over, relative to it",
+	"vars":                      "Variables written to the env file, localhost URLs are rewritten with the LAN IP",
+	"vars.*.url":                "Value, localhost URLs are rewritten with the LAN IP",
+	"vars.*.scheme":             "Scheme written instead of the one of url, e.g. https",
+	"vars.*.host":               "Host written instead of the LAN IP",
+	"vars.*.port":               "Port written instead of the one of url",
+	"vars.*.when":               "Condition on the machine, e.g. os == windows or interface.type == wifi",
+	"static_vars":               "Variables written to the env file as they are, e.g. API keys and feature flags",
+	"output":                    "Env file path, relative to the configuration file, or ssh://user@host/path to write it on another machine",
+	"var_prefix":                "Prefix added to the names of the variables found by detectors, e.g. NEXT_PUBLIC_",
+	"hostname":                  "Hostname used in URLs instead of the IP (see lanup hosts)",
+	"ip":                        "IP address used instead of the detected one, e.g. a secondary address of the LAN interface",
+	"rewrites":                  "Rules rewriting the values before localhost is replaced, applied in order",
+	"rewrites.match":            "Regular expression to replace",
+	"rewrites.replace":          "Replacement of match, ${1} for groups and {host} for the LAN host",
+	"rewrites.host":             "Host replaced with the LAN host, e.g. host.docker.internal",
+	"rewrites.port":             "Port replaced with to_port, in URLs of host or of any host",
+	"rewrites.to_port":          "Port written instead of port",
+	"host_vars":                 "Write the variables making the dev servers listen on all interfaces, such as HOST=0.0.0.0 for Create React App and Nuxt (requires auto_detect.dev_servers)",
+	"check_interval":            "How often watch mode checks the network, instead of the global check_interval: a Go duration such as 2s or 1m30s, or a number of seconds, of at least 1s",
+	"gitignore":                 "Add the env file and its .bak backup to the .gitignore of the repository when git doesn't ignore them",
+	"allowed_origins_var":       "Variable listing the origins of the exposed URLs separated by commas, for CORS settings, e.g. LANUP_ALLOWED_ORIGINS",
+	"address_mode":              "Address the URLs point to: lan (default), android-emulator (10.0.2.2), genymotion (10.0.3.2) or ios-simulator (localhost)",
+	"auto_detect":               "Built-in detectors",
+	"auto_detect.docker":        "Add the published ports of the running Docker containers",
+	"auto_detect.supabase":      "Add the URLs of the local Supabase stack",
+	"auto_detect.dev_servers":   "Add the URLs of the running dev servers",
+	"auto_detect.expo":          "Add the Metro bundler URL of Expo",
+	"detectors":                 "External detectors, commands printing KEY=VALUE lines or a JSON object",
+	"detectors.name":            "Detector name, shown in the output",
+	"detectors.command":         "Shell command run in the project directory",
+	"detectors.timeout":         "Timeout in seconds",
+	"serve":                     "Built-in reverse proxy (lanup serve)",
+	"serve.port":                "Port the proxy listens on",
+	"serve.tls":                 "Serve HTTPS with a locally trusted certificate",
+	"serve.auth":                "Basic auth credentials user:password asked by the proxy, ${VAR} is read from the environment",
+	"serve.allow":               "Client IP addresses and CIDR ranges allowed to use the proxy, any when empty (loopback is always allowed)",
+	"serve.routes":              "Routes to local services, by path prefix and/or host",
+	"serve.routes.target":       "Local service URL",
+	"serve.routes.strip_prefix": "Remove the path prefix before forwarding",
+	"hooks":                     "Shell commands run on lifecycle events, with the generated variables in their environment",
+	"hooks.on_start":            "Run after 'lanup start' wrote the env file",
+	"hooks.on_change":           "Run after watch mode regenerated the env file",
+	"hooks.on_stop":             "Run after 'lanup stop' reverted the env file",
+	"notifications":             "Messages posting the exposed URLs to Slack, Discord or a webhook on lifecycle events",
+	"notifications.url":         "Webhook URL, ${VAR} is read from the environment",
+	"notifications.type":        "slack, discord or webhook (JSON payload), guessed from url by default",
+	"notifications.events":      "Hook events posted (on_start, on_change, on_stop), on_change by default",
+	"templates":                 "Files rendered with the generated variables on each run, e.g. nginx or Caddy configurations",
+	"templates.source":          "Go text/template file, relative to the configuration file",
+	"templates.output":          "File written, relative to the configuration file",
+	"files":                     "Files, e.g. capacitor.config.ts, whose localhost URLs between lanup:begin and lanup:end markers are rewritten on each run, relative to the configuration file",
+	"cert":                      "Certificate valid on the LAN issued by lanup start (see lanup cert), whose paths are written to the env file",
+	"cert.hosts":                "Names covered besides the LAN IP, the .local name, localhost and hostname",
+	"cert.cert_var":             "Variable holding the certificate path, e.g. SSL_CRT_FILE",
+	"cert.key_var":              "Variable holding the private key path, e.g. SSL_KEY_FILE",
+	"cert.ca_var":               "Variable holding the root CA path, e.g. NODE_EXTRA_CA_CERTS",
+	"expo":                      "Expo app config whose URLs lanup rewrites, since Expo reads them when it builds the app rather than from the env file",
+	"expo.app_json":             "app.json whose expo.extra and expo.updates.url values are rewritten on each run, relative to the configuration file",
+	"format":                    "Env file format: dotenv (default), dart-define (JSON for flutter run --dart-define-from-file) or a formatter plugin",
+	"plugins":                   "lanup-<name> plugins found on PATH",
+	"plugins.detectors":         "Detector plugins",
+	"plugins.notifiers":         "Notifier plugins, told about the hook events",
+	"profiles":                  "Named profiles selected with --profile",
+	"profiles.*.vars":           "Variables merged over the base vars",
+	"profiles.*.static_vars":    "Static variables merged over the base static vars",
+	"profiles.*.output":         "Env file replacing the base output",
+	"profiles.*.hostname":       "Hostname replacing the base hostname",
+	"profiles.*.ip":             "IP address replacing the base ip",
+	"profiles.*.address_mode":   "Address mode replacing the base address_mode",
+	"profiles.*.auto_detect":    "Built-in detectors turned on or off",
+	"profiles.*.detectors":      "External detectors added to the base detectors",
+}
+
+// schemaPatterns are the regular expressions the string values of some keys must match
+var schemaPatterns = map[string]string{
+	// A Go duration or a number of seconds, the minimum is checked when the configuration is loaded
+	"check_interval": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|^[0-9]+$`,
+}
+
+// schemaRequired lists the keys each object of the project configuration must have
+var schemaRequired = map[string][]string{
+	"":              {"output"},
+	"vars.*":        {"url"},
+	"detectors":     {"name", "command"},
+	"serve.routes":  {"target"},
+	"templates":     {"source", "output"},
+	"notifications": {"url"},
+}
+
+// ProjectConfigJSONSchema returns the JSON Schema (draft-07) of the project configuration
+// It is generated from ProjectConfig, the keys are the ones of the YAML, TOML and JSON files.
+func ProjectConfigJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(ProjectConfig{}), "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaURL
+	schema["title"] = "lanup project configuration"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// typeSchema returns the schema of the values of type t found at path
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	if t == reflect.TypeOf(StringList(nil)) {
+		str := map[string]interface{}{"type": "string"}
+		return map[string]interface{}{"oneOf": []interface{}{str, map[string]interface{}{"type": "array", "items": str}}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), path)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), path)}
+	case reflect.Map:
+		values := typeSchema(t.Elem(), joinPath(path, "*"))
+		if isVarsPath(path) {
+			// A variable is its URL, a mapping with the URL and its options, or a list of alternatives
+			definition := typeSchema(reflect.TypeOf(varDefinition{}), joinPath(path, "*"))
+			values = map[string]interface{}{"oneOf": []interface{}{
+				values, definition, map[string]interface{}{"type": "array", "items": definition, "minItems": 1},
+			}}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for name, field := range yamlFields(t) {
+			key := joinPath(path, name)
+			property := typeSchema(field.Type, key)
+			if description, ok := lookupSchemaKey(schemaDescriptions, key); ok {
+				property["description"] = description
+			}
+			if pattern, ok := lookupSchemaKey(schemaPatterns, key); ok {
+				property["pattern"] = pattern
+			}
+			properties[name] = property
+		}
+
+		schema := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required, ok := lookupSchemaKey(schemaRequired, path); ok {
+			sorted := append([]string(nil), required...)
+			sort.Strings(sorted)
+			schema["required"] = sorted
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// lookupSchemaKey returns the entry of a key, or the one of the base key for a profile key
+func lookupSchemaKey[T any](entries map[string]T, key string) (T, bool) {
+	if value, ok := entries[key]; ok {
+		return value, true
+	}
+	if base := strings.TrimPrefix(key, "profiles.*."); base != key {
+		value, ok := entries[base]
+		return value, ok
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectConfigJSONSchema(t *testing.T) {
+	data, err := ProjectConfigJSONSchema()
+	require.NoError(t, err)
+
+	var schema struct {
+		ID         string                     `json:"$id"`
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, SchemaURL, schema.ID)
+	assert.Equal(t, []string{"output"}, schema.Required)
+
+	// Every key of the configuration is described
+	for _, key := range []string{"$schema", "vars", "output", "hostname", "auto_detect", "detectors",
+		"serve", "hooks", "format", "plugins", "profiles"} {
+		assert.Contains(t, schema.Properties, key)
+	}
+
+	var profiles struct {
+		AdditionalProperties struct {
+			Properties map[string]struct {
+				Description string `json:"description"`
+			} `json:"properties"`
+		} `json:"additionalProperties"`
+	}
+	require.NoError(t, json.Unmarshal(schema.Properties["profiles"], &profiles))
+	assert.Equal(t, "Env file replacing the base output", profiles.AdditionalProperties.Properties["output"].Description)
+
+	// check_interval is a string, its format is described and checked by editors
+	var interval struct {
+		Description string `json:"description"`
+		Pattern     string `json:"pattern"`
+	}
+	require.NoError(t, json.Unmarshal(schema.Properties["check_interval"], &interval))
+	assert.Contains(t, interval.Description, "at least 1s")
+	pattern := regexp.MustCompile(interval.Pattern)
+	for _, valid := range []string{"2s", "1m30s", "1.5s", "10"} {
+		assert.True(t, pattern.MatchString(valid), valid)
+	}
+	for _, invalid := range []string{"soon", "2 s", "-1s", ""} {
+		assert.False(t, pattern.MatchString(invalid), invalid)
+	}
+}
+
+func TestProjectConfigJSONSchema_Published(t *testing.T) {
+	published, err := os.ReadFile("../../docs/static/schema/lanup.schema.json")
+	require.NoError(t, err)
+
+	data, err := ProjectConfigJSONSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(published),
+		"the published schema is out of date, run: go run . config schema > docs/static/schema/lanup.schema.json")
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GlobalConfigKeys returns the keys of the global configuration, as used in config.yaml
+// Nested settings are joined with a dot (e.g. section.key).
+func GlobalConfigKeys() []string {
+	var keys []string
+	walkKeys(reflect.TypeOf(GlobalConfig{}), "", func(key string, _ []int) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Get returns the value of a global configuration key as a string
+func (c *GlobalConfig) Get(key string) (string, error) {
+	field, err := fieldByKey(reflect.ValueOf(c).Elem(), key)
+	if err != nil {
+		return "", err
+	}
+	if field.Kind() == reflect.Ptr {
+		// Optional settings are empty when unset
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+	return fmt.Sprint(field.Interface()), nil
+}
+
+// Set parses and assigns the value of a global configuration key
+// The configuration is left unchanged when the value is invalid.
+func (c *GlobalConfig) Set(key, value string) error {
+	updated := *c
+
+	field, err := fieldByKey(reflect.ValueOf(&updated).Elem(), key)
+	if err != nil {
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", key, value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+		field.SetBool(b)
+	case reflect.Ptr:
+		// Optional booleans, an empty value unsets them
+		if field.Type().Elem().Kind() != reflect.Bool {
+			return fmt.Errorf("%s cannot be set from the command line", key)
+		}
+		if value == "" {
+			field.Set(reflect.Zero(field.Type()))
+			break
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true, false or empty, got %q", key, value)
+		}
+		field.Set(reflect.ValueOf(&b))
+	default:
+		return fmt.Errorf("%s cannot be set from the command line", key)
+	}
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+
+	*c = updated
+	return nil
+}
+
+// fieldByKey returns the struct field matching a dotted configuration key
+func fieldByKey(v reflect.Value, key string) (reflect.Value, error) {
+	var index []int
+	walkKeys(v.Type(), "", func(k string, i []int) {
+		if k == key {
+			index = i
+		}
+	})
+	if index == nil {
+		return reflect.Value{}, fmt.Errorf("unknown configuration key: %s (valid keys: %s)",
+			key, strings.Join(GlobalConfigKeys(), ", "))
+	}
+	return v.FieldByIndex(index), nil
+}
+
+// walkKeys calls fn with the dotted YAML key and field index of every scalar field of t
+// Lists and maps are left out, they are edited in the file.
+func walkKeys(t reflect.Type, prefix string, fn func(key string, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if kind := field.Type.Kind(); kind == reflect.Slice || kind == reflect.Map {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+
+		if field.Type.Kind() == reflect.Struct {
+			walkKeys(field.Type, key+".", func(k string, index []int) {
+				fn(k, append([]int{i}, index...))
+			})
+			continue
+		}
+		fn(key, []int{i})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalConfigKeys(t *testing.T) {
+	keys := GlobalConfigKeys()
+
+	assert.Equal(t, []string{"log_path", "log_level", "log_format", "system_log", "log_max_age", "log_compress", "default_port", "check_interval", "detect_interval", "style", "telemetry",
+		"project.output", "project.var_prefix", "project.auto_detect.docker", "project.auto_detect.supabase",
+		"project.auto_detect.dev_servers", "project.auto_detect.expo"}, keys)
+}
+
+func TestGlobalConfig_Get(t *testing.T) {
+	cfg := GetDefaultGlobalConfig()
+
+	value, err := cfg.Get("log_level")
+	require.NoError(t, err)
+	assert.Equal(t, "info", value)
+
+	value, err = cfg.Get("check_interval")
+	require.NoError(t, err)
+	assert.Equal(t, "5", value)
+
+	_, err = cfg.Get("unknown")
+	assert.Error(t, err)
+}
+
+func TestGlobalConfig_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{name: "log level", key: "log_level", value: "debug"},
+		{name: "check interval", key: "check_interval", value: "10"},
+		{name: "invalid log level", key: "log_level", value: "verbose", wantErr: true},
+		{name: "log format", key: "log_format", value: "json"},
+		{name: "invalid log format", key: "log_format", value: "xml", wantErr: true},
+		{name: "system log", key: "system_log", value: "true"},
+		{name: "log max age", key: "log_max_age", value: "14"},
+		{name: "negative log max age", key: "log_max_age", value: "-1", wantErr: true},
+		{name: "detect interval", key: "detect_interval", value: "60"},
+		{name: "detect interval disabled", key: "detect_interval", value: "-1"},
+		{name: "invalid detect interval", key: "detect_interval", value: "-5", wantErr: true},
+		{name: "style", key: "style", value: "ascii"},
+		{name: "invalid style", key: "style", value: "fancy", wantErr: true},
+		{name: "not an integer", key: "default_port", value: "abc", wantErr: true},
+		{name: "out of range", key: "default_port", value: "70000", wantErr: true},
+		{name: "unknown key", key: "nope", value: "1", wantErr: true},
+		{name: "project output", key: "project.output", value: ".env"},
+		{name: "project var prefix", key: "project.var_prefix", value: "VITE_"},
+		{name: "invalid project var prefix", key: "project.var_prefix", value: "1-", wantErr: true},
+		{name: "project auto-detect", key: "project.auto_detect.docker", value: "false"},
+		{name: "project auto-detect unset", key: "project.auto_detect.docker", value: ""},
+		{name: "invalid project auto-detect", key: "project.auto_detect.docker", value: "maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultGlobalConfig()
+			original := *cfg
+
+			err := cfg.Set(tt.key, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, original, *cfg, "config must be unchanged on error")
+				return
+			}
+
+			require.NoError(t, err)
+			value, err := cfg.Get(tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
@@ -2,30 +2,473 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/hosts"
+	"github.com/raucheacho/lanup/internal/plugin"
+	"github.com/raucheacho/lanup/internal/remote"
 )
 
 // GlobalConfig represents the global configuration stored in ~/.lanup/config.yaml
 type GlobalConfig struct {
-	LogPath       string `yaml:"log_path"`
-	LogLevel      string `yaml:"log_level"`
-	DefaultPort   int    `yaml:"default_port"`
-	CheckInterval int    `yaml:"check_interval"` // seconds for the watcher
+	LogPath        string `yaml:"log_path"`
+	LogLevel       string `yaml:"log_level"`
+	LogFormat      string `yaml:"log_format,omitempty"`   // text (default) or json
+	SystemLog      bool   `yaml:"system_log,omitempty"`   // also log to syslog or the Windows Event Log
+	LogMaxAge      int    `yaml:"log_max_age,omitempty"`  // days to keep rotated logs, 0 keeps them
+	LogCompress    bool   `yaml:"log_compress,omitempty"` // gzip rotated logs
+	DefaultPort    int    `yaml:"default_port"`
+	CheckInterval  int    `yaml:"check_interval"`            // seconds for the watcher
+	DetectInterval int    `yaml:"detect_interval,omitempty"` // seconds between detector runs in watch mode, 0 for 30, -1 disables
+	Style          string `yaml:"style,omitempty"`           // console decorations: emoji (default), plain or ascii
+	Telemetry      bool   `yaml:"telemetry,omitempty"`       // send anonymous usage statistics, see 'lanup telemetry'
+
+	Project ProjectDefaults `yaml:"project,omitempty"` // defaults of the project settings
+}
+
+// ProjectDefaults holds the project settings used when a project configuration doesn't set them,
+// so that an organization can standardize them across repositories
+type ProjectDefaults struct {
+	Output     string              `yaml:"output,omitempty"`      // env file when 'output' is not set
+	VarPrefix  string              `yaml:"var_prefix,omitempty"`  // when 'var_prefix' is not set
+	AutoDetect AutoDetectOverrides `yaml:"auto_detect,omitempty"` // built-in detectors not set in 'auto_detect'
+	Detectors  []DetectorConfig    `yaml:"detectors,omitempty"`   // added to the project detectors, unless one has the same name
 }
 
 // ProjectConfig represents the project-specific configuration stored in .lanup.yaml
 type ProjectConfig struct {
-	Vars       map[string]string `yaml:"vars"`
-	Output     string            `yaml:"output"`
-	AutoDetect AutoDetectConfig  `yaml:"auto_detect"`
+	Schema        string                   `yaml:"$schema,omitempty"` // JSON Schema for editors, see SchemaURL
+	Extends       StringList               `yaml:"extends,omitempty"` // configuration files merged under this one
+	Vars          map[string]string        `yaml:"vars"`
+	VarOptions    map[string]VarOptions    `yaml:"-"`                     // options of the vars written as mappings
+	StaticVars    map[string]string        `yaml:"static_vars,omitempty"` // written as is, localhost is not replaced
+	Output        string                   `yaml:"output"`
+	VarPrefix     string                   `yaml:"var_prefix,omitempty"`          // added to the names of the variables found by detectors
+	Hostname      string                   `yaml:"hostname,omitempty"`            // used in URLs instead of the IP (see lanup hosts)
+	IP            string                   `yaml:"ip,omitempty"`                  // used instead of the detected IP
+	AddressMode   string                   `yaml:"address_mode,omitempty"`        // see AddressModeLAN and the other modes
+	OriginsVar    string                   `yaml:"allowed_origins_var,omitempty"` // variable listing the exposed origins, for CORS
+	Rewrites      []RewriteConfig          `yaml:"rewrites,omitempty"`            // applied in order, before localhost is replaced
+	AutoDetect    AutoDetectConfig         `yaml:"auto_detect"`
+	HostVars      bool                     `yaml:"host_vars,omitempty"`      // write HOST=0.0.0.0 and the like for the dev servers
+	GitIgnore     bool                     `yaml:"gitignore,omitempty"`      // add the env file and its backup to .gitignore when git doesn't ignore them
+	CheckInterval string                   `yaml:"check_interval,omitempty"` // watch mode polling interval, e.g. 2s, instead of the global one
+	Detectors     []DetectorConfig         `yaml:"detectors,omitempty"`
+	Serve         ServeConfig              `yaml:"serve,omitempty"`
+	Hooks         HooksConfig              `yaml:"hooks,omitempty"`
+	Notifications []NotificationConfig     `yaml:"notifications,omitempty"` // messages posted on lifecycle events
+	Templates     []TemplateConfig         `yaml:"templates,omitempty"`
+	Files         []string                 `yaml:"files,omitempty"` // rewritten in place between lanup:begin and lanup:end markers, see internal/files
+	Cert          CertConfig               `yaml:"cert,omitempty"`
+	Expo          ExpoConfig               `yaml:"expo,omitempty"`
+	Format        string                   `yaml:"format,omitempty"` // env file format: dotenv (default), dart-define or a formatter plugin
+	Plugins       PluginsConfig            `yaml:"plugins,omitempty"`
+	Profiles      map[string]ProfileConfig `yaml:"profiles,omitempty"`
+
+	path string // file the configuration was loaded from, empty when built in code
+}
+
+// Path returns the file the configuration was loaded from, empty when it was built in code
+func (c *ProjectConfig) Path() string {
+	return c.path
+}
+
+// Dir returns the project directory: the directory of the configuration file, "." when there is none
+func (c *ProjectConfig) Dir() string {
+	if c.path == "" {
+		return "."
+	}
+	return filepath.Dir(c.path)
+}
+
+// WatchInterval returns the polling interval of watch mode set by check_interval, 0 when unset
+func (c *ProjectConfig) WatchInterval() (time.Duration, error) {
+	return ParseInterval(c.CheckInterval)
+}
+
+// MinInterval is the shortest check_interval, like the global one: polling faster only burns CPU
+const MinInterval = time.Second
+
+// ParseInterval parses a duration of at least MinInterval such as 2s or 1m30s, plain numbers being
+// seconds like the global check_interval, and returns 0 for an empty string
+func ParseInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("%s is not a duration, e.g. 2s or 1m", s)
+		}
+		d = time.Duration(seconds) * time.Second
+	}
+	if d < MinInterval {
+		return 0, fmt.Errorf("%s is shorter than the minimum of %s", s, MinInterval)
+	}
+	return d, nil
+}
+
+// PrefixKey returns the name of a variable found by a detector, with VarPrefix added
+// Names that already start with the prefix are left unchanged.
+func (c *ProjectConfig) PrefixKey(key string) string {
+	if c.VarPrefix == "" || strings.HasPrefix(key, c.VarPrefix) {
+		return key
+	}
+	return c.VarPrefix + key
+}
+
+// Address modes, telling which address of this machine the URLs point to
+const (
+	AddressModeLAN             = "lan"              // the LAN IP, or the hostname when set (default)
+	AddressModeAndroidEmulator = "android-emulator" // 10.0.2.2, this machine seen from the Android emulator
+	AddressModeGenymotion      = "genymotion"       // 10.0.3.2, this machine seen from Genymotion
+	AddressModeIOSSimulator    = "ios-simulator"    // localhost, the iOS simulator shares the network of the Mac
+)
+
+// addressModeHosts maps the emulator address modes to the host they reach this machine at
+var addressModeHosts = map[string]string{
+	AddressModeAndroidEmulator: "10.0.2.2",
+	AddressModeGenymotion:      "10.0.3.2",
+	AddressModeIOSSimulator:    "localhost",
+}
+
+// URLHost returns the host written in the URLs for the LAN IP ip, depending on the address mode
+func (c *ProjectConfig) URLHost(ip string) string {
+	if host, ok := addressModeHosts[c.AddressMode]; ok {
+		return host
+	}
+	if c.Hostname != "" {
+		return c.Hostname
+	}
+	return ip
+}
+
+// OutputPath returns the env file path, a relative output is relative to the configuration file
+// ssh:// outputs, written on another machine, are returned as they are.
+func (c *ProjectConfig) OutputPath() string {
+	if remote.IsRemote(c.Output) {
+		return c.Output
+	}
+	return c.ProjectPath(c.Output)
+}
+
+// ProjectPath returns a path of the configuration, which is relative to the configuration file when relative
+func (c *ProjectConfig) ProjectPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.Dir(), path)
+}
+
+// TemplateConfig is a file rendered with the generated variables on each run, see internal/templates
+type TemplateConfig struct {
+	Source string `yaml:"source"` // Go text/template file
+	Output string `yaml:"output"`
+}
+
+// CertConfig makes lanup start issue a certificate valid on the LAN, see lanup cert,
+// and write the paths of its files to the env file
+type CertConfig struct {
+	Hosts   []string `yaml:"hosts,omitempty"`    // covered besides the LAN IP, the .local name, localhost and hostname
+	CertVar string   `yaml:"cert_var,omitempty"` // e.g. SSL_CRT_FILE
+	KeyVar  string   `yaml:"key_var,omitempty"`  // e.g. SSL_KEY_FILE
+	CAVar   string   `yaml:"ca_var,omitempty"`   // e.g. NODE_EXTRA_CA_CERTS
+}
+
+// Enabled reports whether lanup start writes the certificate to the env file
+func (c CertConfig) Enabled() bool {
+	return c.CertVar != "" || c.KeyVar != "" || c.CAVar != ""
+}
+
+// Validate checks if the CertConfig has valid values
+func (c CertConfig) Validate() error {
+	for _, host := range c.Hosts {
+		if !hosts.ValidHostname(host) && net.ParseIP(host) == nil {
+			return fmt.Errorf("invalid cert host: %s", host)
+		}
+	}
+	for _, key := range []string{c.CertVar, c.KeyVar, c.CAVar} {
+		if key != "" && !varPrefixRe.MatchString(key) {
+			return fmt.Errorf("invalid cert variable: %s", key)
+		}
+	}
+	return nil
+}
+
+// Vars returns the variables holding the given certificate, key and CA files
+func (c CertConfig) Vars(certFile, keyFile, caFile string) map[string]string {
+	vars := make(map[string]string)
+	for key, value := range map[string]string{c.CertVar: certFile, c.KeyVar: keyFile, c.CAVar: caFile} {
+		if key != "" && value != "" {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// ExpoConfig makes lanup rewrite the URLs of the Expo app config, see internal/expo
+type ExpoConfig struct {
+	AppJSON string `yaml:"app_json,omitempty"` // e.g. app.json, relative to the configuration file
+}
+
+// Validate checks if the ExpoConfig has valid values
+func (c ExpoConfig) Validate() error {
+	if c.AppJSON != "" && !strings.EqualFold(filepath.Ext(c.AppJSON), ".json") {
+		return fmt.Errorf("expo.app_json must be a JSON file, rewrite %s with files and lanup:begin markers instead", c.AppJSON)
+	}
+	return nil
+}
+
+// ProfileConfig holds the settings a named profile overrides (see ProjectConfig.WithProfile)
+type ProfileConfig struct {
+	Vars        map[string]string     `yaml:"vars,omitempty"` // merged over the base vars
+	VarOptions  map[string]VarOptions `yaml:"-"`
+	StaticVars  map[string]string     `yaml:"static_vars,omitempty"` // merged over the base static vars
+	Output      string                `yaml:"output,omitempty"`
+	Hostname    string                `yaml:"hostname,omitempty"`
+	IP          string                `yaml:"ip,omitempty"`
+	AddressMode string                `yaml:"address_mode,omitempty"`
+	AutoDetect  AutoDetectOverrides   `yaml:"auto_detect,omitempty"`
+	Detectors   []DetectorConfig      `yaml:"detectors,omitempty"` // added to the base detectors
+}
+
+// AutoDetectOverrides holds the auto-detection settings changed by a profile, nil means unchanged
+type AutoDetectOverrides struct {
+	Docker     *bool `yaml:"docker,omitempty"`
+	Supabase   *bool `yaml:"supabase,omitempty"`
+	DevServers *bool `yaml:"dev_servers,omitempty"`
+	Expo       *bool `yaml:"expo,omitempty"`
 }
 
 // AutoDetectConfig holds settings for automatic service detection
 type AutoDetectConfig struct {
-	Docker   bool `yaml:"docker"`
-	Supabase bool `yaml:"supabase"`
+	Docker     bool `yaml:"docker"`
+	Supabase   bool `yaml:"supabase"`
+	DevServers bool `yaml:"dev_servers"`
+	Expo       bool `yaml:"expo"`
+}
+
+// DetectorConfig defines an external detector command
+// The command must print KEY=VALUE lines or a JSON object on stdout
+type DetectorConfig struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	Timeout int    `yaml:"timeout,omitempty"` // seconds
+}
+
+// HooksConfig lists the shell commands run on lifecycle events, with the generated variables in their environment
+type HooksConfig struct {
+	OnStart  []string `yaml:"on_start,omitempty"`  // after 'lanup start' wrote the env file
+	OnChange []string `yaml:"on_change,omitempty"` // after watch mode regenerated the env file for a new IP or changed services
+	OnStop   []string `yaml:"on_stop,omitempty"`   // after 'lanup stop' reverted the env file
+}
+
+// Notification types, see NotificationConfig.Type
+const (
+	NotificationSlack   = "slack"
+	NotificationDiscord = "discord"
+	NotificationWebhook = "webhook"
+)
+
+// NotificationConfig posts the exposed URLs to a chat channel or a webhook on lifecycle events
+type NotificationConfig struct {
+	URL    string   `yaml:"url"`              // ${VAR} is expanded from the environment, to keep secrets out of the file
+	Type   string   `yaml:"type,omitempty"`   // slack, discord or webhook, guessed from url by default
+	Events []string `yaml:"events,omitempty"` // hook events posted, on_change by default
+}
+
+// Kind returns the type of the notification, guessed from the URL when not set
+func (n NotificationConfig) Kind() string {
+	if n.Type != "" {
+		return n.Type
+	}
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return NotificationWebhook
+	}
+	switch host := u.Hostname(); {
+	case host == "hooks.slack.com":
+		return NotificationSlack
+	case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(u.Path, "/api/webhooks/"):
+		return NotificationDiscord
+	default:
+		return NotificationWebhook
+	}
+}
+
+// Posts reports whether the notification is posted on event (e.g. on_change)
+func (n NotificationConfig) Posts(event string) bool {
+	if len(n.Events) == 0 {
+		return event == "on_change"
+	}
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks if the NotificationConfig has valid values
+func (n NotificationConfig) Validate() error {
+	if n.URL == "" {
+		return fmt.Errorf("notifications must define a url")
+	}
+	// URLs read from the environment are checked when they are expanded
+	if !strings.Contains(n.URL, "$") {
+		u, err := url.Parse(n.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid notification url: %s (http or https)", n.URL)
+		}
+	}
+	switch n.Type {
+	case "", NotificationSlack, NotificationDiscord, NotificationWebhook:
+	default:
+		return fmt.Errorf("invalid notification type: %s (slack, discord or webhook)", n.Type)
+	}
+	for _, event := range n.Events {
+		if event != "on_start" && event != "on_change" && event != "on_stop" {
+			return fmt.Errorf("invalid notification event: %s (on_start, on_change or on_stop)", event)
+		}
+	}
+	return nil
+}
+
+// PluginsConfig enables lanup-<name> plugins found on PATH
+type PluginsConfig struct {
+	Detectors []string `yaml:"detectors,omitempty"` // run like the detectors
+	Notifiers []string `yaml:"notifiers,omitempty"` // told about the hook events
+}
+
+// ServeConfig holds settings for the built-in reverse proxy (lanup serve)
+type ServeConfig struct {
+	Port   int           `yaml:"port,omitempty"`
+	TLS    bool          `yaml:"tls,omitempty"`
+	Auth   string        `yaml:"auth,omitempty"`  // basic auth user:password, ${VAR} is expanded from the environment
+	Allow  []string      `yaml:"allow,omitempty"` // client IPs and CIDR ranges allowed, any when empty
+	Routes []RouteConfig `yaml:"routes,omitempty"`
+}
+
+// Validate checks if the ServeConfig has valid values
+func (c ServeConfig) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("serve.port must be between 1 and 65535, got %d", c.Port)
+	}
+	// Credentials read from the environment are checked when lanup serve runs
+	if c.Auth != "" && !strings.Contains(c.Auth, "$") {
+		if user, password, ok := strings.Cut(c.Auth, ":"); !ok || user == "" || password == "" {
+			return fmt.Errorf("serve.auth must be written user:password")
+		}
+	}
+	for _, entry := range c.Allow {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid serve.allow entry: %s (IP address or CIDR range)", entry)
+		}
+	}
+	for _, route := range c.Routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("invalid serve route: %w", err)
+		}
+	}
+	return nil
+}
+
+// RouteConfig maps incoming requests, by path prefix and/or host, to a local service
+type RouteConfig struct {
+	Path        string `yaml:"path,omitempty"`
+	Host        string `yaml:"host,omitempty"`
+	Target      string `yaml:"target"`
+	StripPrefix bool   `yaml:"strip_prefix,omitempty"`
+}
+
+// Validate checks if the RouteConfig has valid values
+func (r RouteConfig) Validate() error {
+	if r.Path == "" && r.Host == "" {
+		return fmt.Errorf("route to %s must define a path or a host", r.Target)
+	}
+	if r.Path != "" && !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("route path %s must start with /", r.Path)
+	}
+
+	target, err := url.Parse(r.Target)
+	if err != nil || target.Host == "" {
+		return fmt.Errorf("route target %q must be an absolute URL", r.Target)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("route target %s must use http or https", r.Target)
+	}
+
+	return nil
+}
+
+// builtinDetectors lists the names of the built-in detectors, which external detectors cannot reuse
+var builtinDetectors = map[string]bool{
+	"docker":      true,
+	"supabase":    true,
+	"dev_servers": true,
+	"expo":        true,
+}
+
+// AllDetectors names every detector in EnableDetector
+const AllDetectors = "all"
+
+// EnableDetector turns a detector on or off for one run, overriding auto_detect
+// External and plugin detectors are on when configured: turning them off removes them, and
+// AllDetectors turns the built-in detectors on, or every detector off.
+func (c *ProjectConfig) EnableDetector(name string, enabled bool) error {
+	all := name == AllDetectors
+	found := all
+	for _, d := range []struct {
+		name string
+		flag *bool
+	}{
+		{"docker", &c.AutoDetect.Docker},
+		{"supabase", &c.AutoDetect.Supabase},
+		{"dev_servers", &c.AutoDetect.DevServers},
+		{"expo", &c.AutoDetect.Expo},
+	} {
+		if all || d.name == name {
+			*d.flag = enabled
+			found = true
+		}
+	}
+
+	detectors := make([]DetectorConfig, 0, len(c.Detectors))
+	for _, d := range c.Detectors {
+		if all || d.Name == name {
+			found = true
+			if !enabled {
+				continue
+			}
+		}
+		detectors = append(detectors, d)
+	}
+	c.Detectors = detectors
+
+	plugins := make([]string, 0, len(c.Plugins.Detectors))
+	for _, p := range c.Plugins.Detectors {
+		if all || p == name {
+			found = true
+			if !enabled {
+				continue
+			}
+		}
+		plugins = append(plugins, p)
+	}
+	c.Plugins.Detectors = plugins
+
+	if !found {
+		return fmt.Errorf("unknown detector %s (docker, supabase, dev_servers, expo, all or a detector of the configuration)", name)
+	}
+	return nil
 }
 
 // Validate checks if the GlobalConfig has valid values
@@ -53,6 +496,16 @@ func (c *GlobalConfig) Validate() error {
 		return fmt.Errorf("invalid log_level: %s (must be debug, info, warn, or error)", c.LogLevel)
 	}
 
+	switch strings.ToLower(c.LogFormat) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid log_format: %s (must be text or json)", c.LogFormat)
+	}
+
+	if c.LogMaxAge < 0 {
+		return fmt.Errorf("log_max_age cannot be negative, got %d", c.LogMaxAge)
+	}
+
 	if c.DefaultPort < 1 || c.DefaultPort > 65535 {
 		return fmt.Errorf("default_port must be between 1 and 65535, got %d", c.DefaultPort)
 	}
@@ -61,14 +514,63 @@ func (c *GlobalConfig) Validate() error {
 		return fmt.Errorf("check_interval must be at least 1 second, got %d", c.CheckInterval)
 	}
 
+	if c.DetectInterval < -1 {
+		return fmt.Errorf("detect_interval must be -1 (disabled), 0 (default) or a number of seconds, got %d", c.DetectInterval)
+	}
+
+	switch strings.ToLower(c.Style) {
+	case "", "emoji", "plain", "ascii":
+	default:
+		return fmt.Errorf("invalid style: %s (must be emoji, plain or ascii)", c.Style)
+	}
+
+	if c.Project.VarPrefix != "" && !varPrefixRe.MatchString(c.Project.VarPrefix) {
+		return fmt.Errorf("invalid project.var_prefix: %s (letters, digits and underscores, not starting with a digit)", c.Project.VarPrefix)
+	}
+	if _, err := validateDetectors(c.Project.Detectors); err != nil {
+		return fmt.Errorf("invalid project.detectors: %w", err)
+	}
+
 	return nil
 }
 
+// varPrefixRe matches the prefixes that keep variable names valid
+var varPrefixRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateDetectors checks external detectors and returns their names
+func validateDetectors(detectors []DetectorConfig) (map[string]bool, error) {
+	names := make(map[string]bool)
+	for i, d := range detectors {
+		if d.Name == "" {
+			return nil, fmt.Errorf("detector #%d must have a name", i+1)
+		}
+		if builtinDetectors[d.Name] {
+			return nil, fmt.Errorf("detector name %s is reserved for a built-in detector", d.Name)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("duplicate detector name: %s", d.Name)
+		}
+		names[d.Name] = true
+		if d.Command == "" {
+			return nil, fmt.Errorf("detector %s must have a command", d.Name)
+		}
+		if d.Timeout < 0 {
+			return nil, fmt.Errorf("detector %s timeout cannot be negative, got %d", d.Name, d.Timeout)
+		}
+	}
+	return names, nil
+}
+
 // Validate checks if the ProjectConfig has valid values
 func (c *ProjectConfig) Validate() error {
 	if c.Output == "" {
 		return fmt.Errorf("output file path cannot be empty")
 	}
+	if remote.IsRemote(c.Output) {
+		if _, err := remote.ParseSSH(c.Output); err != nil {
+			return err
+		}
+	}
 
 	if c.Vars == nil {
 		c.Vars = make(map[string]string)
@@ -83,6 +585,146 @@ func (c *ProjectConfig) Validate() error {
 			return fmt.Errorf("variable %s has empty value", key)
 		}
 	}
+	if err := validateVarOptions(c.Vars, c.VarOptions); err != nil {
+		return err
+	}
+	for key := range c.StaticVars {
+		if key == "" {
+			return fmt.Errorf("variable key cannot be empty")
+		}
+		if _, ok := c.Vars[key]; ok {
+			return fmt.Errorf("variable %s is set in both vars and static_vars", key)
+		}
+	}
+
+	if c.Hostname != "" && !hosts.ValidHostname(c.Hostname) {
+		return fmt.Errorf("invalid hostname: %s", c.Hostname)
+	}
+
+	if c.IP != "" && net.ParseIP(c.IP) == nil {
+		return fmt.Errorf("invalid ip: %s", c.IP)
+	}
+
+	if _, err := c.WatchInterval(); err != nil {
+		return fmt.Errorf("invalid check_interval: %w", err)
+	}
+
+	if _, ok := addressModeHosts[c.AddressMode]; !ok && c.AddressMode != "" && c.AddressMode != AddressModeLAN {
+		return fmt.Errorf("invalid address_mode: %s (lan, android-emulator, genymotion or ios-simulator)", c.AddressMode)
+	}
+
+	if c.VarPrefix != "" && !varPrefixRe.MatchString(c.VarPrefix) {
+		return fmt.Errorf("invalid var_prefix: %s (letters, digits and underscores, not starting with a digit)", c.VarPrefix)
+	}
+
+	if c.OriginsVar != "" {
+		if !varPrefixRe.MatchString(c.OriginsVar) {
+			return fmt.Errorf("invalid allowed_origins_var: %s", c.OriginsVar)
+		}
+		_, inVars := c.Vars[c.OriginsVar]
+		_, inStatic := c.StaticVars[c.OriginsVar]
+		if inVars || inStatic {
+			return fmt.Errorf("variable %s is also the allowed_origins_var", c.OriginsVar)
+		}
+	}
+
+	for _, rewrite := range c.Rewrites {
+		if err := rewrite.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate external detectors
+	names, err := validateDetectors(c.Detectors)
+	if err != nil {
+		return err
+	}
+
+	// Validate hooks
+	for event, commands := range map[string][]string{
+		"on_start":  c.Hooks.OnStart,
+		"on_change": c.Hooks.OnChange,
+		"on_stop":   c.Hooks.OnStop,
+	} {
+		for _, command := range commands {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("hooks.%s cannot contain an empty command", event)
+			}
+		}
+	}
+
+	for _, t := range c.Templates {
+		if t.Source == "" || t.Output == "" {
+			return fmt.Errorf("templates must define a source and an output")
+		}
+		if c.ProjectPath(t.Output) == c.OutputPath() || t.Source == t.Output {
+			return fmt.Errorf("template %s cannot be written to %s", t.Source, t.Output)
+		}
+	}
+
+	for _, file := range c.Files {
+		if strings.TrimSpace(file) == "" {
+			return fmt.Errorf("files cannot contain an empty path")
+		}
+		if c.ProjectPath(file) == c.OutputPath() {
+			return fmt.Errorf("files cannot contain the env file %s", file)
+		}
+	}
+
+	for _, n := range c.Notifications {
+		if err := n.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Cert.Validate(); err != nil {
+		return err
+	}
+	if err := c.Expo.Validate(); err != nil {
+		return err
+	}
+	if c.Expo.AppJSON != "" && c.ProjectPath(c.Expo.AppJSON) == c.OutputPath() {
+		return fmt.Errorf("expo.app_json cannot be the env file %s", c.Expo.AppJSON)
+	}
+
+	// Validate plugins, whose detectors share the names of the other detectors
+	if c.Format != "" && c.Format != "dotenv" && c.Format != "dart-define" && !plugin.ValidName(c.Format) {
+		return fmt.Errorf("invalid format: %s", c.Format)
+	}
+	for _, name := range c.Plugins.Detectors {
+		if !plugin.ValidName(name) {
+			return fmt.Errorf("invalid plugin name: %s", name)
+		}
+		if builtinDetectors[name] || names[name] {
+			return fmt.Errorf("duplicate detector name: %s", name)
+		}
+		names[name] = true
+	}
+	for _, name := range c.Plugins.Notifiers {
+		if !plugin.ValidName(name) {
+			return fmt.Errorf("invalid plugin name: %s", name)
+		}
+	}
+
+	// Validate reverse proxy settings
+	if err := c.Serve.Validate(); err != nil {
+		return err
+	}
+
+	// Validate profiles by validating the configuration they produce
+	for name := range c.Profiles {
+		if name == "" {
+			return fmt.Errorf("profile name cannot be empty")
+		}
+		profile, err := c.WithProfile(name)
+		if err != nil {
+			return err
+		}
+		profile.Profiles = nil
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("invalid profile %s: %w", name, err)
+		}
+	}
 
 	return nil
 }
@@ -1,24 +1,92 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"gopkg.in/yaml.v3"
 )
 
-// LoadGlobalConfig reads the global configuration from ~/.lanup/config.yaml
-func LoadGlobalConfig() (*GlobalConfig, error) {
+// EnvGlobalConfig is the environment variable selecting another global configuration file
+const EnvGlobalConfig = "LANUP_CONFIG"
+
+// ErrGlobalConfigNotFound is returned when the global configuration file selected with
+// --config or LANUP_CONFIG doesn't exist, only the default one is created on first run
+var ErrGlobalConfigNotFound = errors.New("global config file not found")
+
+// ErrProjectConfigNotFound is returned when there is no .lanup.yaml in the current directory or its parents
+var ErrProjectConfigNotFound = errors.New("project config file not found")
+
+// ProjectConfigFile is the name of the project configuration file
+const ProjectConfigFile = ".lanup.yaml"
+
+// ProjectConfigFiles are the project configuration file names lanup looks for, in order
+var ProjectConfigFiles = []string{ProjectConfigFile, ".lanup.toml", ".lanup.json"}
+
+// globalConfigPath is the global configuration file selected with --config, empty for the default
+var globalConfigPath string
+
+// SetGlobalConfigPath selects the global configuration file, $LANUP_CONFIG or ~/.lanup/config.yaml when empty
+func SetGlobalConfigPath(path string) {
+	globalConfigPath = path
+}
+
+// customGlobalConfigPath returns the absolute path selected with --config or LANUP_CONFIG, empty for the default
+func customGlobalConfigPath() (string, error) {
+	path := globalConfigPath
+	if path == "" {
+		path = os.Getenv(EnvGlobalConfig)
+	}
+	if path == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve global config path: %w", err)
+	}
+	return abs, nil
+}
+
+// GlobalConfigPath returns the path of the global configuration file: the one selected with
+// --config or LANUP_CONFIG, ~/.lanup/config.yaml by default
+func GlobalConfigPath() (string, error) {
+	if custom, err := customGlobalConfigPath(); err != nil || custom != "" {
+		return custom, err
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".lanup", "config.yaml"), nil
+}
+
+// LoadGlobalConfig reads the global configuration from GlobalConfigPath
+// The default ~/.lanup/config.yaml is created on first run, a file selected with --config or LANUP_CONFIG must exist
+func LoadGlobalConfig() (*GlobalConfig, error) {
+	custom, err := customGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := GlobalConfigPath()
+	if err != nil {
+		return nil, err
 	}
 
-	configPath := filepath.Join(home, ".lanup", "config.yaml")
+	info, err := os.Stat(configPath)
+	if err == nil && info.IsDir() {
+		return nil, fmt.Errorf("global config path %s is a directory", configPath)
+	}
+	if os.IsNotExist(err) && custom != "" {
+		return nil, fmt.Errorf("%w: %s (run 'lanup config edit' to create it)", ErrGlobalConfigNotFound, configPath)
+	}
 
 	// If config doesn't exist, create it with defaults
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	if os.IsNotExist(err) {
 		defaultConfig := GetDefaultGlobalConfig()
 		if err := ensureGlobalConfigDir(); err != nil {
 			return nil, fmt.Errorf("failed to create config directory: %w", err)
@@ -34,6 +102,11 @@ func LoadGlobalConfig() (*GlobalConfig, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return ParseGlobalConfig(data)
+}
+
+// ParseGlobalConfig parses and validates global configuration YAML
+func ParseGlobalConfig(data []byte) (*GlobalConfig, error) {
 	var config GlobalConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -46,43 +119,210 @@ func LoadGlobalConfig() (*GlobalConfig, error) {
 	return &config, nil
 }
 
-// LoadProjectConfig reads the project configuration from .lanup.yaml in the current directory
+// SaveGlobalConfig validates and writes the global configuration to GlobalConfigPath
+func SaveGlobalConfig(config *GlobalConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	custom, err := customGlobalConfigPath()
+	if err != nil {
+		return err
+	}
+	configPath, err := GlobalConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if custom != "" {
+		err = os.MkdirAll(filepath.Dir(configPath), 0755)
+	} else {
+		err = ensureGlobalConfigDir()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return saveGlobalConfig(configPath, config)
+}
+
+// FindProjectConfig returns the nearest project configuration file in dir or its parent directories
+// The search stops at the root of the git repository, a configuration above it belongs to another project.
+// The path is relative to dir when dir is relative.
+func FindProjectConfig(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		for _, name := range ProjectConfigFiles {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		if _, err := os.Stat(filepath.Join(abs, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			break
+		}
+		abs = parent
+		dir = filepath.Join(dir, "..")
+	}
+
+	return "", fmt.Errorf("%w: %s (run 'lanup init' to create one)", ErrProjectConfigNotFound, ProjectConfigFile)
+}
+
+// LoadProjectConfig reads the project configuration at path, the nearest one from the current
+// directory when empty (see FindProjectConfig)
+// A .toml file is read as TOML, any other one as YAML, which JSON is a subset of.
+// Settings the file leaves out fall back to the defaults of the global configuration.
 func LoadProjectConfig(path string) (*ProjectConfig, error) {
 	if path == "" {
-		path = ".lanup.yaml"
+		found, err := FindProjectConfig(".")
+		if err != nil {
+			return nil, err
+		}
+		path = found
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("project config file not found: %s (run 'lanup init' to create one)", path)
+			return nil, fmt.Errorf("%w: %s (run 'lanup init' to create one)", ErrProjectConfigNotFound, path)
 		}
 		return nil, fmt.Errorf("failed to read project config: %w", err)
 	}
 
-	var config ProjectConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse project config: %w", err)
+	config, err := decodeProjectConfig(path, data, projectDefaults)
+	if err != nil {
+		return nil, err
 	}
-
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid project configuration: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
+}
+
+// UpdateProjectConfig applies fn to the project configuration file at path and saves it
+// Only the settings fn changes are written: the file keeps relying on the global defaults.
+func UpdateProjectConfig(path string, fn func(*ProjectConfig)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read project config: %w", err)
+	}
+
+	config, err := decodeProjectConfig(path, data, ProjectDefaults{})
+	if err != nil {
+		return err
+	}
+	doc, err := projectConfigDocument(path, data)
+	if err != nil {
+		return err
+	}
+
+	before, err := yamlDocument(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	fn(config)
+	after, err := yamlDocument(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	for key, value := range after {
+		if !reflect.DeepEqual(value, before[key]) {
+			doc[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			delete(doc, key)
+		}
+	}
+
+	if data, err = marshalProjectConfig(path, doc); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Check the file the way lanup will load it
+	updated, err := decodeProjectConfig(path, data, projectDefaults)
+	if err != nil {
+		return err
+	}
+	if err := updated.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
 }
 
-// SaveProjectConfig writes the project configuration to a file in YAML format
+// projectConfigDocument parses the content of the project configuration file at path as a generic document
+func projectConfigDocument(path string, data []byte) (map[string]interface{}, error) {
+	var err error
+	if isTOML(path) {
+		if data, err = tomlToYAML(data); err != nil {
+			return nil, fmt.Errorf("failed to parse project config: %w", err)
+		}
+	}
+
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse project config: %w", err)
+	}
+	return doc, nil
+}
+
+// decodeProjectConfig parses the content of the project configuration file at path over the defaults
+func decodeProjectConfig(path string, data []byte, defaults ProjectDefaults) (*ProjectConfig, error) {
+	var err error
+	if isTOML(path) {
+		if data, err = tomlToYAML(data); err != nil {
+			return nil, fmt.Errorf("failed to parse project config: %w", err)
+		}
+	}
+
+	// Files it extends are merged first, as if their keys were written in it
+	if extendsOthers(data) {
+		doc, err := extendedDocument(path, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("failed to merge project config: %w", err)
+		}
+	}
+
+	// Keys missing from the file keep their default
+	config := defaults.projectConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse project config: %w", err)
+	}
+	config.addDetectors(defaults.Detectors)
+
+	config.path = path
+	return config, nil
+}
+
+// SaveProjectConfig writes the project configuration to a file, in the format of its extension
+// (.toml or .json), in YAML format otherwise
 func SaveProjectConfig(path string, config *ProjectConfig) error {
 	if path == "" {
-		path = ".lanup.yaml"
+		path = ProjectConfigFile
 	}
 
 	if err := config.Validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	data, err := yaml.Marshal(config)
+	data, err := marshalProjectConfig(path, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -94,6 +334,18 @@ func SaveProjectConfig(path string, config *ProjectConfig) error {
 	return nil
 }
 
+// marshalProjectConfig encodes the project configuration, or its generic document, in the format of the file at path
+func marshalProjectConfig(path string, config interface{}) ([]byte, error) {
+	switch {
+	case isTOML(path):
+		return marshalTOML(config)
+	case isJSON(path):
+		return marshalJSON(config)
+	default:
+		return yaml.Marshal(config)
+	}
+}
+
 // GetDefaultGlobalConfig returns a GlobalConfig with default values
 func GetDefaultGlobalConfig() *GlobalConfig {
 	home, _ := os.UserHomeDir()
@@ -102,14 +354,17 @@ func GetDefaultGlobalConfig() *GlobalConfig {
 	return &GlobalConfig{
 		LogPath:       logPath,
 		LogLevel:      "info",
+		LogFormat:     "text",
 		DefaultPort:   8080,
 		CheckInterval: 5,
+		Style:         "emoji",
 	}
 }
 
-// GetDefaultProjectConfig returns a ProjectConfig with default values
+// GetDefaultProjectConfig returns a ProjectConfig with default values, the project defaults
+// of the global configuration taking precedence
 func GetDefaultProjectConfig() *ProjectConfig {
-	return &ProjectConfig{
+	config := &ProjectConfig{
 		Vars: map[string]string{
 			"SUPABASE_URL":      "http://localhost:54321",
 			"SUPABASE_ANON_KEY": "your-anon-key",
@@ -118,10 +373,14 @@ func GetDefaultProjectConfig() *ProjectConfig {
 		},
 		Output: ".env.local",
 		AutoDetect: AutoDetectConfig{
-			Docker:   true,
-			Supabase: true,
+			Docker:     true,
+			Supabase:   true,
+			DevServers: true,
+			Expo:       true,
 		},
 	}
+	projectDefaults.apply(config)
+	return config
 }
 
 // ensureGlobalConfigDir creates the ~/.lanup directory structure if it doesn't exist
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,6 +47,28 @@ func TestGlobalConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid log format",
+			config: GlobalConfig{
+				LogPath:       "/tmp/lanup.log",
+				LogLevel:      "info",
+				LogFormat:     "xml",
+				DefaultPort:   8080,
+				CheckInterval: 5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid style",
+			config: GlobalConfig{
+				LogPath:       "/tmp/lanup.log",
+				LogLevel:      "info",
+				DefaultPort:   8080,
+				CheckInterval: 5,
+				Style:         "fancy",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid port - too low",
 			config: GlobalConfig{
@@ -75,6 +99,43 @@ func TestGlobalConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "project defaults",
+			config: GlobalConfig{
+				LogPath:       "/tmp/lanup.log",
+				LogLevel:      "info",
+				DefaultPort:   8080,
+				CheckInterval: 5,
+				Project: ProjectDefaults{
+					Output:    ".env",
+					VarPrefix: "NEXT_PUBLIC_",
+					Detectors: []DetectorConfig{{Name: "vault", Command: "./vault-env.sh"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid project var prefix",
+			config: GlobalConfig{
+				LogPath:       "/tmp/lanup.log",
+				LogLevel:      "info",
+				DefaultPort:   8080,
+				CheckInterval: 5,
+				Project:       ProjectDefaults{VarPrefix: "NEXT-PUBLIC"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid project detector",
+			config: GlobalConfig{
+				LogPath:       "/tmp/lanup.log",
+				LogLevel:      "info",
+				DefaultPort:   8080,
+				CheckInterval: 5,
+				Project:       ProjectDefaults{Detectors: []DetectorConfig{{Name: "docker", Command: "true"}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +180,16 @@ func TestProjectConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:    "ssh output",
+			config:  ProjectConfig{Output: "ssh://pi@raspberrypi.local/~/api/.env"},
+			wantErr: false,
+		},
+		{
+			name:    "ssh output without path",
+			config:  ProjectConfig{Output: "ssh://pi@raspberrypi.local"},
+			wantErr: true,
+		},
 		{
 			name: "empty variable value",
 			config: ProjectConfig{
@@ -129,6 +200,86 @@ func TestProjectConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid external detector",
+			config: ProjectConfig{
+				Output:    ".env.local",
+				Detectors: []DetectorConfig{{Name: "rails", Command: "./detect.sh", Timeout: 5}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "detector without command",
+			config: ProjectConfig{
+				Output:    ".env.local",
+				Detectors: []DetectorConfig{{Name: "rails"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "detector with built-in name",
+			config: ProjectConfig{
+				Output:    ".env.local",
+				Detectors: []DetectorConfig{{Name: "docker", Command: "./detect.sh"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate detector names",
+			config: ProjectConfig{
+				Output: ".env.local",
+				Detectors: []DetectorConfig{
+					{Name: "rails", Command: "./a.sh"},
+					{Name: "rails", Command: "./b.sh"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "valid hostname",
+			config:  ProjectConfig{Output: ".env.local", Hostname: "myapp.lan"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid hostname",
+			config:  ProjectConfig{Output: ".env.local", Hostname: "my_app lan"},
+			wantErr: true,
+		},
+		{
+			name:    "valid ip",
+			config:  ProjectConfig{Output: ".env.local", IP: "192.168.1.50"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid ip",
+			config:  ProjectConfig{Output: ".env.local", IP: "192.168.1"},
+			wantErr: true,
+		},
+		{
+			name:    "valid hooks",
+			config:  ProjectConfig{Output: ".env.local", Hooks: HooksConfig{OnChange: []string{"npm run codegen"}}},
+			wantErr: false,
+		},
+		{
+			name:    "valid plugins",
+			config:  ProjectConfig{Output: ".env.local", Format: "json", Plugins: PluginsConfig{Detectors: []string{"rails"}, Notifiers: []string{"slack"}}},
+			wantErr: false,
+		},
+		{
+			name:    "plugin detector with built-in name",
+			config:  ProjectConfig{Output: ".env.local", Plugins: PluginsConfig{Detectors: []string{"docker"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			config:  ProjectConfig{Output: ".env.local", Format: "../json"},
+			wantErr: true,
+		},
+		{
+			name:    "empty hook command",
+			config:  ProjectConfig{Output: ".env.local", Hooks: HooksConfig{OnStart: []string{" "}}},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -303,6 +454,53 @@ check_interval: 10
 	assert.Equal(t, 10, config.CheckInterval)
 }
 
+func TestLoadGlobalConfig_CustomPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvGlobalConfig, "")
+	t.Cleanup(func() { SetGlobalConfigPath("") })
+
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "team", "lanup.yaml")
+	SetGlobalConfigPath(custom)
+
+	path, err := GlobalConfigPath()
+	require.NoError(t, err)
+	assert.Equal(t, custom, path)
+
+	// A selected file is not created on first run, nor is ~/.lanup
+	_, err = LoadGlobalConfig()
+	assert.ErrorIs(t, err, ErrGlobalConfigNotFound)
+	assert.NoDirExists(t, filepath.Join(home, ".lanup"))
+
+	cfg := GetDefaultGlobalConfig()
+	cfg.CheckInterval = 42
+	require.NoError(t, SaveGlobalConfig(cfg))
+	loaded, err := LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 42, loaded.CheckInterval)
+	assert.NoDirExists(t, filepath.Join(home, ".lanup"))
+
+	// LANUP_CONFIG applies when no path is set, relative paths are resolved
+	SetGlobalConfigPath("")
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(dir))
+	t.Setenv(EnvGlobalConfig, filepath.Join("team", "lanup.yaml"))
+	path, err = GlobalConfigPath()
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(path))
+	loaded, err = LoadGlobalConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 42, loaded.CheckInterval)
+
+	// Directories are refused
+	t.Setenv(EnvGlobalConfig, dir)
+	_, err = LoadGlobalConfig()
+	assert.ErrorContains(t, err, "is a directory")
+}
+
 func TestLoadProjectConfig_InvalidYAML(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, ".lanup.yaml")
@@ -378,6 +576,110 @@ func TestProjectConfig_Validate_EmptyKey(t *testing.T) {
 	assert.Contains(t, err.Error(), "key cannot be empty")
 }
 
+func TestProjectConfig_Validate_Rewrites(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewrite RewriteConfig
+		wantErr bool
+	}{
+		{name: "regular expression", rewrite: RewriteConfig{Match: `:3000\b`, Replace: ":8443"}},
+		{name: "host", rewrite: RewriteConfig{Host: "host.docker.internal"}},
+		{name: "port", rewrite: RewriteConfig{Port: 3000, ToPort: 8443}},
+		{name: "host and port", rewrite: RewriteConfig{Host: "api.local", Port: 80, ToPort: 8080}},
+		{name: "invalid regular expression", rewrite: RewriteConfig{Match: "(", Replace: "x"}, wantErr: true},
+		{name: "match and host", rewrite: RewriteConfig{Match: "db", Host: "db"}, wantErr: true},
+		{name: "replace without match", rewrite: RewriteConfig{Replace: "x"}, wantErr: true},
+		{name: "empty", rewrite: RewriteConfig{}, wantErr: true},
+		{name: "port without to_port", rewrite: RewriteConfig{Port: 3000}, wantErr: true},
+		{name: "port out of range", rewrite: RewriteConfig{Port: 3000, ToPort: 70000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ProjectConfig{Output: ".env", Rewrites: []RewriteConfig{tt.rewrite}}
+			if tt.wantErr {
+				assert.Error(t, config.Validate())
+			} else {
+				assert.NoError(t, config.Validate())
+			}
+		})
+	}
+}
+
+func TestProjectConfig_URLHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ProjectConfig
+		want   string
+	}{
+		{name: "default", config: ProjectConfig{}, want: "192.168.1.10"},
+		{name: "lan", config: ProjectConfig{AddressMode: AddressModeLAN}, want: "192.168.1.10"},
+		{name: "hostname", config: ProjectConfig{Hostname: "myapp.lan"}, want: "myapp.lan"},
+		{name: "android emulator", config: ProjectConfig{AddressMode: AddressModeAndroidEmulator, Hostname: "myapp.lan"}, want: "10.0.2.2"},
+		{name: "genymotion", config: ProjectConfig{AddressMode: AddressModeGenymotion}, want: "10.0.3.2"},
+		{name: "ios simulator", config: ProjectConfig{AddressMode: AddressModeIOSSimulator}, want: "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.URLHost("192.168.1.10"))
+		})
+	}
+
+	config := &ProjectConfig{Output: ".env", AddressMode: "emulator"}
+	assert.ErrorContains(t, config.Validate(), "invalid address_mode")
+	config.AddressMode = AddressModeIOSSimulator
+	assert.NoError(t, config.Validate())
+}
+
+func TestProjectConfig_Validate_OriginsVar(t *testing.T) {
+	config := &ProjectConfig{Output: ".env", OriginsVar: "LANUP_ALLOWED_ORIGINS"}
+	assert.NoError(t, config.Validate())
+
+	config.OriginsVar = "ALLOWED-ORIGINS"
+	assert.ErrorContains(t, config.Validate(), "invalid allowed_origins_var")
+
+	config.OriginsVar = "CORS_ORIGINS"
+	config.Vars = map[string]string{"CORS_ORIGINS": "http://localhost:3000"}
+	assert.ErrorContains(t, config.Validate(), "also the allowed_origins_var")
+}
+
+func TestCertConfig(t *testing.T) {
+	cert := CertConfig{Hosts: []string{"myapp.lan", "10.0.0.5"}, CertVar: "SSL_CRT_FILE", KeyVar: "SSL_KEY_FILE"}
+	assert.NoError(t, cert.Validate())
+	assert.True(t, cert.Enabled())
+	assert.Equal(t, map[string]string{"SSL_CRT_FILE": "lanup.pem", "SSL_KEY_FILE": "lanup-key.pem"},
+		cert.Vars("lanup.pem", "lanup-key.pem", ""))
+
+	assert.False(t, CertConfig{Hosts: []string{"myapp.lan"}}.Enabled())
+	assert.ErrorContains(t, CertConfig{Hosts: []string{"my app"}}.Validate(), "invalid cert host")
+	assert.ErrorContains(t, CertConfig{CertVar: "SSL-CRT"}.Validate(), "invalid cert variable")
+}
+
+func TestProjectConfig_OutputPath_Remote(t *testing.T) {
+	cfg := &ProjectConfig{Output: "ssh://pi@raspberrypi.local/srv/api/.env", path: filepath.Join("project", ".lanup.yaml")}
+	assert.Equal(t, "ssh://pi@raspberrypi.local/srv/api/.env", cfg.OutputPath())
+}
+
+func TestNotificationConfig(t *testing.T) {
+	assert.Equal(t, NotificationSlack, NotificationConfig{URL: "https://hooks.slack.com/services/T0/B0/x"}.Kind())
+	assert.Equal(t, NotificationDiscord, NotificationConfig{URL: "https://discord.com/api/webhooks/1/x"}.Kind())
+	assert.Equal(t, NotificationWebhook, NotificationConfig{URL: "https://discord.com/channels/1"}.Kind())
+	assert.Equal(t, NotificationWebhook, NotificationConfig{URL: "${WEBHOOK}"}.Kind())
+	assert.Equal(t, NotificationSlack, NotificationConfig{URL: "${WEBHOOK}", Type: "slack"}.Kind())
+
+	assert.True(t, NotificationConfig{}.Posts("on_change"))
+	assert.False(t, NotificationConfig{}.Posts("on_start"))
+	assert.True(t, NotificationConfig{Events: []string{"on_start", "on_stop"}}.Posts("on_stop"))
+
+	assert.NoError(t, NotificationConfig{URL: "${SLACK_WEBHOOK_URL}"}.Validate())
+	assert.NoError(t, NotificationConfig{URL: "https://example.com/hook", Type: "webhook", Events: []string{"on_start"}}.Validate())
+	assert.ErrorContains(t, NotificationConfig{}.Validate(), "must define a url")
+	assert.ErrorContains(t, NotificationConfig{URL: "ftp://example.com"}.Validate(), "invalid notification url")
+	assert.ErrorContains(t, NotificationConfig{URL: "https://example.com", Type: "teams"}.Validate(), "invalid notification type")
+	assert.ErrorContains(t, NotificationConfig{URL: "https://example.com", Events: []string{"on_crash"}}.Validate(), "invalid notification event")
+}
+
 func TestGlobalConfig_Validate_TildeExpansion(t *testing.T) {
 	config := &GlobalConfig{
 		LogPath:       "~/.lanup/logs/lanup.log",
@@ -423,3 +725,443 @@ func TestLoadProjectConfig_EmptyPath(t *testing.T) {
 	assert.Equal(t, testConfig.Vars, loadedConfig.Vars)
 	assert.Equal(t, testConfig.Output, loadedConfig.Output)
 }
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	frontend := filepath.Join(root, "apps", "frontend")
+	require.NoError(t, os.MkdirAll(frontend, 0755))
+
+	// No configuration up to the git root
+	_, err := FindProjectConfig(frontend)
+	assert.ErrorIs(t, err, ErrProjectConfigNotFound)
+
+	// The nearest configuration is found from a subdirectory
+	rootConfig := filepath.Join(root, ".lanup.yaml")
+	require.NoError(t, SaveProjectConfig(rootConfig, GetDefaultProjectConfig()))
+	path, err := FindProjectConfig(frontend)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(frontend, "..", "..", ".lanup.yaml"), path)
+
+	// A relative directory gives a relative path
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(frontend))
+	path, err = FindProjectConfig(".")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "..", ".lanup.yaml"), path)
+
+	// A configuration closer to the directory wins
+	require.NoError(t, SaveProjectConfig(filepath.Join(frontend, ".lanup.yaml"), GetDefaultProjectConfig()))
+	path, err = FindProjectConfig(".")
+	require.NoError(t, err)
+	assert.Equal(t, ".lanup.yaml", path)
+}
+
+func TestFindProjectConfig_StopsAtGitRoot(t *testing.T) {
+	parent := t.TempDir()
+	require.NoError(t, SaveProjectConfig(filepath.Join(parent, ".lanup.yaml"), GetDefaultProjectConfig()))
+
+	// The repository below has no configuration, the one of the parent directory isn't used
+	repo := filepath.Join(parent, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, "src"), 0755))
+
+	_, err := FindProjectConfig(filepath.Join(repo, "src"))
+	assert.ErrorIs(t, err, ErrProjectConfigNotFound)
+}
+
+func TestLoadProjectConfig_OutputRelativeToConfig(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	frontend := filepath.Join(root, "frontend")
+	require.NoError(t, os.Mkdir(frontend, 0755))
+
+	cfg := GetDefaultProjectConfig()
+	cfg.Output = "frontend/.env.local"
+	cfg.Profiles = map[string]ProfileConfig{"mobile": {Output: ".env.mobile"}}
+	require.NoError(t, SaveProjectConfig(filepath.Join(root, ".lanup.yaml"), cfg))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(frontend))
+
+	loaded, err := LoadProjectConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", ".lanup.yaml"), loaded.Path())
+	assert.Equal(t, "..", loaded.Dir())
+	assert.Equal(t, "frontend/.env.local", loaded.Output, "the configured value is kept")
+	assert.Equal(t, filepath.Join("..", "frontend", ".env.local"), loaded.OutputPath())
+
+	mobile, err := LoadProjectConfigProfile("", "mobile")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", ".env.mobile"), mobile.OutputPath())
+
+	// Configurations built in code keep their output as is
+	assert.Equal(t, ".env.local", GetDefaultProjectConfig().OutputPath())
+}
+
+func TestSaveAndLoadProjectConfig_TOML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".lanup.toml")
+
+	testConfig := GetDefaultProjectConfig()
+	testConfig.Detectors = []DetectorConfig{{Name: "api", Command: "./detect.sh", Timeout: 5}}
+	testConfig.Profiles = map[string]ProfileConfig{"mobile": {Output: ".env.mobile"}}
+	require.NoError(t, SaveProjectConfig(configPath, testConfig))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `output = ".env.local"`)
+	assert.Contains(t, string(data), "[[detectors]]")
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, testConfig.Vars, loaded.Vars)
+	assert.Equal(t, testConfig.Output, loaded.Output)
+	assert.Equal(t, testConfig.AutoDetect, loaded.AutoDetect)
+	assert.Equal(t, testConfig.Detectors, loaded.Detectors)
+	assert.Equal(t, testConfig.Profiles, loaded.Profiles)
+}
+
+func TestLoadProjectConfig_TOML(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".lanup.toml")
+	data := `output = ".env"
+hostname = "myapp.lan"
+
+[vars]
+API_URL = "http://localhost:8000"
+
+[auto_detect]
+docker = true
+supabase = false
+
+[profiles.mobile.vars]
+API_URL = "http://localhost:9000"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, ".env", loaded.Output)
+	assert.Equal(t, "myapp.lan", loaded.Hostname)
+	assert.Equal(t, "http://localhost:8000", loaded.Vars["API_URL"])
+	assert.True(t, loaded.AutoDetect.Docker)
+	assert.Equal(t, "http://localhost:9000", loaded.Profiles["mobile"].Vars["API_URL"])
+
+	// Validation is shared with YAML
+	require.NoError(t, os.WriteFile(configPath, []byte("output = \"\"\n"), 0644))
+	_, err = LoadProjectConfig(configPath)
+	assert.ErrorContains(t, err, "invalid project configuration")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("output = \n"), 0644))
+	_, err = LoadProjectConfig(configPath)
+	assert.ErrorContains(t, err, "failed to parse")
+
+	// .lanup.toml is found like .lanup.yaml
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".git"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "src"), 0755))
+	path, err := FindProjectConfig(filepath.Join(tmpDir, "src"))
+	require.NoError(t, err)
+	assert.Equal(t, ".lanup.toml", filepath.Base(path))
+}
+
+func TestLoadProjectConfig_VarOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".lanup.yaml")
+	data := `output: .env.local
+vars:
+  API_URL: {url: http://localhost:8000, scheme: https, port: 8443}
+  WEB_URL: http://localhost:3000
+  DOCKER_HOST:
+    - {url: "npipe:////./pipe/docker_engine", when: os == windows}
+    - {url: "unix:///var/run/docker.sock"}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"API_URL":     "http://localhost:8000",
+		"WEB_URL":     "http://localhost:3000",
+		"DOCKER_HOST": "npipe:////./pipe/docker_engine",
+	}, loaded.Vars)
+	assert.Equal(t, map[string]VarOptions{
+		"API_URL":     {Scheme: "https", Port: 8443},
+		"DOCKER_HOST": {When: "os == windows", Else: []VarCase{{URL: "unix:///var/run/docker.sock"}}},
+	}, loaded.VarOptions)
+
+	// The options are written back in every format
+	for _, name := range ProjectConfigFiles {
+		path := filepath.Join(tmpDir, "saved", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, SaveProjectConfig(path, loaded))
+
+		saved, err := LoadProjectConfig(path)
+		require.NoError(t, err, name)
+		assert.Equal(t, loaded.Vars, saved.Vars, name)
+		assert.Equal(t, loaded.VarOptions, saved.VarOptions, name)
+	}
+
+	// Options are validated
+	invalid := "output: .env.local\nvars:\n  API_URL: {url: http://localhost:8000, port: 70000}\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(invalid), 0644))
+	_, err = LoadProjectConfig(configPath)
+	assert.ErrorContains(t, err, "API_URL")
+}
+
+func TestLoadProjectConfig_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	shared := filepath.Join(tmpDir, "shared")
+	pkg := filepath.Join(tmpDir, "packages", "web")
+	require.NoError(t, os.MkdirAll(shared, 0755))
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "lanup.base.toml"), []byte(`output = ".env.local"
+
+[vars]
+API_URL = "http://localhost:8000"
+
+[auto_detect]
+docker = true
+supabase = true
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "lanup.ports.yaml"), []byte(`vars:
+  API_URL: {url: http://localhost:8000, port: 8443}
+  WS_URL: ws://localhost:8080
+`), 0644))
+	configPath := filepath.Join(pkg, ".lanup.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`extends:
+  - ../../shared/lanup.base.toml
+  - ../../shared/lanup.ports.yaml
+output: .env
+vars:
+  WEB_URL: http://localhost:3000
+auto_detect:
+  supabase: false
+`), 0644))
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, ".env", loaded.Output)
+	assert.Equal(t, filepath.Join(pkg, ".env"), loaded.OutputPath())
+	assert.Equal(t, map[string]string{
+		"API_URL": "http://localhost:8000",
+		"WS_URL":  "ws://localhost:8080",
+		"WEB_URL": "http://localhost:3000",
+	}, loaded.Vars)
+	assert.Equal(t, VarOptions{Port: 8443}, loaded.VarOptions["API_URL"])
+	assert.Equal(t, AutoDetectConfig{Docker: true}, loaded.AutoDetect)
+
+	// The merged configuration is what validate checks
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, CheckProjectConfigFile(configPath, data))
+}
+
+func TestLoadProjectConfig_ExtendsErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a.yaml")
+	b := filepath.Join(tmpDir, "b.yaml")
+	require.NoError(t, os.WriteFile(a, []byte("extends: b.yaml\noutput: .env\n"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("extends: a.yaml\n"), 0644))
+
+	_, err := LoadProjectConfig(a)
+	assert.ErrorContains(t, err, "extends cycle")
+
+	missing := filepath.Join(tmpDir, ".lanup.yaml")
+	require.NoError(t, os.WriteFile(missing, []byte("extends: shared.yaml\n"), 0644))
+	_, err = LoadProjectConfig(missing)
+	assert.ErrorContains(t, err, "shared.yaml")
+
+	data, err := os.ReadFile(missing)
+	require.NoError(t, err)
+	issues := CheckProjectConfigFile(missing, data)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "shared.yaml")
+}
+
+func TestSaveAndLoadProjectConfig_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".lanup.json")
+
+	testConfig := GetDefaultProjectConfig()
+	testConfig.Schema = SchemaURL
+	testConfig.Detectors = []DetectorConfig{{Name: "api", Command: "./detect.sh"}}
+	require.NoError(t, SaveProjectConfig(configPath, testConfig))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, SchemaURL, raw["$schema"])
+	assert.Equal(t, ".env.local", raw["output"])
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, testConfig.Vars, loaded.Vars)
+	assert.Equal(t, testConfig.AutoDetect, loaded.AutoDetect)
+	assert.Equal(t, testConfig.Detectors, loaded.Detectors)
+
+	// Validation is shared with YAML
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"output": ""}`), 0644))
+	_, err = LoadProjectConfig(configPath)
+	assert.ErrorContains(t, err, "invalid project configuration")
+
+	// .lanup.json is found like .lanup.yaml
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".git"), 0755))
+	path, err := FindProjectConfig(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, ".lanup.json", filepath.Base(path))
+}
+
+func TestLoadProjectConfig_GlobalDefaults(t *testing.T) {
+	disabled := false
+	SetProjectDefaults(ProjectDefaults{
+		Output:     ".env.development",
+		VarPrefix:  "VITE_",
+		AutoDetect: AutoDetectOverrides{Docker: &disabled},
+		Detectors: []DetectorConfig{
+			{Name: "vault", Command: "./vault-env.sh"},
+			{Name: "api", Command: "./default-api.sh"},
+		},
+	})
+	defer SetProjectDefaults(ProjectDefaults{})
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".lanup.yaml")
+
+	// A configuration relying on the defaults
+	require.NoError(t, os.WriteFile(configPath, []byte(`vars:
+  API_URL: http://localhost:8000
+auto_detect:
+  supabase: true
+detectors:
+  - name: api
+    command: ./api.sh
+`), 0644))
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, ".env.development", loaded.Output)
+	assert.Equal(t, "VITE_", loaded.VarPrefix)
+	assert.False(t, loaded.AutoDetect.Docker)
+	assert.True(t, loaded.AutoDetect.Supabase)
+	assert.Equal(t, []DetectorConfig{
+		{Name: "api", Command: "./api.sh"}, // the project's own detector wins
+		{Name: "vault", Command: "./vault-env.sh"},
+	}, loaded.Detectors)
+
+	// Settings of the file take precedence
+	require.NoError(t, os.WriteFile(configPath, []byte(`output: .env.local
+var_prefix: NEXT_PUBLIC_
+auto_detect:
+  docker: true
+`), 0644))
+	loaded, err = LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, ".env.local", loaded.Output)
+	assert.Equal(t, "NEXT_PUBLIC_", loaded.VarPrefix)
+	assert.True(t, loaded.AutoDetect.Docker)
+
+	// New configurations start from the defaults
+	defaults := GetDefaultProjectConfig()
+	assert.Equal(t, ".env.development", defaults.Output)
+	assert.Equal(t, "VITE_", defaults.VarPrefix)
+	assert.False(t, defaults.AutoDetect.Docker)
+	assert.True(t, defaults.AutoDetect.Expo)
+	assert.Len(t, defaults.Detectors, 2)
+}
+
+func TestUpdateProjectConfig(t *testing.T) {
+	SetProjectDefaults(ProjectDefaults{Output: ".env.development", Detectors: []DetectorConfig{{Name: "vault", Command: "./vault-env.sh"}}})
+	defer SetProjectDefaults(ProjectDefaults{})
+
+	configPath := filepath.Join(t.TempDir(), ".lanup.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("vars:\n  API_URL: http://localhost:8000\n"), 0644))
+
+	require.NoError(t, UpdateProjectConfig(configPath, func(c *ProjectConfig) {
+		c.Hostname = "myapp.lan"
+	}))
+
+	// The defaults still apply but are not written to the file
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "hostname: myapp.lan")
+	assert.NotContains(t, string(data), ".env.development")
+	assert.NotContains(t, string(data), "vault")
+
+	loaded, err := LoadProjectConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "myapp.lan", loaded.Hostname)
+	assert.Equal(t, ".env.development", loaded.Output)
+
+	// Invalid changes are not saved
+	err = UpdateProjectConfig(configPath, func(c *ProjectConfig) {
+		c.Hostname = "not a hostname"
+	})
+	assert.Error(t, err)
+	data, err = os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "hostname: myapp.lan")
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"2s", 2 * time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"3", 3 * time.Second, false},
+		{"1s", time.Second, false},
+		{"500ms", 0, true},
+		{"0", 0, true},
+		{"-1s", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseInterval(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestLoadProjectConfig_CheckInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, ".lanup.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte("vars:\n  API_URL: http://localhost:8000\noutput: .env.local\ncheck_interval: 3\n"), 0644))
+	cfg, err := LoadProjectConfig(path)
+	require.NoError(t, err)
+	interval, err := cfg.WatchInterval()
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, interval)
+
+	require.NoError(t, os.WriteFile(path, []byte("vars:\n  API_URL: http://localhost:8000\noutput: .env.local\ncheck_interval: soon\n"), 0644))
+	_, err = LoadProjectConfig(path)
+	assert.Error(t, err)
+}
+
+func TestProjectConfig_PrefixKey(t *testing.T) {
+	cfg := &ProjectConfig{VarPrefix: "NEXT_PUBLIC_"}
+	assert.Equal(t, "NEXT_PUBLIC_SUPABASE_URL", cfg.PrefixKey("SUPABASE_URL"))
+	assert.Equal(t, "NEXT_PUBLIC_API_URL", cfg.PrefixKey("NEXT_PUBLIC_API_URL"))
+	assert.Equal(t, "API_URL", (&ProjectConfig{}).PrefixKey("API_URL"))
+}
+
+func TestServeConfig_Validate(t *testing.T) {
+	valid := ServeConfig{Auth: "admin:s3cret", Allow: []string{"192.168.1.0/24", "10.0.0.7", "fd00::/8"}}
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, ServeConfig{Auth: "admin:${SERVE_PASSWORD}"}.Validate())
+
+	assert.ErrorContains(t, ServeConfig{Auth: "admin"}.Validate(), "serve.auth must be written user:password")
+	assert.ErrorContains(t, ServeConfig{Allow: []string{"192.168.1"}}.Validate(), "invalid serve.allow entry: 192.168.1")
+	assert.ErrorContains(t, ServeConfig{Port: 70000}.Validate(), "serve.port must be between 1 and 65535")
+}
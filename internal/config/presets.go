@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Preset is a starting configuration for a framework, used by 'lanup init --preset'
+type Preset struct {
+	Name   string
+	Title  string
+	Config func() *ProjectConfig
+	Hints  []string // printed after the configuration is created
+}
+
+// presets lists the available presets by name
+var presets = map[string]Preset{
+	"nextjs": {
+		Name:  "nextjs",
+		Title: "Next.js",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"NEXT_PUBLIC_API_URL": "http://localhost:8000",
+					"NEXT_PUBLIC_APP_URL": "http://localhost:3000",
+				},
+				Output:     ".env.local",
+				AutoDetect: AutoDetectConfig{Docker: true, DevServers: true},
+			}
+		},
+		Hints: []string{
+			"Run 'next dev -H 0.0.0.0' so that other devices can reach the dev server",
+			"NEXT_PUBLIC_ variables are inlined when Next.js starts: restart 'next dev' after 'lanup start'",
+		},
+	},
+	"vite": {
+		Name:  "vite",
+		Title: "Vite",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"VITE_API_URL": "http://localhost:8000",
+					"VITE_APP_URL": "http://localhost:5173",
+				},
+				Output:     ".env.local",
+				AutoDetect: AutoDetectConfig{Docker: true, DevServers: true},
+			}
+		},
+		Hints: []string{
+			"Run 'vite --host' (or set server.host: true in vite.config) to listen on the LAN",
+			"Add your hostname to server.allowedHosts when using 'lanup hosts'",
+		},
+	},
+	"expo": {
+		Name:  "expo",
+		Title: "Expo",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"EXPO_PUBLIC_API_URL": "http://localhost:8000",
+				},
+				Output:     ".env",
+				AutoDetect: AutoDetectConfig{Docker: true, DevServers: true, Expo: true},
+			}
+		},
+		Hints: []string{
+			"Run 'npx expo start --lan' so that Expo Go connects through your LAN IP",
+			"EXPO_PUBLIC_ variables are read when Metro starts: restart it after 'lanup start'",
+		},
+	},
+	"flutter": {
+		Name:  "flutter",
+		Title: "Flutter",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"API_URL": "http://localhost:8000",
+				},
+				Output:     "dart_defines.json",
+				Format:     "dart-define",
+				AutoDetect: AutoDetectConfig{Docker: true, Supabase: true},
+			}
+		},
+		Hints: []string{
+			"Run 'flutter run --dart-define-from-file=dart_defines.json' and read the URLs with String.fromEnvironment",
+			"Dart defines are compiled into the app: run 'flutter run' again after 'lanup start', a hot restart keeps the old ones",
+		},
+	},
+	"laravel": {
+		Name:  "laravel",
+		Title: "Laravel",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"APP_URL":   "http://localhost:8000",
+					"ASSET_URL": "http://localhost:8000",
+				},
+				Output:     ".env",
+				AutoDetect: AutoDetectConfig{Docker: true, DevServers: true},
+			}
+		},
+		Hints: []string{
+			"Run 'php artisan serve --host=0.0.0.0' so that other devices can reach the app",
+			"With Vite, set server.host to '0.0.0.0' and server.hmr.host to your LAN IP in vite.config.js",
+		},
+	},
+	"supabase": {
+		Name:  "supabase",
+		Title: "Supabase",
+		Config: func() *ProjectConfig {
+			return &ProjectConfig{
+				Vars: map[string]string{
+					"SUPABASE_URL": "http://localhost:54321",
+				},
+				Output:     ".env.local",
+				AutoDetect: AutoDetectConfig{Docker: true, Supabase: true},
+			}
+		},
+		Hints: []string{
+			"Run 'supabase start' before 'lanup start': the API URL and keys are detected automatically",
+			"Supabase Studio is served on port 54323 of your LAN IP",
+		},
+	},
+}
+
+// PresetNames returns the names of the available presets, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetPreset returns the preset with the given name
+func GetPreset(name string) (Preset, error) {
+	preset, ok := presets[strings.ToLower(name)]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset: %s (available: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	return preset, nil
+}
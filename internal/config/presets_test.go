@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets_Valid(t *testing.T) {
+	assert.Equal(t, []string{"expo", "flutter", "laravel", "nextjs", "supabase", "vite"}, PresetNames())
+
+	for _, name := range PresetNames() {
+		t.Run(name, func(t *testing.T) {
+			preset, err := GetPreset(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, preset.Name)
+			assert.NotEmpty(t, preset.Title)
+			assert.NotEmpty(t, preset.Hints)
+
+			cfg := preset.Config()
+			require.NoError(t, cfg.Validate())
+			assert.NotEmpty(t, cfg.Vars)
+		})
+	}
+}
+
+func TestGetPreset(t *testing.T) {
+	preset, err := GetPreset("NextJS")
+	require.NoError(t, err)
+	assert.Contains(t, preset.Config().Vars, "NEXT_PUBLIC_API_URL")
+
+	// Each call returns a fresh configuration
+	cfg := preset.Config()
+	cfg.Vars["EXTRA"] = "value"
+	assert.NotContains(t, preset.Config().Vars, "EXTRA")
+
+	_, err = GetPreset("rails")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: expo, flutter, laravel, nextjs, supabase, vite")
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile used when none is selected
+// Unless a profile with this name is defined, it is the base configuration itself.
+const DefaultProfile = "default"
+
+// ProfileNames returns the names of the defined profiles, sorted
+func (c *ProjectConfig) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns a copy of the configuration with the named profile applied
+// Profile vars and static vars are merged over the base ones, profile detectors are added to the base
+// detectors, and other settings replace the base ones when set.
+func (c *ProjectConfig) WithProfile(name string) (*ProjectConfig, error) {
+	result := c.clone()
+
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if name == DefaultProfile {
+			return result, nil
+		}
+		return nil, fmt.Errorf("unknown profile: %s (available: %s)", name, c.availableProfiles())
+	}
+
+	// A variable keeps the section of the profile that sets it
+	for key, value := range profile.Vars {
+		result.Vars[key] = value
+		delete(result.StaticVars, key)
+		delete(result.VarOptions, key)
+	}
+	for key, opts := range profile.VarOptions {
+		if result.VarOptions == nil {
+			result.VarOptions = make(map[string]VarOptions)
+		}
+		result.VarOptions[key] = opts
+	}
+	for key, value := range profile.StaticVars {
+		if result.StaticVars == nil {
+			result.StaticVars = make(map[string]string)
+		}
+		result.StaticVars[key] = value
+		delete(result.Vars, key)
+		delete(result.VarOptions, key)
+	}
+	if profile.Output != "" {
+		result.Output = profile.Output
+	}
+	if profile.Hostname != "" {
+		result.Hostname = profile.Hostname
+	}
+	if profile.IP != "" {
+		result.IP = profile.IP
+	}
+	if profile.AddressMode != "" {
+		result.AddressMode = profile.AddressMode
+	}
+	result.Detectors = append(result.Detectors, profile.Detectors...)
+
+	overrides := profile.AutoDetect
+	applyOverride(&result.AutoDetect.Docker, overrides.Docker)
+	applyOverride(&result.AutoDetect.Supabase, overrides.Supabase)
+	applyOverride(&result.AutoDetect.DevServers, overrides.DevServers)
+	applyOverride(&result.AutoDetect.Expo, overrides.Expo)
+
+	return result, nil
+}
+
+// LoadProjectConfigProfile reads the project configuration and applies the named profile
+func LoadProjectConfigProfile(path, profile string) (*ProjectConfig, error) {
+	config, err := LoadProjectConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.WithProfile(profile)
+}
+
+// clone returns a copy of the configuration that shares no maps or slices with the original
+func (c *ProjectConfig) clone() *ProjectConfig {
+	result := *c
+
+	result.Vars = make(map[string]string, len(c.Vars))
+	for key, value := range c.Vars {
+		result.Vars[key] = value
+	}
+	if c.VarOptions != nil {
+		result.VarOptions = make(map[string]VarOptions, len(c.VarOptions))
+		for key, opts := range c.VarOptions {
+			result.VarOptions[key] = opts
+		}
+	}
+	if c.StaticVars != nil {
+		result.StaticVars = make(map[string]string, len(c.StaticVars))
+		for key, value := range c.StaticVars {
+			result.StaticVars[key] = value
+		}
+	}
+	result.Rewrites = append([]RewriteConfig(nil), c.Rewrites...)
+	result.Templates = append([]TemplateConfig(nil), c.Templates...)
+	result.Files = append([]string(nil), c.Files...)
+	result.Notifications = append([]NotificationConfig(nil), c.Notifications...)
+	result.Detectors = append([]DetectorConfig(nil), c.Detectors...)
+	result.Serve.Routes = append([]RouteConfig(nil), c.Serve.Routes...)
+	result.Serve.Allow = append([]string(nil), c.Serve.Allow...)
+
+	return &result
+}
+
+// availableProfiles returns the profile names for error messages
+func (c *ProjectConfig) availableProfiles() string {
+	if len(c.Profiles) == 0 {
+		return "none defined"
+	}
+	return strings.Join(c.ProfileNames(), ", ")
+}
+
+// applyOverride replaces target with the override value when one is set
+func applyOverride(target *bool, override *bool) {
+	if override != nil {
+		*target = *override
+	}
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func profileTestConfig() *ProjectConfig {
+	return &ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+			"WEB_URL": "http://localhost:3000",
+		},
+		Output:     ".env.local",
+		AutoDetect: AutoDetectConfig{Docker: true, Supabase: true, DevServers: true, Expo: false},
+		Detectors:  []DetectorConfig{{Name: "rails", Command: "./rails.sh"}},
+		Profiles: map[string]ProfileConfig{
+			"mobile": {
+				Vars:       map[string]string{"API_URL": "http://localhost:9000", "METRO_URL": "http://localhost:8081"},
+				Output:     ".env.mobile",
+				AutoDetect: AutoDetectOverrides{Docker: boolPtr(false), Expo: boolPtr(true)},
+				Detectors:  []DetectorConfig{{Name: "tunnel", Command: "./tunnel.sh"}},
+			},
+			"demo": {
+				Hostname: "demo.lan",
+			},
+		},
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	base := profileTestConfig()
+
+	mobile, err := base.WithProfile("mobile")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"API_URL":   "http://localhost:9000",
+		"WEB_URL":   "http://localhost:3000",
+		"METRO_URL": "http://localhost:8081",
+	}, mobile.Vars)
+	assert.Equal(t, ".env.mobile", mobile.Output)
+	assert.Equal(t, AutoDetectConfig{Docker: false, Supabase: true, DevServers: true, Expo: true}, mobile.AutoDetect)
+	assert.Len(t, mobile.Detectors, 2)
+
+	// The base configuration is not modified
+	assert.Equal(t, "http://localhost:8000", base.Vars["API_URL"])
+	assert.Equal(t, ".env.local", base.Output)
+	assert.True(t, base.AutoDetect.Docker)
+	assert.Len(t, base.Detectors, 1)
+
+	demo, err := base.WithProfile("demo")
+	require.NoError(t, err)
+	assert.Equal(t, "demo.lan", demo.Hostname)
+	assert.Equal(t, ".env.local", demo.Output)
+
+	base.Profiles["emulator"] = ProfileConfig{AddressMode: AddressModeAndroidEmulator, Output: ".env.emulator"}
+	emulator, err := base.WithProfile("emulator")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.2.2", emulator.URLHost("192.168.1.10"))
+	assert.Empty(t, base.AddressMode)
+}
+
+func TestWithProfile_Default(t *testing.T) {
+	base := profileTestConfig()
+
+	// Without a "default" profile, the default is the base configuration
+	for _, name := range []string{"", DefaultProfile} {
+		cfg, err := base.WithProfile(name)
+		require.NoError(t, err)
+		assert.Equal(t, base.Vars, cfg.Vars)
+		assert.Equal(t, base.Output, cfg.Output)
+	}
+
+	// A "default" profile is applied when no profile is selected
+	base.Profiles[DefaultProfile] = ProfileConfig{Output: ".env.default"}
+	cfg, err := base.WithProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, ".env.default", cfg.Output)
+}
+
+func TestWithProfile_StaticVars(t *testing.T) {
+	base := &ProjectConfig{
+		Vars:       map[string]string{"API_URL": "http://localhost:8000"},
+		StaticVars: map[string]string{"API_KEY": "localhost-dev-key"},
+		Output:     ".env",
+		Profiles: map[string]ProfileConfig{
+			"demo": {
+				Vars:       map[string]string{"API_KEY": "http://localhost:9000"},
+				StaticVars: map[string]string{"API_URL": "https://api.example.com"},
+			},
+		},
+	}
+	require.NoError(t, base.Validate())
+
+	// A variable moves to the section of the profile that sets it
+	demo, err := base.WithProfile("demo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "http://localhost:9000"}, demo.Vars)
+	assert.Equal(t, map[string]string{"API_URL": "https://api.example.com"}, demo.StaticVars)
+	assert.Equal(t, "localhost-dev-key", base.StaticVars["API_KEY"])
+
+	// The same variable cannot be in both sections
+	base.StaticVars["API_URL"] = "http://localhost:8000"
+	assert.Error(t, base.Validate())
+}
+
+func TestWithProfile_Unknown(t *testing.T) {
+	_, err := profileTestConfig().WithProfile("staging")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "demo, mobile")
+}
+
+func TestProjectConfig_Validate_Profiles(t *testing.T) {
+	cfg := profileTestConfig()
+	assert.NoError(t, cfg.Validate())
+
+	// A profile detector clashing with a base detector is invalid
+	cfg.Profiles["broken"] = ProfileConfig{Detectors: []DetectorConfig{{Name: "rails", Command: "./other.sh"}}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestLoadProjectConfigProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanup.yaml")
+	content := `vars:
+  API_URL: http://localhost:8000
+output: .env.local
+auto_detect:
+  docker: true
+  supabase: true
+profiles:
+  mobile:
+    output: .env.mobile
+    auto_detect:
+      docker: false
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := LoadProjectConfigProfile(path, "mobile")
+	require.NoError(t, err)
+	assert.Equal(t, ".env.mobile", cfg.Output)
+	assert.False(t, cfg.AutoDetect.Docker)
+	assert.True(t, cfg.AutoDetect.Supabase)
+
+	_, err = LoadProjectConfigProfile(path, "unknown")
+	assert.Error(t, err)
+}
+
+func TestEnableDetector(t *testing.T) {
+	cfg := &ProjectConfig{
+		AutoDetect: AutoDetectConfig{Docker: false, Supabase: true},
+		Detectors:  []DetectorConfig{{Name: "rails", Command: "./rails.sh"}, {Name: "django", Command: "./django.sh"}},
+		Plugins:    PluginsConfig{Detectors: []string{"k8s"}},
+	}
+
+	require.NoError(t, cfg.EnableDetector("docker", true))
+	require.NoError(t, cfg.EnableDetector("supabase", false))
+	require.NoError(t, cfg.EnableDetector("rails", false))
+	require.NoError(t, cfg.EnableDetector("k8s", true))
+
+	assert.Equal(t, AutoDetectConfig{Docker: true}, cfg.AutoDetect)
+	assert.Equal(t, []DetectorConfig{{Name: "django", Command: "./django.sh"}}, cfg.Detectors)
+	assert.Equal(t, []string{"k8s"}, cfg.Plugins.Detectors)
+
+	assert.Error(t, cfg.EnableDetector("kubernetes", true))
+
+	require.NoError(t, cfg.EnableDetector(AllDetectors, true))
+	assert.Equal(t, AutoDetectConfig{Docker: true, Supabase: true, DevServers: true, Expo: true}, cfg.AutoDetect)
+
+	require.NoError(t, cfg.EnableDetector(AllDetectors, false))
+	assert.Equal(t, AutoDetectConfig{}, cfg.AutoDetect)
+	assert.Empty(t, cfg.Detectors)
+	assert.Empty(t, cfg.Plugins.Detectors)
+}
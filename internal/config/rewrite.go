@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/raucheacho/lanup/internal/hosts"
+)
+
+// HostPlaceholder stands for the LAN host (the IP or the project hostname) in rewrite replacements
+const HostPlaceholder = "{host}"
+
+// RewriteConfig is a rule rewriting the variable values before localhost is replaced
+// A rule is either a regular expression (match and replace) or a host and/or port mapping:
+// host is replaced with the LAN host, port with to_port. A port mapping without host applies to any host.
+type RewriteConfig struct {
+	Match   string `yaml:"match,omitempty"`   // regular expression
+	Replace string `yaml:"replace,omitempty"` // replacement of match, ${1} for groups and {host} for the LAN host
+	Host    string `yaml:"host,omitempty"`    // e.g. host.docker.internal
+	Port    int    `yaml:"port,omitempty"`
+	ToPort  int    `yaml:"to_port,omitempty"`
+}
+
+// Validate checks if the RewriteConfig has valid values
+func (r RewriteConfig) Validate() error {
+	switch {
+	case r.Match != "":
+		if r.Host != "" || r.Port != 0 || r.ToPort != 0 {
+			return fmt.Errorf("rewrite %s cannot set both match and host or port", r.Match)
+		}
+		if _, err := regexp.Compile(r.Match); err != nil {
+			return fmt.Errorf("invalid rewrite match %s: %w", r.Match, err)
+		}
+		return nil
+	case r.Replace != "":
+		return fmt.Errorf("rewrite replace %s requires match", r.Replace)
+	case r.Host == "" && r.Port == 0:
+		return fmt.Errorf("rewrite must define match, host or port")
+	}
+
+	if r.Host != "" && !hosts.ValidHostname(r.Host) {
+		return fmt.Errorf("invalid rewrite host: %s", r.Host)
+	}
+	if (r.Port == 0) != (r.ToPort == 0) {
+		return fmt.Errorf("rewrite port and to_port must be set together")
+	}
+	for _, port := range []int{r.Port, r.ToPort} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("rewrite port must be between 1 and 65535, got %d", port)
+		}
+	}
+	return nil
+}
+
+// Compile returns the regular expression of the rule and its replacement template,
+// in which HostPlaceholder stands for the LAN host
+func (r RewriteConfig) Compile() (*regexp.Regexp, string, error) {
+	if r.Match != "" {
+		re, err := regexp.Compile(r.Match)
+		return re, r.Replace, err
+	}
+
+	// A host is matched whole, not as the end of a longer name
+	host, replacement := `([\w.\]-])`, "${1}"
+	if r.Host != "" {
+		host, replacement = `(^|[^\w.-])`+regexp.QuoteMeta(r.Host), "${1}"+HostPlaceholder
+	}
+	if r.Port == 0 {
+		return regexp.MustCompile(host + `([^\w.-]|$)`), replacement + "${2}", nil
+	}
+	return regexp.MustCompile(host + ":" + strconv.Itoa(r.Port) + `(\D|$)`),
+		replacement + ":" + strconv.Itoa(r.ToPort) + "${2}", nil
+}
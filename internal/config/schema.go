@@ -0,0 +1,433 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity tells whether an issue makes the configuration unusable
+type Severity int
+
+const (
+	// SeverityError is an invalid configuration that lanup refuses to load
+	SeverityError Severity = iota
+	// SeverityWarning is a valid but suspicious configuration
+	SeverityWarning
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue is a problem found in a configuration file
+type Issue struct {
+	Line     int // 0 when the issue is not tied to a line
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+// String formats the issue as LINE:COLUMN: severity: message
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+}
+
+// yamlLineRe extracts the line number from yaml parse errors
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// CheckProjectConfig checks .lanup.yaml content against the project configuration schema
+// It reports unknown keys, type errors, validation errors and suspicious values
+func CheckProjectConfig(data []byte) []Issue {
+	return checkProjectConfig(data, (*ProjectConfig).Validate)
+}
+
+// checkProjectConfig is CheckProjectConfig, validating the decoded configuration with validate
+func checkProjectConfig(data []byte, validate func(*ProjectConfig) error) []Issue {
+	var cfg ProjectConfig
+	issues := checkSchema(data, &cfg)
+	if !hasErrors(issues) {
+		if err := validate(&cfg); err != nil {
+			issues = append(issues, Issue{Severity: SeverityError, Message: err.Error()})
+		}
+	}
+
+	root := documentRoot(data)
+	issues = append(issues, checkVarURLs(lookupNode(root, "vars"), "vars")...)
+	if profiles := lookupNode(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := profiles.Content[i].Value
+			vars := lookupNode(profiles.Content[i+1], "vars")
+			issues = append(issues, checkVarURLs(vars, "profiles."+name+".vars")...)
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// CheckProjectConfigFile checks project configuration content in the format of its file, see CheckProjectConfig
+// Issues in a TOML file are not tied to a line: they are found in its YAML conversion.
+// A configuration that extends others is validated once merged with them.
+func CheckProjectConfigFile(path string, data []byte) []Issue {
+	converted := data
+	if isTOML(path) {
+		var err error
+		if converted, err = tomlToYAML(data); err != nil {
+			return []Issue{tomlParseIssue(err)}
+		}
+	}
+
+	validate := (*ProjectConfig).Validate
+	if extendsOthers(converted) {
+		validate = func(*ProjectConfig) error {
+			merged, err := decodeProjectConfig(path, data, ProjectDefaults{})
+			if err != nil {
+				return err
+			}
+			return merged.Validate()
+		}
+	}
+
+	issues := checkProjectConfig(converted, validate)
+	if isTOML(path) {
+		for i := range issues {
+			issues[i].Line, issues[i].Column = 0, 0
+		}
+	}
+	return issues
+}
+
+// tomlParseIssue converts a TOML parse error to an issue, with its line when known
+func tomlParseIssue(err error) Issue {
+	issue := Issue{Severity: SeverityError, Message: err.Error()}
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		issue.Line, issue.Column = parseErr.Position.Line, 1
+		if parseErr.Message != "" {
+			issue.Message = parseErr.Message
+		}
+	}
+	return issue
+}
+
+// CheckGlobalConfig checks config.yaml content against the global configuration schema
+func CheckGlobalConfig(data []byte) []Issue {
+	var cfg GlobalConfig
+	issues := checkSchema(data, &cfg)
+	if hasErrors(issues) {
+		return issues
+	}
+
+	if err := cfg.Validate(); err != nil {
+		issues = append(issues, Issue{Severity: SeverityError, Message: err.Error()})
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// checkSchema parses data, walks it against the type of out and decodes it into out
+func checkSchema(data []byte, out interface{}) []Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Issue{parseIssue(err)}
+	}
+
+	if len(doc.Content) == 0 {
+		return []Issue{{Severity: SeverityError, Message: "configuration file is empty"}}
+	}
+	root := doc.Content[0]
+
+	var issues []Issue
+	walkSchema(root, reflect.TypeOf(out).Elem(), "", &issues)
+	if len(issues) > 0 {
+		sortIssues(issues)
+		return issues
+	}
+
+	if err := root.Decode(out); err != nil {
+		return []Issue{parseIssue(err)}
+	}
+	return nil
+}
+
+// walkSchema reports the keys and values of node that don't match type t
+func walkSchema(node *yaml.Node, t reflect.Type, path string, issues *[]Issue) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(StringList(nil)) && node.Kind == yaml.ScalarNode {
+		t = reflect.TypeOf("")
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			*issues = append(*issues, typeIssue(node, path, "a mapping"))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				*issues = append(*issues, unknownKeyIssue(key, path, fields))
+				continue
+			}
+			walkSchema(value, field.Type, joinPath(path, key.Value), issues)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			*issues = append(*issues, typeIssue(node, path, "a mapping"))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			elem := t.Elem()
+			if isVarsPath(path) {
+				switch node.Content[i+1].Kind {
+				case yaml.MappingNode:
+					elem = reflect.TypeOf(varDefinition{})
+				case yaml.SequenceNode:
+					elem = reflect.TypeOf([]varDefinition{})
+				}
+			}
+			walkSchema(node.Content[i+1], elem, joinPath(path, node.Content[i].Value), issues)
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			*issues = append(*issues, typeIssue(node, path, "a list"))
+			return
+		}
+		for i, item := range node.Content {
+			walkSchema(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+
+	default:
+		if node.Kind != yaml.ScalarNode {
+			*issues = append(*issues, typeIssue(node, path, kindName(t)))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			*issues = append(*issues, typeIssue(node, path, kindName(t)))
+		}
+	}
+}
+
+// checkVarURLs warns about URL variables that lanup will never transform,
+// such as remote URLs written where a localhost URL was intended
+func checkVarURLs(vars *yaml.Node, path string) []Issue {
+	if vars == nil || vars.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var issues []Issue
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		key, value := vars.Content[i], vars.Content[i+1]
+		if value.Kind == yaml.MappingNode {
+			value = lookupNode(value, "url")
+		}
+		if value == nil || value.Kind != yaml.ScalarNode || !strings.Contains(value.Value, "://") {
+			continue
+		}
+		u, err := url.Parse(value.Value)
+		if err != nil {
+			continue
+		}
+		host := u.Hostname()
+		if host == "localhost" || host == "127.0.0.1" {
+			continue
+		}
+		issues = append(issues, Issue{
+			Line:     value.Line,
+			Column:   value.Column,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s.%s points to %s, only localhost and 127.0.0.1 URLs are rewritten for the LAN", path, key.Value, host),
+		})
+	}
+	return issues
+}
+
+// isVarsPath reports whether path is a vars section, whose values are a URL, a mapping with options
+// or a list of such mappings
+func isVarsPath(path string) bool {
+	return path == "vars" || strings.HasSuffix(path, ".vars")
+}
+
+// documentRoot returns the top-level node of a YAML document, or nil when it is empty
+func documentRoot(data []byte) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// lookupNode returns the value of key in a mapping node, or nil
+func lookupNode(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlFields maps the yaml keys of a struct type to their field
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// unknownKeyIssue reports an unknown key, suggesting the closest known key
+func unknownKeyIssue(key *yaml.Node, path string, fields map[string]reflect.StructField) Issue {
+	msg := fmt.Sprintf("unknown key %q", joinPath(path, key.Value))
+	if suggestion := closestKey(key.Value, fields); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	return Issue{Line: key.Line, Column: key.Column, Severity: SeverityError, Message: msg}
+}
+
+// closestKey returns the known key a typo most likely refers to, or an empty string
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	normalized := strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	best, bestDistance := "", 3
+	for name := range fields {
+		if name == normalized {
+			return name
+		}
+		if d := levenshtein(normalized, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if bestDistance > 2 {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// typeIssue reports a value of the wrong type
+func typeIssue(node *yaml.Node, path, expected string) Issue {
+	got := node.Value
+	switch node.Kind {
+	case yaml.MappingNode:
+		got = "a mapping"
+	case yaml.SequenceNode:
+		got = "a list"
+	default:
+		got = strconv.Quote(got)
+	}
+	return Issue{
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("%s must be %s, got %s", path, expected, got),
+	}
+}
+
+// kindName describes the values accepted by a scalar type
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	default:
+		return "a string"
+	}
+}
+
+// parseIssue converts a yaml error to an issue, keeping its line number
+func parseIssue(err error) Issue {
+	issue := Issue{Severity: SeverityError, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Column = 1
+		issue.Message = strings.TrimPrefix(issue.Message, m[0]+": ")
+	}
+	return issue
+}
+
+// joinPath appends a key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// hasErrors reports whether issues contains an error
+func hasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// sortIssues orders issues by position, file-level issues first
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProjectConfig_Valid(t *testing.T) {
+	data := `vars:
+  API_URL: http://localhost:8000
+output: .env.local
+auto_detect:
+  docker: true
+`
+	assert.Empty(t, CheckProjectConfig([]byte(data)))
+}
+
+func TestCheckProjectConfig_Issues(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		line     int
+		severity Severity
+		contains string
+	}{
+		{
+			name: "unknown key with suggestion",
+			data: `vars:
+  API_URL: http://localhost:8000
+output: .env.local
+auto-detect:
+  docker: true
+`,
+			line:     4,
+			severity: SeverityError,
+			contains: `unknown key "auto-detect" (did you mean "auto_detect"?)`,
+		},
+		{
+			name: "nested unknown key",
+			data: `output: .env.local
+auto_detect:
+  dokcer: true
+`,
+			line:     3,
+			severity: SeverityError,
+			contains: `unknown key "auto_detect.dokcer" (did you mean "docker"?)`,
+		},
+		{
+			name: "type error",
+			data: `output: .env.local
+auto_detect:
+  docker: sometimes
+`,
+			line:     3,
+			severity: SeverityError,
+			contains: `auto_detect.docker must be true or false, got "sometimes"`,
+		},
+		{
+			name: "list expected",
+			data: `output: .env.local
+detectors:
+  name: custom
+`,
+			line:     3,
+			severity: SeverityError,
+			contains: "detectors must be a list",
+		},
+		{
+			name: "integer in a list item",
+			data: `output: .env.local
+detectors:
+  - name: custom
+    command: ./detect.sh
+    timeout: soon
+`,
+			line:     5,
+			severity: SeverityError,
+			contains: "detectors[0].timeout must be an integer",
+		},
+		{
+			name:     "syntax error",
+			data:     "output: .env.local\nvars:\n  API_URL: [\n",
+			line:     3,
+			severity: SeverityError,
+		},
+		{
+			name: "validation error",
+			data: `vars:
+  API_URL: http://localhost:8000
+`,
+			line:     0,
+			severity: SeverityError,
+			contains: "output file path cannot be empty",
+		},
+		{
+			name: "remote URL never transformed",
+			data: `output: .env.local
+vars:
+  API_URL: https://api.example.com
+`,
+			line:     3,
+			severity: SeverityWarning,
+			contains: "vars.API_URL points to api.example.com",
+		},
+		{
+			name: "unknown variable option",
+			data: `output: .env.local
+vars:
+  API_URL: {url: http://localhost:8000, shceme: https}
+`,
+			line:     3,
+			severity: SeverityError,
+			contains: `unknown key "vars.API_URL.shceme" (did you mean "scheme"?)`,
+		},
+		{
+			name: "remote URL in a profile",
+			data: `output: .env.local
+profiles:
+  mobile:
+    vars:
+      METRO_URL: http://192.168.1.5:8081
+`,
+			line:     5,
+			severity: SeverityWarning,
+			contains: "profiles.mobile.vars.METRO_URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckProjectConfig([]byte(tt.data))
+			require.Len(t, issues, 1, "issues: %v", issues)
+			assert.Equal(t, tt.line, issues[0].Line)
+			assert.Equal(t, tt.severity, issues[0].Severity)
+			assert.Contains(t, issues[0].Message, tt.contains)
+		})
+	}
+}
+
+func TestCheckProjectConfig_VarOptions(t *testing.T) {
+	data := `output: .env.local
+vars:
+  API_URL:
+    url: http://localhost:8000
+    scheme: https
+    port: 8443
+profiles:
+  mobile:
+    vars:
+      WEB_URL: {url: http://localhost:3000, host: myapp.lan}
+      DOCKER_HOST:
+        - {url: "npipe:////./pipe/docker_engine", when: os == windows}
+        - url: unix:///var/run/docker.sock
+`
+	assert.Empty(t, CheckProjectConfig([]byte(data)))
+}
+
+func TestCheckProjectConfig_Empty(t *testing.T) {
+	issues := CheckProjectConfig([]byte(""))
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityError, issues[0].Severity)
+}
+
+func TestCheckProjectConfigFile_TOML(t *testing.T) {
+	valid := `output = ".env.local"
+
+[vars]
+API_URL = "http://localhost:8000"
+
+[auto_detect]
+docker = true
+`
+	assert.Empty(t, CheckProjectConfigFile(".lanup.toml", []byte(valid)))
+
+	// The schema checks apply to TOML, without line numbers
+	unknown := `output = ".env.local"
+
+[auto-detect]
+docker = true
+`
+	issues := CheckProjectConfigFile(".lanup.toml", []byte(unknown))
+	require.Len(t, issues, 1)
+	assert.Equal(t, 0, issues[0].Line)
+	assert.Contains(t, issues[0].Message, `unknown key "auto-detect"`)
+
+	// Syntax errors keep their line
+	issues = CheckProjectConfigFile("configs/lanup.toml", []byte("output = \".env.local\"\nvars = [\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityError, issues[0].Severity)
+	assert.NotZero(t, issues[0].Line)
+
+	// Other files are YAML
+	assert.Empty(t, CheckProjectConfigFile(".lanup.yaml", []byte("output: .env.local\n")))
+}
+
+func TestCheckProjectConfigFile_JSON(t *testing.T) {
+	data := `{
+  "output": ".env.local",
+  "auto-detect": {"docker": true}
+}
+`
+	issues := CheckProjectConfigFile(".lanup.json", []byte(data))
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Contains(t, issues[0].Message, `unknown key "auto-detect"`)
+
+	valid := `{"$schema": "` + SchemaURL + `", "output": ".env.local", "vars": {"API_URL": "http://localhost:8000"}}`
+	assert.Empty(t, CheckProjectConfigFile(".lanup.json", []byte(valid)))
+}
+
+func TestCheckGlobalConfig(t *testing.T) {
+	valid := `log_path: /tmp/lanup.log
+log_level: info
+default_port: 8080
+check_interval: 5
+`
+	assert.Empty(t, CheckGlobalConfig([]byte(valid)))
+
+	issues := CheckGlobalConfig([]byte(`log_path: /tmp/lanup.log
+log_level: info
+default_port: eighty
+check_intervall: 5
+`))
+	require.Len(t, issues, 2)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Contains(t, issues[0].Message, "default_port must be an integer")
+	assert.Equal(t, 4, issues[1].Line)
+	assert.Contains(t, issues[1].Message, `did you mean "check_interval"?`)
+
+	issues = CheckGlobalConfig([]byte(`log_path: /tmp/lanup.log
+log_level: verbose
+default_port: 8080
+check_interval: 5
+`))
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "invalid log_level")
+}
+
+func TestIssue_String(t *testing.T) {
+	assert.Equal(t, "3:1: error: bad", Issue{Line: 3, Column: 1, Severity: SeverityError, Message: "bad"}.String())
+	assert.Equal(t, "warning: odd", Issue{Severity: SeverityWarning, Message: "odd"}.String())
+}
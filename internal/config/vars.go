@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/raucheacho/lanup/internal/hosts"
+	"gopkg.in/yaml.v3"
+)
+
+// VarOptions changes the URL of a variable after localhost was replaced, like expose --https/--port,
+// and makes it conditional. A variable with options is written as a mapping in vars:
+//
+//	API_URL: {url: http://localhost:8000, scheme: https, port: 8443}
+//
+// and a variable with alternatives as a list of mappings, the first whose condition holds is used:
+//
+//	DOCKER_HOST:
+//	  - {url: npipe:////./pipe/docker_engine, when: os == windows}
+//	  - {url: unix:///var/run/docker.sock}
+type VarOptions struct {
+	Scheme string    // e.g. https when a TLS proxy fronts the service
+	Host   string    // replaces the LAN host
+	Port   int       // replaces the port
+	When   string    // condition on the machine, see CheckCondition
+	Else   []VarCase // alternatives tried in order when When doesn't hold
+}
+
+// VarCase is an alternative value of a variable
+type VarCase struct {
+	URL     string
+	Options VarOptions // without Else
+}
+
+// varDefinition is the mapping form of a variable in vars
+type varDefinition struct {
+	URL    string `yaml:"url"`
+	Scheme string `yaml:"scheme,omitempty"`
+	Host   string `yaml:"host,omitempty"`
+	Port   int    `yaml:"port,omitempty"`
+	When   string `yaml:"when,omitempty"`
+}
+
+// varCase returns the value and options of a definition
+func (d varDefinition) varCase() VarCase {
+	return VarCase{URL: d.URL, Options: VarOptions{Scheme: d.Scheme, Host: d.Host, Port: d.Port, When: d.When}}
+}
+
+// newVarDefinition returns the mapping form of a value and its options
+func newVarDefinition(vc VarCase) varDefinition {
+	o := vc.Options
+	return varDefinition{URL: vc.URL, Scheme: o.Scheme, Host: o.Host, Port: o.Port, When: o.When}
+}
+
+var schemeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+// Validate checks if the VarOptions have valid values
+func (o VarOptions) Validate() error {
+	if o.Scheme != "" && !schemeRe.MatchString(o.Scheme) {
+		return fmt.Errorf("invalid scheme: %s", o.Scheme)
+	}
+	if o.Host != "" && !hosts.ValidHostname(o.Host) {
+		return fmt.Errorf("invalid host: %s", o.Host)
+	}
+	if o.Port < 0 || o.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", o.Port)
+	}
+	if o.When != "" {
+		if _, err := CheckCondition(o.When, Facts{}); err != nil {
+			return err
+		}
+	}
+	for _, vc := range o.Else {
+		if vc.URL == "" {
+			return fmt.Errorf("alternative has empty url")
+		}
+		if len(vc.Options.Else) > 0 {
+			return fmt.Errorf("alternatives cannot be nested")
+		}
+		if err := vc.Options.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML reads the configuration, moving the options of the vars written as mappings or lists
+// to VarOptions
+func (c *ProjectConfig) UnmarshalYAML(node *yaml.Node) error {
+	if err := splitVars(node, &c.VarOptions); err != nil {
+		return err
+	}
+	type plain ProjectConfig
+	return node.Decode((*plain)(c))
+}
+
+// MarshalYAML writes the vars with options as mappings, and the ones with alternatives as lists
+func (c ProjectConfig) MarshalYAML() (interface{}, error) {
+	type plain ProjectConfig
+	return joinVars((*plain)(&c), c.VarOptions)
+}
+
+// UnmarshalYAML reads the profile, moving the options of the vars written as mappings or lists
+// to VarOptions
+func (p *ProfileConfig) UnmarshalYAML(node *yaml.Node) error {
+	if err := splitVars(node, &p.VarOptions); err != nil {
+		return err
+	}
+	type plain ProfileConfig
+	return node.Decode((*plain)(p))
+}
+
+// MarshalYAML writes the vars with options as mappings, and the ones with alternatives as lists
+func (p ProfileConfig) MarshalYAML() (interface{}, error) {
+	type plain ProfileConfig
+	return joinVars((*plain)(&p), p.VarOptions)
+}
+
+// splitVars replaces the vars of node written as mappings or lists with their (first) URL
+// and stores their options
+func splitVars(node *yaml.Node, options *map[string]VarOptions) error {
+	vars := lookupNode(node, "vars")
+	if vars == nil || vars.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		key, value := vars.Content[i], vars.Content[i+1]
+
+		var defs []varDefinition
+		switch value.Kind {
+		case yaml.MappingNode:
+			defs = make([]varDefinition, 1)
+			if err := value.Decode(&defs[0]); err != nil {
+				return err
+			}
+		case yaml.SequenceNode:
+			if err := value.Decode(&defs); err != nil {
+				return err
+			}
+			if len(defs) == 0 {
+				return fmt.Errorf("line %d: variable %s has no value", value.Line, key.Value)
+			}
+		default:
+			continue
+		}
+
+		first := defs[0].varCase()
+		for _, def := range defs[1:] {
+			first.Options.Else = append(first.Options.Else, def.varCase())
+		}
+		if *options == nil {
+			*options = make(map[string]VarOptions)
+		}
+		(*options)[key.Value] = first.Options
+		vars.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: first.URL, Line: value.Line, Column: value.Column}
+	}
+	return nil
+}
+
+// joinVars encodes v and writes the vars that have options as mappings, or lists with alternatives
+func joinVars(v interface{}, options map[string]VarOptions) (interface{}, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+
+	vars := lookupNode(&node, "vars")
+	if vars == nil || len(options) == 0 {
+		return &node, nil
+	}
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		opts, ok := options[vars.Content[i].Value]
+		if !ok {
+			continue
+		}
+
+		defs := []varDefinition{newVarDefinition(VarCase{URL: vars.Content[i+1].Value, Options: opts})}
+		for _, vc := range opts.Else {
+			defs = append(defs, newVarDefinition(vc))
+		}
+		var value yaml.Node
+		if err := value.Encode(defs); err != nil {
+			return nil, err
+		}
+		for _, item := range value.Content {
+			item.Style = yaml.FlowStyle
+		}
+		if len(defs) == 1 {
+			value = *value.Content[0]
+		}
+		vars.Content[i+1] = &value
+	}
+	return &node, nil
+}
+
+// validateVarOptions checks the options of the vars
+func validateVarOptions(vars map[string]string, options map[string]VarOptions) error {
+	for key, opts := range options {
+		if _, ok := vars[key]; !ok {
+			return fmt.Errorf("variable %s has options but no url", key)
+		}
+		if err := opts.Validate(); err != nil {
+			return fmt.Errorf("variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
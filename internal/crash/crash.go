@@ -0,0 +1,121 @@
+// Package crash records the panics of lanup in crash reports (~/.lanup/crash), so that they can
+// be attached to bug reports with 'lanup report'
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxReports is the number of crash reports kept, older ones are removed
+const MaxReports = 10
+
+// filePrefix starts the name of the crash reports
+const filePrefix = "crash-"
+
+// Report is a panic with what is needed to investigate it
+type Report struct {
+	Time    time.Time
+	Version string
+	Args    []string // command line arguments, see SanitizeArgs
+	Panic   string   // value passed to panic
+	Stack   []byte
+	Config  string // summary of the configuration, without the values of the variables
+}
+
+// DefaultDir returns the directory of the crash reports (~/.lanup/crash)
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "crash"), nil
+}
+
+// Write saves the report in dir, removes the reports beyond MaxReports, and returns its path
+func Write(dir string, r Report) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s%s-%d.txt", filePrefix, r.Time.Format("20060102-150405"), os.Getpid())
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(r.String()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+
+	reports, err := List(dir)
+	if err == nil && len(reports) > MaxReports {
+		for _, old := range reports[MaxReports:] {
+			os.Remove(old)
+		}
+	}
+	return path, nil
+}
+
+// List returns the crash reports of dir, newest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crash directory: %w", err)
+	}
+
+	var reports []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), filePrefix) && strings.HasSuffix(entry.Name(), ".txt") {
+			reports = append(reports, filepath.Join(dir, entry.Name()))
+		}
+	}
+	// The names start with the time of the crash
+	sort.Sort(sort.Reverse(sort.StringSlice(reports)))
+	return reports, nil
+}
+
+// String formats the report as text
+func (r Report) String() string {
+	var b strings.Builder
+	b.WriteString("lanup crash report\n\n")
+	fmt.Fprintf(&b, "Time:    %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version: %s\n", r.Version)
+	fmt.Fprintf(&b, "Go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Command: %s\n", strings.Join(append([]string{"lanup"}, r.Args...), " "))
+	fmt.Fprintf(&b, "Panic:   %s\n", r.Panic)
+	if r.Config != "" {
+		fmt.Fprintf(&b, "\nConfiguration:\n%s\n", strings.TrimRight(r.Config, "\n"))
+	}
+	fmt.Fprintf(&b, "\nStack:\n%s\n", strings.TrimRight(string(r.Stack), "\n"))
+	return b.String()
+}
+
+// SanitizeArgs hides the values of the command line arguments, which may hold credentials such as
+// serve --auth: the subcommands are kept up to the first flag, then only the flag names
+func SanitizeArgs(args []string) []string {
+	sanitized := make([]string, 0, len(args))
+	flags := false
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			flags = true
+			sanitized = append(sanitized, arg)
+		case strings.HasPrefix(arg, "-"):
+			flags = true
+			if name, _, ok := strings.Cut(arg, "="); ok {
+				arg = name + "=***"
+			}
+			sanitized = append(sanitized, arg)
+		case flags:
+			sanitized = append(sanitized, "***")
+		default:
+			sanitized = append(sanitized, arg)
+		}
+	}
+	return sanitized
+}
@@ -0,0 +1,80 @@
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	report := Report{
+		Time:    time.Date(2025, 10, 27, 23, 50, 12, 0, time.UTC),
+		Version: "1.2.3",
+		Args:    []string{"start", "--watch"},
+		Panic:   "runtime error: index out of range",
+		Stack:   []byte("goroutine 1 [running]:\nmain.main()\n"),
+		Config:  "  vars: API_URL\n",
+	}
+
+	path, err := Write(dir, report)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("crash-20251027-235012-%d.txt", os.Getpid()), filepath.Base(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "Version: 1.2.3")
+	assert.Contains(t, content, "Command: lanup start --watch")
+	assert.Contains(t, content, "Panic:   runtime error: index out of range")
+	assert.Contains(t, content, "Configuration:\n  vars: API_URL\n")
+	assert.Contains(t, content, "Stack:\ngoroutine 1 [running]:")
+
+	reports, err := List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, reports)
+}
+
+func TestWrite_Prune(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var last string
+	for i := 0; i < MaxReports+3; i++ {
+		path, err := Write(dir, Report{Time: start.Add(time.Duration(i) * time.Minute)})
+		require.NoError(t, err)
+		last = path
+	}
+
+	reports, err := List(dir)
+	require.NoError(t, err)
+	assert.Len(t, reports, MaxReports)
+	assert.Equal(t, last, reports[0])
+}
+
+func TestList_MissingDir(t *testing.T) {
+	reports, err := List(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"start", "--watch"}, []string{"start", "--watch"}},
+		{[]string{"serve", "--auth=admin:secret"}, []string{"serve", "--auth=***"}},
+		{[]string{"serve", "--auth", "admin:secret"}, []string{"serve", "--auth", "***"}},
+		{[]string{"daemon", "install", "-p", "dev"}, []string{"daemon", "install", "-p", "***"}},
+		{[]string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, SanitizeArgs(tt.args), "args %v", tt.args)
+	}
+}
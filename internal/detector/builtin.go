@@ -0,0 +1,232 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/devserver"
+	"github.com/raucheacho/lanup/internal/docker"
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// DockerDetector exposes the host ports of running Docker containers
+type DockerDetector struct{}
+
+// NewDockerDetector creates a new Docker detector
+func NewDockerDetector() *DockerDetector {
+	return &DockerDetector{}
+}
+
+// Name returns the detector name
+func (d *DockerDetector) Name() string {
+	return "docker"
+}
+
+// Available reports whether Docker is installed and running
+func (d *DockerDetector) Available() bool {
+	return docker.IsDockerAvailable()
+}
+
+// Detect returns a DOCKER_<NAME>_PORT variable for each published container port
+func (d *DockerDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	containers, err := docker.GetRunningContainers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect Docker containers: %w", err)
+	}
+
+	values := make(map[string]string)
+	for _, container := range containers {
+		for _, port := range container.Ports {
+			varName := fmt.Sprintf("DOCKER_%s_PORT", strings.ToUpper(strings.ReplaceAll(container.Name, "-", "_")))
+			values[varName] = fmt.Sprintf("http://localhost:%d", port.HostPort)
+		}
+	}
+
+	return varsFromMap(values), nil
+}
+
+// SupabaseDetector exposes the services of a local Supabase stack
+type SupabaseDetector struct{}
+
+// NewSupabaseDetector creates a new Supabase detector
+func NewSupabaseDetector() *SupabaseDetector {
+	return &SupabaseDetector{}
+}
+
+// Name returns the detector name
+func (d *SupabaseDetector) Name() string {
+	return "supabase"
+}
+
+// Available reports whether the Supabase CLI is installed
+func (d *SupabaseDetector) Available() bool {
+	_, err := exec.LookPath("supabase")
+	return err == nil
+}
+
+// Detect returns the URLs of the running services under the names of the Supabase conventions
+// (SUPABASE_URL, SUPABASE_FUNCTIONS_URL...), and a SUPABASE_<SERVICE>_PORT variable for each service
+func (d *SupabaseDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	urls, err := docker.GetSupabaseURLs()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	return varsFromMap(supabaseVars(urls)), nil
+}
+
+// supabaseAPIPaths are the services served by the API gateway, by the key of their URL
+var supabaseAPIPaths = map[string]string{
+	"FUNCTIONS_URL": "/functions/v1",
+	"STORAGE_URL":   "/storage/v1",
+	"REALTIME_URL":  "/realtime/v1",
+	"REST_URL":      "/rest/v1",
+	"GRAPHQL_URL":   "/graphql/v1",
+}
+
+// supabaseVars returns the variables of the Supabase services from their URLs keyed like the output
+// of 'supabase status -o env': API_URL is SUPABASE_URL, the others get the SUPABASE_ prefix, and
+// the services behind the API gateway missing from the status are derived from API_URL
+func supabaseVars(urls map[string]string) map[string]string {
+	values := make(map[string]string)
+	for key, value := range urls {
+		name := "SUPABASE_" + key
+		if key == "API_URL" {
+			name = "SUPABASE_URL"
+		}
+		values[name] = value
+
+		// Variables of the previous versions, kept for existing configurations
+		if u, err := url.Parse(value); err == nil && u.Port() != "" {
+			values[fmt.Sprintf("SUPABASE_%s_PORT", key)] = "http://localhost:" + u.Port()
+		}
+	}
+
+	api, err := url.Parse(urls["API_URL"])
+	if err != nil || api.Host == "" {
+		return values
+	}
+	base := strings.TrimSuffix(api.String(), "/")
+	for key, path := range supabaseAPIPaths {
+		if _, ok := urls[key]; ok {
+			continue
+		}
+		value := base + path
+		if key == "REALTIME_URL" {
+			// The SDKs connect to Realtime over WebSocket
+			value = strings.Replace(value, "http", "ws", 1)
+		}
+		values["SUPABASE_"+key] = value
+	}
+	return values
+}
+
+// DevServerDetector exposes running JavaScript dev servers (Vite, Next.js, CRA, ...)
+type DevServerDetector struct {
+	dir      string
+	hostVars bool
+	hostKeys []string
+	warnings []string
+}
+
+// NewDevServerDetector creates a new dev server detector for the project in dir
+// With hostVars, it also returns the variables making the dev servers of the project
+// listen on all interfaces, such as HOST=0.0.0.0 for Create React App.
+func NewDevServerDetector(dir string, hostVars bool) *DevServerDetector {
+	return &DevServerDetector{dir: dir, hostVars: hostVars}
+}
+
+// Name returns the detector name
+func (d *DevServerDetector) Name() string {
+	return "dev_servers"
+}
+
+// Available always returns true, detection degrades gracefully without lsof
+func (d *DevServerDetector) Available() bool {
+	return true
+}
+
+// Detect returns a variable named after the framework of each running dev server
+func (d *DevServerDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	d.warnings = nil
+	d.hostKeys = nil
+
+	servers, err := devserver.DetectDevServers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect dev servers: %w", err)
+	}
+
+	values := make(map[string]string)
+	if d.hostVars {
+		if binding, err := devserver.PlanBinding(d.dir); err == nil {
+			for key, value := range binding.Vars {
+				values[key] = value
+				d.hostKeys = append(d.hostKeys, key)
+			}
+			sort.Strings(d.hostKeys)
+		}
+	}
+	for _, server := range servers {
+		values[server.Framework.VarName] = server.URL()
+		if server.LoopbackOnly {
+			d.warnings = append(d.warnings, fmt.Sprintf(
+				"%s dev server on port %d only listens on %s and is not reachable from your LAN (try '%s')",
+				server.Framework.Name, server.Port, server.Address, server.Framework.HostHint))
+		}
+	}
+
+	return varsFromMap(values), nil
+}
+
+// Warnings returns the dev servers that are only listening on loopback
+func (d *DevServerDetector) Warnings() []string {
+	return d.warnings
+}
+
+// UnprefixedKeys returns the host variables, read by the dev servers under their own name
+func (d *DevServerDetector) UnprefixedKeys() []string {
+	return d.hostKeys
+}
+
+// ExpoDetector exposes a running Metro bundler (Expo or React Native)
+type ExpoDetector struct {
+	projectVars map[string]string
+	metro       *devserver.MetroServer
+}
+
+// NewExpoDetector creates a new Expo detector
+// projectVars are used to derive EXPO_PUBLIC_API_URL from the project's API_URL
+func NewExpoDetector(projectVars map[string]string) *ExpoDetector {
+	return &ExpoDetector{projectVars: projectVars}
+}
+
+// Name returns the detector name
+func (d *ExpoDetector) Name() string {
+	return "expo"
+}
+
+// Available always returns true, the Metro status endpoint is probed over HTTP
+func (d *ExpoDetector) Available() bool {
+	return true
+}
+
+// Detect returns the variables Expo needs when a Metro bundler is running
+func (d *ExpoDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	d.metro = nil
+
+	metro, err := devserver.DetectMetro()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+
+	d.metro = metro
+	return varsFromMap(metro.Vars(d.projectVars)), nil
+}
+
+// Metro returns the Metro bundler found by the last Detect call, or nil
+func (d *ExpoDetector) Metro() *devserver.MetroServer {
+	return d.metro
+}
@@ -0,0 +1,239 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/devserver"
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// ErrNotRunning indicates that a detector's service is simply not running,
+// as opposed to a real failure that the user should be warned about
+var ErrNotRunning = errors.New("service not running")
+
+// Detector discovers local services and returns the variables they provide
+// Returned values are localhost URLs; they are transformed to the LAN IP by the caller
+type Detector interface {
+	// Name returns the unique name of the detector
+	Name() string
+	// Available reports whether the detector can run on this machine (e.g. required CLI installed)
+	Available() bool
+	// Detect runs the detection and returns the discovered variables
+	Detect(ctx context.Context) ([]env.EnvVar, error)
+}
+
+// WarningReporter is implemented by detectors that produce non-fatal warnings during Detect
+type WarningReporter interface {
+	Warnings() []string
+}
+
+// UnprefixedReporter is implemented by detectors returning variables read by other tools under
+// a fixed name, such as HOST for the dev servers, which var_prefix must not rename
+type UnprefixedReporter interface {
+	UnprefixedKeys() []string
+}
+
+// Result holds the outcome of running a single detector
+type Result struct {
+	Detector   string
+	Vars       []env.EnvVar
+	Warnings   []string
+	Unprefixed []string // keys of Vars written without the var_prefix of the project
+	Skipped    bool     // true when the detector was not available
+	Err        error
+}
+
+// Key returns the name of a variable of the result in the env file, with the var_prefix of cfg
+// unless the detector needs it unprefixed
+func (r Result) Key(cfg *config.ProjectConfig, key string) string {
+	for _, unprefixed := range r.Unprefixed {
+		if key == unprefixed {
+			return key
+		}
+	}
+	return cfg.PrefixKey(key)
+}
+
+// Registry holds detectors in registration order and tracks which ones are enabled
+type Registry struct {
+	detectors []Detector
+	disabled  map[string]bool
+}
+
+// NewRegistry creates an empty detector registry
+func NewRegistry() *Registry {
+	return &Registry{
+		disabled: make(map[string]bool),
+	}
+}
+
+// NewRegistryFromConfig creates a registry with the built-in detectors enabled according
+// to the project auto_detect settings, followed by the project's external and plugin detectors
+func NewRegistryFromConfig(cfg *config.ProjectConfig) *Registry {
+	r := NewRegistry()
+
+	builtins := []struct {
+		detector Detector
+		enabled  bool
+	}{
+		{NewDockerDetector(), cfg.AutoDetect.Docker},
+		{NewSupabaseDetector(), cfg.AutoDetect.Supabase},
+		{NewDevServerDetector(cfg.Dir(), cfg.HostVars), cfg.AutoDetect.DevServers},
+		{NewExpoDetector(cfg.Vars), cfg.AutoDetect.Expo},
+	}
+	for _, b := range builtins {
+		// Built-in names are unique, registration cannot fail
+		_ = r.Register(b.detector)
+		if !b.enabled {
+			r.Disable(b.detector.Name())
+		}
+	}
+
+	for _, d := range cfg.Detectors {
+		_ = r.Register(NewExternalDetector(d))
+	}
+	for _, name := range cfg.Plugins.Detectors {
+		_ = r.Register(NewPluginDetector(name, cfg.Dir()))
+	}
+
+	return r
+}
+
+// Register adds a detector to the registry
+func (r *Registry) Register(d Detector) error {
+	if _, exists := r.Get(d.Name()); exists {
+		return fmt.Errorf("detector %s is already registered", d.Name())
+	}
+	r.detectors = append(r.detectors, d)
+	return nil
+}
+
+// Get returns the detector with the given name
+func (r *Registry) Get(name string) (Detector, bool) {
+	for _, d := range r.detectors {
+		if d.Name() == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Enable enables a registered detector
+func (r *Registry) Enable(name string) {
+	delete(r.disabled, name)
+}
+
+// Disable disables a registered detector
+func (r *Registry) Disable(name string) {
+	r.disabled[name] = true
+}
+
+// IsEnabled reports whether a detector is registered and enabled
+func (r *Registry) IsEnabled(name string) bool {
+	_, exists := r.Get(name)
+	return exists && !r.disabled[name]
+}
+
+// Detectors returns all registered detectors in registration order
+func (r *Registry) Detectors() []Detector {
+	return append([]Detector(nil), r.detectors...)
+}
+
+// Enabled returns the enabled detectors in registration order
+func (r *Registry) Enabled() []Detector {
+	var enabled []Detector
+	for _, d := range r.detectors {
+		if !r.disabled[d.Name()] {
+			enabled = append(enabled, d)
+		}
+	}
+	return enabled
+}
+
+// Run executes every enabled detector and returns their results in registration order
+func (r *Registry) Run(ctx context.Context) []Result {
+	var results []Result
+
+	for _, d := range r.Enabled() {
+		result := Result{Detector: d.Name()}
+
+		if !d.Available() {
+			result.Skipped = true
+			results = append(results, result)
+			continue
+		}
+
+		result.Vars, result.Err = d.Detect(ctx)
+		if reporter, ok := d.(WarningReporter); ok {
+			result.Warnings = reporter.Warnings()
+		}
+		if reporter, ok := d.(UnprefixedReporter); ok {
+			result.Unprefixed = reporter.UnprefixedKeys()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Collected holds the variables gathered by Collect
+type Collected struct {
+	Vars    map[string]string
+	Sources map[string]string      // detector that provided each detected variable
+	Results []Result               // result of each enabled detector, in order
+	Metro   *devserver.MetroServer // Metro bundler found by the expo detector, nil when none
+}
+
+// Collect returns the configured variables of cfg merged with the ones found by its enabled
+// detectors, named with its var_prefix; the static vars win over the detected variables
+// onResult is called with the outcome of each detector, it may be nil
+func Collect(ctx context.Context, cfg *config.ProjectConfig, onResult func(Result)) *Collected {
+	found := &Collected{
+		Vars:    make(map[string]string, len(cfg.Vars)),
+		Sources: make(map[string]string),
+	}
+	for key, value := range cfg.Vars {
+		found.Vars[key] = value
+	}
+
+	registry := NewRegistryFromConfig(cfg)
+	found.Results = registry.Run(ctx)
+	for _, result := range found.Results {
+		if onResult != nil {
+			onResult(result)
+		}
+		for _, v := range result.Vars {
+			key := result.Key(cfg, v.Key)
+			found.Vars[key] = v.Value
+			found.Sources[key] = result.Detector
+		}
+	}
+	for key, value := range cfg.StaticVars {
+		found.Vars[key] = value
+		delete(found.Sources, key)
+	}
+
+	if d, ok := registry.Get("expo"); ok {
+		if expo, ok := d.(*ExpoDetector); ok {
+			found.Metro = expo.Metro()
+		}
+	}
+	return found
+}
+
+// varsFromMap converts a map of variables to managed EnvVars sorted by key
+func varsFromMap(values map[string]string) []env.EnvVar {
+	vars := make([]env.EnvVar, 0, len(values))
+	for key, value := range values {
+		vars = append(vars, env.EnvVar{Key: key, Value: value, Managed: true})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Key < vars[j].Key
+	})
+	return vars
+}
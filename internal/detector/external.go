@@ -0,0 +1,142 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// DefaultExternalTimeout is used when an external detector has no timeout configured
+const DefaultExternalTimeout = 10 * time.Second
+
+// ExternalDetector runs a user-registered command as a detector
+type ExternalDetector struct {
+	Config config.DetectorConfig
+}
+
+// NewExternalDetector creates a detector for an external command
+func NewExternalDetector(cfg config.DetectorConfig) *ExternalDetector {
+	return &ExternalDetector{Config: cfg}
+}
+
+// Name returns the configured detector name
+func (d *ExternalDetector) Name() string {
+	return d.Config.Name
+}
+
+// Available always returns true, command failures are reported by Detect
+func (d *ExternalDetector) Available() bool {
+	return true
+}
+
+// Detect runs the external command and returns the variables it printed
+func (d *ExternalDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	values, err := RunExternal(ctx, d.Config)
+	if err != nil {
+		return nil, err
+	}
+	return varsFromMap(values), nil
+}
+
+// RunExternal executes an external detector command and returns the variables it printed
+// The command is run through the system shell and must print either KEY=VALUE lines
+// or a single JSON object on stdout
+func RunExternal(ctx context.Context, cfg config.DetectorConfig) (map[string]string, error) {
+	timeout := DefaultExternalTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", cfg.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", cfg.Command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("detector %s timed out after %s", cfg.Name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("detector %s failed: %w: %s", cfg.Name, err, msg)
+		}
+		return nil, fmt.Errorf("detector %s failed: %w", cfg.Name, err)
+	}
+
+	vars, err := ParseDetectorOutput(stdout.String())
+	if err != nil {
+		return nil, fmt.Errorf("detector %s: %w", cfg.Name, err)
+	}
+
+	return vars, nil
+}
+
+// ParseDetectorOutput parses the stdout of an external detector
+// Supported formats:
+//
+//	API_URL=http://localhost:8000     (one KEY=VALUE per line, # comments allowed)
+//	{"API_URL": "http://localhost:8000"}
+func ParseDetectorOutput(output string) (map[string]string, error) {
+	trimmed := strings.TrimSpace(output)
+	vars := make(map[string]string)
+
+	if trimmed == "" {
+		return vars, nil
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON output: %w", err)
+		}
+		for key, value := range raw {
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSON output: empty variable name")
+			}
+			if value == nil {
+				// null is an empty value, not "<nil>"
+				vars[key] = ""
+				continue
+			}
+			vars[key] = fmt.Sprint(value)
+		}
+		return vars, nil
+	}
+
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		vars[key] = strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+	}
+
+	return vars, nil
+}
@@ -0,0 +1,93 @@
+package detector
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDetectorOutput_KeyValue(t *testing.T) {
+	output := `# rails detector
+API_URL=http://localhost:3000
+export WS_URL="ws://localhost:3035"
+not a variable
+
+CABLE_URL='ws://localhost:28080'
+`
+	vars, err := ParseDetectorOutput(output)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"API_URL":   "http://localhost:3000",
+		"WS_URL":    "ws://localhost:3035",
+		"CABLE_URL": "ws://localhost:28080",
+	}, vars)
+}
+
+func TestParseDetectorOutput_JSON(t *testing.T) {
+	vars, err := ParseDetectorOutput(`{"API_URL": "http://localhost:3000", "PORT": 3000, "DEBUG": true, "TOKEN": null}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost:3000", vars["API_URL"])
+	assert.Equal(t, "3000", vars["PORT"])
+	assert.Equal(t, "true", vars["DEBUG"])
+	value, ok := vars["TOKEN"]
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+}
+
+func TestParseDetectorOutput_Empty(t *testing.T) {
+	vars, err := ParseDetectorOutput("  \n")
+	require.NoError(t, err)
+	assert.Empty(t, vars)
+}
+
+func TestParseDetectorOutput_InvalidJSON(t *testing.T) {
+	_, err := ParseDetectorOutput(`{"API_URL": `)
+	assert.Error(t, err)
+}
+
+func TestRunExternal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	vars, err := RunExternal(context.Background(), config.DetectorConfig{
+		Name:    "echo",
+		Command: "echo API_URL=http://localhost:8000",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8000", vars["API_URL"])
+}
+
+func TestRunExternal_Failure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	_, err := RunExternal(context.Background(), config.DetectorConfig{
+		Name:    "broken",
+		Command: "echo boom >&2; exit 3",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "detector broken failed")
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestRunExternal_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	_, err := RunExternal(context.Background(), config.DetectorConfig{
+		Name:    "slow",
+		Command: "sleep 5",
+		Timeout: 1,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+}
@@ -0,0 +1,50 @@
+package detector
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/plugin"
+)
+
+// PluginDetector runs a lanup-<name> plugin as a detector
+type PluginDetector struct {
+	name string
+	dir  string // project directory sent to the plugin
+}
+
+// NewPluginDetector creates a detector for the named plugin of the project in dir
+func NewPluginDetector(name, dir string) *PluginDetector {
+	return &PluginDetector{name: name, dir: dir}
+}
+
+// Name returns the name of the plugin
+func (d *PluginDetector) Name() string {
+	return d.name
+}
+
+// Available always returns true: a configured plugin missing from PATH is reported by Detect
+func (d *PluginDetector) Available() bool {
+	return true
+}
+
+// Detect sends a detect request to the plugin and returns the variables it answered
+func (d *PluginDetector) Detect(ctx context.Context) ([]env.EnvVar, error) {
+	p, err := plugin.Find(d.name)
+	if err != nil {
+		return nil, err
+	}
+
+	project, _ := filepath.Abs(d.dir)
+	resp, err := p.Call(ctx, plugin.Request{Action: plugin.ActionDetect, Project: project})
+	if err != nil {
+		return nil, err
+	}
+
+	vars := plugin.FromVars(resp.Vars)
+	for i := range vars {
+		vars[i].Managed = true
+	}
+	return vars, nil
+}
@@ -0,0 +1,126 @@
+// Package devices pairs test devices with the daemon of a project: a companion script or app
+// scans a QR code once, then receives the new URLs whenever the IP of the machine changes,
+// instead of them being typed again in the debug settings of the app.
+package devices
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Device is a paired device
+type Device struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	TokenHash string    `json:"token_hash"` // SHA-256 of the token, only the device knows the token
+	PairedAt  time.Time `json:"paired_at"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
+// Store is the devices file of a project
+type Store struct {
+	Port    int      `json:"port,omitempty"` // of the device server, kept so that devices find it again after a restart
+	Devices []Device `json:"devices"`
+}
+
+// Load reads the devices file, a missing file is an empty store
+func Load(path string) (*Store, error) {
+	s := &Store{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devices: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse devices %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the devices file, readable by the user only since it authenticates the devices
+func (s *Store) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create devices directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode devices: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write devices: %w", err)
+	}
+	return nil
+}
+
+// Add pairs a new device and returns it with its token
+func (s *Store) Add(name string, now time.Time) (Device, string, error) {
+	id, err := randomString(5)
+	if err != nil {
+		return Device{}, "", err
+	}
+	token, err := randomString(20)
+	if err != nil {
+		return Device{}, "", err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "device-" + id
+	}
+	device := Device{ID: id, Name: name, TokenHash: hashToken(token), PairedAt: now}
+	s.Devices = append(s.Devices, device)
+	return device, token, nil
+}
+
+// Authenticate returns the device holding token, nil when no device does
+func (s *Store) Authenticate(token string) *Device {
+	if token == "" {
+		return nil
+	}
+	hash := hashToken(token)
+	for i := range s.Devices {
+		if subtle.ConstantTimeCompare([]byte(s.Devices[i].TokenHash), []byte(hash)) == 1 {
+			return &s.Devices[i]
+		}
+	}
+	return nil
+}
+
+// Remove unpairs the devices with the given ID or name, and reports whether there was one
+func (s *Store) Remove(nameOrID string) bool {
+	kept := s.Devices[:0]
+	for _, d := range s.Devices {
+		if d.ID != nameOrID && !strings.EqualFold(d.Name, nameOrID) {
+			kept = append(kept, d)
+		}
+	}
+	removed := len(kept) < len(s.Devices)
+	s.Devices = kept
+	return removed
+}
+
+// hashToken returns the hash of a token stored in the devices file
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded in lowercase base32, easy to type and to put in URLs
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a random code: %w", err)
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
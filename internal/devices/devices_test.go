@@ -0,0 +1,226 @@
+package devices
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+
+	store, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, store.Devices)
+
+	now := time.Date(2025, 10, 27, 23, 50, 0, 0, time.UTC)
+	device, token, err := store.Add("Pixel 7", now)
+	require.NoError(t, err)
+	assert.Equal(t, "Pixel 7", device.Name)
+	assert.NotEmpty(t, token)
+	assert.NotContains(t, device.TokenHash, token)
+
+	unnamed, _, err := store.Add("  ", now)
+	require.NoError(t, err)
+	assert.Equal(t, "device-"+unnamed.ID, unnamed.Name)
+
+	store.Port = 47800
+	require.NoError(t, store.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, store, loaded)
+
+	require.NotNil(t, loaded.Authenticate(token))
+	assert.Equal(t, device.ID, loaded.Authenticate(token).ID)
+	assert.Nil(t, loaded.Authenticate("wrong"))
+	assert.Nil(t, loaded.Authenticate(""))
+
+	assert.True(t, loaded.Remove("pixel 7"))
+	assert.False(t, loaded.Remove("pixel 7"))
+	assert.Nil(t, loaded.Authenticate(token))
+	assert.True(t, loaded.Remove(unnamed.ID))
+	assert.Empty(t, loaded.Devices)
+}
+
+func testState(ip string) State {
+	return State{
+		Project: "app",
+		IP:      ip,
+		URLs:    map[string]string{"API_URL": "http://" + ip + ":8000"},
+	}
+}
+
+// pairDevice pairs a device with the server and returns the response
+func pairDevice(t *testing.T, server *Server, url string) PairResponse {
+	code, _, err := server.NewCode()
+	require.NoError(t, err)
+
+	resp, err := http.Post(url+"/v1/pair", "application/json", strings.NewReader(`{"code": "`+code+`", "name": "Pixel 7"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var pair PairResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pair))
+	return pair
+}
+
+func TestServer_Pair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	server := NewServer(path, "macbook.local", testState("192.168.1.10"))
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	pair := pairDevice(t, server, ts.URL)
+	assert.NotEmpty(t, pair.Token)
+	assert.Equal(t, "192.168.1.10", pair.State.IP)
+	require.Len(t, pair.Servers, 2)
+	assert.Equal(t, ts.URL, pair.Servers[0])
+	assert.True(t, strings.HasPrefix(pair.Servers[1], "http://macbook.local:"))
+
+	store, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, store.Devices, 1)
+	assert.Equal(t, "Pixel 7", store.Devices[0].Name)
+
+	// The state requires the token
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/state", nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req.Header.Set("Authorization", "Bearer "+pair.Token)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var state State
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.Equal(t, "http://192.168.1.10:8000", state.URLs["API_URL"])
+}
+
+func TestServer_PairCodes(t *testing.T) {
+	server := NewServer(filepath.Join(t.TempDir(), "devices.json"), "", State{})
+	now := time.Now()
+	server.now = func() time.Time { return now }
+
+	code, expires, err := server.NewCode()
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(CodeTTL), expires)
+
+	// Codes are used once
+	assert.True(t, server.useCode(strings.ToUpper(code)))
+	assert.False(t, server.useCode(code))
+
+	// Codes expire
+	code, _, err = server.NewCode()
+	require.NoError(t, err)
+	now = now.Add(CodeTTL + time.Second)
+	assert.False(t, server.useCode(code))
+
+	// Wrong codes invalidate the pending ones
+	code, _, err = server.NewCode()
+	require.NoError(t, err)
+	for i := 0; i < maxFailedPairings; i++ {
+		assert.False(t, server.useCode("wrong"))
+	}
+	assert.False(t, server.useCode(code))
+}
+
+func TestServer_Events(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	server := NewServer(path, "", testState("192.168.1.10"))
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	pair := pairDevice(t, server, ts.URL)
+
+	resp, err := http.Get(ts.URL + "/v1/events?token=" + pair.Token)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readState := func() State {
+		var state State
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				require.NoError(t, json.Unmarshal([]byte(data), &state))
+				return state
+			}
+		}
+	}
+
+	assert.Equal(t, "192.168.1.10", readState().IP)
+
+	// An unchanged state is not sent again
+	unchanged := testState("192.168.1.10")
+	unchanged.UpdatedAt = time.Now()
+	server.Publish(unchanged)
+	server.Publish(testState("192.168.1.20"))
+	state := readState()
+	assert.Equal(t, "192.168.1.20", state.IP)
+	assert.Equal(t, "http://192.168.1.20:8000", state.URLs["API_URL"])
+}
+
+func TestServer_Unpaired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	server := NewServer(path, "", testState("192.168.1.10"))
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	pair := pairDevice(t, server, ts.URL)
+
+	store, err := Load(path)
+	require.NoError(t, err)
+	require.True(t, store.Remove(pair.ID))
+	require.NoError(t, store.Save(path))
+
+	resp, err := http.Get(ts.URL + "/v1/state?token=" + pair.Token)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_Disconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	server := NewServer(path, "", testState("192.168.1.10"))
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	kept := pairDevice(t, server, ts.URL)
+	removed := pairDevice(t, server, ts.URL)
+
+	streams := make(map[string]*bufio.Reader)
+	for _, pair := range []PairResponse{kept, removed} {
+		resp, err := http.Get(ts.URL + "/v1/events?token=" + pair.Token)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		streams[pair.ID] = bufio.NewReader(resp.Body)
+		_, err = streams[pair.ID].ReadString('\n')
+		require.NoError(t, err)
+	}
+
+	store, err := Load(path)
+	require.NoError(t, err)
+	require.True(t, store.Remove(removed.ID))
+	require.NoError(t, store.Save(path))
+	require.NoError(t, server.Disconnect())
+
+	// The stream of the removed device ends at once, the other one stays open
+	_, err = io.ReadAll(streams[removed.ID])
+	require.NoError(t, err)
+	server.mu.Lock()
+	assert.Len(t, server.subscribers, 1)
+	server.mu.Unlock()
+}
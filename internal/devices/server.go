@@ -0,0 +1,389 @@
+package devices
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CodeTTL is how long a pairing code can be used
+const CodeTTL = 5 * time.Minute
+
+// maxFailedPairings invalidates the pending codes after this many wrong codes, so that they cannot be guessed
+const maxFailedPairings = 5
+
+// heartbeatInterval keeps the event streams open through idle timeouts, and lets devices notice a lost connection
+const heartbeatInterval = 30 * time.Second
+
+// lastSeenInterval limits the writes of the devices file when devices poll the state
+const lastSeenInterval = time.Minute
+
+// State is what the devices receive: the URLs only, the other variables may hold secrets
+type State struct {
+	Project   string            `json:"project"` // name of the project directory
+	IP        string            `json:"ip"`
+	Hostname  string            `json:"hostname,omitempty"` // used in the URLs instead of the IP
+	URLs      map[string]string `json:"urls"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// PairRequest is the body of POST /v1/pair, the code may also be given in the query string
+type PairRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"` // shown by 'lanup pair list'
+}
+
+// PairResponse is returned to a device when it pairs
+type PairResponse struct {
+	ID      string   `json:"id"`
+	Token   string   `json:"token"`   // sent as "Authorization: Bearer <token>", or ?token= by EventSource
+	Servers []string `json:"servers"` // base URLs of this server, to try in turn when the IP changes
+	State   State    `json:"state"`
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the state to the paired devices, and pairs new ones with one-time codes
+type Server struct {
+	path     string // devices file
+	hostname string // mDNS name of the machine, e.g. macbook.local
+
+	mu          sync.Mutex
+	codes       map[string]time.Time // pending pairing codes and their expiry
+	failed      int                  // wrong codes since the last new code
+	state       State
+	subscribers map[*subscriber]struct{}
+	now         func() time.Time
+}
+
+// subscriber is an event stream of a device
+type subscriber struct {
+	token   string
+	states  chan State
+	revoked chan struct{} // closed by Disconnect when the device is unpaired
+}
+
+// NewServer creates a server storing the devices in path
+// hostname, when set, is advertised to the devices so that they find the server after an IP change.
+func NewServer(path, hostname string, state State) *Server {
+	return &Server{
+		path:        path,
+		hostname:    hostname,
+		codes:       make(map[string]time.Time),
+		state:       state,
+		subscribers: make(map[*subscriber]struct{}),
+		now:         time.Now,
+	}
+}
+
+// NewCode returns a pairing code valid for CodeTTL, which pairs a single device
+func (s *Server) NewCode() (string, time.Time, error) {
+	code, err := randomString(5)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for c, expires := range s.codes {
+		if now.After(expires) {
+			delete(s.codes, c)
+		}
+	}
+	expires := now.Add(CodeTTL)
+	s.codes[code] = expires
+	s.failed = 0
+	return code, expires, nil
+}
+
+// useCode consumes a pairing code, and reports whether it was valid
+func (s *Server) useCode(code string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code = strings.ToLower(strings.TrimSpace(code))
+	expires, ok := s.codes[code]
+	if ok && !s.now().After(expires) {
+		delete(s.codes, code)
+		return true
+	}
+
+	s.failed++
+	if s.failed >= maxFailedPairings {
+		s.codes = make(map[string]time.Time)
+	}
+	return false
+}
+
+// Publish sends the state to the connected devices when the URLs changed
+func (s *Server) Publish(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.state
+	s.state = state
+	if state.IP == previous.IP && state.Hostname == previous.Hostname && reflect.DeepEqual(state.URLs, previous.URLs) {
+		return
+	}
+	for sub := range s.subscribers {
+		// A slow device only needs the latest state
+		select {
+		case <-sub.states:
+		default:
+		}
+		sub.states <- state
+	}
+}
+
+// Disconnect closes the event streams of the devices that are no longer paired, such as the
+// ones 'lanup pair remove' just removed from the devices file
+func (s *Server) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+	for sub := range s.subscribers {
+		if store.Authenticate(sub.token) == nil {
+			close(sub.revoked)
+			delete(s.subscribers, sub)
+		}
+	}
+	return nil
+}
+
+// State returns the last published state
+func (s *Server) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// subscribe returns a subscriber of the device with token receiving the published states,
+// until unsubscribe is called
+func (s *Server) subscribe(token string) (*subscriber, func()) {
+	sub := &subscriber{token: token, states: make(chan State, 1), revoked: make(chan struct{})}
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub, func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}
+}
+
+// Handler returns the HTTP handler of the device server
+//
+//	POST /v1/pair    pair with a one-time code, returns the device token
+//	GET  /v1/state   current URLs
+//	GET  /v1/events  current URLs, then the new ones whenever they change (server-sent events)
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/pair", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.pair(w, r)
+	})
+
+	mux.HandleFunc("/v1/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		if s.authenticate(w, r) == "" {
+			return
+		}
+		writeJSON(w, http.StatusOK, s.State())
+	})
+
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		if token := s.authenticate(w, r); token != "" {
+			s.events(w, r, token)
+		}
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "not found"})
+	})
+
+	return mux
+}
+
+// pair checks the code of a new device and returns its token
+func (s *Server) pair(w http.ResponseWriter, r *http.Request) {
+	var req PairRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+	if req.Code == "" {
+		req.Code = r.URL.Query().Get("code")
+	}
+	if req.Name == "" {
+		req.Name = r.URL.Query().Get("name")
+	}
+
+	if !s.useCode(req.Code) {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "invalid or expired pairing code, run 'lanup pair' again"})
+		return
+	}
+
+	s.mu.Lock()
+	store, err := Load(s.path)
+	var device Device
+	var token string
+	if err == nil {
+		device, token, err = store.Add(req.Name, s.now())
+	}
+	if err == nil {
+		err = store.Save(s.path)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, PairResponse{
+		ID:      device.ID,
+		Token:   token,
+		Servers: s.servers(r.Host),
+		State:   s.State(),
+	})
+}
+
+// servers returns the base URLs of the server: the one the device used, then the mDNS hostname
+func (s *Server) servers(host string) []string {
+	servers := []string{"http://" + host}
+	if _, port, err := net.SplitHostPort(host); err == nil && s.hostname != "" {
+		servers = append(servers, "http://"+net.JoinHostPort(s.hostname, port))
+	}
+	return servers
+}
+
+// authenticate returns the token of a paired device, or writes a 401 response and returns an empty string
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		token = r.URL.Query().Get("token")
+	}
+
+	if !s.paired(token, true) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="lanup"`)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unknown device, pair it with 'lanup pair'"})
+		return ""
+	}
+	return token
+}
+
+// paired reports whether token belongs to a paired device, reading the devices file so that
+// 'lanup pair remove' takes effect at once; seen records the time of the request
+func (s *Server) paired(token string, seen bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := Load(s.path)
+	if err != nil {
+		return false
+	}
+	device := store.Authenticate(token)
+	if device == nil {
+		return false
+	}
+	if now := s.now(); seen && now.Sub(device.LastSeen) >= lastSeenInterval {
+		device.LastSeen = now
+		_ = store.Save(s.path)
+	}
+	return true
+}
+
+// events streams the state to a device until it disconnects or is unpaired
+func (s *Server) events(w http.ResponseWriter, r *http.Request, token string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming not supported"})
+		return
+	}
+
+	sub, unsubscribe := s.subscribe(token)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if writeEvent(w, s.State()) != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.revoked:
+			return
+		case state := <-sub.states:
+			if !s.paired(token, false) || writeEvent(w, state) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if !s.paired(token, false) {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a state event of the stream
+func writeEvent(w http.ResponseWriter, state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+	return err
+}
+
+// methodNotAllowed writes a 405 response advertising the allowed method
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+}
+
+// writeJSON writes value as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
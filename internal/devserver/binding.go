@@ -0,0 +1,196 @@
+package devserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScriptPatch is a change to a package.json script making its dev server listen on all interfaces
+type ScriptPatch struct {
+	Framework string
+	Script    string // name of the script, e.g. dev
+	Before    string
+	After     string
+}
+
+// Binding tells how to make the dev servers of a project listen on all interfaces
+type Binding struct {
+	Patches []ScriptPatch     // package.json scripts to change, see ApplyPatches
+	Vars    map[string]string // environment variables read by the frameworks configured by environment
+	Advice  []string          // changes lanup does not make itself
+}
+
+// scriptSeparatorRe splits a script into the commands it runs
+var scriptSeparatorRe = regexp.MustCompile(`&&|\|\||[;&|]`)
+
+// PlanBinding inspects the package.json of dir and returns how to make the dev servers of the
+// frameworks it uses listen on all interfaces
+func PlanBinding(dir string) (*Binding, error) {
+	scripts, err := readPackageScripts(dir)
+	if err != nil {
+		return nil, err
+	}
+	deps := readPackageDependencies(dir)
+
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	binding := &Binding{Vars: make(map[string]string)}
+	for _, fw := range Frameworks {
+		used := deps[fw.Package]
+		for _, name := range names {
+			patched, matched := addHostFlag(scripts[name], fw)
+			used = used || matched
+			if patched != scripts[name] {
+				binding.Patches = append(binding.Patches, ScriptPatch{
+					Framework: fw.Name, Script: name, Before: scripts[name], After: patched,
+				})
+				scripts[name] = patched
+			}
+		}
+		if !used {
+			continue
+		}
+
+		for key, value := range fw.HostVars {
+			binding.Vars[key] = value
+		}
+		if fw.Package == "vite" {
+			if advice := viteConfigAdvice(dir); advice != "" {
+				binding.Advice = append(binding.Advice, advice)
+			}
+		}
+	}
+	return binding, nil
+}
+
+// addHostFlag adds the host flag of fw after each command of fw in script that does not set a host
+// It also reports whether script runs fw at all.
+func addHostFlag(script string, fw Framework) (string, bool) {
+	if len(fw.Commands) == 0 {
+		return script, false
+	}
+
+	var b strings.Builder
+	matched := false
+	last := 0
+	for _, sep := range append(scriptSeparatorRe.FindAllStringIndex(script, -1), []int{len(script), len(script)}) {
+		segment := script[last:sep[0]]
+		for _, command := range fw.Commands {
+			// The command may be called by path, e.g. node_modules/.bin/vite, and its sub-commands
+			// (vite build) are other commands
+			words := strings.Fields(command)
+			for i := range words {
+				words[i] = regexp.QuoteMeta(words[i])
+			}
+			re := regexp.MustCompile(`(^|\s)(\S*/)?` + strings.Join(words, `\s+`) + `(\s|$)`)
+			loc := re.FindStringIndex(segment)
+			if loc == nil {
+				continue
+			}
+			end := loc[1]
+			if end > 0 && strings.TrimSpace(segment[end-1:end]) == "" {
+				end--
+			}
+			if next := strings.Fields(segment[end:]); len(next) > 0 && !strings.HasPrefix(next[0], "-") {
+				continue
+			}
+
+			matched = true
+			if !setsHost(segment) {
+				segment = segment[:end] + " " + fw.HostFlag + segment[end:]
+			}
+		}
+		b.WriteString(segment)
+		b.WriteString(script[sep[0]:sep[1]])
+		last = sep[1]
+	}
+	return b.String(), matched
+}
+
+// setsHost reports whether a command passes a host flag
+func setsHost(command string) bool {
+	for _, field := range strings.Fields(command) {
+		switch {
+		case field == "--host", field == "-H", field == "--hostname",
+			strings.HasPrefix(field, "--host="), strings.HasPrefix(field, "--hostname="):
+			return true
+		}
+	}
+	return false
+}
+
+// viteConfigAdvice suggests setting server.host in the Vite configuration of dir when it has none,
+// so that Vite listens on all interfaces however it is started
+func viteConfigAdvice(dir string) string {
+	for _, name := range []string{"vite.config.ts", "vite.config.js", "vite.config.mts", "vite.config.mjs"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if bytes.Contains(data, []byte("host")) {
+			return ""
+		}
+		return fmt.Sprintf("Set server: { host: true } in %s so that Vite listens on all interfaces however it is started", name)
+	}
+	return ""
+}
+
+// ApplyPatches writes the script patches to the package.json of dir, keeping its formatting
+func ApplyPatches(dir string, patches []ScriptPatch) error {
+	path := filepath.Join(dir, "package.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range patches {
+		before, after := jsonString(p.Before), jsonString(p.After)
+		if !bytes.Contains(data, before) {
+			return fmt.Errorf("script %s not found in %s", p.Script, path)
+		}
+		data = bytes.ReplaceAll(data, before, after)
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
+
+// jsonString encodes s as a JSON string, the way package managers write it
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// readPackageScripts returns the scripts of dir/package.json
+func readPackageScripts(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	if pkg.Scripts == nil {
+		pkg.Scripts = make(map[string]string)
+	}
+	return pkg.Scripts, nil
+}
@@ -0,0 +1,92 @@
+package devserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frameworkNamed(t *testing.T, name string) Framework {
+	for _, fw := range Frameworks {
+		if fw.Name == name {
+			return fw
+		}
+	}
+	t.Fatalf("unknown framework %s", name)
+	return Framework{}
+}
+
+func TestAddHostFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		script    string
+		want      string
+		matched   bool
+	}{
+		{name: "vite", framework: "Vite", script: "vite", want: "vite --host 0.0.0.0", matched: true},
+		{name: "vite with flags", framework: "Vite", script: "vite --port 3000", want: "vite --host 0.0.0.0 --port 3000", matched: true},
+		{name: "vite sub-command", framework: "Vite", script: "vite preview", want: "vite preview --host 0.0.0.0", matched: true},
+		{name: "vite build", framework: "Vite", script: "tsc && vite build", want: "tsc && vite build"},
+		{name: "host already set", framework: "Vite", script: "vite --host", want: "vite --host", matched: true},
+		{name: "by path", framework: "Vite", script: "node_modules/.bin/vite", want: "node_modules/.bin/vite --host 0.0.0.0", matched: true},
+		{name: "next", framework: "Next.js", script: "next dev --turbo", want: "next dev -H 0.0.0.0 --turbo", matched: true},
+		{name: "next in background", framework: "Next.js", script: "next dev & node server.js", want: "next dev -H 0.0.0.0 & node server.js", matched: true},
+		{name: "next hostname set", framework: "Next.js", script: "next dev --hostname=0.0.0.0", want: "next dev --hostname=0.0.0.0", matched: true},
+		{name: "several commands", framework: "Nuxt", script: "nuxi prepare && nuxi dev", want: "nuxi prepare && nuxi dev --host 0.0.0.0", matched: true},
+		{name: "angular", framework: "Angular", script: "ng serve", want: "ng serve --host 0.0.0.0", matched: true},
+		{name: "other command", framework: "Vite", script: "eslint src/vite.ts", want: "eslint src/vite.ts"},
+		{name: "configured by environment", framework: "Create React App", script: "react-scripts start", want: "react-scripts start"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matched := addHostFlag(tt.script, frameworkNamed(t, tt.framework))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.matched, matched)
+		})
+	}
+}
+
+func TestPlanBinding(t *testing.T) {
+	dir := t.TempDir()
+	pkg := `{
+  "scripts": {
+    "dev": "vite --port 3000",
+    "build": "tsc && vite build",
+    "start": "react-scripts start"
+  },
+  "devDependencies": {"vite": "^5.0.0", "react-scripts": "5.0.1"}
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vite.config.ts"), []byte("export default defineConfig({})\n"), 0644))
+
+	binding, err := PlanBinding(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []ScriptPatch{
+		{Framework: "Vite", Script: "dev", Before: "vite --port 3000", After: "vite --host 0.0.0.0 --port 3000"},
+	}, binding.Patches)
+	assert.Equal(t, map[string]string{"HOST": "0.0.0.0"}, binding.Vars)
+	require.Len(t, binding.Advice, 1)
+	assert.Contains(t, binding.Advice[0], "vite.config.ts")
+
+	// Scripts are patched in place, the rest of the file is kept as written
+	require.NoError(t, ApplyPatches(dir, binding.Patches))
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"dev": "vite --host 0.0.0.0 --port 3000",`)
+	assert.Contains(t, string(data), `"devDependencies": {"vite": "^5.0.0", "react-scripts": "5.0.1"}`)
+
+	binding, err = PlanBinding(dir)
+	require.NoError(t, err)
+	assert.Empty(t, binding.Patches)
+}
+
+func TestPlanBinding_NoPackageJSON(t *testing.T) {
+	_, err := PlanBinding(t.TempDir())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
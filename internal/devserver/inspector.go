@@ -0,0 +1,255 @@
+package devserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/net"
+)
+
+// Framework describes a JavaScript dev server that lanup knows how to recognize
+type Framework struct {
+	Name       string            // human-readable framework name
+	Ports      []int             // default ports used by the dev server
+	Signatures []string          // substrings identifying the dev server process command line
+	Package    string            // npm dependency that identifies the framework in package.json
+	VarName    string            // variable generated for the dev server URL
+	HostHint   string            // how to make the dev server listen on all interfaces
+	Commands   []string          // commands of package.json scripts starting the dev server
+	HostFlag   string            // flag added to Commands to listen on all interfaces
+	HostVars   map[string]string // environment variables making the dev server listen on all interfaces
+}
+
+// Frameworks lists the supported dev servers, most specific first
+var Frameworks = []Framework{
+	{
+		Name:       "Vite",
+		Ports:      []int{5173, 4173},
+		Signatures: []string{"bin/vite", "/vite.js", "vite dev", "vite preview", "vite serve"},
+		Package:    "vite",
+		VarName:    "VITE_DEV_SERVER_URL",
+		HostHint:   "vite --host 0.0.0.0",
+		Commands:   []string{"vite", "vite dev", "vite serve", "vite preview"},
+		HostFlag:   "--host 0.0.0.0",
+	},
+	{
+		Name:       "Next.js",
+		Ports:      []int{3000},
+		Signatures: []string{"next dev", "next start", "next-server", "next-router-worker"},
+		Package:    "next",
+		VarName:    "NEXT_PUBLIC_DEV_SERVER_URL",
+		HostHint:   "next dev -H 0.0.0.0",
+		Commands:   []string{"next dev", "next start"},
+		HostFlag:   "-H 0.0.0.0",
+	},
+	{
+		Name:       "Nuxt",
+		Ports:      []int{3000},
+		Signatures: []string{"nuxi dev", "nuxt dev", "nuxi.mjs dev"},
+		Package:    "nuxt",
+		VarName:    "NUXT_PUBLIC_DEV_SERVER_URL",
+		HostHint:   "nuxi dev --host 0.0.0.0",
+		Commands:   []string{"nuxi dev", "nuxt dev"},
+		HostFlag:   "--host 0.0.0.0",
+		HostVars:   map[string]string{"HOST": "0.0.0.0"},
+	},
+	{
+		Name:       "Create React App",
+		Ports:      []int{3000},
+		Signatures: []string{"react-scripts start", "react-scripts/scripts/start"},
+		Package:    "react-scripts",
+		VarName:    "REACT_APP_DEV_SERVER_URL",
+		HostHint:   "HOST=0.0.0.0 npm start",
+		HostVars:   map[string]string{"HOST": "0.0.0.0"},
+	},
+	{
+		Name:       "Angular",
+		Ports:      []int{4200},
+		Signatures: []string{"ng serve"},
+		Package:    "@angular/cli",
+		VarName:    "NG_DEV_SERVER_URL",
+		HostHint:   "ng serve --host 0.0.0.0",
+		Commands:   []string{"ng serve"},
+		HostFlag:   "--host 0.0.0.0",
+	},
+}
+
+// DevServer represents a running dev server detected on this machine
+type DevServer struct {
+	Framework    Framework
+	Port         int
+	Address      string // bind address, empty when it could not be determined
+	PID          int
+	LoopbackOnly bool // true when the server only accepts connections from this machine
+}
+
+// URL returns the localhost URL of the dev server
+func (s DevServer) URL() string {
+	return fmt.Sprintf("http://localhost:%d", s.Port)
+}
+
+// DetectDevServers returns the dev servers currently running on this machine
+// Detection uses listening sockets and process command lines, and falls back to
+// probing default ports when socket information is not available
+func DetectDevServers() ([]DevServer, error) {
+	deps := readPackageDependencies(".")
+
+	ports, err := net.GetListeningPorts()
+	if err != nil {
+		return probeDefaultPorts(deps), nil
+	}
+
+	commands := make(map[int]string)
+	for _, p := range ports {
+		if _, ok := commands[p.PID]; !ok {
+			commands[p.PID] = processCommand(p.PID)
+		}
+	}
+
+	return MatchDevServers(ports, commands, deps), nil
+}
+
+// MatchDevServers identifies dev servers among listening ports
+// A port matches a framework when the owning process command line contains one of the
+// framework signatures, or, when the command line is unknown, when the port is a default
+// port of a framework listed in the project's package.json dependencies
+func MatchDevServers(ports []net.ListeningPort, commands map[int]string, deps map[string]bool) []DevServer {
+	servers := []DevServer{}
+	index := make(map[int]int) // port -> position in servers
+
+	for _, p := range ports {
+		framework, ok := matchFramework(p.Port, commands[p.PID], deps)
+		if !ok {
+			continue
+		}
+
+		// The same server often listens on both IPv4 and IPv6 sockets
+		if i, exists := index[p.Port]; exists {
+			if !p.IsLoopbackOnly() {
+				servers[i].LoopbackOnly = false
+				servers[i].Address = p.Address
+			}
+			continue
+		}
+
+		index[p.Port] = len(servers)
+		servers = append(servers, DevServer{
+			Framework:    framework,
+			Port:         p.Port,
+			Address:      p.Address,
+			PID:          p.PID,
+			LoopbackOnly: p.IsLoopbackOnly(),
+		})
+	}
+
+	return servers
+}
+
+// matchFramework returns the framework matching a listening port
+func matchFramework(port int, command string, deps map[string]bool) (Framework, bool) {
+	command = strings.ToLower(command)
+
+	if command != "" {
+		for _, fw := range Frameworks {
+			for _, sig := range fw.Signatures {
+				if strings.Contains(command, sig) {
+					return fw, true
+				}
+			}
+		}
+		return Framework{}, false
+	}
+
+	for _, fw := range Frameworks {
+		if deps[fw.Package] && containsPort(fw.Ports, port) {
+			return fw, true
+		}
+	}
+
+	return Framework{}, false
+}
+
+// probeDefaultPorts checks the default ports of frameworks used by the project
+func probeDefaultPorts(deps map[string]bool) []DevServer {
+	servers := []DevServer{}
+	probed := make(map[int]bool)
+
+	for _, fw := range Frameworks {
+		if !deps[fw.Package] {
+			continue
+		}
+		for _, port := range fw.Ports {
+			if probed[port] {
+				continue
+			}
+			probed[port] = true
+
+			if net.IsPortOpen("127.0.0.1", port, 200*time.Millisecond) {
+				servers = append(servers, DevServer{Framework: fw, Port: port})
+			}
+		}
+	}
+
+	return servers
+}
+
+// processCommand returns the full command line of a process, or an empty string if unknown
+func processCommand(pid int) string {
+	// Linux exposes the command line with NUL-separated arguments
+	if data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline")); err == nil {
+		return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+	}
+
+	cmd := exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid))
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(out.String())
+}
+
+// readPackageDependencies returns the npm dependencies declared in dir/package.json
+func readPackageDependencies(dir string) map[string]bool {
+	deps := make(map[string]bool)
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return deps
+	}
+
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return deps
+	}
+
+	for name := range pkg.Dependencies {
+		deps[name] = true
+	}
+	for name := range pkg.DevDependencies {
+		deps[name] = true
+	}
+
+	return deps
+}
+
+// containsPort checks whether port is in ports
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package devserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDevServers_BySignature(t *testing.T) {
+	ports := []net.ListeningPort{
+		{Port: 5173, Address: "127.0.0.1", PID: 100, Process: "node"},
+		{Port: 3000, Address: "*", PID: 200, Process: "node"},
+		{Port: 4200, Address: "0.0.0.0", PID: 300, Process: "node"},
+		{Port: 5432, Address: "0.0.0.0", PID: 400, Process: "postgres"},
+	}
+	commands := map[int]string{
+		100: "node /app/node_modules/.bin/vite",
+		200: "node /app/node_modules/.bin/next dev",
+		300: "ng serve",
+		400: "postgres -D /var/lib/postgres",
+	}
+
+	servers := MatchDevServers(ports, commands, map[string]bool{})
+	require.Len(t, servers, 3)
+
+	assert.Equal(t, "Vite", servers[0].Framework.Name)
+	assert.Equal(t, 5173, servers[0].Port)
+	assert.True(t, servers[0].LoopbackOnly)
+	assert.Equal(t, "http://localhost:5173", servers[0].URL())
+
+	assert.Equal(t, "Next.js", servers[1].Framework.Name)
+	assert.Equal(t, "NEXT_PUBLIC_DEV_SERVER_URL", servers[1].Framework.VarName)
+	assert.False(t, servers[1].LoopbackOnly)
+
+	assert.Equal(t, "Angular", servers[2].Framework.Name)
+}
+
+func TestMatchDevServers_MergesIPv4AndIPv6Sockets(t *testing.T) {
+	ports := []net.ListeningPort{
+		{Port: 5173, Address: "::1", PID: 100},
+		{Port: 5173, Address: "0.0.0.0", PID: 100},
+	}
+	commands := map[int]string{100: "node node_modules/vite/bin/vite.js"}
+
+	servers := MatchDevServers(ports, commands, nil)
+	require.Len(t, servers, 1)
+	assert.False(t, servers[0].LoopbackOnly)
+	assert.Equal(t, "0.0.0.0", servers[0].Address)
+}
+
+func TestMatchDevServers_UnknownCommandUsesPackageJSON(t *testing.T) {
+	ports := []net.ListeningPort{
+		{Port: 3000, Address: "*", PID: 100},
+		{Port: 8080, Address: "*", PID: 200},
+	}
+
+	servers := MatchDevServers(ports, map[int]string{}, map[string]bool{"react-scripts": true})
+	require.Len(t, servers, 1)
+	assert.Equal(t, "Create React App", servers[0].Framework.Name)
+	assert.Equal(t, "REACT_APP_DEV_SERVER_URL", servers[0].Framework.VarName)
+
+	servers = MatchDevServers(ports, map[int]string{}, map[string]bool{})
+	assert.Empty(t, servers)
+}
+
+func TestMatchDevServers_IgnoresUnrelatedPaths(t *testing.T) {
+	ports := []net.ListeningPort{{Port: 5173, Address: "*", PID: 100}}
+	commands := map[int]string{100: "node /home/dev/invite-app/server.js"}
+
+	assert.Empty(t, MatchDevServers(ports, commands, map[string]bool{"vite": true}))
+}
+
+func TestReadPackageDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	content := `{"dependencies": {"next": "14.0.0"}, "devDependencies": {"vite": "5.0.0"}}`
+	err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(content), 0644)
+	require.NoError(t, err)
+
+	deps := readPackageDependencies(tmpDir)
+	assert.True(t, deps["next"])
+	assert.True(t, deps["vite"])
+	assert.False(t, deps["react-scripts"])
+
+	assert.Empty(t, readPackageDependencies(t.TempDir()))
+}
@@ -0,0 +1,74 @@
+package devserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MetroPorts lists the ports probed for a Metro bundler (React Native default, then legacy Expo CLI)
+var MetroPorts = []int{8081, 19000}
+
+// MetroServer represents a running Metro bundler (React Native or Expo)
+type MetroServer struct {
+	Port int
+}
+
+// URL returns the localhost URL of the Metro bundler
+func (m MetroServer) URL() string {
+	return fmt.Sprintf("http://localhost:%d", m.Port)
+}
+
+// ExpoURL returns the URL to open the project in Expo Go from another device
+func (m MetroServer) ExpoURL(ip string) string {
+	return fmt.Sprintf("exp://%s:%d", ip, m.Port)
+}
+
+// Vars returns the variables Expo and React Native need to reach this machine
+// REACT_NATIVE_PACKAGER_HOSTNAME is emitted as "localhost" so that it is transformed
+// to the LAN IP like every other variable. EXPO_PUBLIC_API_URL mirrors the project's
+// API_URL, since Expo only exposes EXPO_PUBLIC_* variables to the app bundle.
+func (m MetroServer) Vars(projectVars map[string]string) map[string]string {
+	vars := map[string]string{
+		"REACT_NATIVE_PACKAGER_HOSTNAME": "localhost",
+	}
+
+	if apiURL, ok := projectVars["API_URL"]; ok {
+		if _, defined := projectVars["EXPO_PUBLIC_API_URL"]; !defined {
+			vars["EXPO_PUBLIC_API_URL"] = apiURL
+		}
+	}
+
+	return vars
+}
+
+// DetectMetro returns the Metro bundler running on this machine
+func DetectMetro() (*MetroServer, error) {
+	client := &http.Client{Timeout: 500 * time.Millisecond}
+
+	for _, port := range MetroPorts {
+		if isMetroRunning(client, fmt.Sprintf("http://127.0.0.1:%d/status", port)) {
+			return &MetroServer{Port: port}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no Metro bundler found on ports %v", MetroPorts)
+}
+
+// isMetroRunning checks the Metro status endpoint, which answers "packager-status:running"
+func isMetroRunning(client *http.Client, statusURL string) bool {
+	resp, err := client.Get(statusURL)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(body), "packager-status:running")
+}
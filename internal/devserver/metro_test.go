@@ -0,0 +1,51 @@
+package devserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetroServer_URLs(t *testing.T) {
+	m := MetroServer{Port: 8081}
+
+	assert.Equal(t, "http://localhost:8081", m.URL())
+	assert.Equal(t, "exp://192.168.1.100:8081", m.ExpoURL("192.168.1.100"))
+}
+
+func TestMetroServer_Vars(t *testing.T) {
+	m := MetroServer{Port: 8081}
+
+	vars := m.Vars(map[string]string{"API_URL": "http://localhost:8000"})
+	assert.Equal(t, "localhost", vars["REACT_NATIVE_PACKAGER_HOSTNAME"])
+	assert.Equal(t, "http://localhost:8000", vars["EXPO_PUBLIC_API_URL"])
+
+	// Without API_URL only the packager hostname is generated
+	vars = m.Vars(map[string]string{})
+	assert.Len(t, vars, 1)
+
+	// An explicitly configured EXPO_PUBLIC_API_URL wins
+	vars = m.Vars(map[string]string{
+		"API_URL":             "http://localhost:8000",
+		"EXPO_PUBLIC_API_URL": "http://localhost:9000",
+	})
+	assert.NotContains(t, vars, "EXPO_PUBLIC_API_URL")
+}
+
+func TestIsMetroRunning(t *testing.T) {
+	metro := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("packager-status:running"))
+	}))
+	defer metro.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer other.Close()
+
+	assert.True(t, isMetroRunning(metro.Client(), metro.URL+"/status"))
+	assert.False(t, isMetroRunning(other.Client(), other.URL+"/status"))
+	assert.False(t, isMetroRunning(other.Client(), "http://127.0.0.1:1/status"))
+}
@@ -177,3 +177,69 @@ func parseSupabaseStatus(output string) (map[string]int, error) {
 
 	return services, nil
 }
+
+// supabaseURLLineRe matches the URL lines of 'supabase status', e.g. "API URL: http://127.0.0.1:54321"
+var supabaseURLLineRe = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9 ]*?) URL:\s*(\S+://\S+)\s*$`)
+
+// GetSupabaseURLs returns the URLs of the local Supabase services keyed like the variables of
+// 'supabase status -o env' (API_URL, DB_URL, STUDIO_URL...), the keys and secrets excepted
+// CLI versions without the env output are parsed from the plain status.
+func GetSupabaseURLs() (map[string]string, error) {
+	if _, err := exec.LookPath("supabase"); err != nil {
+		return nil, fmt.Errorf("supabase CLI is not installed or not available in PATH")
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("supabase", "status", "-o", "env")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err == nil {
+		if urls := parseSupabaseEnv(out.String()); len(urls) > 0 {
+			return urls, nil
+		}
+	}
+
+	out.Reset()
+	cmd = exec.Command("supabase", "status")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to execute supabase status: %w", err)
+	}
+	urls := parseSupabaseStatusURLs(out.String())
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no supabase services found in status output")
+	}
+	return urls, nil
+}
+
+// parseSupabaseEnv returns the *_URL variables of 'supabase status -o env', whose lines look like
+// API_URL="http://127.0.0.1:54321"
+func parseSupabaseEnv(output string) map[string]string {
+	urls := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || !strings.HasSuffix(key, "_URL") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if strings.Contains(value, "://") {
+			urls[key] = value
+		}
+	}
+	return urls
+}
+
+// parseSupabaseStatusURLs returns the URLs of the plain 'supabase status' output, "GraphQL URL" keyed GRAPHQL_URL
+func parseSupabaseStatusURLs(output string) map[string]string {
+	urls := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		matches := supabaseURLLineRe.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		key := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(matches[1]), " ", "_")) + "_URL"
+		urls[key] = matches[2]
+	}
+	return urls
+}
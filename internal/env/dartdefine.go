@@ -0,0 +1,114 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DartDefineManagedKey lists the managed variables of a dart-define file, since JSON has no comments:
+// "API_URL,DOCKER_DB_URL:docker", each name followed by the source of detected variables
+const DartDefineManagedKey = "lanup:managed"
+
+// DartDefineFormatter reads and writes the flat JSON objects Flutter reads with
+// --dart-define-from-file, the managed variables listed under DartDefineManagedKey
+type DartDefineFormatter struct{}
+
+// Name returns the name of the format
+func (DartDefineFormatter) Name() string {
+	return "dart-define"
+}
+
+// Parse reads the members of the object in order, numbers and booleans as their text
+func (DartDefineFormatter) Parse(r io.Reader) ([]EnvVar, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("invalid dart-define file: not a JSON object")
+	}
+
+	var vars []EnvVar
+	sources := make(map[string]string)
+	managed := make(map[string]bool)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid dart-define file: %w", err)
+		}
+		key, _ := t.(string)
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid dart-define file: %w", err)
+		}
+
+		if key == DartDefineManagedKey {
+			list, _ := value.(string)
+			for _, name := range strings.Split(list, ",") {
+				name, source, _ := strings.Cut(strings.TrimSpace(name), ":")
+				if name != "" {
+					managed[name] = true
+					sources[name] = source
+				}
+			}
+			continue
+		}
+
+		switch value := value.(type) {
+		case string:
+			vars = append(vars, EnvVar{Key: key, Value: value})
+		case json.Number, bool:
+			vars = append(vars, EnvVar{Key: key, Value: fmt.Sprint(value)})
+		default:
+			return nil, fmt.Errorf("invalid dart-define file: %s must be a string, a number or a boolean", key)
+		}
+	}
+
+	for i := range vars {
+		if managed[vars[i].Key] {
+			vars[i].Managed = true
+			vars[i].Source = sources[vars[i].Key]
+		}
+	}
+	return vars, nil
+}
+
+// Render writes the list of the managed variables, the managed variables and then the user variables
+func (DartDefineFormatter) Render(w io.Writer, vars []EnvVar) error {
+	managedVars, userVars := splitManaged(vars)
+
+	names := make([]string, len(managedVars))
+	for i, v := range managedVars {
+		names[i] = v.Key
+		if v.Source != "" {
+			names[i] += ":" + v.Source
+		}
+	}
+
+	members := []string{fmt.Sprintf("  %s: %s", jsonString(DartDefineManagedKey), jsonString(strings.Join(names, ",")))}
+	for _, v := range append(managedVars, userVars...) {
+		members = append(members, fmt.Sprintf("  %s: %s", jsonString(v.Key), jsonString(v.Value)))
+	}
+	if _, err := fmt.Fprintf(w, "{\n%s\n}\n", strings.Join(members, ",\n")); err != nil {
+		return fmt.Errorf("failed to write variables: %w", err)
+	}
+	return nil
+}
+
+// jsonString encodes s as a JSON string, leaving <, > and & as they are
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
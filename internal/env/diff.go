@@ -0,0 +1,63 @@
+package env
+
+import "sort"
+
+// ChangeKind describes how a managed variable differs between two states
+type ChangeKind int
+
+const (
+	// Unchanged means the variable has the same value in both states
+	Unchanged ChangeKind = iota
+	// Added means the variable is missing from the current state
+	Added
+	// Removed means the variable is no longer generated
+	Removed
+	// Modified means the variable has a stale value
+	Modified
+)
+
+// Change is the difference of a managed variable between the current and desired states
+type Change struct {
+	Key      string
+	Kind     ChangeKind
+	OldValue string
+	NewValue string
+}
+
+// Diff compares the managed variables of current (e.g. read from the env file) with the
+// desired managed variables, and returns one change per variable sorted by key
+// User variables in current are ignored since lanup never modifies them
+func Diff(current []EnvVar, desired []EnvVar) []Change {
+	old := make(map[string]string)
+	for _, v := range current {
+		if v.Managed {
+			old[v.Key] = v.Value
+		}
+	}
+
+	changes := make([]Change, 0, len(desired)+len(old))
+	seen := make(map[string]bool)
+	for _, v := range desired {
+		seen[v.Key] = true
+		oldValue, ok := old[v.Key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: v.Key, Kind: Added, NewValue: v.Value})
+		case oldValue != v.Value:
+			changes = append(changes, Change{Key: v.Key, Kind: Modified, OldValue: oldValue, NewValue: v.Value})
+		default:
+			changes = append(changes, Change{Key: v.Key, Kind: Unchanged, OldValue: oldValue, NewValue: v.Value})
+		}
+	}
+	for key, value := range old {
+		if !seen[key] {
+			changes = append(changes, Change{Key: key, Kind: Removed, OldValue: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
@@ -0,0 +1,47 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	current := []EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000", Managed: true},
+		{Key: "WEB_URL", Value: "http://192.168.1.104:3000", Managed: true},
+		{Key: "OLD_URL", Value: "http://192.168.1.100:9000", Managed: true},
+		{Key: "SECRET", Value: "user-value", Managed: false},
+	}
+	desired := []EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.104:8000", Managed: true},
+		{Key: "WEB_URL", Value: "http://192.168.1.104:3000", Managed: true},
+		{Key: "NEW_URL", Value: "http://192.168.1.104:4000", Managed: true},
+	}
+
+	changes := Diff(current, desired)
+
+	assert.Equal(t, []Change{
+		{Key: "API_URL", Kind: Modified, OldValue: "http://192.168.1.100:8000", NewValue: "http://192.168.1.104:8000"},
+		{Key: "NEW_URL", Kind: Added, NewValue: "http://192.168.1.104:4000"},
+		{Key: "OLD_URL", Kind: Removed, OldValue: "http://192.168.1.100:9000"},
+		{Key: "WEB_URL", Kind: Unchanged, OldValue: "http://192.168.1.104:3000", NewValue: "http://192.168.1.104:3000"},
+	}, changes)
+}
+
+func TestDiff_EmptyCurrent(t *testing.T) {
+	changes := Diff(nil, []EnvVar{{Key: "API_URL", Value: "http://192.168.1.104:8000", Managed: true}})
+
+	assert.Len(t, changes, 1)
+	assert.Equal(t, Added, changes[0].Kind)
+}
+
+func TestDiff_UserVariablesIgnored(t *testing.T) {
+	// A user variable with the same name as a generated one is not the managed value
+	current := []EnvVar{{Key: "API_URL", Value: "https://api.example.com", Managed: false}}
+	desired := []EnvVar{{Key: "API_URL", Value: "http://192.168.1.104:8000", Managed: true}}
+
+	changes := Diff(current, desired)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, Added, changes[0].Kind)
+}
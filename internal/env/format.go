@@ -0,0 +1,136 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ManagedMarker is the comment written before each variable managed by lanup
+const ManagedMarker = "# lanup:managed"
+
+// Formatter reads and writes env files in a given format
+// Parse must recognize the managed variables written by Render, so that they can be
+// replaced on the next run while the user variables are preserved
+type Formatter interface {
+	// Name returns the name of the format
+	Name() string
+	// Parse reads the variables of a file, with Managed set for the ones lanup wrote
+	Parse(r io.Reader) ([]EnvVar, error)
+	// Render writes the variables, the managed ones marked as such
+	Render(w io.Writer, vars []EnvVar) error
+}
+
+// DotenvFormatter reads and writes KEY=VALUE files, each managed variable preceded by ManagedMarker
+// and the source of detected variables: "# lanup:managed source=docker"
+type DotenvFormatter struct{}
+
+// Name returns the name of the format
+func (DotenvFormatter) Name() string {
+	return "dotenv"
+}
+
+// Parse reads KEY=VALUE lines, a variable is managed when the line above is the marker
+func (DotenvFormatter) Parse(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	managed := false
+	source := ""
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Check for lanup:managed marker, followed by the source of detected variables
+		if _, after, ok := strings.Cut(line, ManagedMarker); ok {
+			managed = true
+			source = strings.TrimPrefix(strings.TrimSpace(after), "source=")
+			continue
+		}
+
+		// Skip empty lines and comments (except managed marker)
+		if line == "" || strings.HasPrefix(line, "#") {
+			managed = false
+			source = ""
+			continue
+		}
+
+		// Parse KEY=VALUE
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		// Remove quotes if present
+		value = strings.Trim(value, "\"'")
+
+		v := EnvVar{Key: key, Value: value, Managed: managed}
+		if managed {
+			v.Source = source
+		}
+		vars = append(vars, v)
+
+		managed = false
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// Render writes a header, the managed variables and then the user variables
+func (DotenvFormatter) Render(w io.Writer, vars []EnvVar) error {
+	// Write header
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	header := fmt.Sprintf("# Generated by lanup on %s\n# Do not edit the managed variables manually\n\n", timestamp)
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	managedVars, userVars := splitManaged(vars)
+
+	// Write managed variables
+	for _, v := range managedVars {
+		marker := ManagedMarker
+		if v.Source != "" {
+			marker += " source=" + v.Source
+		}
+		if _, err := fmt.Fprintf(w, "%s\n%s=%s\n", marker, v.Key, v.Value); err != nil {
+			return fmt.Errorf("failed to write variable: %w", err)
+		}
+	}
+
+	// Write separator if there are user variables
+	if len(userVars) > 0 {
+		if _, err := io.WriteString(w, "\n# User variables (preserved)\n"); err != nil {
+			return fmt.Errorf("failed to write separator: %w", err)
+		}
+
+		// Write user variables
+		for _, v := range userVars {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", v.Key, v.Value); err != nil {
+				return fmt.Errorf("failed to write variable: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitManaged separates the managed variables from the user variables, keeping their order
+func splitManaged(vars []EnvVar) (managed, user []EnvVar) {
+	for _, v := range vars {
+		if v.Managed {
+			managed = append(managed, v)
+		} else {
+			user = append(user, v)
+		}
+	}
+	return managed, user
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportFormatter writes shell exports, marking the managed ones with a trailing comment
+type exportFormatter struct{}
+
+func (exportFormatter) Name() string { return "export" }
+
+func (exportFormatter) Parse(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, managed := strings.CutSuffix(scanner.Text(), " # lanup")
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if ok {
+			vars = append(vars, EnvVar{Key: key, Value: value, Managed: managed})
+		}
+	}
+	return vars, scanner.Err()
+}
+
+func (exportFormatter) Render(w io.Writer, vars []EnvVar) error {
+	for _, v := range vars {
+		suffix := ""
+		if v.Managed {
+			suffix = " # lanup"
+		}
+		if _, err := fmt.Fprintf(w, "export %s=%s%s\n", v.Key, v.Value, suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDotenvFormatter_RoundTrip(t *testing.T) {
+	vars := []EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.100:8000", Managed: true},
+		{Key: "DB_URL", Value: "postgresql://192.168.1.100:5432", Managed: true, Source: "docker"},
+		{Key: "SECRET_KEY", Value: "my-secret", Managed: false},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, DotenvFormatter{}.Render(&buf, vars))
+	assert.Contains(t, buf.String(), ManagedMarker+"\nAPI_URL=http://192.168.1.100:8000\n")
+	assert.Contains(t, buf.String(), ManagedMarker+" source=docker\nDB_URL=postgresql://192.168.1.100:5432\n")
+
+	parsed, err := DotenvFormatter{}.Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, vars, parsed)
+}
+
+func TestEnvWriter_CustomFormatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.sh")
+	require.NoError(t, os.WriteFile(path, []byte("export API_URL=http://old:8000 # lanup\nexport TOKEN=abc\n"), 0644))
+
+	writer := NewEnvWriter(path)
+	writer.Formatter = exportFormatter{}
+	existing, err := writer.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []EnvVar{
+		{Key: "API_URL", Value: "http://old:8000", Managed: true},
+		{Key: "TOKEN", Value: "abc", Managed: false},
+	}, existing)
+
+	merged := writer.Merge([]EnvVar{{Key: "API_URL", Value: "http://192.168.1.100:8000", Managed: true}}, existing)
+	require.NoError(t, writer.Write(merged))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export API_URL=http://192.168.1.100:8000 # lanup\nexport TOKEN=abc\n", string(content))
+}
+
+func TestDartDefineFormatter_RoundTrip(t *testing.T) {
+	vars := []EnvVar{
+		{Key: "FLAVOR", Value: "dev", Managed: false},
+		{Key: "API_URL", Value: "http://192.168.1.100:8000/?a=1&b=<2>", Managed: true},
+		{Key: "DB_URL", Value: "postgresql://192.168.1.100:5432", Managed: true, Source: "docker"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, DartDefineFormatter{}.Render(&buf, vars))
+	assert.Equal(t, `{
+  "lanup:managed": "API_URL,DB_URL:docker",
+  "API_URL": "http://192.168.1.100:8000/?a=1&b=<2>",
+  "DB_URL": "postgresql://192.168.1.100:5432",
+  "FLAVOR": "dev"
+}
+`, buf.String())
+
+	parsed, err := DartDefineFormatter{}.Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []EnvVar{vars[1], vars[2], vars[0]}, parsed)
+}
+
+func TestDartDefineFormatter_Parse(t *testing.T) {
+	// Values written by hand may be numbers or booleans
+	parsed, err := DartDefineFormatter{}.Parse(strings.NewReader(`{"PORT": 8080, "DEBUG": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, []EnvVar{{Key: "PORT", Value: "8080"}, {Key: "DEBUG", Value: "true"}}, parsed)
+
+	parsed, err = DartDefineFormatter{}.Parse(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, parsed)
+
+	_, err = DartDefineFormatter{}.Parse(strings.NewReader(`["API_URL"]`))
+	assert.ErrorContains(t, err, "not a JSON object")
+	_, err = DartDefineFormatter{}.Parse(strings.NewReader(`{"HOSTS": ["a", "b"]}`))
+	assert.ErrorContains(t, err, "HOSTS must be a string")
+}
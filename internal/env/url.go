@@ -0,0 +1,111 @@
+package env
+
+import (
+	"net/url"
+	"strings"
+)
+
+// localHosts are the hostnames TransformURL replaces
+var localHosts = []string{"localhost", "127.0.0.1"}
+
+// TransformURL replaces localhost or 127.0.0.1 with host
+// URLs of any scheme (http, ws, postgres, redis...) are parsed so that only the host is replaced:
+// credentials, path and query are kept as written, but for the hosts of the URLs they contain,
+// e.g. ?redirect=http://localhost:3000. In values that are not URLs, such as localhost:6379
+// or host=localhost, every localhost that is a whole hostname is replaced.
+func TransformURL(value, host string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return replaceHostnames(value, host, "")
+	}
+
+	// The authority runs from // to the path, query or fragment, its hosts follow the credentials.
+	// It may list several hosts, e.g. mongodb://localhost:27017,localhost:27018
+	start := strings.Index(value, "//") + 2
+	end := len(value)
+	if i := strings.IndexAny(value[start:], "/?#"); i >= 0 {
+		end = start + i
+	}
+	hostsStart := start + strings.LastIndex(value[start:end], "@") + 1
+
+	authorityHost := host
+	if strings.Contains(host, ":") {
+		authorityHost = "[" + host + "]"
+	}
+	return value[:hostsStart] +
+		replaceHostnames(value[hostsStart:end], authorityHost, "") +
+		replaceHostnames(value[end:], host, "//")
+}
+
+// PublicURL returns value without its credentials when it is an http(s) or ws(s) URL, which other
+// devices and people may see: database DSNs and the like are of no use to them and may hold passwords
+func PublicURL(value string) (string, bool) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ws", "wss":
+	default:
+		return "", false
+	}
+	if u.User != nil {
+		u.User = nil
+		return u.String(), true
+	}
+	return value, true
+}
+
+// ReplaceLocalhost replaces every localhost or 127.0.0.1 of s that is a whole hostname with host
+func ReplaceLocalhost(s, host string) string {
+	return replaceHostnames(s, host, "")
+}
+
+// ReplaceLocalhostFunc replaces every localhost or 127.0.0.1 of s that is a whole hostname with
+// the result of fn for the name replaced, in order
+func ReplaceLocalhostFunc(s string, fn func(name string) string) string {
+	return replaceNames(s, localHosts, "", fn)
+}
+
+// ReplaceHostname replaces every name of s that is a whole hostname with host
+func ReplaceHostname(s, name, host string) string {
+	return ReplaceHostnameFunc(s, name, func(string) string { return host })
+}
+
+// ReplaceHostnameFunc replaces every name of s that is a whole hostname with the result of fn, in order
+func ReplaceHostnameFunc(s, name string, fn func(name string) string) string {
+	return replaceNames(s, []string{name}, "", fn)
+}
+
+// replaceHostnames replaces the local hostnames of s that follow prefix with host
+// Only whole names are replaced, not the ones part of a longer name such as localhost_db.
+func replaceHostnames(s, host, prefix string) string {
+	return replaceNames(s, localHosts, prefix, func(string) string { return host })
+}
+
+// replaceNames replaces the names of s that follow prefix and are whole hostnames with the result of fn
+func replaceNames(s string, names []string, prefix string, fn func(name string) string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(s); i++ {
+		for _, name := range names {
+			end := i + len(name)
+			if !strings.HasPrefix(s[i:], name) || !strings.HasSuffix(s[:i], prefix) ||
+				(i > 0 && isHostnameChar(s[i-1])) || (end < len(s) && isHostnameChar(s[end])) {
+				continue
+			}
+			b.WriteString(s[last:i])
+			b.WriteString(fn(name))
+			last = end
+			i = end - 1
+			break
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isHostnameChar reports whether c can be part of a hostname
+func isHostnameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_'
+}
@@ -1,25 +1,35 @@
 package env
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
+
+	"github.com/raucheacho/lanup/internal/backup"
+	"github.com/raucheacho/lanup/internal/logger"
+	"github.com/raucheacho/lanup/internal/remote"
 )
 
 // EnvVar represents a single environment variable
 type EnvVar struct {
 	Key     string
 	Value   string
-	Managed bool // true if managed by lanup
+	Managed bool   // true if managed by lanup
+	Source  string // detector that provided a managed variable, empty for configured variables
 }
 
 // EnvWriter handles reading and writing environment files
+// FilePath may be an ssh:// target, the file is then read and written on that machine (see internal/remote).
 type EnvWriter struct {
 	FilePath      string
 	BackupEnabled bool
+	Store         *backup.Store  // also keeps timestamped backups when set
+	Formatter     Formatter      // dotenv when nil
+	Logger        *logger.Logger // optional
 }
 
 // NewEnvWriter creates a new EnvWriter instance
@@ -27,12 +37,21 @@ func NewEnvWriter(path string) *EnvWriter {
 	return &EnvWriter{
 		FilePath:      path,
 		BackupEnabled: true,
+		Formatter:     DotenvFormatter{},
 	}
 }
 
-// Read parses an existing .env file and returns the variables
+// formatter returns the format of the file
+func (w *EnvWriter) formatter() Formatter {
+	if w.Formatter == nil {
+		return DotenvFormatter{}
+	}
+	return w.Formatter
+}
+
+// Read parses an existing env file and returns the variables
 func (w *EnvWriter) Read() ([]EnvVar, error) {
-	file, err := os.Open(w.FilePath)
+	data, err := w.readFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist yet, return empty slice
@@ -40,75 +59,78 @@ func (w *EnvWriter) Read() ([]EnvVar, error) {
 		}
 		return nil, fmt.Errorf("failed to open file %s: %w", w.FilePath, err)
 	}
-	defer file.Close()
-
-	var vars []EnvVar
-	scanner := bufio.NewScanner(file)
-	managed := false
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Check for lanup:managed marker
-		if strings.Contains(line, "# lanup:managed") {
-			managed = true
-			continue
-		}
+	vars, err := w.formatter().Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", w.FilePath, err)
+	}
 
-		// Skip empty lines and comments (except managed marker)
-		if line == "" || strings.HasPrefix(line, "#") {
-			managed = false
-			continue
-		}
+	return vars, nil
+}
 
-		// Parse KEY=VALUE
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+// Exists reports whether the env file exists
+func (w *EnvWriter) Exists() (bool, error) {
+	if remote.IsRemote(w.FilePath) {
+		target, err := remote.ParseSSH(w.FilePath)
+		if err != nil {
+			return false, err
 		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = strings.Trim(value, "\"'")
-
-		vars = append(vars, EnvVar{
-			Key:     key,
-			Value:   value,
-			Managed: managed,
-		})
-
-		managed = false
+		return target.Exists()
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file %s: %w", w.FilePath, err)
+	_, err := os.Stat(w.FilePath)
+	if os.IsNotExist(err) {
+		return false, nil
 	}
-
-	return vars, nil
+	return err == nil, err
 }
 
-// Backup creates a backup of the existing file with .bak extension
+// Backup creates a backup of the existing file with .bak extension, and a timestamped one in Store when set
 func (w *EnvWriter) Backup() error {
-	// Check if the file exists
-	if _, err := os.Stat(w.FilePath); os.IsNotExist(err) {
-		// No file to backup
-		return nil
-	}
-
 	backupPath := w.FilePath + ".bak"
 
-	// Read the original file
-	data, err := os.ReadFile(w.FilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file for backup: %w", err)
+	if remote.IsRemote(w.FilePath) {
+		target, err := remote.ParseSSH(w.FilePath)
+		if err != nil {
+			return err
+		}
+		if err := target.Backup(); err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
+	} else {
+		// Check if the file exists
+		if _, err := os.Stat(w.FilePath); os.IsNotExist(err) {
+			// No file to backup
+			return nil
+		}
+
+		// Read the original file
+		data, err := os.ReadFile(w.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read file for backup: %w", err)
+		}
+
+		// Write to backup file
+		err = os.WriteFile(backupPath, data, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
 	}
 
-	// Write to backup file
-	err = os.WriteFile(backupPath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to create backup file: %w", err)
+	if w.Store != nil {
+		data, err := w.readFile()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read file for backup: %w", err)
+		}
+		if err == nil {
+			if err := w.Store.Save(w.FilePath, data, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.Logger != nil {
+		w.Logger.Debug("Created backup", logger.Field{Key: "path", Value: backupPath})
 	}
 
 	return nil
@@ -140,6 +162,33 @@ func (w *EnvWriter) Merge(newVars []EnvVar, existing []EnvVar) []EnvVar {
 	return result
 }
 
+// Stale returns the managed variables of existing that are missing from newVars,
+// the ones Merge drops because their service or configuration entry disappeared
+func Stale(newVars []EnvVar, existing []EnvVar) []EnvVar {
+	current := make(map[string]bool, len(newVars))
+	for _, v := range newVars {
+		current[v.Key] = true
+	}
+
+	var stale []EnvVar
+	for _, v := range existing {
+		if v.Managed && !current[v.Key] {
+			stale = append(stale, v)
+		}
+	}
+	return stale
+}
+
+// Restore replaces the file with data, such as the content of a backup, backing it up first
+func (w *EnvWriter) Restore(data []byte) error {
+	if w.BackupEnabled {
+		if err := w.Backup(); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+	return w.writeFile(data)
+}
+
 // Write writes the environment variables to the file with proper formatting
 func (w *EnvWriter) Write(vars []EnvVar) error {
 	// Create backup if enabled
@@ -149,79 +198,64 @@ func (w *EnvWriter) Write(vars []EnvVar) error {
 		}
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(w.FilePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	var buf bytes.Buffer
+	if err := w.formatter().Render(&buf, vars); err != nil {
+		return err
 	}
-
-	// Open file for writing
-	file, err := os.Create(w.FilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+	if err := w.writeFile(buf.Bytes()); err != nil {
+		return err
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
 
-	// Write header
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	header := fmt.Sprintf("# Generated by lanup on %s\n# Do not edit the managed variables manually\n\n", timestamp)
-	if _, err := writer.WriteString(header); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
+	if w.Logger != nil {
+		managed := 0
+		for _, v := range vars {
+			if v.Managed {
+				managed++
+			}
+		}
+		w.Logger.Debug("Wrote env file",
+			logger.Field{Key: "path", Value: w.FilePath},
+			logger.Field{Key: "format", Value: w.formatter().Name()},
+			logger.Field{Key: "managed", Value: managed},
+			logger.Field{Key: "user", Value: len(vars) - managed})
 	}
 
-	// Separate managed and non-managed variables
-	var managedVars []EnvVar
-	var userVars []EnvVar
+	return nil
+}
 
-	for _, v := range vars {
-		if v.Managed {
-			managedVars = append(managedVars, v)
-		} else {
-			userVars = append(userVars, v)
+// readFile returns the content of the env file, locally or over SSH
+func (w *EnvWriter) readFile() ([]byte, error) {
+	if remote.IsRemote(w.FilePath) {
+		target, err := remote.ParseSSH(w.FilePath)
+		if err != nil {
+			return nil, err
 		}
+		return target.ReadFile()
 	}
+	return os.ReadFile(w.FilePath)
+}
 
-	// Write managed variables
-	for _, v := range managedVars {
-		if _, err := writer.WriteString("# lanup:managed\n"); err != nil {
-			return fmt.Errorf("failed to write managed marker: %w", err)
+// writeFile replaces the content of the env file, locally or over SSH
+func (w *EnvWriter) writeFile(data []byte) error {
+	if remote.IsRemote(w.FilePath) {
+		target, err := remote.ParseSSH(w.FilePath)
+		if err != nil {
+			return err
 		}
-		if _, err := writer.WriteString(fmt.Sprintf("%s=%s\n", v.Key, v.Value)); err != nil {
-			return fmt.Errorf("failed to write variable: %w", err)
+		if err := target.WriteFile(data); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
 		}
+		return nil
 	}
 
-	// Write separator if there are user variables
-	if len(userVars) > 0 {
-		if _, err := writer.WriteString("\n# User variables (preserved)\n"); err != nil {
-			return fmt.Errorf("failed to write separator: %w", err)
-		}
-
-		// Write user variables
-		for _, v := range userVars {
-			if _, err := writer.WriteString(fmt.Sprintf("%s=%s\n", v.Key, v.Value)); err != nil {
-				return fmt.Errorf("failed to write variable: %w", err)
-			}
-		}
+	// Ensure directory exists
+	dir := filepath.Dir(w.FilePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Flush the buffer
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
+	if err := os.WriteFile(w.FilePath, data, 0666); err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
 	}
-
 	return nil
 }
-
-// transformURL replaces localhost or 127.0.0.1 with the detected IP address
-func transformURL(url string, newIP string) string {
-	// Replace localhost
-	url = strings.ReplaceAll(url, "localhost", newIP)
-
-	// Replace 127.0.0.1
-	url = strings.ReplaceAll(url, "127.0.0.1", newIP)
-
-	return url
-}
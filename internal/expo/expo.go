@@ -0,0 +1,187 @@
+// Package expo rewrites the URLs of the Expo app config (app.json), which Expo reads when it builds
+// the app rather than from the env file: the values of expo.extra and expo.updates.url
+// Rewrite returns a Record of the original values, which the caller keeps in the lanup state so
+// that the next run rewrites them for a new IP and Revert writes them back. Only the rewritten
+// values change in the file, the rest of it is left as written.
+package expo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// Record is what lanup keeps about the values it rewrote in an app config
+type Record struct {
+	Host      string            `json:"host"`
+	Originals map[string]string `json:"originals"` // values before they were rewritten, by path such as extra.apiUrl
+}
+
+// edit replaces the bytes from start to end with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Rewrite replaces localhost with host in the URLs of expo.extra and expo.updates.url of the
+// app config at path, and reports whether the file changed
+// previous is the record of the last rewrite, nil when there was none. The record returned is
+// nil when no value is rewritten.
+func Rewrite(path, host string, previous *Record) (*Record, bool, error) {
+	return update(path, host, previous)
+}
+
+// Revert writes back the values of the app config at path recorded in previous, and reports
+// whether the file changed
+func Revert(path string, previous *Record) (bool, error) {
+	_, changed, err := update(path, "", previous)
+	return changed, err
+}
+
+// Changes returns the values Rewrite would write to the app config data, by path
+func Changes(data []byte, host string, previous *Record) (map[string]string, error) {
+	root, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	values, err := managedValues(root, host, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]string)
+	for path, v := range values {
+		if v.value != v.node.str {
+			changes[path] = v.value
+		}
+	}
+	return changes, nil
+}
+
+// update rewrites the app config at path for host, reverting it when host is empty
+func update(path, host string, previous *Record) (*Record, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	content, rec, err := rewrite(data, host, previous)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	if bytes.Equal(content, data) {
+		return rec, false, nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, info.Mode().Perm()); err != nil {
+		return nil, false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return rec, true, nil
+}
+
+// managedValue is a value lanup rewrites, with the value to write
+type managedValue struct {
+	node     *node
+	original string
+	value    string
+}
+
+// managedValues returns the values of the app config to rewrite for host, by path, given the
+// record of the previous run
+func managedValues(root *node, host string, rec *Record) (map[string]managedValue, error) {
+	expo := root.get("expo")
+	if expo == nil || expo.kind != '{' {
+		return nil, fmt.Errorf("no expo object")
+	}
+	if rec == nil {
+		rec = &Record{}
+	}
+
+	nodes := make(map[string]*node)
+	if extra := expo.get("extra"); extra != nil && extra.kind == '{' {
+		for _, m := range extra.members {
+			collect(m.value, "extra."+m.key, nodes)
+		}
+	}
+	if url := expo.get("updates").get("url"); url != nil && url.kind == '"' {
+		nodes["updates.url"] = url
+	}
+
+	values := make(map[string]managedValue, len(nodes))
+	for path, n := range nodes {
+		original, ok := rec.Originals[path]
+		// A value edited since it was rewritten is the new original
+		if !ok || n.str != env.TransformURL(original, rec.Host) {
+			original = n.str
+		}
+		value := original
+		if host != "" {
+			value = env.TransformURL(original, host)
+		}
+		values[path] = managedValue{node: n, original: original, value: value}
+	}
+	return values, nil
+}
+
+// collect adds the strings of n to nodes, by path
+func collect(n *node, path string, nodes map[string]*node) {
+	switch n.kind {
+	case '"':
+		nodes[path] = n
+	case '{':
+		for _, m := range n.members {
+			collect(m.value, path+"."+m.key, nodes)
+		}
+	case '[':
+		for i, item := range n.items {
+			collect(item, path+"."+strconv.Itoa(i), nodes)
+		}
+	}
+}
+
+// rewrite returns the app config data rewritten for host, reverted when host is empty, and the
+// record of the values rewritten
+func rewrite(data []byte, host string, previous *Record) ([]byte, *Record, error) {
+	root, err := parseJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := managedValues(root, host, previous)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var edits []edit
+	rec := &Record{Host: host, Originals: make(map[string]string)}
+	for path, v := range values {
+		if v.value != v.original {
+			rec.Originals[path] = v.original
+		}
+		if v.value != v.node.str {
+			edits = append(edits, edit{start: v.node.start, end: v.node.end, text: quote(v.value)})
+		}
+	}
+	if len(rec.Originals) == 0 {
+		rec = nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	result := append([]byte(nil), data...)
+	for _, e := range edits {
+		result = append(result[:e.start], append([]byte(e.text), result[e.end:]...)...)
+	}
+	return result, rec, nil
+}
@@ -0,0 +1,111 @@
+package expo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appJSON = `{
+  "expo": {
+    "name": "my-app",
+    "platforms": ["ios", "android"],
+    "extra": {
+      "apiUrl": "http://localhost:8000/v1?next=<home>",
+      "hosts": ["localhost:3000", "https://api.example.com"],
+      "eas": { "projectId": "0000" }
+    },
+    "updates": {
+      "url": "http://127.0.0.1:3001/api/manifest"
+    }
+  }
+}
+`
+
+func TestRewriteAndRevert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	require.NoError(t, os.WriteFile(path, []byte(appJSON), 0644))
+
+	rec, changed, err := Rewrite(path, "192.168.1.42", nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, &Record{Host: "192.168.1.42", Originals: map[string]string{
+		"extra.apiUrl":  "http://localhost:8000/v1?next=<home>",
+		"extra.hosts.0": "localhost:3000",
+		"updates.url":   "http://127.0.0.1:3001/api/manifest",
+	}}, rec)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "expo": {
+    "name": "my-app",
+    "platforms": ["ios", "android"],
+    "extra": {
+      "apiUrl": "http://192.168.1.42:8000/v1?next=<home>",
+      "hosts": ["192.168.1.42:3000", "https://api.example.com"],
+      "eas": { "projectId": "0000" }
+    },
+    "updates": {
+      "url": "http://192.168.1.42:3001/api/manifest"
+    }
+  }
+}
+`, string(content))
+
+	again, changed, err := Rewrite(path, "192.168.1.42", rec)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, rec, again)
+
+	// A new IP is written from the original values, and edited values become the originals
+	edited := []byte(strings.Replace(string(content), `"http://192.168.1.42:8000/v1?next=<home>"`, `"http://localhost:9000"`, 1))
+	require.NoError(t, os.WriteFile(path, edited, 0644))
+	changes, err := Changes(edited, "10.0.0.7", rec)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"extra.apiUrl":  "http://10.0.0.7:9000",
+		"extra.hosts.0": "10.0.0.7:3000",
+		"updates.url":   "http://10.0.0.7:3001/api/manifest",
+	}, changes)
+
+	changed, err = Revert(path, rec)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(appJSON, "http://localhost:8000/v1?next=<home>", "http://localhost:9000", 1), string(content))
+}
+
+func TestRewrite_WithoutRecord(t *testing.T) {
+	original := `{"expo": {"name": "my-app", "updates": {"url": "http://localhost:3001"}}}`
+	content, rec, err := rewrite([]byte(original), "myapp.lan", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"expo": {"name": "my-app", "updates": {"url": "http://myapp.lan:3001"}}}`, string(content))
+
+	// Without the record, the values rewritten are taken as the originals
+	reverted, _, err := rewrite(content, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(reverted))
+
+	reverted, _, err = rewrite(content, "", rec)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(reverted))
+
+	// Nothing to rewrite leaves the file as it is
+	content, rec, err = rewrite([]byte(`{"expo": {"extra": {}}}`), "myapp.lan", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"expo": {"extra": {}}}`, string(content))
+	assert.Nil(t, rec)
+}
+
+func TestRewrite_Errors(t *testing.T) {
+	_, _, err := rewrite([]byte(`{"name": "my-app"}`), "192.168.1.42", nil)
+	assert.ErrorContains(t, err, "no expo object")
+
+	_, _, err = rewrite([]byte(`{"expo": {`), "192.168.1.42", nil)
+	assert.ErrorContains(t, err, "invalid JSON")
+}
@@ -0,0 +1,132 @@
+package expo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// node is a JSON value with its position in the document, so that values can be replaced
+// without reformatting the rest of the file
+type node struct {
+	start, end int      // offsets of the value
+	kind       byte     // '{', '[', '"' or 0 for the other values
+	str        string   // value of a string
+	members    []member // members of an object, in order
+	items      []*node  // items of an array
+}
+
+// member is a key of an object and its value
+type member struct {
+	key   string
+	value *node
+}
+
+// get returns the value of key in an object, nil when there is none
+func (n *node) get(key string) *node {
+	if n == nil || n.kind != '{' {
+		return nil
+	}
+	for _, m := range n.members {
+		if m.key == key {
+			return m.value
+		}
+	}
+	return nil
+}
+
+// parseJSON parses a valid JSON document
+func parseJSON(data []byte) (*node, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("invalid JSON")
+	}
+	p := &parser{data: data}
+	return p.value(), nil
+}
+
+// parser reads the values of a document json.Valid accepted
+type parser struct {
+	data []byte
+	pos  int
+}
+
+// skip moves past whitespace
+func (p *parser) skip() {
+	for p.pos < len(p.data) && bytes.IndexByte([]byte(" \t\r\n"), p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// value reads the value at the current position
+func (p *parser) value() *node {
+	p.skip()
+	n := &node{start: p.pos}
+	switch p.data[p.pos] {
+	case '{':
+		n.kind = '{'
+		p.pos++
+		for {
+			p.skip()
+			if p.data[p.pos] == '}' {
+				break
+			}
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skip()
+			}
+			key := p.value().str
+			p.skip()
+			p.pos++ // colon
+			n.members = append(n.members, member{key: key, value: p.value()})
+			p.skip()
+			if p.data[p.pos] == '}' {
+				break
+			}
+		}
+		p.pos++
+	case '[':
+		n.kind = '['
+		p.pos++
+		for {
+			p.skip()
+			if p.data[p.pos] == ']' {
+				break
+			}
+			if p.data[p.pos] == ',' {
+				p.pos++
+			}
+			n.items = append(n.items, p.value())
+			p.skip()
+			if p.data[p.pos] == ']' {
+				break
+			}
+		}
+		p.pos++
+	case '"':
+		n.kind = '"'
+		p.pos++
+		for p.data[p.pos] != '"' {
+			if p.data[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		p.pos++
+		_ = json.Unmarshal(p.data[n.start:p.pos], &n.str)
+	default:
+		for p.pos < len(p.data) && bytes.IndexByte([]byte(",]} \t\r\n"), p.data[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+	n.end = p.pos
+	return n
+}
+
+// quote encodes s as a JSON string, leaving <, > and & as they are
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
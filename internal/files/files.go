@@ -0,0 +1,185 @@
+// Package files rewrites localhost in project files other than the env file, such as
+// capacitor.config.ts or app.json, which mobile tooling reads instead of the environment
+// Only the lines between a lanup:begin and a lanup:end marker are rewritten, in place.
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// Markers delimiting the rewritten lines, found anywhere on a line so that they can be written
+// in the comments of any language
+const (
+	BeginMarker = "lanup:begin"
+	EndMarker   = "lanup:end"
+)
+
+// hostAttr follows the begin marker and records the host written, e.g. lanup:begin host=192.168.1.42
+const hostAttr = " host="
+
+// fromAttr follows hostAttr and records the hostnames replaced, in order, when one of them is not
+// localhost, e.g. lanup:begin host=192.168.1.42 from=localhost,127.0.0.1
+const fromAttr = " from="
+
+// ErrNoMarkers is returned for a file without a lanup:begin marker
+var ErrNoMarkers = errors.New("no " + BeginMarker + " marker")
+
+// Rewrite replaces localhost and 127.0.0.1 with host between the markers of the file at path
+// The host and the hostnames it replaced are recorded on the begin marker, so that the next run
+// replaces it with a new one and Revert with the original hostnames. Rewrite reports whether the file changed.
+func Rewrite(path, host string) (bool, error) {
+	return update(path, host)
+}
+
+// Revert writes the original hostnames back in place of the host Rewrite wrote, and reports whether the file changed
+func Revert(path string) (bool, error) {
+	return update(path, "")
+}
+
+// update rewrites the file at path for host, reverting it when host is empty
+func update(path, host string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := rewrite(string(data), host)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	if content == string(data) {
+		return false, nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// rewrite returns content with the lines between markers rewritten for host, reverted when host is empty
+func rewrite(content, host string) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	found, inside, begin := false, false, 0
+	var mark marker
+	var replaced []string
+	for i, line := range lines {
+		if at := strings.Index(line, BeginMarker); at >= 0 {
+			if inside {
+				return "", fmt.Errorf("line %d: %s before %s", i+1, BeginMarker, EndMarker)
+			}
+			found, inside, begin = true, true, i
+			mark = parseMarker(line, at+len(BeginMarker))
+			replaced = nil
+			continue
+		}
+		if !inside {
+			continue
+		}
+		if strings.Contains(line, EndMarker) {
+			inside = false
+			lines[begin] = mark.line(host, replaced)
+			continue
+		}
+
+		if mark.host != "" {
+			line = env.ReplaceHostnameFunc(line, mark.host, mark.original)
+		}
+		if host != "" {
+			line = env.ReplaceLocalhostFunc(line, func(name string) string {
+				replaced = append(replaced, name)
+				return host
+			})
+		}
+		lines[i] = line
+	}
+
+	switch {
+	case !found:
+		return "", ErrNoMarkers
+	case inside:
+		return "", fmt.Errorf("%s without %s", BeginMarker, EndMarker)
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// marker is a begin marker line and the attributes recorded on it
+type marker struct {
+	prefix, suffix string   // the line before and after the attributes
+	host           string   // host written by the last run
+	from           []string // hostnames it replaced, in order
+}
+
+// parseMarker parses the attributes of the begin marker line ending at end
+func parseMarker(line string, end int) marker {
+	m := marker{prefix: line[:end]}
+	rest := line[end:]
+	m.host, rest = attr(rest, hostAttr)
+	if m.host != "" {
+		var from string
+		from, rest = attr(rest, fromAttr)
+		if from != "" {
+			m.from = strings.Split(from, ",")
+		}
+	}
+	m.suffix = rest
+	return m
+}
+
+// attr returns the value of the attribute at the start of s, and the rest of s
+func attr(s, name string) (string, string) {
+	if !strings.HasPrefix(s, name) {
+		return "", s
+	}
+	n := len(name)
+	for n < len(s) && (isHostChar(s[n]) || s[n] == ',') {
+		n++
+	}
+	return s[len(name):n], s[n:]
+}
+
+// original returns the hostname the next occurrence of the recorded host replaced, localhost when
+// the occurrences outnumber the recorded hostnames, as markers written before from= was recorded
+func (m *marker) original(string) string {
+	if len(m.from) == 0 {
+		return "localhost"
+	}
+	name := m.from[0]
+	m.from = m.from[1:]
+	return name
+}
+
+// line returns the marker line recording host and the hostnames it replaced
+func (m marker) line(host string, replaced []string) string {
+	if host == "" {
+		return m.prefix + m.suffix
+	}
+	attrs := hostAttr + host
+	for _, name := range replaced {
+		if name != "localhost" {
+			attrs += fromAttr + strings.Join(replaced, ",")
+			break
+		}
+	}
+	return m.prefix + attrs + m.suffix
+}
+
+// isHostChar reports whether c can be part of a hostname or an IP address
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '.' || c == '-' || c == '_' || c == ':' || c == '[' || c == ']'
+}
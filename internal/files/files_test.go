@@ -0,0 +1,130 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const capacitorConfig = `const config: CapacitorConfig = {
+  appId: 'com.example.app',
+  server: {
+    // lanup:begin
+    url: 'http://localhost:5173',
+    allowNavigation: ['127.0.0.1', 'localhost_api'],
+    // lanup:end
+  },
+  plugins: { Api: { url: 'http://localhost:8000' } },
+};
+`
+
+func TestRewriteAndRevert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capacitor.config.ts")
+	require.NoError(t, os.WriteFile(path, []byte(capacitorConfig), 0600))
+
+	changed, err := Rewrite(path, "192.168.1.42")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `const config: CapacitorConfig = {
+  appId: 'com.example.app',
+  server: {
+    // lanup:begin host=192.168.1.42 from=localhost,127.0.0.1
+    url: 'http://192.168.1.42:5173',
+    allowNavigation: ['192.168.1.42', 'localhost_api'],
+    // lanup:end
+  },
+  plugins: { Api: { url: 'http://localhost:8000' } },
+};
+`, string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Unchanged content is not written again
+	changed, err = Rewrite(path, "192.168.1.42")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// A new IP replaces the recorded one
+	changed, err = Rewrite(path, "10.0.0.7")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// lanup:begin host=10.0.0.7 from=localhost,127.0.0.1\n    url: 'http://10.0.0.7:5173',")
+	assert.Contains(t, string(content), "allowNavigation: ['10.0.0.7', 'localhost_api'],")
+
+	// Each hostname is restored, 127.0.0.1 included
+	changed, err = Revert(path)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, capacitorConfig, string(content))
+}
+
+func TestRewrite_JSONMarkers(t *testing.T) {
+	content, err := rewrite(`{
+  "//": "lanup:begin",
+  "apiUrl": "http://localhost:8000",
+  "//": "lanup:end"
+}
+`, "myapp.lan")
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "//": "lanup:begin host=myapp.lan",
+  "apiUrl": "http://myapp.lan:8000",
+  "//": "lanup:end"
+}
+`, content)
+
+	content, err = rewrite(content, "")
+	require.NoError(t, err)
+	assert.Contains(t, content, `"//": "lanup:begin",`)
+	assert.Contains(t, content, `"apiUrl": "http://localhost:8000",`)
+}
+
+func TestRewrite_RoundTrip(t *testing.T) {
+	original := `# lanup:begin
+api: http://127.0.0.1:8000
+db: postgres://127.0.0.1:5432/app
+# lanup:end
+`
+	content, err := rewrite(original, "192.168.1.42")
+	require.NoError(t, err)
+	assert.Contains(t, content, "# lanup:begin host=192.168.1.42 from=127.0.0.1,127.0.0.1\n")
+
+	// A second run with another IP must not lose the original hostnames
+	content, err = rewrite(content, "10.0.0.7")
+	require.NoError(t, err)
+	assert.Contains(t, content, "# lanup:begin host=10.0.0.7 from=127.0.0.1,127.0.0.1\n")
+
+	content, err = rewrite(content, "")
+	require.NoError(t, err)
+	assert.Equal(t, original, content)
+
+	// Markers written before the hostnames were recorded revert to localhost
+	content, err = rewrite("# lanup:begin host=10.0.0.7\napi: http://10.0.0.7:8000\n# lanup:end\n", "")
+	require.NoError(t, err)
+	assert.Equal(t, "# lanup:begin\napi: http://localhost:8000\n# lanup:end\n", content)
+}
+
+func TestRewrite_Errors(t *testing.T) {
+	_, err := rewrite("url: http://localhost:3000\n", "192.168.1.42")
+	assert.ErrorIs(t, err, ErrNoMarkers)
+
+	_, err = rewrite("# lanup:begin\nurl: http://localhost:3000\n", "192.168.1.42")
+	assert.ErrorContains(t, err, "lanup:begin without lanup:end")
+
+	_, err = rewrite("# lanup:begin\n# lanup:begin\n# lanup:end\n", "192.168.1.42")
+	assert.ErrorContains(t, err, "line 2: lanup:begin before lanup:end")
+
+	_, err = Rewrite(filepath.Join(t.TempDir(), "missing.ts"), "192.168.1.42")
+	assert.True(t, os.IsNotExist(err))
+}
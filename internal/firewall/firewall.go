@@ -0,0 +1,122 @@
+// Package firewall checks whether the host firewalls let incoming LAN connections reach local ports
+package firewall
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Verdict tells whether a firewall lets incoming connections reach a port
+type Verdict int
+
+const (
+	// Allowed means a rule or the default policy accepts incoming connections
+	Allowed Verdict = iota
+	// Blocked means incoming connections are dropped or rejected
+	Blocked
+	// Unknown means the firewall decides per application, or its rules could not be read
+	Unknown
+)
+
+// String returns the name of the verdict
+func (v Verdict) String() string {
+	switch v {
+	case Allowed:
+		return "allowed"
+	case Blocked:
+		return "blocked"
+	default:
+		return "unknown"
+	}
+}
+
+// Firewall is a host firewall that may block incoming LAN connections
+type Firewall interface {
+	// Name returns the name of the firewall (e.g. ufw)
+	Name() string
+	// Active reports whether the firewall filters incoming connections
+	Active() (bool, error)
+	// Check tells whether incoming TCP connections to port are let in
+	Check(port int) (Verdict, error)
+	// AllowCommands returns the commands letting incoming TCP connections to port in
+	AllowCommands(port int) [][]string
+}
+
+// output runs a command and returns its standard output
+type output func(name string, args ...string) (string, error)
+
+// commandOutput runs a command and includes its output in errors
+func commandOutput(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// Detect returns the firewalls installed on this machine, active or not
+// iface is the network interface of the LAN, firewalld filtering it with the rules of its zone;
+// when empty, the rules of the default zone are checked.
+func Detect(iface string) []Firewall {
+	var firewalls []Firewall
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("ufw"); err == nil {
+			firewalls = append(firewalls, &ufw{out: commandOutput})
+		}
+		if _, err := exec.LookPath("firewall-cmd"); err == nil {
+			firewalls = append(firewalls, &firewalld{out: commandOutput, iface: iface})
+		}
+	case "darwin":
+		if _, err := os.Stat(socketfilterfwPath); err == nil {
+			firewalls = append(firewalls, &socketfilterfw{out: commandOutput})
+		}
+	case "windows":
+		if _, err := exec.LookPath("netsh"); err == nil {
+			firewalls = append(firewalls, &windowsFirewall{out: commandOutput})
+		}
+	}
+	return firewalls
+}
+
+// CommandString returns a command as it would be typed in a shell
+func CommandString(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// sudo prefixes a command with sudo unless running as root
+func sudo(command ...string) []string {
+	if os.Geteuid() == 0 {
+		return command
+	}
+	return append([]string{"sudo"}, command...)
+}
+
+// portInSpec reports whether port is in a list of ports and ranges such as "80,443" or "8000:8010",
+// sep separating the bounds of a range
+func portInSpec(spec string, port int, sep string) bool {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		low, high, isRange := strings.Cut(part, sep)
+		if !isRange {
+			high = low
+		}
+		from, err1 := strconv.Atoi(strings.TrimSpace(low))
+		to, err2 := strconv.Atoi(strings.TrimSpace(high))
+		if err1 == nil && err2 == nil && from <= port && port <= to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package firewall
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ufwStatus = `Status: active
+Logging: on (low)
+Default: deny (incoming), allow (outgoing), disabled (routed)
+New profiles: skip
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW IN    Anywhere
+3000                       ALLOW IN    192.168.1.0/24
+5432/tcp                   DENY IN     Anywhere
+8000:8010/tcp              ALLOW IN    Anywhere
+9000/udp                   ALLOW IN    Anywhere
+5432                       ALLOW IN    Anywhere
+22/tcp (v6)                ALLOW IN    Anywhere (v6)
+`
+
+func TestParseUfwStatus(t *testing.T) {
+	tests := []struct {
+		port int
+		want Verdict
+	}{
+		{port: 22, want: Allowed},
+		{port: 3000, want: Allowed},
+		{port: 8005, want: Allowed},
+		{port: 5432, want: Blocked}, // the first matching rule wins
+		{port: 9000, want: Blocked}, // UDP only
+		{port: 4000, want: Blocked},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseUfwStatus(ufwStatus, tt.port), "port %d", tt.port)
+	}
+
+	assert.Equal(t, Allowed, parseUfwStatus("Status: inactive\n", 4000))
+	assert.Equal(t, Allowed, parseUfwStatus("Status: active\nDefault: allow (incoming), allow (outgoing)\n", 4000))
+}
+
+func TestUfw_RequiresRoot(t *testing.T) {
+	f := &ufw{out: func(name string, args ...string) (string, error) {
+		return "ERROR: You need to be root to run this script\n", errors.New("exit status 1")
+	}}
+	_, err := f.Active()
+	assert.ErrorContains(t, err, "sudo lanup doctor")
+}
+
+func TestParseFirewalldLists(t *testing.T) {
+	assert.Equal(t, Allowed, parseFirewalldLists("3000/tcp 8000-8010/tcp", "ssh", 8005))
+	assert.Equal(t, Allowed, parseFirewalldLists("", "ssh http", 80))
+	assert.Equal(t, Blocked, parseFirewalldLists("3000/udp", "ssh", 3000))
+	assert.Equal(t, Blocked, parseFirewalldLists("", "", 5173))
+}
+
+func TestFirewalld_AllowCommands(t *testing.T) {
+	commands := (&firewalld{}).AllowCommands(3000)
+	require.Len(t, commands, 2)
+	assert.Contains(t, CommandString(commands[0]), "firewall-cmd --add-port=3000/tcp")
+	assert.Contains(t, CommandString(commands[1]), "firewall-cmd --permanent --add-port=3000/tcp")
+}
+
+func TestFirewalld_ZoneOfInterface(t *testing.T) {
+	// The LAN interface is in the home zone, which opens 3000; the default public zone doesn't
+	var calls []string
+	f := &firewalld{iface: "wlp2s0", out: func(name string, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		switch strings.Join(args, " ") {
+		case "--get-zone-of-interface=wlp2s0":
+			return "home\n", nil
+		case "--zone=home --list-ports":
+			return "3000/tcp\n", nil
+		case "--zone=home --list-services":
+			return "ssh mdns\n", nil
+		}
+		return "\n", nil
+	}}
+	verdict, err := f.Check(3000)
+	require.NoError(t, err)
+	assert.Equal(t, Allowed, verdict)
+	assert.Contains(t, calls, "--zone=home --list-ports")
+	assert.Contains(t, CommandString(f.AllowCommands(5173)[1]), "firewall-cmd --permanent --zone=home --add-port=5173/tcp")
+
+	// An interface bound to no zone uses the default zone
+	f.out = func(name string, args ...string) (string, error) {
+		if strings.HasPrefix(args[0], "--get-zone-of-interface") {
+			return "no zone\n", errors.New("exit status 2")
+		}
+		return "\n", nil
+	}
+	assert.Contains(t, CommandString(f.AllowCommands(5173)[0]), "firewall-cmd --add-port=5173/tcp")
+}
+
+const netshRules = "Rule Name:                            Dev server\r\n" +
+	"----------------------------------------------------------------------\r\n" +
+	"Enabled:                              Yes\r\n" +
+	"Direction:                            In\r\n" +
+	"Protocol:                             TCP\r\n" +
+	"LocalPort:                            3000,8000-8010\r\n" +
+	"Action:                               Allow\r\n" +
+	"\r\n" +
+	"Rule Name:                            Postgres\r\n" +
+	"----------------------------------------------------------------------\r\n" +
+	"Enabled:                              Yes\r\n" +
+	"Direction:                            In\r\n" +
+	"Protocol:                             TCP\r\n" +
+	"LocalPort:                            5432\r\n" +
+	"Action:                               Block\r\n" +
+	"\r\n" +
+	"Rule Name:                            Node.js JavaScript Runtime\r\n" +
+	"----------------------------------------------------------------------\r\n" +
+	"Enabled:                              Yes\r\n" +
+	"Direction:                            In\r\n" +
+	"Protocol:                             TCP\r\n" +
+	"LocalPort:                            Any\r\n" +
+	"Program:                              C:\\Program Files\\nodejs\\node.exe\r\n" +
+	"Action:                               Allow\r\n" +
+	"\r\n" +
+	"Rule Name:                            Disabled\r\n" +
+	"----------------------------------------------------------------------\r\n" +
+	"Enabled:                              No\r\n" +
+	"Protocol:                             TCP\r\n" +
+	"LocalPort:                            6379\r\n" +
+	"Action:                               Allow\r\n"
+
+func TestParseNetshRules(t *testing.T) {
+	assert.Equal(t, Allowed, parseNetshRules(netshRules, 3000))
+	assert.Equal(t, Allowed, parseNetshRules(netshRules, 8010))
+	assert.Equal(t, Blocked, parseNetshRules(netshRules, 5432))
+	assert.Equal(t, Unknown, parseNetshRules(netshRules, 6379)) // only the node.exe rule may apply
+	assert.Equal(t, Blocked, parseNetshRules("", 6379))
+}
+
+func TestWindowsFirewall_Active(t *testing.T) {
+	f := &windowsFirewall{out: func(name string, args ...string) (string, error) {
+		return "\r\nPrivate Profile Settings:\r\n----------------------------------------------------------------------\r\nState                                 ON\r\nOk.\r\n", nil
+	}}
+	active, err := f.Active()
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestSocketfilterfw_Check(t *testing.T) {
+	blockAll := "Firewall is set to block all non-essential incoming connections\n"
+	f := &socketfilterfw{out: func(name string, args ...string) (string, error) {
+		return blockAll, nil
+	}}
+	verdict, err := f.Check(3000)
+	require.NoError(t, err)
+	assert.Equal(t, Blocked, verdict)
+
+	blockAll = "Block all DISABLED! \n"
+	verdict, err = f.Check(3000)
+	require.NoError(t, err)
+	assert.Equal(t, Unknown, verdict)
+}
+
+func TestCommandString(t *testing.T) {
+	assert.Equal(t, `netsh advfirewall firewall add rule "name=lanup 3000" dir=in`,
+		CommandString([]string{"netsh", "advfirewall", "firewall", "add", "rule", "name=lanup 3000", "dir=in"}))
+}
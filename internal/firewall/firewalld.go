@@ -0,0 +1,85 @@
+package firewall
+
+import (
+	"strconv"
+	"strings"
+)
+
+// firewalldServicePorts are the TCP ports of the firewalld services commonly used for web servers
+var firewalldServicePorts = map[string][]int{
+	"http":  {80},
+	"https": {443},
+}
+
+// firewalld is the firewall of Fedora, RHEL and openSUSE
+type firewalld struct {
+	out   output
+	iface string // interface of the LAN, whose zone applies to the devices
+}
+
+// Name returns the name of the firewall
+func (f *firewalld) Name() string {
+	return "firewalld"
+}
+
+// Active reports whether firewalld is running
+func (f *firewalld) Active() (bool, error) {
+	// firewall-cmd --state exits with an error when firewalld is not running
+	out, _ := f.out("firewall-cmd", "--state")
+	return strings.TrimSpace(out) == "running", nil
+}
+
+// Check looks for port in the ports and services opened in the zone of the LAN interface
+func (f *firewalld) Check(port int) (Verdict, error) {
+	ports, err := f.out("firewall-cmd", f.zoneArgs("--list-ports")...)
+	if err != nil {
+		return Unknown, err
+	}
+	services, err := f.out("firewall-cmd", f.zoneArgs("--list-services")...)
+	if err != nil {
+		return Unknown, err
+	}
+	return parseFirewalldLists(ports, services, port), nil
+}
+
+// AllowCommands opens port in the zone of the LAN interface, now and after reboots
+func (f *firewalld) AllowCommands(port int) [][]string {
+	rule := "--add-port=" + strconv.Itoa(port) + "/tcp"
+	return [][]string{
+		sudo(append([]string{"firewall-cmd"}, f.zoneArgs(rule)...)...),
+		sudo(append([]string{"firewall-cmd", "--permanent"}, f.zoneArgs(rule)...)...),
+	}
+}
+
+// zoneArgs returns args for the zone of the LAN interface
+// An interface bound to no zone is filtered by the default zone, which firewall-cmd uses without --zone.
+func (f *firewalld) zoneArgs(args ...string) []string {
+	if f.iface == "" {
+		return args
+	}
+	// firewall-cmd prints "no zone" and fails for an interface bound to no zone
+	out, err := f.out("firewall-cmd", "--get-zone-of-interface="+f.iface)
+	zone := strings.TrimSpace(out)
+	if err != nil || zone == "" || strings.ContainsAny(zone, " \n") {
+		return args
+	}
+	return append([]string{"--zone=" + zone}, args...)
+}
+
+// parseFirewalldLists evaluates the outputs of 'firewall-cmd --list-ports' and '--list-services'
+func parseFirewalldLists(ports, services string, port int) Verdict {
+	for _, entry := range strings.Fields(ports) {
+		spec, proto, _ := strings.Cut(entry, "/")
+		if proto == "tcp" && portInSpec(spec, port, "-") {
+			return Allowed
+		}
+	}
+	for _, service := range strings.Fields(services) {
+		for _, p := range firewalldServicePorts[service] {
+			if p == port {
+				return Allowed
+			}
+		}
+	}
+	return Blocked
+}
@@ -0,0 +1,85 @@
+package firewall
+
+import (
+	"strconv"
+	"strings"
+)
+
+// windowsFirewall is Windows Defender Firewall, managed with netsh
+type windowsFirewall struct {
+	out output
+}
+
+// Name returns the name of the firewall
+func (f *windowsFirewall) Name() string {
+	return "Windows Defender Firewall"
+}
+
+// Active reports whether the firewall of the current profile is on
+func (f *windowsFirewall) Active() (bool, error) {
+	out, err := f.out("netsh", "advfirewall", "show", "currentprofile", "state")
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "State" {
+			return fields[1] == "ON", nil
+		}
+	}
+	return false, nil
+}
+
+// Check looks for the enabled inbound rules matching port, block rules taking precedence
+func (f *windowsFirewall) Check(port int) (Verdict, error) {
+	out, err := f.out("netsh", "advfirewall", "firewall", "show", "rule", "name=all", "dir=in", "verbose")
+	if err != nil {
+		return Unknown, err
+	}
+	return parseNetshRules(out, port), nil
+}
+
+// AllowCommands returns the inbound rule opening port, to run from an elevated prompt
+func (f *windowsFirewall) AllowCommands(port int) [][]string {
+	p := strconv.Itoa(port)
+	return [][]string{{"netsh", "advfirewall", "firewall", "add", "rule", "name=lanup " + p,
+		"dir=in", "action=allow", "protocol=TCP", "localport=" + p}}
+}
+
+// parseNetshRules evaluates the output of 'netsh advfirewall firewall show rule name=all dir=in verbose'
+// Rules of a program that accept any port may or may not concern the service listening on port.
+func parseNetshRules(out string, port int) Verdict {
+	verdict := Blocked
+	for _, block := range strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n\n") {
+		rule := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			if key, value, ok := strings.Cut(line, ":"); ok {
+				rule[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		if rule["Enabled"] != "Yes" || (rule["Protocol"] != "TCP" && rule["Protocol"] != "Any") {
+			continue
+		}
+
+		localPort := rule["LocalPort"]
+		explicit := localPort != "Any" && portInSpec(localPort, port, "-")
+		if !explicit && localPort != "Any" {
+			continue
+		}
+		switch {
+		case rule["Action"] == "Block":
+			if explicit {
+				return Blocked
+			}
+		case explicit:
+			verdict = Allowed
+		case rule["Program"] != "" && rule["Program"] != "Any":
+			if verdict == Blocked {
+				verdict = Unknown
+			}
+		default:
+			verdict = Allowed
+		}
+	}
+	return verdict
+}
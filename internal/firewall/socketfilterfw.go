@@ -0,0 +1,43 @@
+package firewall
+
+import "strings"
+
+// socketfilterfwPath is the command line interface of the macOS application firewall
+const socketfilterfwPath = "/usr/libexec/ApplicationFirewall/socketfilterfw"
+
+// socketfilterfw is the macOS application firewall, which filters by application rather than port
+type socketfilterfw struct {
+	out output
+}
+
+// Name returns the name of the firewall
+func (f *socketfilterfw) Name() string {
+	return "macOS application firewall"
+}
+
+// Active reports whether the firewall is enabled
+func (f *socketfilterfw) Active() (bool, error) {
+	out, err := f.out(socketfilterfwPath, "--getglobalstate")
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(out, "enabled"), nil
+}
+
+// Check reports blocked ports when all incoming connections are blocked, otherwise the
+// application listening on port decides, which macOS asks about on its first connection
+func (f *socketfilterfw) Check(port int) (Verdict, error) {
+	out, err := f.out(socketfilterfwPath, "--getblockall")
+	if err != nil {
+		return Unknown, err
+	}
+	if strings.Contains(out, "DISABLED") {
+		return Unknown, nil
+	}
+	return Blocked, nil
+}
+
+// AllowCommands stops blocking all incoming connections, the applications can then be allowed
+func (f *socketfilterfw) AllowCommands(port int) [][]string {
+	return [][]string{sudo(socketfilterfwPath, "--setblockall", "off")}
+}
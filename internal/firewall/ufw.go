@@ -0,0 +1,101 @@
+package firewall
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ufw is the Uncomplicated Firewall of Ubuntu and Debian
+type ufw struct {
+	out output
+}
+
+// Name returns the name of the firewall
+func (f *ufw) Name() string {
+	return "ufw"
+}
+
+// status returns the output of 'ufw status verbose', which requires root
+func (f *ufw) status() (string, error) {
+	out, err := f.out("ufw", "status", "verbose")
+	if err != nil && strings.Contains(out, "root") {
+		return "", fmt.Errorf("reading the ufw rules requires root, run 'sudo lanup doctor'")
+	}
+	return out, err
+}
+
+// Active reports whether ufw is enabled
+func (f *ufw) Active() (bool, error) {
+	out, err := f.status()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(out, "Status: active"), nil
+}
+
+// Check applies the first rule matching port, or the default incoming policy
+func (f *ufw) Check(port int) (Verdict, error) {
+	out, err := f.status()
+	if err != nil {
+		return Unknown, err
+	}
+	return parseUfwStatus(out, port), nil
+}
+
+// AllowCommands returns the ufw rule opening port
+func (f *ufw) AllowCommands(port int) [][]string {
+	return [][]string{sudo("ufw", "allow", strconv.Itoa(port)+"/tcp")}
+}
+
+// parseUfwStatus evaluates the output of 'ufw status verbose' for incoming TCP connections to port
+func parseUfwStatus(out string, port int) Verdict {
+	verdict := Blocked
+	rules := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Status:") && line != "Status: active":
+			return Allowed
+		case strings.HasPrefix(line, "Default:"):
+			if strings.Contains(line, "allow (incoming)") {
+				verdict = Allowed
+			}
+		case strings.HasPrefix(line, "--"):
+			rules = true
+		case rules && line != "":
+			if v, ok := ufwRule(line, port); ok {
+				return v
+			}
+		}
+	}
+	return verdict
+}
+
+// ufwRule evaluates a rule line such as "3000/tcp  ALLOW IN  Anywhere", reporting whether it applies to port
+func ufwRule(line string, port int) (Verdict, bool) {
+	fields := strings.Fields(line)
+	action := -1
+	for i, field := range fields {
+		if field == "ALLOW" || field == "DENY" || field == "REJECT" || field == "LIMIT" {
+			action = i
+			break
+		}
+	}
+	if action < 1 || (len(fields) > action+1 && fields[action+1] == "OUT") {
+		return Unknown, false
+	}
+
+	to := fields[0]
+	if to != "Anywhere" {
+		spec, proto, _ := strings.Cut(to, "/")
+		if (proto != "" && proto != "tcp") || !portInSpec(spec, port, ":") {
+			return Unknown, false
+		}
+	}
+
+	if fields[action] == "ALLOW" || fields[action] == "LIMIT" {
+		return Allowed, true
+	}
+	return Blocked, true
+}
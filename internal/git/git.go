@@ -0,0 +1,124 @@
+// Package git checks that the generated env files stay out of git repositories,
+// as their LAN-specific URLs are only valid on one machine and network
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepo is returned for files outside of a git repository, or when git is not installed
+var ErrNotRepo = errors.New("not in a git repository")
+
+// gitignoreHeader precedes the entries lanup adds to .gitignore
+const gitignoreHeader = "# LAN-specific env files generated by lanup"
+
+// FileStatus tells how git sees a file
+type FileStatus struct {
+	Path    string // as given to Check
+	Root    string // root of the repository
+	Entry   string // .gitignore entry matching the file from the root, e.g. /web/.env.local
+	Tracked bool   // committed or staged
+	Ignored bool
+}
+
+// Exposed reports whether the file is or may get committed
+func (s *FileStatus) Exposed() bool {
+	return s.Tracked || !s.Ignored
+}
+
+// Check returns the status of path in its repository, which path need not exist in
+func Check(path string) (*FileStatus, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, name := filepath.Split(abs)
+
+	out, err := git(dir, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return nil, ErrNotRepo
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	status := &FileStatus{Path: path, Root: lines[0]}
+	prefix := ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+	status.Entry = "/" + prefix + name
+
+	// ls-files --error-unmatch fails for untracked files
+	_, err = git(dir, "ls-files", "--error-unmatch", "--", name)
+	status.Tracked = err == nil
+
+	// check-ignore exits with 1 for files that are not ignored
+	_, err = git(dir, "check-ignore", "-q", "--", name)
+	switch exitCode(err) {
+	case 0:
+		status.Ignored = true
+	case 1:
+	default:
+		return nil, fmt.Errorf("git check-ignore %s: %w", path, err)
+	}
+	return status, nil
+}
+
+// Ignore appends entries to the .gitignore file at the root of the repository, under a comment
+func Ignore(root string, entries []string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path := filepath.Join(root, ".gitignore")
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var b strings.Builder
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		b.WriteString("\n")
+	}
+	if !strings.Contains(string(existing), gitignoreHeader) {
+		if len(existing) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(gitignoreHeader + "\n")
+	}
+	for _, entry := range entries {
+		b.WriteString(entry + "\n")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// exitCode returns the exit code of a failed command, 0 on success and -1 when it didn't run
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo creates a git repository with a web directory and returns its root
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "web"), 0755))
+	_, err := git(root, "init", "-q")
+	require.NoError(t, err)
+	return root
+}
+
+func TestCheck(t *testing.T) {
+	root := newRepo(t)
+	output := filepath.Join(root, "web", ".env.local")
+
+	status, err := Check(output)
+	require.NoError(t, err)
+	assert.Equal(t, "/web/.env.local", status.Entry)
+	assert.False(t, status.Tracked)
+	assert.False(t, status.Ignored)
+	assert.True(t, status.Exposed())
+
+	require.NoError(t, Ignore(status.Root, []string{status.Entry, status.Entry + ".bak"}))
+	status, err = Check(output)
+	require.NoError(t, err)
+	assert.True(t, status.Ignored)
+	assert.False(t, status.Exposed())
+	status, err = Check(output + ".bak")
+	require.NoError(t, err)
+	assert.True(t, status.Ignored)
+
+	// Ignoring a committed file doesn't untrack it
+	require.NoError(t, os.WriteFile(output, []byte("API_URL=http://192.168.1.42:8000\n"), 0644))
+	_, err = git(root, "add", "-f", "web/.env.local")
+	require.NoError(t, err)
+	status, err = Check(output)
+	require.NoError(t, err)
+	assert.True(t, status.Tracked)
+	assert.True(t, status.Exposed())
+}
+
+func TestCheck_NotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+
+	_, err := Check(filepath.Join(t.TempDir(), ".env.local"))
+	assert.ErrorIs(t, err, ErrNotRepo)
+}
+
+func TestIgnore(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("node_modules"), 0644))
+
+	require.NoError(t, Ignore(root, []string{"/.env.local"}))
+	require.NoError(t, Ignore(root, []string{"/.env.local.bak"}))
+	require.NoError(t, Ignore(root, nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "node_modules\n\n"+gitignoreHeader+"\n/.env.local\n/.env.local.bak\n", string(data))
+}
@@ -0,0 +1,133 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MaxEntries is the number of entries kept when the history file is compacted
+const MaxEntries = 1000
+
+// Entry records a change of the detected IP address
+type Entry struct {
+	Time      time.Time `json:"time"`
+	OldIP     string    `json:"old_ip,omitempty"` // empty for the first detection
+	NewIP     string    `json:"new_ip"`
+	Interface string    `json:"interface,omitempty"`
+	Trigger   string    `json:"trigger"` // command that detected the change (start, watch, daemon, run)
+	Project   string    `json:"project,omitempty"`
+}
+
+// DefaultPath returns the path of the history file (~/.lanup/history.jsonl)
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "history.jsonl"), nil
+}
+
+// Read returns the entries of the history file, oldest first
+// A missing file is an empty history; malformed lines are skipped
+func Read(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Last returns the most recent entry, or nil when the history is empty
+func Last(path string) (*Entry, error) {
+	entries, err := Read(path)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[len(entries)-1], nil
+}
+
+// Append adds an entry to the history file, creating it if needed
+// The file is compacted to the last MaxEntries entries once it holds twice as many
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) >= 2*MaxEntries {
+		return write(path, entries[len(entries)-MaxEntries:])
+	}
+
+	return nil
+}
+
+// Clear removes the history file
+func Clear(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+	return nil
+}
+
+// write replaces the history file with entries
+func write(path string, entries []Entry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup", "history.jsonl")
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	last, err := Last(path)
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	now := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	require.NoError(t, Append(path, Entry{Time: now, NewIP: "192.168.1.10", Interface: "en0", Trigger: "start"}))
+	require.NoError(t, Append(path, Entry{Time: now.Add(time.Hour), OldIP: "192.168.1.10", NewIP: "192.168.1.20", Trigger: "watch", Project: "/src/app"}))
+
+	entries, err = Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "", entries[0].OldIP)
+	assert.Equal(t, "192.168.1.10", entries[0].NewIP)
+	assert.Equal(t, "en0", entries[0].Interface)
+	assert.True(t, now.Equal(entries[0].Time))
+	assert.Equal(t, "/src/app", entries[1].Project)
+
+	last, err = Last(path)
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, "192.168.1.20", last.NewIP)
+}
+
+func TestRead_SkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := `{"time":"2024-05-01T09:30:00Z","new_ip":"192.168.1.10","trigger":"start"}
+not json
+{"time":"2024-05-01T10:30:00Z","old_ip":"192.168.1.10","new_ip":"10.0.0.5","trigger":"watch"}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "10.0.0.5", entries[1].NewIP)
+}
+
+func TestAppend_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	for i := 0; i < 2*MaxEntries; i++ {
+		require.NoError(t, Append(path, Entry{NewIP: "192.168.1.10", Trigger: "watch"}))
+	}
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, MaxEntries)
+}
+
+func TestClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, Append(path, Entry{NewIP: "192.168.1.10", Trigger: "start"}))
+
+	require.NoError(t, Clear(path))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// Clearing an empty history is not an error
+	require.NoError(t, Clear(path))
+}
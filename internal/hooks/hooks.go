@@ -0,0 +1,117 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// DefaultTimeout is how long a hook command may run before it is killed
+const DefaultTimeout = time.Minute
+
+// Event is a lifecycle event that runs hooks, named like its configuration key
+type Event string
+
+const (
+	// Start runs after 'lanup start' wrote the env file
+	Start Event = "on_start"
+	// Change runs after watch mode regenerated the env file for a new IP or changed services
+	Change Event = "on_change"
+	// Stop runs after 'lanup stop' reverted the env file to localhost
+	Stop Event = "on_stop"
+)
+
+// Info describes the event to the hook commands, through LANUP_* variables
+type Info struct {
+	IP     string // LANUP_IP
+	OldIP  string // LANUP_OLD_IP, for Change
+	Output string // LANUP_OUTPUT, the env file
+}
+
+// Runner runs hook commands through the system shell
+type Runner struct {
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Timeout time.Duration
+}
+
+// NewRunner creates a runner printing the output of the hooks to the console
+func NewRunner() *Runner {
+	return &Runner{
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		Timeout: DefaultTimeout,
+	}
+}
+
+// Run runs the commands one after the other, with vars and the LANUP_* variables added to their environment
+// A failing command doesn't prevent the next ones from running, all the failures are returned
+func (r *Runner) Run(ctx context.Context, event Event, commands []string, vars []env.EnvVar, info Info) error {
+	environ := Environ(event, vars, info)
+
+	var errs []error
+	for _, command := range commands {
+		if err := r.run(ctx, command, environ); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %q: %w", event, command, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run runs one command, killing it after the timeout
+func (r *Runner) run(ctx context.Context, command string, environ []string) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = environ
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	// Background processes started by the hook may keep its output open, don't wait for them
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		return err
+	}
+	return nil
+}
+
+// Environ returns the environment of the hook commands: the current one, the variables and the event
+// exec.Cmd keeps the last value of duplicate keys, so the variables override the current environment
+func Environ(event Event, vars []env.EnvVar, info Info) []string {
+	environ := os.Environ()
+	for _, v := range vars {
+		environ = append(environ, v.Key+"="+v.Value)
+	}
+
+	environ = append(environ, "LANUP_EVENT="+string(event))
+	if info.IP != "" {
+		environ = append(environ, "LANUP_IP="+info.IP)
+	}
+	if info.OldIP != "" {
+		environ = append(environ, "LANUP_OLD_IP="+info.OldIP)
+	}
+	if info.Output != "" {
+		environ = append(environ, "LANUP_OUTPUT="+info.Output)
+	}
+	return environ
+}
@@ -0,0 +1,76 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner() (*Runner, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &Runner{Stdout: &stdout, Stderr: &stderr, Timeout: 5 * time.Second}, &stdout, &stderr
+}
+
+func TestRunner_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	runner, stdout, _ := newTestRunner()
+	vars := []env.EnvVar{{Key: "API_URL", Value: "http://192.168.1.20:8000", Managed: true}}
+	info := Info{IP: "192.168.1.20", OldIP: "192.168.1.10", Output: ".env.local"}
+
+	err := runner.Run(context.Background(), Change, []string{
+		`echo "$LANUP_EVENT $LANUP_OLD_IP $LANUP_IP $LANUP_OUTPUT"`,
+		`echo "$API_URL"`,
+	}, vars, info)
+	require.NoError(t, err)
+	assert.Equal(t, "on_change 192.168.1.10 192.168.1.20 .env.local\nhttp://192.168.1.20:8000\n", stdout.String())
+}
+
+func TestRunner_Run_Failures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	runner, stdout, stderr := newTestRunner()
+	runner.Timeout = 100 * time.Millisecond
+
+	err := runner.Run(context.Background(), Start, []string{
+		"echo oops >&2; exit 3",
+		"sleep 5",
+		"echo still running",
+	}, nil, Info{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `on_start hook "echo oops >&2; exit 3": exit status 3`)
+	assert.Contains(t, err.Error(), `on_start hook "sleep 5": timed out after 100ms`)
+	assert.Equal(t, "oops\n", stderr.String())
+	assert.Equal(t, "still running\n", stdout.String(), "a failing hook doesn't stop the next ones")
+}
+
+func TestEnviron(t *testing.T) {
+	t.Setenv("API_URL", "http://localhost:8000")
+
+	environ := Environ(Stop, []env.EnvVar{{Key: "API_URL", Value: "http://192.168.1.20:8000"}}, Info{Output: ".env"})
+
+	assert.Contains(t, environ, "LANUP_EVENT=on_stop")
+	assert.Contains(t, environ, "LANUP_OUTPUT=.env")
+	assert.NotContains(t, environ, "LANUP_IP=")
+	// The variable comes after the inherited one, exec.Cmd keeps the last value
+	assert.Greater(t, indexOf(environ, "API_URL=http://192.168.1.20:8000"), indexOf(environ, "API_URL=http://localhost:8000"))
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package hosts
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Marker is appended to the lines managed by lanup so that they can be updated and removed safely
+const Marker = "# lanup"
+
+// Entry is a hostname mapping found in a hosts file
+type Entry struct {
+	IP       string
+	Hostname string
+	Managed  bool // true when the line was added by lanup
+}
+
+// Line returns the hosts file line mapping hostname to ip
+func (e Entry) Line() string {
+	return fmt.Sprintf("%s\t%s %s", e.IP, e.Hostname, Marker)
+}
+
+var hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// ValidHostname reports whether name can be used as a hosts file entry
+// IP addresses and localhost are rejected since they don't need an entry.
+func ValidHostname(name string) bool {
+	if len(name) > 253 || !hostnameRe.MatchString(name) {
+		return false
+	}
+	if net.ParseIP(name) != nil || strings.EqualFold(name, "localhost") {
+		return false
+	}
+	return true
+}
+
+// DefaultPath returns the path of the system hosts file
+func DefaultPath() string {
+	if runtime.GOOS == "windows" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return filepath.Join(root, "System32", "drivers", "etc", "hosts")
+	}
+	return "/etc/hosts"
+}
+
+// Parse returns every hostname mapping in the hosts file content
+func Parse(content string) []Entry {
+	var entries []Entry
+
+	for _, line := range strings.Split(content, "\n") {
+		fields, managed := splitLine(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, name := range fields[1:] {
+			entries = append(entries, Entry{IP: fields[0], Hostname: name, Managed: managed})
+		}
+	}
+
+	return entries
+}
+
+// Lookup returns the IP the hosts file content maps hostname to
+func Lookup(content, hostname string) (Entry, bool) {
+	for _, entry := range Parse(content) {
+		if strings.EqualFold(entry.Hostname, hostname) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Managed returns the entries added by lanup
+func Managed(content string) []Entry {
+	var managed []Entry
+	for _, entry := range Parse(content) {
+		if entry.Managed {
+			managed = append(managed, entry)
+		}
+	}
+	return managed
+}
+
+// Set maps hostname to ip, replacing a previous lanup entry for the same hostname
+// Entries not managed by lanup are left untouched.
+func Set(content, hostname, ip string) string {
+	content, _ = Remove(content, hostname)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + Entry{IP: ip, Hostname: hostname}.Line() + "\n"
+}
+
+// Remove deletes the lanup entry for hostname and reports whether one was found
+func Remove(content, hostname string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	kept := make([]string, 0, len(lines))
+	removed := false
+
+	for _, line := range lines {
+		fields, managed := splitLine(line)
+		if managed && len(fields) >= 2 && strings.EqualFold(fields[1], hostname) {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, ""), removed
+}
+
+// Update reads the hosts file at path, applies fn and writes the result back
+// The file mode is preserved; writing usually requires administrator privileges.
+func Update(path string, fn func(content string) string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	updated := fn(string(data))
+	if updated == string(data) {
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(updated), info.Mode().Perm())
+}
+
+// splitLine returns the fields of a hosts file line without its comment, and whether
+// the line carries the lanup marker
+func splitLine(line string) ([]string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	managed := false
+
+	if i := strings.Index(line, "#"); i >= 0 {
+		managed = strings.TrimSpace(line[i:]) == Marker
+		line = line[:i]
+	}
+
+	return strings.Fields(line), managed
+}
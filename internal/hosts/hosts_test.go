@@ -0,0 +1,101 @@
+package hosts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `127.0.0.1	localhost
+::1	localhost ip6-localhost
+# 10.0.0.1 commented.lan
+192.168.1.50	printer.lan
+192.168.1.10	myapp.lan # lanup
+`
+
+func TestParse(t *testing.T) {
+	entries := Parse(sample)
+
+	assert.Len(t, entries, 5)
+	assert.Equal(t, Entry{IP: "192.168.1.50", Hostname: "printer.lan"}, entries[3])
+	assert.Equal(t, Entry{IP: "192.168.1.10", Hostname: "myapp.lan", Managed: true}, entries[4])
+
+	assert.Equal(t, []Entry{{IP: "192.168.1.10", Hostname: "myapp.lan", Managed: true}}, Managed(sample))
+}
+
+func TestLookup(t *testing.T) {
+	entry, ok := Lookup(sample, "MyApp.lan")
+	require.True(t, ok)
+	assert.Equal(t, "192.168.1.10", entry.IP)
+
+	_, ok = Lookup(sample, "commented.lan")
+	assert.False(t, ok)
+}
+
+func TestSet(t *testing.T) {
+	// Replaces the existing lanup entry
+	updated := Set(sample, "myapp.lan", "192.168.1.20")
+	entry, ok := Lookup(updated, "myapp.lan")
+	require.True(t, ok)
+	assert.Equal(t, "192.168.1.20", entry.IP)
+	assert.Len(t, Managed(updated), 1)
+	assert.Contains(t, updated, "192.168.1.50\tprinter.lan\n")
+
+	// Appends a newline when missing
+	assert.Equal(t, "127.0.0.1 localhost\n192.168.1.20\tnew.lan # lanup\n",
+		Set("127.0.0.1 localhost", "new.lan", "192.168.1.20"))
+}
+
+func TestRemove(t *testing.T) {
+	updated, removed := Remove(sample, "myapp.lan")
+	assert.True(t, removed)
+	assert.Empty(t, Managed(updated))
+
+	// Entries not managed by lanup are never removed
+	_, removed = Remove(sample, "printer.lan")
+	assert.False(t, removed)
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(path, []byte(sample), 0644))
+
+	err := Update(path, func(content string) string {
+		return Set(content, "demo.lan", "10.0.0.5")
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	entry, ok := Lookup(string(data), "demo.lan")
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.5", entry.IP)
+
+	assert.Error(t, Update(filepath.Join(t.TempDir(), "missing"), func(s string) string { return s }))
+}
+
+func TestValidHostname(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"myapp.lan", true},
+		{"my-app.local", true},
+		{"api", true},
+		{"", false},
+		{"-bad.lan", false},
+		{"bad_name.lan", false},
+		{"localhost", false},
+		{"192.168.1.10", false},
+		{"my app.lan", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, ValidHostname(tt.name))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampFormat is the layout of the timestamp starting each log line
+const TimestampFormat = "2006-01-02 15:04:05"
+
+// Entry is a log line parsed back into its parts
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Module  string
+	Message string
+	Fields  []Field // values are strings, or JSON values for JSON lines
+	Raw     string  // the line as written
+	JSON    bool    // whether the line was written with FormatJSON
+}
+
+var (
+	// entryRe matches "[timestamp] LEVEL rest"
+	entryRe = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] (DEBUG|INFO|WARN|ERROR) +(.*)$`)
+	// moduleRe matches the "module: " prefix written by FormatLogEntry
+	moduleRe = regexp.MustCompile(`^([a-z][a-z0-9._-]*): `)
+	// fieldRe matches the start of a " key=value" field
+	fieldRe = regexp.MustCompile(` ([A-Za-z_][A-Za-z0-9_.]*)=`)
+)
+
+// ParseLevel converts a level name (debug, info, warn, error) to a LogLevel
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("invalid log level: %s (must be debug, info, warn, or error)", name)
+	}
+}
+
+// ParseEntry parses a line written by the logger, in the text or JSON format
+// It returns false for lines that don't start with a timestamp and a level
+func ParseEntry(line string) (Entry, bool) {
+	if entry, ok := parseJSONEntry(line); ok {
+		return entry, true
+	}
+
+	m := entryRe.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, false
+	}
+
+	ts, err := time.ParseInLocation(TimestampFormat, m[1], time.Local)
+	if err != nil {
+		return Entry{}, false
+	}
+	level, _ := ParseLevel(m[2])
+
+	entry := Entry{Time: ts, Level: level, Raw: line}
+	rest := m[3]
+
+	if mm := moduleRe.FindStringSubmatch(rest); mm != nil {
+		entry.Module = mm[1]
+		rest = rest[len(mm[0]):]
+	}
+
+	// The message ends at the first field, each field value at the next one
+	locs := fieldRe.FindAllStringSubmatchIndex(rest, -1)
+	if len(locs) == 0 {
+		entry.Message = rest
+		return entry, true
+	}
+	entry.Message = rest[:locs[0][0]]
+	for i, loc := range locs {
+		end := len(rest)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		entry.Fields = append(entry.Fields, Field{Key: rest[loc[2]:loc[3]], Value: rest[loc[1]:end]})
+	}
+
+	return entry, true
+}
+
+// Text formats the entry as a text log line
+func (e Entry) Text() string {
+	text := fmt.Sprintf("[%s] %-5s ", e.Time.Format(TimestampFormat), e.Level.String())
+	if e.Module != "" {
+		text += e.Module + ": "
+	}
+	text += e.Message
+	for _, f := range e.Fields {
+		text += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	return text
+}
+
+// Field returns the value of a field and whether the entry has it
+func (e Entry) Field(key string) (string, bool) {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return fmt.Sprint(f.Value), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"debug", DEBUG, false},
+		{"INFO", INFO, false},
+		{"warn", WARN, false},
+		{"warning", WARN, false},
+		{"Error", ERROR, false},
+		{"loud", INFO, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := ParseLevel(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		level   LogLevel
+		module  string
+		message string
+		fields  []Field
+	}{
+		{
+			name:    "message only",
+			line:    "[2024-05-01 10:00:00] INFO  Watch mode stopped by user",
+			level:   INFO,
+			message: "Watch mode stopped by user",
+		},
+		{
+			name:    "fields",
+			line:    "[2024-05-01 10:00:00] INFO  Detected IP ip=192.168.1.10 interface=en0 type=wifi",
+			level:   INFO,
+			message: "Detected IP",
+			fields: []Field{
+				{Key: "ip", Value: "192.168.1.10"},
+				{Key: "interface", Value: "en0"},
+				{Key: "type", Value: "wifi"},
+			},
+		},
+		{
+			name:    "field value with spaces",
+			line:    "[2024-05-01 10:00:00] ERROR Start failed error=Failed to detect local IP address: no active network interfaces found",
+			level:   ERROR,
+			message: "Start failed",
+			fields:  []Field{{Key: "error", Value: "Failed to detect local IP address: no active network interfaces found"}},
+		},
+		{
+			name:    "module",
+			line:    "[2024-05-01 10:00:00] WARN  watcher: Network interface changed old_ip=192.168.1.10",
+			level:   WARN,
+			module:  "watcher",
+			message: "Network interface changed",
+			fields:  []Field{{Key: "old_ip", Value: "192.168.1.10"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := ParseEntry(tt.line)
+			require.True(t, ok)
+			assert.Equal(t, tt.level, entry.Level)
+			assert.Equal(t, tt.module, entry.Module)
+			assert.Equal(t, tt.message, entry.Message)
+			assert.Equal(t, tt.fields, entry.Fields)
+			assert.Equal(t, tt.line, entry.Raw)
+			assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local), entry.Time)
+		})
+	}
+}
+
+func TestParseEntry_Invalid(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"not a log line",
+		"[2024-05-01 10:00:00] TRACE something",
+		"[yesterday] INFO something",
+	} {
+		_, ok := ParseEntry(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestParseEntry_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: DEBUG, FilePath: path})
+	require.NoError(t, err)
+	log.Warn("Detector failed", Field{Key: "detector", Value: "docker"}, Field{Key: "count", Value: 3})
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	entry, ok := ParseEntry(string(data[:len(data)-1]))
+	require.True(t, ok)
+	assert.Equal(t, WARN, entry.Level)
+	assert.Equal(t, "Detector failed", entry.Message)
+	value, ok := entry.Field("count")
+	assert.True(t, ok)
+	assert.Equal(t, "3", value)
+	_, ok = entry.Field("missing")
+	assert.False(t, ok)
+}
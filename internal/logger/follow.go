@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Follower follows a log file as it grows, like tail -F
+// It keeps following the path when the file is rotated, removed and recreated, or truncated
+type Follower struct {
+	Path     string
+	OnLine   func(line string)
+	OnNotice func(msg string) // called on rotation and truncation, optional
+
+	watcher *fsnotify.Watcher
+	file    *os.File
+	offset  int64
+	partial []byte
+	lost    bool // the file was renamed or removed since it was opened
+}
+
+// NewFollower creates a follower for the log file at path
+func NewFollower(path string) *Follower {
+	return &Follower{
+		Path:   filepath.Clean(path),
+		OnLine: func(string) {},
+	}
+}
+
+// Open starts watching the log file, from its current end
+// The file doesn't need to exist yet: it is followed from its creation
+func (f *Follower) Open() error {
+	// Watching the directory sees the file being created, renamed and removed
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch log directory: %w", err)
+	}
+	f.watcher = watcher
+
+	if err := f.open(true); err != nil && !os.IsNotExist(err) {
+		f.Close()
+		return err
+	}
+	return nil
+}
+
+// Exists reports whether the log file is open, false while waiting for it to be created
+func (f *Follower) Exists() bool {
+	return f.file != nil
+}
+
+// Run calls OnLine for each new line until ctx is done
+func (f *Follower) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != f.Path {
+				continue
+			}
+			if err := f.handle(event); err != nil {
+				return err
+			}
+
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch log file: %w", err)
+		}
+	}
+}
+
+// Close stops watching the log file
+func (f *Follower) Close() error {
+	f.closeFile()
+	if f.watcher != nil {
+		return f.watcher.Close()
+	}
+	return nil
+}
+
+// handle reacts to a change of the log file
+func (f *Follower) handle(event fsnotify.Event) error {
+	switch {
+	case event.Has(fsnotify.Create):
+		// A new file replaced the one being followed, the rest of the old one is read first
+		if f.file != nil {
+			if err := f.read(); err != nil {
+				return err
+			}
+			f.closeFile()
+		}
+		if f.lost {
+			f.notice("%s was replaced, following the new file", f.Path)
+			f.lost = false
+		}
+		return f.open(false)
+
+	case event.Has(fsnotify.Write):
+		if f.file == nil {
+			return f.open(false)
+		}
+		return f.read()
+
+	case event.Has(fsnotify.Rename), event.Has(fsnotify.Remove):
+		// The handle still reads the renamed file, lines written before the rename are kept
+		if f.file != nil {
+			if err := f.read(); err != nil {
+				return err
+			}
+			f.closeFile()
+		}
+		f.lost = true
+	}
+	return nil
+}
+
+// open opens the log file, at its end or at its start
+func (f *Follower) open(atEnd bool) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) && !atEnd {
+			// Removed again before it could be opened, the next Create will tell
+			return nil
+		}
+		return err
+	}
+	f.file = file
+	f.offset = 0
+	f.partial = nil
+
+	if atEnd {
+		offset, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("failed to seek to end of log file: %w", err)
+		}
+		f.offset = offset
+		return nil
+	}
+	return f.read()
+}
+
+// read delivers the lines appended since the last read
+func (f *Follower) read() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	if info.Size() < f.offset {
+		f.notice("%s was truncated", f.Path)
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek to start of log file: %w", err)
+		}
+		f.offset = 0
+		f.partial = nil
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.file.Read(buf)
+		if n > 0 {
+			f.offset += int64(n)
+			f.consume(buf[:n])
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read log file: %w", err)
+		}
+	}
+}
+
+// consume splits data into lines, keeping an incomplete last line for the next read
+func (f *Follower) consume(data []byte) {
+	f.partial = append(f.partial, data...)
+	for {
+		i := bytes.IndexByte(f.partial, '\n')
+		if i < 0 {
+			return
+		}
+		line := strings.TrimSuffix(string(f.partial[:i]), "\r")
+		f.partial = f.partial[i+1:]
+		f.OnLine(line)
+	}
+}
+
+// closeFile closes the followed file, delivering an incomplete last line
+func (f *Follower) closeFile() {
+	if f.file == nil {
+		return
+	}
+	if len(f.partial) > 0 {
+		f.OnLine(string(f.partial))
+		f.partial = nil
+	}
+	f.file.Close()
+	f.file = nil
+}
+
+// notice reports a rotation or truncation
+func (f *Follower) notice(format string, args ...interface{}) {
+	if f.OnNotice != nil {
+		f.OnNotice(fmt.Sprintf(format, args...))
+	}
+}
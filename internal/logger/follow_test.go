@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// followLog starts following path and returns a function reading the lines received so far
+func followLog(t *testing.T, path string) (lines func() []string, notices func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var gotLines, gotNotices []string
+
+	follower := NewFollower(path)
+	follower.OnLine = func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		gotLines = append(gotLines, line)
+	}
+	follower.OnNotice = func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		gotNotices = append(gotNotices, msg)
+	}
+	require.NoError(t, follower.Open())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- follower.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		follower.Close()
+	})
+
+	lines = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), gotLines...)
+	}
+	notices = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), gotNotices...)
+	}
+	return lines, notices
+}
+
+func appendLog(t *testing.T, path, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func TestFollower_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	appendLog(t, path, "before\n")
+
+	lines, _ := followLog(t, path)
+
+	appendLog(t, path, "first\nsecond")
+	appendLog(t, path, " half\n")
+
+	assert.Eventually(t, func() bool { return len(lines()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"first", "second half"}, lines(), "existing lines are skipped, partial lines joined")
+}
+
+func TestFollower_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	appendLog(t, path, "before\n")
+
+	lines, notices := followLog(t, path)
+
+	appendLog(t, path, "old file\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendLog(t, path, "new file\n")
+
+	assert.Eventually(t, func() bool { return len(lines()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"old file", "new file"}, lines())
+	assert.Equal(t, []string{path + " was replaced, following the new file"}, notices())
+}
+
+func TestFollower_Truncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	appendLog(t, path, "a long line before truncation\n")
+
+	lines, notices := followLog(t, path)
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendLog(t, path, "after\n")
+
+	assert.Eventually(t, func() bool { return len(lines()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"after"}, lines())
+	assert.Equal(t, []string{path + " was truncated"}, notices())
+}
+
+func TestFollower_Created(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "lanup.log")
+
+	lines, _ := followLog(t, path)
+
+	appendLog(t, path, "created\n")
+
+	assert.Eventually(t, func() bool { return len(lines()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"created"}, lines())
+}
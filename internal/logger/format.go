@@ -28,14 +28,27 @@ func GetColorScheme() ColorScheme {
 	}
 }
 
+// colorsEnabled is whether FormatLogEntry colors entries: stdout is a terminal and NO_COLOR is unset
+var colorsEnabled = os.Getenv("NO_COLOR") == "" && IsTerminal()
+
+// SetColors overrides whether FormatLogEntry colors entries, e.g. to honor --no-color
+func SetColors(enabled bool) {
+	colorsEnabled = enabled
+}
+
 // FormatLogEntry formats a log entry with timestamp, level, and optional colorization
 func FormatLogEntry(level LogLevel, module string, msg string, fields ...Field) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	return formatEntry(time.Now(), level, module, msg, colorsEnabled, fields)
+}
+
+// formatEntry formats a log entry, with ANSI colors when colored is set
+func formatEntry(ts time.Time, level LogLevel, module string, msg string, colored bool, fields []Field) string {
+	timestamp := ts.Format(TimestampFormat)
 
 	var entry string
 
 	// Add color if terminal supports it
-	if IsTerminal() {
+	if colored {
 		colors := GetColorScheme()
 		var color string
 
@@ -86,7 +99,12 @@ func FormatLogEntry(level LogLevel, module string, msg string, fields ...Field)
 // This is used to determine whether to use colored output
 func IsTerminal() bool {
 	// Check if stdout is a terminal
-	fileInfo, err := os.Stdout.Stat()
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal checks if a file is a terminal (TTY)
+func isTerminal(f *os.File) bool {
+	fileInfo, err := f.Stat()
 	if err != nil {
 		return false
 	}
@@ -151,17 +151,23 @@ func TestFormatLogEntry_ColorizedOutput(t *testing.T) {
 			// Verify the log level string is present
 			assert.Contains(t, result, tt.level.String())
 
-			// If terminal, should contain ANSI codes
-			if IsTerminal() {
-				// Should contain color codes
-				assert.True(t,
-					strings.Contains(result, "\033[") || !IsTerminal(),
-					"Expected ANSI color codes in terminal output")
-			}
+			// If colors are enabled, should contain ANSI codes
+			assert.Equal(t, colorsEnabled, strings.Contains(result, "\033["),
+				"Expected ANSI color codes only when colors are enabled")
 		})
 	}
 }
 
+func TestFormatLogEntry_SetColors(t *testing.T) {
+	defer SetColors(colorsEnabled)
+
+	SetColors(false)
+	assert.NotContains(t, FormatLogEntry(WARN, "env", "Backup failed"), "\033[")
+
+	SetColors(true)
+	assert.Contains(t, FormatLogEntry(WARN, "env", "Backup failed"), GetColorScheme().Warn)
+}
+
 func TestFormatLogEntry_LevelPadding(t *testing.T) {
 	// Test that log levels are properly padded to 5 characters
 	tests := []struct {
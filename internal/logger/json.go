@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonEntry is the layout of a line written with FormatJSON
+type jsonEntry struct {
+	Time    time.Time              `json:"ts"`
+	Level   string                 `json:"level"`
+	Module  string                 `json:"module,omitempty"`
+	Message string                 `json:"msg"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// formatJSON formats a log entry as a JSON line
+func formatJSON(ts time.Time, level LogLevel, module, msg string, fields []Field) string {
+	entry := jsonEntry{Time: ts, Level: level.String(), Module: module, Message: msg}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			entry.Fields[field.Key] = jsonValue(field.Value)
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		// Values are converted by jsonValue, this only guards against surprises
+		data, _ = json.Marshal(jsonEntry{Time: ts, Level: level.String(), Module: module, Message: msg})
+	}
+	return string(data) + "\n"
+}
+
+// jsonValue converts a field value to a value encoding/json renders readably
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, int, int64, int32, uint, uint64, uint32, float64, float32:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+// parseJSONEntry parses a line written with FormatJSON
+func parseJSONEntry(line string) (Entry, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return Entry{}, false
+	}
+
+	var je jsonEntry
+	if err := json.Unmarshal([]byte(line), &je); err != nil || je.Time.IsZero() {
+		return Entry{}, false
+	}
+	level, err := ParseLevel(je.Level)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	entry := Entry{Time: je.Time.Local(), Level: level, Module: je.Module, Message: je.Message, Raw: line, JSON: true}
+	if mm := moduleRe.FindStringSubmatch(entry.Message); mm != nil && entry.Module == "" {
+		entry.Module = mm[1]
+		entry.Message = entry.Message[len(mm[0]):]
+	}
+
+	keys := make([]string, 0, len(je.Fields))
+	for key := range je.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry.Fields = append(entry.Fields, Field{Key: key, Value: je.Fields[key]})
+	}
+
+	return entry, true
+}
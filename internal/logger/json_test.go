@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"JSON", FormatJSON, false},
+		{"xml", FormatText, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseFormat(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: DEBUG, FilePath: path, Format: FormatJSON})
+	require.NoError(t, err)
+	log.Error("watcher: Failed to regenerate env file",
+		Field{Key: "error", Value: errors.New("permission denied")},
+		Field{Key: "count", Value: 3},
+		Field{Key: "interval", Value: 5 * time.Second})
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, "ERROR", decoded["level"])
+	assert.Equal(t, "watcher: Failed to regenerate env file", decoded["msg"])
+	assert.NotEmpty(t, decoded["ts"])
+	assert.Equal(t, map[string]interface{}{
+		"error":    "permission denied",
+		"count":    float64(3),
+		"interval": "5s",
+	}, decoded["fields"])
+
+	entry, ok := ParseEntry(lines[0])
+	require.True(t, ok)
+	assert.True(t, entry.JSON)
+	assert.Equal(t, ERROR, entry.Level)
+	assert.Equal(t, "watcher", entry.Module)
+	assert.Equal(t, "Failed to regenerate env file", entry.Message)
+	value, ok := entry.Field("error")
+	assert.True(t, ok)
+	assert.Equal(t, "permission denied", value)
+}
+
+func TestEntry_Text(t *testing.T) {
+	line := `{"ts":"2024-05-01T10:00:05Z","level":"WARN","msg":"watcher: Network interface changed","fields":{"old_ip":"192.168.1.10","new_ip":"192.168.1.20"}}`
+
+	entry, ok := ParseEntry(line)
+	require.True(t, ok)
+
+	ts := time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC).Local().Format(TimestampFormat)
+	assert.Equal(t, "["+ts+"] WARN  watcher: Network interface changed new_ip=192.168.1.20 old_ip=192.168.1.10", entry.Text())
+
+	// Text lines render back unchanged
+	text := "[2024-05-01 10:00:00] INFO  Detected IP ip=192.168.1.10 interface=en0"
+	entry, ok = ParseEntry(text)
+	require.True(t, ok)
+	assert.Equal(t, text, entry.Text())
+}
+
+func TestParseJSONEntry_Invalid(t *testing.T) {
+	for _, line := range []string{
+		"{",
+		`{"msg":"no timestamp","level":"INFO"}`,
+		`{"ts":"2024-05-01T10:00:05Z","level":"TRACE","msg":"unknown level"}`,
+		`{"foo":"bar"}`,
+	} {
+		_, ok := ParseEntry(line)
+		assert.False(t, ok, line)
+	}
+}
@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -36,6 +39,28 @@ func (l LogLevel) String() string {
 	}
 }
 
+// Format is the layout of the lines written to the log file
+type Format int
+
+const (
+	// FormatText writes "[timestamp] LEVEL message key=value" lines
+	FormatText Format = iota
+	// FormatJSON writes one JSON object per line
+	FormatJSON
+)
+
+// ParseFormat converts a format name (text, json) to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("invalid log format: %s (must be text or json)", name)
+	}
+}
+
 // Field represents a structured log field
 type Field struct {
 	Key   string
@@ -48,11 +73,26 @@ type Logger struct {
 	FilePath   string
 	MaxSize    int64 // bytes
 	MaxBackups int
-	Console    bool
+	MaxAge     time.Duration // backups older than this are deleted, 0 keeps them
+	Compress   bool          // gzip rotated backups
+	Format     Format        // of the log file, the console is always text
+	Sink       Sink          // secondary destination such as the system log, optional
+	Console    bool          // mirror entries to the console
+	Output     io.Writer     // console of the entries and of the logging failures, stderr when nil
 	Colors     bool
-	mu         sync.Mutex
-	file       *os.File
-	size       int64
+	// Identical consecutive entries are logged once, then summarized with a
+	// repeated=N field at most once per RepeatInterval; 0 logs every entry
+	RepeatInterval time.Duration
+	mu             sync.Mutex
+	file           *os.File
+	lock           *os.File // serializes writes and rotations across lanup processes
+	size           int64
+	repeat         *repeatedEntry
+
+	// Set on loggers created by With, which write through base
+	base   *Logger
+	module string
+	fields []Field
 }
 
 // LoggerConfig holds configuration for creating a new logger
@@ -61,8 +101,26 @@ type LoggerConfig struct {
 	FilePath   string
 	MaxSize    int64
 	MaxBackups int
+	MaxAge     time.Duration
+	Compress   bool
+	Format     Format
+	Sink       Sink
 	Console    bool
+	Output     io.Writer
 	Colors     bool
+
+	RepeatInterval time.Duration
+}
+
+// repeatedEntry tracks the last entry to fold identical ones
+type repeatedEntry struct {
+	key      string
+	level    LogLevel
+	module   string
+	msg      string
+	fields   []Field
+	count    int       // identical entries not logged yet
+	reported time.Time // when the entry or its last summary was logged
 }
 
 // NewLogger creates a new logger instance with the given configuration
@@ -80,8 +138,15 @@ func NewLogger(config LoggerConfig) (*Logger, error) {
 		FilePath:   config.FilePath,
 		MaxSize:    config.MaxSize,
 		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAge,
+		Compress:   config.Compress,
+		Format:     config.Format,
+		Sink:       config.Sink,
 		Console:    config.Console,
-		Colors:     config.Colors,
+		Output:     config.Output,
+		Colors:     config.Colors && isTerminal(os.Stderr), // checked once, colors only go to terminals
+
+		RepeatInterval: config.RepeatInterval,
 	}
 
 	// Create log directory if it doesn't exist
@@ -98,28 +163,79 @@ func NewLogger(config LoggerConfig) (*Logger, error) {
 		}
 		logger.file = file
 
+		// Other lanup processes, such as watch mode and a manual start, log to the same file
+		lock, err := os.OpenFile(config.FilePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open log lock file: %w", err)
+		}
+		logger.lock = lock
+
 		// Get current file size
 		info, err := file.Stat()
 		if err != nil {
 			return nil, fmt.Errorf("failed to stat log file: %w", err)
 		}
 		logger.size = info.Size()
+
+		// Backups may have expired since the last rotation
+		if logger.MaxAge > 0 {
+			if err := lockFile(lock); err == nil {
+				logger.cleanupOldBackups()
+				unlockFile(lock)
+			}
+		}
 	}
 
 	return logger, nil
 }
 
-// Close closes the log file
+// Close closes the log file and the sink
+// Loggers created by With share them and don't need to be closed
 func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	l.flushRepeat(time.Now())
+
+	if l.Sink != nil {
+		l.Sink.Close()
+	}
+	if l.lock != nil {
+		l.lock.Close()
+	}
 	if l.file != nil {
 		return l.file.Close()
 	}
 	return nil
 }
 
+// With returns a logger adding a field to every entry, writing to the same destinations as l
+// The "module" key names the subsystem emitting the entries (e.g. watcher): it is written
+// before the message as "module: message" and replaces the module of l
+func (l *Logger) With(key string, value interface{}) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	base := l
+	if l.base != nil {
+		base = l.base
+	}
+	child := &Logger{
+		Level:  base.Level,
+		base:   base,
+		module: l.module,
+		fields: append([]Field(nil), l.fields...),
+	}
+	if key == "module" {
+		child.module = fmt.Sprint(value)
+	} else {
+		child.fields = append(child.fields, Field{Key: key, Value: value})
+	}
+	return child
+}
+
 // Debug logs a debug message
 func (l *Logger) Debug(msg string, fields ...Field) {
 	l.log(DEBUG, msg, fields...)
@@ -142,6 +258,18 @@ func (l *Logger) Error(msg string, fields ...Field) {
 
 // log is the internal logging method
 func (l *Logger) log(level LogLevel, msg string, fields ...Field) {
+	if l.base != nil {
+		if len(l.fields) > 0 {
+			fields = append(append([]Field(nil), l.fields...), fields...)
+		}
+		l.base.write(level, l.module, msg, fields)
+		return
+	}
+	l.write(level, "", msg, fields)
+}
+
+// write logs an entry, folding it into a summary when it repeats the previous one
+func (l *Logger) write(level LogLevel, module string, msg string, fields []Field) {
 	// Check if we should log this level
 	if level < l.Level {
 		return
@@ -150,9 +278,44 @@ func (l *Logger) log(level LogLevel, msg string, fields ...Field) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	now := time.Now()
+	if l.RepeatInterval > 0 {
+		key := fmt.Sprint(level, module, msg, fields)
+		if l.repeat != nil && l.repeat.key == key {
+			l.repeat.count++
+			// Long streaks, such as a network outage, are summarized at each interval
+			if now.Sub(l.repeat.reported) >= l.RepeatInterval {
+				l.flushRepeat(now)
+			}
+			return
+		}
+		l.flushRepeat(now)
+		l.repeat = &repeatedEntry{key: key, level: level, module: module, msg: msg, fields: fields, reported: now}
+	}
+
+	l.emit(now, level, module, msg, fields)
+}
+
+// flushRepeat logs the summary of the identical entries not logged yet
+func (l *Logger) flushRepeat(now time.Time) {
+	r := l.repeat
+	if r == nil || r.count == 0 {
+		return
+	}
+	fields := append(append([]Field(nil), r.fields...), Field{Key: "repeated", Value: r.count})
+	l.emit(now, r.level, r.module, r.msg, fields)
+	r.count = 0
+	r.reported = now
+}
+
+// emit formats an entry and writes it to the file, the sink and the console
+func (l *Logger) emit(now time.Time, level LogLevel, module string, msg string, fields []Field) {
 	// Format the log entry
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	entry := fmt.Sprintf("[%s] %-5s %s", timestamp, level.String(), msg)
+	text := msg
+	if module != "" {
+		text = module + ": " + msg
+	}
+	entry := fmt.Sprintf("[%s] %-5s %s", now.Format(TimestampFormat), level.String(), text)
 
 	// Add fields if present
 	if len(fields) > 0 {
@@ -164,36 +327,86 @@ func (l *Logger) log(level LogLevel, msg string, fields ...Field) {
 
 	// Write to file if configured
 	if l.file != nil {
-		n, err := l.file.WriteString(entry)
-		if err != nil {
-			// If we can't write to the log file, write to stderr
-			fmt.Fprintf(os.Stderr, "Failed to write to log file: %v\n", err)
-		} else {
-			l.size += int64(n)
+		line := entry
+		if l.Format == FormatJSON {
+			line = formatJSON(now, level, module, msg, fields)
+		}
+		l.writeFile(line)
+	}
 
-			// Check if rotation is needed
-			if l.size >= l.MaxSize {
-				if err := l.rotate(); err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
-				}
-			}
+	// Write to the sink if configured, it adds its own timestamp and level
+	if l.Sink != nil {
+		for _, field := range fields {
+			text += fmt.Sprintf(" %s=%v", field.Key, field.Value)
+		}
+		if err := l.Sink.Write(level, text); err != nil {
+			fmt.Fprintf(l.console(), "Failed to write to log sink: %v\n", err)
 		}
 	}
 
-	// Write to console if configured
+	// Write to console if configured, on stderr to keep stdout for the command output
 	if l.Console {
-		var output io.Writer = os.Stdout
-		if level == ERROR {
-			output = os.Stderr
+		fmt.Fprint(l.console(), formatEntry(now, level, module, msg, l.Colors, fields))
+	}
+}
+
+// console returns where console output goes
+func (l *Logger) console() io.Writer {
+	if l.Output != nil {
+		return l.Output
+	}
+	return os.Stderr
+}
+
+// writeFile appends a line to the log file and rotates it when it is full
+// The lock keeps other lanup processes from writing or rotating in between
+func (l *Logger) writeFile(line string) {
+	if l.lock != nil {
+		if err := lockFile(l.lock); err != nil {
+			// Writing unlocked is better than losing the entry
+			fmt.Fprintf(l.console(), "Failed to lock log file: %v\n", err)
+		} else {
+			defer unlockFile(l.lock)
+		}
+		if err := l.reopen(); err != nil {
+			fmt.Fprintf(l.console(), "Failed to reopen log file: %v\n", err)
 		}
+	}
 
-		// Use colored output if enabled
-		if l.Colors && IsTerminal() {
-			entry = FormatLogEntry(level, "", msg, fields...)
+	n, err := l.file.WriteString(line)
+	if err != nil {
+		// If we can't write to the log file, write to stderr
+		fmt.Fprintf(l.console(), "Failed to write to log file: %v\n", err)
+		return
+	}
+	l.size += int64(n)
+
+	// Check if rotation is needed
+	if l.size >= l.MaxSize {
+		if err := l.rotate(); err != nil {
+			fmt.Fprintf(l.console(), "Failed to rotate log file: %v\n", err)
+		}
+	}
+}
+
+// reopen switches to the current log file when another process rotated it,
+// and updates the size with what the other processes wrote
+func (l *Logger) reopen() error {
+	info, err := os.Stat(l.FilePath)
+	if current, cerr := l.file.Stat(); err != nil || cerr != nil || !os.SameFile(info, current) {
+		file, err := os.OpenFile(l.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
 		}
+		l.file.Close()
+		l.file = file
 
-		fmt.Fprint(output, entry)
+		if info, err = file.Stat(); err != nil {
+			return err
+		}
 	}
+	l.size = info.Size()
+	return nil
 }
 
 // rotate performs log rotation
@@ -205,18 +418,21 @@ func (l *Logger) rotate() error {
 		}
 	}
 
-	// Rotate existing backup files
+	// Rotate existing backup files, compressed or not
 	for i := l.MaxBackups - 1; i >= 1; i-- {
-		oldPath := fmt.Sprintf("%s.%d", l.FilePath, i)
-		newPath := fmt.Sprintf("%s.%d", l.FilePath, i+1)
+		for _, ext := range []string{"", ".gz"} {
+			oldPath := fmt.Sprintf("%s.%d%s", l.FilePath, i, ext)
 
-		// Check if old backup exists
-		if _, err := os.Stat(oldPath); err == nil {
+			// Check if old backup exists
+			if _, err := os.Stat(oldPath); err != nil {
+				continue
+			}
 			// Remove the oldest backup if it exists
 			if i == l.MaxBackups-1 {
-				os.Remove(newPath)
+				l.removeBackup(i + 1)
 			}
 			// Rename the backup
+			newPath := fmt.Sprintf("%s.%d%s", l.FilePath, i+1, ext)
 			if err := os.Rename(oldPath, newPath); err != nil {
 				return fmt.Errorf("failed to rotate backup %d: %w", i, err)
 			}
@@ -229,9 +445,6 @@ func (l *Logger) rotate() error {
 		return fmt.Errorf("failed to rename log file: %w", err)
 	}
 
-	// Clean up old backups beyond MaxBackups
-	l.cleanupOldBackups()
-
 	// Create new log file
 	file, err := os.OpenFile(l.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
@@ -241,10 +454,62 @@ func (l *Logger) rotate() error {
 	l.file = file
 	l.size = 0
 
+	if l.Compress {
+		if err := compressFile(backupPath); err != nil {
+			return fmt.Errorf("failed to compress log backup: %w", err)
+		}
+	}
+
+	// Clean up old backups beyond MaxBackups or MaxAge
+	l.cleanupOldBackups()
+
 	return nil
 }
 
-// cleanupOldBackups removes backup files beyond MaxBackups
+// removeBackup deletes backup number i, compressed or not
+func (l *Logger) removeBackup(i int) {
+	os.Remove(fmt.Sprintf("%s.%d", l.FilePath, i))
+	os.Remove(fmt.Sprintf("%s.%d.gz", l.FilePath, i))
+}
+
+// compressFile replaces a file with its gzip-compressed version (path.gz)
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	// The archive only takes the place of the backup once it is complete
+	tmpPath := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		src.Close()
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	// Windows can't remove an open file
+	src.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path+".gz"); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// cleanupOldBackups removes backup files beyond MaxBackups and older than MaxAge
 func (l *Logger) cleanupOldBackups() {
 	dir := filepath.Dir(l.FilePath)
 	base := filepath.Base(l.FilePath)
@@ -263,10 +528,19 @@ func (l *Logger) cleanupOldBackups() {
 	}
 	var files []fileInfo
 	for _, match := range matches {
+		// Only lanup.log.N and lanup.log.N.gz are backups, not the lock file nor a compression in progress
+		suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), base+"."), ".gz")
+		if n, err := strconv.Atoi(suffix); err != nil || n < 1 {
+			continue
+		}
 		info, err := os.Stat(match)
 		if err != nil {
 			continue
 		}
+		if l.MaxAge > 0 && time.Since(info.ModTime()) > l.MaxAge {
+			os.Remove(match)
+			continue
+		}
 		files = append(files, fileInfo{path: match, modTime: info.ModTime()})
 	}
 
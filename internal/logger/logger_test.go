@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestLogger_RotateCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{
+		Level:      INFO,
+		FilePath:   path,
+		MaxSize:    10, // every entry triggers a rotation
+		MaxBackups: 3,
+		Compress:   true,
+	})
+	require.NoError(t, err)
+
+	for _, msg := range []string{"first", "second", "third", "fourth"} {
+		log.Info(msg)
+	}
+	require.NoError(t, log.Close())
+
+	assert.NoFileExists(t, path+".1")
+	assert.NoFileExists(t, path+".1.gz.tmp")
+	assert.Contains(t, readGzip(t, path+".1.gz"), "INFO  fourth")
+	assert.Contains(t, readGzip(t, path+".2.gz"), "INFO  third")
+	assert.Contains(t, readGzip(t, path+".3.gz"), "INFO  second")
+	assert.NoFileExists(t, path+".4.gz", "backups beyond MaxBackups are deleted")
+}
+
+func TestLogger_MaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	old := time.Now().Add(-20 * 24 * time.Hour)
+
+	for _, backup := range []string{path + ".1", path + ".2.gz"} {
+		require.NoError(t, os.WriteFile(backup, []byte("backup\n"), 0644))
+	}
+	require.NoError(t, os.Chtimes(path+".2.gz", old, old))
+
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, MaxAge: 14 * 24 * time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	assert.FileExists(t, path+".1")
+	assert.NoFileExists(t, path+".2.gz", "backups older than MaxAge are deleted")
+}
+
+func TestLogger_CleanupKeepsLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	old := time.Now().Add(-20 * 24 * time.Hour)
+	require.NoError(t, os.WriteFile(path+".lock", nil, 0644))
+	require.NoError(t, os.Chtimes(path+".lock", old, old))
+
+	log, err := NewLogger(LoggerConfig{
+		Level:      INFO,
+		FilePath:   path,
+		MaxSize:    10,
+		MaxBackups: 1,
+		MaxAge:     14 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	for _, msg := range []string{"first", "second", "third"} {
+		log.Info(msg)
+	}
+	require.NoError(t, log.Close())
+
+	assert.FileExists(t, path+".1")
+	assert.NoFileExists(t, path+".2")
+	assert.FileExists(t, path+".lock", "the lock file is not a backup")
+}
+
+func TestLogger_RotateWithoutCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, MaxSize: 10, MaxBackups: 2})
+	require.NoError(t, err)
+
+	log.Info("first")
+	log.Info("second")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(data)), "INFO  second"))
+	assert.NoFileExists(t, path+".1.gz")
+}
+
+func TestLogger_ConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	config := LoggerConfig{Level: INFO, FilePath: path, MaxSize: 200, MaxBackups: 100}
+
+	// Each logger opens its own descriptors, like separate processes do
+	var wg sync.WaitGroup
+	for _, name := range []string{"watch", "start"} {
+		log, err := NewLogger(config)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer log.Close()
+			for i := 0; i < 50; i++ {
+				log.Info("Entry", Field{Key: "process", Value: name}, Field{Key: "i", Value: i})
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	files, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	count := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, ".lock") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		if len(data) == 0 {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			_, ok := ParseEntry(line)
+			assert.True(t, ok, "line %q of %s is corrupted", line, file)
+			count++
+		}
+	}
+	assert.Equal(t, 100, count, "no entry is lost to a rotation by the other process")
+}
+
+func TestLogger_ReopenAfterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	watch, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, MaxSize: 10})
+	require.NoError(t, err)
+	start, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, MaxSize: 1024})
+	require.NoError(t, err)
+
+	watch.Info("Rotated by watch")
+	start.Info("After the rotation")
+	require.NoError(t, watch.Close())
+	require.NoError(t, start.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "After the rotation", "start follows the rotation of watch")
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "After the rotation")
+}
+
+func TestLogger_With(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	sink := &recordingSink{}
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, Sink: sink})
+	require.NoError(t, err)
+
+	watcher := log.With("module", "watcher")
+	watcher.Warn("Network interface changed", Field{Key: "new_ip", Value: "192.168.1.20"})
+	watcher.Debug("Below the level of the base logger")
+	watcher.With("project", "/src/app").With("module", "env").Info("Updated env file")
+	log.Info("No module")
+	require.NoError(t, watcher.Close(), "derived loggers don't own the file")
+	log.Info("Still open")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+
+	entry, ok := ParseEntry(lines[0])
+	require.True(t, ok)
+	assert.Equal(t, "watcher", entry.Module)
+	assert.Equal(t, "Network interface changed", entry.Message)
+
+	entry, ok = ParseEntry(lines[1])
+	require.True(t, ok)
+	assert.Equal(t, "env", entry.Module, "the module is replaced")
+	value, _ := entry.Field("project")
+	assert.Equal(t, "/src/app", value, "fields are inherited")
+
+	assert.Contains(t, lines[2], "INFO  No module")
+	assert.Equal(t, "watcher: Network interface changed new_ip=192.168.1.20", sink.messages[0])
+}
+
+func TestLogger_WithJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, Format: FormatJSON})
+	require.NoError(t, err)
+	log.With("module", "watcher").Warn("Network interface changed")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"module":"watcher","msg":"Network interface changed"`)
+
+	entry, ok := ParseEntry(strings.TrimSpace(string(data)))
+	require.True(t, ok)
+	assert.Equal(t, "watcher", entry.Module)
+	assert.Equal(t, "Network interface changed", entry.Message)
+}
+
+func TestLogger_Repeated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, RepeatInterval: time.Hour})
+	require.NoError(t, err)
+
+	watcher := log.With("module", "watcher")
+	for i := 0; i < 3; i++ {
+		watcher.Warn("Failed to detect IP", Field{Key: "error", Value: "no network"})
+	}
+	watcher.Warn("Failed to detect IP", Field{Key: "error", Value: "timeout"})
+	watcher.Info("Network interface changed")
+	watcher.Info("Network interface changed")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasSuffix(lines[0], "watcher: Failed to detect IP error=no network"))
+	assert.True(t, strings.HasSuffix(lines[1], "watcher: Failed to detect IP error=no network repeated=2"))
+	assert.True(t, strings.HasSuffix(lines[2], "watcher: Failed to detect IP error=timeout"), "different fields are another entry")
+	assert.True(t, strings.HasSuffix(lines[3], "watcher: Network interface changed"))
+	assert.True(t, strings.HasSuffix(lines[4], "watcher: Network interface changed repeated=1"), "Close logs the pending summary")
+}
+
+func TestLogger_RepeatedInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lanup.log")
+	log, err := NewLogger(LoggerConfig{Level: INFO, FilePath: path, RepeatInterval: time.Nanosecond})
+	require.NoError(t, err)
+
+	log.Error("Failed to detect IP")
+	time.Sleep(time.Millisecond)
+	log.Error("Failed to detect IP")
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[1], "Failed to detect IP repeated=1"), "the summary is logged once the interval has elapsed")
+}
+
+func TestLogger_WithNil(t *testing.T) {
+	var log *Logger
+	assert.Nil(t, log.With("module", "watcher"))
+}
+
+func TestLogger_Console(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	originalStderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = originalStderr }()
+
+	log, err := NewLogger(LoggerConfig{Level: DEBUG, Console: true, Colors: true})
+	require.NoError(t, err)
+	log.With("module", "watcher").Debug("Checking IP", Field{Key: "interval", Value: "5s"})
+	require.NoError(t, log.Close())
+
+	w.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	// The pipe is not a terminal, so no colors
+	assert.Regexp(t, `^\[[0-9-]+ [0-9:]+\] DEBUG watcher: Checking IP interval=5s\n$`, string(data))
+}
+
+func TestFormatEntry_Colored(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	colors := GetColorScheme()
+
+	entry := formatEntry(ts, WARN, "env", "Backup failed", true, nil)
+	assert.Equal(t, "[2024-05-01 10:00:00] "+colors.Warn+"WARN "+colors.Reset+" env: Backup failed\n", entry)
+
+	entry = formatEntry(ts, WARN, "env", "Backup failed", false, nil)
+	assert.Equal(t, "[2024-05-01 10:00:00] WARN  env: Backup failed\n", entry)
+}
@@ -0,0 +1,11 @@
+package logger
+
+// Sink is a secondary destination of log entries, such as the system log
+type Sink interface {
+	// Write records a message, formatted without timestamp and level
+	Write(level LogLevel, msg string) error
+	Close() error
+}
+
+// SystemLogTag identifies lanup entries in the system log
+const SystemLogTag = "lanup"
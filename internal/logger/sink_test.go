@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps the messages written to it
+type recordingSink struct {
+	levels   []LogLevel
+	messages []string
+	closed   bool
+}
+
+func (s *recordingSink) Write(level LogLevel, msg string) error {
+	s.levels = append(s.levels, level)
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestLogger_Sink(t *testing.T) {
+	sink := &recordingSink{}
+	log, err := NewLogger(LoggerConfig{
+		Level:    INFO,
+		FilePath: filepath.Join(t.TempDir(), "lanup.log"),
+		Sink:     sink,
+	})
+	require.NoError(t, err)
+
+	log.Debug("Below the level")
+	log.Info("Updated env file", Field{Key: "path", Value: ".env.local"}, Field{Key: "vars", Value: 3})
+	log.Error("Failed to detect IP")
+	require.NoError(t, log.Close())
+
+	assert.Equal(t, []LogLevel{INFO, ERROR}, sink.levels)
+	assert.Equal(t, []string{"Updated env file path=.env.local vars=3", "Failed to detect IP"}, sink.messages)
+	assert.True(t, sink.closed)
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink writes log entries to the local syslog daemon (or journald)
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSystemSink connects to the system log: syslog on Unix, the Event Log on Windows
+func NewSystemSink(tag string) (Sink, error) {
+	w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+// Write sends a message with the syslog severity matching level
+func (s *syslogSink) Write(level LogLevel, msg string) error {
+	switch level {
+	case DEBUG:
+		return s.w.Debug(msg)
+	case WARN:
+		return s.w.Warning(msg)
+	case ERROR:
+		return s.w.Err(msg)
+	default:
+		return s.w.Info(msg)
+	}
+}
+
+// Close closes the connection to syslog
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,45 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the event identifier of lanup entries
+const eventID = 1
+
+// eventLogSink writes log entries to the Windows Event Log
+type eventLogSink struct {
+	log *eventlog.Log
+}
+
+// NewSystemSink connects to the system log: syslog on Unix, the Event Log on Windows
+// Entries go to the Application log; an unregistered source is shown with a generic description
+func NewSystemSink(tag string) (Sink, error) {
+	log, err := eventlog.Open(tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Event Log: %w", err)
+	}
+	return &eventLogSink{log: log}, nil
+}
+
+// Write records a message with the event type matching level
+// The Event Log has no debug type, debug entries are recorded as information
+func (s *eventLogSink) Write(level LogLevel, msg string) error {
+	switch level {
+	case WARN:
+		return s.log.Warning(eventID, msg)
+	case ERROR:
+		return s.log.Error(eventID, msg)
+	default:
+		return s.log.Info(eventID, msg)
+	}
+}
+
+// Close closes the Event Log handle
+func (s *eventLogSink) Close() error {
+	return s.log.Close()
+}
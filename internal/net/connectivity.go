@@ -0,0 +1,241 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoPing is returned by Ping when the ping command is not installed
+var ErrNoPing = errors.New("ping is not installed")
+
+// GatewayResult is the outcome of CheckGateway
+type GatewayResult struct {
+	Gateway string
+	Method  string        // "ping" when it answered a ping, "arp" when it only answered ARP
+	Latency time.Duration // round trip of the ping, 0 for ARP
+	MAC     string        // hardware address of the gateway in the ARP cache, when found
+	PingErr error         // why the ping failed when only ARP answered, e.g. ErrNoPing
+}
+
+// DNSResult is the outcome of CheckDNS
+type DNSResult struct {
+	Host      string
+	Addresses []string
+	Servers   []string // configured DNS servers, empty when they are unknown (Windows)
+	Latency   time.Duration
+}
+
+// CheckGateway checks that the gateway answers a ping, or at least ARP when it drops pings,
+// which tells a broken network apart from a broken lanup
+func CheckGateway(ctx context.Context, gateway string, timeout time.Duration) (GatewayResult, error) {
+	result := GatewayResult{Gateway: gateway}
+
+	latency, pingErr := Ping(ctx, gateway, timeout)
+	if pingErr == nil {
+		result.Method, result.Latency = "ping", latency
+		result.MAC, _ = ARPEntry(gateway)
+		return result, nil
+	}
+
+	// Routers may drop pings, but they always answer ARP to be reachable at all.
+	// A datagram to the discard port makes the system resolve the gateway when ping is not installed.
+	if conn, err := net.DialTimeout("udp4", net.JoinHostPort(gateway, "9"), timeout); err == nil {
+		_, _ = conn.Write([]byte{0})
+		conn.Close()
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		if mac, err := ARPEntry(gateway); err == nil && mac != "" {
+			result.Method, result.MAC, result.PingErr = "arp", mac, pingErr
+			return result, nil
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return result, fmt.Errorf("gateway %s does not answer ping nor ARP: %w", gateway, pingErr)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// pingTimeRe matches the round trip in the output of ping: time=1.23 ms, time<1ms (Windows)
+var pingTimeRe = regexp.MustCompile(`(?i)time[=<]\s*([0-9.]+)\s*ms`)
+
+// Ping sends one ping to host with the ping command of the system, which needs no privileges,
+// and returns the round trip
+func Ping(ctx context.Context, host string, timeout time.Duration) (time.Duration, error) {
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	var args []string
+	switch runtime.GOOS {
+	case "windows":
+		args = []string{"-n", "1", "-w", strconv.FormatInt(timeout.Milliseconds(), 10), host}
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		args = []string{"-c", "1", "-t", strconv.Itoa(seconds), host}
+	default:
+		args = []string{"-c", "1", "-W", strconv.Itoa(seconds), host}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout+time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ping", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return 0, fmt.Errorf("no reply from %s", host)
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return 0, ErrNoPing
+		}
+		return 0, fmt.Errorf("failed to run ping: %w", err)
+	}
+
+	latency, ok := parsePingTime(string(out))
+	if !ok {
+		// Windows exits with 0 for "Destination host unreachable"
+		return 0, fmt.Errorf("no reply from %s", host)
+	}
+	return latency, nil
+}
+
+// parsePingTime extracts the round trip from the output of ping
+func parsePingTime(out string) (time.Duration, bool) {
+	m := pingTimeRe.FindStringSubmatch(out)
+	if m == nil {
+		return 0, false
+	}
+	ms, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms * float64(time.Millisecond)), true
+}
+
+// ARPEntry returns the hardware address of ip in the ARP cache, empty when it is not there
+func ARPEntry(ip string) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/net/arp")
+		if err != nil {
+			return "", err
+		}
+		return parseProcNetARP(string(data), ip), nil
+	case "windows":
+		out, err := exec.Command("arp", "-a", ip).Output()
+		if err != nil {
+			// arp exits with 1 when there is no entry
+			return "", nil
+		}
+		return parseARPOutput(string(out), ip), nil
+	default:
+		out, err := exec.Command("arp", "-n", ip).Output()
+		if err != nil {
+			return "", nil
+		}
+		return parseARPOutput(string(out), ip), nil
+	}
+}
+
+// parseProcNetARP extracts the hardware address of ip from /proc/net/arp, skipping incomplete entries
+func parseProcNetARP(out, ip string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != ip || fields[2] == "0x0" {
+			continue
+		}
+		if mac := normalizeMAC(fields[3]); mac != "" {
+			return mac
+		}
+	}
+	return ""
+}
+
+// parseARPOutput extracts the hardware address of ip from the output of arp on macOS and BSD
+// ("? (192.168.1.1) at a4:2b:b0:0:1:2 on en0") or Windows ("  192.168.1.1  a4-2b-b0-00-01-02  dynamic")
+func parseARPOutput(out, ip string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if strings.Trim(field, "()") != ip || i+1 >= len(fields) {
+				continue
+			}
+			next := fields[i+1]
+			if next == "at" && i+2 < len(fields) {
+				next = fields[i+2]
+			}
+			if mac := normalizeMAC(next); mac != "" {
+				return mac
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeMAC returns a hardware address as aa:bb:cc:dd:ee:ff, empty when it is not one
+// or when it is the all-zero address of an unresolved entry
+func normalizeMAC(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) != 6 {
+		return ""
+	}
+	zero := true
+	for i, part := range parts {
+		b, err := strconv.ParseUint(part, 16, 8)
+		if err != nil {
+			return ""
+		}
+		zero = zero && b == 0
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	if zero {
+		return ""
+	}
+	return strings.Join(parts, ":")
+}
+
+// CheckDNS resolves host with the DNS servers of the system and measures how long it took
+// The error is the one of the resolver, usually a *net.DNSError.
+func CheckDNS(ctx context.Context, host string, timeout time.Duration) (DNSResult, error) {
+	result := DNSResult{Host: host, Servers: DNSServers()}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	result.Latency = time.Since(start)
+	if err != nil {
+		return result, err
+	}
+	result.Addresses = addresses
+	return result, nil
+}
+
+// DNSServers returns the DNS servers of /etc/resolv.conf, nil on Windows or when it is missing
+func DNSServers() []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	return parseResolvConf(string(data))
+}
+
+// parseResolvConf extracts the nameserver lines of resolv.conf
+func parseResolvConf(out string) []string {
+	var servers []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
@@ -0,0 +1,76 @@
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePingTime(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want time.Duration
+		ok   bool
+	}{
+		{
+			name: "linux",
+			out:  "PING 192.168.1.1 (192.168.1.1) 56(84) bytes of data.\n64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=2.45 ms\n",
+			want: 2450 * time.Microsecond,
+			ok:   true,
+		},
+		{
+			name: "macos",
+			out:  "64 bytes from 192.168.0.254: icmp_seq=0 ttl=64 time=12.081 ms\n",
+			want: 12081 * time.Microsecond,
+			ok:   true,
+		},
+		{
+			name: "windows below 1ms",
+			out:  "Reply from 192.168.1.1: bytes=32 time<1ms TTL=64\n",
+			want: time.Millisecond,
+			ok:   true,
+		},
+		{
+			name: "windows unreachable",
+			out:  "Reply from 192.168.1.42: Destination host unreachable.\n",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePingTime(tt.out)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseProcNetARP(t *testing.T) {
+	out := "IP address       HW type     Flags       HW address            Mask     Device\n" +
+		"192.168.1.1      0x1         0x2         A4:2B:B0:00:01:02     *        wlan0\n" +
+		"192.168.1.7      0x1         0x0         00:00:00:00:00:00     *        wlan0\n"
+	assert.Equal(t, "a4:2b:b0:00:01:02", parseProcNetARP(out, "192.168.1.1"))
+	assert.Empty(t, parseProcNetARP(out, "192.168.1.7"), "incomplete entry")
+	assert.Empty(t, parseProcNetARP(out, "192.168.1.9"))
+}
+
+func TestParseARPOutput(t *testing.T) {
+	macos := "? (192.168.0.254) at a4:2b:b0:0:1:2 on en0 ifscope [ethernet]\n"
+	assert.Equal(t, "a4:2b:b0:00:01:02", parseARPOutput(macos, "192.168.0.254"))
+	assert.Empty(t, parseARPOutput("? (192.168.0.254) at (incomplete) on en0 ifscope [ethernet]\n", "192.168.0.254"))
+	assert.Empty(t, parseARPOutput("192.168.0.9 (192.168.0.9) -- no entry\n", "192.168.0.9"))
+
+	windows := "\nInterface: 192.168.1.42 --- 0x6\n" +
+		"  Internet Address      Physical Address      Type\n" +
+		"  192.168.1.1           a4-2b-b0-00-01-02     dynamic\n"
+	assert.Equal(t, "a4:2b:b0:00:01:02", parseARPOutput(windows, "192.168.1.1"))
+}
+
+func TestParseResolvConf(t *testing.T) {
+	out := "# Generated by NetworkManager\nsearch home\nnameserver 192.168.1.1\nnameserver 1.1.1.1\noptions edns0\n"
+	assert.Equal(t, []string{"192.168.1.1", "1.1.1.1"}, parseResolvConf(out))
+	assert.Empty(t, parseResolvConf("search home\n"))
+}
@@ -33,6 +33,35 @@ func DetectLocalIP() (*NetworkInfo, error) {
 	return selected, nil
 }
 
+// ManualIP returns the network of an IP address chosen by the user instead of the detected one,
+// with the interface that has it, Interface is empty when no interface of this machine has it
+func ManualIP(ip string) (*NetworkInfo, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	info := &NetworkInfo{IP: parsed.String()}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return info, nil
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(parsed) {
+				info.Interface = iface.Name
+				info.Type = classifyInterface(iface.Name)
+				return info, nil
+			}
+		}
+	}
+	return info, nil
+}
+
 // GetAllInterfaces returns all network interfaces with valid private IPs
 func GetAllInterfaces() ([]NetworkInfo, error) {
 	ifaces, err := net.Interfaces()
@@ -202,3 +202,19 @@ func TestClassifyInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestManualIP(t *testing.T) {
+	// The loopback address is assigned to an interface on every machine
+	info, err := ManualIP("127.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", info.IP)
+	assert.NotEmpty(t, info.Interface)
+
+	// Addresses of other machines are kept, without an interface
+	info, err = ManualIP("192.0.2.50")
+	assert.NoError(t, err)
+	assert.Equal(t, &NetworkInfo{IP: "192.0.2.50"}, info)
+
+	_, err = ManualIP("192.168.1")
+	assert.Error(t, err)
+}
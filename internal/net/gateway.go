@@ -0,0 +1,90 @@
+package net
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoGateway is returned when the default IPv4 gateway cannot be determined
+var ErrNoGateway = errors.New("no default gateway found")
+
+// DefaultGateway returns the IPv4 address of the default gateway, usually the router
+func DefaultGateway() (string, error) {
+	var gateway string
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/net/route")
+		if err != nil {
+			return "", err
+		}
+		gateway = parseProcNetRoute(string(data))
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		out, err := exec.Command("route", "-n", "get", "default").Output()
+		if err != nil {
+			return "", err
+		}
+		gateway = parseRouteGet(string(out))
+	case "windows":
+		out, err := exec.Command("route", "print", "-4", "0.0.0.0").Output()
+		if err != nil {
+			return "", err
+		}
+		gateway = parseRoutePrint(string(out))
+	}
+
+	if gateway == "" {
+		return "", ErrNoGateway
+	}
+	return gateway, nil
+}
+
+// parseProcNetRoute extracts the default gateway from /proc/net/route,
+// where addresses are little-endian hexadecimal
+func parseProcNetRoute(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" || fields[2] == "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip.String()
+	}
+	return ""
+}
+
+// parseRouteGet extracts the gateway from the output of 'route -n get default' (macOS and BSD)
+func parseRouteGet(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && key == "gateway" {
+			if ip := net.ParseIP(strings.TrimSpace(value)); ip != nil && ip.To4() != nil {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
+// parseRoutePrint extracts the gateway of the 0.0.0.0/0 route from the output of 'route print -4 0.0.0.0' (Windows)
+func parseRoutePrint(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "0.0.0.0" && fields[1] == "0.0.0.0" {
+			if ip := net.ParseIP(fields[2]); ip != nil && ip.To4() != nil {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
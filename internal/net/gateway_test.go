@@ -0,0 +1,43 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcNetRoute(t *testing.T) {
+	out := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"docker0\t000011AC\t00000000\t0001\t0\t0\t0\t0000FFFF\t0\t0\t0\n" +
+		"wlan0\t00000000\t0101A8C0\t0003\t0\t0\t600\t00000000\t0\t0\t0\n" +
+		"wlan0\t0001A8C0\t00000000\t0001\t0\t0\t600\t00FFFFFF\t0\t0\t0\n"
+	assert.Equal(t, "192.168.1.1", parseProcNetRoute(out))
+	assert.Empty(t, parseProcNetRoute("Iface\tDestination\tGateway\n"))
+}
+
+func TestParseRouteGet(t *testing.T) {
+	out := `   route to: default
+destination: default
+       mask: default
+    gateway: 192.168.0.254
+  interface: en0
+      flags: <UP,GATEWAY,DONE,STATIC,PRCLONING,GLOBAL>
+`
+	assert.Equal(t, "192.168.0.254", parseRouteGet(out))
+	assert.Empty(t, parseRouteGet("route: writing to routing socket: not in table\n"))
+}
+
+func TestParseRoutePrint(t *testing.T) {
+	out := `===========================================================================
+IPv4 Route Table
+===========================================================================
+Active Routes:
+Network Destination        Netmask          Gateway       Interface  Metric
+          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.42     25
+===========================================================================
+Persistent Routes:
+  None
+`
+	assert.Equal(t, "192.168.1.1", parseRoutePrint(out))
+	assert.Empty(t, parseRoutePrint("Active Routes:\nNone\n"))
+}
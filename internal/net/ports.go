@@ -0,0 +1,215 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListeningPort represents a TCP socket in the LISTEN state on this machine
+type ListeningPort struct {
+	Port    int
+	Address string // bind address as reported by the OS (e.g. *, 127.0.0.1, ::1)
+	PID     int
+	Process string
+}
+
+// IsLoopbackOnly reports whether the socket is bound to a loopback address only,
+// which means it cannot be reached from other devices on the LAN
+func (p ListeningPort) IsLoopbackOnly() bool {
+	addr := strings.Trim(p.Address, "[]")
+	if addr == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.IsLoopback()
+}
+
+// IsWildcard reports whether the socket listens on every interface (e.g. *, 0.0.0.0, ::)
+func (p ListeningPort) IsWildcard() bool {
+	addr := strings.Trim(p.Address, "[]")
+	if addr == "*" || addr == "" {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// GetListeningPorts returns the TCP ports currently listening on this machine
+// It relies on lsof, which is available by default on macOS and most Linux distributions,
+// and falls back to ss on Linux systems without lsof
+func GetListeningPorts() ([]ListeningPort, error) {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		if _, ssErr := exec.LookPath("ss"); ssErr == nil {
+			return getListeningPortsSs()
+		}
+	}
+
+	cmd := exec.Command("lsof", "-nP", "-iTCP", "-sTCP:LISTEN")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		// lsof exits with status 1 when nothing matches the filter
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && out.Len() == 0 {
+			return []ListeningPort{}, nil
+		}
+		return nil, fmt.Errorf("failed to execute lsof: %w", err)
+	}
+
+	return ParseLsofOutput(out.String()), nil
+}
+
+// ParseLsofOutput parses the output of `lsof -nP -iTCP -sTCP:LISTEN`
+// Format example:
+//
+//	COMMAND   PID USER   FD   TYPE DEVICE SIZE/OFF NODE NAME
+//	node    12345 dev    23u  IPv4 0x1234      0t0  TCP 127.0.0.1:5173 (LISTEN)
+//	node    12345 dev    24u  IPv6 0x5678      0t0  TCP [::1]:5173 (LISTEN)
+func ParseLsofOutput(output string) []ListeningPort {
+	ports := []ListeningPort{}
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] == "COMMAND" {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		// The socket address follows the TCP protocol column
+		var name string
+		for i, field := range fields {
+			if field == "TCP" && i+1 < len(fields) {
+				name = fields[i+1]
+				break
+			}
+		}
+		if name == "" {
+			continue
+		}
+
+		sep := strings.LastIndex(name, ":")
+		if sep < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(name[sep+1:])
+		if err != nil {
+			continue
+		}
+
+		listening := ListeningPort{
+			Port:    port,
+			Address: strings.Trim(name[:sep], "[]"),
+			PID:     pid,
+			Process: fields[0],
+		}
+
+		key := fmt.Sprintf("%d|%s|%d", listening.PID, listening.Address, listening.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		ports = append(ports, listening)
+	}
+
+	return ports
+}
+
+// getListeningPortsSs returns the listening TCP ports using ss
+func getListeningPortsSs() ([]ListeningPort, error) {
+	out, err := exec.Command("ss", "-Hltnp").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute ss: %w", err)
+	}
+	return ParseSsOutput(string(out)), nil
+}
+
+// ParseSsOutput parses the output of `ss -Hltnp`
+// Format example:
+//
+//	LISTEN 0 511   127.0.0.1:5173   0.0.0.0:*  users:(("node",pid=12345,fd=23))
+//	LISTEN 0 4096  [::]:22          [::]:*
+//
+// The process column is only present for sockets owned by the current user (or as root).
+func ParseSsOutput(output string) []ListeningPort {
+	ports := []ListeningPort{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "LISTEN" {
+			continue
+		}
+
+		local := fields[3]
+		sep := strings.LastIndex(local, ":")
+		if sep < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[sep+1:])
+		if err != nil {
+			continue
+		}
+
+		// Strip the interface scope, e.g. 127.0.0.53%lo
+		address := strings.Trim(local[:sep], "[]")
+		if i := strings.Index(address, "%"); i >= 0 {
+			address = address[:i]
+		}
+
+		listening := ListeningPort{Port: port, Address: address}
+		if len(fields) > 5 {
+			listening.Process, listening.PID = parseSsUsers(fields[5])
+		}
+
+		ports = append(ports, listening)
+	}
+
+	return ports
+}
+
+// parseSsUsers extracts the first process name and PID from an ss users:((...)) column
+func parseSsUsers(users string) (string, int) {
+	start := strings.Index(users, `(("`)
+	if start < 0 {
+		return "", 0
+	}
+	rest := users[start+3:]
+	end := strings.Index(rest, `"`)
+	if end < 0 {
+		return "", 0
+	}
+	name := rest[:end]
+
+	pid := 0
+	if i := strings.Index(rest, "pid="); i >= 0 {
+		digits := rest[i+4:]
+		if j := strings.IndexAny(digits, ",)"); j >= 0 {
+			digits = digits[:j]
+		}
+		pid, _ = strconv.Atoi(digits)
+	}
+
+	return name, pid
+}
+
+// IsPortOpen checks whether a TCP connection can be established to host:port
+func IsPortOpen(host string, port int, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,89 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLsofOutput(t *testing.T) {
+	output := `COMMAND   PID USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+node    12345 dev    23u  IPv4 0x1234567890abcdef      0t0  TCP 127.0.0.1:5173 (LISTEN)
+node    12345 dev    24u  IPv6 0x1234567890abcdee      0t0  TCP [::1]:5173 (LISTEN)
+node    23456 dev    25u  IPv6 0x1234567890abcded      0t0  TCP *:3000 (LISTEN)
+postgres  345 dev     7u  IPv4 0x1234567890abcdec      0t0  TCP 0.0.0.0:5432 (LISTEN)
+`
+
+	ports := ParseLsofOutput(output)
+	require.Len(t, ports, 4)
+
+	assert.Equal(t, ListeningPort{Port: 5173, Address: "127.0.0.1", PID: 12345, Process: "node"}, ports[0])
+	assert.Equal(t, ListeningPort{Port: 5173, Address: "::1", PID: 12345, Process: "node"}, ports[1])
+	assert.Equal(t, ListeningPort{Port: 3000, Address: "*", PID: 23456, Process: "node"}, ports[2])
+	assert.Equal(t, ListeningPort{Port: 5432, Address: "0.0.0.0", PID: 345, Process: "postgres"}, ports[3])
+}
+
+func TestParseLsofOutput_EmptyAndMalformed(t *testing.T) {
+	assert.Empty(t, ParseLsofOutput(""))
+	assert.Empty(t, ParseLsofOutput("COMMAND PID USER FD TYPE DEVICE SIZE/OFF NODE NAME\n"))
+	assert.Empty(t, ParseLsofOutput("node abc dev 23u IPv4 0x1 0t0 TCP 127.0.0.1:5173 (LISTEN)\n"))
+	assert.Empty(t, ParseLsofOutput("node 123 dev 23u IPv4 0x1 0t0 TCP 127.0.0.1:http (LISTEN)\n"))
+}
+
+func TestParseLsofOutput_Deduplicates(t *testing.T) {
+	output := `node 1 dev 23u IPv4 0x1 0t0 TCP *:8080 (LISTEN)
+node 1 dev 24u IPv4 0x2 0t0 TCP *:8080 (LISTEN)
+`
+	assert.Len(t, ParseLsofOutput(output), 1)
+}
+
+func TestListeningPort_IsLoopbackOnly(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"[::1]", true},
+		{"localhost", true},
+		{"*", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"192.168.1.100", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			p := ListeningPort{Port: 3000, Address: tt.address}
+			assert.Equal(t, tt.expected, p.IsLoopbackOnly())
+		})
+	}
+}
+
+func TestParseSsOutput(t *testing.T) {
+	output := `LISTEN 0      511        127.0.0.1:5173       0.0.0.0:*    users:(("node",pid=12345,fd=23))
+LISTEN 0      4096   127.0.0.53%lo:53         0.0.0.0:*
+LISTEN 0      128             [::]:22            [::]:*
+LISTEN 0      511                *:3000             *:*    users:(("node",pid=23456,fd=25),("node",pid=23457,fd=25))
+`
+
+	ports := ParseSsOutput(output)
+	require.Len(t, ports, 4)
+
+	assert.Equal(t, ListeningPort{Port: 5173, Address: "127.0.0.1", PID: 12345, Process: "node"}, ports[0])
+	assert.Equal(t, ListeningPort{Port: 53, Address: "127.0.0.53"}, ports[1])
+	assert.Equal(t, ListeningPort{Port: 22, Address: "::"}, ports[2])
+	assert.Equal(t, ListeningPort{Port: 3000, Address: "*", PID: 23456, Process: "node"}, ports[3])
+
+	assert.Empty(t, ParseSsOutput(""))
+	assert.Empty(t, ParseSsOutput("State Recv-Q Send-Q Local Address:Port Peer Address:Port Process\n"))
+}
+
+func TestListeningPort_IsWildcard(t *testing.T) {
+	assert.True(t, ListeningPort{Address: "*"}.IsWildcard())
+	assert.True(t, ListeningPort{Address: "0.0.0.0"}.IsWildcard())
+	assert.True(t, ListeningPort{Address: "::"}.IsWildcard())
+	assert.False(t, ListeningPort{Address: "127.0.0.1"}.IsWildcard())
+	assert.False(t, ListeningPort{Address: "192.168.1.100"}.IsWildcard())
+}
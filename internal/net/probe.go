@@ -0,0 +1,115 @@
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrUnsupportedScheme is returned for URLs that cannot be probed (no port and unknown scheme)
+var ErrUnsupportedScheme = errors.New("unsupported URL scheme")
+
+// defaultPorts maps URL schemes probed over TCP to their default port
+var defaultPorts = map[string]string{
+	"http":       "80",
+	"https":      "443",
+	"ws":         "80",
+	"wss":        "443",
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"mysql":      "3306",
+	"redis":      "6379",
+	"mongodb":    "27017",
+	"amqp":       "5672",
+}
+
+// ProbeResult is the outcome of a reachability probe
+type ProbeResult struct {
+	URL        string
+	Method     string // "http" or "tcp"
+	StatusCode int    // HTTP status code, 0 for TCP probes
+	Latency    time.Duration
+	Err        error
+}
+
+// Reachable reports whether the service answered the probe
+func (r ProbeResult) Reachable() bool {
+	return r.Err == nil
+}
+
+// Probe checks that the service behind rawURL answers, connecting from sourceIP
+// HTTP(S) URLs get a GET request and any response counts as reachable; other URLs
+// only need to accept a TCP connection. An empty sourceIP lets the OS pick the address
+func Probe(ctx context.Context, rawURL string, sourceIP string, timeout time.Duration) ProbeResult {
+	result := ProbeResult{URL: rawURL, Method: "tcp"}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		result.Err = fmt.Errorf("invalid URL: %s", rawURL)
+		return result
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[u.Scheme]
+	}
+	if port == "" {
+		result.Err = fmt.Errorf("%w: %s", ErrUnsupportedScheme, u.Scheme)
+		return result
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if sourceIP != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(sourceIP)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	if u.Scheme == "http" || u.Scheme == "https" {
+		result.Method = "http"
+		result.StatusCode, result.Err = probeHTTP(ctx, dialer, u)
+	} else {
+		var conn net.Conn
+		conn, result.Err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	result.Latency = time.Since(start)
+
+	return result
+}
+
+// probeHTTP sends a GET request without following redirects and returns the status code
+func probeHTTP(ctx context.Context, dialer *net.Dialer, u *url.URL) (int, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: dialer.DialContext,
+			// Local services often use self-signed certificates, only reachability matters here
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
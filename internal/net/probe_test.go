@@ -0,0 +1,90 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+
+	result := Probe(context.Background(), server.URL, "127.0.0.1", time.Second)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Reachable())
+	assert.Equal(t, "http", result.Method)
+	assert.Equal(t, http.StatusFound, result.StatusCode, "redirects should not be followed")
+	assert.Greater(t, result.Latency, time.Duration(0))
+
+	// Any HTTP response means the service is reachable
+	result = Probe(context.Background(), server.URL+"/missing", "", time.Second)
+	assert.True(t, result.Reachable())
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+}
+
+func TestProbe_HTTPS_SelfSigned(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result := Probe(context.Background(), server.URL, "", time.Second)
+	require.NoError(t, result.Err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+}
+
+func TestProbe_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	result := Probe(context.Background(), "postgresql://postgres:postgres@"+listener.Addr().String()+"/postgres", "127.0.0.1", time.Second)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "tcp", result.Method)
+	assert.Equal(t, 0, result.StatusCode)
+}
+
+func TestProbe_Unreachable(t *testing.T) {
+	// Grab a free port, then close it so that nothing listens there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	result := Probe(context.Background(), "http://"+addr, "", time.Second)
+	assert.False(t, result.Reachable())
+	assert.Error(t, result.Err)
+}
+
+func TestProbe_InvalidURLs(t *testing.T) {
+	result := Probe(context.Background(), "not a url", "", time.Second)
+	assert.Error(t, result.Err)
+
+	result = Probe(context.Background(), "custom://example.local", "", time.Second)
+	assert.True(t, errors.Is(result.Err, ErrUnsupportedScheme))
+	assert.True(t, strings.Contains(result.Err.Error(), "custom"))
+}
@@ -4,17 +4,50 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/raucheacho/lanup/internal/logger"
+)
+
+// ChangeReason tells what changed in the network
+type ChangeReason string
+
+const (
+	// ReasonIPChanged means the LAN IP address changed
+	ReasonIPChanged ChangeReason = "ip_changed"
+	// ReasonInterfaceChanged means the same IP is now reached through another interface
+	ReasonInterfaceChanged ChangeReason = "interface_changed"
+	// ReasonNetworkLost means no suitable interface is left, New is empty
+	ReasonNetworkLost ChangeReason = "network_lost"
+	// ReasonNetworkRestored means the network came back with the IP it had before being lost
+	ReasonNetworkRestored ChangeReason = "network_restored"
 )
 
+// ChangeEvent describes a change of the network seen by the watcher
+type ChangeEvent struct {
+	Old    NetworkInfo
+	New    NetworkInfo
+	Reason ChangeReason
+	Time   time.Time
+}
+
+// subscriberBuffer is the number of events a slow subscriber can fall behind before losing some
+const subscriberBuffer = 16
+
 // IPWatcher monitors network changes and detects IP address changes
 type IPWatcher struct {
-	CurrentIP string
+	CurrentIP string // last known IP, kept while the network is lost
 	Interval  time.Duration
-	OnChange  func(oldIP, newIP string)
-
-	mu      sync.RWMutex
-	stopCh  chan struct{}
-	stopped bool
+	OnChange  func(oldIP, newIP string) // called when the IP changes, see Subscribe for the other changes
+	Logger    *logger.Logger            // optional
+
+	mu          sync.RWMutex
+	stopCh      chan struct{}
+	stopped     bool
+	network     NetworkInfo
+	lost        bool
+	checked     time.Time // time of the last check
+	subscribers map[chan ChangeEvent]struct{}
+	detect      func() (*NetworkInfo, error)
 }
 
 // NewIPWatcher creates a new IP watcher with the specified check interval
@@ -25,8 +58,10 @@ func NewIPWatcher(interval time.Duration) *IPWatcher {
 	}
 
 	return &IPWatcher{
-		Interval: interval,
-		stopCh:   make(chan struct{}),
+		Interval:    interval,
+		stopCh:      make(chan struct{}),
+		subscribers: make(map[chan ChangeEvent]struct{}),
+		detect:      DetectLocalIP,
 	}
 }
 
@@ -41,13 +76,15 @@ func (w *IPWatcher) Start(ctx context.Context) error {
 	w.mu.Unlock()
 
 	// Detect initial IP
-	netInfo, err := DetectLocalIP()
+	netInfo, err := w.detect()
 	if err != nil {
 		return err
 	}
 
 	w.mu.Lock()
 	w.CurrentIP = netInfo.IP
+	w.network = *netInfo
+	w.checked = time.Now()
 	w.mu.Unlock()
 
 	// Start monitoring loop
@@ -63,13 +100,16 @@ func (w *IPWatcher) Start(ctx context.Context) error {
 		case <-ticker.C:
 			if err := w.checkIPChange(); err != nil {
 				// Continue monitoring even if detection fails
+				if w.Logger != nil {
+					w.Logger.Warn("Failed to detect IP", logger.Field{Key: "error", Value: err.Error()})
+				}
 				continue
 			}
 		}
 	}
 }
 
-// Stop stops the IP watcher
+// Stop stops the IP watcher and closes the subscriptions
 func (w *IPWatcher) Stop() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -80,28 +120,99 @@ func (w *IPWatcher) Stop() {
 
 	w.stopped = true
 	close(w.stopCh)
+	for ch := range w.subscribers {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Subscribe returns a channel receiving the network changes until ctx is done or the watcher is stopped,
+// when it is closed
+// Events are dropped rather than blocking the watcher when the channel is not read fast enough
+func (w *IPWatcher) Subscribe(ctx context.Context) <-chan ChangeEvent {
+	ch := make(chan ChangeEvent, subscriberBuffer)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		close(ch)
+		return ch
+	}
+	w.subscribers[ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.stopCh:
+		}
+		w.unsubscribe(ch)
+	}()
+	return ch
 }
 
-// checkIPChange detects if the IP address has changed and triggers the callback
+// unsubscribe removes and closes a subscription, unless Stop already did
+func (w *IPWatcher) unsubscribe(ch chan ChangeEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.subscribers[ch]; ok {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish sends an event to the subscribers without waiting for them
+func (w *IPWatcher) publish(event ChangeEvent) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for ch := range w.subscribers {
+		select {
+		case ch <- event:
+		default:
+			if w.Logger != nil {
+				w.Logger.Warn("Dropped network change event, subscriber too slow",
+					logger.Field{Key: "reason", Value: string(event.Reason)})
+			}
+		}
+	}
+}
+
+// checkIPChange detects if the network has changed, notifies the subscribers and triggers the callback
 func (w *IPWatcher) checkIPChange() error {
-	netInfo, err := DetectLocalIP()
+	netInfo, err := w.detect()
+
+	w.mu.Lock()
+	old, wasLost := w.network, w.lost
+	w.checked = time.Now()
 	if err != nil {
+		w.lost = true
+		w.mu.Unlock()
+		if !wasLost {
+			w.publish(ChangeEvent{Old: old, Reason: ReasonNetworkLost, Time: time.Now()})
+		}
 		return err
 	}
-
-	w.mu.Lock()
 	oldIP := w.CurrentIP
 	newIP := netInfo.IP
+	w.CurrentIP = newIP
+	w.network = *netInfo
+	w.lost = false
 	w.mu.Unlock()
 
-	if oldIP != newIP {
-		w.mu.Lock()
-		w.CurrentIP = newIP
-		w.mu.Unlock()
+	var reason ChangeReason
+	switch {
+	case old.IP != netInfo.IP:
+		reason = ReasonIPChanged
+	case wasLost:
+		reason = ReasonNetworkRestored
+	case old.Interface != netInfo.Interface:
+		reason = ReasonInterfaceChanged
+	default:
+		return nil
+	}
+	w.publish(ChangeEvent{Old: old, New: *netInfo, Reason: reason, Time: time.Now()})
 
-		if w.OnChange != nil {
-			w.OnChange(oldIP, newIP)
-		}
+	if oldIP != newIP && w.OnChange != nil {
+		w.OnChange(oldIP, newIP)
 	}
 
 	return nil
@@ -113,3 +224,17 @@ func (w *IPWatcher) GetCurrentIP() string {
 	defer w.mu.RUnlock()
 	return w.CurrentIP
 }
+
+// LastCheck returns the time of the last check of the network, zero before the first one
+func (w *IPWatcher) LastCheck() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.checked
+}
+
+// Network returns the network found by the last check, and false while it is lost
+func (w *IPWatcher) Network() (NetworkInfo, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.network, !w.lost && w.network.IP != ""
+}
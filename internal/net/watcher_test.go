@@ -0,0 +1,125 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNetwork replaces the IP detection of a watcher with a value set by the test
+type fakeNetwork struct {
+	mu   sync.Mutex
+	info *NetworkInfo
+}
+
+func (f *fakeNetwork) set(info *NetworkInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.info = info
+}
+
+func (f *fakeNetwork) detect() (*NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.info == nil {
+		return nil, errors.New("no suitable private IP address found")
+	}
+	info := *f.info
+	return &info, nil
+}
+
+func newTestWatcher(network *fakeNetwork) *IPWatcher {
+	w := NewIPWatcher(time.Millisecond)
+	w.detect = network.detect
+	return w
+}
+
+func TestIPWatcher_CheckIPChange(t *testing.T) {
+	wifi := &NetworkInfo{IP: "192.168.1.10", Interface: "en0", Type: "wifi"}
+	network := &fakeNetwork{info: wifi}
+	w := newTestWatcher(network)
+	w.CurrentIP, w.network = wifi.IP, *wifi
+
+	var changes [][2]string
+	w.OnChange = func(oldIP, newIP string) { changes = append(changes, [2]string{oldIP, newIP}) }
+	events := w.Subscribe(context.Background())
+	defer w.Stop()
+
+	steps := []struct {
+		info   *NetworkInfo
+		reason ChangeReason // empty when no event is expected
+	}{
+		{wifi, ""},
+		{&NetworkInfo{IP: "192.168.1.10", Interface: "eth0", Type: "ethernet"}, ReasonInterfaceChanged},
+		{nil, ReasonNetworkLost},
+		{nil, ""},
+		{&NetworkInfo{IP: "192.168.1.10", Interface: "eth0", Type: "ethernet"}, ReasonNetworkRestored},
+		{&NetworkInfo{IP: "10.0.0.4", Interface: "eth0", Type: "ethernet"}, ReasonIPChanged},
+	}
+	for i, step := range steps {
+		network.set(step.info)
+		err := w.checkIPChange()
+		assert.Equal(t, step.info == nil, err != nil, "step %d", i)
+
+		if step.reason == "" {
+			assert.Len(t, events, 0, "step %d", i)
+			continue
+		}
+		require.Len(t, events, 1, "step %d", i)
+		event := <-events
+		assert.Equal(t, step.reason, event.Reason, "step %d", i)
+		if step.info != nil {
+			assert.Equal(t, *step.info, event.New, "step %d", i)
+		} else {
+			assert.Empty(t, event.New.IP, "step %d", i)
+		}
+	}
+
+	assert.Equal(t, [][2]string{{"192.168.1.10", "10.0.0.4"}}, changes, "OnChange only sees IP changes")
+	assert.Equal(t, "10.0.0.4", w.GetCurrentIP())
+}
+
+func TestIPWatcher_Subscribe(t *testing.T) {
+	network := &fakeNetwork{info: &NetworkInfo{IP: "192.168.1.10", Interface: "en0"}}
+	w := newTestWatcher(network)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := w.Subscribe(ctx)
+	subCtx, unsubscribe := context.WithCancel(ctx)
+	second := w.Subscribe(subCtx)
+
+	go w.Start(ctx)
+	assert.Eventually(t, func() bool { return w.GetCurrentIP() == "192.168.1.10" }, time.Second, time.Millisecond)
+	network.set(&NetworkInfo{IP: "192.168.1.11", Interface: "en0"})
+
+	for _, events := range []<-chan ChangeEvent{first, second} {
+		select {
+		case event := <-events:
+			assert.Equal(t, ReasonIPChanged, event.Reason)
+			assert.Equal(t, "192.168.1.10", event.Old.IP)
+			assert.Equal(t, "192.168.1.11", event.New.IP)
+		case <-time.After(time.Second):
+			t.Fatal("no event received")
+		}
+	}
+
+	// Cancelling a subscription closes its channel only
+	unsubscribe()
+	assert.Eventually(t, func() bool {
+		_, open := <-second
+		return !open
+	}, time.Second, time.Millisecond)
+
+	// Stopping the watcher closes the others, later subscriptions are closed right away
+	w.Stop()
+	_, open := <-first
+	assert.False(t, open)
+	_, open = <-w.Subscribe(context.Background())
+	assert.False(t, open)
+}
@@ -0,0 +1,176 @@
+// Package notify posts the exposed URLs to Slack, Discord or webhooks on lifecycle events,
+// so that a team testing on the LAN learns the new URLs when they change
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// DefaultTimeout is how long a webhook may take to answer
+const DefaultTimeout = 10 * time.Second
+
+// discordMaxLength is the maximum length of a Discord message
+const discordMaxLength = 2000
+
+// Event is a lifecycle event of a project
+type Event struct {
+	Name    string // hook event: on_start, on_change or on_stop
+	Project string // project name
+	IP      string
+	OldIP   string // for on_change, empty when only the services changed
+	Vars    []env.EnvVar
+}
+
+// URLs returns the variables holding http(s) and ws(s) URLs, in order, without their credentials
+// Other schemes are left out: database DSNs and the like are of no use to a tester and may hold passwords.
+func (e Event) URLs() []env.EnvVar {
+	var urls []env.EnvVar
+	for _, v := range e.Vars {
+		if value, ok := env.PublicURL(v.Value); ok {
+			v.Value = value
+			urls = append(urls, v)
+		}
+	}
+	return urls
+}
+
+// Message returns the text posted for the event, e.g. "lanup: project shop now at http://192.168.1.42:3000"
+func (e Event) Message() string {
+	if e.Name == "on_stop" {
+		return fmt.Sprintf("lanup: project %s stopped, its URLs point to localhost again", e.Project)
+	}
+
+	urls := e.URLs()
+	var b strings.Builder
+	fmt.Fprintf(&b, "lanup: project %s", e.Project)
+	at := " now at"
+	if e.Name == "on_start" {
+		at = " started at"
+	}
+	switch {
+	case len(urls) == 1:
+		fmt.Fprintf(&b, "%s %s", at, urls[0].Value)
+	case e.IP != "":
+		fmt.Fprintf(&b, "%s %s", at, e.IP)
+	}
+	if e.OldIP != "" && e.OldIP != e.IP {
+		fmt.Fprintf(&b, " (was %s)", e.OldIP)
+	}
+	if len(urls) > 1 {
+		for _, v := range urls {
+			fmt.Fprintf(&b, "\n• %s: %s", v.Key, v.Value)
+		}
+	}
+	return b.String()
+}
+
+// webhookPayload is the JSON object posted to generic webhooks
+type webhookPayload struct {
+	Text    string            `json:"text"`
+	Event   string            `json:"event"`
+	Project string            `json:"project"`
+	IP      string            `json:"ip,omitempty"`
+	OldIP   string            `json:"old_ip,omitempty"`
+	URLs    map[string]string `json:"urls,omitempty"`
+}
+
+// Payload returns the JSON body posted for the event to a notification of kind
+func Payload(kind string, e Event) ([]byte, error) {
+	text := e.Message()
+	switch kind {
+	case config.NotificationSlack:
+		return json.Marshal(map[string]string{"text": text})
+	case config.NotificationDiscord:
+		if runes := []rune(text); len(runes) > discordMaxLength {
+			text = string(runes[:discordMaxLength-3]) + "..."
+		}
+		return json.Marshal(map[string]string{"content": text})
+	default:
+		payload := webhookPayload{Text: text, Event: e.Name, Project: e.Project, IP: e.IP, OldIP: e.OldIP}
+		if urls := e.URLs(); len(urls) > 0 && e.Name != "on_stop" {
+			payload.URLs = make(map[string]string, len(urls))
+			for _, v := range urls {
+				payload.URLs[v.Key] = v.Value
+			}
+		}
+		return json.Marshal(payload)
+	}
+}
+
+// Sender posts events to the configured notifications
+type Sender struct {
+	Client *http.Client
+}
+
+// NewSender creates a sender with the default timeout
+func NewSender() *Sender {
+	return &Sender{Client: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// Send posts the event to the notifications subscribed to it, even when some of them fail
+// Errors name the host of the webhook only, as its URL usually holds a secret.
+func (s *Sender) Send(ctx context.Context, notifications []config.NotificationConfig, e Event) error {
+	var errs []error
+	for _, n := range notifications {
+		if !n.Posts(e.Name) {
+			continue
+		}
+		if err := s.post(ctx, n, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends the event to one notification
+func (s *Sender) post(ctx context.Context, n config.NotificationConfig, e Event) error {
+	rawURL := os.ExpandEnv(n.URL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid notification url %s: check the environment variables it uses", n.URL)
+	}
+
+	expanded := n
+	expanded.URL = rawURL
+	body, err := Payload(expanded.Kind(), e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notification to %s: %w", u.Host, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lanup")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		// The error of the client includes the URL
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("notification to %s failed: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification to %s failed: %s", u.Host, resp.Status)
+	}
+	return nil
+}
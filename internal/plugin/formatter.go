@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// Formatter is an env file format implemented by a plugin
+type Formatter struct {
+	Plugin Plugin
+}
+
+// NewFormatter returns the formatter of an env file format: dotenv, dart-define, or the plugin with that name
+func NewFormatter(format string) (env.Formatter, error) {
+	switch format {
+	case "", "dotenv":
+		return env.DotenvFormatter{}, nil
+	case "dart-define":
+		return env.DartDefineFormatter{}, nil
+	}
+	p, err := Find(format)
+	if err != nil {
+		return nil, err
+	}
+	return Formatter{Plugin: p}, nil
+}
+
+// Name returns the name of the plugin
+func (f Formatter) Name() string {
+	return f.Plugin.Name
+}
+
+// Parse sends the file content to the plugin, which returns its variables
+func (f Formatter) Parse(r io.Reader) ([]env.EnvVar, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.Plugin.Call(context.Background(), Request{Action: ActionParse, Content: string(content)})
+	if err != nil {
+		return nil, err
+	}
+	return FromVars(resp.Vars), nil
+}
+
+// Render sends the variables to the plugin, which returns the file content
+func (f Formatter) Render(w io.Writer, vars []env.EnvVar) error {
+	resp, err := f.Plugin.Call(context.Background(), Request{Action: ActionRender, Vars: ToVars(vars)})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, resp.Content); err != nil {
+		return fmt.Errorf("failed to write variables: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+)
+
+// Notify sends a notify request to each notifier plugin, even when some of them fail
+// event must have its Event set, Action is set by Notify
+func Notify(ctx context.Context, names []string, event Request) error {
+	event.Action = ActionNotify
+
+	var errs []error
+	for _, name := range names {
+		p, err := Find(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := p.Call(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
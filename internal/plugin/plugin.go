@@ -0,0 +1,212 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/env"
+)
+
+// Prefix starts the name of the plugin executables: plugin "rails" is lanup-rails
+const Prefix = "lanup-"
+
+// ProtocolVersion is sent in every request, plugins can refuse versions they don't know
+const ProtocolVersion = 1
+
+// DefaultTimeout is how long a plugin may take to answer a request
+const DefaultTimeout = 10 * time.Second
+
+// Actions of the requests, a plugin supports the ones of its capabilities
+const (
+	ActionInfo   = "info"   // all plugins: name and capabilities
+	ActionDetect = "detect" // detector: variables of the services found
+	ActionParse  = "parse"  // formatter: variables of an env file
+	ActionRender = "render" // formatter: content of an env file
+	ActionNotify = "notify" // notifier: a lifecycle event happened
+)
+
+// Capabilities a plugin can declare in its info response
+const (
+	CapabilityDetector  = "detector"
+	CapabilityFormatter = "formatter"
+	CapabilityNotifier  = "notifier"
+)
+
+// nameRe matches valid plugin names
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidName reports whether name can be used as a plugin name
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// Var is a variable exchanged with a plugin
+type Var struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Managed bool   `json:"managed,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// Request is the JSON object written to the standard input of a plugin
+type Request struct {
+	Version int    `json:"version"`
+	Action  string `json:"action"`
+	Project string `json:"project,omitempty"` // project directory
+	Content string `json:"content,omitempty"` // parse: the env file
+	Vars    []Var  `json:"vars,omitempty"`    // render and notify
+	Event   string `json:"event,omitempty"`   // notify: on_start, on_change or on_stop
+	IP      string `json:"ip,omitempty"`      // notify
+	OldIP   string `json:"old_ip,omitempty"`  // notify, for on_change
+	Output  string `json:"output,omitempty"`  // notify: the env file path
+}
+
+// Response is the JSON object a plugin prints on its standard output
+type Response struct {
+	Name         string   `json:"name,omitempty"`         // info
+	Capabilities []string `json:"capabilities,omitempty"` // info
+	Vars         []Var    `json:"vars,omitempty"`         // detect and parse
+	Content      string   `json:"content,omitempty"`      // render
+	Error        string   `json:"error,omitempty"`        // set when the request failed
+}
+
+// Plugin is a lanup-<name> executable
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Find looks up the executable of the named plugin on PATH
+func Find(name string) (Plugin, error) {
+	if !ValidName(name) {
+		return Plugin{}, fmt.Errorf("invalid plugin name: %s", name)
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("plugin %s not found: no %s%s executable on PATH", name, Prefix, name)
+	}
+	return Plugin{Name: name, Path: path}, nil
+}
+
+// Discover returns the plugins found on PATH, sorted by name
+// A plugin found in several directories is the one exec.LookPath would pick, the first
+func Discover() []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// pluginName returns the plugin name of an executable file name
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(file))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	name, ok := strings.CutPrefix(file, Prefix)
+	return name, ok && ValidName(name)
+}
+
+// isExecutable reports whether path is a regular file that can be executed
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// Call sends a request to the plugin and returns its response
+// The request version is set, a response with an error is returned as an error
+func (p Plugin) Call(ctx context.Context, req Request) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req.Version = ProtocolVersion
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.Path)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("plugin %s timed out after %s", p.Name, DefaultTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", p.Name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", p.Name, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response to %s: %w", p.Name, req.Action, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	return &resp, nil
+}
+
+// Info asks the plugin for its name and capabilities
+func (p Plugin) Info(ctx context.Context) (*Response, error) {
+	return p.Call(ctx, Request{Action: ActionInfo})
+}
+
+// ToVars converts env variables to plugin variables
+func ToVars(vars []env.EnvVar) []Var {
+	result := make([]Var, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, Var{Key: v.Key, Value: v.Value, Managed: v.Managed, Source: v.Source})
+	}
+	return result
+}
+
+// FromVars converts plugin variables to env variables
+func FromVars(vars []Var) []env.EnvVar {
+	result := make([]env.EnvVar, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, env.EnvVar{Key: v.Key, Value: v.Value, Managed: v.Managed, Source: v.Source})
+	}
+	return result
+}
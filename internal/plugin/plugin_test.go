@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlugin answers like a plugin, and records the requests it receives in requests.log
+// It only uses shell builtins since PATH only contains the plugins
+const testPlugin = `#!/bin/sh
+read -r req
+echo "$req" >> "${0%/*}/requests.log"
+case "$req" in
+  *'"action":"info"'*) echo '{"name":"test","capabilities":["detector","formatter","notifier"]}' ;;
+  *'"action":"detect"'*) echo '{"vars":[{"key":"RAILS_URL","value":"http://localhost:3000"}]}' ;;
+  *'"action":"parse"'*) echo '{"vars":[{"key":"A","value":"1","managed":true}]}' ;;
+  *'"action":"render"'*) printf '%s\n' '{"content":"rendered\n"}' ;;
+  *'"action":"notify"'*) echo '{}' ;;
+  *) echo '{"error":"unknown action"}' ;;
+esac
+`
+
+// installPlugins writes plugin executables in a directory put on PATH and returns it
+func installPlugins(t *testing.T, plugins map[string]string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script plugins")
+	}
+
+	dir := t.TempDir()
+	for name, script := range plugins {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := installPlugins(t, map[string]string{
+		"lanup-test":    testPlugin,
+		"lanup-broken":  "#!/bin/sh\nexit 1\n",
+		"lanup-Invalid": testPlugin,
+		"other-tool":    testPlugin,
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lanup-noexec"), []byte(testPlugin), 0644))
+
+	plugins := Discover()
+	assert.Equal(t, []Plugin{
+		{Name: "broken", Path: filepath.Join(dir, "lanup-broken")},
+		{Name: "test", Path: filepath.Join(dir, "lanup-test")},
+	}, plugins)
+
+	p, err := Find("test")
+	require.NoError(t, err)
+	assert.Equal(t, plugins[1], p)
+
+	_, err = Find("missing")
+	assert.EqualError(t, err, "plugin missing not found: no lanup-missing executable on PATH")
+}
+
+func TestPlugin_Call(t *testing.T) {
+	dir := installPlugins(t, map[string]string{
+		"lanup-test":   testPlugin,
+		"lanup-broken": "#!/bin/sh\necho boom >&2\nexit 1\n",
+		"lanup-text":   "#!/bin/sh\necho not json\n",
+	})
+
+	p, _ := Find("test")
+	info, err := p.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{CapabilityDetector, CapabilityFormatter, CapabilityNotifier}, info.Capabilities)
+
+	resp, err := p.Call(context.Background(), Request{Action: ActionDetect, Project: "/project"})
+	require.NoError(t, err)
+	assert.Equal(t, []Var{{Key: "RAILS_URL", Value: "http://localhost:3000"}}, resp.Vars)
+
+	requests, err := os.ReadFile(filepath.Join(dir, "requests.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(requests), `{"version":1,"action":"detect","project":"/project"}`)
+
+	_, err = p.Call(context.Background(), Request{Action: "unknown"})
+	assert.EqualError(t, err, "plugin test: unknown action")
+
+	broken, _ := Find("broken")
+	_, err = broken.Info(context.Background())
+	assert.EqualError(t, err, "plugin broken failed: exit status 1: boom")
+
+	text, _ := Find("text")
+	_, err = text.Info(context.Background())
+	assert.ErrorContains(t, err, "plugin text: invalid response to info")
+}
+
+func TestFormatter(t *testing.T) {
+	installPlugins(t, map[string]string{"lanup-test": testPlugin})
+
+	formatter, err := NewFormatter("")
+	require.NoError(t, err)
+	assert.Equal(t, env.DotenvFormatter{}, formatter)
+
+	_, err = NewFormatter("missing")
+	assert.Error(t, err)
+
+	formatter, err = NewFormatter("test")
+	require.NoError(t, err)
+	assert.Equal(t, "test", formatter.Name())
+
+	vars, err := formatter.Parse(strings.NewReader("A=1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []env.EnvVar{{Key: "A", Value: "1", Managed: true}}, vars)
+
+	var buf bytes.Buffer
+	require.NoError(t, formatter.Render(&buf, vars))
+	assert.Equal(t, "rendered\n", buf.String())
+}
+
+func TestNotify(t *testing.T) {
+	dir := installPlugins(t, map[string]string{"lanup-test": testPlugin})
+
+	err := Notify(context.Background(), []string{"missing", "test"}, Request{Event: "on_change", IP: "192.168.1.20"})
+	assert.EqualError(t, err, "plugin missing not found: no lanup-missing executable on PATH")
+
+	requests, err := os.ReadFile(filepath.Join(dir, "requests.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(requests), `"action":"notify"`, "the other notifiers are still called")
+	assert.Contains(t, string(requests), `"event":"on_change","ip":"192.168.1.20"`)
+}
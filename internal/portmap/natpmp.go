@@ -0,0 +1,147 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// natpmpPort is the UDP port routers listen on for NAT-PMP requests (RFC 6886)
+const natpmpPort = 5351
+
+// NAT-PMP opcodes, responses use the request opcode + 128
+const (
+	natpmpOpExternalAddress = 0
+	natpmpOpMapTCP          = 2
+)
+
+// natpmpResults describes the NAT-PMP result codes
+var natpmpResults = map[uint16]string{
+	1: "unsupported version",
+	2: "not authorized or refused",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// NATPMPClient requests port mappings with NAT-PMP, supported by Apple routers and many others
+type NATPMPClient struct {
+	addr     string
+	timeout  time.Duration // first retransmission timeout, doubled on each attempt
+	attempts int
+}
+
+// NewNATPMPClient creates a client for the router at gateway
+func NewNATPMPClient(gateway string) *NATPMPClient {
+	return &NATPMPClient{
+		addr:     net.JoinHostPort(gateway, strconv.Itoa(natpmpPort)),
+		timeout:  250 * time.Millisecond,
+		attempts: 3,
+	}
+}
+
+// Name returns the protocol of the client
+func (c *NATPMPClient) Name() string {
+	return "NAT-PMP"
+}
+
+// ExternalIP returns the public address of the router
+func (c *NATPMPClient) ExternalIP(ctx context.Context) (string, error) {
+	resp, err := c.call(ctx, []byte{0, natpmpOpExternalAddress}, 12)
+	if err != nil {
+		return "", err
+	}
+	return net.IP(resp[8:12]).String(), nil
+}
+
+// Map forwards externalPort to the machine sending the request, internalIP is informational
+// as NAT-PMP always maps to the source address
+func (c *NATPMPClient) Map(ctx context.Context, internalIP string, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	resp, err := c.call(ctx, natpmpMapRequest(internalPort, externalPort, lifetime), 16)
+	if err != nil {
+		return nil, err
+	}
+	externalIP, err := c.ExternalIP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mapping{
+		Method:       c.Name(),
+		ExternalIP:   externalIP,
+		ExternalPort: int(binary.BigEndian.Uint16(resp[10:12])),
+		InternalIP:   internalIP,
+		InternalPort: int(binary.BigEndian.Uint16(resp[8:10])),
+		Lifetime:     time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second,
+	}, nil
+}
+
+// Unmap removes a mapping by requesting a lifetime of zero
+func (c *NATPMPClient) Unmap(ctx context.Context, m *Mapping) error {
+	_, err := c.call(ctx, natpmpMapRequest(m.InternalPort, 0, 0), 16)
+	return err
+}
+
+// natpmpMapRequest builds a TCP mapping request
+func natpmpMapRequest(internalPort, externalPort int, lifetime time.Duration) []byte {
+	req := make([]byte, 12)
+	req[1] = natpmpOpMapTCP
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+	return req
+}
+
+// call sends req until a response of at least size bytes arrives, and checks its result code
+func (c *NATPMPClient) call(ctx context.Context, req []byte, size int) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 16)
+	timeout := c.timeout
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		_ = conn.SetReadDeadline(deadline)
+		timeout *= 2
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if n < size || buf[0] != 0 || buf[1] != req[1]+128 {
+			continue
+		}
+		if code := binary.BigEndian.Uint16(buf[2:4]); code != 0 {
+			reason, ok := natpmpResults[code]
+			if !ok {
+				reason = fmt.Sprintf("result code %d", code)
+			}
+			return nil, fmt.Errorf("router refused the request: %s", reason)
+		}
+		return buf[:n], nil
+	}
+
+	return nil, fmt.Errorf("no response from %s", c.addr)
+}
@@ -0,0 +1,106 @@
+// Package portmap asks the router to forward a port from the internet to this machine,
+// using NAT-PMP or UPnP IGD
+package portmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrNoRouter is returned when no router answered the NAT-PMP or UPnP requests
+var ErrNoRouter = errors.New("no router supporting NAT-PMP or UPnP found")
+
+// DefaultLifetime is the lease requested for mappings, which are renewed before they expire
+const DefaultLifetime = time.Hour
+
+// Mapping is a TCP port forwarded by the router
+type Mapping struct {
+	Method       string // "NAT-PMP" or "UPnP"
+	ExternalIP   string
+	ExternalPort int
+	InternalIP   string
+	InternalPort int
+	Lifetime     time.Duration // 0 when the router only supports permanent mappings
+}
+
+// Address returns the external host:port of the mapping
+func (m *Mapping) Address() string {
+	return fmt.Sprintf("%s:%d", m.ExternalIP, m.ExternalPort)
+}
+
+// carrierNAT is the shared address space of carrier-grade NAT (RFC 6598)
+var carrierNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Public reports whether the external address is reachable from the internet, which it is not
+// when the router is itself behind another NAT, such as the carrier-grade NAT of the ISP
+func (m *Mapping) Public() bool {
+	ip := net.ParseIP(m.ExternalIP)
+	return ip != nil && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsUnspecified() && !carrierNAT.Contains(ip)
+}
+
+// Client requests port mappings from a router
+type Client interface {
+	// Name returns the protocol of the client
+	Name() string
+	// Map forwards externalPort (or the port chosen by the router) to internalIP:internalPort
+	Map(ctx context.Context, internalIP string, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error)
+	// Unmap removes a mapping
+	Unmap(ctx context.Context, m *Mapping) error
+}
+
+// Discover returns a client for the router, trying NAT-PMP on gateway first and then UPnP
+// gateway may be empty when unknown, in which case only UPnP is tried
+func Discover(ctx context.Context, gateway, localIP string) (Client, error) {
+	var errs []error
+	if gateway != "" {
+		client := NewNATPMPClient(gateway)
+		_, err := client.ExternalIP(ctx)
+		if err == nil {
+			return client, nil
+		}
+		errs = append(errs, fmt.Errorf("NAT-PMP: %w", err))
+	}
+
+	client, err := DiscoverUPnP(ctx, localIP)
+	if err == nil {
+		return client, nil
+	}
+	errs = append(errs, fmt.Errorf("UPnP: %w", err))
+
+	return nil, fmt.Errorf("%w (%w)", ErrNoRouter, errors.Join(errs...))
+}
+
+// Keep renews m at half its lifetime until ctx is done, reporting renewal failures to onError
+// Routers may assign another external port on renewal: m is updated in place, so wait
+// for Keep to return before reading it again.
+func Keep(ctx context.Context, client Client, m *Mapping, onError func(error)) {
+	if m.Lifetime <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	timer := time.NewTimer(m.Lifetime / 2)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		renewed, err := client.Map(ctx, m.InternalIP, m.InternalPort, m.ExternalPort, m.Lifetime)
+		if err != nil {
+			if ctx.Err() == nil && onError != nil {
+				onError(err)
+			}
+			// Retry sooner, the mapping may still be valid for a while
+			timer.Reset(time.Minute)
+			continue
+		}
+		*m = *renewed
+		timer.Reset(m.Lifetime / 2)
+	}
+}
@@ -0,0 +1,252 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATPMP answers NAT-PMP requests on a local UDP port, mapping external port 40000,
+// and returns the client with a function returning the last request
+func fakeNATPMP(t *testing.T, result uint16) (*NATPMPClient, func() []byte) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	var mu sync.Mutex
+	var last []byte
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte(nil), buf[:n]...)
+			mu.Lock()
+			last = req
+			mu.Unlock()
+
+			var resp []byte
+			switch req[1] {
+			case natpmpOpExternalAddress:
+				resp = make([]byte, 12)
+				copy(resp[8:], net.ParseIP("203.0.113.7").To4())
+			case natpmpOpMapTCP:
+				resp = make([]byte, 16)
+				copy(resp[8:10], req[4:6])
+				binary.BigEndian.PutUint16(resp[10:12], 40000)
+				copy(resp[12:16], req[8:12])
+			}
+			resp[1] = req[1] + 128
+			binary.BigEndian.PutUint16(resp[2:4], result)
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	client := &NATPMPClient{addr: conn.LocalAddr().String(), timeout: 50 * time.Millisecond, attempts: 2}
+	return client, func() []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+func TestNATPMPClient_Map(t *testing.T) {
+	client, lastRequest := fakeNATPMP(t, 0)
+	ctx := context.Background()
+
+	ip, err := client.ExternalIP(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", ip)
+
+	m, err := client.Map(ctx, "192.168.1.42", 3000, 3000, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, &Mapping{
+		Method:       "NAT-PMP",
+		ExternalIP:   "203.0.113.7",
+		ExternalPort: 40000,
+		InternalIP:   "192.168.1.42",
+		InternalPort: 3000,
+		Lifetime:     time.Hour,
+	}, m)
+	assert.Equal(t, "203.0.113.7:40000", m.Address())
+
+	require.NoError(t, client.Unmap(ctx, m))
+	assert.Equal(t, natpmpMapRequest(3000, 0, 0), lastRequest())
+}
+
+func TestNATPMPClient_Refused(t *testing.T) {
+	client, _ := fakeNATPMP(t, 2)
+	_, err := client.Map(context.Background(), "192.168.1.42", 3000, 3000, time.Hour)
+	assert.ErrorContains(t, err, "not authorized or refused")
+}
+
+func TestNATPMPClient_NoResponse(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := &NATPMPClient{addr: conn.LocalAddr().String(), timeout: 10 * time.Millisecond, attempts: 2}
+	_, err = client.ExternalIP(context.Background())
+	assert.ErrorContains(t, err, "no response")
+}
+
+const igdDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+// fakeIGD serves an Internet Gateway Device that only supports permanent leases
+func fakeIGD(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	actions := &[]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = io.WriteString(w, igdDescription)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		action := r.Header.Get("SOAPAction")
+		*actions = append(*actions, action)
+
+		switch {
+		case strings.HasSuffix(action, `#AddPortMapping"`) && !strings.Contains(string(body), "<NewLeaseDuration>0<"):
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail>`+
+				`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>725</errorCode>`+
+				`<errorDescription>OnlyPermanentLeasesSupported</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+		case strings.HasSuffix(action, `#GetExternalIPAddress"`):
+			_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+				`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+				`<NewExternalIPAddress>198.51.100.20</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>`)
+		default:
+			_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body/></s:Envelope>`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, actions
+}
+
+func TestUPnPClient_Map(t *testing.T) {
+	server, actions := fakeIGD(t)
+	ctx := context.Background()
+
+	client, err := upnpClientFromDescription(ctx, server.URL+"/rootDesc.xml")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/ctl/IPConn", client.controlURL)
+	assert.Equal(t, "urn:schemas-upnp-org:service:WANIPConnection:1", client.serviceType)
+
+	m, err := client.Map(ctx, "192.168.1.42", 5173, 0, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, &Mapping{
+		Method:       "UPnP",
+		ExternalIP:   "198.51.100.20",
+		ExternalPort: 5173,
+		InternalIP:   "192.168.1.42",
+		InternalPort: 5173,
+		Lifetime:     0, // the router refused the lease duration
+	}, m)
+
+	require.NoError(t, client.Unmap(ctx, m))
+	suffix := func(s string) string { return s[strings.Index(s, "#")+1 : len(s)-1] }
+	var names []string
+	for _, action := range *actions {
+		names = append(names, suffix(action))
+	}
+	assert.Equal(t, []string{"AddPortMapping", "AddPortMapping", "GetExternalIPAddress", "DeletePortMapping"}, names)
+}
+
+func TestUPnPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail>`+
+			`<UPnPError><errorCode>718</errorCode><errorDescription>ConflictInMappingEntry</errorDescription></UPnPError>`+
+			`</detail></s:Fault></s:Body></s:Envelope>`)
+	}))
+	defer server.Close()
+
+	client := NewUPnPClient(server.URL, "urn:schemas-upnp-org:service:WANIPConnection:1")
+	_, err := client.Map(context.Background(), "192.168.1.42", 3000, 3000, time.Hour)
+	var upnpErr *UPnPError
+	require.True(t, errors.As(err, &upnpErr))
+	assert.Equal(t, 718, upnpErr.Code)
+	assert.Equal(t, "ConflictInMappingEntry", upnpErr.Description)
+}
+
+func TestParseSSDPResponse(t *testing.T) {
+	resp := "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=120\r\n" +
+		"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+		"LOCATION: http://192.168.1.1:5000/rootDesc.xml\r\n" +
+		"SERVER: OpenWRT/21.02 UPnP/1.1 MiniUPnPd/2.2.1\r\n\r\n"
+	assert.Equal(t, "http://192.168.1.1:5000/rootDesc.xml", parseSSDPResponse([]byte(resp)))
+	assert.Empty(t, parseSSDPResponse([]byte("NOTIFY * HTTP/1.1\r\n\r\n")))
+}
+
+// renewingClient counts renewals
+type renewingClient struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *renewingClient) Name() string { return "fake" }
+
+func (c *renewingClient) Map(ctx context.Context, internalIP string, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return &Mapping{InternalIP: internalIP, InternalPort: internalPort, ExternalPort: externalPort + 1, Lifetime: lifetime}, nil
+}
+
+func (c *renewingClient) Unmap(ctx context.Context, m *Mapping) error { return nil }
+
+func TestKeep(t *testing.T) {
+	client := &renewingClient{}
+	m := &Mapping{InternalPort: 3000, ExternalPort: 3000, Lifetime: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	Keep(ctx, client, m, nil)
+
+	assert.GreaterOrEqual(t, client.calls, 2)
+	assert.Equal(t, 3000+client.calls, m.ExternalPort)
+}
+
+func TestMapping_Public(t *testing.T) {
+	assert.True(t, (&Mapping{ExternalIP: "203.0.113.7"}).Public())
+	assert.False(t, (&Mapping{ExternalIP: "192.168.0.2"}).Public())
+	assert.False(t, (&Mapping{ExternalIP: "100.72.1.9"}).Public())
+	assert.False(t, (&Mapping{ExternalIP: "0.0.0.0"}).Public())
+	assert.False(t, (&Mapping{ExternalIP: ""}).Public())
+}
@@ -0,0 +1,369 @@
+package portmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ssdpAddr is the multicast address UPnP devices listen on for discovery
+const ssdpAddr = "239.255.255.250:1900"
+
+// ssdpTimeout is how long discovery waits for routers to answer
+const ssdpTimeout = 2 * time.Second
+
+// upnpGatewayTypes are the device types searched with SSDP
+var upnpGatewayTypes = []string{
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+}
+
+// upnpServiceTypes are the services able to forward ports, in order of preference
+var upnpServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// upnpOnlyPermanentLeases is the error of routers that do not support lease durations
+const upnpOnlyPermanentLeases = 725
+
+// UPnPError is an error returned by the router for a SOAP action
+type UPnPError struct {
+	Code        int
+	Description string
+}
+
+// Error implements the error interface
+func (e *UPnPError) Error() string {
+	return fmt.Sprintf("router refused the request: %s (UPnP error %d)", e.Description, e.Code)
+}
+
+// UPnPClient requests port mappings from an Internet Gateway Device
+type UPnPClient struct {
+	controlURL  string
+	serviceType string
+	http        *http.Client
+}
+
+// NewUPnPClient creates a client for the service of serviceType controlled at controlURL
+func NewUPnPClient(controlURL, serviceType string) *UPnPClient {
+	return &UPnPClient{
+		controlURL:  controlURL,
+		serviceType: serviceType,
+		http:        &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name returns the protocol of the client
+func (c *UPnPClient) Name() string {
+	return "UPnP"
+}
+
+// DiscoverUPnP searches the LAN of localIP for an Internet Gateway Device
+func DiscoverUPnP(ctx context.Context, localIP string) (*UPnPClient, error) {
+	locations, err := ssdpSearch(ctx, localIP)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return nil, errors.New("no Internet Gateway Device answered")
+	}
+
+	var errs []error
+	for _, location := range locations {
+		client, err := upnpClientFromDescription(ctx, location)
+		if err == nil {
+			return client, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ExternalIP returns the public address of the router
+func (c *UPnPClient) ExternalIP(ctx context.Context) (string, error) {
+	values, err := c.soap(ctx, "GetExternalIPAddress", nil)
+	if err != nil {
+		return "", err
+	}
+	ip := values["NewExternalIPAddress"]
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("router returned an invalid external address %q", ip)
+	}
+	return ip, nil
+}
+
+// Map forwards externalPort (internalPort when 0) to internalIP:internalPort
+func (c *UPnPClient) Map(ctx context.Context, internalIP string, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	if externalPort == 0 {
+		externalPort = internalPort
+	}
+
+	args := func(lifetime time.Duration) [][2]string {
+		return [][2]string{
+			{"NewRemoteHost", ""},
+			{"NewExternalPort", strconv.Itoa(externalPort)},
+			{"NewProtocol", "TCP"},
+			{"NewInternalPort", strconv.Itoa(internalPort)},
+			{"NewInternalClient", internalIP},
+			{"NewEnabled", "1"},
+			{"NewPortMappingDescription", "lanup"},
+			{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+		}
+	}
+
+	_, err := c.soap(ctx, "AddPortMapping", args(lifetime))
+	var upnpErr *UPnPError
+	if errors.As(err, &upnpErr) && upnpErr.Code == upnpOnlyPermanentLeases {
+		lifetime = 0
+		_, err = c.soap(ctx, "AddPortMapping", args(lifetime))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	externalIP, err := c.ExternalIP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mapping{
+		Method:       c.Name(),
+		ExternalIP:   externalIP,
+		ExternalPort: externalPort,
+		InternalIP:   internalIP,
+		InternalPort: internalPort,
+		Lifetime:     lifetime,
+	}, nil
+}
+
+// Unmap removes a mapping
+func (c *UPnPClient) Unmap(ctx context.Context, m *Mapping) error {
+	_, err := c.soap(ctx, "DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(m.ExternalPort)},
+		{"NewProtocol", "TCP"},
+	})
+	return err
+}
+
+// soap calls action on the service and returns the values of the response
+func (c *UPnPClient) soap(ctx context.Context, action string, args [][2]string) (map[string]string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, c.serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(&body, "<%s>", arg[0])
+		_ = xml.EscapeText(&body, []byte(arg[1]))
+		fmt.Fprintf(&body, "</%s>", arg[0])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.controlURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, c.serviceType, action))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	values, err := soapValues(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		code, _ := strconv.Atoi(values["errorCode"])
+		if code == 0 {
+			return nil, fmt.Errorf("%s failed: %s", action, resp.Status)
+		}
+		return nil, &UPnPError{Code: code, Description: values["errorDescription"]}
+	}
+	return values, nil
+}
+
+// soapValues returns the text of the leaf elements of a SOAP response by local name
+func soapValues(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	decoder := xml.NewDecoder(r)
+	var name string
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name = t.Name.Local
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if t.Name.Local == name {
+				values[name] = strings.TrimSpace(text.String())
+			}
+			name = ""
+		}
+	}
+}
+
+// ssdpSearch multicasts M-SEARCH requests from localIP and returns the description URLs of the gateways
+func ssdpSearch(ctx context.Context, localIP string) ([]string, error) {
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(localIP, "0"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	dst, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range upnpGatewayTypes {
+		msg := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpAddr + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 1\r\n" +
+			"ST: " + st + "\r\n\r\n"
+		if _, err := conn.WriteTo([]byte(msg), dst); err != nil {
+			return nil, err
+		}
+	}
+
+	deadline := time.Now().Add(ssdpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetReadDeadline(deadline)
+
+	var locations []string
+	seen := make(map[string]bool)
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The deadline ends the search
+			return locations, nil
+		}
+		if location := parseSSDPResponse(buf[:n]); location != "" && !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+}
+
+// parseSSDPResponse returns the LOCATION header of an SSDP search response
+func parseSSDPResponse(data []byte) string {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Location")
+}
+
+// upnpDescription is the device description document of a UPnP root device
+type upnpDescription struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+// upnpDevice is a UPnP device and its embedded devices
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+// upnpService is a service of a UPnP device
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// upnpClientFromDescription fetches the description at location and finds its WAN connection service
+func upnpClientFromDescription(ctx context.Context, location string) (*UPnPClient, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", location, resp.Status)
+	}
+
+	var desc upnpDescription
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&desc); err != nil {
+		return nil, fmt.Errorf("invalid device description at %s: %w", location, err)
+	}
+
+	for _, serviceType := range upnpServiceTypes {
+		service, ok := findUPnPService(desc.Device, serviceType)
+		if !ok {
+			continue
+		}
+		base := desc.URLBase
+		if base == "" {
+			base = location
+		}
+		controlURL, err := resolveURL(base, service.ControlURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewUPnPClient(controlURL, serviceType), nil
+	}
+	return nil, fmt.Errorf("%s has no WAN connection service", location)
+}
+
+// findUPnPService searches device and its embedded devices for a service of serviceType
+func findUPnPService(device upnpDevice, serviceType string) (upnpService, bool) {
+	for _, service := range device.Services {
+		if service.ServiceType == serviceType {
+			return service, true
+		}
+	}
+	for _, embedded := range device.Devices {
+		if service, ok := findUPnPService(embedded, serviceType); ok {
+			return service, true
+		}
+	}
+	return upnpService{}, false
+}
+
+// resolveURL resolves ref, possibly relative, against base
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
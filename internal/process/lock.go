@@ -0,0 +1,98 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// DefaultLockTimeout is how long a lanup process waits for another one to finish writing the env file
+const DefaultLockTimeout = 30 * time.Second
+
+// lockPollInterval is how often a waiting process retries the lock
+const lockPollInterval = 100 * time.Millisecond
+
+// ErrLocked is returned when another process still holds the project lock after the timeout
+var ErrLocked = errors.New("project is locked by another lanup process")
+
+// Lock is an exclusive lock on a project, held while its env file and backups are written
+// It is released by the system when the process exits, a crash doesn't leave a stale lock
+type Lock struct {
+	file *os.File
+}
+
+// LockFile returns the lock file path for a project directory
+func LockFile(projectDir string) (string, error) {
+	return projectRunFile(projectDir, ".lock")
+}
+
+// LockProject locks the project directory, waiting up to timeout for another process to release it
+func LockProject(projectDir string, timeout time.Duration) (*Lock, error) {
+	path, err := LockFile(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return AcquireLock(path, timeout)
+}
+
+// AcquireLock locks the file at path, waiting up to timeout for another process to release it
+// A zero timeout fails at once, the error wraps ErrLocked and names the holder
+func AcquireLock(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			err := lockedError(path)
+			file.Close()
+			return nil, err
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// The PID tells the processes waiting for the lock who holds it
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Release unlocks the project, it is safe to call on a nil lock
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// lockedError describes the lock holder, when its PID can be read
+func lockedError(path string) error {
+	if pid, err := ReadPIDFile(path); err == nil {
+		return fmt.Errorf("%w (PID %d)", ErrLocked, pid)
+	}
+	return ErrLocked
+}
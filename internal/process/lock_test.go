@@ -0,0 +1,68 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "test.lock")
+
+	lock, err := AcquireLock(path, 0)
+	require.NoError(t, err)
+
+	// A second lock on the same file fails at once, naming the holder
+	_, err = AcquireLock(path, 0)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Contains(t, err.Error(), fmt.Sprintf("PID %d", os.Getpid()))
+
+	require.NoError(t, lock.Release())
+	assert.NoError(t, lock.Release(), "releasing twice is harmless")
+
+	again, err := AcquireLock(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, again.Release())
+}
+
+func TestAcquireLock_Waits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lock")
+
+	lock, err := AcquireLock(path, 0)
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lock.Release()
+	}()
+
+	start := time.Now()
+	waiting, err := AcquireLock(path, 5*time.Second)
+	require.NoError(t, err)
+	defer waiting.Release()
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestLockProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := LockFile("/projects/a")
+	require.NoError(t, err)
+	assert.Equal(t, ".lock", filepath.Ext(path))
+
+	lock, err := LockProject("/projects/a", 0)
+	require.NoError(t, err)
+	defer lock.Release()
+
+	// Projects are locked independently
+	other, err := LockProject("/projects/b", 0)
+	require.NoError(t, err)
+	require.NoError(t, other.Release())
+
+	_, err = LockProject("/projects/a", 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLocked)
+}
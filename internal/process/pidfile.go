@@ -0,0 +1,131 @@
+package process
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunDir returns the directory holding lanup PID files (~/.lanup/run)
+func RunDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "run"), nil
+}
+
+// WatchPIDFile returns the PID file path of the watcher for a project directory
+// The file name is derived from the absolute project path so that each project has its own watcher
+func WatchPIDFile(projectDir string) (string, error) {
+	return projectRunFile(projectDir, ".pid")
+}
+
+// SharedDaemonPIDFile returns the PID file of the daemon watching all the registered projects
+func SharedDaemonPIDFile() (string, error) {
+	runDir, err := RunDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runDir, "daemon.pid"), nil
+}
+
+// APIAddrFile returns the file holding the API address of the watcher for a project directory
+func APIAddrFile(projectDir string) (string, error) {
+	return projectRunFile(projectDir, ".api")
+}
+
+// DevicesFile returns the file holding the devices paired with the daemon of a project directory
+func DevicesFile(projectDir string) (string, error) {
+	return projectRunFile(projectDir, ".devices.json")
+}
+
+// projectRunFile returns the path of a per-project file in the run directory
+func projectRunFile(projectDir, ext string) (string, error) {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	runDir, err := RunDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum([]byte(absDir))
+	return filepath.Join(runDir, "watch-"+hex.EncodeToString(sum[:])[:12]+ext), nil
+}
+
+// WritePIDFile writes the current process ID to path, creating parent directories
+func WritePIDFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadPIDFile returns the process ID stored in path
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+
+	return pid, nil
+}
+
+// RemovePIDFile removes the PID file, ignoring missing files
+func RemovePIDFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove PID file: %w", err)
+	}
+	return nil
+}
+
+// RunningPID returns the PID stored in path if that process is still alive
+// Stale PID files are removed and reported as not running
+func RunningPID(path string) (int, bool) {
+	pid, err := ReadPIDFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	if !IsRunning(pid) {
+		_ = RemovePIDFile(path)
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// StopAndWait asks the process to terminate and waits up to timeout for it to exit
+func StopAndWait(pid int, timeout time.Duration) error {
+	if err := Terminate(pid); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !IsRunning(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("process %d did not exit within %s", pid, timeout)
+}
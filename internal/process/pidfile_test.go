@@ -0,0 +1,83 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndReadPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "test.pid")
+
+	require.NoError(t, WritePIDFile(path))
+
+	pid, err := ReadPIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+
+	running, ok := RunningPID(path)
+	assert.True(t, ok)
+	assert.Equal(t, os.Getpid(), running)
+
+	require.NoError(t, RemovePIDFile(path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// Removing a missing file is not an error
+	assert.NoError(t, RemovePIDFile(path))
+}
+
+func TestReadPIDFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.pid")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-pid"), 0644))
+
+	_, err := ReadPIDFile(path)
+	assert.Error(t, err)
+
+	_, ok := RunningPID(path)
+	assert.False(t, ok)
+}
+
+func TestRunningPID_StaleFileIsRemoved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stale.pid")
+	// PIDs are capped well below this value on all supported platforms
+	require.NoError(t, os.WriteFile(path, []byte("999999999"), 0644))
+
+	_, ok := RunningPID(path)
+	assert.False(t, ok)
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWatchPIDFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	a, err := WatchPIDFile("/projects/a")
+	require.NoError(t, err)
+	b, err := WatchPIDFile("/projects/b")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, ".pid", filepath.Ext(a))
+
+	again, err := WatchPIDFile("/projects/a")
+	require.NoError(t, err)
+	assert.Equal(t, a, again)
+}
+
+func TestAPIAddrFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pidPath, err := WatchPIDFile("/projects/a")
+	require.NoError(t, err)
+	addrPath, err := APIAddrFile("/projects/a")
+	require.NoError(t, err)
+
+	assert.Equal(t, ".api", filepath.Ext(addrPath))
+	assert.Equal(t, strings.TrimSuffix(pidPath, ".pid"), strings.TrimSuffix(addrPath, ".api"))
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package process
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name string
+		want syscall.Signal
+	}{
+		{"HUP", syscall.SIGHUP},
+		{"hup", syscall.SIGHUP},
+		{"SIGUSR1", syscall.SIGUSR1},
+		{"term", syscall.SIGTERM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseSignal(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sig)
+		})
+	}
+
+	_, err := ParseSignal("KILLALL")
+	assert.Error(t, err)
+}
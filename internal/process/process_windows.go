@@ -0,0 +1,48 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is the DETACHED_PROCESS creation flag: the child gets no console
+const detachedProcess = 0x00000008
+
+// IsRunning reports whether a process with the given PID exists
+// On Windows, FindProcess opens a handle and fails when the process is gone
+func IsRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// Terminate stops the process; Windows has no SIGTERM so the process is killed
+func Terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process %d: %w", pid, err)
+	}
+	if err := p.Kill(); err != nil {
+		return fmt.Errorf("failed to stop process %d: %w", pid, err)
+	}
+	return nil
+}
+
+// Detach makes cmd run without a console, so that it survives the terminal it was started from
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// ParseSignal always fails: processes cannot be sent signals on Windows
+func ParseSignal(name string) (os.Signal, error) {
+	return nil, fmt.Errorf("sending signal %s is not supported on Windows", name)
+}
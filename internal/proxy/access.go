@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Access restricts who reaches the proxied services
+type Access struct {
+	User     string       // basic auth user, no authentication when empty
+	Password string       // basic auth password
+	Allow    []*net.IPNet // client networks allowed, any when empty; loopback clients are always allowed
+}
+
+// ParseAuth parses basic auth credentials written user:password
+func ParseAuth(s string) (user, password string, err error) {
+	user, password, ok := strings.Cut(s, ":")
+	if !ok || user == "" || password == "" {
+		return "", "", fmt.Errorf("expected USER:PASSWORD, got %q", redact(s))
+	}
+	return user, password, nil
+}
+
+// ParseAllow parses IP addresses and CIDR ranges, such as 192.168.1.0/24
+func ParseAllow(entries []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR range: %s", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+// Restrict returns next behind the allowlist and the basic auth: clients outside the allowlist
+// get a 403, requests without the credentials a 401
+func (a Access) Restrict(next http.Handler) http.Handler {
+	if a.User == "" && len(a.Allow) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Allowed(clientIP(r)) {
+			http.Error(w, "lanup: forbidden", http.StatusForbidden)
+			return
+		}
+		if a.User != "" {
+			user, password, ok := r.BasicAuth()
+			// Both are compared so that the time taken doesn't tell which one is wrong
+			userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.User)) == 1
+			passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1
+			if !ok || !userOK || !passwordOK {
+				w.Header().Set("WWW-Authenticate", `Basic realm="lanup", charset="UTF-8"`)
+				http.Error(w, "lanup: unauthorized", http.StatusUnauthorized)
+				return
+			}
+			// The credentials of the proxy are not for the apps behind it, which may have their own
+			r = r.Clone(r.Context())
+			r.Header.Del("Authorization")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Allowed reports whether a client IP is in the allowlist
+func (a Access) Allowed(ip net.IP) bool {
+	if len(a.Allow) == 0 || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	for _, network := range a.Allow {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP of the client that connected, X-Forwarded-For is not trusted
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// redact hides the password of user:password credentials in error messages
+func redact(s string) string {
+	if user, _, ok := strings.Cut(s, ":"); ok {
+		return user + ":***"
+	}
+	return s
+}
+
+// Entry is a request served by the proxy, for the access logs
+type Entry struct {
+	Method   string
+	Host     string
+	Path     string // without the query string, which may hold tokens
+	Remote   string // client address
+	User     string // basic auth user sent, if any
+	Status   int
+	Bytes    int64 // response body size
+	Duration time.Duration
+}
+
+// LogRequests returns next, passing each request to log once it was served
+func LogRequests(next http.Handler, log func(Entry)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		user, _, _ := r.BasicAuth()
+		log(Entry{
+			Method:   r.Method,
+			Host:     r.Host,
+			Path:     r.URL.Path,
+			Remote:   r.RemoteAddr,
+			User:     user,
+			Status:   rec.status,
+			Bytes:    rec.bytes,
+			Duration: time.Since(start),
+		})
+	})
+}
+
+// statusRecorder records the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of the body
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the response writer, so that WebSocket upgrades can hijack the connection
+// and streamed responses be flushed through http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuth(t *testing.T) {
+	user, password, err := ParseAuth("admin:s3cret:with:colons")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user)
+	assert.Equal(t, "s3cret:with:colons", password)
+
+	for _, invalid := range []string{"admin", "admin:", ":s3cret"} {
+		_, _, err := ParseAuth(invalid)
+		assert.Error(t, err, invalid)
+	}
+	// The password is not part of the error
+	_, _, err = ParseAuth(":s3cret")
+	assert.NotContains(t, err.Error(), "s3cret")
+}
+
+func TestParseAllow(t *testing.T) {
+	networks, err := ParseAllow([]string{"192.168.1.0/24", "10.0.0.7", "fd00::/8"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "10.0.0.7/32", networks[1].String())
+
+	_, err = ParseAllow([]string{"192.168.1"})
+	assert.ErrorContains(t, err, "invalid IP address or CIDR range: 192.168.1")
+}
+
+func TestAccess_Restrict(t *testing.T) {
+	allow, err := ParseAllow([]string{"192.168.1.0/24"})
+	require.NoError(t, err)
+	access := Access{User: "admin", Password: "s3cret", Allow: allow}
+	handler := access.Restrict(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The password of the proxy is not forwarded to the upstream
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.Write([]byte("ok"))
+	}))
+
+	request := func(remote, user, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://192.168.1.10:8888/admin", nil)
+		req.RemoteAddr = remote
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("192.168.1.20:50000", "admin", "s3cret").Code)
+	assert.Equal(t, http.StatusOK, request("127.0.0.1:50000", "admin", "s3cret").Code)
+	assert.Equal(t, http.StatusForbidden, request("10.0.0.5:50000", "admin", "s3cret").Code)
+
+	rec := request("192.168.1.20:50000", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `Basic realm="lanup"`)
+	assert.Equal(t, http.StatusUnauthorized, request("192.168.1.20:50000", "admin", "wrong").Code)
+
+	// Without an allowlist any client is allowed
+	assert.True(t, Access{}.Allowed(nil))
+}
+
+func TestLogRequests(t *testing.T) {
+	var entries []Entry
+	handler := LogRequests(Access{User: "admin", Password: "s3cret"}.Restrict(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})), func(e Entry) {
+		entries = append(entries, e)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "http://admin.lan:8888/users?page=2", nil)
+	req.RemoteAddr = "192.168.1.20:50000"
+	req.SetBasicAuth("admin", "s3cret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Denied requests are logged too
+	req = httptest.NewRequest(http.MethodGet, "http://admin.lan:8888/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, "POST", entries[0].Method)
+	assert.Equal(t, "admin.lan:8888", entries[0].Host)
+	assert.Equal(t, "/users", entries[0].Path, "the query string may hold tokens")
+	assert.Equal(t, "192.168.1.20:50000", entries[0].Remote)
+	assert.Equal(t, "admin", entries[0].User)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+	assert.Equal(t, int64(len("created")), entries[0].Bytes)
+	assert.Equal(t, http.StatusUnauthorized, entries[1].Status)
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/config"
+)
+
+// Route forwards matching requests to a local service
+type Route struct {
+	Path        string // path prefix, empty matches any path
+	Host        string // request host (without port), empty matches any host
+	Target      *url.URL
+	StripPrefix bool
+
+	proxy *httputil.ReverseProxy
+}
+
+// Proxy is a reverse proxy routing requests by host and path prefix
+type Proxy struct {
+	routes []*Route
+}
+
+// New creates a proxy from route configurations
+// Routes are matched from the most specific to the least specific: host routes first,
+// then longer path prefixes before shorter ones
+func New(routes []config.RouteConfig) (*Proxy, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no routes configured")
+	}
+
+	p := &Proxy{}
+	for _, cfg := range routes {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+
+		target, _ := url.Parse(cfg.Target)
+		route := &Route{
+			Path:        strings.TrimSuffix(cfg.Path, "/"),
+			Host:        strings.ToLower(cfg.Host),
+			Target:      target,
+			StripPrefix: cfg.StripPrefix,
+		}
+		route.proxy = newReverseProxy(route)
+		p.routes = append(p.routes, route)
+	}
+
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		a, b := p.routes[i], p.routes[j]
+		if (a.Host != "") != (b.Host != "") {
+			return a.Host != ""
+		}
+		return len(a.Path) > len(b.Path)
+	})
+
+	return p, nil
+}
+
+// Routes returns the routes in matching order
+func (p *Proxy) Routes() []*Route {
+	return p.routes
+}
+
+// ServeHTTP forwards the request to the first matching route
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := p.Match(r)
+	if route == nil {
+		http.Error(w, fmt.Sprintf("lanup: no route for %s%s", r.Host, r.URL.Path), http.StatusBadGateway)
+		return
+	}
+	route.proxy.ServeHTTP(w, r)
+}
+
+// Match returns the route handling the request, or nil
+func (p *Proxy) Match(r *http.Request) *Route {
+	host := strings.ToLower(r.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	for _, route := range p.routes {
+		if route.Host != "" && route.Host != host {
+			continue
+		}
+		if !matchPath(route.Path, r.URL.Path) {
+			continue
+		}
+		return route
+	}
+
+	return nil
+}
+
+// matchPath reports whether path is within the prefix, on a segment boundary
+func matchPath(prefix, path string) bool {
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// newReverseProxy creates the reverse proxy forwarding to a route target
+// The outbound Host header is rewritten to the target so that dev servers checking
+// the Host header (e.g. Vite allowed hosts) accept the request; WebSocket upgrades
+// are supported by httputil.ReverseProxy
+func newReverseProxy(route *Route) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if route.StripPrefix && route.Path != "" {
+				pr.Out.URL.Path = strings.TrimPrefix(pr.In.URL.Path, route.Path)
+				if pr.Out.URL.Path == "" {
+					pr.Out.URL.Path = "/"
+				}
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(route.Target)
+			pr.SetXForwarded()
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer responds with its name and the received path and host
+func echoServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name+" "+r.URL.Path+" "+r.Host)
+	}))
+}
+
+func get(t *testing.T, handler http.Handler, host, path string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "http://"+host+path, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestProxy_PathRouting(t *testing.T) {
+	api := echoServer("api")
+	defer api.Close()
+	web := echoServer("web")
+	defer web.Close()
+
+	p, err := New([]config.RouteConfig{
+		{Path: "/", Target: web.URL},
+		{Path: "/api", Target: api.URL, StripPrefix: true},
+	})
+	require.NoError(t, err)
+
+	target, _ := url.Parse(api.URL)
+
+	code, body := get(t, p, "192.168.1.100:8888", "/api/users")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "api /users "+target.Host, body)
+
+	_, body = get(t, p, "192.168.1.100:8888", "/api")
+	assert.Equal(t, "api / "+target.Host, body)
+
+	// Prefixes only match on segment boundaries
+	_, body = get(t, p, "192.168.1.100:8888", "/apiary")
+	assert.Contains(t, body, "web /apiary")
+
+	_, body = get(t, p, "192.168.1.100:8888", "/")
+	assert.Contains(t, body, "web /")
+}
+
+func TestProxy_HostRouting(t *testing.T) {
+	admin := echoServer("admin")
+	defer admin.Close()
+	web := echoServer("web")
+	defer web.Close()
+
+	p, err := New([]config.RouteConfig{
+		{Path: "/", Target: web.URL},
+		{Host: "admin.lan", Target: admin.URL},
+	})
+	require.NoError(t, err)
+
+	_, body := get(t, p, "admin.lan:8888", "/settings")
+	assert.Contains(t, body, "admin /settings")
+
+	_, body = get(t, p, "192.168.1.100:8888", "/settings")
+	assert.Contains(t, body, "web /settings")
+}
+
+func TestProxy_NoMatch(t *testing.T) {
+	p, err := New([]config.RouteConfig{{Path: "/api", Target: "http://localhost:8000"}})
+	require.NoError(t, err)
+
+	code, _ := get(t, p, "192.168.1.100:8888", "/other")
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestNew_InvalidRoutes(t *testing.T) {
+	_, err := New(nil)
+	assert.Error(t, err)
+
+	_, err = New([]config.RouteConfig{{Target: "http://localhost:8000"}})
+	assert.Error(t, err, "route without path or host")
+
+	_, err = New([]config.RouteConfig{{Path: "/api", Target: "localhost:8000"}})
+	assert.Error(t, err, "target without scheme")
+}
+
+func TestNew_RouteOrder(t *testing.T) {
+	p, err := New([]config.RouteConfig{
+		{Path: "/", Target: "http://localhost:3000"},
+		{Path: "/api/v2", Target: "http://localhost:8002"},
+		{Host: "app.lan", Target: "http://localhost:4000"},
+		{Path: "/api", Target: "http://localhost:8000"},
+	})
+	require.NoError(t, err)
+
+	var order []string
+	for _, r := range p.Routes() {
+		order = append(order, r.Target.Host)
+	}
+	assert.Equal(t, []string{"localhost:4000", "localhost:8002", "localhost:8000", "localhost:3000"}, order)
+}
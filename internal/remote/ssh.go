@@ -0,0 +1,191 @@
+// Package remote reads and writes files on other machines over SSH, so that the env file of a
+// backend running elsewhere (e.g. on a Raspberry Pi) follows the IP of this machine
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Scheme starts the outputs written over SSH, e.g. ssh://pi@raspberrypi.local/srv/api/.env
+const Scheme = "ssh://"
+
+// missingExitCode is the exit code of the remote commands when the file doesn't exist
+const missingExitCode = 44
+
+// IsRemote reports whether path is an SSH target rather than a local path
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, Scheme)
+}
+
+// SSHTarget is a file on another machine, reached with the ssh command so that
+// the SSH agent, ~/.ssh/config and known_hosts apply
+type SSHTarget struct {
+	User string
+	Host string
+	Port int    // 0 for the default or configured port
+	Path string // absolute, or relative to the home directory when it starts with ~/
+}
+
+// ParseSSH parses ssh://[user@]host[:port]/path, where /~/path is relative to the home directory
+func ParseSSH(raw string) (*SSHTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "ssh" {
+		return nil, fmt.Errorf("invalid SSH target %s (ssh://user@host/path)", raw)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SSH target %s: missing host", raw)
+	}
+	if u.Path == "" || u.Path == "/" || strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("invalid SSH target %s: missing file path", raw)
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return nil, fmt.Errorf("invalid SSH target %s: passwords are not supported, use the SSH agent", raw)
+	}
+
+	target := &SSHTarget{User: u.User.Username(), Host: u.Hostname(), Path: u.Path}
+	// ssh would take them for options, e.g. -oProxyCommand=...
+	if strings.HasPrefix(target.User, "-") || strings.HasPrefix(target.Host, "-") {
+		return nil, fmt.Errorf("invalid SSH target %s: user and host may not start with -", raw)
+	}
+	if port := u.Port(); port != "" {
+		target.Port, err = strconv.Atoi(port)
+		if err != nil || target.Port < 1 || target.Port > 65535 {
+			return nil, fmt.Errorf("invalid SSH target %s: invalid port", raw)
+		}
+	}
+	if strings.HasPrefix(target.Path, "/~/") {
+		target.Path = target.Path[1:]
+	}
+	return target, nil
+}
+
+// String returns the target in scp notation, e.g. pi@raspberrypi.local:/srv/api/.env
+func (t *SSHTarget) String() string {
+	return t.destination() + ":" + t.Path
+}
+
+// destination returns the [user@]host argument of ssh
+func (t *SSHTarget) destination() string {
+	if t.User == "" {
+		return t.Host
+	}
+	return t.User + "@" + t.Host
+}
+
+// ReadFile returns the content of the file, an error wrapping fs.ErrNotExist when it doesn't exist
+func (t *SSHTarget) ReadFile() ([]byte, error) {
+	p := shellPath(t.Path)
+	out, err := t.run(nil, fmt.Sprintf("test -e %s || exit %d; cat %s", p, missingExitCode, p))
+	if exitCode(err) == missingExitCode {
+		return nil, &fs.PathError{Op: "open", Path: t.String(), Err: fs.ErrNotExist}
+	}
+	return out, err
+}
+
+// WriteFile replaces the file atomically: data goes to a temporary file renamed over it,
+// so that readers never see a partial file
+func (t *SSHTarget) WriteFile(data []byte) error {
+	return t.write(t.Path, data)
+}
+
+// Exists reports whether the file exists
+func (t *SSHTarget) Exists() (bool, error) {
+	_, err := t.run(nil, "test -e "+shellPath(t.Path))
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Backup copies the file to its path with the .bak extension, when it exists
+func (t *SSHTarget) Backup() error {
+	p := shellPath(t.Path)
+	_, err := t.run(nil, fmt.Sprintf("test ! -e %s || cp -p %s %s", p, p, shellPath(t.Path+".bak")))
+	return err
+}
+
+// write writes data to file through a temporary file in the same directory
+func (t *SSHTarget) write(file string, data []byte) error {
+	p := shellPath(file)
+	tmp := shellPath(file + ".lanup-tmp")
+	script := fmt.Sprintf("mkdir -p %s && cat > %s && mv -f %s %s || { rm -f %s; exit 1; }",
+		shellPath(path.Dir(file)), tmp, tmp, p, tmp)
+	_, err := t.run(data, script)
+	return err
+}
+
+// run runs a POSIX shell script on the machine, with stdin as its input
+func (t *SSHTarget) run(stdin []byte, script string) ([]byte, error) {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+	if t.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(t.Port))
+	}
+	args = append(args, "--", t.destination(), script)
+
+	cmd := exec.Command("ssh", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, &Error{Target: t.String(), Msg: msg, err: err}
+	}
+	return out, nil
+}
+
+// Error is a failed SSH command
+type Error struct {
+	Target string
+	Msg    string
+	err    error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("ssh %s: %s", e.Target, e.Msg)
+}
+
+// Unwrap returns the error of the ssh command
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code of a failed command, -1 when it didn't run
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// shellPath quotes a path for a POSIX shell, keeping a leading ~/ relative to the home directory
+func shellPath(p string) string {
+	if p == "~" {
+		return `"$HOME"`
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(p)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
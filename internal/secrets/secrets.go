@@ -0,0 +1,130 @@
+// Package secrets resolves the references to secrets used as values in .lanup.yaml, such as
+// op://vault/item/field (1Password CLI) or env://NAME, so that the committed configuration
+// holds no secret while the generated env file gets the real values
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reference schemes
+const (
+	SchemeEnv         = "env://"
+	SchemeOnePassword = "op://"
+)
+
+// DefaultTimeout bounds the commands reading secrets, which may wait for an unlock prompt
+const DefaultTimeout = 30 * time.Second
+
+// IsRef reports whether value is a reference to a secret rather than a value
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, SchemeEnv) || strings.HasPrefix(value, SchemeOnePassword)
+}
+
+// Resolver reads secrets, caching them for its lifetime so that watch mode doesn't read them
+// on each regeneration
+type Resolver struct {
+	Timeout time.Duration
+	// run runs a command and returns its output, replaced in tests
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver creates a resolver using the 1Password CLI and the environment
+func NewResolver() *Resolver {
+	return &Resolver{Timeout: DefaultTimeout, run: runCommand, cache: make(map[string]string)}
+}
+
+// Resolve returns the secret ref points to, or ref itself when it is not a reference
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, SchemeEnv); ok {
+		if name == "" {
+			return "", fmt.Errorf("invalid reference %s: missing variable name", ref)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%s: environment variable %s is not set", ref, name)
+		}
+		return value, nil
+	}
+	if !strings.HasPrefix(ref, SchemeOnePassword) {
+		return ref, nil
+	}
+
+	r.mu.Lock()
+	value, ok := r.cache[ref]
+	r.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	out, err := r.run(ctx, "op", "read", "--no-newline", ref)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s: the 1Password CLI (op) is not installed", ref)
+		}
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	value = string(out)
+
+	r.mu.Lock()
+	r.cache[ref] = value
+	r.mu.Unlock()
+	return value, nil
+}
+
+// ResolveVars replaces the references among the values of vars with their secrets
+// The values that failed to resolve are removed, and their errors joined.
+func (r *Resolver) ResolveVars(ctx context.Context, vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for key, value := range vars {
+		if IsRef(value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		value := vars[key]
+		secret, err := r.Resolve(ctx, value)
+		if err != nil {
+			delete(vars, key)
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		vars[key] = secret
+	}
+	return errors.Join(errs...)
+}
+
+// runCommand runs name and returns its output, with its error message on failure
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// The CLI may prompt for an unlock on the terminal
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
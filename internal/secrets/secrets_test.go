@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRef(t *testing.T) {
+	assert.True(t, IsRef("op://dev/stripe/secret key"))
+	assert.True(t, IsRef("env://STRIPE_KEY"))
+	assert.False(t, IsRef("http://localhost:8000"))
+	assert.False(t, IsRef("sk_test_123"))
+	assert.False(t, IsRef(""))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Setenv("LANUP_TEST_SECRET", "s3cret")
+
+	calls := 0
+	r := NewResolver()
+	r.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		assert.Equal(t, "op", name)
+		assert.Equal(t, []string{"read", "--no-newline", "op://dev/stripe/key"}, args)
+		return []byte("sk_test_123"), nil
+	}
+
+	value, err := r.Resolve(context.Background(), "env://LANUP_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	_, err = r.Resolve(context.Background(), "env://LANUP_TEST_MISSING")
+	assert.ErrorContains(t, err, "LANUP_TEST_MISSING is not set")
+
+	// 1Password is asked once
+	for i := 0; i < 2; i++ {
+		value, err = r.Resolve(context.Background(), "op://dev/stripe/key")
+		require.NoError(t, err)
+		assert.Equal(t, "sk_test_123", value)
+	}
+	assert.Equal(t, 1, calls)
+
+	value, err = r.Resolve(context.Background(), "http://localhost:8000")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8000", value)
+}
+
+func TestResolver_ResolveVars(t *testing.T) {
+	t.Setenv("LANUP_TEST_SECRET", "s3cret")
+
+	r := NewResolver()
+	r.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: "op", Err: exec.ErrNotFound}
+	}
+
+	vars := map[string]string{
+		"API_URL":    "http://localhost:8000",
+		"API_KEY":    "env://LANUP_TEST_SECRET",
+		"STRIPE_KEY": "op://dev/stripe/key",
+	}
+	err := r.ResolveVars(context.Background(), vars)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "STRIPE_KEY: op://dev/stripe/key: the 1Password CLI (op) is not installed")
+
+	// Unresolved secrets are dropped rather than written as references
+	assert.Equal(t, map[string]string{"API_URL": "http://localhost:8000", "API_KEY": "s3cret"}, vars)
+}
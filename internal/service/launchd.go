@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// launchdManager installs launchd agents (~/Library/LaunchAgents)
+// launchd has no watchdog protocol: with --watchdog the daemon exits when it hangs, and KeepAlive
+// starts it again since the exit is not successful
+type launchdManager struct {
+	run runner
+}
+
+var launchdTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml":   xmlEscape,
+	"label": launchdLabel,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{label . | xml}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .Executable}}</string>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+{{- if .Watchdog}}
+		<string>--watchdog={{.Watchdog}}</string>
+{{- end}}
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{xml .WorkingDir}}</string>
+{{- if .Path}}
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>{{xml .Path}}</string>
+	</dict>
+{{- end}}
+{{- if .LogPath}}
+	<key>StandardOutPath</key>
+	<string>{{xml .LogPath}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .LogPath}}</string>
+{{- end}}
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+</dict>
+</plist>
+`))
+
+// LaunchdPlist renders the launchd agent definition for a spec
+func LaunchdPlist(spec Spec) (string, error) {
+	var b strings.Builder
+	if err := launchdTemplate.Execute(&b, spec); err != nil {
+		return "", fmt.Errorf("failed to render launchd plist: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Kind returns the name of the service system
+func (m *launchdManager) Kind() string {
+	return "launchd"
+}
+
+// FilePath returns the path of the agent plist
+func (m *launchdManager) FilePath(spec Spec) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(spec)+".plist"), nil
+}
+
+// Install writes the plist and loads the agent in the user session
+func (m *launchdManager) Install(spec Spec) error {
+	plist, err := LaunchdPlist(spec)
+	if err != nil {
+		return err
+	}
+	path, err := m.FilePath(spec)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, plist); err != nil {
+		return err
+	}
+
+	return m.run("launchctl", "bootstrap", launchdDomain(), path)
+}
+
+// Uninstall unloads the agent and removes its plist
+func (m *launchdManager) Uninstall(spec Spec) error {
+	path, err := m.FilePath(spec)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("service %s is not installed", spec.Name)
+	}
+
+	if err := m.run("launchctl", "bootout", launchdDomain()+"/"+launchdLabel(spec)); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// launchdLabel returns the reverse-DNS label of the agent
+func launchdLabel(spec Spec) string {
+	return "com.github.raucheacho." + spec.Name
+}
+
+// launchdDomain returns the launchd domain of the current user session
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// xmlEscape escapes a value for use in the plist
+func xmlEscape(value string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
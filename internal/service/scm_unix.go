@@ -0,0 +1,25 @@
+//go:build !windows
+
+package service
+
+import "fmt"
+
+// newWindowsManager fails: Windows services only exist on Windows
+func newWindowsManager() (Manager, error) {
+	return nil, fmt.Errorf("Windows services are only supported on Windows")
+}
+
+// WindowsAccount fails: Windows services only exist on Windows
+func WindowsAccount() (string, error) {
+	return "", fmt.Errorf("Windows services are only supported on Windows")
+}
+
+// ReadPassword fails: the password is only needed by Windows services
+func ReadPassword(prompt string) (string, error) {
+	return "", fmt.Errorf("Windows services are only supported on Windows")
+}
+
+// RunWindowsService reports false: processes are never started as Windows services
+func RunWindowsService(name string, run func(stop <-chan struct{}) error) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,294 @@
+//go:build windows
+
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsStopTimeout is how long Uninstall waits for the service to stop
+const windowsStopTimeout = 10 * time.Second
+
+// newWindowsManager returns the manager of Windows services
+func newWindowsManager() (Manager, error) {
+	return &windowsManager{}, nil
+}
+
+// connect opens the service control manager, which requires an elevated prompt
+func connect() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, fmt.Errorf("access denied by the service manager, run lanup from an elevated prompt: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	return m, nil
+}
+
+// WindowsAccount returns the account of the current user, e.g. MACHINE\dev, which the service runs as
+// The hooks, plugins and detectors of the project come from files the user can write: running
+// them as LocalSystem would give SYSTEM rights to any process of the user.
+func WindowsAccount() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the current user: %w", err)
+	}
+	return u.Username, nil
+}
+
+// ReadPassword prints prompt and reads a line from the console without echoing it
+func ReadPassword(prompt string) (string, error) {
+	console := windows.Handle(os.Stdin.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(console, &mode); err != nil {
+		return "", fmt.Errorf("no console to read the password from: %w", err)
+	}
+	if err := windows.SetConsoleMode(console, mode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return "", fmt.Errorf("failed to hide the password: %w", err)
+	}
+	defer windows.SetConsoleMode(console, mode)
+
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Install creates the service in the account of the current user, restarted on failure, then starts it
+func (m *windowsManager) Install(spec Spec) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	account, err := WindowsAccount()
+	if err != nil {
+		return err
+	}
+	if spec.Password == "" {
+		return fmt.Errorf("the password of %s is required to run the service in this account", account)
+	}
+
+	scm, err := connect()
+	if err != nil {
+		return err
+	}
+	defer scm.Disconnect()
+
+	if s, err := scm.OpenService(spec.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s is already installed", spec.Name)
+	}
+	if err := grantServiceLogon(account); err != nil {
+		return err
+	}
+
+	s, err := scm.CreateService(spec.Name, spec.Executable, mgr.Config{
+		DisplayName:      windowsDisplayName(spec),
+		Description:      "lanup watcher for " + spec.WorkingDir,
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+		ServiceStartName: account,
+		Password:         spec.Password,
+	}, windowsArgs(spec)...)
+	if err != nil {
+		return fmt.Errorf("failed to create service %s: %w", spec.Name, err)
+	}
+	defer s.Close()
+
+	if err := configureWindowsService(s, spec, home); err != nil {
+		_ = s.Delete()
+		return err
+	}
+
+	if err := s.Start(); err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_LOGON_FAILED) {
+			// The password is only checked when the service starts
+			_ = s.Delete()
+			return fmt.Errorf("failed to log on as %s, check the password: %w", account, err)
+		}
+		return fmt.Errorf("failed to start service %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// Access rights of LsaOpenPolicy
+const (
+	policyCreateAccount = 0x00000010
+	policyLookupNames   = 0x00000800
+)
+
+var (
+	advapi32                = windows.NewLazySystemDLL("advapi32.dll")
+	procLsaOpenPolicy       = advapi32.NewProc("LsaOpenPolicy")
+	procLsaAddAccountRights = advapi32.NewProc("LsaAddAccountRights")
+	procLsaClose            = advapi32.NewProc("LsaClose")
+)
+
+// grantServiceLogon gives account the "Log on as a service" right, which services need to run in
+// the account of a user and which the Services console grants, but not the service manager
+func grantServiceLogon(account string) error {
+	sid, _, _, err := windows.LookupSID("", account)
+	if err != nil {
+		return fmt.Errorf("failed to look up account %s: %w", account, err)
+	}
+	right, err := windows.NewNTUnicodeString("SeServiceLogonRight")
+	if err != nil {
+		return err
+	}
+
+	// LSA_OBJECT_ATTRIBUTES has the layout of OBJECT_ATTRIBUTES, zeroed as LsaOpenPolicy requires
+	var attrs windows.OBJECT_ATTRIBUTES
+	attrs.Length = uint32(unsafe.Sizeof(attrs))
+	var policy windows.Handle
+	status, _, _ := procLsaOpenPolicy.Call(0, uintptr(unsafe.Pointer(&attrs)),
+		policyCreateAccount|policyLookupNames, uintptr(unsafe.Pointer(&policy)))
+	if status != 0 {
+		return fmt.Errorf("failed to open the security policy: %w", windows.NTStatus(status).Errno())
+	}
+	defer procLsaClose.Call(uintptr(policy))
+
+	status, _, _ = procLsaAddAccountRights.Call(uintptr(policy), uintptr(unsafe.Pointer(sid)),
+		uintptr(unsafe.Pointer(right)), 1)
+	if status != 0 {
+		return fmt.Errorf("failed to allow %s to log on as a service: %w", account, windows.NTStatus(status).Errno())
+	}
+	return nil
+}
+
+// configureWindowsService sets the restarts on failure and the environment of the service
+func configureWindowsService(s *mgr.Service, spec Spec, home string) error {
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 5 * time.Second}
+	// The failure count is reset after a day without failure
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, 86400); err != nil {
+		return fmt.Errorf("failed to set the recovery actions: %w", err)
+	}
+	// Also restart when the daemon stops with an error rather than crashing
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return fmt.Errorf("failed to set the recovery actions: %w", err)
+	}
+
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, windowsServicesKey+spec.Name, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the registry key of the service: %w", err)
+	}
+	defer key.Close()
+	if err := key.SetStringsValue("Environment", windowsEnvironment(spec, home)); err != nil {
+		return fmt.Errorf("failed to set the environment of the service: %w", err)
+	}
+	return nil
+}
+
+// Uninstall stops the service, then deletes it
+func (m *windowsManager) Uninstall(spec Spec) error {
+	scm, err := connect()
+	if err != nil {
+		return err
+	}
+	defer scm.Disconnect()
+
+	s, err := scm.OpenService(spec.Name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", spec.Name)
+	}
+	defer s.Close()
+
+	if _, err := s.Control(svc.Stop); err != nil && !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		return fmt.Errorf("failed to stop service %s: %w", spec.Name, err)
+	}
+	deadline := time.Now().Add(windowsStopTimeout)
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return fmt.Errorf("failed to query service %s: %w", spec.Name, err)
+		}
+		if status.State == svc.Stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not stop within %s", spec.Name, windowsStopTimeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// RunWindowsService runs run as the Windows service the process was started as, stop being
+// closed when the service manager stops the service. It reports false, without running run, when
+// the process was not started by the service manager.
+func RunWindowsService(name string, run func(stop <-chan struct{}) error) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return false, fmt.Errorf("failed to determine whether lanup runs as a service: %w", err)
+	}
+	if !isService {
+		return false, nil
+	}
+
+	h := &windowsHandler{run: run}
+	if err := svc.Run(name, h); err != nil {
+		return true, fmt.Errorf("failed to run service %s: %w", name, err)
+	}
+	return true, h.err
+}
+
+// windowsHandler answers the requests of the service manager while run runs
+type windowsHandler struct {
+	run func(stop <-chan struct{}) error
+	err error // returned by run
+}
+
+// Execute runs the service until it returns or the service manager stops it
+func (h *windowsHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	stopping := false
+	done := make(chan error, 1)
+	go func() {
+		done <- h.run(stop)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			h.err = err
+			if err != nil {
+				// A service-specific exit code makes the service manager apply the recovery actions
+				return true, 1
+			}
+			return false, 0
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				if !stopping {
+					status <- svc.Status{State: svc.StopPending}
+					close(stop)
+					stopping = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Spec describes the lanup process to run as a service
+type Spec struct {
+	Name       string // unique per project, used for unit and label names
+	Executable string
+	Args       []string
+	WorkingDir string
+	LogPath    string        // file receiving the service output
+	Path       string        // PATH environment of the service, so that detectors find docker, supabase...
+	Watchdog   time.Duration // restart the service when watch mode hangs for this long, 0 to disable
+	Password   string        // password of the user, required by Windows services to run in their account
+}
+
+// WatchdogTimeout is how long a service may hang before it is restarted
+const WatchdogTimeout = 2 * time.Minute
+
+// Manager installs and removes services for the current user
+type Manager interface {
+	// Kind returns the name of the service system (e.g. systemd)
+	Kind() string
+	// FilePath returns the path of the service definition file
+	FilePath(spec Spec) (string, error)
+	// Install writes the service definition, then enables and starts the service
+	Install(spec Spec) error
+	// Uninstall stops and disables the service, then removes its definition
+	Uninstall(spec Spec) error
+}
+
+// runner executes a service management command
+type runner func(name string, args ...string) error
+
+// runCommand runs a command and includes its output in errors
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewSpec creates the service specification for a project directory
+// The name combines the directory name with a hash of its absolute path, so that
+// projects with the same directory name get distinct services.
+func NewSpec(projectDir, executable string, args []string, logPath string) (Spec, error) {
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return Spec{}, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	sum := sha1.Sum([]byte(abs))
+	slug := strings.Trim(slugRe.ReplaceAllString(strings.ToLower(filepath.Base(abs)), "-"), "-")
+	if slug == "" {
+		slug = "project"
+	}
+
+	return Spec{
+		Name:       fmt.Sprintf("lanup-%s-%s", slug, hex.EncodeToString(sum[:])[:6]),
+		Executable: executable,
+		Args:       args,
+		WorkingDir: abs,
+		LogPath:    logPath,
+		Path:       os.Getenv("PATH"),
+		Watchdog:   WatchdogTimeout,
+	}, nil
+}
+
+// NewManager returns the service manager of the current platform
+func NewManager() (Manager, error) {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			return nil, fmt.Errorf("systemctl not found: only systemd is supported on Linux")
+		}
+		return &systemdManager{run: runCommand}, nil
+	case "darwin":
+		return &launchdManager{run: runCommand}, nil
+	case "windows":
+		return newWindowsManager()
+	default:
+		return nil, fmt.Errorf("system services are not supported on %s", runtime.GOOS)
+	}
+}
+
+// writeFile writes a service definition, creating parent directories
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Name:       "lanup-my-app-abc123",
+		Executable: "/usr/local/bin/lanup",
+		Args:       []string{"daemon", "run", "--profile", "mobile"},
+		WorkingDir: "/home/dev/My Projects/my-app",
+		LogPath:    "/home/dev/.lanup/logs/daemon.log",
+		Path:       "/usr/local/bin:/usr/bin",
+		Watchdog:   2 * time.Minute,
+	}
+}
+
+// recorder records the commands run by a manager
+type recorder struct {
+	commands []string
+}
+
+func (r *recorder) run(name string, args ...string) error {
+	r.commands = append(r.commands, name+" "+strings.Join(args, " "))
+	return nil
+}
+
+func TestNewSpec(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "My App")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	spec, err := NewSpec(dir, "/usr/local/bin/lanup", []string{"daemon", "run"}, "/tmp/daemon.log")
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^lanup-my-app-[0-9a-f]{6}$`, spec.Name)
+	assert.Equal(t, dir, spec.WorkingDir)
+	assert.Equal(t, WatchdogTimeout, spec.Watchdog)
+
+	// The same directory name in another location gets a distinct service
+	other := filepath.Join(t.TempDir(), "My App")
+	require.NoError(t, os.Mkdir(other, 0755))
+	otherSpec, err := NewSpec(other, "/usr/local/bin/lanup", nil, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, spec.Name, otherSpec.Name)
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit, err := SystemdUnit(testSpec())
+	require.NoError(t, err)
+
+	assert.Contains(t, unit, `WorkingDirectory="/home/dev/My Projects/my-app"`)
+	assert.Contains(t, unit, "ExecStart=/usr/local/bin/lanup daemon run --profile mobile\n")
+	assert.Contains(t, unit, "Environment=PATH=/usr/local/bin:/usr/bin\n")
+	assert.Contains(t, unit, "StandardOutput=append:/home/dev/.lanup/logs/daemon.log")
+	assert.Contains(t, unit, "WantedBy=default.target")
+	assert.Contains(t, unit, "Type=notify\n")
+	assert.Contains(t, unit, "WatchdogSec=120\n")
+
+	spec := testSpec()
+	spec.Watchdog = 0
+	unit, err = SystemdUnit(spec)
+	require.NoError(t, err)
+	assert.NotContains(t, unit, "WatchdogSec")
+}
+
+func TestSystemdQuote(t *testing.T) {
+	assert.Equal(t, "/usr/bin/lanup", systemdQuote("/usr/bin/lanup"))
+	assert.Equal(t, `"/my dir"`, systemdQuote("/my dir"))
+	assert.Equal(t, `"say \"hi\""`, systemdQuote(`say "hi"`))
+	assert.Equal(t, "100%%", systemdQuote("100%"))
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	spec := testSpec()
+	spec.WorkingDir = "/Users/dev/R&D"
+
+	plist, err := LaunchdPlist(spec)
+	require.NoError(t, err)
+
+	assert.Contains(t, plist, "<string>com.github.raucheacho.lanup-my-app-abc123</string>")
+	assert.Contains(t, plist, "<string>/usr/local/bin/lanup</string>\n\t\t<string>daemon</string>")
+	assert.Contains(t, plist, "<string>/Users/dev/R&amp;D</string>")
+	assert.Contains(t, plist, "<key>StandardOutPath</key>")
+	assert.Contains(t, plist, "<key>RunAtLoad</key>")
+	// launchd has no watchdog, the daemon exits for KeepAlive to restart it
+	assert.Contains(t, plist, "<string>mobile</string>\n\t\t<string>--watchdog=2m0s</string>\n\t</array>")
+	assert.Contains(t, plist, "<key>SuccessfulExit</key>\n\t\t<false/>")
+}
+
+func TestSystemdManager_InstallUninstall(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	rec := &recorder{}
+	m := &systemdManager{run: rec.run}
+	spec := testSpec()
+
+	require.NoError(t, m.Install(spec))
+	path, err := m.FilePath(spec)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+	assert.Equal(t, []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now lanup-my-app-abc123.service",
+	}, rec.commands)
+
+	rec.commands = nil
+	require.NoError(t, m.Uninstall(spec))
+	assert.NoFileExists(t, path)
+	assert.Equal(t, []string{
+		"systemctl --user disable --now lanup-my-app-abc123.service",
+		"systemctl --user daemon-reload",
+	}, rec.commands)
+
+	assert.Error(t, m.Uninstall(spec), "uninstalling twice should fail")
+}
+
+func TestLaunchdManager_InstallUninstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rec := &recorder{}
+	m := &launchdManager{run: rec.run}
+	spec := testSpec()
+
+	require.NoError(t, m.Install(spec))
+	path, err := m.FilePath(spec)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+	require.Len(t, rec.commands, 1)
+	assert.True(t, strings.HasPrefix(rec.commands[0], "launchctl bootstrap gui/"))
+
+	require.NoError(t, m.Uninstall(spec))
+	assert.NoFileExists(t, path)
+	assert.Contains(t, rec.commands[1], "launchctl bootout gui/")
+}
+
+func TestWindowsService(t *testing.T) {
+	spec := testSpec()
+	m := &windowsManager{}
+
+	path, err := m.FilePath(spec)
+	require.NoError(t, err)
+	assert.Equal(t, `HKLM\SYSTEM\CurrentControlSet\Services\lanup-my-app-abc123`, path)
+	assert.Equal(t, "lanup (my-app)", windowsDisplayName(spec))
+
+	// Services start in the system directory without a watchdog protocol
+	assert.Equal(t, []string{"daemon", "run", "--profile", "mobile",
+		"--dir=/home/dev/My Projects/my-app", "--watchdog=2m0s"}, windowsArgs(spec))
+	assert.Equal(t, []string{"daemon", "run", "--profile", "mobile"}, spec.Args, "the spec should not change")
+
+	// The daemon shares the configuration and state of the user who installed it
+	assert.Equal(t, []string{`USERPROFILE=C:\Users\dev`, "PATH=/usr/local/bin:/usr/bin"},
+		windowsEnvironment(spec, `C:\Users\dev`))
+	spec.Path = ""
+	assert.Equal(t, []string{`USERPROFILE=C:\Users\dev`}, windowsEnvironment(spec, `C:\Users\dev`))
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// systemdManager installs systemd user units (~/.config/systemd/user)
+// The units are Type=notify: the daemon sends READY=1 once it watches and WATCHDOG=1 while the
+// watcher checks the network, systemd restarts it when the pings stop for WatchdogSec
+type systemdManager struct {
+	run runner
+}
+
+var systemdTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{
+	"quote":   systemdQuote,
+	"seconds": func(d time.Duration) int { return int(d.Seconds()) },
+}).Parse(`[Unit]
+Description=lanup watcher for {{.WorkingDir}}
+
+[Service]
+Type=notify
+NotifyAccess=main
+WorkingDirectory={{quote .WorkingDir}}
+ExecStart={{quote .Executable}}{{range .Args}} {{quote .}}{{end}}
+{{- if .Path}}
+Environment={{quote (printf "PATH=%s" .Path)}}
+{{- end}}
+{{- if .LogPath}}
+StandardOutput=append:{{.LogPath}}
+StandardError=append:{{.LogPath}}
+{{- end}}
+{{- if .Watchdog}}
+WatchdogSec={{seconds .Watchdog}}
+{{- end}}
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`))
+
+// SystemdUnit renders the systemd user unit for a spec
+func SystemdUnit(spec Spec) (string, error) {
+	var b strings.Builder
+	if err := systemdTemplate.Execute(&b, spec); err != nil {
+		return "", fmt.Errorf("failed to render systemd unit: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Kind returns the name of the service system
+func (m *systemdManager) Kind() string {
+	return "systemd"
+}
+
+// FilePath returns the path of the unit file
+func (m *systemdManager) FilePath(spec Spec) (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "systemd", "user", spec.Name+".service"), nil
+}
+
+// Install writes the unit, then enables and starts it
+func (m *systemdManager) Install(spec Spec) error {
+	unit, err := SystemdUnit(spec)
+	if err != nil {
+		return err
+	}
+	path, err := m.FilePath(spec)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, unit); err != nil {
+		return err
+	}
+
+	if err := m.run("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return m.run("systemctl", "--user", "enable", "--now", spec.Name+".service")
+}
+
+// Uninstall stops and disables the unit, then removes it
+func (m *systemdManager) Uninstall(spec Spec) error {
+	path, err := m.FilePath(spec)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("service %s is not installed", spec.Name)
+	}
+
+	if err := m.run("systemctl", "--user", "disable", "--now", spec.Name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return m.run("systemctl", "--user", "daemon-reload")
+}
+
+// systemdQuote quotes a value for unit files when it contains special characters
+func systemdQuote(value string) string {
+	value = strings.ReplaceAll(value, "%", "%%")
+	if !strings.ContainsAny(value, " \t\"'\\;$") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// windowsManager installs Windows services, run by the service control manager in the account of
+// the user who installed them (see WindowsAccount)
+// Windows services have no working directory setting: the daemon gets the project directory with
+// --dir, and an environment pointing to the profile of the user, so that it shares their ~/.lanup
+type windowsManager struct{}
+
+// windowsServicesKey is the registry key holding the definitions of the services
+const windowsServicesKey = `SYSTEM\CurrentControlSet\Services\`
+
+// Kind returns the name of the service system
+func (m *windowsManager) Kind() string {
+	return "windows"
+}
+
+// FilePath returns the registry key of the service
+func (m *windowsManager) FilePath(spec Spec) (string, error) {
+	return `HKLM\` + windowsServicesKey + spec.Name, nil
+}
+
+// windowsDisplayName returns the name shown in the Services console
+func windowsDisplayName(spec Spec) string {
+	return "lanup (" + filepath.Base(spec.WorkingDir) + ")"
+}
+
+// windowsArgs returns the arguments of the service, with the project directory and the watchdog
+// timeout since the service manager provides neither
+func windowsArgs(spec Spec) []string {
+	args := append(append([]string(nil), spec.Args...), "--dir="+spec.WorkingDir)
+	if spec.Watchdog > 0 {
+		args = append(args, fmt.Sprintf("--watchdog=%s", spec.Watchdog))
+	}
+	return args
+}
+
+// windowsEnvironment returns the environment of the service, with the profile directory of the
+// user so that the daemon finds the same configuration and state
+func windowsEnvironment(spec Spec, home string) []string {
+	env := []string{"USERPROFILE=" + home}
+	if spec.Path != "" {
+		env = append(env, "PATH="+spec.Path)
+	}
+	return env
+}
@@ -0,0 +1,95 @@
+package share
+
+import (
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"github.com/raucheacho/lanup/pkg/utils"
+)
+
+// Service is a named URL listed on the share page
+type Service struct {
+	Name string
+	URL  string
+}
+
+// ServiceProvider returns the services to list; it is called on every request so the page
+// always reflects the current IP address
+type ServiceProvider func() ([]Service, error)
+
+// pageService is the template view of a Service
+type pageService struct {
+	Service
+	QRCode template.URL // PNG data URI
+}
+
+var pageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 0; padding: 1.5rem; background: #f6f7f9; color: #1f2328; }
+  h1 { font-size: 1.4rem; margin: 0 0 1.5rem; }
+  .services { display: grid; grid-template-columns: repeat(auto-fill, minmax(220px, 1fr)); gap: 1rem; }
+  .service { background: #fff; border-radius: 8px; padding: 1rem; box-shadow: 0 1px 3px rgba(0,0,0,.1); text-align: center; }
+  .service h2 { font-size: 1rem; margin: 0 0 .5rem; word-break: break-all; }
+  .service a { display: block; margin-bottom: .75rem; word-break: break-all; color: #0969da; }
+  .service img { width: 180px; height: 180px; }
+  .empty, .error { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>
+{{else if not .Services}}<p class="empty">No services are exposed right now.</p>
+{{else}}<div class="services">
+{{range .Services}}  <div class="service">
+    <h2>{{.Name}}</h2>
+    <a href="{{.URL}}">{{.URL}}</a>
+    {{if .QRCode}}<img src="{{.QRCode}}" alt="QR code for {{.URL}}">{{end}}
+  </div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+// Handler returns an HTTP handler serving the share page at /
+func Handler(title string, provider ServiceProvider) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		data := struct {
+			Title    string
+			Services []pageService
+			Error    string
+		}{Title: title}
+
+		services, err := provider()
+		if err != nil {
+			data.Error = err.Error()
+		}
+
+		for _, s := range services {
+			view := pageService{Service: s}
+			if png, err := utils.QRCodePNG(s.URL, 256); err == nil {
+				view.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+			}
+			data.Services = append(data.Services, view)
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := pageTemplate.Execute(w, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	return mux
+}
@@ -0,0 +1,59 @@
+package share
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_ListsServices(t *testing.T) {
+	handler := Handler("My project", func() ([]Service, error) {
+		return []Service{
+			{Name: "API_URL", URL: "http://192.168.1.100:8000"},
+			{Name: "DASHBOARD_URL", URL: "http://192.168.1.100:3000"},
+		}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "My project")
+	assert.Contains(t, body, `href="http://192.168.1.100:8000"`)
+	assert.Contains(t, body, "DASHBOARD_URL")
+	assert.Contains(t, body, "data:image/png;base64,")
+}
+
+func TestHandler_Empty(t *testing.T) {
+	handler := Handler("lanup", func() ([]Service, error) { return nil, nil })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Contains(t, rec.Body.String(), "No services are exposed")
+}
+
+func TestHandler_ProviderError(t *testing.T) {
+	handler := Handler("lanup", func() ([]Service, error) { return nil, errors.New("no network") })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no network")
+}
+
+func TestHandler_UnknownPath(t *testing.T) {
+	handler := Handler("lanup", func() ([]Service, error) { return nil, nil })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,262 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/raucheacho/lanup/internal/expo"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/remote"
+)
+
+// Version is the format version written in the state file
+const Version = 1
+
+// MaxRuns is the number of runs kept per project
+const MaxRuns = 50
+
+// State records the projects lanup has touched, in ~/.lanup/state.json
+type State struct {
+	Version  int                 `json:"version"`
+	Projects map[string]*Project `json:"projects"` // keyed by absolute project directory
+}
+
+// Project records what lanup did in a project directory
+type Project struct {
+	Dir     string                  `json:"dir"`
+	Config  string                  `json:"config"`            // project configuration file
+	Profile string                  `json:"profile,omitempty"` // profile of the last run
+	Outputs []string                `json:"outputs,omitempty"` // env files written, absolute paths
+	LastIP  string                  `json:"last_ip,omitempty"`
+	LastRun time.Time               `json:"last_run"`
+	Watcher *Watcher                `json:"watcher,omitempty"` // watch mode or daemon running for the project
+	Daemon  bool                    `json:"daemon,omitempty"`  // registered with the shared daemon
+	Runs    []Run                   `json:"runs,omitempty"`    // last generations of the env file, oldest first
+	Expo    map[string]*expo.Record `json:"expo,omitempty"`    // values rewritten in the Expo app configs, by absolute path
+}
+
+// Run records a generation of the env file
+// Values are not recorded, they may hold secrets and the state file is readable by everyone.
+type Run struct {
+	ID       int         `json:"id"` // increasing number within the project
+	Time     time.Time   `json:"time"`
+	Trigger  string      `json:"trigger"`          // command that ran it (start, watch, daemon)
+	Reason   string      `json:"reason,omitempty"` // why watch mode or the daemon ran it, e.g. ip_changed
+	Profile  string      `json:"profile,omitempty"`
+	IP       string      `json:"ip,omitempty"`
+	Output   string      `json:"output,omitempty"`  // env file written
+	Vars     []string    `json:"vars,omitempty"`    // managed variables written
+	Changes  []VarChange `json:"changes,omitempty"` // managed variables added, modified or removed
+	Duration int64       `json:"duration_ms"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Error    string      `json:"error,omitempty"` // the env file was not written
+}
+
+// VarChange records how a managed variable changed in a run
+type VarChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"` // added, modified or removed
+}
+
+// Watcher describes a watch mode or daemon process
+type Watcher struct {
+	PID     int       `json:"pid"`
+	Mode    string    `json:"mode"` // watch or daemon
+	Started time.Time `json:"started"`
+}
+
+// DefaultPath returns the path of the state file (~/.lanup/state.json)
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "state.json"), nil
+}
+
+// Load reads the state file, a missing file is an empty state
+func Load(path string) (*State, error) {
+	s := &State{Version: Version, Projects: make(map[string]*Project)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	if s.Projects == nil {
+		s.Projects = make(map[string]*Project)
+	}
+	return s, nil
+}
+
+// Save writes the state file, replacing it at once so that readers never see a partial file
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	s.Version = Version
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// Update loads the state file, applies fn and saves it
+// A lock keeps the lanup processes of other projects from losing each other's changes
+func Update(path string, fn func(*State)) error {
+	lock, err := process.AcquireLock(path+".lock", process.DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer lock.Release()
+
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	fn(s)
+	return s.Save(path)
+}
+
+// Project returns the record of a project directory, creating it when needed
+func (s *State) Project(dir string) *Project {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	p, ok := s.Projects[dir]
+	if !ok {
+		p = &Project{Dir: dir}
+		s.Projects[dir] = p
+	}
+	return p
+}
+
+// Remove forgets a project directory
+func (s *State) Remove(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	delete(s.Projects, dir)
+}
+
+// List returns the projects, most recently run first
+func (s *State) List() []*Project {
+	projects := make([]*Project, 0, len(s.Projects))
+	for _, p := range s.Projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if !projects[i].LastRun.Equal(projects[j].LastRun) {
+			return projects[i].LastRun.After(projects[j].LastRun)
+		}
+		return projects[i].Dir < projects[j].Dir
+	})
+	return projects
+}
+
+// DaemonProjects returns the projects registered with the shared daemon, sorted by directory
+func (s *State) DaemonProjects() []*Project {
+	var projects []*Project
+	for _, p := range s.Projects {
+		if p.Daemon {
+			projects = append(projects, p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Dir < projects[j].Dir
+	})
+	return projects
+}
+
+// AddOutput records an env file written for the project
+func (p *Project) AddOutput(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for _, output := range p.Outputs {
+		if output == path {
+			return
+		}
+	}
+	p.Outputs = append(p.Outputs, path)
+	sort.Strings(p.Outputs)
+}
+
+// AddRun records a run, numbering it after the previous one, and keeps the last MaxRuns
+func (p *Project) AddRun(run Run) Run {
+	run.ID = 1
+	if len(p.Runs) > 0 {
+		run.ID = p.Runs[len(p.Runs)-1].ID + 1
+	}
+	p.Runs = append(p.Runs, run)
+	if len(p.Runs) > MaxRuns {
+		p.Runs = append([]Run(nil), p.Runs[len(p.Runs)-MaxRuns:]...)
+	}
+	return run
+}
+
+// Run returns the run with the given ID, nil when it is not recorded
+func (p *Project) Run(id int) *Run {
+	for i := range p.Runs {
+		if p.Runs[i].ID == id {
+			return &p.Runs[i]
+		}
+	}
+	return nil
+}
+
+// ActiveWatcher returns the watcher of the project while its process runs
+// A watcher that was killed without clearing its record is reported as nil
+func (p *Project) ActiveWatcher() *Watcher {
+	if p.Watcher == nil || !process.IsRunning(p.Watcher.PID) {
+		return nil
+	}
+	return p.Watcher
+}
+
+// Missing reports whether the project directory or its configuration file no longer exists
+func (p *Project) Missing() bool {
+	if _, err := os.Stat(p.Dir); err != nil {
+		return true
+	}
+	if p.Config == "" {
+		return false
+	}
+	_, err := os.Stat(p.Config)
+	return err != nil
+}
+
+// MissingOutputs returns the recorded env files that no longer exist
+// Files written over SSH are not checked, that would need a connection to each machine.
+func (p *Project) MissingOutputs() []string {
+	var missing []string
+	for _, output := range p.Outputs {
+		if remote.IsRemote(output) {
+			continue
+		}
+		if _, err := os.Stat(output); os.IsNotExist(err) {
+			missing = append(missing, output)
+		}
+	}
+	return missing
+}
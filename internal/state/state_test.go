@@ -0,0 +1,125 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	assert.Equal(t, Version, s.Version)
+	assert.Empty(t, s.Projects)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	_, err := Load(path)
+	assert.Error(t, err)
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".lanup", "state.json")
+	project := filepath.Join(dir, "app")
+	lastRun := time.Date(2025, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, Update(path, func(s *State) {
+		p := s.Project(project)
+		p.Config = filepath.Join(project, ".lanup.yaml")
+		p.AddOutput(filepath.Join(project, ".env"))
+		p.AddOutput(filepath.Join(project, ".env"))
+		p.LastIP = "192.168.1.20"
+		p.LastRun = lastRun
+	}))
+	require.NoError(t, Update(path, func(s *State) {
+		s.Project(filepath.Join(dir, "other")).LastRun = lastRun.Add(time.Hour)
+	}))
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, s.Projects, 2)
+
+	p := s.Projects[project]
+	require.NotNil(t, p)
+	assert.Equal(t, project, p.Dir)
+	assert.Equal(t, []string{filepath.Join(project, ".env")}, p.Outputs, "outputs are recorded once")
+	assert.Equal(t, "192.168.1.20", p.LastIP)
+	assert.True(t, lastRun.Equal(p.LastRun))
+
+	list := s.List()
+	assert.Equal(t, filepath.Join(dir, "other"), list[0].Dir, "most recent first")
+	assert.Equal(t, project, list[1].Dir)
+
+	require.NoError(t, Update(path, func(s *State) { s.Remove(project) }))
+	s, err = Load(path)
+	require.NoError(t, err)
+	assert.NotContains(t, s.Projects, project)
+}
+
+func TestDaemonProjects(t *testing.T) {
+	s := &State{Projects: make(map[string]*Project)}
+	s.Project("/projects/b").Daemon = true
+	s.Project("/projects/c")
+	s.Project("/projects/a").Daemon = true
+
+	projects := s.DaemonProjects()
+	require.Len(t, projects, 2)
+	assert.Equal(t, s.Project("/projects/a"), projects[0])
+	assert.Equal(t, s.Project("/projects/b"), projects[1])
+}
+
+func TestProject_ActiveWatcher(t *testing.T) {
+	p := &Project{}
+	assert.Nil(t, p.ActiveWatcher())
+
+	p.Watcher = &Watcher{PID: os.Getpid(), Mode: "watch"}
+	assert.Equal(t, p.Watcher, p.ActiveWatcher())
+
+	// PIDs are capped well below this value on all supported platforms
+	p.Watcher = &Watcher{PID: 999999999, Mode: "daemon"}
+	assert.Nil(t, p.ActiveWatcher(), "a killed watcher is not active")
+}
+
+func TestProject_Missing(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".lanup.yaml")
+	output := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(config, []byte("vars: {}\n"), 0644))
+
+	p := &Project{Dir: dir, Config: config, Outputs: []string{output}}
+	assert.False(t, p.Missing())
+	assert.Equal(t, []string{output}, p.MissingOutputs())
+
+	require.NoError(t, os.Remove(config))
+	assert.True(t, p.Missing())
+
+	p = &Project{Dir: filepath.Join(dir, "gone")}
+	assert.True(t, p.Missing())
+}
+
+func TestProject_AddRun(t *testing.T) {
+	p := &Project{}
+	assert.Nil(t, p.Run(1))
+
+	first := p.AddRun(Run{IP: "192.168.1.20"})
+	assert.Equal(t, 1, first.ID)
+	second := p.AddRun(Run{IP: "192.168.1.21"})
+	assert.Equal(t, 2, second.ID)
+	require.NotNil(t, p.Run(2))
+	assert.Equal(t, "192.168.1.21", p.Run(2).IP)
+
+	for i := 0; i < MaxRuns; i++ {
+		p.AddRun(Run{})
+	}
+	require.Len(t, p.Runs, MaxRuns)
+	assert.Nil(t, p.Run(2), "the oldest runs are dropped")
+	assert.Equal(t, MaxRuns+2, p.Runs[len(p.Runs)-1].ID, "IDs keep increasing")
+}
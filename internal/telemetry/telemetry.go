@@ -0,0 +1,158 @@
+// Package telemetry sends anonymous usage statistics when the user opted in with
+// 'lanup telemetry on': the command run, the OS and which built-in detectors are available.
+// IP addresses, hostnames, paths, variable names and values are never sent.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint receives the events, LANUP_TELEMETRY_URL replaces it
+const DefaultEndpoint = "https://lanup.raucheacho.com/api/telemetry"
+
+// Timeout is how long sending an event may delay the exit of lanup
+const Timeout = 2 * time.Second
+
+// Environment variables disabling telemetry whatever the configuration, for CI and shared machines
+const (
+	EnvTelemetry   = "LANUP_TELEMETRY"     // 0, false or off
+	EnvDoNotTrack  = "DO_NOT_TRACK"        // https://consoledonottrack.com
+	EnvEndpointURL = "LANUP_TELEMETRY_URL" // replaces DefaultEndpoint
+)
+
+// BuiltinDetectors are the detectors whose availability is reported
+// The detectors of the projects are named by their users, so their names are never sent.
+var BuiltinDetectors = []string{"docker", "supabase", "dev_servers", "expo"}
+
+// Event is sent after a command, when telemetry is enabled
+type Event struct {
+	ID        string            `json:"id"` // random identifier of the installation, see State
+	Version   string            `json:"version"`
+	Command   string            `json:"command"` // e.g. "service install", without arguments or flags
+	OS        string            `json:"os"`
+	Arch      string            `json:"arch"`
+	Success   bool              `json:"success"`
+	Duration  int64             `json:"duration_ms"`
+	Detectors map[string]string `json:"detectors,omitempty"` // built-in detector: found, not_running, skipped or failed
+}
+
+// State is the telemetry file (~/.lanup/telemetry.json)
+type State struct {
+	ID          string `json:"id,omitempty"` // created by 'lanup telemetry on', removed by 'off'
+	NoticeShown bool   `json:"notice_shown"` // the first-run notice was printed
+}
+
+// DefaultPath returns the path of the telemetry file (~/.lanup/telemetry.json)
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".lanup", "telemetry.json"), nil
+}
+
+// Load reads the telemetry file, a missing file is an empty state
+func Load(path string) (*State, error) {
+	s := &State{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse telemetry state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the telemetry file
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create telemetry directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write telemetry state: %w", err)
+	}
+	return nil
+}
+
+// NewID returns a random identifier, unrelated to the machine or the user
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate telemetry ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DisabledByEnv returns the environment variable disabling telemetry, empty when none does
+func DisabledByEnv() string {
+	if v := os.Getenv(EnvDoNotTrack); v != "" && v != "0" && !strings.EqualFold(v, "false") {
+		return EnvDoNotTrack
+	}
+	switch strings.ToLower(os.Getenv(EnvTelemetry)) {
+	case "0", "false", "off", "no":
+		return EnvTelemetry
+	}
+	return ""
+}
+
+// Endpoint returns the URL events are sent to
+func Endpoint() string {
+	if url := os.Getenv(EnvEndpointURL); url != "" {
+		return url
+	}
+	return DefaultEndpoint
+}
+
+// Client sends events
+type Client struct {
+	Endpoint string
+	HTTP     *http.Client
+}
+
+// NewClient creates a client sending to Endpoint, with Timeout
+func NewClient() *Client {
+	return &Client{Endpoint: Endpoint(), HTTP: &http.Client{Timeout: Timeout}}
+}
+
+// Send posts the event as JSON
+func (c *Client) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lanup/"+e.Version)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry event: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanup", "telemetry.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, &State{}, s)
+
+	id, err := NewID()
+	require.NoError(t, err)
+	assert.Len(t, id, 32)
+
+	s.ID, s.NoticeShown = id, true
+	require.NoError(t, s.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func TestDisabledByEnv(t *testing.T) {
+	tests := []struct {
+		doNotTrack string
+		telemetry  string
+		expected   string
+	}{
+		{"", "", ""},
+		{"1", "", EnvDoNotTrack},
+		{"0", "", ""},
+		{"", "off", EnvTelemetry},
+		{"", "FALSE", EnvTelemetry},
+		{"", "1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(EnvDoNotTrack, tt.doNotTrack)
+		t.Setenv(EnvTelemetry, tt.telemetry)
+		assert.Equal(t, tt.expected, DisabledByEnv(), "DO_NOT_TRACK=%q LANUP_TELEMETRY=%q", tt.doNotTrack, tt.telemetry)
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := Event{ID: "abc", Version: "1.0.0", Command: "start", OS: "linux", Arch: "amd64", Success: true,
+		Detectors: map[string]string{"docker": "found"}}
+	client := &Client{Endpoint: server.URL, HTTP: server.Client()}
+	require.NoError(t, client.Send(context.Background(), event))
+	assert.Equal(t, event, received)
+}
+
+func TestClient_Send_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &Client{Endpoint: server.URL, HTTP: server.Client()}
+	assert.Error(t, client.Send(context.Background(), Event{}))
+}
@@ -0,0 +1,103 @@
+// Package templates renders the template files of a project, such as nginx or Caddy
+// configurations, with the variables lanup generated
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Data is what the templates are executed with
+type Data struct {
+	IP        string            // LAN IP
+	Host      string            // host written in the URLs: the project hostname, or the IP
+	Interface string            // network interface of the IP
+	Vars      map[string]string // generated variables, by name
+}
+
+// Funcs are the functions available in templates besides the text/template built-ins
+var Funcs = template.FuncMap{
+	"port":     urlPort,
+	"hostname": urlHostname,
+	"default":  defaultValue,
+}
+
+// Render executes the template file source with data and writes the result to output
+// Unknown fields are errors, index .Vars "NAME" is empty for a variable that doesn't exist.
+// The output is only written when its content changes, Render reports whether it was.
+func Render(source, output string, data Data) (bool, error) {
+	text, err := os.ReadFile(source)
+	if err != nil {
+		return false, fmt.Errorf("failed to read template %s: %w", source, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(source)).Funcs(Funcs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return false, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(output); err == nil {
+		mode = info.Mode().Perm()
+		if current, err := os.ReadFile(output); err == nil && bytes.Equal(current, buf.Bytes()) {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory of %s: %w", output, err)
+	}
+	tmp := output + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), mode); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return true, nil
+}
+
+// urlPort returns the port of a URL, the default one of its scheme when it has none
+func urlPort(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https", "wss":
+		return "443"
+	case "http", "ws":
+		return "80"
+	}
+	return ""
+}
+
+// urlHostname returns the host of a URL, without its port
+func urlHostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// defaultValue returns value, or def when value is empty: {{ index .Vars "API_URL" | default "http://localhost" }}
+func defaultValue(def, value string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
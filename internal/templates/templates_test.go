@@ -0,0 +1,51 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "nginx.conf.tmpl")
+	output := filepath.Join(dir, "generated", "nginx.conf")
+	require.NoError(t, os.WriteFile(source, []byte(`server_name {{ .Host }};
+proxy_pass http://{{ .IP }}:{{ port .Vars.API_URL }};
+# {{ hostname .Vars.API_URL }} {{ index .Vars "MISSING" | default "none" }}
+`), 0644))
+
+	data := Data{IP: "192.168.1.100", Host: "myapp.lan", Vars: map[string]string{"API_URL": "https://myapp.lan"}}
+	written, err := Render(source, output, data)
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, `server_name myapp.lan;
+proxy_pass http://192.168.1.100:443;
+# myapp.lan none
+`, string(content))
+
+	// Unchanged content is not written again
+	written, err = Render(source, output, data)
+	require.NoError(t, err)
+	assert.False(t, written)
+}
+
+func TestRender_Errors(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out")
+
+	_, err := Render(filepath.Join(dir, "missing.tmpl"), output, Data{})
+	assert.ErrorContains(t, err, "failed to read template")
+
+	source := filepath.Join(dir, "typo.tmpl")
+	require.NoError(t, os.WriteFile(source, []byte("{{ .Vars.API_URL }}"), 0644))
+	_, err = Render(source, output, Data{Vars: map[string]string{}})
+	assert.ErrorContains(t, err, "failed to render template")
+	assert.NoFileExists(t, output)
+}
@@ -1,8 +1,14 @@
 package errors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+)
 
 // ErrorCode represents specific error types in lanup
+// Codes are errors themselves, so that errors.Is(err, ErrNoNetwork) matches any LanupError with that code
 type ErrorCode int
 
 const (
@@ -18,8 +24,78 @@ const (
 	ErrInvalidURL
 	// ErrDockerUnavailable indicates Docker is not available or not running
 	ErrDockerUnavailable
+	// ErrIO indicates a file operation or a command failed for another reason than permissions or a missing file
+	ErrIO
+	// ErrProcess indicates the daemon or watch mode failed to start, or is already running
+	ErrProcess
+	// ErrChangesPending indicates the env file is out of date, as reported by 'lanup diff --exit-code'
+	ErrChangesPending
 )
 
+// Error returns the name of the code
+func (c ErrorCode) Error() string {
+	switch c {
+	case ErrNoNetwork:
+		return "no network"
+	case ErrInvalidConfig:
+		return "invalid configuration"
+	case ErrFileNotFound:
+		return "file not found"
+	case ErrPermissionDenied:
+		return "permission denied"
+	case ErrInvalidURL:
+		return "invalid URL"
+	case ErrDockerUnavailable:
+		return "docker unavailable"
+	case ErrIO:
+		return "I/O error"
+	case ErrProcess:
+		return "process error"
+	case ErrChangesPending:
+		return "changes pending"
+	default:
+		return fmt.Sprintf("error code %d", int(c))
+	}
+}
+
+// ExitCode returns the process exit code for errors with this code
+func (c ErrorCode) ExitCode() int {
+	switch c {
+	case ErrNoNetwork:
+		return 3
+	case ErrInvalidConfig:
+		return 2
+	case ErrPermissionDenied:
+		return 4
+	case ErrInvalidURL:
+		return 5
+	case ErrProcess:
+		return 6
+	case ErrChangesPending:
+		return 7
+	default:
+		return 1
+	}
+}
+
+// ExitCodeInfo describes a process exit code
+type ExitCodeInfo struct {
+	Code    int
+	Meaning string
+}
+
+// ExitCodes lists the exit codes of lanup, as documented by 'lanup help exit-codes'
+var ExitCodes = []ExitCodeInfo{
+	{Code: 0, Meaning: "Success"},
+	{Code: 1, Meaning: "General error, such as a missing file or Docker being unavailable"},
+	{Code: 2, Meaning: "Invalid configuration or flag"},
+	{Code: 3, Meaning: "Network error: no LAN interface found or services unreachable"},
+	{Code: 4, Meaning: "Permission denied, e.g. writing the hosts file without sudo"},
+	{Code: 5, Meaning: "Invalid URL"},
+	{Code: 6, Meaning: "The daemon or watch mode failed to start, or is already running"},
+	{Code: 7, Meaning: "Changes pending: 'lanup diff --exit-code' found the env file out of date"},
+}
+
 // LanupError represents a structured error with code, message, and cause
 type LanupError struct {
 	Code    ErrorCode
@@ -35,6 +111,17 @@ func (e *LanupError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the cause, so that errors.Is and errors.As see through the LanupError
+func (e *LanupError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error has the target code
+func (e *LanupError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && e.Code == code
+}
+
 // NewError creates a new LanupError with the given code, message, and optional cause
 func NewError(code ErrorCode, msg string, cause error) *LanupError {
 	return &LanupError{
@@ -44,22 +131,35 @@ func NewError(code ErrorCode, msg string, cause error) *LanupError {
 	}
 }
 
+// FromOSError wraps an error from a file operation or a command run with os/exec,
+// choosing the code from its cause: ErrPermissionDenied for EACCES and EPERM,
+// ErrFileNotFound for ENOENT and commands missing from PATH, ErrIO otherwise
+func FromOSError(msg string, err error) *LanupError {
+	code := ErrIO
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		code = ErrPermissionDenied
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, exec.ErrNotFound):
+		code = ErrFileNotFound
+	}
+	return NewError(code, msg, err)
+}
+
 // ExitCode returns the appropriate exit code for the error
 func (e *LanupError) ExitCode() int {
-	switch e.Code {
-	case ErrNoNetwork:
-		return 3
-	case ErrInvalidConfig:
-		return 2
-	case ErrFileNotFound:
-		return 1
-	case ErrPermissionDenied:
-		return 4
-	case ErrInvalidURL:
-		return 5
-	case ErrDockerUnavailable:
-		return 1
-	default:
-		return 1
+	return e.Code.ExitCode()
+}
+
+// ExitCode returns the process exit code for an error returned by a command
+// The outermost error with an ExitCode method decides, such as a LanupError or the exit
+// status of a command run by 'lanup run'; other errors exit with 1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
 	}
+	return 1
 }
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanupError_Is(t *testing.T) {
+	err := NewError(ErrPermissionDenied, "Failed to write hosts file", os.ErrPermission)
+	wrapped := fmt.Errorf("hosts: %w", err)
+
+	assert.True(t, errors.Is(wrapped, ErrPermissionDenied))
+	assert.False(t, errors.Is(wrapped, ErrFileNotFound))
+	assert.True(t, errors.Is(wrapped, os.ErrPermission), "the cause is unwrapped")
+
+	var lanupErr *LanupError
+	assert.True(t, errors.As(wrapped, &lanupErr))
+	assert.Equal(t, "Failed to write hosts file", lanupErr.Message)
+}
+
+// exitStatus mimics the exit status of a command run by 'lanup run'
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitStatus) ExitCode() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: 0},
+		{name: "no network", err: NewError(ErrNoNetwork, "Failed to detect local IP address", nil), want: 3},
+		{name: "invalid config", err: NewError(ErrInvalidConfig, "Failed to load project configuration", nil), want: 2},
+		{name: "file not found", err: NewError(ErrFileNotFound, "Failed to read .env", nil), want: 1},
+		{name: "permission denied", err: NewError(ErrPermissionDenied, "Failed to write hosts file", nil), want: 4},
+		{name: "invalid URL", err: NewError(ErrInvalidURL, "Invalid URL", nil), want: 5},
+		{name: "process", err: NewError(ErrProcess, "Daemon exited during startup", nil), want: 6},
+		{name: "changes pending", err: NewError(ErrChangesPending, ".env.local is out of date", nil), want: 7},
+		{name: "wrapped", err: fmt.Errorf("watch: %w", NewError(ErrNoNetwork, "Lost network", nil)), want: 3},
+		{name: "message is ignored", err: errors.New("invalid network configuration"), want: 1},
+		{name: "child exit status", err: exitStatus(42), want: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestFromOSError(t *testing.T) {
+	_, notFound := os.ReadFile(filepath.Join(t.TempDir(), "missing.env"))
+	_, noCommand := exec.LookPath("lanup-command-that-does-not-exist")
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{name: "missing file", err: notFound, want: ErrFileNotFound},
+		{name: "missing command", err: &exec.Error{Name: "lsof", Err: exec.ErrNotFound}, want: ErrFileNotFound},
+		{name: "command not in PATH", err: noCommand, want: ErrFileNotFound},
+		{name: "access denied", err: &fs.PathError{Op: "open", Path: "/etc/hosts", Err: syscall.EACCES}, want: ErrPermissionDenied},
+		{name: "operation not permitted", err: &fs.PathError{Op: "chmod", Path: "/etc/hosts", Err: syscall.EPERM}, want: ErrPermissionDenied},
+		{name: "wrapped", err: fmt.Errorf("failed to write env file: %w", os.ErrPermission), want: ErrPermissionDenied},
+		{name: "other", err: &fs.PathError{Op: "write", Path: ".env", Err: syscall.ENOSPC}, want: ErrIO},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromOSError("Failed", tt.err)
+			assert.Equal(t, tt.want, err.Code)
+			assert.ErrorIs(t, err, tt.err, "the cause is kept")
+		})
+	}
+}
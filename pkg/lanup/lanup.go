@@ -0,0 +1,313 @@
+// Package lanup exposes the core flow of the lanup CLI to other Go tools:
+// detect the LAN IP, run the detectors, transform the localhost URLs and merge
+// them into the project env file
+//
+//	result, err := lanup.Run(ctx, lanup.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(result.Network.IP, result.Vars)
+package lanup
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/raucheacho/lanup/internal/config"
+	"github.com/raucheacho/lanup/internal/detector"
+	"github.com/raucheacho/lanup/internal/env"
+	"github.com/raucheacho/lanup/internal/net"
+	"github.com/raucheacho/lanup/internal/plugin"
+	"github.com/raucheacho/lanup/internal/process"
+	"github.com/raucheacho/lanup/internal/secrets"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+)
+
+// EnvVar is a variable of the env file, Managed when lanup owns it
+type EnvVar = env.EnvVar
+
+// NetworkInfo describes the network interface whose IP is used
+type NetworkInfo = net.NetworkInfo
+
+// ProjectConfig is the content of .lanup.yaml
+type ProjectConfig = config.ProjectConfig
+
+// AutoDetectConfig enables the built-in detectors of a ProjectConfig
+type AutoDetectConfig = config.AutoDetectConfig
+
+// DetectorConfig is an external detector of a ProjectConfig
+type DetectorConfig = config.DetectorConfig
+
+// RewriteConfig is a rewrite rule of a ProjectConfig
+type RewriteConfig = config.RewriteConfig
+
+// VarOptions changes the scheme, host or port of a variable of a ProjectConfig
+type VarOptions = config.VarOptions
+
+// Formatter reads and writes env files in a given format, see env.Formatter
+// Parse must recognize the managed variables written by Render
+type Formatter = env.Formatter
+
+// DotenvFormatter is the KEY=VALUE format written by default
+type DotenvFormatter = env.DotenvFormatter
+
+// DartDefineFormatter is the JSON format of flutter run --dart-define-from-file
+type DartDefineFormatter = env.DartDefineFormatter
+
+// DetectorResult is the outcome of running one detector
+type DetectorResult = detector.Result
+
+// Options configures a run, the zero value behaves like 'lanup start'
+type Options struct {
+	// ConfigPath is the project configuration file, the nearest .lanup.yaml from the current
+	// directory when empty
+	ConfigPath string
+	// Profile selects a profile of the configuration, none when empty
+	Profile string
+	// Config is used instead of reading ConfigPath when set, Profile is still applied
+	Config *ProjectConfig
+	// IP is the LAN address written in the URLs, the ip of the configuration or the detected one when empty
+	IP string
+	// DryRun computes the variables without writing the env file
+	DryRun bool
+	// NoBackup skips the .bak copy of the env file
+	NoBackup bool
+	// Formatter is the format of the env file, the format of the configuration when nil
+	Formatter Formatter
+	// OnDetector is called with the outcome of each detector, optional
+	OnDetector func(DetectorResult)
+}
+
+// Result is what a run produced
+type Result struct {
+	Network  NetworkInfo
+	Host     string   // host written in the URLs: the project hostname, the address of the emulators, or the IP
+	Vars     []EnvVar // managed variables, sorted by key
+	Output   string   // env file path, left untouched with DryRun
+	Warnings []string // failures and warnings of the detectors
+}
+
+// Run loads the project configuration, detects the IP, collects and transforms the
+// variables and merges them into the env file
+// Errors are *errors.LanupError values from github.com/raucheacho/lanup/pkg/errors
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	cfg, err := LoadConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := opts.IP
+	if ip == "" {
+		ip = cfg.IP
+	}
+	info, err := network(ip)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cfg = cfg.WithFacts(config.CurrentFacts(info.Interface, info.Type))
+	result := &Result{Network: *info, Host: cfg.URLHost(info.IP), Output: cfg.OutputPath()}
+
+	vars := Collect(ctx, cfg, func(r DetectorResult) {
+		result.Warnings = append(result.Warnings, detectorWarnings(r)...)
+		if opts.OnDetector != nil {
+			opts.OnDetector(r)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ResolveSecrets(ctx, vars); err != nil {
+		return nil, err
+	}
+	result.Vars = TransformConfig(cfg, vars, result.Host)
+
+	if opts.DryRun {
+		return result, nil
+	}
+	formatter := opts.Formatter
+	if formatter == nil {
+		if formatter, err = plugin.NewFormatter(cfg.Format); err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid env file format", err)
+		}
+	}
+	if err := writeEnv(cfg.Dir(), result.Output, result.Vars, formatter, !opts.NoBackup); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadConfig returns opts.Config or the configuration at opts.ConfigPath, with opts.Profile applied
+func LoadConfig(opts Options) (*ProjectConfig, error) {
+	if opts.Config != nil {
+		if err := opts.Config.Validate(); err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid project configuration", err)
+		}
+		cfg, err := opts.Config.WithProfile(opts.Profile)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid profile", err)
+		}
+		return cfg, nil
+	}
+
+	cfg, err := config.LoadProjectConfigProfile(opts.ConfigPath, opts.Profile)
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig,
+			"Failed to load project configuration", err)
+	}
+	return cfg, nil
+}
+
+// DetectIP returns the network interface lanup would use
+func DetectIP() (*NetworkInfo, error) {
+	info, err := net.DetectLocalIP()
+	if err != nil {
+		return nil, lanuperrors.NewError(lanuperrors.ErrNoNetwork,
+			"Failed to detect local IP address", err)
+	}
+	return info, nil
+}
+
+// Collect returns the configured variables merged with the ones found by the enabled detectors,
+// named with the var_prefix of the configuration
+// The static vars of the configuration win over the detected variables, see TransformConfig.
+// The op:// and env:// references to secrets are returned as is, see ResolveSecrets.
+// onResult is called with the outcome of each detector, it may be nil
+func Collect(ctx context.Context, cfg *ProjectConfig, onResult func(DetectorResult)) map[string]string {
+	return detector.Collect(ctx, cfg, onResult).Vars
+}
+
+// ResolveSecrets replaces the op:// and env:// references of vars with the secrets they point to,
+// like Run and the CLI do before writing the env file
+func ResolveSecrets(ctx context.Context, vars map[string]string) error {
+	if err := secrets.NewResolver().ResolveVars(ctx, vars); err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Failed to resolve secrets", err)
+	}
+	return nil
+}
+
+// Transform replaces localhost with host in every value and returns managed
+// variables sorted by key
+func Transform(vars map[string]string, host string) []EnvVar {
+	return transform(vars, host, nil, nil, nil)
+}
+
+// TransformConfig is Transform with the settings of cfg: its rewrite rules are applied
+// before localhost is replaced, the options of its vars after, and its static vars are left unchanged
+// The allowed_origins_var of cfg is added when it lists at least one origin.
+func TransformConfig(cfg *ProjectConfig, vars map[string]string, host string) []EnvVar {
+	transformed := transform(vars, host, cfg.StaticVars, compileRewrites(cfg.Rewrites), cfg.VarOptions)
+	if cfg.OriginsVar == "" {
+		return transformed
+	}
+	if _, ok := vars[cfg.OriginsVar]; ok {
+		return transformed
+	}
+	origins := allowedOrigins(transformed, host)
+	if origins == "" {
+		return transformed
+	}
+
+	transformed = append(transformed, EnvVar{Key: cfg.OriginsVar, Value: origins, Managed: true})
+	sort.Slice(transformed, func(i, j int) bool {
+		return transformed[i].Key < transformed[j].Key
+	})
+	return transformed
+}
+
+// transform rewrites the values of vars but the static ones and sorts them by key
+func transform(vars map[string]string, host string, static map[string]string, rewrites []rewrite,
+	options map[string]VarOptions) []EnvVar {
+	transformed := make([]EnvVar, 0, len(vars))
+	for key, value := range vars {
+		if _, ok := static[key]; !ok {
+			for _, r := range rewrites {
+				value = r.apply(value, host)
+			}
+			value = TransformURL(value, host)
+			if opts, ok := options[key]; ok {
+				value = applyVarOptions(value, opts)
+			}
+		}
+		transformed = append(transformed, EnvVar{
+			Key:     key,
+			Value:   value,
+			Managed: true,
+		})
+	}
+
+	sort.Slice(transformed, func(i, j int) bool {
+		return transformed[i].Key < transformed[j].Key
+	})
+
+	return transformed
+}
+
+// TransformURL replaces localhost or 127.0.0.1 with host
+// Only the host of a URL is replaced, credentials, path and query are kept.
+func TransformURL(url string, host string) string {
+	return env.TransformURL(url, host)
+}
+
+// WriteEnv merges the managed variables into the env file at path, keeping the user variables
+// formatter is the format of the file, dotenv when nil
+// It holds the lock of the project in the current directory, like the CLI
+func WriteEnv(path string, vars []EnvVar, formatter Formatter) error {
+	return writeEnv(".", path, vars, formatter, true)
+}
+
+// writeEnv reads, merges and writes the env file, waiting for other lanup processes writing it
+func writeEnv(projectDir, path string, vars []EnvVar, formatter Formatter, backup bool) error {
+	lock, err := process.LockProject(projectDir, process.DefaultLockTimeout)
+	if err != nil {
+		return lanuperrors.NewError(lanuperrors.ErrIO,
+			"Failed to lock the project, another lanup process is still writing the env file", err)
+	}
+	defer lock.Release()
+
+	writer := env.NewEnvWriter(path)
+	writer.BackupEnabled = backup
+	if formatter != nil {
+		writer.Formatter = formatter
+	}
+
+	existing, err := writer.Read()
+	if err != nil {
+		return lanuperrors.FromOSError("Failed to read existing env file", err)
+	}
+	if err := writer.Write(writer.Merge(vars, existing)); err != nil {
+		return lanuperrors.FromOSError("Failed to write env file", err)
+	}
+	return nil
+}
+
+// network returns the interface to use, the one that has the IP when it is given
+func network(ip string) (*NetworkInfo, error) {
+	if ip != "" {
+		info, err := net.ManualIP(ip)
+		if err != nil {
+			return nil, lanuperrors.NewError(lanuperrors.ErrInvalidConfig, "Invalid IP address", err)
+		}
+		return info, nil
+	}
+	return DetectIP()
+}
+
+// detectorWarnings turns the failure and warnings of a detector into messages
+// Services that are simply not running are not worth a warning
+func detectorWarnings(r DetectorResult) []string {
+	if r.Skipped {
+		return nil
+	}
+	if r.Err != nil {
+		if errors.Is(r.Err, detector.ErrNotRunning) {
+			return nil
+		}
+		return []string{r.Err.Error()}
+	}
+	return r.Warnings
+}
@@ -0,0 +1,244 @@
+package lanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raucheacho/lanup/internal/cert"
+	"github.com/raucheacho/lanup/internal/config"
+	lanuperrors "github.com/raucheacho/lanup/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig returns a configuration without detectors, writing to dir
+func testConfig(dir string) *ProjectConfig {
+	return &ProjectConfig{
+		Vars: map[string]string{
+			"API_URL": "http://localhost:8000",
+			"DB_URL":  "postgresql://127.0.0.1:5432/db",
+		},
+		Output: filepath.Join(dir, ".env.local"),
+		Profiles: map[string]config.ProfileConfig{
+			"mobile": {Vars: map[string]string{"APP_URL": "http://localhost:19000"}},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	cfg := testConfig(dir)
+	require.NoError(t, os.WriteFile(cfg.Output, []byte("SECRET=keep\n"), 0644))
+
+	result, err := Run(context.Background(), Options{Config: cfg, IP: "192.168.1.20"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "192.168.1.20", result.Network.IP)
+	assert.Equal(t, "192.168.1.20", result.Host)
+	assert.Equal(t, []EnvVar{
+		{Key: "API_URL", Value: "http://192.168.1.20:8000", Managed: true},
+		{Key: "DB_URL", Value: "postgresql://192.168.1.20:5432/db", Managed: true},
+	}, result.Vars)
+
+	content, err := os.ReadFile(cfg.Output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "API_URL=http://192.168.1.20:8000")
+	assert.Contains(t, string(content), "SECRET=keep", "user variables are preserved")
+	assert.FileExists(t, cfg.Output+".bak")
+}
+
+func TestRun_DryRunProfileAndHostname(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.Hostname = "myapp.local"
+
+	result, err := Run(context.Background(), Options{Config: cfg, Profile: "mobile", IP: "10.0.0.5", DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, "myapp.local", result.Host)
+	assert.Len(t, result.Vars, 3)
+	assert.Equal(t, "http://myapp.local:19000", result.Vars[1].Value)
+	assert.NoFileExists(t, cfg.Output)
+}
+
+func TestRun_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.Vars["DB_PASSWORD"] = "env://LANUP_SDK_TEST_SECRET"
+
+	t.Setenv("LANUP_SDK_TEST_SECRET", "s3cret")
+	result, err := Run(context.Background(), Options{Config: cfg, IP: "10.0.0.5", DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, result.Vars, EnvVar{Key: "DB_PASSWORD", Value: "s3cret", Managed: true})
+
+	// A secret that can't be read fails the run rather than writing the reference
+	require.NoError(t, os.Unsetenv("LANUP_SDK_TEST_SECRET"))
+	_, err = Run(context.Background(), Options{Config: cfg, IP: "10.0.0.5"})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+	assert.NoFileExists(t, cfg.Output)
+}
+
+func TestRun_ConfigIP(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.IP = "10.0.0.9"
+
+	// The ip of the configuration is used instead of the detected one, like 'lanup start'
+	result, err := Run(context.Background(), Options{Config: cfg, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.9", result.Network.IP)
+	assert.Contains(t, result.Vars, EnvVar{Key: "API_URL", Value: "http://10.0.0.9:8000", Managed: true})
+
+	// Options.IP still wins
+	result, err = Run(context.Background(), Options{Config: cfg, IP: "10.0.0.5", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.5", result.Network.IP)
+}
+
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Run(context.Background(), Options{ConfigPath: filepath.Join(dir, "missing.yaml"), IP: "10.0.0.5"})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+
+	_, err = Run(context.Background(), Options{Config: testConfig(dir), Profile: "nope", IP: "10.0.0.5"})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+
+	_, err = Run(context.Background(), Options{Config: testConfig(dir), IP: "not-an-ip"})
+	assert.ErrorIs(t, err, lanuperrors.ErrInvalidConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, Options{Config: testConfig(dir), IP: "10.0.0.5"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(dir, ".env.local"))
+}
+
+func TestTransformURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		newIP string
+	}{
+		{
+			name:  "replace localhost",
+			url:   "http://localhost:8000",
+			newIP: "192.168.1.100",
+		},
+		{
+			name:  "replace 127.0.0.1",
+			url:   "http://127.0.0.1:8000",
+			newIP: "192.168.1.100",
+		},
+		{
+			name:  "replace localhost with https",
+			url:   "https://localhost:8443",
+			newIP: "192.168.1.100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TransformURL(tt.url, tt.newIP)
+
+			// Verify localhost was replaced
+			assert.NotContains(t, result, "localhost")
+			assert.NotContains(t, result, "127.0.0.1")
+
+			// Verify new IP is in the result
+			assert.Contains(t, result, tt.newIP)
+
+			// Verify protocol is preserved
+			if strings.HasPrefix(tt.url, "https") {
+				assert.True(t, strings.HasPrefix(result, "https"))
+			} else {
+				assert.True(t, strings.HasPrefix(result, "http"))
+			}
+		})
+	}
+}
+
+func TestTransformConfig(t *testing.T) {
+	cfg := &ProjectConfig{
+		StaticVars: map[string]string{"API_KEY": "localhost-dev-key"},
+		VarOptions: map[string]VarOptions{
+			"ADMIN_URL": {Scheme: "https", Port: 8443},
+			"CDN_URL":   {Host: "cdn.lan"},
+			"APP_NAME":  {Scheme: "https"},
+		},
+		Rewrites: []RewriteConfig{
+			{Host: "host.docker.internal"},
+			{Port: 3000, ToPort: 8443},
+			{Match: `^http://(.+):8443`, Replace: "https://${1}:8443"},
+			{Match: `postgres://db:`, Replace: "postgres://{host}:"},
+		},
+	}
+	vars := map[string]string{
+		"API_KEY":      "localhost-dev-key",
+		"API_URL":      "http://host.docker.internal:8000/v1",
+		"OTHER_URL":    "http://myhost.docker.internal:8000",
+		"WEB_URL":      "http://localhost:3000/app",
+		"PORT_URL":     "http://localhost:30001",
+		"DATABASE_URL": "postgres://db:5432/app",
+		"ADMIN_URL":    "http://localhost:8000/admin",
+		"CDN_URL":      "http://localhost:9000",
+		"APP_NAME":     "My App",
+	}
+
+	values := make(map[string]string)
+	for _, v := range TransformConfig(cfg, vars, "192.168.1.100") {
+		values[v.Key] = v.Value
+	}
+	assert.Equal(t, map[string]string{
+		"API_KEY":      "localhost-dev-key",
+		"API_URL":      "http://192.168.1.100:8000/v1",
+		"OTHER_URL":    "http://myhost.docker.internal:8000",
+		"WEB_URL":      "https://192.168.1.100:8443/app",
+		"PORT_URL":     "http://192.168.1.100:30001",
+		"DATABASE_URL": "postgres://192.168.1.100:5432/app",
+		"ADMIN_URL":    "https://192.168.1.100:8443/admin",
+		"CDN_URL":      "http://cdn.lan:9000",
+		"APP_NAME":     "My App",
+	}, values)
+}
+
+func TestTransformConfig_AllowedOrigins(t *testing.T) {
+	cfg := &ProjectConfig{
+		OriginsVar: "LANUP_ALLOWED_ORIGINS",
+		StaticVars: map[string]string{"DOCS_URL": "http://localhost:4000"},
+		VarOptions: map[string]VarOptions{"ADMIN_URL": {Scheme: "https", Port: 8443}},
+	}
+	vars := map[string]string{
+		"WEB_URL":      "http://localhost:3000/app",
+		"API_URL":      "http://127.0.0.1:8000/v1",
+		"SOCKET_URL":   "ws://localhost:8000/ws",
+		"ADMIN_URL":    "http://localhost:8000/admin",
+		"DATABASE_URL": "postgres://localhost:5432/app",
+		"EXTERNAL_URL": "https://api.example.com",
+		"DOCS_URL":     "http://localhost:4000",
+	}
+
+	transformed := TransformConfig(cfg, vars, "192.168.1.100")
+	var origins string
+	for _, v := range transformed {
+		if v.Key == "LANUP_ALLOWED_ORIGINS" {
+			origins = v.Value
+			assert.True(t, v.Managed)
+		}
+	}
+
+	expected := []string{"http://192.168.1.100:3000", "http://192.168.1.100:8000", "https://192.168.1.100:8443"}
+	if name := cert.LocalHostname(); name != "" {
+		expected = append(expected, "http://"+name+":3000", "http://"+name+":8000", "https://"+name+":8443")
+	}
+	assert.ElementsMatch(t, expected, strings.Split(origins, ","))
+
+	// No variable without origins, nor without the setting
+	assert.Len(t, TransformConfig(cfg, map[string]string{"APP_NAME": "My App"}, "192.168.1.100"), 1)
+	cfg.OriginsVar = ""
+	assert.Len(t, TransformConfig(cfg, vars, "192.168.1.100"), len(vars))
+}
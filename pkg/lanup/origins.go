@@ -0,0 +1,43 @@
+package lanup
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/cert"
+)
+
+// allowedOrigins returns the origins of the http(s) URLs of vars served at host, with the
+// .local name of this machine as a variant, sorted and separated by commas
+func allowedOrigins(vars []EnvVar, host string) string {
+	hosts := []string{host}
+	if name := cert.LocalHostname(); name != "" && name != host {
+		hosts = append(hosts, name)
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range vars {
+		u, err := url.Parse(v.Value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() != host {
+			continue
+		}
+		for _, h := range hosts {
+			origin := &url.URL{Scheme: u.Scheme, Host: h}
+			if strings.Contains(h, ":") {
+				origin.Host = "[" + h + "]"
+			}
+			if port := u.Port(); port != "" {
+				origin.Host += ":" + port
+			}
+			seen[origin.String()] = true
+		}
+	}
+
+	origins := make([]string, 0, len(seen))
+	for origin := range seen {
+		origins = append(origins, origin)
+	}
+	sort.Strings(origins)
+	return strings.Join(origins, ",")
+}
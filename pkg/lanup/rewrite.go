@@ -0,0 +1,76 @@
+package lanup
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/raucheacho/lanup/internal/config"
+)
+
+// rewrite is a compiled rewrite rule
+type rewrite struct {
+	re       *regexp.Regexp
+	template string // with config.HostPlaceholder for the LAN host
+}
+
+// compileRewrites compiles the rules of a configuration, skipping invalid ones
+// The configuration is validated when loaded, so that none is skipped in practice.
+func compileRewrites(rules []RewriteConfig) []rewrite {
+	compiled := make([]rewrite, 0, len(rules))
+	for _, rule := range rules {
+		re, template, err := rule.Compile()
+		if err != nil {
+			continue
+		}
+		compiled = append(compiled, rewrite{re: re, template: template})
+	}
+	return compiled
+}
+
+// apply replaces every match of the rule in value, with host in place of the placeholder
+func (r rewrite) apply(value, host string) string {
+	matches := r.re.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(value[last:m[0]])
+		expanded := r.re.ExpandString(nil, r.template, value, m)
+		b.WriteString(strings.ReplaceAll(string(expanded), config.HostPlaceholder, host))
+		last = m[1]
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
+
+// applyVarOptions changes the scheme, host and port of a URL, values that are not URLs are left unchanged
+func applyVarOptions(value string, opts VarOptions) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return value
+	}
+
+	if opts.Scheme != "" {
+		u.Scheme = opts.Scheme
+	}
+	host, port := u.Hostname(), u.Port()
+	if opts.Host != "" {
+		host = opts.Host
+	}
+	if opts.Port != 0 {
+		port = strconv.Itoa(opts.Port)
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	return u.String()
+}
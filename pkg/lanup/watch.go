@@ -0,0 +1,30 @@
+package lanup
+
+import (
+	"time"
+
+	"github.com/raucheacho/lanup/internal/net"
+)
+
+// Watcher polls the network and reports its changes to the channels returned by Subscribe
+type Watcher = net.IPWatcher
+
+// ChangeEvent is a network change reported by a Watcher, with the old and new network
+type ChangeEvent = net.ChangeEvent
+
+// ChangeReason tells what changed in a ChangeEvent
+type ChangeReason = net.ChangeReason
+
+// Reasons of the change events
+const (
+	ReasonIPChanged        = net.ReasonIPChanged
+	ReasonInterfaceChanged = net.ReasonInterfaceChanged
+	ReasonNetworkLost      = net.ReasonNetworkLost
+	ReasonNetworkRestored  = net.ReasonNetworkRestored
+)
+
+// NewWatcher creates a watcher checking the network every interval, 5 seconds when 0
+// Call Subscribe before Start so that no change is missed
+func NewWatcher(interval time.Duration) *Watcher {
+	return net.NewIPWatcher(interval)
+}
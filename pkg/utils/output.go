@@ -2,12 +2,17 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 )
 
 // Output utilities for console formatting with colors and emojis
+// Outside terminals, messages start with tags such as [INFO] and colors are disabled
 
 var (
 	// Color functions
@@ -18,75 +23,280 @@ var (
 	highlightColor = color.New(color.FgCyan, color.Bold)
 )
 
+var (
+	// terminal is whether stdout is a terminal, checked once
+	terminal = isTerminal()
+	// colors is whether output is colored, off when NO_COLOR is set or with --no-color
+	colors = os.Getenv("NO_COLOR") == ""
+)
+
+// SetColor enables or disables colored output, including the colors of the commands
+// Colors are only written to terminals either way
+func SetColor(enabled bool) {
+	colors = enabled
+	color.NoColor = !enabled || !terminal
+}
+
+// ColorEnabled reports whether colors are enabled, false when NO_COLOR is set or with --no-color
+func ColorEnabled() bool {
+	return colors
+}
+
+// RedirectOutput sends the console output to f, for processes without a console such as
+// Windows services, and returns a function restoring it
+func RedirectOutput(f *os.File) (restore func()) {
+	console.Lock()
+	defer console.Unlock()
+	osStdout, osStderr := os.Stdout, os.Stderr
+	out, errOut := stdout.out, stderr.out
+	os.Stdout, os.Stderr = f, f
+	stdout.out, stderr.out = f, f
+
+	return func() {
+		console.Lock()
+		defer console.Unlock()
+		os.Stdout, os.Stderr = osStdout, osStderr
+		stdout.out, stderr.out = out, errOut
+	}
+}
+
+// Style is how console output is decorated on terminals
+type Style string
+
+const (
+	// StyleEmoji starts messages with emojis, the default
+	StyleEmoji Style = "emoji"
+	// StylePlain starts messages with tags such as [INFO], like outside terminals
+	StylePlain Style = "plain"
+	// StyleASCII only prints ASCII characters, for terminals and CI logs that can't render Unicode
+	StyleASCII Style = "ascii"
+)
+
+// style is set from the style setting of the global configuration
+var style = StyleEmoji
+
+// SetStyle sets how console output is decorated, unknown styles are the emoji style
+func SetStyle(s Style) {
+	switch s {
+	case StylePlain, StyleASCII:
+		style = s
+	default:
+		style = StyleEmoji
+	}
+}
+
+// Emoji returns emoji with the emoji style, fallback otherwise
+func Emoji(emoji, fallback string) string {
+	if style == StyleEmoji {
+		return emoji
+	}
+	return fallback
+}
+
+// Symbol returns a Unicode symbol such as an arrow, or its fallback with the ASCII style
+func Symbol(symbol, fallback string) string {
+	if style == StyleASCII {
+		return fallback
+	}
+	return symbol
+}
+
+// emojis reports whether messages start with emojis rather than tags
+func emojis() bool {
+	return terminal && style == StyleEmoji
+}
+
+// quiet suppresses decorative output, set by the global --quiet flag
+var quiet bool
+
+// SetQuiet enables or disables quiet mode, in which only results, warnings and errors are printed
+func SetQuiet(enabled bool) {
+	quiet = enabled
+}
+
+// IsQuiet reports whether quiet mode is enabled
+func IsQuiet() bool {
+	return quiet
+}
+
+// Println prints decorative text, such as blank lines and hints, skipped in quiet mode
+func Println(a ...interface{}) {
+	if !quiet {
+		fmt.Fprintln(Stdout, a...)
+	}
+}
+
+// Printf prints decorative details, skipped in quiet mode
+func Printf(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(Stdout, format, args...)
+	}
+}
+
+// console serializes the console output, so that the status line stays below it
+var console struct {
+	sync.Mutex
+	status string // status line, empty when there is none
+	shown  bool   // the status line is displayed
+}
+
+// consoleWriter writes to the console, erasing the status line before the text and drawing it
+// again once the line is complete
+type consoleWriter struct {
+	out io.Writer
+}
+
+var (
+	stdout = &consoleWriter{out: color.Output}
+	stderr = &consoleWriter{out: color.Error}
+
+	// Stdout and Stderr are the console, for the output that can be printed while the status
+	// line is displayed, such as from other goroutines
+	Stdout io.Writer = stdout
+	Stderr io.Writer = stderr
+)
+
+func init() {
+	color.Output, color.Error = stdout, stderr
+}
+
+// Write writes p to the console
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	console.Lock()
+	defer console.Unlock()
+	eraseStatus()
+	n, err := w.out.Write(p)
+	if console.status != "" && len(p) > 0 && p[len(p)-1] == '\n' {
+		drawStatus()
+	}
+	return n, err
+}
+
+// eraseStatus erases the status line when it is displayed, with the console locked
+func eraseStatus() {
+	if console.shown {
+		fmt.Fprintf(stdout.out, "\r%s\r", strings.Repeat(" ", utf8.RuneCountInString(console.status)))
+		console.shown = false
+	}
+}
+
+// drawStatus displays the status line, with the console locked
+func drawStatus() {
+	fmt.Fprint(stdout.out, console.status)
+	console.shown = true
+}
+
+// Status replaces the status line, which stays on the last line of the terminal below the other
+// output, with a message
+// Nothing is printed in quiet mode or outside terminals, where carriage returns aren't rendered.
+func Status(format string, args ...interface{}) {
+	if quiet || !terminal {
+		return
+	}
+	console.Lock()
+	defer console.Unlock()
+	eraseStatus()
+	console.status = fmt.Sprintf(format, args...)
+	drawStatus()
+}
+
+// ClearStatus erases the status line, for the next messages to start on an empty line
+func ClearStatus() {
+	console.Lock()
+	defer console.Unlock()
+	eraseStatus()
+	console.status = ""
+}
+
 // Success prints a success message with green color and checkmark emoji
 func Success(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := fmt.Sprintf(format, args...)
-	if isTerminal() {
+	if emojis() {
 		successColor.Printf("✅ %s\n", msg)
 	} else {
-		fmt.Printf("[SUCCESS] %s\n", msg)
+		successColor.Printf("[SUCCESS] %s\n", msg)
 	}
 }
 
 // Info prints an informational message with blue color and info emoji
 func Info(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := fmt.Sprintf(format, args...)
-	if isTerminal() {
+	if emojis() {
 		infoColor.Printf("ℹ️  %s\n", msg)
 	} else {
-		fmt.Printf("[INFO] %s\n", msg)
+		infoColor.Printf("[INFO] %s\n", msg)
 	}
 }
 
 // Warning prints a warning message with yellow color and warning emoji
 func Warning(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	if isTerminal() {
+	if emojis() {
 		warningColor.Printf("⚠️  %s\n", msg)
 	} else {
-		fmt.Printf("[WARNING] %s\n", msg)
+		warningColor.Printf("[WARNING] %s\n", msg)
 	}
 }
 
 // Error prints an error message with red color and error emoji
 func Error(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	if isTerminal() {
-		errorColor.Fprintf(os.Stderr, "❌ %s\n", msg)
+	if emojis() {
+		errorColor.Fprintf(Stderr, "❌ %s\n", msg)
 	} else {
-		fmt.Fprintf(os.Stderr, "[ERROR] %s\n", msg)
+		errorColor.Fprintf(Stderr, "[ERROR] %s\n", msg)
 	}
 }
 
 // Highlight prints a highlighted message with cyan color
 func Highlight(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := fmt.Sprintf(format, args...)
-	if isTerminal() {
+	if emojis() {
 		highlightColor.Printf("🔗 %s\n", msg)
 	} else {
-		fmt.Printf("%s\n", msg)
+		highlightColor.Printf("%s\n", msg)
 	}
 }
 
 // PrintURL prints a URL with special formatting
 func PrintURL(name, url string) {
-	if isTerminal() {
-		fmt.Printf("  %s %s\n",
+	PrintURLStatus(name, url, "")
+}
+
+// PrintURLStatus prints a URL like PrintURL, followed by status when not empty
+func PrintURLStatus(name, url, status string) {
+	if status != "" {
+		status = " " + status
+	}
+	if terminal {
+		fmt.Fprintf(Stdout, "  %s %s%s\n",
 			color.New(color.FgCyan, color.Bold).Sprint(name+":"),
-			color.New(color.FgWhite, color.Underline).Sprint(url))
+			color.New(color.FgWhite, color.Underline).Sprint(url), status)
 	} else {
-		fmt.Printf("  %s %s\n", name+":", url)
+		fmt.Fprintf(Stdout, "  %s %s%s\n", name+":", url, status)
 	}
 }
 
 // PrintSection prints a section header
 func PrintSection(title string) {
-	if isTerminal() {
-		fmt.Println()
+	if quiet {
+		return
+	}
+	if terminal && style != StyleASCII {
+		fmt.Fprintln(Stdout)
 		color.New(color.FgMagenta, color.Bold).Printf("═══ %s ═══\n", title)
-		fmt.Println()
+		fmt.Fprintln(Stdout)
 	} else {
-		fmt.Printf("\n=== %s ===\n\n", title)
+		fmt.Fprintf(Stdout, "\n=== %s ===\n\n", title)
 	}
 }
 
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetStyle(t *testing.T) {
+	defer SetStyle(StyleEmoji)
+
+	tests := []struct {
+		style  Style
+		emoji  string
+		symbol string
+	}{
+		{style: StyleEmoji, emoji: "⚠️  ", symbol: "→"},
+		{style: StylePlain, emoji: "", symbol: "→"},
+		{style: StyleASCII, emoji: "", symbol: "->"},
+		{style: "fancy", emoji: "⚠️  ", symbol: "→"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			SetStyle(tt.style)
+			assert.Equal(t, tt.emoji, Emoji("⚠️  ", ""))
+			assert.Equal(t, tt.symbol, Symbol("→", "->"))
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	defer func(enabled bool) { terminal = enabled }(terminal)
+
+	out := captureOutput(t, func() {
+		terminal = true
+		Status("watching %s", "192.168.1.42")
+		Status("lost")
+		// Messages are printed above the status line, drawn again once the line is complete
+		Printf("partial ")
+		Printf("line\n")
+		ClearStatus()
+		ClearStatus()
+
+		// Outside terminals nothing is printed
+		terminal = false
+		Status("watching %s", "192.168.1.42")
+		ClearStatus()
+	})
+	assert.Equal(t, "watching 192.168.1.42\r"+strings.Repeat(" ", 21)+"\rlost\r    \rpartial line\nlost\r    \r", out)
+}
+
+// captureOutput returns what fn printed to stdout and stderr, colored or not
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	restore := RedirectOutput(w)
+	defer restore()
+
+	fn()
+
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestQuiet(t *testing.T) {
+	defer func(enabled bool) { terminal = enabled }(terminal)
+	defer SetQuiet(false)
+	terminal = false
+
+	printAll := func() {
+		Success("written")
+		Info("hint")
+		Highlight("highlighted")
+		PrintSection("Variables")
+		Println("blank")
+		Printf("detail %d\n", 1)
+		Status("watching")
+		Warning("careful")
+		Error("failed")
+		PrintURL("API_URL", "http://192.168.1.42:8000")
+	}
+
+	out := captureOutput(t, printAll)
+	for _, msg := range []string{"[SUCCESS] written", "[INFO] hint", "highlighted", "=== Variables ===",
+		"blank", "detail 1", "[WARNING] careful", "[ERROR] failed", "API_URL: http://192.168.1.42:8000"} {
+		assert.Contains(t, out, msg)
+	}
+
+	// Quiet mode only keeps the results, warnings and errors
+	SetQuiet(true)
+	assert.True(t, IsQuiet())
+	out = captureOutput(t, printAll)
+	assert.Equal(t, "[WARNING] careful\n[ERROR] failed\n  API_URL: http://192.168.1.42:8000\n", out)
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// PrintQRCode renders a URL as a QR code in the terminal, preceded by its label
+func PrintQRCode(label, url string) error {
+	qr, err := qrcode.New(url, qrcode.Low)
+	if err != nil {
+		return fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	PrintURL(label, url)
+	fmt.Fprintln(Stdout, qr.ToSmallString(false))
+
+	return nil
+}
+
+// QRCodePNG encodes a URL as a PNG QR code image of the given size in pixels
+func QRCodePNG(url string, size int) ([]byte, error) {
+	png, err := qrcode.Encode(url, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	return png, nil
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiRe matches the color escape codes, which take no room on screen
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Table renders rows as aligned columns, optionally with borders
+// Cells may be colored: the escape codes don't break the alignment
+type Table struct {
+	Headers []string // no header line when empty
+	Rows    [][]string
+	Border  bool   // draw a box around the table and between the columns
+	Indent  string // printed before each line
+}
+
+// NewTable creates a table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row, missing cells are left empty
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Print writes the table to stdout
+func (t *Table) Print() {
+	fmt.Fprint(Stdout, t.String())
+}
+
+// String renders the table, one line per row
+func (t *Table) String() string {
+	rows := t.Rows
+	if len(t.Headers) > 0 {
+		rows = append([][]string{t.Headers}, rows...)
+	}
+
+	// Column widths, in characters shown on screen
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	var b strings.Builder
+	if t.Border {
+		t.renderBordered(&b, rows, widths)
+	} else {
+		t.renderPlain(&b, rows, widths)
+	}
+	return b.String()
+}
+
+// renderPlain separates the columns with two spaces, like tabwriter
+func (t *Table) renderPlain(b *strings.Builder, rows [][]string, widths []int) {
+	for _, row := range rows {
+		b.WriteString(t.Indent)
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+}
+
+// renderBordered draws box lines around the cells, with ASCII characters outside terminals and with the ASCII style
+func (t *Table) renderBordered(b *strings.Builder, rows [][]string, widths []int) {
+	// Characters for the top, middle and bottom lines: left, fill, junction, right
+	top, middle, bottom, vertical := "┌─┬┐", "├─┼┤", "└─┴┘", "│"
+	if !terminal || style == StyleASCII {
+		top, middle, bottom, vertical = "+-++", "+-++", "+-++", "|"
+	}
+
+	line := func(chars string) {
+		c := []rune(chars)
+		b.WriteString(t.Indent)
+		b.WriteRune(c[0])
+		for i, w := range widths {
+			if i > 0 {
+				b.WriteRune(c[2])
+			}
+			b.WriteString(strings.Repeat(string(c[1]), w+2))
+		}
+		b.WriteRune(c[3])
+		b.WriteString("\n")
+	}
+
+	line(top)
+	for r, row := range rows {
+		b.WriteString(t.Indent)
+		b.WriteString(vertical)
+		for i, w := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			b.WriteString(" " + cell + strings.Repeat(" ", w-displayWidth(cell)+1) + vertical)
+		}
+		b.WriteString("\n")
+		if r == 0 && len(t.Headers) > 0 {
+			line(middle)
+		}
+	}
+	line(bottom)
+}
+
+// displayWidth returns the number of characters of s shown on screen
+func displayWidth(s string) int {
+	return utf8.RuneCountInString(ansiRe.ReplaceAllString(s, ""))
+}
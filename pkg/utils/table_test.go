@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_Plain(t *testing.T) {
+	table := NewTable("NAME", "URL", "RESULT")
+	table.AddRow("API_URL", "http://192.168.1.10:3000", "reachable")
+	table.AddRow("DB", "postgres://192.168.1.10:5432")
+
+	expected := "" +
+		"NAME     URL                           RESULT\n" +
+		"API_URL  http://192.168.1.10:3000      reachable\n" +
+		"DB       postgres://192.168.1.10:5432\n"
+	assert.Equal(t, expected, table.String())
+}
+
+func TestTable_ColoredCells(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	table := NewTable("RESULT", "NAME")
+	table.AddRow(color.GreenString("reachable"), "API_URL")
+	table.AddRow("-", "DB")
+
+	lines := table.String()
+	assert.Contains(t, lines, color.GreenString("reachable")+"  API_URL\n")
+	assert.Contains(t, lines, "-          DB\n", "escape codes don't count in the width")
+}
+
+func TestTable_Border(t *testing.T) {
+	table := NewTable("SERVICE", "PORT")
+	table.Border = true
+	table.Indent = "  "
+	table.AddRow("api", "54321")
+	table.AddRow("studio")
+
+	expected := ""
+	if terminal {
+		expected = "" +
+			"  ┌─────────┬───────┐\n" +
+			"  │ SERVICE │ PORT  │\n" +
+			"  ├─────────┼───────┤\n" +
+			"  │ api     │ 54321 │\n" +
+			"  │ studio  │       │\n" +
+			"  └─────────┴───────┘\n"
+	} else {
+		expected = "" +
+			"  +---------+-------+\n" +
+			"  | SERVICE | PORT  |\n" +
+			"  +---------+-------+\n" +
+			"  | api     | 54321 |\n" +
+			"  | studio  |       |\n" +
+			"  +---------+-------+\n"
+	}
+	assert.Equal(t, expected, table.String())
+}
+
+func TestTable_BorderASCIIStyle(t *testing.T) {
+	SetStyle(StyleASCII)
+	defer SetStyle(StyleEmoji)
+
+	table := NewTable("USED")
+	table.Border = true
+	table.AddRow(Symbol("✓", "*"))
+
+	assert.Equal(t, "+------+\n| USED |\n+------+\n| *    |\n+------+\n", table.String())
+}
+
+func TestTable_NoHeaders(t *testing.T) {
+	table := NewTable()
+	table.AddRow("Logs:", "/tmp/lanup.log")
+	table.AddRow("API:", "http://127.0.0.1:4000/v1/state")
+
+	assert.Equal(t, "Logs:  /tmp/lanup.log\nAPI:   http://127.0.0.1:4000/v1/state\n", table.String())
+}